@@ -0,0 +1,112 @@
+package editor
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// ambiguousWidthTable is the ranges of the East Asian Ambiguous characters
+// of Unicode, which take one or two cells by 'ambiwidth'. The Latin Extended
+// and the combining characters are left out, since they are narrow in any
+// font.
+var ambiguousWidthTable = [][2]rune{
+	{0x00a1, 0x00a1}, {0x00a4, 0x00a4}, {0x00a7, 0x00a8}, {0x00aa, 0x00aa},
+	{0x00ad, 0x00ae}, {0x00b0, 0x00b4}, {0x00b6, 0x00ba}, {0x00bc, 0x00bf},
+	{0x00c6, 0x00c6}, {0x00d0, 0x00d0}, {0x00d7, 0x00d8}, {0x00de, 0x00e1},
+	{0x00e6, 0x00e6}, {0x00e8, 0x00ea}, {0x00ec, 0x00ed}, {0x00f0, 0x00f0},
+	{0x00f2, 0x00f3}, {0x00f7, 0x00fa}, {0x00fc, 0x00fc}, {0x00fe, 0x00fe},
+	{0x0391, 0x03a1}, {0x03a3, 0x03a9}, {0x03b1, 0x03c1}, {0x03c3, 0x03c9},
+	{0x0401, 0x0401}, {0x0410, 0x044f}, {0x0451, 0x0451},
+	{0x2010, 0x2010}, {0x2013, 0x2016}, {0x2018, 0x2019}, {0x201c, 0x201d},
+	{0x2020, 0x2022}, {0x2024, 0x2027}, {0x2030, 0x2030}, {0x2032, 0x2033},
+	{0x2035, 0x2035}, {0x203b, 0x203b}, {0x203e, 0x203e}, {0x2074, 0x2074},
+	{0x207f, 0x207f}, {0x2081, 0x2084}, {0x20ac, 0x20ac}, {0x2103, 0x2103},
+	{0x2105, 0x2105}, {0x2109, 0x2109}, {0x2113, 0x2113}, {0x2116, 0x2116},
+	{0x2121, 0x2122}, {0x2126, 0x2126}, {0x212b, 0x212b}, {0x2153, 0x2154},
+	{0x215b, 0x215e}, {0x2160, 0x216b}, {0x2170, 0x2179}, {0x2189, 0x2189},
+	{0x2190, 0x2199}, {0x21b8, 0x21b9}, {0x21d2, 0x21d2}, {0x21d4, 0x21d4},
+	{0x21e7, 0x21e7}, {0x2200, 0x2200}, {0x2202, 0x2203}, {0x2207, 0x2208},
+	{0x220b, 0x220b}, {0x220f, 0x220f}, {0x2211, 0x2211}, {0x2215, 0x2215},
+	{0x221a, 0x221a}, {0x221d, 0x2220}, {0x2223, 0x2223}, {0x2225, 0x2225},
+	{0x2227, 0x222c}, {0x222e, 0x222e}, {0x2234, 0x2237}, {0x223c, 0x223d},
+	{0x2248, 0x2248}, {0x224c, 0x224c}, {0x2252, 0x2252}, {0x2260, 0x2261},
+	{0x2264, 0x2267}, {0x226a, 0x226b}, {0x226e, 0x226f}, {0x2282, 0x2283},
+	{0x2286, 0x2287}, {0x2295, 0x2295}, {0x2299, 0x2299}, {0x22a5, 0x22a5},
+	{0x22bf, 0x22bf}, {0x2312, 0x2312}, {0x2460, 0x24e9}, {0x24eb, 0x254b},
+	{0x2550, 0x2573}, {0x2580, 0x258f}, {0x2592, 0x2595}, {0x25a0, 0x25a1},
+	{0x25a3, 0x25a9}, {0x25b2, 0x25b3}, {0x25b6, 0x25b7}, {0x25bc, 0x25bd},
+	{0x25c0, 0x25c1}, {0x25c6, 0x25c8}, {0x25cb, 0x25cb}, {0x25ce, 0x25d1},
+	{0x25e2, 0x25e5}, {0x25ef, 0x25ef}, {0x2605, 0x2606}, {0x2609, 0x2609},
+	{0x260e, 0x260f}, {0x261c, 0x261c}, {0x261e, 0x261e}, {0x2640, 0x2640},
+	{0x2642, 0x2642}, {0x2660, 0x2661}, {0x2663, 0x2665}, {0x2667, 0x266a},
+	{0x266c, 0x266d}, {0x266f, 0x266f}, {0x269e, 0x269f}, {0x26bf, 0x26bf},
+	{0x26c6, 0x26cd}, {0x26cf, 0x26d3}, {0x26d5, 0x26e1}, {0x26e3, 0x26e3},
+	{0x26e8, 0x26e9}, {0x26eb, 0x26f1}, {0x26f4, 0x26f4}, {0x26f6, 0x26f9},
+	{0x26fb, 0x26fc}, {0x26fe, 0x26ff}, {0x273d, 0x273d}, {0x2776, 0x277f},
+	{0x2b56, 0x2b59}, {0x3248, 0x324f}, {0xe000, 0xf8ff}, {0xfffd, 0xfffd},
+	{0x1f100, 0x1f10a}, {0x1f110, 0x1f12d}, {0x1f130, 0x1f169},
+	{0x1f170, 0x1f18d}, {0x1f18f, 0x1f190}, {0x1f19b, 0x1f1ac},
+	{0xf0000, 0xffffd}, {0x100000, 0x10fffd},
+}
+
+// isAmbiguousWidth returns true if the first rune of the char is of the East
+// Asian Ambiguous width
+func isAmbiguousWidth(char string) bool {
+	r, size := utf8.DecodeRuneInString(char)
+	if size == 0 || r < 0xa1 {
+		return false
+	}
+	i := sort.Search(len(ambiguousWidthTable), func(i int) bool {
+		return ambiguousWidthTable[i][1] >= r
+	})
+
+	return i < len(ambiguousWidthTable) && ambiguousWidthTable[i][0] <= r
+}
+
+// ambiguousNormalWidth returns whether the ambiguous-width char is measured
+// narrow by 'ambiwidth', and false for ok if the option isn't known yet and
+// the char is measured by the font
+func ambiguousNormalWidth(char, ambiwidth string) (normal bool, ok bool) {
+	if ambiwidth == "" || !isAmbiguousWidth(char) {
+		return false, false
+	}
+
+	return ambiwidth != "double", true
+}
+
+// ambiWidthCommands set 'ambiwidth' by the config on startup, so that nvim
+// and the GUI agree on the width
+func ambiWidthCommands(ambiwidth string) string {
+	if ambiwidth == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(`
+	set ambiwidth=%s
+	`, ambiwidth)
+}
+
+// setAmbiwidth is called by the option_set of 'ambiwidth', and measures the
+// ambiguous-width chars in the windows again by it
+func (w *Workspace) setAmbiwidth(val interface{}) {
+	ambiwidth, _ := val.(string)
+	if ambiwidth == w.ambiwidth {
+		return
+	}
+	w.ambiwidth = ambiwidth
+
+	w.screen.purgeTextCacheForWins()
+	w.screen.windows.Range(func(_, winITF interface{}) bool {
+		win := winITF.(*Window)
+		if win == nil {
+			return true
+		}
+		for row := 0; row < win.rows; row++ {
+			win.measureLine(row)
+		}
+		win.update()
+		return true
+	})
+	w.cursor.update()
+}
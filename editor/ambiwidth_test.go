@@ -0,0 +1,55 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestIsAmbiguousWidth(t *testing.T) {
+	tests := []struct {
+		char string
+		want bool
+	}{
+		{"", false},
+		{"a", false},
+		{"¡", true},
+		{"é", true},
+		{"ā", false},
+		{"α", true},
+		{"Ж", true},
+		{"…", true},
+		{"→", true},
+		{"○", true},
+		{"※", true},
+		{"─", true},
+		{"あ", false},
+		{"漢", false},
+		{"", true},
+		{"\U0010fffd", true},
+	}
+	for _, tt := range tests {
+		if got := isAmbiguousWidth(tt.char); got != tt.want {
+			t.Errorf("isAmbiguousWidth(%q) = %v, want %v", tt.char, got, tt.want)
+		}
+	}
+}
+
+func TestAmbiguousNormalWidth(t *testing.T) {
+	tests := []struct {
+		char      string
+		ambiwidth string
+		normal    bool
+		ok        bool
+	}{
+		{"○", "", false, false},
+		{"○", "single", true, true},
+		{"○", "double", false, true},
+		{"あ", "double", false, false},
+		{"a", "single", false, false},
+	}
+	for _, tt := range tests {
+		normal, ok := ambiguousNormalWidth(tt.char, tt.ambiwidth)
+		if normal != tt.normal || ok != tt.ok {
+			t.Errorf("ambiguousNormalWidth(%q, %q) = %v, %v, want %v, %v", tt.char, tt.ambiwidth, normal, ok, tt.normal, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package editor
+
+import (
+	"math"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+)
+
+// atlasPageSize is the width and the height of an atlas page in pixels
+const atlasPageSize = 1024
+
+// glyphAtlas caches the glyphs in a few large images, each glyph in its own
+// rect of a page. The glyphs are cached per cell, so the same glyph is shared
+// by all the runs of text in any position on the screen. A single atlas is
+// shared by all the windows, and the glyphs are namespaced by the font, so
+// the windows with the same custom font share their glyphs too.
+//
+// The memory of the atlas is bounded by the bytes of the page images, which
+// grow with the device pixel ratio, and by the number of the glyphs. When
+// either is reached, the least recently drawn page is evicted and its glyphs
+// are cached again as drawn.
+type glyphAtlas struct {
+	dpr       float64
+	pages     []*atlasPage
+	glyphs    map[glyphKey]atlasGlyph
+	maxGlyphs int
+	maxBytes  int
+	// current is the page the new glyphs are allocated in
+	current int
+	// clock stamps the pages when their glyphs are drawn
+	clock uint64
+}
+
+// glyphFont is the namespace of the glyphs drawn in a font
+type glyphFont struct {
+	family     string
+	size       float64
+	lineHeight int
+	// fontwide is true if the wide glyphs are drawn in 'guifontwide'
+	fontwide bool
+	// subpixel is true if the glyphs are drawn on their background
+	subpixel bool
+}
+
+// glyphKey identifies a glyph in the atlas
+type glyphKey struct {
+	font   glyphFont
+	char   string
+	fg     RGBA
+	bold   bool
+	italic bool
+	wide   bool
+	// bg is the background of the subpixel glyphs
+	bg RGBA
+}
+
+// atlasGlyph is the rect of a glyph in the page
+type atlasGlyph struct {
+	page   int
+	x      int
+	y      int
+	width  int
+	height int
+}
+
+type atlasPage struct {
+	image  *gui.QImage
+	packer atlasPacker
+	used   uint64
+}
+
+// atlasPacker allocates the rects in a page row by row. All the glyphs have
+// the line height, so the rows are filled without gaps.
+type atlasPacker struct {
+	width  int
+	height int
+	x      int
+	y      int
+	shelf  int
+}
+
+// newGlyphAtlas returns the atlas of at most maxGlyphs glyphs in the pages
+// of at most memory MB. maxGlyphs 0 means unlimited.
+func newGlyphAtlas(maxGlyphs, memory int) *glyphAtlas {
+	return &glyphAtlas{
+		glyphs:    make(map[glyphKey]atlasGlyph),
+		maxGlyphs: maxGlyphs,
+		maxBytes:  memory * 1024 * 1024,
+	}
+}
+
+// atlasPageBytes returns the bytes of the image of a page
+func atlasPageBytes(dpr float64) int {
+	size := int(math.Ceil(atlasPageSize * dpr))
+
+	return size * size * 4
+}
+
+// maxPages returns the number of the pages within the memory. At least a
+// page is allowed, even if it is larger than the memory.
+func (a *glyphAtlas) maxPages() int {
+	return maxInt(1, a.maxBytes/atlasPageBytes(a.dpr))
+}
+
+// bytes returns the bytes of the images of the pages
+func (a *glyphAtlas) bytes() int {
+	return len(a.pages) * atlasPageBytes(a.dpr)
+}
+
+// hasRoom returns true if a glyph can be cached without evicting the others
+func (a *glyphAtlas) hasRoom() bool {
+	if a.maxGlyphs > 0 && len(a.glyphs) >= a.maxGlyphs {
+		return false
+	}
+
+	return len(a.pages) < a.maxPages()
+}
+
+// lruPage returns the least recently drawn page
+func (a *glyphAtlas) lruPage() int {
+	lru := 0
+	for i, page := range a.pages {
+		if page.used < a.pages[lru].used {
+			lru = i
+		}
+	}
+
+	return lru
+}
+
+// dropGlyphs removes the glyphs of the page from the atlas
+func (a *glyphAtlas) dropGlyphs(page int) {
+	for key, g := range a.glyphs {
+		if g.page == page {
+			delete(a.glyphs, key)
+		}
+	}
+	a.pages[page].packer = atlasPacker{width: atlasPageSize, height: atlasPageSize}
+}
+
+// evict clears the page to cache the other glyphs in it
+func (a *glyphAtlas) evict(page int) {
+	a.dropGlyphs(page)
+	a.pages[page].image.Fill3(core.Qt__transparent)
+}
+
+func newGlyphKey(font glyphFont, cell *Cell) glyphKey {
+	key := glyphKey{
+		font:   font,
+		char:   cell.char,
+		bold:   cell.highlight.bold,
+		italic: cell.highlight.italic,
+		wide:   !cell.normalWidth,
+	}
+	if isEmoji(cell.char) && !cell.normalWidth {
+		// The color emoji don't depend on the highlight
+		key.bold, key.italic = false, false
+		return key
+	}
+	if fg := cell.highlight.fg(); fg != nil {
+		key.fg = *fg
+	}
+	if font.subpixel {
+		if bg := cell.highlight.bg(); bg != nil {
+			key.bg = *bg
+		}
+	}
+
+	return key
+}
+
+// alloc returns the position of the rect of the size, or false if the page
+// is full
+func (a *atlasPacker) alloc(width, height int) (int, int, bool) {
+	x, y, shelf := a.x, a.y, a.shelf
+	if x+width > a.width {
+		x = 0
+		y += shelf
+		shelf = 0
+	}
+	if x+width > a.width || y+height > a.height {
+		return 0, 0, false
+	}
+	a.x = x + width
+	a.y = y
+	a.shelf = maxInt(shelf, height)
+
+	return x, y, true
+}
+
+// purge destroys the pages, e.g. when the font is changed
+func (a *glyphAtlas) purge() {
+	for _, page := range a.pages {
+		page.image.DestroyQImage()
+	}
+	a.pages = nil
+	a.current = 0
+	a.glyphs = make(map[glyphKey]atlasGlyph)
+}
+
+// drawGlyph draws the glyph of the cell at the position, caching the glyph
+// if it is not in the atlas yet. The glyph of glyphOverflow is clipped to the
+// cell unless overflow.
+func (w *Window) drawGlyph(p *gui.QPainter, a *glyphAtlas, cell *Cell, x, y float64, overflow bool) {
+	if a.dpr != w.devicePixelRatio {
+		a.purge()
+		a.dpr = w.devicePixelRatio
+	}
+
+	key := newGlyphKey(w.glyphFont(), cell)
+	g, ok := a.glyphs[key]
+	if !ok {
+		g, ok = w.cacheGlyph(a, cell, key)
+	}
+	if ok {
+		a.clock++
+		a.pages[g.page].used = a.clock
+	}
+	if !ok {
+		// The glyph which doesn't fit in a page is drawn without the atlas
+		width, height := w.glyphSize(cell)
+		p.Save()
+		if !overflow && isGlyphOverflow(cell.char) {
+			p.SetClipRect(core.NewQRectF4(x, y, w.getFont().italicWidth, float64(height)), core.Qt__IntersectClip)
+		}
+		w.paintGlyph(p, core.NewQRectF4(x, y, float64(width), float64(height)), cell)
+		p.Restore()
+		return
+	}
+
+	width := float64(g.width)
+	if !overflow && isGlyphOverflow(cell.char) {
+		width = math.Min(width, math.Ceil(w.getFont().italicWidth))
+	}
+	p.DrawImage(
+		core.NewQRectF4(x, y, width, float64(g.height)),
+		a.pages[g.page].image,
+		core.NewQRectF4(
+			float64(g.x)*a.dpr,
+			float64(g.y)*a.dpr,
+			width*a.dpr,
+			float64(g.height)*a.dpr,
+		),
+		core.Qt__AutoColor,
+	)
+}
+
+// glyphFont returns the namespace of the glyphs of the window
+func (w *Window) glyphFont() glyphFont {
+	font := w.getFont()
+
+	return glyphFont{
+		family:     font.family,
+		size:       font.size,
+		lineHeight: font.lineHeight,
+		fontwide:   w.font == nil && w.s.ws.fontwide != nil,
+		subpixel:   isSubpixelGlyphs(),
+	}
+}
+
+// cacheGlyph paints the glyph of the cell into the atlas
+func (w *Window) cacheGlyph(a *glyphAtlas, cell *Cell, key glyphKey) (atlasGlyph, bool) {
+	width, height := w.glyphSize(cell)
+	g := atlasGlyph{
+		page:   a.current,
+		width:  width,
+		height: height,
+	}
+
+	var ok bool
+	full := a.maxGlyphs > 0 && len(a.glyphs) >= a.maxGlyphs
+	if !full && g.page < len(a.pages) {
+		g.x, g.y, ok = a.pages[g.page].packer.alloc(width, height)
+	}
+	if !ok && !full && len(a.pages) < a.maxPages() {
+		a.pages = append(a.pages, newAtlasPage(a.dpr))
+		g.page = len(a.pages) - 1
+		g.x, g.y, ok = a.pages[g.page].packer.alloc(width, height)
+	}
+	if !ok && len(a.pages) > 0 {
+		g.page = a.lruPage()
+		a.evict(g.page)
+		g.x, g.y, ok = a.pages[g.page].packer.alloc(width, height)
+	}
+	if !ok {
+		return g, false
+	}
+	a.current = g.page
+
+	pi := gui.NewQPainter2(a.pages[g.page].image)
+	rect := core.NewQRectF4(float64(g.x), float64(g.y), float64(width), float64(height))
+	// The italic glyphs must not overhang into the neighbors in the page
+	pi.SetClipRect(rect, core.Qt__ReplaceClip)
+	if key.font.subpixel {
+		// The background of the cell only, not of the overhang of the
+		// italic glyph into the neighbor
+		cellWidth := w.getFont().truewidth
+		if !cell.normalWidth {
+			cellWidth *= 2
+		}
+		pi.FillRect4(
+			core.NewQRectF4(float64(g.x), float64(g.y), math.Min(cellWidth, float64(width)), float64(height)),
+			key.bg.QColor(),
+		)
+	}
+	w.paintGlyph(pi, rect, cell)
+	pi.DestroyQPainter()
+
+	a.glyphs[key] = g
+
+	return g, true
+}
+
+func newAtlasPage(dpr float64) *atlasPage {
+	// QImage default device pixel ratio is 1.0,
+	// So we set the correct device pixel ratio
+	size := int(math.Ceil(atlasPageSize * dpr))
+	image := gui.NewQImage3(size, size, gui.QImage__Format_ARGB32_Premultiplied)
+	image.SetDevicePixelRatio(dpr)
+	image.Fill3(core.Qt__transparent)
+
+	return &atlasPage{
+		image:  image,
+		packer: atlasPacker{width: atlasPageSize, height: atlasPageSize},
+	}
+}
+
+// glyphSize returns the size of the rect of the glyph of the cell
+func (w *Window) glyphSize(cell *Cell) (int, int) {
+	font := w.getFont()
+	width := font.italicWidth
+	if isEmoji(cell.char) && !cell.normalWidth && font.emojiFont() != nil {
+		width = font.truewidth * 2
+	} else if !cell.normalWidth {
+		width = font.fontMetrics.HorizontalAdvance(cell.char, -1)
+	} else if isGlyphOverflow(cell.char) {
+		// The room for the overflow into the next cell
+		width = math.Max(width, font.truewidth*2)
+	}
+
+	return int(math.Ceil(width)), font.lineHeight
+}
+
+// paintGlyph paints the text of the cell in the rect
+func (w *Window) paintGlyph(p *gui.QPainter, rect *core.QRectF, cell *Cell) {
+	font := w.getFont()
+	if isEmoji(cell.char) && w.drawEmoji(p, cell, rect.X(), rect.Y()) {
+		return
+	}
+	if !cell.normalWidth && w.font == nil && w.s.ws.fontwide != nil {
+		p.SetFont(w.s.ws.fontwide.fontNew)
+	} else {
+		p.SetFont(font.fontNew)
+	}
+	if cell.highlight.bold {
+		setFontBold(p.Font(), true)
+	}
+	if cell.highlight.italic {
+		p.Font().SetItalic(true)
+	}
+	w.fitGlyph(p.Font(), cell.char, font)
+
+	fg := cell.highlight.fg()
+	if fg != nil {
+		p.SetPen2(fg.QColor())
+	}
+	p.DrawText6(rect, cell.char, gui.NewQTextOption2(core.Qt__AlignVCenter))
+}
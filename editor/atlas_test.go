@@ -0,0 +1,100 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestAtlasPackerAlloc(t *testing.T) {
+	packer := atlasPacker{width: 30, height: 40}
+	tests := []struct {
+		width, height int
+		x, y          int
+		ok            bool
+	}{
+		{10, 20, 0, 0, true},
+		{10, 20, 10, 0, true},
+		{10, 20, 20, 0, true},
+		// The next row
+		{20, 20, 0, 20, true},
+		// Doesn't fit in the rest of the row nor in the page
+		{20, 20, 0, 0, false},
+		{10, 20, 20, 20, true},
+		// Larger than the page
+		{40, 10, 0, 0, false},
+	}
+	for i, tt := range tests {
+		x, y, ok := packer.alloc(tt.width, tt.height)
+		if ok != tt.ok || (ok && (x != tt.x || y != tt.y)) {
+			t.Errorf("#%d alloc(%d, %d) = %d, %d, %v, want %d, %d, %v", i, tt.width, tt.height, x, y, ok, tt.x, tt.y, tt.ok)
+		}
+	}
+}
+
+func TestGlyphAtlasMaxPages(t *testing.T) {
+	tests := []struct {
+		memory int
+		dpr    float64
+		want   int
+	}{
+		{64, 1.0, 16},
+		{64, 2.0, 4},
+		// At least a page
+		{1, 2.0, 1},
+		{16, 4.0, 1},
+	}
+	for _, tt := range tests {
+		a := newGlyphAtlas(0, tt.memory)
+		a.dpr = tt.dpr
+		if got := a.maxPages(); got != tt.want {
+			t.Errorf("maxPages() with %dMB at %v = %d, want %d", tt.memory, tt.dpr, got, tt.want)
+		}
+	}
+}
+
+func TestGlyphAtlasEviction(t *testing.T) {
+	a := newGlyphAtlas(0, 64)
+	a.pages = []*atlasPage{{used: 5}, {used: 2}, {used: 9}}
+	a.glyphs[glyphKey{char: "a"}] = atlasGlyph{page: 0}
+	a.glyphs[glyphKey{char: "b"}] = atlasGlyph{page: 1}
+	a.glyphs[glyphKey{char: "c"}] = atlasGlyph{page: 1}
+	a.pages[1].packer = atlasPacker{width: atlasPageSize, height: atlasPageSize, x: 20, shelf: 10}
+
+	page := a.lruPage()
+	if page != 1 {
+		t.Fatalf("lruPage() = %d, want 1", page)
+	}
+	a.dropGlyphs(page)
+	if len(a.glyphs) != 1 {
+		t.Errorf("got %d glyphs, want 1", len(a.glyphs))
+	}
+	if _, ok := a.glyphs[glyphKey{char: "a"}]; !ok {
+		t.Errorf("the glyph of the other page is dropped")
+	}
+	if x, y, ok := a.pages[1].packer.alloc(10, 10); !ok || x != 0 || y != 0 {
+		t.Errorf("alloc() in the evicted page = %d, %d, %v, want 0, 0, true", x, y, ok)
+	}
+}
+
+func TestGlyphAtlasHasRoom(t *testing.T) {
+	a := newGlyphAtlas(2, 8)
+	a.dpr = 1.0
+	if !a.hasRoom() {
+		t.Errorf("hasRoom() of the empty atlas = false")
+	}
+	a.pages = []*atlasPage{{}}
+	if !a.hasRoom() {
+		t.Errorf("hasRoom() with a page to grow = false")
+	}
+	a.pages = append(a.pages, &atlasPage{})
+	if a.hasRoom() {
+		t.Errorf("hasRoom() with all the pages = true")
+	}
+
+	a = newGlyphAtlas(2, 64)
+	a.dpr = 1.0
+	a.glyphs[glyphKey{char: "a"}] = atlasGlyph{}
+	a.glyphs[glyphKey{char: "b"}] = atlasGlyph{}
+	if a.hasRoom() {
+		t.Errorf("hasRoom() with the max glyphs = true")
+	}
+}
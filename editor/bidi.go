@@ -0,0 +1,220 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+)
+
+// The bidi mode of the window of the b:gonvim_bidi of its buffer
+const (
+	bidiDefault = iota
+	bidiOn
+	bidiOff
+)
+
+// bidiAutoCmds notify the b:gonvim_bidi of the buffer of the window, -1 if
+// it isn't set, so that the shaping is switched per buffer
+const bidiAutoCmds = `
+	aug GonvimAuBidi | au! | aug END
+	au GonvimAuBidi BufWinEnter,WinEnter * call rpcnotify(0, "Gui", "gonvim_bidi", win_getid(), get(b:, "gonvim_bidi", -1))
+	`
+
+// bidiCommands toggle the shaping of the current buffer, which is enabled
+// by default if enabled
+func bidiCommands(enabled bool) string {
+	def := 0
+	if enabled {
+		def = 1
+	}
+
+	return fmt.Sprintf(`
+	command! GonvimBidi let b:gonvim_bidi = !get(b:, "gonvim_bidi", %d) | call rpcnotify(0, "Gui", "gonvim_bidi", win_getid(), b:gonvim_bidi)
+	`, def)
+}
+
+// updateBidi is called by the gonvim_bidi notification.
+// args: [winid, b:gonvim_bidi or -1]
+func (w *Workspace) updateBidi(args []interface{}) {
+	if len(args) < 2 {
+		return
+	}
+	id := util.ReflectToInt(args[0])
+	mode := bidiDefault
+	switch util.ReflectToInt(args[1]) {
+	case 0:
+		mode = bidiOff
+	case 1:
+		mode = bidiOn
+	}
+
+	w.screen.windows.Range(func(_, winITF interface{}) bool {
+		win := winITF.(*Window)
+		if win == nil || int(win.id) != id {
+			return true
+		}
+		if win.bidi != mode {
+			win.bidi = mode
+			win.update()
+		}
+		return false
+	})
+}
+
+// isBidiEnabled returns true if the RTL runs of the window are shaped, by
+// the buffer or the config
+func (w *Window) isBidiEnabled() bool {
+	switch w.bidi {
+	case bidiOn:
+		return true
+	case bidiOff:
+		return false
+	}
+
+	return editor.config.Editor.Bidi
+}
+
+// isRTL returns true if the char is a strong right-to-left letter, e.g. of
+// Hebrew or Arabic
+func isRTL(char string) bool {
+	r, _ := utf8.DecodeRuneInString(char)
+
+	return unicode.In(r, unicode.Hebrew, unicode.Arabic, unicode.Syriac, unicode.Thaana, unicode.Nko)
+}
+
+// isBidiNeutral returns true if the char takes the direction of the text
+// around it, e.g. the spaces, the punctuations and the digits
+func isBidiNeutral(char string) bool {
+	if char == "" {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(char)
+
+	return unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsDigit(r) || unicode.IsSymbol(r)
+}
+
+// rtlRuns returns the first and the last columns of the runs of the RTL text
+// in the line. A run is the cells of the same highlight from an RTL letter to
+// the last RTL letter before a left-to-right one, with the neutral chars
+// between them, e.g. the spaces between the words. The runs are found in the
+// whole line, as the text is shaped and reordered by the run.
+func rtlRuns(line []*Cell) [][2]int {
+	var runs [][2]int
+	start, last := -1, -1
+	closeRun := func() {
+		if start >= 0 {
+			runs = append(runs, [2]int{start, last})
+		}
+		start, last = -1, -1
+	}
+	for x := 0; x < len(line); x++ {
+		cell := line[x]
+		if cell == nil {
+			closeRun()
+			continue
+		}
+		if start >= 0 && cell.highlight != line[start].highlight {
+			closeRun()
+		}
+		switch {
+		case isRTL(cell.char):
+			if start < 0 {
+				start = x
+			}
+			last = x
+		case isBidiNeutral(cell.char):
+		default:
+			closeRun()
+		}
+	}
+	closeRun()
+
+	return runs
+}
+
+// overlappingRuns returns the runs which overlap the columns from col to last
+func overlappingRuns(runs [][2]int, col, last int) [][2]int {
+	var overlap [][2]int
+	for _, run := range runs {
+		if run[1] >= col && run[0] <= last {
+			overlap = append(overlap, run)
+		}
+	}
+
+	return overlap
+}
+
+// rtlMask returns the columns of the line in the runs
+func rtlMask(runs [][2]int, length int) []bool {
+	if len(runs) == 0 {
+		return nil
+	}
+	mask := make([]bool, length)
+	for _, run := range runs {
+		for x := run[0]; x <= run[1] && x < length; x++ {
+			mask[x] = true
+		}
+	}
+
+	return mask
+}
+
+// drawRTLRuns draws the runs shaped and reordered by Qt in the cells they
+// take, while the cells keep the logical order of nvim for the cursor. The
+// shaped text is aligned to the right of the cells, and squeezed into them
+// if it is wider. The whole runs are shaped, and clipped to the cells from
+// col to col+cols-1 which are repainted.
+func (w *Window) drawRTLRuns(p *gui.QPainter, y, col, cols int, runs [][2]int) {
+	line := w.content[y]
+	wsfont := w.getFont()
+	baseline := float64(y*wsfont.lineHeight + wsfont.shift + w.scrollDust[1])
+	p.Save()
+	defer p.Restore()
+	p.SetClipRect(
+		core.NewQRectF4(
+			float64(col)*wsfont.truewidth,
+			float64(y*wsfont.lineHeight+w.scrollDust[1]),
+			float64(cols)*wsfont.truewidth,
+			float64(wsfont.lineHeight),
+		),
+		core.Qt__IntersectClip,
+	)
+	p.SetFont(wsfont.fontNew)
+	font := p.Font()
+
+	for _, run := range runs {
+		var b strings.Builder
+		for x := run[0]; x <= run[1]; x++ {
+			if line[x] != nil {
+				b.WriteString(line[x].char)
+			}
+		}
+		text := b.String()
+		highlight := line[run[0]].highlight
+		fg := highlight.fg()
+		if fg != nil {
+			p.SetPen2(fg.QColor())
+		}
+		setFontBold(font, highlight.bold)
+		font.SetItalic(highlight.italic)
+		p.SetFont(font)
+
+		left := float64(run[0]) * wsfont.truewidth
+		span := float64(run[1]-run[0]+1) * wsfont.truewidth
+		width := gui.NewQFontMetricsF(font).HorizontalAdvance(text, -1)
+		if width <= span {
+			p.DrawText(core.NewQPointF3(left+span-width, baseline), text)
+			continue
+		}
+		p.Save()
+		p.Translate3(left, baseline)
+		p.Scale(span/width, 1)
+		p.DrawText(core.NewQPointF3(0, 0), text)
+		p.Restore()
+	}
+}
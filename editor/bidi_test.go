@@ -0,0 +1,85 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func bidiLine(text string, hl map[int]Highlight) []*Cell {
+	var line []*Cell
+	for _, c := range text {
+		line = append(line, &Cell{normalWidth: true, char: string(c), highlight: hl[len(line)]})
+	}
+
+	return line
+}
+
+func TestIsRTL(t *testing.T) {
+	tests := []struct {
+		char string
+		want bool
+	}{
+		{"a", false},
+		{" ", false},
+		{"", false},
+		{"1", false},
+		{"ש", true},
+		{"م", true},
+		{"ﻻ", true},
+		{"あ", false},
+	}
+	for _, tt := range tests {
+		if got := isRTL(tt.char); got != tt.want {
+			t.Errorf("isRTL(%q) = %v, want %v", tt.char, got, tt.want)
+		}
+	}
+}
+
+func TestRTLRuns(t *testing.T) {
+	tests := []struct {
+		name string
+		line []*Cell
+		want [][2]int
+	}{
+		{"ltr", bidiLine("hello world", nil), nil},
+		{"words", bidiLine("a مرحبا بالعالم b", nil), [][2]int{{2, 14}}},
+		{"trailing neutrals", bidiLine("שלום, 12 a", nil), [][2]int{{0, 3}}},
+		{"two runs", bidiLine("אב x גד", nil), [][2]int{{0, 1}, {5, 6}}},
+		{"highlight", bidiLine("אבגד", map[int]Highlight{2: {bold: true}, 3: {bold: true}}), [][2]int{{0, 1}, {2, 3}}},
+	}
+	for _, tt := range tests {
+		if got := rtlRuns(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: rtlRuns() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOverlappingRuns(t *testing.T) {
+	runs := [][2]int{{0, 1}, {5, 9}, {12, 12}}
+	tests := []struct {
+		col, last int
+		want      [][2]int
+	}{
+		// The run is kept whole, not cut at the range
+		{6, 7, [][2]int{{5, 9}}},
+		{1, 5, [][2]int{{0, 1}, {5, 9}}},
+		{2, 4, nil},
+		{0, 20, runs},
+	}
+	for _, tt := range tests {
+		if got := overlappingRuns(runs, tt.col, tt.last); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("overlappingRuns(%v, %d, %d) = %v, want %v", runs, tt.col, tt.last, got, tt.want)
+		}
+	}
+}
+
+func TestRTLMask(t *testing.T) {
+	if got := rtlMask(nil, 4); got != nil {
+		t.Errorf("rtlMask(nil) = %v, want nil", got)
+	}
+	got := rtlMask([][2]int{{1, 2}, {4, 6}}, 6)
+	want := []bool{false, true, true, false, true, true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rtlMask() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,21 @@
+package editor
+
+// blendAlpha returns the alpha of the background in the blend highlight
+// attribute, which is the transparency in percent
+func blendAlpha(alpha, blend int) int {
+	blend = maxInt(0, minInt(blend, 100))
+
+	return alpha * (100 - blend) / 100
+}
+
+// setBlended makes the background of the floating window translucent, so
+// that the grid under it shows through the blended cells. The window was
+// filled opaque in this paint, so it is painted again.
+func (w *Window) setBlended() {
+	if w.blended {
+		return
+	}
+	w.blended = true
+	w.widget.SetAutoFillBackground(false)
+	w.widget.Update()
+}
@@ -0,0 +1,25 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestBlendAlpha(t *testing.T) {
+	tests := []struct {
+		alpha, blend int
+		want         int
+	}{
+		{255, 0, 255},
+		{255, 100, 0},
+		{255, 20, 204},
+		{200, 50, 100},
+		// Out of the range
+		{255, 120, 0},
+		{255, -10, 255},
+	}
+	for _, tt := range tests {
+		if got := blendAlpha(tt.alpha, tt.blend); got != tt.want {
+			t.Errorf("blendAlpha(%d, %d) = %d, want %d", tt.alpha, tt.blend, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package editor
+
+import (
+	"github.com/akiyosi/goneovim/util"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+)
+
+// blockVisualCommands notify the anchor of the blockwise visual selection of
+// the current window in the cells of the window, which the GUI spans to its
+// own cursor. The columns are the virtual columns, so the selection extends
+// beyond the end of the shorter lines and into the 'virtualedit' columns.
+// The background of Visual is notified too, so that the GUI doesn't request
+// it on each notification.
+const blockVisualCommands = `
+	function! GonvimBlockVisual() abort
+		if mode() !=# nr2char(22)
+			call rpcnotify(0, "Gui", "gonvim_block_visual", win_getid(), 0)
+			return
+		endif
+		let l:info = getwininfo(win_getid())[0]
+		let l:v = getpos("v")
+		let l:pos = screenpos(win_getid(), l:v[1], 1)
+		if l:pos.row == 0
+			let l:row = l:v[1] < l:info.topline ? -1 : l:info.height
+		else
+			let l:row = l:pos.row - l:info.winrow
+		endif
+		let l:first = (l:v[2] > 1 ? virtcol([l:v[1], l:v[2] - 1]) + 1 : 1) + l:v[3]
+		let l:last = max([l:first, virtcol([l:v[1], l:v[2], l:v[3]])])
+		let l:offset = l:info.textoff - 1 - winsaveview().leftcol
+		call rpcnotify(0, "Gui", "gonvim_block_visual", win_getid(), 1, l:row, l:first + l:offset, l:last + l:offset, l:info.textoff, getcurpos()[4] == get(v:, "maxcol", 2147483647), synIDattr(synIDtrans(hlID("Visual")), "bg#"))
+	endfunction
+	aug GonvimAuBlockVisual | au! | aug END
+	if exists("##ModeChanged")
+	au GonvimAuBlockVisual ModeChanged * call GonvimBlockVisual()
+	au GonvimAuBlockVisual CursorMoved,WinScrolled * if mode() ==# nr2char(22) | call GonvimBlockVisual() | endif
+	endif
+	`
+
+// blockVisualFillAlpha is the alpha of the fill of the selection rectangle
+const blockVisualFillAlpha = 0.25
+
+// blockVisual is the anchor of the blockwise visual selection in the cells
+// of the window. The other corner is the cursor.
+type blockVisual struct {
+	row     int
+	left    int
+	right   int
+	textoff int
+	// dollar is true if the selection extends to the end of the lines
+	dollar bool
+
+	color *RGBA
+}
+
+// updateBlockVisual is called by the gonvim_block_visual notification.
+// args: [winid, whether the mode is blockwise visual, row, left, right,
+// textoff, dollar, the background of Visual]
+func (s *Screen) updateBlockVisual(args []interface{}) {
+	if len(args) < 2 {
+		return
+	}
+	id := util.ReflectToInt(args[0])
+
+	var block *blockVisual
+	if util.ReflectToInt(args[1]) != 0 && len(args) >= 7 {
+		block = &blockVisual{
+			row:     util.ReflectToInt(args[2]),
+			left:    util.ReflectToInt(args[3]),
+			right:   util.ReflectToInt(args[4]),
+			textoff: util.ReflectToInt(args[5]),
+			dollar:  util.ReflectToInt(args[6]) != 0,
+		}
+		if len(args) >= 8 {
+			if bg, ok := args[7].(string); ok && bg != "" {
+				block.color = hexToRGBA(bg)
+			}
+		}
+	}
+
+	s.windows.Range(func(_, winITF interface{}) bool {
+		win := winITF.(*Window)
+		if win == nil {
+			return true
+		}
+		if int(win.id) == id && block != nil {
+			win.blockVisual = block
+			win.update()
+		} else if win.blockVisual != nil {
+			win.blockVisual = nil
+			win.update()
+		}
+		return true
+	})
+}
+
+// drawBlockVisual draws the blockwise visual selection as a rectangle with a
+// crisp outline over the text
+func (w *Window) drawBlockVisual(p *gui.QPainter) {
+	b := w.blockVisual
+	if b == nil || w.isMsgGrid || w.s.ws.cursor.gridid != w.grid {
+		return
+	}
+	// The other corner is the cursor of the grid, over the width of its cell
+	row, col := w.s.cursor[0], w.s.cursor[1]
+	cursor := [3]int{row, col, col}
+	if row >= 0 && row < len(w.content) {
+		cursor[2] = col + cellColumns(w.content[row], col) - 1
+	}
+	top, bottom, left, right, ok := blockVisualRect(b, cursor, w.lenLine, w.rows, w.cols)
+	if !ok {
+		return
+	}
+
+	color := b.color
+	if color == nil {
+		color = editor.colors.selectedBg
+	}
+	if color == nil {
+		return
+	}
+
+	font := w.getFont()
+	x := float64(left) * font.truewidth
+	y := float64(top * font.lineHeight)
+	width := float64(right-left+1) * font.truewidth
+	height := float64((bottom - top + 1) * font.lineHeight)
+
+	p.FillRect4(
+		core.NewQRectF4(x, y, width, height),
+		newRGBA(color.R, color.G, color.B, blockVisualFillAlpha).QColor(),
+	)
+
+	// Align the 1px outline on the pixels
+	outline := warpColor(color, -40)
+	p.Save()
+	p.SetPen2(outline.QColor())
+	p.DrawRect(core.NewQRectF4(x+0.5, y+0.5, width-1, height-1))
+	p.Restore()
+}
+
+// blockVisualRect returns the rows and the columns of the selection from the
+// anchor to the cursor, [row, left, right], clipped to the text area of the
+// window. With "$" the right edge is the end of the longest line in the
+// selection.
+func blockVisualRect(b *blockVisual, cursor [3]int, lenLine []int, rows, cols int) (int, int, int, int, bool) {
+	top := maxInt(minInt(b.row, cursor[0]), 0)
+	bottom := minInt(maxInt(b.row, cursor[0]), rows-1)
+	left := maxInt(minInt(b.left, cursor[1]), b.textoff)
+	right := maxInt(b.right, cursor[2])
+	if b.dollar {
+		for y := top; y <= bottom && y < len(lenLine); y++ {
+			right = maxInt(right, lenLine[y]-1)
+		}
+	}
+	right = minInt(right, cols-1)
+	if top > bottom || left > right {
+		return 0, 0, 0, 0, false
+	}
+
+	return top, bottom, left, right, true
+}
@@ -0,0 +1,42 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBlockVisualRect(t *testing.T) {
+	lenLine := []int{10, 3, 25, 0, 8}
+	tests := []struct {
+		name                     string
+		block                    blockVisual
+		cursor                   [3]int
+		top, bottom, left, right int
+		ok                       bool
+	}{
+		{"inside", blockVisual{row: 1, left: 4, right: 4}, [3]int{3, 6, 6}, 1, 3, 4, 6, true},
+		// The cursor is the top left corner
+		{"cursor before the anchor", blockVisual{row: 3, left: 6, right: 6}, [3]int{1, 4, 4}, 1, 3, 4, 6, true},
+		// The wide char spans two cells
+		{"wide char", blockVisual{row: 0, left: 2, right: 3}, [3]int{1, 6, 7}, 0, 1, 2, 7, true},
+		// The virtual columns beyond the end of the shorter lines
+		{"beyond EOL", blockVisual{row: 0, left: 12, right: 12}, [3]int{4, 18, 18}, 0, 4, 12, 18, true},
+		{"dollar", blockVisual{row: 0, left: 2, right: 2, dollar: true}, [3]int{1, 2, 2}, 0, 1, 2, 9, true},
+		{"dollar clipped", blockVisual{row: 0, left: 2, right: 2, dollar: true}, [3]int{4, 2, 2}, 0, 4, 2, 19, true},
+		{"clipped rows", blockVisual{row: -1, left: 1, right: 1}, [3]int{9, 2, 2}, 0, 4, 1, 2, true},
+		{"under the number column", blockVisual{row: 0, left: 1, right: 1, textoff: 4}, [3]int{1, 6, 6}, 0, 1, 4, 6, true},
+		{"scrolled out left", blockVisual{row: 0, left: 0, right: 0, textoff: 4}, [3]int{1, 3, 3}, 0, 0, 0, 0, false},
+		{"scrolled out right", blockVisual{row: 0, left: 22, right: 22}, [3]int{1, 30, 30}, 0, 0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		top, bottom, left, right, ok := blockVisualRect(&tt.block, tt.cursor, lenLine, 5, 20)
+		if top != tt.top || bottom != tt.bottom || left != tt.left || right != tt.right || ok != tt.ok {
+			t.Errorf("%s: blockVisualRect() = %d, %d, %d, %d, %v, want %d, %d, %d, %d, %v", tt.name, top, bottom, left, right, ok, tt.top, tt.bottom, tt.left, tt.right, tt.ok)
+		}
+	}
+
+	// The commands are executed line by line in single quotes
+	if strings.Contains(blockVisualCommands, "'") {
+		t.Errorf("the commands have the single quotes: %s", blockVisualCommands)
+	}
+}
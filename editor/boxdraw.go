@@ -0,0 +1,387 @@
+package editor
+
+import (
+	"math"
+	"unicode/utf8"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+)
+
+// The box drawing characters (U+2500-U+257F) and the block elements
+// (U+2580-U+259F) are drawn with the primitives sized exactly to the cell,
+// instead of the glyphs of the font, which often misalign with the cells or
+// leave the gaps between them, e.g. in the borders of the floating windows.
+
+const (
+	boxNone   = 0
+	boxLight  = 1
+	boxHeavy  = 2
+	boxDouble = 3
+)
+
+// The arms of the box drawing characters
+const (
+	boxUp = iota
+	boxRight
+	boxDown
+	boxLeft
+)
+
+// boxArms is the weights of the arms, up, right, down and left, of the box
+// drawing characters. The blanks are the arcs and the diagonals.
+var boxArms = [128]string{
+	// U+2500
+	"0101", "0202", "1010", "2020", "0101", "0202", "1010", "2020",
+	// U+2508
+	"0101", "0202", "1010", "2020", "0110", "0210", "0120", "0220",
+	// U+2510
+	"0011", "0012", "0021", "0022", "1100", "1200", "2100", "2200",
+	// U+2518
+	"1001", "1002", "2001", "2002", "1110", "1210", "2110", "1120",
+	// U+2520
+	"2120", "2210", "1220", "2220", "1011", "1012", "2011", "1021",
+	// U+2528
+	"2021", "2012", "1022", "2022", "0111", "0112", "0211", "0212",
+	// U+2530
+	"0121", "0122", "0221", "0222", "1101", "1102", "1201", "1202",
+	// U+2538
+	"2101", "2102", "2201", "2202", "1111", "1112", "1211", "1212",
+	// U+2540
+	"2111", "1121", "2121", "2112", "2211", "1122", "1221", "2212",
+	// U+2548
+	"1222", "2122", "2221", "2222", "0101", "0202", "1010", "2020",
+	// U+2550
+	"0303", "3030", "0310", "0130", "0330", "0013", "0031", "0033",
+	// U+2558
+	"1300", "3100", "3300", "1003", "3001", "3003", "1310", "3130",
+	// U+2560
+	"3330", "1013", "3031", "3033", "0313", "0131", "0333", "1303",
+	// U+2568
+	"3101", "3303", "1313", "3131", "3333", "", "", "",
+	// U+2570
+	"", "", "", "", "0001", "1000", "0100", "0010",
+	// U+2578
+	"0002", "2000", "0200", "0020", "0201", "1020", "0102", "2010",
+}
+
+// boxDashes is the number of the dashes of the dashed lines
+var boxDashes = map[rune]int{
+	0x2504: 3, 0x2505: 3, 0x2506: 3, 0x2507: 3,
+	0x2508: 4, 0x2509: 4, 0x250A: 4, 0x250B: 4,
+	0x254C: 2, 0x254D: 2, 0x254E: 2, 0x254F: 2,
+}
+
+// boxRect is a rect in the cell, or in the fractions of the cell for the
+// block elements
+type boxRect struct {
+	x, y, w, h float64
+}
+
+// blockElement is the rects of the block element in the eighths of the cell,
+// and the alpha of the shades
+type blockElement struct {
+	rects []boxRect
+	alpha float64
+}
+
+var blockElements = map[rune]blockElement{
+	0x2580: {[]boxRect{{0, 0, 8, 4}}, 1},
+	0x2581: {[]boxRect{{0, 7, 8, 1}}, 1},
+	0x2582: {[]boxRect{{0, 6, 8, 2}}, 1},
+	0x2583: {[]boxRect{{0, 5, 8, 3}}, 1},
+	0x2584: {[]boxRect{{0, 4, 8, 4}}, 1},
+	0x2585: {[]boxRect{{0, 3, 8, 5}}, 1},
+	0x2586: {[]boxRect{{0, 2, 8, 6}}, 1},
+	0x2587: {[]boxRect{{0, 1, 8, 7}}, 1},
+	0x2588: {[]boxRect{{0, 0, 8, 8}}, 1},
+	0x2589: {[]boxRect{{0, 0, 7, 8}}, 1},
+	0x258A: {[]boxRect{{0, 0, 6, 8}}, 1},
+	0x258B: {[]boxRect{{0, 0, 5, 8}}, 1},
+	0x258C: {[]boxRect{{0, 0, 4, 8}}, 1},
+	0x258D: {[]boxRect{{0, 0, 3, 8}}, 1},
+	0x258E: {[]boxRect{{0, 0, 2, 8}}, 1},
+	0x258F: {[]boxRect{{0, 0, 1, 8}}, 1},
+	0x2590: {[]boxRect{{4, 0, 4, 8}}, 1},
+	0x2591: {[]boxRect{{0, 0, 8, 8}}, 0.25},
+	0x2592: {[]boxRect{{0, 0, 8, 8}}, 0.5},
+	0x2593: {[]boxRect{{0, 0, 8, 8}}, 0.75},
+	0x2594: {[]boxRect{{0, 0, 8, 1}}, 1},
+	0x2595: {[]boxRect{{7, 0, 1, 8}}, 1},
+	0x2596: {[]boxRect{{0, 4, 4, 4}}, 1},
+	0x2597: {[]boxRect{{4, 4, 4, 4}}, 1},
+	0x2598: {[]boxRect{{0, 0, 4, 4}}, 1},
+	0x2599: {[]boxRect{{0, 0, 4, 8}, {4, 4, 4, 4}}, 1},
+	0x259A: {[]boxRect{{0, 0, 4, 4}, {4, 4, 4, 4}}, 1},
+	0x259B: {[]boxRect{{0, 0, 8, 4}, {0, 4, 4, 4}}, 1},
+	0x259C: {[]boxRect{{0, 0, 8, 4}, {4, 4, 4, 4}}, 1},
+	0x259D: {[]boxRect{{4, 0, 4, 4}}, 1},
+	0x259E: {[]boxRect{{4, 0, 4, 4}, {0, 4, 4, 4}}, 1},
+	0x259F: {[]boxRect{{4, 0, 4, 8}, {0, 4, 4, 4}}, 1},
+}
+
+// boxRune returns the rune of the char if it is drawn by drawBoxChar
+func boxRune(char string) (rune, bool) {
+	if len(char) != 3 {
+		return 0, false
+	}
+	r, _ := utf8.DecodeRuneInString(char)
+
+	return r, r >= 0x2500 && r <= 0x259F
+}
+
+// parseBoxArms returns the weights of the arms of the box drawing character
+func parseBoxArms(r rune) ([4]int, bool) {
+	var arms [4]int
+	if r < 0x2500 || r > 0x257F {
+		return arms, false
+	}
+	s := boxArms[r-0x2500]
+	if s == "" {
+		return arms, false
+	}
+	for i := range arms {
+		arms[i] = int(s[i] - '0')
+	}
+
+	return arms, true
+}
+
+// boxThickness returns the thickness of the light lines in the cell
+func boxThickness(width float64) float64 {
+	return math.Max(1, math.Round(width/8))
+}
+
+// boxLineRects returns the rects of the lines of the arms in the cell of the
+// size. The lines of the double arms stop at the lines of the perpendicular
+// arms, so that the corners and the junctions are drawn as in the fonts.
+func boxLineRects(arms [4]int, width, height float64) []boxRect {
+	light := boxThickness(width)
+	heavy := light * 2
+	// The distance of the lines of the double arms from the center
+	gap := light
+	cx := math.Floor(width / 2)
+	cy := math.Floor(height / 2)
+
+	thickness := func(weight int) float64 {
+		if weight == boxHeavy {
+			return heavy
+		}
+		return light
+	}
+	perpendicular := func(dir int) (int, int) {
+		if dir == boxUp || dir == boxDown {
+			return boxLeft, boxRight
+		}
+		return boxUp, boxDown
+	}
+	opposite := func(dir int) int {
+		return (dir + 2) % 4
+	}
+
+	var rects []boxRect
+	// line adds the line of the arm from the distance start from the center
+	// to the edge of the cell, shifted by across
+	line := func(dir int, start, across, t float64) {
+		x := cx + across - t/2
+		y := cy + across - t/2
+		switch dir {
+		case boxUp:
+			rects = append(rects, boxRect{x, 0, t, cy - start})
+		case boxDown:
+			rects = append(rects, boxRect{x, cy + start, t, height - cy - start})
+		case boxLeft:
+			rects = append(rects, boxRect{0, y, cx - start, t})
+		case boxRight:
+			rects = append(rects, boxRect{cx + start, y, width - cx - start, t})
+		}
+	}
+
+	for dir, weight := range arms {
+		if weight == boxNone {
+			continue
+		}
+		before, after := perpendicular(dir)
+		opp := arms[opposite(dir)]
+		if weight != boxDouble {
+			t := thickness(weight)
+			start := 0.0
+			switch {
+			case arms[before] == boxDouble && arms[after] == boxDouble && opp == boxNone:
+				// Stop at the near line of the perpendicular double arms
+				start = gap
+			case arms[before] == boxDouble || arms[after] == boxDouble:
+				if opp == boxNone {
+					// Turn at the far line of the double arm
+					start = -gap - light/2
+				} else {
+					start = -t / 2
+				}
+			default:
+				// Cover the perpendicular lines to square the junction
+				for _, p := range []int{before, after} {
+					if arms[p] != boxNone {
+						start = math.Min(start, -thickness(arms[p])/2)
+					}
+				}
+			}
+			line(dir, start, 0, t)
+			continue
+		}
+
+		for _, side := range []struct {
+			perp  int
+			other int
+			sign  float64
+		}{
+			{before, after, -1},
+			{after, before, 1},
+		} {
+			start := -light / 2
+			switch {
+			case arms[side.perp] == boxDouble:
+				// The inner line stops at the perpendicular double arm
+				start = gap - light/2
+			case arms[side.perp] != boxNone || opp != boxNone:
+				start = -light / 2
+			case arms[side.other] == boxDouble:
+				// The outer line of the corner
+				start = -gap - light/2
+			}
+			line(dir, start, side.sign*gap, light)
+		}
+	}
+
+	return rects
+}
+
+// dashRects divides the rects of the line into the dashes
+func dashRects(rects []boxRect, dashes int, vertical bool) []boxRect {
+	var result []boxRect
+	for _, r := range rects {
+		length := r.w
+		if vertical {
+			length = r.h
+		}
+		segment := length / float64(dashes)
+		dash := segment * 0.6
+		for i := 0; i < dashes; i++ {
+			offset := float64(i)*segment + (segment-dash)/2
+			if vertical {
+				result = append(result, boxRect{r.x, r.y + offset, r.w, dash})
+			} else {
+				result = append(result, boxRect{r.x + offset, r.y, dash, r.h})
+			}
+		}
+	}
+
+	return result
+}
+
+// drawBoxChar draws the box drawing character or the block element of the
+// cell at the position, and returns false for the other chars
+func (w *Window) drawBoxChar(p *gui.QPainter, cell *Cell, x, y float64) bool {
+	if !editor.config.Editor.BuiltinBoxDrawing {
+		return false
+	}
+	r, ok := boxRune(cell.char)
+	if !ok {
+		return false
+	}
+	fg := cell.highlight.fg()
+	if fg == nil {
+		return false
+	}
+	font := w.getFont()
+	width := font.truewidth
+	if !cell.normalWidth {
+		width *= 2
+	}
+	height := float64(font.lineHeight)
+	color := fg.QColor()
+
+	if block, ok := blockElements[r]; ok {
+		color.SetAlphaF(block.alpha)
+		for _, b := range block.rects {
+			p.FillRect4(core.NewQRectF4(
+				x+math.Round(width*b.x/8),
+				y+math.Round(height*b.y/8),
+				math.Round(width*(b.x+b.w)/8)-math.Round(width*b.x/8),
+				math.Round(height*(b.y+b.h)/8)-math.Round(height*b.y/8),
+			), color)
+		}
+		return true
+	}
+
+	arms, ok := parseBoxArms(r)
+	if !ok {
+		w.drawBoxCurve(p, r, color, x, y, width, height)
+		return true
+	}
+	rects := boxLineRects(arms, width, height)
+	if dashes, ok := boxDashes[r]; ok {
+		rects = dashRects(rects, dashes, arms[boxUp] != boxNone)
+	}
+	for _, b := range rects {
+		p.FillRect4(core.NewQRectF4(x+b.x, y+b.y, b.w, b.h), color)
+	}
+
+	return true
+}
+
+// drawBoxCurve draws the arcs and the diagonals, which are antialiased
+func (w *Window) drawBoxCurve(p *gui.QPainter, r rune, color *gui.QColor, x, y, width, height float64) {
+	light := boxThickness(width)
+	cx := x + math.Floor(width/2)
+	cy := y + math.Floor(height/2)
+	radius := math.Min(width, height) / 2
+
+	path := gui.NewQPainterPath()
+	switch r {
+	case 0x256D, 0x256E, 0x256F, 0x2570:
+		// The arcs from the vertical edge to the horizontal edge
+		dx, dy := 1.0, 1.0
+		switch r {
+		case 0x256E:
+			dx = -1
+		case 0x256F:
+			dx, dy = -1, -1
+		case 0x2570:
+			dy = -1
+		}
+		edgeY := y + height
+		if dy < 0 {
+			edgeY = y
+		}
+		edgeX := x + width
+		if dx < 0 {
+			edgeX = x
+		}
+		path.MoveTo2(cx, edgeY)
+		path.LineTo2(cx, cy+dy*radius)
+		path.QuadTo2(cx, cy, cx+dx*radius, cy)
+		path.LineTo2(edgeX, cy)
+	case 0x2571:
+		path.MoveTo2(x+width, y)
+		path.LineTo2(x, y+height)
+	case 0x2572:
+		path.MoveTo2(x, y)
+		path.LineTo2(x+width, y+height)
+	case 0x2573:
+		path.MoveTo2(x+width, y)
+		path.LineTo2(x, y+height)
+		path.MoveTo2(x, y)
+		path.LineTo2(x+width, y+height)
+	default:
+		return
+	}
+
+	pen := gui.NewQPen3(color)
+	pen.SetWidthF(light)
+	pen.SetCapStyle(core.Qt__FlatCap)
+	p.Save()
+	p.SetRenderHint(gui.QPainter__Antialiasing, true)
+	p.SetClipRect(core.NewQRectF4(x, y, width, height), core.Qt__IntersectClip)
+	p.StrokePath(path, pen)
+	p.Restore()
+}
@@ -0,0 +1,104 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestBoxRune(t *testing.T) {
+	tests := []struct {
+		char string
+		ok   bool
+	}{
+		{"─", true},
+		{"╭", true},
+		{"█", true},
+		{"▟", true},
+		{"■", false},
+		{"a", false},
+		{"あ", false},
+	}
+	for _, tt := range tests {
+		if _, ok := boxRune(tt.char); ok != tt.ok {
+			t.Errorf("boxRune(%q) = %v, want %v", tt.char, ok, tt.ok)
+		}
+	}
+}
+
+func TestParseBoxArms(t *testing.T) {
+	tests := []struct {
+		r    rune
+		arms [4]int
+		ok   bool
+	}{
+		{'─', [4]int{0, 1, 0, 1}, true},
+		{'┏', [4]int{0, 2, 2, 0}, true},
+		{'┾', [4]int{1, 2, 1, 1}, true},
+		{'╣', [4]int{3, 0, 3, 3}, true},
+		{'╼', [4]int{0, 2, 0, 1}, true},
+		// The arcs are not lines
+		{'╭', [4]int{}, false},
+		{'█', [4]int{}, false},
+	}
+	for _, tt := range tests {
+		arms, ok := parseBoxArms(tt.r)
+		if ok != tt.ok || arms != tt.arms {
+			t.Errorf("parseBoxArms(%q) = %v, %v, want %v, %v", tt.r, arms, ok, tt.arms, tt.ok)
+		}
+	}
+}
+
+// covered reports whether the point is in any of the rects
+func covered(rects []boxRect, x, y float64) bool {
+	for _, r := range rects {
+		if x >= r.x && x < r.x+r.w && y >= r.y && y < r.y+r.h {
+			return true
+		}
+	}
+	return false
+}
+
+func TestBoxLineRects(t *testing.T) {
+	// The cell is 8x16 pixels, the light line is 1 pixel and the center is
+	// (4, 8)
+	horizontal := boxLineRects([4]int{0, 1, 0, 1}, 8, 16)
+	for x := 0.0; x < 8; x++ {
+		if !covered(horizontal, x+0.5, 8) {
+			t.Errorf("─ has the gap at x=%v", x)
+		}
+	}
+	if covered(horizontal, 4, 7) || covered(horizontal, 4, 9) {
+		t.Errorf("─ is thicker than the light line: %v", horizontal)
+	}
+
+	vertical := boxLineRects([4]int{1, 0, 1, 0}, 8, 16)
+	for y := 0.0; y < 16; y++ {
+		if !covered(vertical, 4, y+0.5) {
+			t.Errorf("│ has the gap at y=%v", y)
+		}
+	}
+
+	// ╔ is the outer line from the top left corner of the lines, and the
+	// inner line from the inner corner
+	corner := boxLineRects([4]int{0, 3, 3, 0}, 8, 16)
+	if !covered(corner, 3, 7) {
+		t.Errorf("╔ has no outer corner: %v", corner)
+	}
+	if covered(corner, 4, 9) || !covered(corner, 5, 9) {
+		t.Errorf("╔ has the wrong inner corner: %v", corner)
+	}
+	if covered(corner, 1, 9) || !covered(corner, 3, 15.5) {
+		t.Errorf("╔ has the lines out of the corner: %v", corner)
+	}
+}
+
+func TestDashRects(t *testing.T) {
+	rects := dashRects([]boxRect{{0, 0, 1, 16}}, 4, true)
+	if len(rects) != 4 {
+		t.Fatalf("got %d dashes, want 4", len(rects))
+	}
+	for i, r := range rects {
+		if r.y < float64(i)*4 || r.y+r.h > float64(i+1)*4 {
+			t.Errorf("dash %d is out of the segment: %v", i, r)
+		}
+	}
+}
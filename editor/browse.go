@@ -0,0 +1,182 @@
+package editor
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/therecipe/qt/widgets"
+)
+
+// browseCommand is a command which :browse opens the file dialog for
+type browseCommand struct {
+	name string
+	// abbrev is the shortest abbreviation of the command
+	abbrev int
+	save   bool
+}
+
+var browseCommands = []browseCommand{
+	{"edit", 1, false},
+	{"view", 3, false},
+	{"split", 2, false},
+	{"vsplit", 2, false},
+	{"sview", 2, false},
+	{"new", 3, false},
+	{"vnew", 3, false},
+	{"tabedit", 4, false},
+	{"tabnew", 6, false},
+	{"read", 1, false},
+	{"source", 2, false},
+	{"write", 1, true},
+	{"saveas", 3, true},
+	{"update", 2, true},
+	{"wq", 2, true},
+}
+
+// browseRequest is the parsed command line of :browse
+type browseRequest struct {
+	command browseCommand
+	bang    bool
+	arg     string
+}
+
+// parseBrowseCommand parses the command given to :browse, e.g. "e", "w! foo".
+// It returns false for the commands without the file argument, which are
+// left to nvim, e.g. ":browse oldfiles".
+func parseBrowseCommand(cmdline string) (browseRequest, bool) {
+	cmdline = strings.TrimSpace(cmdline)
+	end := 0
+	for end < len(cmdline) && cmdline[end] >= 'a' && cmdline[end] <= 'z' {
+		end++
+	}
+	name := cmdline[:end]
+	rest := cmdline[end:]
+
+	req := browseRequest{}
+	if strings.HasPrefix(rest, "!") {
+		req.bang = true
+		rest = rest[1:]
+	}
+	req.arg = strings.TrimSpace(rest)
+
+	for _, c := range browseCommands {
+		if len(name) >= c.abbrev && strings.HasPrefix(c.name, name) {
+			req.command = c
+			return req, true
+		}
+	}
+
+	return req, false
+}
+
+// browseFilter converts the Vim 'browsefilter' style filter, e.g.
+// "Vim Scripts\t*.vim\nAll Files\t*.*\n", to the filter of the QFileDialog
+func browseFilter(filter string) string {
+	var filters []string
+	for _, line := range strings.Split(filter, "\n") {
+		parts := strings.SplitN(line, "\t", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		patterns := strings.Split(parts[1], ";")
+		for i, pattern := range patterns {
+			// "*.*" matches the files without extensions in the dialog of Vim
+			if pattern == "*.*" {
+				pattern = "*"
+			}
+			patterns[i] = pattern
+		}
+		filters = append(filters, fmt.Sprintf("%s (%s)", strings.TrimSpace(parts[0]), strings.Join(patterns, " ")))
+	}
+
+	return strings.Join(filters, ";;")
+}
+
+// defaultBrowseFilter returns the filter of the files with the extension of
+// the buffer, for the buffers without b:browsefilter
+func defaultBrowseFilter(name, filetype string) string {
+	all := "All Files (*)"
+	ext := filepath.Ext(name)
+	if ext == "" {
+		return all
+	}
+	label := filetype
+	if label == "" {
+		label = strings.TrimPrefix(ext, ".")
+	}
+
+	return fmt.Sprintf("%s files (*%s);;%s", label, ext, all)
+}
+
+// browse is called by the gonvim_browse notification, and runs the command
+// with the file chosen in the native file dialog.
+// args: [command line, the directory of the buffer, the file name of the
+// buffer, &filetype, b:browsefilter]
+func (w *Workspace) browse(args []interface{}) {
+	if len(args) < 5 {
+		return
+	}
+	cmdline, _ := args[0].(string)
+	dir, _ := args[1].(string)
+	name, _ := args[2].(string)
+	filetype, _ := args[3].(string)
+	vimFilter, _ := args[4].(string)
+
+	req, ok := parseBrowseCommand(cmdline)
+	if !ok {
+		go w.nvim.Command("browse " + cmdline)
+		return
+	}
+
+	if dir == "" {
+		dir = w.cwd
+	}
+	// The argument of the command is the directory to browse, or the
+	// default file name
+	initial := filepath.Join(dir, name)
+	if req.command.save && name == "" {
+		initial = dir
+	}
+	if req.arg != "" {
+		initial = req.arg
+		if strings.HasPrefix(initial, "~") {
+			initial = filepath.Join(editor.homeDir, initial[1:])
+		}
+		if !filepath.IsAbs(initial) {
+			initial = filepath.Join(w.cwd, initial)
+		}
+	}
+
+	filter := browseFilter(vimFilter)
+	if filter == "" {
+		filter = defaultBrowseFilter(name, filetype)
+	}
+
+	title := strings.Title(req.command.name)
+	var file string
+	if req.command.save {
+		file = widgets.QFileDialog_GetSaveFileName(w.widget, title, initial, filter, "", 0)
+	} else {
+		file = widgets.QFileDialog_GetOpenFileName(w.widget, title, initial, filter, "", 0)
+	}
+	if file == "" {
+		return
+	}
+
+	command := req.command.name
+	if req.bang {
+		command += "!"
+	}
+	go func() {
+		var escaped string
+		err := w.nvim.Call("fnameescape", &escaped, file)
+		if err != nil {
+			return
+		}
+		err = w.nvim.Command(command + " " + escaped)
+		if err != nil {
+			editor.pushNotification(NotifyWarn, -1, fmt.Sprintf("[Goneovim] %s", err))
+		}
+	}()
+}
@@ -0,0 +1,65 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestParseBrowseCommand(t *testing.T) {
+	tests := []struct {
+		cmdline string
+		name    string
+		bang    bool
+		arg     string
+		ok      bool
+	}{
+		{"e", "edit", false, "", true},
+		{"edit ~/src", "edit", false, "~/src", true},
+		{"w", "write", false, "", true},
+		{"w! foo.txt", "write", true, "foo.txt", true},
+		{"wq", "wq", false, "", true},
+		{"vs", "vsplit", false, "", true},
+		{"sav", "saveas", false, "", true},
+		{"tabe", "tabedit", false, "", true},
+		// Too short to be the command
+		{"sa", "", false, "", false},
+		{"ne", "", false, "", false},
+		// The commands without the file are left to nvim
+		{"oldfiles", "", false, "", false},
+	}
+	for _, tt := range tests {
+		req, ok := parseBrowseCommand(tt.cmdline)
+		if ok != tt.ok {
+			t.Errorf("parseBrowseCommand(%q) ok = %v, want %v", tt.cmdline, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if req.command.name != tt.name || req.bang != tt.bang || req.arg != tt.arg {
+			t.Errorf("parseBrowseCommand(%q) = %q, %v, %q, want %q, %v, %q", tt.cmdline, req.command.name, req.bang, req.arg, tt.name, tt.bang, tt.arg)
+		}
+	}
+}
+
+func TestBrowseFilter(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   string
+	}{
+		{"", ""},
+		{"Vim Scripts\t*.vim\nAll Files\t*.*\n", "Vim Scripts (*.vim);;All Files (*)"},
+		{"C Sources\t*.c;*.h\n", "C Sources (*.c *.h)"},
+	}
+	for _, tt := range tests {
+		if got := browseFilter(tt.filter); got != tt.want {
+			t.Errorf("browseFilter(%q) = %q, want %q", tt.filter, got, tt.want)
+		}
+	}
+
+	if got, want := defaultBrowseFilter("main.go", "go"), "go files (*.go);;All Files (*)"; got != want {
+		t.Errorf("defaultBrowseFilter() = %q, want %q", got, want)
+	}
+	if got, want := defaultBrowseFilter("Makefile", "make"), "All Files (*)"; got != want {
+		t.Errorf("defaultBrowseFilter() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,463 @@
+package editor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/akiyosi/goneovim/fuzzy"
+	"github.com/neovim/go-client/nvim"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+// Cheatsheet is the which-key style overlay which lists the mappings and the
+// user commands. It is shown by :GonvimCheatsheet, or when a prefix key of
+// mappings is typed and no more keys are typed for cheatsheet.timeout msec.
+type Cheatsheet struct {
+	ws     *Workspace
+	widget *widgets.QWidget
+	filter *widgets.QLineEdit
+	list   *widgets.QListWidget
+	timer  *core.QTimer
+
+	entries []*cheatsheetEntry
+	shown   []*cheatsheetEntry
+	typed   []string
+	prefix  []string
+	// seq is the count of the loads, which drops the stale entries
+	seq int
+}
+
+// cheatsheetEntry is a mapping from nvim_get_keymap, or a user command
+type cheatsheetEntry struct {
+	Mode string `msgpack:"mode"`
+	LHS  string `msgpack:"lhs"`
+	RHS  string `msgpack:"rhs"`
+	Desc string `msgpack:"desc"`
+
+	keys      []string
+	isCommand bool
+	nargs     string
+}
+
+// userCommand is a command from nvim_get_commands
+type userCommand struct {
+	Name       string `msgpack:"name"`
+	Definition string `msgpack:"definition"`
+	Nargs      string `msgpack:"nargs"`
+}
+
+func newCheatsheet(ws *Workspace) *Cheatsheet {
+	c := &Cheatsheet{
+		ws: ws,
+	}
+
+	widget := widgets.NewQWidget(ws.screen.widget, 0)
+	widget.SetObjectName("cheatsheet")
+	widget.SetAttribute(core.Qt__WA_StyledBackground, true)
+	layout := widgets.NewQVBoxLayout()
+	layout.SetContentsMargins(8, 8, 8, 8)
+	layout.SetSpacing(4)
+	widget.SetLayout(layout)
+
+	filter := widgets.NewQLineEdit(nil)
+	filter.SetPlaceholderText("Filter mappings and commands")
+	filter.ConnectTextChanged(func(string) {
+		c.refresh()
+	})
+	filter.ConnectKeyPressEvent(c.filterKeyPress)
+
+	list := widgets.NewQListWidget(nil)
+	list.SetFocusPolicy(core.Qt__NoFocus)
+	list.SetFrameShape(widgets.QFrame__NoFrame)
+	list.ConnectItemClicked(func(item *widgets.QListWidgetItem) {
+		c.execute(list.Row(item))
+	})
+
+	layout.AddWidget(filter, 0, 0)
+	layout.AddWidget(list, 1, 0)
+	widget.Hide()
+
+	c.widget = widget
+	c.filter = filter
+	c.list = list
+
+	c.timer = core.NewQTimer(nil)
+	c.timer.SetSingleShot(true)
+	c.timer.ConnectTimeout(c.showPending)
+
+	return c
+}
+
+// keyInput is called with the keys typed in the editor
+func (c *Cheatsheet) keyInput(keys string) {
+	if c.widget.IsVisible() {
+		c.hide()
+	}
+	timeout := editor.config.Cheatsheet.Timeout
+	if timeout <= 0 {
+		return
+	}
+	if !c.isMappingMode() || keys == "<Esc>" {
+		c.typed = nil
+		c.timer.Stop()
+		return
+	}
+	c.typed = append(c.typed, splitKeys(keys)...)
+	c.timer.Start(timeout)
+}
+
+func (c *Cheatsheet) isMappingMode() bool {
+	switch c.ws.mode {
+	case "normal", "visual", "visual_select":
+		return true
+	}
+
+	return false
+}
+
+// showPending loads the mappings for the keys typed last, which loaded shows
+// if the keys are waiting for the rest of a mapping
+func (c *Cheatsheet) showPending() {
+	typed := c.typed
+	c.typed = nil
+	if !c.isMappingMode() || len(typed) == 0 {
+		return
+	}
+	c.load(typed)
+}
+
+// load fetches the mappings of the current mode and the user commands off
+// the GUI thread in a batch, and sends them by gonvim_cheatsheet_entries
+// with the typed keys, which are nil for :GonvimCheatsheet
+func (c *Cheatsheet) load(typed []string) {
+	mode := "n"
+	if strings.HasPrefix(c.ws.mode, "visual") {
+		mode = "v"
+	}
+	c.seq++
+	seq := c.seq
+	neovim := c.ws.nvim
+	go func() {
+		entries, err := loadCheatsheet(neovim, mode)
+		if err != nil {
+			return
+		}
+		c.ws.guiUpdates <- []interface{}{"gonvim_cheatsheet_entries", seq, typed, entries}
+		c.ws.signal.GuiSignal()
+	}()
+}
+
+// loaded shows the entries of load, with the mappings of the pending prefix
+// of the typed keys, or all of them with the filter for :GonvimCheatsheet.
+// args: [seq, typed, entries]
+func (c *Cheatsheet) loaded(args []interface{}) {
+	if len(args) < 3 {
+		return
+	}
+	if seq, _ := args[0].(int); seq != c.seq {
+		return
+	}
+	typed, _ := args[1].([]string)
+	c.entries, _ = args[2].([]*cheatsheetEntry)
+	if typed == nil {
+		c.show(nil, true)
+		return
+	}
+	if !c.isMappingMode() || len(c.typed) > 0 {
+		return
+	}
+	prefix := pendingPrefix(typed, c.entries)
+	if prefix == nil {
+		return
+	}
+	c.show(prefix, false)
+}
+
+// loadCheatsheet returns the mappings of the mode, the ones of the current
+// buffer first, and the user commands
+func loadCheatsheet(neovim *nvim.Nvim, mode string) ([]*cheatsheetEntry, error) {
+	var global, local []*cheatsheetEntry
+	commands := make(map[string]*userCommand)
+	b := neovim.NewBatch()
+	b.Request("nvim_get_keymap", &global, mode)
+	b.Request("nvim_buf_get_keymap", &local, 0, mode)
+	b.Request("nvim_get_commands", &commands, map[string]interface{}{})
+	if err := b.Execute(); err != nil {
+		return nil, err
+	}
+
+	var entries []*cheatsheetEntry
+	for _, e := range append(local, global...) {
+		if e == nil || strings.HasPrefix(e.LHS, "<Plug>") {
+			continue
+		}
+		e.keys = splitKeys(e.LHS)
+		entries = append(entries, e)
+	}
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		command := commands[name]
+		entries = append(entries, &cheatsheetEntry{
+			Mode:      ":",
+			LHS:       name,
+			RHS:       command.Definition,
+			isCommand: true,
+			nargs:     command.Nargs,
+		})
+	}
+
+	return entries, nil
+}
+
+// show shows the overlay. If focus is true, the filter takes the keyboard
+// focus; otherwise the overlay is closed by the next key.
+func (c *Cheatsheet) show(prefix []string, focus bool) {
+	c.prefix = prefix
+	c.setColor()
+	c.list.SetFont(c.ws.font.fontNew)
+	c.filter.SetText("")
+	c.refresh()
+
+	margin := editor.iconSize
+	width := c.ws.screen.widget.Width() - margin*2
+	height := c.ws.screen.widget.Height() * 2 / 5
+	c.widget.SetGeometry2(margin, c.ws.screen.widget.Height()-height-margin, width, height)
+	c.widget.Show()
+	c.widget.Raise()
+	c.filter.SetVisible(focus)
+	if focus {
+		c.filter.SetFocus2()
+	}
+}
+
+func (c *Cheatsheet) hide() {
+	if !c.widget.IsVisible() {
+		return
+	}
+	hadFocus := c.filter.HasFocus()
+	c.widget.Hide()
+	c.prefix = nil
+	if hadFocus {
+		c.ws.widget.SetFocus2()
+	}
+}
+
+func (c *Cheatsheet) toggle() {
+	if c.widget.IsVisible() {
+		c.hide()
+		return
+	}
+	c.load(nil)
+}
+
+func (c *Cheatsheet) refresh() {
+	c.shown = filterCheatsheet(c.entries, c.prefix, c.filter.Text())
+	c.list.Clear()
+	for _, e := range c.shown {
+		c.list.AddItem(e.String())
+	}
+	if len(c.shown) > 0 {
+		c.list.SetCurrentRow(0)
+	}
+}
+
+func (c *Cheatsheet) filterKeyPress(event *gui.QKeyEvent) {
+	switch core.Qt__Key(event.Key()) {
+	case core.Qt__Key_Escape:
+		c.hide()
+	case core.Qt__Key_Return, core.Qt__Key_Enter:
+		c.execute(c.list.CurrentRow())
+	case core.Qt__Key_Down:
+		if c.list.CurrentRow() < c.list.Count()-1 {
+			c.list.SetCurrentRow(c.list.CurrentRow() + 1)
+		}
+	case core.Qt__Key_Up:
+		if c.list.CurrentRow() > 0 {
+			c.list.SetCurrentRow(c.list.CurrentRow() - 1)
+		}
+	default:
+		c.filter.KeyPressEventDefault(event)
+	}
+}
+
+// execute runs the mapping, or the rest of it if the prefix is already typed
+func (c *Cheatsheet) execute(row int) {
+	if row < 0 || row >= len(c.shown) {
+		return
+	}
+	e := c.shown[row]
+	prefix := c.prefix
+	c.hide()
+
+	if e.isCommand {
+		if e.nargs == "0" {
+			go c.ws.nvim.Input(fmt.Sprintf(":%s<CR>", e.LHS))
+		} else {
+			go c.ws.nvim.Input(fmt.Sprintf(":%s ", e.LHS))
+		}
+		return
+	}
+	go c.ws.nvim.Input(strings.Join(e.keys[len(prefix):], ""))
+}
+
+func (c *Cheatsheet) setColor() {
+	fg := editor.colors.widgetFg
+	bg := editor.colors.widgetBg
+	if fg == nil || bg == nil || editor.colors.inactiveFg == nil || editor.colors.selectedBg == nil {
+		return
+	}
+	c.widget.SetStyleSheet(withUserStyle(fmt.Sprintf(
+		"QWidget#cheatsheet { border: 1px solid %s; background-color: %s; } * { color: %s; background-color: %s; } QListWidget::item:selected { background-color: %s; }",
+		editor.colors.inactiveFg.String(),
+		bg.String(),
+		fg.String(),
+		bg.String(),
+		editor.colors.selectedBg.String(),
+	)))
+}
+
+func (e *cheatsheetEntry) String() string {
+	desc := e.Desc
+	if desc == "" {
+		desc = e.RHS
+	}
+
+	return fmt.Sprintf("%-2s %-20s %s", e.Mode, e.LHS, desc)
+}
+
+// splitKeys splits the keys in the key notation into the keys, normalizing
+// the notation so that the keys from the GUI and nvim_get_keymap match:
+// " f<c-w>" becomes ["<SPACE>", "f", "<C-W>"].
+func splitKeys(keys string) []string {
+	var split []string
+	runes := []rune(keys)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '<' {
+			end := -1
+			for j := i + 1; j < len(runes); j++ {
+				if runes[j] == '>' {
+					end = j
+					break
+				}
+				if runes[j] == '<' {
+					break
+				}
+			}
+			if end > i+1 {
+				split = append(split, "<"+strings.ToUpper(string(runes[i+1:end]))+">")
+				i = end
+				continue
+			}
+		}
+		switch r {
+		case ' ':
+			split = append(split, "<SPACE>")
+		case '\\':
+			split = append(split, "<BSLASH>")
+		default:
+			split = append(split, string(r))
+		}
+	}
+
+	return split
+}
+
+// pendingPrefix returns the keys at the end of the typed keys which wait for
+// the rest of a mapping, or nil if the typed keys don't wait. The typed keys
+// are resolved as nvim does: the keys which are the proper prefix of a
+// mapping wait, the keys of a whole mapping run it, and the other keys run
+// alone, so that the keys in the middle of a mapping which ran don't wait.
+func pendingPrefix(typed []string, entries []*cheatsheetEntry) []string {
+	for i := 0; i < len(typed); {
+		rest := typed[i:]
+		mapped := 0
+		for _, e := range entries {
+			if e.isCommand {
+				continue
+			}
+			if len(e.keys) > len(rest) && hasKeysPrefix(e.keys, rest) {
+				return rest
+			}
+			if len(e.keys) > mapped && hasKeysPrefix(rest, e.keys) {
+				mapped = len(e.keys)
+			}
+		}
+		if mapped == 0 {
+			mapped = 1
+		}
+		i += mapped
+	}
+
+	return nil
+}
+
+func hasKeysPrefix(keys, prefix []string) bool {
+	if len(keys) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if keys[i] != prefix[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// filterCheatsheet returns the entries which start with the prefix and
+// match the words of the pattern fuzzily as the finder, in the order of the
+// score, and of the length of the text for the same score
+func filterCheatsheet(entries []*cheatsheetEntry, prefix []string, pattern string) []*cheatsheetEntry {
+	type scored struct {
+		e      *cheatsheetEntry
+		score  int
+		length int
+	}
+	words := strings.Fields(pattern)
+	var matched []scored
+	for _, e := range entries {
+		if prefix != nil && (e.isCommand || len(e.keys) <= len(prefix) || !hasKeysPrefix(e.keys, prefix)) {
+			continue
+		}
+		text := e.LHS + " " + e.Desc + " " + e.RHS
+		score, ok := 0, true
+		for _, word := range words {
+			var s int
+			s, ok = fuzzy.Score(word, text)
+			if !ok {
+				break
+			}
+			score += s
+		}
+		if !ok {
+			continue
+		}
+		length := 0
+		if len(words) > 0 {
+			length = len(text)
+		}
+		matched = append(matched, scored{e, score, length})
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		if matched[i].score != matched[j].score {
+			return matched[i].score > matched[j].score
+		}
+		return matched[i].length < matched[j].length
+	})
+
+	filtered := make([]*cheatsheetEntry, len(matched))
+	for i, m := range matched {
+		filtered[i] = m.e
+	}
+
+	return filtered
+}
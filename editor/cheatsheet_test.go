@@ -0,0 +1,92 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitKeys(t *testing.T) {
+	tests := []struct {
+		keys string
+		want []string
+	}{
+		{"gc", []string{"g", "c"}},
+		{" ff", []string{"<SPACE>", "f", "f"}},
+		{"<Space>ff", []string{"<SPACE>", "f", "f"}},
+		{"<c-w>v", []string{"<C-W>", "v"}},
+		{"<lt>a", []string{"<LT>", "a"}},
+		{"\\x", []string{"<BSLASH>", "x"}},
+		{"<", []string{"<"}},
+		{"a<b", []string{"a", "<", "b"}},
+	}
+	for _, tt := range tests {
+		if got := splitKeys(tt.keys); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitKeys(%q) = %v, want %v", tt.keys, got, tt.want)
+		}
+	}
+}
+
+func testCheatsheetEntries() []*cheatsheetEntry {
+	entries := []*cheatsheetEntry{
+		{Mode: "n", LHS: " ff", RHS: ":Files<CR>", Desc: "Find files"},
+		{Mode: "n", LHS: " fg", RHS: ":Rg<CR>", Desc: "Grep"},
+		{Mode: "n", LHS: " b", RHS: ":Buffers<CR>"},
+		{Mode: "n", LHS: "gcc", RHS: "<Plug>Comment"},
+		{Mode: ":", LHS: "Files", RHS: "call fzf#run()", isCommand: true},
+	}
+	for _, e := range entries {
+		e.keys = splitKeys(e.LHS)
+	}
+
+	return entries
+}
+
+func TestPendingPrefix(t *testing.T) {
+	entries := testCheatsheetEntries()
+	tests := []struct {
+		typed []string
+		want  []string
+	}{
+		{[]string{"<SPACE>"}, []string{"<SPACE>"}},
+		{[]string{"j", "j", "<SPACE>", "f"}, []string{"<SPACE>", "f"}},
+		{[]string{"g", "c"}, []string{"g", "c"}},
+		{[]string{"<SPACE>", "b"}, nil},
+		// The keys in the middle of the mapping which ran don't wait
+		{[]string{"<SPACE>", "f", "g", "c"}, nil},
+		{[]string{"g", "c", "c", "g"}, []string{"g"}},
+		{[]string{"x"}, nil},
+		{nil, nil},
+	}
+	for _, tt := range tests {
+		if got := pendingPrefix(tt.typed, entries); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("pendingPrefix(%v) = %v, want %v", tt.typed, got, tt.want)
+		}
+	}
+}
+
+func TestFilterCheatsheet(t *testing.T) {
+	entries := testCheatsheetEntries()
+	lhs := func(filtered []*cheatsheetEntry) []string {
+		var l []string
+		for _, e := range filtered {
+			l = append(l, e.LHS)
+		}
+		return l
+	}
+	tests := []struct {
+		prefix  []string
+		pattern string
+		want    []string
+	}{
+		{nil, "", []string{" ff", " fg", " b", "gcc", "Files"}},
+		{[]string{"<SPACE>"}, "", []string{" ff", " fg", " b"}},
+		{[]string{"<SPACE>", "f"}, "grep", []string{" fg"}},
+		{nil, "files", []string{"Files", " ff"}},
+		{nil, "zzz", nil},
+	}
+	for _, tt := range tests {
+		if got := lhs(filterCheatsheet(entries, tt.prefix, tt.pattern)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("filterCheatsheet(%v, %q) = %q, want %q", tt.prefix, tt.pattern, got, tt.want)
+		}
+	}
+}
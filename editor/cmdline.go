@@ -7,19 +7,33 @@ import (
 	"github.com/akiyosi/goneovim/util"
 )
 
-// CmdContent is the content of the cmdline
+// CmdContent is the content of a level of the cmdline
 type CmdContent struct {
 	indent  int
 	firstc  string
 	prompt  string
 	content string
+	pos     int
+	level   int
+	// chunks are the content in the colors of its highlights
+	chunks []cmdlineChunk
+	// special is the char shown at the cursor while it is pending, e.g. by
+	// c_CTRL-V, and shift is true if it is inserted rather than overwrites
+	// the char at the cursor
+	special string
+	shift   bool
+}
+
+// cmdlineChunk is a chunk of the content in the color of its highlight
+type cmdlineChunk struct {
+	color string
+	text  string
 }
 
 // Cmdline is the cmdline
 type Cmdline struct {
 	shown         bool
 	ws            *Workspace
-	pos           int
 	content       *CmdContent
 	preContent    *CmdContent
 	function      []*CmdContent
@@ -27,6 +41,12 @@ type Cmdline struct {
 	rawItems      []interface{}
 	wildmenuShown bool
 	top           int
+	// levels are the nested cmdlines, e.g. the expression of <C-r>=, and
+	// content is the innermost of them
+	levels []*CmdContent
+	// block is the lines of the block entered so far, e.g. of :function
+	// typed in the cmdline
+	block []string
 }
 
 func initCmdline() *Cmdline {
@@ -37,28 +57,89 @@ func initCmdline() *Cmdline {
 }
 
 func (c *CmdContent) getText() string {
-	indentStr := ""
-	for i := 0; i < c.indent; i++ {
-		indentStr += " "
+	return fmt.Sprintf("%s%s", strings.Repeat(" ", c.indent), c.content)
+}
+
+// promptLine returns the last line of the prompt, which precedes the content
+func (c *CmdContent) promptLine() string {
+	lines := strings.Split(c.prompt, "\n")
+
+	return lines[len(lines)-1]
+}
+
+// prefix returns the text before the content: the last line of the prompt,
+// firstc and the indent
+func (c *CmdContent) prefix() string {
+	return c.promptLine() + c.firstc + strings.Repeat(" ", c.indent)
+}
+
+// cmdlineSpecialChar returns the text with the special char at the pos, which
+// is inserted if shift is true, otherwise it overwrites the char at the pos
+func cmdlineSpecialChar(text string, pos int, char string, shift bool) string {
+	pos = cmdlineClampPos(text, pos)
+	if shift || pos == len(text) {
+		return text[:pos] + char + text[pos:]
 	}
-	return fmt.Sprintf("%s%s", indentStr, c.content)
+	next := pos + 1
+	for next < len(text) && !isUTF8Start(text[next]) {
+		next++
+	}
+
+	return text[:pos] + char + text[next:]
 }
 
-func (c *Cmdline) getText(ch string) string {
-	indentStr := ""
-	for i := 0; i < c.content.indent; i++ {
-		indentStr += " "
+// cmdlineClampPos returns the byte pos of the cursor within the text
+func cmdlineClampPos(text string, pos int) int {
+	if pos < 0 {
+		return 0
 	}
-	if c.pos > len(c.content.content) {
-		c.pos = len(c.content.content) - 1
+	if pos > len(text) {
+		return len(text)
 	}
-	if len(c.content.content) == 0 {
-		c.pos = 0
+
+	return pos
+}
+
+// isUTF8Start reports whether the byte starts a UTF-8 char
+func isUTF8Start(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// getText returns the plain text of the cmdline with the special char
+func (c *Cmdline) getText() string {
+	text := c.content.content
+	if c.content.special != "" {
+		text = cmdlineSpecialChar(text, c.content.pos, c.content.special, c.content.shift)
 	}
-	return fmt.Sprintf("%s%s%s", c.content.firstc, indentStr, c.content.content[:c.pos]+ch+c.content.content[c.pos:])
+
+	return c.content.prefix() + text
+}
+
+// getHTML returns the html of the cmdline in the colors of the chunks
+func (c *Cmdline) getHTML() string {
+	var b strings.Builder
+	b.WriteString(sanitize(c.content.prefix()))
+	special := c.content.special
+	pos := 0
+	for _, chunk := range c.content.chunks {
+		text := chunk.text
+		// The special char is put in the chunk of the cursor
+		if special != "" && c.content.pos <= pos+len(text) {
+			text = cmdlineSpecialChar(text, c.content.pos-pos, special, c.content.shift)
+			special = ""
+		}
+		pos += len(chunk.text)
+		b.WriteString(fmt.Sprintf("<font color='%s'>%s</font>", chunk.color, sanitize(text)))
+	}
+	if special != "" {
+		b.WriteString(sanitize(special))
+	}
+
+	return b.String()
 }
 
 func sanitize(s string) string {
+	s = strings.Replace(s, "&", `&amp;`, -1)
 	s = strings.Replace(s, " ", `&nbsp;`, -1)
 	s = strings.Replace(s, "\t", `&nbsp;`, -1)
 	s = strings.Replace(s, "<", `&lt;`, -1)
@@ -67,57 +148,64 @@ func sanitize(s string) string {
 	return s
 }
 
+// show is called by cmdline_show.
+// args: [content, pos, firstc, prompt, indent, level]
 func (c *Cmdline) show(args []interface{}) {
-	palette := c.ws.palette
 	arg := args[0].([]interface{})
 
 	content := ""
-	contentChunks := arg[0].([]interface{})
-	for _, e := range contentChunks {
+	var chunks []cmdlineChunk
+	for _, e := range arg[0].([]interface{}) {
 		a := e.([]interface{})
-
 		if len(a) < 2 {
-			// content += a[0].(string)
-			content += strings.Replace(a[0].(string), "\t", " ", -1)
-		} else {
-			color := c.ws.foreground
-			_, ok := c.ws.screen.hlAttrDef[util.ReflectToInt(a[0])]
-			if ok {
-				color = c.ws.screen.hlAttrDef[util.ReflectToInt(a[0])].foreground
-			}
-
-			// I don't know how to set sticking out direction of
-			// the contents of a qlabel with html text to the left.
-			if len(contentChunks) == 1 {
-				// content += a[1].(string)
-				content += strings.Replace(a[1].(string), "\t", " ", -1)
-			} else {
-				content += fmt.Sprintf(
-					"<font color='%s'>%s</font>",
-					color.Hex(),
-					sanitize(a[1].(string)),
-				)
-				palette.isHTMLText = true
-			}
+			continue
+		}
+		color := c.ws.foreground
+		if hl, ok := c.ws.screen.hlAttrDef[util.ReflectToInt(a[0])]; ok && hl != nil && hl.foreground != nil {
+			color = hl.foreground
 		}
+		text := strings.Replace(a[1].(string), "\t", " ", -1)
+		content += text
+		chunks = append(chunks, cmdlineChunk{color: color.Hex(), text: text})
+	}
+
+	level := 1
+	if len(arg) > 5 {
+		level = util.ReflectToInt(arg[5])
+	}
+	cmd := &CmdContent{
+		content: content,
+		chunks:  chunks,
+		pos:     util.ReflectToInt(arg[1]),
+		firstc:  arg[2].(string),
+		prompt:  arg[3].(string),
+		indent:  util.ReflectToInt(arg[4]),
+		level:   level,
+	}
+	isResize := c.content.content != content || c.content.level != level
+	c.setLevel(cmd)
+	c.render(isResize)
+}
+
+// setLevel puts the content at its level, and drops the inner levels
+func (c *Cmdline) setLevel(cmd *CmdContent) {
+	level := maxInt(cmd.level, 1)
+	for len(c.levels) < level-1 {
+		c.levels = append(c.levels, &CmdContent{level: len(c.levels) + 1})
+	}
+	c.levels = append(c.levels[:level-1], cmd)
+	c.content = cmd
+}
+
+// render shows the innermost level of the cmdline in the palette
+func (c *Cmdline) render(isResize bool) {
+	palette := c.ws.palette
+	// The chunks of one color are drawn as the plain text
+	if len(c.content.chunks) > 1 {
+		palette.setPatternHTML(c.getHTML(), c.getText())
+	} else {
+		palette.setPattern(c.getText())
 	}
-	// content := arg[0].([]interface{})[0].([]interface{})[1].(string)
-
-	pos := util.ReflectToInt(arg[1])
-	firstc := arg[2].(string)
-	prompt := arg[3].(string)
-	indent := util.ReflectToInt(arg[4])
-	// level := util.ReflectToInt(arg[5])
-	// fmt.Println("cmdline show", content, pos, firstc, prompt, indent, level)
-
-	c.pos = pos
-	c.content.firstc = firstc
-	isResize := c.content.content != content
-	c.content.content = content
-	c.content.indent = indent
-	c.content.prompt = prompt
-	text := c.getText("")
-	palette.setPattern(text)
 	c.cursorMove()
 	if isResize {
 		palette.resize()
@@ -131,8 +219,11 @@ func (c *Cmdline) show(args []interface{}) {
 }
 
 func (c *Cmdline) showAddition() {
-	lines := append(c.getPromptLines(), c.getFunctionLines()...)
+	lines := append(c.getPromptLines(), c.getLevelLines()...)
+	lines = append(lines, c.getFunctionLines()...)
 	palette := c.ws.palette
+	// The latest lines of the block are shown in the room left
+	lines = append(lines, cmdlineTail(c.block, palette.showTotal-len(lines))...)
 	for i, resultItem := range palette.resultItems {
 		if i >= len(lines) || i >= palette.showTotal {
 			resultItem.hide()
@@ -144,6 +235,8 @@ func (c *Cmdline) showAddition() {
 	}
 }
 
+// getPromptLines returns the lines of the prompt but the last one, which is
+// shown in the cmdline
 func (c *Cmdline) getPromptLines() []string {
 	result := []string{}
 	if c.content.prompt == "" {
@@ -151,7 +244,7 @@ func (c *Cmdline) getPromptLines() []string {
 	}
 
 	lines := strings.Split(c.content.prompt, "\n")
-	for _, line := range lines {
+	for _, line := range lines[:len(lines)-1] {
 		if strings.TrimSpace(line) == "" {
 			continue
 		}
@@ -160,6 +253,19 @@ func (c *Cmdline) getPromptLines() []string {
 	return result
 }
 
+// getLevelLines returns the outer levels of the cmdline, from the innermost
+func (c *Cmdline) getLevelLines() []string {
+	result := []string{}
+	for i := len(c.levels) - 2; i >= 0; i-- {
+		outer := c.levels[i]
+		if outer.firstc == "" && outer.content == "" && outer.prompt == "" {
+			continue
+		}
+		result = append(result, outer.prefix()+outer.content)
+	}
+	return result
+}
+
 func (c *Cmdline) getFunctionLines() []string {
 	result := []string{}
 	if !c.inFunction {
@@ -173,10 +279,29 @@ func (c *Cmdline) getFunctionLines() []string {
 }
 
 func (c *Cmdline) cursorMove() {
-	c.ws.palette.cursorMove(c.pos + len(c.content.firstc) + c.content.indent)
+	pos := cmdlineClampPos(c.content.content, c.content.pos)
+	c.ws.palette.cursorMove(len(c.content.prefix()) + pos)
 }
 
+// hide is called by cmdline_hide. The outer level is shown again when the
+// inner one is hidden.
+// args: [level]
 func (c *Cmdline) hide(args []interface{}) {
+	level := 1
+	if len(args) > 0 {
+		if arg, ok := args[0].([]interface{}); ok && len(arg) > 0 {
+			level = maxInt(util.ReflectToInt(arg[0]), 1)
+		}
+	}
+	if level > 1 && len(c.levels) >= level {
+		c.levels = c.levels[:level-1]
+		c.content = c.levels[len(c.levels)-1]
+		c.content.special = ""
+		c.render(true)
+		return
+	}
+	c.levels = nil
+
 	palette := c.ws.palette
 	palette.hide()
 	if c.inFunction {
@@ -184,13 +309,6 @@ func (c *Cmdline) hide(args []interface{}) {
 	}
 	c.preContent = c.content
 	c.content = &CmdContent{}
-	// Move cursor into window
-	// win, ok := c.ws.screen.windows[c.ws.cursor.gridid]
-	// if ok && win != nil {
-	// 	c.ws.cursor.widget.SetParent(win.widget)
-	// 	c.ws.cursor.widget.Hide()
-	// 	c.ws.cursor.widget.Show()
-	// }
 	win, ok := c.ws.screen.getWindow(c.ws.cursor.gridid)
 	if ok {
 		c.ws.cursor.widget.SetParent(win.widget)
@@ -201,6 +319,70 @@ func (c *Cmdline) hide(args []interface{}) {
 	c.shown = false
 }
 
+// cmdlineBlockLine returns the text of the line of the block, which is the
+// chunks of the content, [[attr, text], ...]
+func cmdlineBlockLine(chunks []interface{}) string {
+	var b strings.Builder
+	for _, e := range chunks {
+		chunk, ok := e.([]interface{})
+		if !ok || len(chunk) < 2 {
+			continue
+		}
+		text, _ := chunk[1].(string)
+		b.WriteString(strings.Replace(text, "\t", " ", -1))
+	}
+
+	return b.String()
+}
+
+// cmdlineTail returns the last max lines
+func cmdlineTail(lines []string, max int) []string {
+	if max <= 0 {
+		return nil
+	}
+	if len(lines) > max {
+		return lines[len(lines)-max:]
+	}
+
+	return lines
+}
+
+// blockShow is called by cmdline_block_show with the lines of the block
+// entered before the cmdline.
+// args: [lines]
+func (c *Cmdline) blockShow(args []interface{}) {
+	arg := args[0].([]interface{})
+	lines, _ := arg[0].([]interface{})
+	c.block = nil
+	for _, line := range lines {
+		chunks, _ := line.([]interface{})
+		c.block = append(c.block, cmdlineBlockLine(chunks))
+	}
+	c.updateBlock()
+}
+
+// blockAppend is called by cmdline_block_append with the line entered.
+// args: [line]
+func (c *Cmdline) blockAppend(args []interface{}) {
+	arg := args[0].([]interface{})
+	chunks, _ := arg[0].([]interface{})
+	c.block = append(c.block, cmdlineBlockLine(chunks))
+	c.updateBlock()
+}
+
+// blockHide is called by cmdline_block_hide
+func (c *Cmdline) blockHide() {
+	c.block = nil
+	c.updateBlock()
+}
+
+func (c *Cmdline) updateBlock() {
+	if !c.shown || c.wildmenuShown {
+		return
+	}
+	c.showAddition()
+}
+
 func (c *Cmdline) functionShow() {
 	c.inFunction = true
 	c.function = []*CmdContent{c.preContent}
@@ -210,24 +392,43 @@ func (c *Cmdline) functionHide() {
 	c.inFunction = false
 }
 
+// changePos is called by cmdline_pos.
+// args: [pos, level]
 func (c *Cmdline) changePos(args []interface{}) {
 	args = args[0].([]interface{})
-	pos := util.ReflectToInt(args[0])
-	// level := util.ReflectToInt(args[1])
-	// fmt.Println("change pos", pos, level)
-	c.pos = pos
+	cmd := c.levelContent(args, 1)
+	cmd.pos = util.ReflectToInt(args[0])
+	if cmd != c.content {
+		return
+	}
 	c.cursorMove()
 }
 
+// putChar is called by cmdline_special_char, and shows the pending char at
+// the cursor until the next cmdline_show.
+// args: [char, shift, level]
 func (c *Cmdline) putChar(args []interface{}) {
 	args = args[0].([]interface{})
-	ch := args[0].(string)
-	// shift := util.ReflectToInt(args[1])
-	// level := util.ReflectToInt(args[2])
-	// fmt.Println("putChar", ch, shift, level)
-	text := c.getText(ch)
-	palette := c.ws.palette
-	palette.setPattern(text)
+	cmd := c.levelContent(args, 2)
+	cmd.special = args[0].(string)
+	cmd.shift, _ = args[1].(bool)
+	if cmd != c.content {
+		return
+	}
+	c.render(false)
+}
+
+// levelContent returns the content of the level of the i-th arg, or the
+// innermost one
+func (c *Cmdline) levelContent(args []interface{}, i int) *CmdContent {
+	if len(args) > i {
+		level := util.ReflectToInt(args[i])
+		if level >= 1 && level <= len(c.levels) {
+			return c.levels[level-1]
+		}
+	}
+
+	return c.content
 }
 
 func (c *Cmdline) wildmenuShow(args []interface{}) {
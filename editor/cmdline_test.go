@@ -0,0 +1,115 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCmdlineSpecialChar(t *testing.T) {
+	tests := []struct {
+		text  string
+		pos   int
+		char  string
+		shift bool
+		want  string
+	}{
+		{"abc", 1, "^", true, "a^bc"},
+		{"abc", 1, "^", false, "a^c"},
+		{"abc", 3, "^", false, "abc^"},
+		{"abc", 10, "^", true, "abc^"},
+		{"", 0, "^", false, "^"},
+		{"aあb", 1, "^", false, "a^b"},
+		{"aあb", -1, "^", true, "^aあb"},
+	}
+	for _, tt := range tests {
+		if got := cmdlineSpecialChar(tt.text, tt.pos, tt.char, tt.shift); got != tt.want {
+			t.Errorf("cmdlineSpecialChar(%q, %d, %q, %v) = %q, want %q", tt.text, tt.pos, tt.char, tt.shift, got, tt.want)
+		}
+	}
+}
+
+func TestCmdContentPrefix(t *testing.T) {
+	tests := []struct {
+		content *CmdContent
+		want    string
+	}{
+		{&CmdContent{firstc: ":"}, ":"},
+		{&CmdContent{firstc: ":", indent: 2}, ":  "},
+		{&CmdContent{prompt: "Name: "}, "Name: "},
+		{&CmdContent{prompt: "Choose\nNumber: "}, "Number: "},
+	}
+	for _, tt := range tests {
+		if got := tt.content.prefix(); got != tt.want {
+			t.Errorf("prefix() of %+v = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestCmdlineGetText(t *testing.T) {
+	c := initCmdline()
+	c.content = &CmdContent{firstc: ":", content: "echo", pos: 4, special: "^", shift: true}
+	if got, want := c.getText(), ":echo^"; got != want {
+		t.Errorf("getText() = %q, want %q", got, want)
+	}
+
+	c.content.chunks = []cmdlineChunk{{"#ff0000", "ec"}, {"#00ff00", "ho"}}
+	c.content.pos = 3
+	c.content.shift = false
+	want := ":<font color='#ff0000'>ec</font><font color='#00ff00'>h^</font>"
+	if got := c.getHTML(); got != want {
+		t.Errorf("getHTML() = %q, want %q", got, want)
+	}
+}
+
+func TestCmdlineLevels(t *testing.T) {
+	c := initCmdline()
+	c.setLevel(&CmdContent{firstc: ":", content: "echo ", level: 1})
+	c.setLevel(&CmdContent{firstc: "=", content: "1+1", level: 2})
+	if c.content.firstc != "=" || len(c.levels) != 2 {
+		t.Fatalf("setLevel() = %+v, want the level 2", c.content)
+	}
+	if got, want := c.getLevelLines(), []string{":echo "}; !reflect.DeepEqual(got, want) {
+		t.Errorf("getLevelLines() = %q, want %q", got, want)
+	}
+
+	// The level 1 replaces the inner levels
+	c.setLevel(&CmdContent{firstc: ":", content: "echo 2", level: 1})
+	if c.content.content != "echo 2" || len(c.levels) != 1 {
+		t.Errorf("setLevel() = %+v with %d levels, want the level 1", c.content, len(c.levels))
+	}
+	if got := c.getLevelLines(); len(got) != 0 {
+		t.Errorf("getLevelLines() = %q, want none", got)
+	}
+}
+
+func TestCmdlineBlockLine(t *testing.T) {
+	chunks := []interface{}{
+		[]interface{}{int64(0), "function! Foo()"},
+		[]interface{}{int64(12), "\treturn"},
+		[]interface{}{int64(0)},
+	}
+	if got, want := cmdlineBlockLine(chunks), "function! Foo() return"; got != want {
+		t.Errorf("cmdlineBlockLine() = %q, want %q", got, want)
+	}
+	if got := cmdlineBlockLine(nil); got != "" {
+		t.Errorf("cmdlineBlockLine(nil) = %q, want \"\"", got)
+	}
+}
+
+func TestCmdlineTail(t *testing.T) {
+	lines := []string{"a", "b", "c"}
+	tests := []struct {
+		max  int
+		want []string
+	}{
+		{5, []string{"a", "b", "c"}},
+		{2, []string{"b", "c"}},
+		{0, nil},
+		{-1, nil},
+	}
+	for _, tt := range tests {
+		if got := cmdlineTail(lines, tt.max); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("cmdlineTail(%v, %d) = %v, want %v", lines, tt.max, got, tt.want)
+		}
+	}
+}
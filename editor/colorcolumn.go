@@ -0,0 +1,123 @@
+package editor
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+)
+
+// colorColumn is the 'colorcolumn' of a window, which is drawn by the GUI
+// as a 1px line or a shaded region over the cells.
+type colorColumn struct {
+	columns []int
+	textoff int
+	leftcol int
+}
+
+// updateColorColumn is called by the gonvim_colorcolumn notification.
+// args: [winid, &colorcolumn, &textwidth, &filetype, textoff, leftcol]
+func (s *Screen) updateColorColumn(args []interface{}) {
+	if len(args) < 6 {
+		return
+	}
+	id := util.ReflectToInt(args[0])
+	cc, _ := args[1].(string)
+	tw := util.ReflectToInt(args[2])
+	filetype, _ := args[3].(string)
+
+	// The columns for the filetype in the config take precedence
+	if spec, ok := editor.config.ColorColumn.Filetypes[filetype]; ok {
+		cc = spec
+	}
+
+	guide := &colorColumn{
+		columns: parseColorColumn(cc, tw),
+		textoff: util.ReflectToInt(args[4]),
+		leftcol: util.ReflectToInt(args[5]),
+	}
+
+	s.windows.Range(func(_, winITF interface{}) bool {
+		win := winITF.(*Window)
+		if win == nil || int(win.id) != id {
+			return true
+		}
+		win.colorColumn = guide
+		win.update()
+		return false
+	})
+}
+
+// parseColorColumn returns the sorted columns of the 'colorcolumn' option value.
+// "+N" and "-N" are relative to 'textwidth', and are ignored if it is 0.
+func parseColorColumn(cc string, tw int) []int {
+	var columns []int
+	for _, item := range strings.Split(cc, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		relative := item[0] == '+' || item[0] == '-'
+		n, err := strconv.Atoi(item)
+		if err != nil {
+			continue
+		}
+		if relative {
+			if tw == 0 {
+				continue
+			}
+			n += tw
+		}
+		if n <= 0 {
+			continue
+		}
+		columns = append(columns, n)
+	}
+	sort.Ints(columns)
+
+	return columns
+}
+
+// drawColorColumn draws the guides of the colorcolumn in the row
+func (w *Window) drawColorColumn(p *gui.QPainter, y int) {
+	if w.colorColumn == nil || len(w.colorColumn.columns) == 0 {
+		return
+	}
+	if w.isMsgGrid || w.isFloatWin || w.grid == 1 {
+		return
+	}
+	font := w.getFont()
+	width := float64(w.cols) * font.truewidth
+	color := editor.colors.indentGuide
+	if editor.config.ColorColumn.Color != "" {
+		color = hexToRGBA(editor.config.ColorColumn.Color)
+	}
+	top := float64(y*font.lineHeight) + float64(w.scrollDust[1])
+
+	for _, column := range w.colorColumn.columns {
+		// The left edge of the cell of the column
+		col := column - 1 - w.colorColumn.leftcol
+		if col < 0 {
+			continue
+		}
+		x := float64(w.colorColumn.textoff+col) * font.truewidth
+		if x >= width {
+			continue
+		}
+		if editor.config.ColorColumn.Style == "shade" {
+			p.FillRect4(
+				core.NewQRectF4(x, top, width-x, float64(font.lineHeight)),
+				gui.NewQColor3(color.R, color.G, color.B, 24),
+			)
+			// The region beyond the first column is already shaded
+			return
+		}
+		p.FillRect4(
+			core.NewQRectF4(x, top, 1, float64(font.lineHeight)),
+			color.QColor(),
+		)
+	}
+}
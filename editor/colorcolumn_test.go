@@ -0,0 +1,27 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseColorColumn(t *testing.T) {
+	tests := []struct {
+		cc   string
+		tw   int
+		want []int
+	}{
+		{"", 0, nil},
+		{"80", 0, []int{80}},
+		{"120,80", 0, []int{80, 120}},
+		{"+1", 78, []int{79}},
+		{"+1,-2", 0, nil},
+		{"-2,100", 78, []int{76, 100}},
+		{"0,abc,81", 0, []int{81}},
+	}
+	for _, tt := range tests {
+		if got := parseColorColumn(tt.cc, tt.tw); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseColorColumn(%q, %d) = %v, want %v", tt.cc, tt.tw, got, tt.want)
+		}
+	}
+}
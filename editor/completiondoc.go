@@ -0,0 +1,188 @@
+package editor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shurcooL/github_flavored_markdown"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+// docSyntaxGroups are the highlight groups colorizing the classes of the
+// code blocks of github_flavored_markdown
+var docSyntaxGroups = map[string]string{
+	"com": "Comment",
+	"kwd": "Keyword",
+	"str": "String",
+	"lit": "Number",
+	"typ": "Type",
+	"pun": "Delimiter",
+	"dec": "PreProc",
+	"tag": "Tag",
+	"atn": "Identifier",
+	"atv": "String",
+}
+
+// docPanePos returns the position of the pane of the size beside the popup
+// menu of the rect in the screen of the size. The pane is put on the right
+// of the menu, on the left if it doesn't fit, and else under or over the
+// menu, at the top of the menu and within the screen.
+func docPanePos(menu [4]int, width, height, screenWidth, screenHeight int) (int, int) {
+	const gap = 4
+	x := menu[0] + menu[2] + gap
+	y := menu[1]
+	switch {
+	case x+width <= screenWidth:
+	case menu[0]-gap-width >= 0:
+		x = menu[0] - gap - width
+	default:
+		x = menu[0]
+		y = menu[1] + menu[3] + gap
+		if y+height > screenHeight {
+			y = menu[1] - gap - height
+		}
+	}
+	x = maxInt(0, minInt(x, screenWidth-width))
+	y = maxInt(0, minInt(y, screenHeight-height))
+
+	return x, y
+}
+
+// docStyle returns the style sheet of the html of the documentation, with
+// the classes of the code blocks colored by the colors of the classes
+func docStyle(fg string, colors map[string]string) string {
+	classes := make([]string, 0, len(colors))
+	for class := range colors {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("<style>body { color: %s; } pre { white-space: pre-wrap; }", fg))
+	for _, class := range classes {
+		b.WriteString(fmt.Sprintf(" .%s { color: %s; }", class, colors[class]))
+	}
+	b.WriteString("</style>")
+
+	return b.String()
+}
+
+// completionDoc shows the documentation of the selected completion item in a
+// pane beside the popup menu, rendered from markdown with the code blocks
+// highlighted by the colorscheme
+type completionDoc struct {
+	p       *PopupMenu
+	browser *widgets.QTextBrowser
+	info    string
+}
+
+func newCompletionDoc(p *PopupMenu) *completionDoc {
+	browser := widgets.NewQTextBrowser(p.widget.ParentWidget())
+	browser.SetObjectName("completiondoc")
+	browser.SetOpenExternalLinks(true)
+	browser.SetFocusPolicy(core.Qt__NoFocus)
+	browser.SetVerticalScrollBarPolicy(core.Qt__ScrollBarAsNeeded)
+	browser.SetHorizontalScrollBarPolicy(core.Qt__ScrollBarAlwaysOff)
+	browser.Hide()
+
+	return &completionDoc{
+		p:       p,
+		browser: browser,
+	}
+}
+
+// enabled returns true if the documentation is shown in the pane, in place
+// of the detail in the popup menu
+func (d *completionDoc) enabled() bool {
+	return editor.config.Popupmenu.DocPreview
+}
+
+// show shows the info of the selected item beside the popup menu
+func (d *completionDoc) show(info string) {
+	info = strings.TrimSpace(info)
+	if !d.enabled() || info == "" || !d.p.widget.IsVisible() {
+		d.hide()
+		return
+	}
+	if info != d.info {
+		d.info = info
+		d.browser.SetFont(d.p.ws.font.fontNew)
+		d.browser.SetHtml(d.html(info))
+	}
+	d.setColor()
+
+	width := editor.config.Popupmenu.DocWidth
+	doc := d.browser.Document()
+	doc.SetTextWidth(float64(width))
+	height := minInt(int(doc.Size().Height())+4, editor.config.Popupmenu.DocMaxHeight)
+	d.browser.SetFixedSize2(width, height)
+
+	// The menu and the pane are in the widget of the workspace
+	screen := d.p.widget.ParentWidget()
+	menu := d.p.widget.Geometry()
+	x, y := docPanePos(
+		[4]int{menu.X(), menu.Y(), menu.Width(), menu.Height()},
+		width, height,
+		screen.Width(), screen.Height(),
+	)
+	d.browser.Move2(x, y)
+	d.browser.Show()
+	d.browser.Raise()
+}
+
+func (d *completionDoc) hide() {
+	// The info is rendered again by the next show, in the colors of the
+	// colorscheme then
+	d.info = ""
+	d.browser.Hide()
+}
+
+// html returns the html of the markdown of the info
+func (d *completionDoc) html(info string) string {
+	fg := editor.colors.widgetFg
+	if fg == nil {
+		fg = editor.colors.fg
+	}
+	colors := make(map[string]string)
+	for class, group := range docSyntaxGroups {
+		if color := d.groupColor(group); color != nil {
+			colors[class] = color.String()
+		}
+	}
+	body := github_flavored_markdown.Markdown([]byte(info))
+
+	return docStyle(fg.String(), colors) + string(body)
+}
+
+// groupColor returns the foreground of the highlight group, which is defined
+// by the colorscheme
+func (d *completionDoc) groupColor(group string) *RGBA {
+	var color *RGBA
+	var id int
+	for k, hl := range d.p.ws.screen.hlAttrDef {
+		if hl == nil || hl.hlName != group {
+			continue
+		}
+		// The latest definition of the group
+		if color == nil || k > id {
+			color, id = hl.fg(), k
+		}
+	}
+
+	return color
+}
+
+func (d *completionDoc) setColor() {
+	c := d.p.colors
+	if c.fg == nil || c.bg == nil || c.thumb == nil {
+		return
+	}
+	d.browser.SetStyleSheet(fmt.Sprintf(
+		"QTextBrowser#completiondoc { color: %s; background-color: rgba(%d, %d, %d, %f); border: 1px solid %s; padding: 4px; }",
+		c.fg.String(),
+		c.bg.R, c.bg.G, c.bg.B, d.p.alpha,
+		c.thumb.String(),
+	))
+}
@@ -0,0 +1,39 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestDocPanePos(t *testing.T) {
+	tests := []struct {
+		name  string
+		menu  [4]int
+		w, h  int
+		wantX int
+		wantY int
+	}{
+		{"right", [4]int{100, 50, 200, 150}, 300, 100, 304, 50},
+		{"left", [4]int{600, 50, 200, 150}, 300, 100, 296, 50},
+		{"below", [4]int{200, 50, 600, 150}, 300, 100, 200, 204},
+		{"above", [4]int{200, 500, 600, 150}, 300, 100, 200, 396},
+		{"clamped to the bottom", [4]int{100, 550, 200, 150}, 300, 100, 304, 500},
+		{"clamped to the right", [4]int{700, 50, 300, 150}, 400, 100, 296, 50},
+	}
+	for _, tt := range tests {
+		x, y := docPanePos(tt.menu, tt.w, tt.h, 1000, 600)
+		if x != tt.wantX || y != tt.wantY {
+			t.Errorf("%s: docPanePos(%v, %d, %d) = (%d, %d), want (%d, %d)", tt.name, tt.menu, tt.w, tt.h, x, y, tt.wantX, tt.wantY)
+		}
+	}
+}
+
+func TestDocStyle(t *testing.T) {
+	got := docStyle("#ffffff", map[string]string{
+		"str": "#00ff00",
+		"com": "#888888",
+	})
+	want := "<style>body { color: #ffffff; } pre { white-space: pre-wrap; } .com { color: #888888; } .str { color: #00ff00; }</style>"
+	if got != want {
+		t.Errorf("docStyle() = %q, want %q", got, want)
+	}
+}
@@ -16,10 +16,112 @@ import (
 // fontFamily = "FuraCode Nerd Font Mono"
 // fontsize = 18
 // linespace = 10
+// # The space in pixels added to the cell width, which may be negative to
+// # tighten the text. :GonvimLetterSpace changes it at runtime
+// letterspacing = 0
+// # Extend the line height so that the glyphs of all the fonts in 'guifont'
+// # and 'guifontwide', and the tall fallback glyphs (CJK, emoji) fit
+// adaptiveLineHeight = false
 // clipboard = true
 // cursorBlink = true
 // indentGuide = true
+// # Draw the blockwise visual selection as a rectangle, including the virtual
+// # columns beyond the end of the shorter lines
+// blockSelectionOverlay = false
+// # Draw the text from the glyph cache. The glyphs of the ASCII set and of
+// # the visible chars in the highlights on the screen are cached ahead after
+// # the UI attaches and the colorscheme settles
 // cachedDrawing = false
+// # Draw the box drawing characters and the block elements to fit the cells
+// # exactly, instead of the glyphs of the font
+// builtinBoxDrawing = true
+// # The font of the color emoji. The default is the emoji font of the
+// # platform, e.g. "Noto Color Emoji" on Linux. "none" disables the fallback
+// # and draws the emoji by guifont.
+// emojiFont = ""
+// # The number of the glyphs cached for drawing the text, 0 means unlimited
+// cacheSize = 8192
+// # The memory in MB of the glyph cache. The cache takes 4 times the memory
+// # per glyph on HiDPI screens, and the least recently drawn glyphs are evicted
+// cacheMemory = 64
+// # Show the progress while loading the files larger than this size in MB,
+// # 0 disables it
+// fileLoadProgress = 16
+// # Ask what to do with the swap file of the file being edited (E325) in the
+// # dialog, which can also diff the recovered buffer against the file
+// swapDialog = true
+// # The key to zoom the current window to the whole tab and restore it,
+// # which is mapped unless it is already mapped. "" disables the mapping,
+// # and :GonvimZoom is still available
+// zoomKey = "<C-w>m"
+// # The key to move the keyboard focus to the GUI chrome, e.g. the tabs, the
+// # sidebar and the notifications. Tab moves the focus in the chrome, Enter
+// # or Space activates the focused widget, and Escape returns to the grid.
+// # "" disables the mapping, and :GonvimFocusGui is still available
+// focusGuiKey = "<F6>"
+// # "raster" or "opengl". The opengl renderer paints the windows on the GPU,
+// # and falls back to raster if OpenGL is not available
+// renderer = "raster"
+// # The antialiasing of the text, "default", "subpixel", "grayscale" or "none".
+// # The subpixel antialiasing falls back to grayscale in the transparent window
+// fontAntialias = "default"
+// # The hinting of the text, "default", "none", "vertical" or "full"
+// fontHinting = "default"
+// # Draw the text in a step lighter weight of the font, like the thin strokes
+// # of macOS. It has effect on the fonts with the lighter faces
+// fontThinStrokes = false
+// # The thickness of the underline, the undercurl and the strikethrough in
+// # the ratio to the default one, which scales with the font size. The lines
+// # are snapped to the device pixels, at least a device pixel thick
+// underlineThickness = 1.0
+// # The offset of the underline and the undercurl from the default position
+// # in the ratio to the font height, positive moves them down
+// underlineOffset = 0.0
+// # "curl", "dots" or "dashes"
+// undercurlStyle = "curl"
+// # The ranges of the chars whose glyphs are drawn overflowing into the next
+// # cell when it is blank, instead of clipped to the cell, e.g. the powerline
+// # separators and the icons of the Nerd Fonts designed wider than the cell.
+// # e.g. ["U+E0A0-U+E0D7", "U+E200-U+E2A9", "U+F000-U+F2E0"]
+// glyphOverflow = []
+// # Step the animations and blink the cursor in time with the refresh rate of
+// # the display, instead of the fixed 60fps
+// vsyncAnimation = false
+// # The highest rate of the repaints. The redraws which arrive within a frame
+// # are painted at once, which saves the CPU in the floods of the input.
+// # 0 is unlimited. With vsyncAnimation, the frames are snapped to the
+// # refresh period of the display
+// maxFPS = 0
+// # Shape the runs of the right-to-left text, e.g. Arabic and Hebrew, with
+// # the joining and the direction of the script. The cells keep the logical
+// # order of nvim for the cursor. :GonvimBidi toggles it per buffer, and
+// # b:gonvim_bidi sets it
+// bidi = false
+// # Pop up the diff of the hunk on the click of its sign of gitsigns,
+// # gitgutter or signify, or on the pointer resting on it, with the buttons
+// # to stage, unstage or revert it
+// gitHunkPopup = false
+// # The width of the East Asian ambiguous-width chars, e.g. "○" and "※",
+// # "single" or "double". It sets 'ambiwidth' on startup, and the chars are
+// # measured by 'ambiwidth' rather than by the font, so that the glyphs
+// # neither overlap nor are clipped when the font disagrees with it. ""
+// # keeps 'ambiwidth' of the config of nvim
+// ambiWidth = ""
+// # The pastes of the lines of this number or more, by <D-v>, <S-Insert>,
+// # :GonvimPaste or the input method, are sent by nvim_paste in the chunks
+// # of pasteChunkLines, with the progress and the button to cancel it, so
+// # that the GUI keeps redrawing. 0 disables it
+// largePasteLines = 1000
+// pasteChunkLines = 1000
+// # While the GUI is resized, draw the windows of the prose filetypes of
+// # [statusline] with 'wrap' wrapped at their new width, and send the size
+// # to nvim when the resize settles
+// reflowPreview = true
+// # The profile for the older machines and the VMs. The glyph cache is
+// # shrunk, the image caches, the minimap, the animations and the shadows are
+// # disabled, all the windows are drawn in a single canvas, and the repaints
+// # are limited to 30fps
+// lowMemory = false
 // disableIMEinNormal = true
 // startFullScreen = true
 // transparent = 0.5
@@ -47,11 +149,33 @@ import (
 // diffchangepattern = 12
 // diffaddpattern = 1
 // SkipGlobalId = true
+// # Shrink float windows which exceed the workspace size
+// autoShrinkFloatWindow = false
+// # QSS stylesheet for the GUI chrome; reloaded on change (default: ~/.goneovim/style.qss)
+// # Selectors: #tabline, #tab, #palette, #notification, #sidebar, #scrollbar, #scrollbarthumb
+// styleSheet = "~/.goneovim/style.qss"
+// # Directory of the SVG icons which replace the built-in ones by name, e.g.
+// # go.svg, lsp_function.svg; "currentColor" is the color of the icon.
+// # Reloaded on change (default: ~/.goneovim/icons)
+// iconTheme = "~/.goneovim/icons"
 //
 // [palette]
 // AreaRatio = 0.8
 // MaxNumberOfResultItems = 40
 //
+// [message]
+// # Maximum height of the message area as a fraction of the screen
+// maxHeightRatio = 0.5
+// # With extMessages of [editor], show the errors, the warnings, the
+// # "Press ENTER" prompts and the messages of multiple lines or longer than
+// # shortLength as the notifications, and the other messages in the message
+// # area
+// notify = true
+// shortLength = 80
+// # With extMessages of [editor], show the history of :messages in the panel
+// # which can be searched, copied and cleared
+// historyPanel = true
+//
 // [statusLine]
 // visible = true
 // # textLabel / icon / background / none
@@ -63,14 +187,42 @@ import (
 // visualModeColor = "#123456"
 // termnalModeColor = "#123456"
 // left = [ "mode", "filepath", "filename" ]
-// right = [ "message", "git", "filetype", "fileformat", "fileencoding", "curpos", "lint" ]
+// right = [ "message", "git", "filetype", "fileformat", "fileencoding", "wordcount", "curpos", "lint" ]
+// # Filetypes to show the word count ("wordcount") for
+// proseFiletypes = [ "markdown", "text", "tex", "rst", "asciidoc", "org", "mail", "gitcommit" ]
 //
 // [tabline]
 // visible = true
+// # Show the thumbnail of the layout of the tabpage on hovering the tab
+// preview = true
+//
+// [navigation]
+// # Show the back/forward buttons of the jumplist in the tabline
+// visible = true
 //
 // [Popupmenu]
 // showSetail = false
+// # The maximum number of the visible items. The menu is put under the cursor
+// # line if they fit, else over it, else on the side with more room with a
+// # scrollbar, and is clamped within the workspace
 // total = 20
+// # The icon set of the completion kinds: "svg", "nerdfont" or "emoji".
+// # "nerdfont" requires a Nerd Font as the guifont.
+// kindIcons = "svg"
+// # Color the items, the selected item, the menu and the info columns and
+// # the scrollbar by the Pmenu, PmenuSel, PmenuExtra, PmenuExtraSel and
+// # PmenuThumb highlight groups of the colorscheme, and the track of the
+// # scrollbar by PmenuSbar
+// followPmenu = true
+// # Show the documentation of the selected item beside the menu, rendered
+// # from markdown, in place of the detail in the menu
+// docPreview = true
+// docWidth = 420
+// docMaxHeight = 300
+// # Overrides the icon and the color of the kinds, e.g.
+// [Popupmenu.kinds.Function]
+// icon = "ƒ"
+// color = "#c678dd"
 //
 // [lint]
 // visible = true
@@ -104,23 +256,306 @@ import (
 // # restore the previous sessions if there are exists.
 // restoreSession = false
 //
+// # restore the scroll position of the file on reopen.
+// # g:gonvim_restore_viewport overrides this setting per project.
+// restoreViewport = false
+//
+// # Command to open a terminal by :GonvimTerminalHere, which runs in the
+// # directory of the file. If it is empty, the terminal of the OS is opened.
+// terminal = "kitty"
+//
+// [sharing]
+// # Settings of the sharing mode for screen sharing (:GonvimSharingMode)
+// fontScale = 1.5
+// visualFg = "#000000"
+// visualBg = "#ffd700"
+//
+// [theme]
+// # Colorschemes for the light and dark themes
+// light = "morning"
+// dark = "evening"
+// # sun: switch at the local sunrise/sunset
+// # time: switch at the fixed times
+// schedule = "sun"
+// latitude = 35.68
+// longitude = 139.76
+// lightTime = "07:00"
+// darkTime = "19:00"
+//
+// [touchBar]
+// # Show the on-screen command bar when a touch screen is detected
+// visible = true
+// # Always show the on-screen command bar
+// force = false
+// [[touchBar.buttons]]
+// label = "Undo"
+// keys = "<Esc>u"
+//
+// [dictation]
+// # Show the push-to-talk button
+// visible = true
+// # Speech recognizer which prints the recognized text to stdout line by line.
+// # If it is empty, the OS dictation through the input method is used.
+// command = ""
+// [dictation.punctuation]
+// "period" = "."
+// "new line" = "\n"
+//
+// [cheatsheet]
+// # Show the mappings after a prefix key is typed and no more keys are typed
+// # for the time in msec, e.g. 500. 0 disables it; :GonvimCheatsheet still
+// # works.
+// timeout = 0
+//
+// [helpReader]
+// # Render the :help buffers in a reader pane over the window of the buffer,
+// # which keeps the keyboard, with the headings in the proportional font, the
+// # clickable tags and links, and the code blocks in monospace.
+// # :GonvimHelpReader toggles it.
+// enable = false
+// # The font of the text of the pane, "" is the sans-serif font
+// fontFamily = ""
+//
+// [readingMode]
+// # :GonvimReadingMode toggles the reading mode of the markdown and the
+// # prose buffers, which renders the buffer in the proportional font with
+// # the sized headings and the markup concealed in a read-only pane. The
+// # pane covers a window opened beside the buffer ("side") or the window of
+// # the buffer ("replace"), and follows the cursor line.
+// view = "side"
+// # The font of the text of the pane, "" is the sans-serif font
+// fontFamily = ""
+//
+// [colorColumn]
+// # Draw 'colorcolumn' as a 1px line ("line") or shade the region beyond it
+// # ("shade") over the cells. The default is "", which draws no guides. The
+// # ColorColumn highlight is left as is; "highlight ColorColumn NONE" in
+// # init.vim leaves only the guides.
+// style = ""
+// color = "#3c3c3c"
+// # 'colorcolumn' for the filetypes, which takes precedence over the option
+// [colorColumn.filetypes]
+// go = "100"
+// gitcommit = "51,73"
+//
+// [whitespace]
+// # Tint the trailing whitespace and put the dot in the gutter of the lines
+// # whose indentation mixes the tabs and the spaces, which nvim does not see.
+// # The trailing whitespace of the line being typed is not tinted.
+// enable = false
+// trailing = true
+// mixedIndent = true
+// color = "#ff0000"
+// # The filetypes which are not marked, e.g. markdown uses the trailing
+// # spaces for the line breaks
+// disabledFiletypes = ["markdown", "diff", "mail", "help"]
+//
+// [searchIndex]
+// # Index the files and the symbols of the working directory in the
+// # background. The file finder takes the files from the index instead of
+// # walking the directory, and :GonvimIndexFiles and :GonvimIndexSymbols
+// # search it. The index follows the files written in nvim, and the
+// # directories changed outside it when the GUI gets the focus.
+// # :GonvimIndexRebuild builds it again, and :GonvimIndexStatus shows it.
+// enable = false
+// # The names or the paths relative to the working directory of the
+// # directories which are not indexed, in addition to .gitignore
+// exclude = [".git", ".hg", ".svn", "node_modules"]
+// # The command of ctags for the symbols, "" disables it
+// ctags = "ctags"
+// # Add the workspace symbols of the language servers
+// lsp = true
+// # The files over the limits are not indexed. maxMemory is in MB.
+// maxFiles = 200000
+// maxMemory = 256
+//
+// [readOnly]
+// # Show the padlock in the tab of the read-only or unmodifiable buffer
+// badge = true
+// # Tint the background of the window of such buffer. Empty disables it.
+// tint = "#ff0000"
+// # Keep the cursor solid in such buffer
+// stopBlink = true
+//
+// [watermark]
+// # Draw a faint watermark in the windows of the empty buffers, as a built-in
+// # alternative to the dashboard plugins. It is hidden as soon as any text
+// # is in the buffer
+// enable = false
+// # The logo image, e.g. a png file
+// image = ""
+// # The text under the logo. "{project}" is the name of the current directory
+// text = "{project}"
+// # The lines under the text, e.g. the key bindings
+// hints = [":e {file}  Open a file", ":GonvimCheatsheet  Show the mappings"]
+// opacity = 0.15
+//
+// [spell]
+// # The decoration of the spell errors of each kind, "curl", "dots", "dashes",
+// # "underline", "double" or "none". "" keeps the one of the colorscheme
+// bad = "curl"
+// cap = "dashes"
+// rare = "dots"
+// local = "dots"
+// # The colors of the decorations, e.g. "#ff0000". "" keeps the guisp of the
+// # colorscheme
+// badColor = ""
+// capColor = ""
+// rareColor = ""
+// localColor = ""
+// # Pop up the suggestions when the pointer rests on a misspelled word
+// hover = false
+//
+// [statusColumn]
+// # Lay out the number, the sign and the fold columns by 'statuscolumn' in
+// # the order of the layout, each followed by the separator. The columns are
+// # shown as 'number', 'signcolumn' and 'foldcolumn' show them, and the width
+// # of the number column is at least numberWidth. The separator of the
+// # empty column is left out. The columns are set in the normal windows only
+// enable = false
+// layout = ["fold", "sign", "number"]
+// numberWidth = 3
+// separator = " "
+// # The numbers are drawn by the GUI in the number column, "right", "left" or
+// # "center"
+// numberAlign = "right"
+// # The Ex commands run on the left click on the columns, with the cursor on
+// # the clicked line. "{lnum}" is the line, e.g.
+// # numberClick = "lua require('dap').toggle_breakpoint()"
+// numberClick = ""
+// signClick = ""
+// foldClick = "silent! normal! za"
+// # Draw the chevrons of the open and the closed folds in the fold column in
+// # place of the chars of 'fillchars'
+// foldIcons = true
+//
+// [resourceMonitor]
+// # :GonvimResourceMonitor toggles the monitor of the CPU and the memory of
+// # nvim, the memory of the GUI and its glyph cache, and the notifications
+// # from nvim per second. The interval of the samples in ms
+// interval = 1000
+//
+// [reconnect]
+// # Reconnect to the server of the remote attachment (--server, also over
+// # ssh by --ssh) when the connection drops, waiting 0.5s doubling up to 30s
+// # between the attempts
+// enable = true
+// maxAttempts = 10
+// # The keys typed within this time in ms after the drop are sent after the
+// # reconnection, and the later ones are dropped
+// inputBuffer = 3000
+// # The interval in ms of the heartbeat, which takes the connection that
+// # doesn't answer in the interval for a dropped one. 0 disables it
+// heartbeat = 5000
+//
+// [localEcho]
+// # Draw the chars typed in the insert mode of the remote attachment before
+// # nvim echoes them, dimmed and underlined until the grid confirms them.
+// # It works while the round trip time to nvim is over the threshold in ms
+// enable = false
+// threshold = 50
+//
+// # Profiles by display. The first profile which matches the display the
+// # window is on is applied, and is switched when the window moves to
+// # another display. screen matches the name or the model of the display,
+// # and pixelRatio matches its device pixel ratio; empty or 0 matches any.
+// # fontSize overrides 'guifont' size, linespace overrides 'linespace', and
+// # uiScale scales the fonts of the UI other than the grid.
+// [[displayProfiles]]
+// pixelRatio = 2.0
+// fontSize = 14
+// linespace = 6
+// [[displayProfiles]]
+// screen = "DELL"
+// fontSize = 11
+// linespace = 2
+// uiScale = 0.9
+//
+// [follow]
+// # Lua expression of the function which returns the line to scroll the
+// # window of :GonvimFollow to. It is called with the table of win, buf,
+// # line and col of the cursor, and follow_win. If it is empty, the window
+// # follows the same line.
+// callback = "require('docs').section"
+// # Wait for the cursor to settle for the time in msec
+// delay = 150
+// # Duration of the scroll animation of the window in msec
+// duration = 200
+//
+// [horizontalScroll]
+// # Draw the fades on the edges and the thin scrollbar of the 'nowrap'
+// # window whose lines are truncated
+// visible = true
+//
+// [smoothScroll]
+// # Animate the scroll of the windows by the pixels
+// enable = false
+// # Duration of the animation in msec
+// duration = 150
+// # "linear", "outQuad", "outCubic" or "outExpo"
+// easing = "outCubic"
+//
+// [windowAnimation]
+// # Animate the layout changes of the windows: the new split slides open,
+// # the closed one collapses and the moved ones slide to their places. It is
+// # disabled if the system asks to reduce the motion.
+// enable = false
+// # Duration of the animation in msec
+// duration = 100
+//
+// [dropGuard]
+// # The policy of the files dropped from the untrusted locations, i.e. the
+// # downloads, the network shares and untrustedPaths, or which have the
+// # modelines or are the exrc files. "prompt" asks whether to open them with
+// # 'nomodeline' and 'secure', "safe" always opens them so, and "off" opens
+// # them as the other files.
+// policy = "prompt"
+// # The directories whose files are untrusted or trusted, which take
+// # precedence over the locations above
+// untrustedPaths = []
+// trustedPaths = []
+//
 // [dein]
 // tomlFile
 type gonvimConfig struct {
-	Editor      editorConfig
-	Palette     paletteConfig
-	Message     messageConfig
-	Statusline  statusLineConfig
-	Tabline     tabLineConfig
-	Lint        lintConfig
-	Popupmenu   popupMenuConfig
-	ScrollBar   scrollBarConfig
-	ActivityBar activityBarConfig
-	MiniMap     miniMapConfig
-	SideBar     sideBarConfig
-	Workspace   workspaceConfig
-	FileExplore fileExploreConfig
-	Dein        deinConfig
+	Editor           editorConfig
+	Palette          paletteConfig
+	Message          messageConfig
+	Statusline       statusLineConfig
+	Tabline          tabLineConfig
+	Navigation       navigationConfig
+	Lint             lintConfig
+	Popupmenu        popupMenuConfig
+	ScrollBar        scrollBarConfig
+	ActivityBar      activityBarConfig
+	MiniMap          miniMapConfig
+	SideBar          sideBarConfig
+	Workspace        workspaceConfig
+	FileExplore      fileExploreConfig
+	Sharing          sharingConfig
+	Theme            themeConfig
+	TouchBar         touchBarConfig
+	Dictation        dictationConfig
+	Cheatsheet       cheatsheetConfig
+	HelpReader       helpReaderConfig
+	ReadingMode      readingModeConfig
+	ColorColumn      colorColumnConfig
+	Whitespace       whitespaceConfig
+	ReadOnly         readOnlyConfig
+	Watermark        watermarkConfig
+	Reconnect        reconnectConfig
+	LocalEcho        localEchoConfig
+	Spell            spellConfig
+	StatusColumn     statusColumnConfig
+	ResourceMonitor  resourceMonitorConfig
+	DisplayProfiles  []displayProfileConfig
+	Follow           followConfig
+	HorizontalScroll horizontalScrollConfig
+	SmoothScroll     smoothScrollConfig
+	WindowAnimation  windowAnimationConfig
+	Dein             deinConfig
+	SearchIndex      searchIndexConfig
+	DropGuard        dropGuardConfig
 }
 
 type editorConfig struct {
@@ -129,13 +564,39 @@ type editorConfig struct {
 	FontFamily               string
 	FontSize                 int
 	Linespace                int
+	Letterspacing            float64
+	AdaptiveLineHeight       bool
 	ExtCmdline               bool
 	ExtPopupmenu             bool
 	ExtTabline               bool
 	ExtMessages              bool
 	Clipboard                bool
 	CachedDrawing            bool
+	BuiltinBoxDrawing        bool
+	EmojiFont                string
 	CacheSize                int
+	CacheMemory              int
+	Renderer                 string
+	VsyncAnimation           bool
+	LowMemory                bool
+	MaxFPS                   int
+	Bidi                     bool
+	GitHunkPopup             bool
+	AmbiWidth                string
+	LargePasteLines          int
+	PasteChunkLines          int
+	ReflowPreview            bool
+	FontAntialias            string
+	FontHinting              string
+	FontThinStrokes          bool
+	UnderlineThickness       float64
+	UnderlineOffset          float64
+	UndercurlStyle           string
+	GlyphOverflow            []string
+	FileLoadProgress         int
+	SwapDialog               bool
+	ZoomKey                  string
+	FocusGuiKey              string
 	DisableImeInNormal       bool
 	GinitVim                 string
 	StartFullscreen          bool
@@ -144,8 +605,12 @@ type editorConfig struct {
 	DrawBorder               bool
 	SkipGlobalId             bool
 	IndentGuide              bool
+	BlockSelectionOverlay    bool
 	DrawBorderForFloatWindow bool
 	DrawShadowForFloatWindow bool
+	AutoShrinkFloatWindow    bool
+	StyleSheet               string
+	IconTheme                string
 	DesktopNotifications     bool
 	DiffAddPattern           int
 	DiffDeletePattern        int
@@ -162,7 +627,11 @@ type paletteConfig struct {
 }
 
 type messageConfig struct {
-	Transparent float64
+	Transparent    float64
+	MaxHeightRatio float64
+	Notify         bool
+	ShortLength    int
+	HistoryPanel   bool
 }
 
 type statusLineConfig struct {
@@ -176,18 +645,36 @@ type statusLineConfig struct {
 	TerminalModeColor string
 	Left              []string
 	Right             []string
+	ProseFiletypes    []string
 }
 
 type tabLineConfig struct {
 	Visible bool
+	Preview bool
+}
+
+type navigationConfig struct {
+	Visible bool
 }
 
 type popupMenuConfig struct {
-	ShowDetail  bool
-	Total       int
-	MenuWidth   int
-	InfoWidth   int
-	DetailWidth int
+	ShowDetail   bool
+	Total        int
+	MenuWidth    int
+	InfoWidth    int
+	DetailWidth  int
+	KindIcons    string
+	FollowPmenu  bool
+	DocPreview   bool
+	DocWidth     int
+	DocMaxHeight int
+	Kinds        map[string]completionKindConfig
+}
+
+// completionKindConfig is the icon and the color of a completion kind
+type completionKindConfig struct {
+	Icon  string
+	Color string
 }
 
 type lintConfig struct {
@@ -217,8 +704,10 @@ type sideBarConfig struct {
 }
 
 type workspaceConfig struct {
-	RestoreSession bool
-	PathStyle      string
+	RestoreSession  bool
+	RestoreViewport bool
+	PathStyle       string
+	Terminal        string
 }
 
 type fileExploreConfig struct {
@@ -226,10 +715,164 @@ type fileExploreConfig struct {
 	MaxDisplayItems int
 }
 
+type sharingConfig struct {
+	FontScale float64
+	VisualFg  string
+	VisualBg  string
+}
+
+type themeConfig struct {
+	Light     string
+	Dark      string
+	Schedule  string
+	Latitude  float64
+	Longitude float64
+	LightTime string
+	DarkTime  string
+}
+
+type touchBarConfig struct {
+	Visible bool
+	Force   bool
+	Buttons []touchBarButton
+}
+
+type dictationConfig struct {
+	Visible     bool
+	Command     string
+	Punctuation map[string]string
+}
+
+type cheatsheetConfig struct {
+	Timeout int
+}
+
+type helpReaderConfig struct {
+	Enable     bool
+	FontFamily string
+}
+
+type readingModeConfig struct {
+	View       string
+	FontFamily string
+}
+
+type colorColumnConfig struct {
+	Style     string
+	Color     string
+	Filetypes map[string]string
+}
+
+type whitespaceConfig struct {
+	Enable            bool
+	Trailing          bool
+	MixedIndent       bool
+	Color             string
+	DisabledFiletypes []string
+}
+
+type watermarkConfig struct {
+	Enable  bool
+	Image   string
+	Text    string
+	Hints   []string
+	Opacity float64
+}
+
+type spellConfig struct {
+	Bad        string
+	Cap        string
+	Rare       string
+	Local      string
+	BadColor   string
+	CapColor   string
+	RareColor  string
+	LocalColor string
+	Hover      bool
+}
+
+type statusColumnConfig struct {
+	Enable      bool
+	Layout      []string
+	NumberWidth int
+	NumberAlign string
+	Separator   string
+	NumberClick string
+	SignClick   string
+	FoldClick   string
+	FoldIcons   bool
+}
+
+type resourceMonitorConfig struct {
+	Interval int
+}
+
+type reconnectConfig struct {
+	Enable      bool
+	MaxAttempts int
+	InputBuffer int
+	Heartbeat   int
+}
+
+type localEchoConfig struct {
+	Enable    bool
+	Threshold int
+}
+
+type readOnlyConfig struct {
+	Badge     bool
+	Tint      string
+	StopBlink bool
+}
+
+type displayProfileConfig struct {
+	Screen     string
+	PixelRatio float64
+	FontSize   float64
+	Linespace  int
+	UIScale    float64
+}
+
+type followConfig struct {
+	Callback string
+	Delay    int
+	Duration int
+}
+
+type horizontalScrollConfig struct {
+	Visible bool
+}
+
+type smoothScrollConfig struct {
+	Enable   bool
+	Duration int
+	Easing   string
+}
+
+type windowAnimationConfig struct {
+	Enable   bool
+	Duration int
+}
+
+type dropGuardConfig struct {
+	Policy         string
+	UntrustedPaths []string
+	TrustedPaths   []string
+}
+
 type deinConfig struct {
 	TomlFile string
 }
 
+type searchIndexConfig struct {
+	Enable    bool
+	Exclude   []string
+	Ctags     string
+	Lsp       bool
+	MaxFiles  int
+	MaxMemory int
+}
+
 func newGonvimConfig(home string) gonvimConfig {
 	var config gonvimConfig
 
@@ -264,6 +907,108 @@ func newGonvimConfig(home string) gonvimConfig {
 	if config.Editor.Transparent <= 0.1 {
 		config.Editor.Transparent = 1.0
 	}
+	switch config.ReadingMode.View {
+	case "side", "replace":
+	default:
+		config.ReadingMode.View = "side"
+	}
+	switch config.DropGuard.Policy {
+	case "prompt", "safe", "off":
+	default:
+		config.DropGuard.Policy = "prompt"
+	}
+	if config.Message.ShortLength < 1 {
+		config.Message.ShortLength = 80
+	}
+	if config.SearchIndex.MaxFiles < 1 {
+		config.SearchIndex.MaxFiles = 200000
+	}
+	if config.SearchIndex.MaxMemory < 1 {
+		config.SearchIndex.MaxMemory = 256
+	}
+	if config.Popupmenu.Total < 1 {
+		config.Popupmenu.Total = 1
+	}
+	if config.Popupmenu.DocWidth < 100 {
+		config.Popupmenu.DocWidth = 100
+	}
+	if config.Popupmenu.DocMaxHeight < 50 {
+		config.Popupmenu.DocMaxHeight = 50
+	}
+	switch config.Popupmenu.KindIcons {
+	case "svg", "nerdfont", "emoji":
+	default:
+		config.Popupmenu.KindIcons = "svg"
+	}
+	// The kinds are looked up by the normalized names
+	kinds := make(map[string]completionKindConfig, len(config.Popupmenu.Kinds))
+	for kind, k := range config.Popupmenu.Kinds {
+		kinds[normalizeKind(kind)] = k
+	}
+	config.Popupmenu.Kinds = kinds
+	config.applyLowMemory()
+	if config.Editor.CacheSize < 0 {
+		config.Editor.CacheSize = 0
+	}
+	if config.Editor.CacheMemory < 1 {
+		config.Editor.CacheMemory = 1
+	}
+	if config.Editor.FileLoadProgress < 0 {
+		config.Editor.FileLoadProgress = 0
+	}
+	switch config.Editor.Renderer {
+	case "raster", "opengl":
+	default:
+		config.Editor.Renderer = "raster"
+	}
+	switch config.Editor.FontAntialias {
+	case "default", "subpixel", "grayscale", "none":
+	default:
+		config.Editor.FontAntialias = "default"
+	}
+	switch config.Editor.FontHinting {
+	case "default", "none", "vertical", "full":
+	default:
+		config.Editor.FontHinting = "default"
+	}
+	if config.Editor.UnderlineThickness <= 0 {
+		config.Editor.UnderlineThickness = 1.0
+	}
+	switch config.Editor.AmbiWidth {
+	case "", "single", "double":
+	default:
+		config.Editor.AmbiWidth = ""
+	}
+	if config.Editor.LargePasteLines < 0 {
+		config.Editor.LargePasteLines = 0
+	}
+	if config.Editor.PasteChunkLines < 1 {
+		config.Editor.PasteChunkLines = 1000
+	}
+	glyphOverflowRanges = parseRuneRanges(config.Editor.GlyphOverflow)
+	switch config.Editor.UndercurlStyle {
+	case "curl", "dots", "dashes":
+	default:
+		config.Editor.UndercurlStyle = "curl"
+	}
+	for _, style := range []*string{&config.Spell.Bad, &config.Spell.Cap, &config.Spell.Rare, &config.Spell.Local} {
+		switch *style {
+		case "", "none", "curl", "dots", "dashes", "underline", "double":
+		default:
+			*style = ""
+		}
+	}
+	if config.Follow.Delay < 0 {
+		config.Follow.Delay = 0
+	}
+	if config.SmoothScroll.Duration < 0 {
+		config.SmoothScroll.Duration = 0
+	}
+	switch config.ColorColumn.Style {
+	case "", "line", "shade":
+	default:
+		config.ColorColumn.Style = "line"
+	}
 	if config.Statusline.ModeIndicatorType == "" {
 		config.Statusline.ModeIndicatorType = "textLabel"
 	}
@@ -306,6 +1051,14 @@ func newGonvimConfig(home string) gonvimConfig {
 		config.Workspace.PathStyle = "minimum"
 	}
 
+	if config.Message.MaxHeightRatio <= 0.0 || config.Message.MaxHeightRatio > 1.0 {
+		config.Message.MaxHeightRatio = 0.5
+	}
+
+	if config.Sharing.FontScale <= 0 {
+		config.Sharing.FontScale = 1.5
+	}
+
 	if config.MiniMap.Width == 0 || config.MiniMap.Width >= 250 {
 		config.MiniMap.Width = 120
 	}
@@ -321,7 +1074,18 @@ func (c *gonvimConfig) init() {
 
 	c.Editor.SkipGlobalId = false
 	c.Editor.CachedDrawing = true
-	c.Editor.CacheSize = 320
+	c.Editor.BuiltinBoxDrawing = true
+	c.Editor.CacheSize = 8192
+	c.Editor.CacheMemory = 64
+	c.Editor.Renderer = "raster"
+	c.Editor.FontAntialias = "default"
+	c.Editor.FontHinting = "default"
+	c.Editor.UnderlineThickness = 1.0
+	c.Editor.UndercurlStyle = "curl"
+	c.Editor.FileLoadProgress = 16
+	c.Editor.SwapDialog = true
+	c.Editor.ZoomKey = "<C-w>m"
+	c.Editor.FocusGuiKey = "<F6>"
 
 	c.Editor.ExtCmdline = true
 	c.Editor.ExtPopupmenu = false
@@ -334,6 +1098,12 @@ func (c *gonvimConfig) init() {
 	// Indent guide
 	c.Editor.IndentGuide = true
 
+	// Hunk popup on the click of the git signs
+	c.Editor.GitHunkPopup = false
+	c.Editor.LargePasteLines = 1000
+	c.Editor.PasteChunkLines = 1000
+	c.Editor.ReflowPreview = true
+
 	// replace diff color drawing pattern
 	c.Editor.DiffAddPattern = 12
 	c.Editor.DiffDeletePattern = 12
@@ -344,13 +1114,21 @@ func (c *gonvimConfig) init() {
 	c.Palette.Transparent = 1.0
 
 	c.Message.Transparent = 1.0
+	c.Message.MaxHeightRatio = 0.5
+	c.Message.Notify = true
+	c.Message.ShortLength = 80
+	c.Message.HistoryPanel = true
 
 	c.Statusline.Visible = false
 	c.Statusline.ModeIndicatorType = "textLabel"
 	c.Statusline.Left = []string{"mode", "filename"}
-	c.Statusline.Right = []string{"git", "filetype", "fileformat", "fileencoding", "curpos", "lint"}
+	c.Statusline.Right = []string{"git", "filetype", "fileformat", "fileencoding", "wordcount", "curpos", "lint"}
+	c.Statusline.ProseFiletypes = []string{"markdown", "text", "tex", "rst", "asciidoc", "org", "mail", "gitcommit"}
 
 	c.Tabline.Visible = true
+	c.Tabline.Preview = true
+
+	c.Navigation.Visible = true
 
 	c.Lint.Visible = true
 
@@ -359,6 +1137,11 @@ func (c *gonvimConfig) init() {
 	c.Popupmenu.MenuWidth = 400
 	c.Popupmenu.InfoWidth = 1
 	c.Popupmenu.DetailWidth = 250
+	c.Popupmenu.KindIcons = "svg"
+	c.Popupmenu.FollowPmenu = true
+	c.Popupmenu.DocPreview = true
+	c.Popupmenu.DocWidth = 420
+	c.Popupmenu.DocMaxHeight = 300
 
 	// c.ActivityBar.Visible = true
 
@@ -372,4 +1155,80 @@ func (c *gonvimConfig) init() {
 	c.FileExplore.MaxDisplayItems = 30
 
 	c.Workspace.PathStyle = "minimum"
+
+	c.Sharing.FontScale = 1.5
+	c.Sharing.VisualFg = "#000000"
+	c.Sharing.VisualBg = "#ffd700"
+
+	c.Theme.LightTime = "07:00"
+	c.Theme.DarkTime = "19:00"
+
+	c.TouchBar.Visible = true
+
+	c.Cheatsheet.Timeout = 0
+
+	c.ReadingMode.View = "side"
+
+	c.Whitespace.Trailing = true
+	c.Whitespace.MixedIndent = true
+	c.Whitespace.Color = "#ff0000"
+	c.Whitespace.DisabledFiletypes = []string{"markdown", "diff", "mail", "help"}
+
+	c.SearchIndex.Exclude = []string{".git", ".hg", ".svn", "node_modules"}
+	c.SearchIndex.Ctags = "ctags"
+	c.SearchIndex.Lsp = true
+	c.SearchIndex.MaxFiles = 200000
+	c.SearchIndex.MaxMemory = 256
+
+	c.ReadOnly.Badge = true
+	c.ReadOnly.Tint = "#ff0000"
+	c.ReadOnly.StopBlink = true
+
+	c.Watermark.Text = "{project}"
+	c.Watermark.Opacity = 0.15
+
+	c.Spell.Bad = "curl"
+	c.Spell.Cap = "dashes"
+	c.Spell.Rare = "dots"
+	c.Spell.Local = "dots"
+
+	c.StatusColumn.Layout = []string{"fold", "sign", "number"}
+	c.StatusColumn.NumberWidth = 3
+	c.StatusColumn.NumberAlign = "right"
+	c.StatusColumn.Separator = " "
+	c.StatusColumn.FoldClick = "silent! normal! za"
+	c.StatusColumn.FoldIcons = true
+
+	c.ResourceMonitor.Interval = 1000
+
+	c.Reconnect.Enable = true
+	c.Reconnect.MaxAttempts = 10
+	c.Reconnect.InputBuffer = 3000
+	c.Reconnect.Heartbeat = 5000
+
+	c.LocalEcho.Threshold = 50
+
+	c.Follow.Delay = 150
+	c.Follow.Duration = 200
+
+	c.HorizontalScroll.Visible = true
+
+	c.SmoothScroll.Duration = 150
+	c.SmoothScroll.Easing = "outCubic"
+
+	c.WindowAnimation.Duration = 100
+
+	c.DropGuard.Policy = "prompt"
+
+	c.Dictation.Punctuation = map[string]string{
+		"period":           ".",
+		"full stop":        ".",
+		"comma":            ",",
+		"colon":            ":",
+		"semicolon":        ";",
+		"question mark":    "?",
+		"exclamation mark": "!",
+		"new line":         "\n",
+		"new paragraph":    "\n\n",
+	}
 }
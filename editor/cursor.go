@@ -1,9 +1,6 @@
 package editor
 
 import (
-	"math"
-
-	"github.com/akiyosi/goneovim/util"
 	"github.com/therecipe/qt/core"
 	"github.com/therecipe/qt/gui"
 	"github.com/therecipe/qt/widgets"
@@ -39,6 +36,12 @@ type Cursor struct {
 	blinkWait            int
 	blinkOn              int
 	blinkOff             int
+
+	// cellHighlight is the highlight of the cell under the cursor
+	cellHighlight Highlight
+
+	// readOnly is true if the buffer of the current window is locked
+	readOnly bool
 }
 
 func initCursorNew() *Cursor {
@@ -92,6 +95,9 @@ func (c *Cursor) paint(event *gui.QPaintEvent) {
 		rectF,
 		c.bg.brend(c.ws.background, c.brend).QColor(),
 	)
+	if !c.isTextDraw {
+		return
+	}
 	p.DrawText(
 		core.NewQPointF3(
 			0,
@@ -101,13 +107,25 @@ func (c *Cursor) paint(event *gui.QPaintEvent) {
 	)
 }
 
+// isBlinkDisabled reports whether the cursor is kept solid. The cursor
+// doesn't blink in the sharing mode to make it easy to find, nor in the
+// read-only buffers to show that typing is rejected.
+func (c *Cursor) isBlinkDisabled() bool {
+	return editor.sharing.active || (c.readOnly && editor.config.ReadOnly.StopBlink)
+}
+
+// isBlinking reports whether the cursor of the mode blinks, which needs all
+// of blinkwait, blinkon and blinkoff of 'guicursor'
+func (c *Cursor) isBlinking() bool {
+	return c.blinkWait != 0 && c.blinkOn != 0 && c.blinkOff != 0 && !c.isBlinkDisabled()
+}
+
 func (c *Cursor) setBlink() {
 	c.timer.DisconnectTimeout()
+	c.timer.Stop()
 
-	wait := c.blinkWait
-	on := c.blinkOn
-	off := c.blinkOff
-	if wait == 0 || on == 0 || off == 0 {
+	if !c.isBlinking() {
+		c.isShut = false
 		c.brend = 0.0
 		c.widget.Update()
 		return
@@ -115,17 +133,27 @@ func (c *Cursor) setBlink() {
 	c.timer.ConnectTimeout(func() {
 		c.brend = 0.0
 		if !c.isShut {
-			c.timer.SetInterval(off)
+			c.timer.SetInterval(c.blinkOff)
 			c.isShut = true
 			c.brend = 0.6
 		} else {
-			c.timer.SetInterval(on)
+			c.timer.SetInterval(c.blinkOn)
 			c.isShut = false
 		}
-		c.widget.Update()
+		editor.frameClock.nextFrame(c.widget.Update)
 	})
-	c.timer.Start(wait)
-	c.timer.SetInterval(off)
+	c.restartBlink()
+}
+
+// restartBlink shows the cursor and starts the blink over after blinkwait,
+// so that the cursor stays visible while it moves
+func (c *Cursor) restartBlink() {
+	if !c.isBlinking() {
+		return
+	}
+	c.isShut = false
+	c.brend = 0.0
+	c.timer.Start(c.blinkWait)
 }
 
 func (c *Cursor) move() {
@@ -148,100 +176,66 @@ func (c *Cursor) updateFont(font *Font) {
 }
 
 func (c *Cursor) updateCursorShape() {
-	if !c.ws.cursorStyleEnabled {
+	if c.font == nil {
 		return
 	}
 
 	if c.modeInfoModeIdx != c.modeIdx || c.isNeedUpdateModeInfo {
 		c.modeInfoModeIdx = c.modeIdx
-		modeInfo := c.ws.modeInfo[c.modeIdx]
-
-		attrIdITF, ok := modeInfo["attr_id"]
-		if ok {
-			c.currAttrId = util.ReflectToInt(attrIdITF)
-		}
-		var bg, fg *RGBA
-		if c.currAttrId == 0 {
-			fg = c.ws.screen.hlAttrDef[0].background
-			bg = c.ws.screen.hlAttrDef[0].foreground
-		} else {
-			fg = c.ws.screen.hlAttrDef[c.currAttrId].fg()
-			bg = c.ws.screen.hlAttrDef[c.currAttrId].bg()
-		}
-		c.fg = fg
-		c.bg = bg
-		if c.bg == nil {
-			return
-		}
-
-		c.cursorShape = "block"
-		cursorShapeITF, ok := modeInfo["cursor_shape"]
-		if ok {
-			c.cursorShape = cursorShapeITF.(string)
-		}
-		c.cellPercentage = 100
-		cellPercentageITF, ok := modeInfo["cell_percentage"]
-		if ok {
-			c.cellPercentage = util.ReflectToInt(cellPercentageITF)
-		}
-
-		blinkWaitITF, ok := modeInfo["blinkwait"]
-		if ok {
-			c.blinkWait = util.ReflectToInt(blinkWaitITF)
-		}
-		blinkOnITF, ok := modeInfo["blinkon"]
-		if ok {
-			c.blinkOn = util.ReflectToInt(blinkOnITF)
-		}
-		blinkOffITF, ok := modeInfo["blinkoff"]
-		if ok {
-			c.blinkOff = util.ReflectToInt(blinkOffITF)
+		// The cursor is the reversed block without 'guicursor'
+		var modeInfo map[string]interface{}
+		if c.ws.cursorStyleEnabled && c.modeIdx < len(c.ws.modeInfo) {
+			modeInfo = c.ws.modeInfo[c.modeIdx]
 		}
+		m := parseCursorMode(modeInfo)
+		c.cursorShape = m.shape
+		c.cellPercentage = m.cellPercentage
+		c.currAttrId = m.attrID
+		c.blinkWait = m.blinkWait
+		c.blinkOn = m.blinkOn
+		c.blinkOff = m.blinkOff
 		c.setBlink()
 
 		c.isNeedUpdateModeInfo = false
 	}
-
-	height := c.font.height
-	width := int(math.Trunc(c.font.truewidth))
-	if !c.normalWidth {
-		width = width * 2
-	}
-	p := float64(c.cellPercentage) / float64(100)
-
-	switch c.cursorShape {
-	case "horizontal":
-		height = int(float64(height) * p)
-		c.shift = int(float64(c.font.lineHeight) * (1.0 - p))
-		if c.cellPercentage < 99 {
-			c.isTextDraw = false
-		} else {
-			c.isTextDraw = true
-		}
-	case "vertical":
-		c.isTextDraw = true
-		width = int(float64(width) * p)
-		c.shift = 0
-	default:
-		c.isTextDraw = true
-		c.shift = 0
+	c.updateColor()
+	if c.bg == nil {
+		return
 	}
 
-	if width == 0 {
-		width = 1
+	cellWidth := c.font.truewidth
+	if !c.normalWidth {
+		cellWidth = cellWidth * 2
 	}
-	if height == 0 {
-		height = 1
+	percentage := c.cellPercentage
+	// Thicken the thin cursor in the sharing mode
+	if editor.sharing.active && percentage < 30 {
+		percentage = 30
 	}
+	width, height, shift := cursorGeometry(c.cursorShape, percentage, cellWidth, c.font.lineHeight)
+	c.shift = shift
+	// The text is drawn in the block cursor only, the bar and the underline
+	// leave the text of the cell visible
+	c.isTextDraw = c.cursorShape == "block" || percentage >= 99
 
-	if c.blinkWait != 0 {
-		c.brend = 0.0
-		c.timer.Start(c.blinkWait)
-	}
+	c.restartBlink()
 	c.widget.Resize2(width, height)
 	c.widget.Update()
 }
 
+// updateColor sets the colors of the highlight of the mode, or the reversed
+// colors of the cell under the cursor if the mode has no highlight
+func (c *Cursor) updateColor() {
+	hl, ok := c.ws.screen.hlAttrDef[c.currAttrId]
+	if c.currAttrId == 0 || !ok || hl == nil {
+		c.fg = c.cellHighlight.bg()
+		c.bg = c.cellHighlight.fg()
+		return
+	}
+	c.fg = hl.fg()
+	c.bg = hl.bg()
+}
+
 func (c *Cursor) update() {
 	if c.mode != c.ws.mode {
 		c.mode = c.ws.mode
@@ -261,17 +255,17 @@ func (c *Cursor) update() {
 	row := c.ws.screen.cursor[0]
 	col := c.ws.screen.cursor[1]
 
-	if row >= len(win.content) ||
-		col >= len(win.content[0]) ||
-		win.content[row][col] == nil ||
-		win.content[row][col].char == "" ||
+	cell, ok := win.cellAt(row, col)
+	if !ok ||
+		cell.char == "" ||
 		c.ws.palette.widget.IsVisible() {
 		c.text = ""
 		c.normalWidth = true
+		c.cellHighlight = Highlight{}
 	} else {
-		c.text = win.content[row][col].char
-		c.normalWidth = win.content[row][col].normalWidth
-
+		c.text = cell.char
+		c.normalWidth = cell.normalWidth
+		c.cellHighlight = cell.highlight
 	}
 
 	c.updateCursorShape()
@@ -0,0 +1,82 @@
+package editor
+
+import (
+	"math"
+
+	"github.com/akiyosi/goneovim/util"
+)
+
+// cursorMode is the cursor style of a mode of the mode_info_set event,
+// which is set by 'guicursor'
+type cursorMode struct {
+	shape          string
+	cellPercentage int
+	// attrID is the highlight of the cursor, 0 for the reversed colors of
+	// the cell under the cursor
+	attrID    int
+	blinkWait int
+	blinkOn   int
+	blinkOff  int
+}
+
+// parseCursorMode returns the cursor style of the mode info. The keys which
+// are absent take the defaults rather than the values of the previous mode,
+// e.g. the mode without blinkon doesn't blink.
+func parseCursorMode(modeInfo map[string]interface{}) cursorMode {
+	m := cursorMode{
+		shape:          "block",
+		cellPercentage: 100,
+	}
+	if shape, ok := modeInfo["cursor_shape"].(string); ok {
+		m.shape = shape
+	}
+	if v, ok := modeInfo["cell_percentage"]; ok {
+		m.cellPercentage = util.ReflectToInt(v)
+	}
+	if m.cellPercentage <= 0 || m.cellPercentage > 100 {
+		m.cellPercentage = 100
+	}
+	if v, ok := modeInfo["attr_id"]; ok {
+		m.attrID = util.ReflectToInt(v)
+	}
+	if v, ok := modeInfo["blinkwait"]; ok {
+		m.blinkWait = util.ReflectToInt(v)
+	}
+	if v, ok := modeInfo["blinkon"]; ok {
+		m.blinkOn = util.ReflectToInt(v)
+	}
+	if v, ok := modeInfo["blinkoff"]; ok {
+		m.blinkOff = util.ReflectToInt(v)
+	}
+
+	return m
+}
+
+// cursorGeometry returns the size of the cursor in the cell of the width and
+// the line height, and the shift of its top from the top of the cell. The bar
+// and the underline take the percentage of the cell, at least a pixel, and the
+// underline sits on the bottom of the cell.
+func cursorGeometry(shape string, percentage int, cellWidth float64, lineHeight int) (width, height, shift int) {
+	width = int(math.Trunc(cellWidth))
+	height = lineHeight
+	p := float64(percentage) / 100.0
+
+	switch shape {
+	case "horizontal":
+		height = int(math.Round(float64(lineHeight) * p))
+		if height < 1 {
+			height = 1
+		}
+		shift = lineHeight - height
+	case "vertical":
+		width = int(math.Round(cellWidth * p))
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	return
+}
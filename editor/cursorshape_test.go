@@ -0,0 +1,71 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestParseCursorMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		modeInfo map[string]interface{}
+		want     cursorMode
+	}{
+		{
+			"empty",
+			nil,
+			cursorMode{shape: "block", cellPercentage: 100},
+		},
+		{
+			"insert bar",
+			map[string]interface{}{
+				"cursor_shape":    "vertical",
+				"cell_percentage": uint64(25),
+				"attr_id":         uint64(12),
+				"blinkwait":       uint64(700),
+				"blinkon":         uint64(400),
+				"blinkoff":        uint64(250),
+			},
+			cursorMode{shape: "vertical", cellPercentage: 25, attrID: 12, blinkWait: 700, blinkOn: 400, blinkOff: 250},
+		},
+		{
+			"out of range percentage",
+			map[string]interface{}{
+				"cursor_shape":    "horizontal",
+				"cell_percentage": int64(0),
+			},
+			cursorMode{shape: "horizontal", cellPercentage: 100},
+		},
+	}
+	for _, tt := range tests {
+		if got := parseCursorMode(tt.modeInfo); got != tt.want {
+			t.Errorf("%s: parseCursorMode() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCursorGeometry(t *testing.T) {
+	tests := []struct {
+		shape      string
+		percentage int
+		cellWidth  float64
+		lineHeight int
+		width      int
+		height     int
+		shift      int
+	}{
+		{"block", 100, 8.4, 18, 8, 18, 0},
+		{"vertical", 25, 8.4, 18, 2, 18, 0},
+		{"vertical", 1, 8.4, 18, 1, 18, 0},
+		{"horizontal", 20, 8.4, 18, 8, 4, 14},
+		{"horizontal", 1, 8.4, 18, 8, 1, 17},
+		{"vertical", 25, 16.8, 18, 4, 18, 0},
+	}
+	for _, tt := range tests {
+		width, height, shift := cursorGeometry(tt.shape, tt.percentage, tt.cellWidth, tt.lineHeight)
+		if width != tt.width || height != tt.height || shift != tt.shift {
+			t.Errorf("cursorGeometry(%q, %d, %v, %d) = %d, %d, %d, want %d, %d, %d",
+				tt.shape, tt.percentage, tt.cellWidth, tt.lineHeight,
+				width, height, shift, tt.width, tt.height, tt.shift)
+		}
+	}
+}
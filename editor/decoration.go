@@ -0,0 +1,77 @@
+package editor
+
+import (
+	"math"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+)
+
+// decorationThickness returns the thickness of the underline, the undercurl
+// and the strikethrough of the line height. The default is a 14th of the line
+// height, scaled by the config, and snapped to the device pixels so that the
+// lines are sharp and at least a device pixel thick on HiDPI displays.
+func decorationThickness(lineHeight int, scale, dpr float64) float64 {
+	if scale <= 0 {
+		scale = 1
+	}
+	thickness := math.Max(float64(lineHeight)/14.0, 1.0) * scale
+
+	return snapToDevice(thickness, dpr, 1)
+}
+
+// snapToDevice rounds the length in pixels to the device pixels, at least
+// min device pixels
+func snapToDevice(length, dpr float64, min int) float64 {
+	if dpr <= 0 {
+		dpr = 1
+	}
+
+	return math.Max(math.Round(length*dpr), float64(min)) / dpr
+}
+
+// decorationSegments returns the segments from..to within start..end of the
+// pattern of the length repeating every period from x = 0, so that the dots
+// and the dashes continue across the cells
+func decorationSegments(start, end, period, length float64) [][2]float64 {
+	if period <= 0 || length <= 0 {
+		return nil
+	}
+	var segments [][2]float64
+	for x := math.Floor(start/period) * period; x < end; x += period {
+		from := math.Max(x, start)
+		to := math.Min(x+length, end)
+		if to > from {
+			segments = append(segments, [2]float64{from, to})
+		}
+	}
+
+	return segments
+}
+
+// drawUndercurl draws the undercurl of the cell from start to end in the
+// style, "curl", "dots" or "dashes", centered on y
+func (w *Window) drawUndercurl(p *gui.QPainter, color *gui.QColor, style string, start, end, y, thickness float64) {
+	font := w.getFont()
+	switch style {
+	case "dots":
+		for _, s := range decorationSegments(start, end, thickness*2, thickness) {
+			p.FillRect4(core.NewQRectF4(s[0], y-thickness/2, s[1]-s[0], thickness), color)
+		}
+	case "dashes":
+		length := math.Max(thickness*3, font.truewidth/3)
+		for _, s := range decorationSegments(start, end, length*1.5, length) {
+			p.FillRect4(core.NewQRectF4(s[0], y-thickness/2, s[1]-s[0], thickness), color)
+		}
+	default:
+		pen := gui.NewQPen3(color)
+		pen.SetWidthF(thickness)
+		p.SetPen(pen)
+		amplitude := math.Max(font.ascent/8.0, thickness)
+		path := gui.NewQPainterPath2(core.NewQPointF3(start, y+amplitude*math.Sin(2*math.Pi*start/font.truewidth)))
+		for x := math.Floor(start) + 1; x <= end; x++ {
+			path.LineTo(core.NewQPointF3(x, y+amplitude*math.Sin(2*math.Pi*x/font.truewidth)))
+		}
+		p.DrawPath(path)
+	}
+}
@@ -0,0 +1,52 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecorationThickness(t *testing.T) {
+	tests := []struct {
+		lineHeight int
+		scale, dpr float64
+		want       float64
+	}{
+		{20, 1, 1, 1},
+		{28, 1, 1, 2},
+		// Snapped to the device pixels
+		{20, 1, 2, 1.5},
+		{20, 0.5, 2, 0.5},
+		// At least a device pixel
+		{10, 0.1, 2, 0.5},
+		{10, 0.1, 1, 1},
+		{28, 2, 1, 4},
+		// The unset scale and dpr
+		{28, 0, 0, 2},
+	}
+	for _, tt := range tests {
+		if got := decorationThickness(tt.lineHeight, tt.scale, tt.dpr); got != tt.want {
+			t.Errorf("decorationThickness(%d, %v, %v) = %v, want %v", tt.lineHeight, tt.scale, tt.dpr, got, tt.want)
+		}
+	}
+}
+
+func TestDecorationSegments(t *testing.T) {
+	tests := []struct {
+		start, end, period, length float64
+		want                       [][2]float64
+	}{
+		{0, 8, 4, 2, [][2]float64{{0, 2}, {4, 6}}},
+		// The pattern continues from the previous cell
+		{9, 16, 4, 2, [][2]float64{{9, 10}, {12, 14}}},
+		// Cut at the end of the cell
+		{0, 5, 4, 2, [][2]float64{{0, 2}, {4, 5}}},
+		{2, 4, 4, 2, nil},
+		{0, 8, 0, 2, nil},
+	}
+	for _, tt := range tests {
+		got := decorationSegments(tt.start, tt.end, tt.period, tt.length)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("decorationSegments(%v, %v, %v, %v) = %v, want %v", tt.start, tt.end, tt.period, tt.length, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package editor
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/svg"
+)
+
+// Dictation is the speech-to-text input mode.
+// The recognized text is given by the OS dictation through the input method
+// (macOS dictation, Windows voice typing), or by the stdout of the
+// recognizer command set in the config.
+type Dictation struct {
+	ws     *Workspace
+	mu     sync.Mutex
+	button *svg.QSvgWidget
+	cmd    *exec.Cmd
+	active bool
+}
+
+func newDictation(ws *Workspace) *Dictation {
+	d := &Dictation{
+		ws: ws,
+	}
+	if !editor.config.Dictation.Visible {
+		return d
+	}
+
+	button := svg.NewQSvgWidget(ws.screen.widget)
+	button.SetFixedSize2(editor.iconSize*3/2, editor.iconSize*3/2)
+	button.SetToolTip("Push to talk")
+	button.ConnectMousePressEvent(func(*gui.QMouseEvent) {
+		d.start()
+	})
+	button.ConnectMouseReleaseEvent(func(*gui.QMouseEvent) {
+		d.stop()
+	})
+	d.button = button
+	d.updateIcon()
+	button.Show()
+
+	return d
+}
+
+func (d *Dictation) move() {
+	if d.button == nil {
+		return
+	}
+	margin := editor.iconSize / 2
+	x := d.ws.screen.widget.Width() - d.button.Width() - margin
+	y := d.ws.screen.widget.Height() - d.button.Height() - margin
+	d.button.Move2(x, y)
+	d.button.Raise()
+}
+
+func (d *Dictation) updateIcon() {
+	if d.button == nil {
+		return
+	}
+	var color *RGBA
+	if d.active {
+		color = hexToRGBA(editor.config.SideBar.AccentColor)
+	} else {
+		color = editor.colors.inactiveFg
+	}
+	svgContent := editor.getSvg("microphone", color)
+	d.button.Load2(core.NewQByteArray2(svgContent, len(svgContent)))
+}
+
+func (d *Dictation) toggle() {
+	if d.active {
+		d.stop()
+	} else {
+		d.start()
+	}
+}
+
+// start enters insert mode and starts the recognizer command, or the
+// dictation of the OS if the command isn't set
+func (d *Dictation) start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.active {
+		return
+	}
+	d.active = true
+	d.updateIcon()
+
+	if d.ws.mode != "insert" {
+		go d.ws.nvim.Command("startinsert")
+	}
+
+	command := editor.config.Dictation.Command
+	if command == "" {
+		if !startOSDictation() {
+			editor.pushNotification(NotifyWarn, -1, "[Goneovim] There is no dictation of the OS; set the recognizer command to dictation.command")
+		}
+		return
+	}
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	util.PrepareProcGroup(cmd)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return
+	}
+	err = cmd.Start()
+	if err != nil {
+		editor.pushNotification(NotifyWarn, -1, fmt.Sprintf("[Goneovim] Failed to start dictation: %s", err))
+		return
+	}
+	d.cmd = cmd
+
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			d.input(scanner.Text())
+		}
+		cmd.Wait()
+		d.ws.guiUpdates <- []interface{}{"gonvim_dictation_exited", cmd}
+		d.ws.signal.GuiSignal()
+	}()
+}
+
+func (d *Dictation) stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.active {
+		return
+	}
+	d.active = false
+	d.updateIcon()
+
+	// The shell of the command is killed with the recognizer it runs
+	if d.cmd != nil {
+		util.KillProcGroup(d.cmd)
+	}
+	d.cmd = nil
+}
+
+// exited is called by the gonvim_dictation_exited update when the
+// recognizer command exits by itself, and leaves the dictation
+func (d *Dictation) exited(cmd *exec.Cmd) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cmd != cmd {
+		return
+	}
+	d.cmd = nil
+	d.active = false
+	d.updateIcon()
+}
+
+// input sends the recognized text to neovim
+func (d *Dictation) input(text string) {
+	if text == "" {
+		return
+	}
+	text = applyDictationCommands(text, editor.config.Dictation.Punctuation)
+	replacer := strings.NewReplacer("<", "<lt>", "\n", "<CR>")
+	d.ws.nvim.Input(replacer.Replace(text))
+}
+
+// applyDictationCommands replaces the spoken punctuation commands
+// (e.g. "comma", "new line") in the text with the punctuation
+func applyDictationCommands(text string, commands map[string]string) string {
+	// Replace longer commands first so that "new paragraph" wins over "new"
+	words := make([]string, 0, len(commands))
+	for word := range commands {
+		words = append(words, word)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		return len(words[i]) > len(words[j])
+	})
+
+	for _, word := range words {
+		punctuation := commands[word]
+		pattern := `(?i)\s*\b` + regexp.QuoteMeta(word) + `\b`
+		// Also remove the spaces at the beginning of the new line
+		if strings.HasSuffix(punctuation, "\n") {
+			pattern += `\s*`
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			continue
+		}
+		text = re.ReplaceAllLiteralString(text, punctuation)
+	}
+
+	return text
+}
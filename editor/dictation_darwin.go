@@ -0,0 +1,23 @@
+// +build darwin
+
+package editor
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Cocoa
+#import <Cocoa/Cocoa.h>
+
+static void startDictation() {
+	[NSApp sendAction:@selector(startDictation:) to:nil from:nil];
+}
+*/
+import "C"
+
+// startOSDictation starts the dictation of macOS in the focused view, as
+// Edit > Start Dictation does. The recognized text is given through the
+// input method.
+func startOSDictation() bool {
+	C.startDictation()
+
+	return true
+}
@@ -0,0 +1,9 @@
+// +build !darwin,!windows
+
+package editor
+
+// startOSDictation returns false, as there is no dictation of the OS to
+// start. The recognizer command of the config is needed.
+func startOSDictation() bool {
+	return false
+}
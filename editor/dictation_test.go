@@ -0,0 +1,28 @@
+package editor
+
+import "testing"
+
+func TestApplyDictationCommands(t *testing.T) {
+	commands := map[string]string{
+		"period":        ".",
+		"comma":         ",",
+		"question mark": "?",
+		"new line":      "\n",
+		"new paragraph": "\n\n",
+	}
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"hello comma world period", "hello, world."},
+		{"is it Question Mark", "is it?"},
+		{"first new line second", "first\nsecond"},
+		{"first new paragraph second", "first\n\nsecond"},
+		{"periodic table", "periodic table"},
+	}
+	for _, tt := range tests {
+		if got := applyDictationCommands(tt.text, commands); got != tt.want {
+			t.Errorf("applyDictationCommands(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+// +build windows
+
+package editor
+
+import (
+	"syscall"
+)
+
+const (
+	vkLWin         = 0x5B
+	vkH            = 0x48
+	keyeventfKeyUp = 0x0002
+)
+
+var keybdEvent = syscall.NewLazyDLL("user32.dll").NewProc("keybd_event")
+
+// startOSDictation opens the voice typing of Windows by Win+H. The
+// recognized text is given through the input method.
+func startOSDictation() bool {
+	if keybdEvent.Find() != nil {
+		return false
+	}
+	keybdEvent.Call(vkLWin, 0, 0, 0)
+	keybdEvent.Call(vkH, 0, 0, 0)
+	keybdEvent.Call(vkH, 0, keyeventfKeyUp, 0)
+	keybdEvent.Call(vkLWin, 0, keyeventfKeyUp, 0)
+
+	return true
+}
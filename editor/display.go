@@ -0,0 +1,151 @@
+package editor
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/therecipe/qt/gui"
+)
+
+// displayProfiles switches the font size, the linespace and the UI scale by
+// the display the window is on, so that the window keeps the same look when
+// it moves between e.g. the internal Retina display and an external 1080p one.
+type displayProfiles struct {
+	current *displayProfileConfig
+
+	// baseExtFontSize is the size of the fonts of the external UI before
+	// the UI scale of the profile is applied
+	baseExtFontSize int
+}
+
+func newDisplayProfiles(baseExtFontSize int) *displayProfiles {
+	return &displayProfiles{
+		baseExtFontSize: baseExtFontSize,
+	}
+}
+
+// connect applies the profile of the current display, and watches the window
+// moving to another display. It must be called after the window is shown.
+func (d *displayProfiles) connect() {
+	if len(editor.config.DisplayProfiles) == 0 {
+		return
+	}
+	handle := editor.window.WindowHandle()
+	if handle == nil {
+		return
+	}
+	handle.ConnectScreenChanged(d.screenChanged)
+	d.screenChanged(handle.Screen())
+}
+
+func (d *displayProfiles) screenChanged(screen *gui.QScreen) {
+	if screen == nil {
+		return
+	}
+	profile := matchDisplayProfile(
+		editor.config.DisplayProfiles,
+		screen.Name()+" "+screen.Model(),
+		screen.DevicePixelRatio(),
+	)
+	if profile == d.current {
+		return
+	}
+	d.current = profile
+	d.apply()
+}
+
+// apply applies the current profile to the external UI and all workspaces
+func (d *displayProfiles) apply() {
+	editor.setExtFontSize(d.baseExtFontSize)
+	for _, ws := range editor.workspaces {
+		if ws == nil || ws.font == nil {
+			continue
+		}
+		ws.applyDisplayProfile()
+	}
+}
+
+// fontSize returns the font size of the profile, or size if the profile
+// doesn't set it
+func (d *displayProfiles) fontSize(size float64) float64 {
+	if d == nil || d.current == nil || d.current.FontSize <= 0 {
+		return size
+	}
+
+	return d.current.FontSize
+}
+
+// linespace returns the linespace of the profile, or linespace if the
+// profile doesn't set it
+func (d *displayProfiles) linespace(linespace int) int {
+	if d == nil || d.current == nil || d.current.Linespace <= 0 {
+		return linespace
+	}
+
+	return d.current.Linespace
+}
+
+// scale returns the size of the external UI scaled by the profile
+func (d *displayProfiles) scale(size int) int {
+	if d == nil || d.current == nil || d.current.UIScale <= 0 {
+		return size
+	}
+
+	return scaleUISize(size, d.current.UIScale)
+}
+
+// setExtFontSize sets the size of the fonts of the external UI, which is
+// scaled by the profile of the current display
+func (e *Editor) setExtFontSize(size int) {
+	if e.displays != nil {
+		e.displays.baseExtFontSize = size
+		size = e.displays.scale(size)
+	}
+	if e.extFontSize == size {
+		return
+	}
+	e.extFontSize = size
+	e.iconSize = e.extFontSize * 11 / 9
+	e.app.SetFont(gui.NewQFont2(e.extFontFamily, e.extFontSize, 1, false), "QWidget")
+	e.app.SetFont(gui.NewQFont2(e.extFontFamily, e.extFontSize, 1, false), "QLabel")
+}
+
+// applyDisplayProfile reapplies 'guifont' and 'linespace', which the profile
+// of the current display overrides
+func (w *Workspace) applyDisplayProfile() {
+	guifont := w.guifont
+	if guifont == "" {
+		guifont = fmt.Sprintf("%s:h%d", w.font.fontNew.Family(), editor.displays.baseExtFontSize)
+	}
+	w.guiFont(guifont)
+	w.guiLinespace(int64(w.linespace))
+}
+
+// matchDisplayProfile returns the first profile which matches the display,
+// or nil. The screen of the profile matches the name or the model of the
+// display ignoring case, and the pixel ratio matches the device pixel ratio.
+func matchDisplayProfile(profiles []displayProfileConfig, name string, ratio float64) *displayProfileConfig {
+	for i := range profiles {
+		p := &profiles[i]
+		if p.Screen != "" && !strings.Contains(strings.ToLower(name), strings.ToLower(p.Screen)) {
+			continue
+		}
+		if p.PixelRatio > 0 && math.Abs(p.PixelRatio-ratio) > 0.01 {
+			continue
+		}
+
+		return p
+	}
+
+	return nil
+}
+
+func scaleUISize(size int, scale float64) int {
+	scaled := int(math.Round(float64(size) * scale))
+	if scaled < 1 {
+		return 1
+	}
+
+	return scaled
+}
@@ -0,0 +1,51 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestMatchDisplayProfile(t *testing.T) {
+	profiles := []displayProfileConfig{
+		{Screen: "dell", FontSize: 11},
+		{PixelRatio: 2.0, FontSize: 14},
+		{FontSize: 12},
+	}
+	tests := []struct {
+		name  string
+		ratio float64
+		want  int
+	}{
+		{"DP-2 DELL U2720Q", 1.0, 0},
+		{"DP-2 DELL U2720Q", 2.0, 0},
+		{"eDP-1 Color LCD", 2.0, 1},
+		{"HDMI-1 LG", 1.0, 2},
+	}
+	for _, tt := range tests {
+		got := matchDisplayProfile(profiles, tt.name, tt.ratio)
+		if got != &profiles[tt.want] {
+			t.Errorf("matchDisplayProfile(%q, %v) = %v, want profile %d", tt.name, tt.ratio, got, tt.want)
+		}
+	}
+
+	if got := matchDisplayProfile(profiles[:2], "HDMI-1 LG", 1.0); got != nil {
+		t.Errorf("matchDisplayProfile() = %v, want nil", got)
+	}
+}
+
+func TestScaleUISize(t *testing.T) {
+	tests := []struct {
+		size  int
+		scale float64
+		want  int
+	}{
+		{13, 1.0, 13},
+		{13, 0.9, 12},
+		{10, 1.25, 13},
+		{1, 0.1, 1},
+	}
+	for _, tt := range tests {
+		if got := scaleUISize(tt.size, tt.scale); got != tt.want {
+			t.Errorf("scaleUISize(%d, %v) = %d, want %d", tt.size, tt.scale, got, tt.want)
+		}
+	}
+}
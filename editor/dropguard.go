@@ -0,0 +1,297 @@
+package editor
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// dropEdgeSize is the bytes read from the head and the tail of the dropped
+// file to find the modelines
+const dropEdgeSize = 64 * 1024
+
+// dropModelines is the lines from the head and the tail of the file which
+// nvim checks for the modelines, the default of 'modelines'
+const dropModelines = 5
+
+// dropModelineRegexp matches the modeline, "vi:", "vim:" or "Vim:" at the
+// start of the line or after a white space, or "ex:" after a white space
+var dropModelineRegexp = regexp.MustCompile(`(^|\s)(vi|vim[<=>]?[0-9]*|Vim):|\sex:`)
+
+// hasModeline returns true if any of the lines has a modeline
+func hasModeline(lines []string) bool {
+	for _, line := range lines {
+		if dropModelineRegexp.MatchString(line) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isExrcName returns true for the names of the files which nvim sources from
+// the current directory with 'exrc'
+func isExrcName(name string) bool {
+	switch name {
+	case ".exrc", "_exrc", ".vimrc", "_vimrc", ".nvimrc", "_nvimrc", ".nvim.lua":
+		return true
+	}
+
+	return false
+}
+
+// pathUnder returns true if the path is the directory or under it
+func pathUnder(path, dir string) bool {
+	if dir == "" {
+		return false
+	}
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+
+	return rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// dropLocation returns why the location of the path is untrusted, or "" if
+// it is not. The trusted directories take precedence over the others.
+func dropLocation(path, goos string, downloads, untrusted, trusted []string) string {
+	for _, dir := range trusted {
+		if pathUnder(path, dir) {
+			return ""
+		}
+	}
+	for _, dir := range untrusted {
+		if pathUnder(path, dir) {
+			return "is in an untrusted folder"
+		}
+	}
+	for _, dir := range downloads {
+		if pathUnder(path, dir) {
+			return "was downloaded"
+		}
+	}
+
+	var shares []string
+	switch goos {
+	case "windows":
+		if strings.HasPrefix(path, `\\`) || strings.HasPrefix(path, "//") {
+			return "is on a network share"
+		}
+	case "darwin":
+		shares = []string{"/Volumes", "/Network"}
+	default:
+		shares = []string{"/mnt", "/media", "/run/media", "/net", "/smb"}
+		if strings.HasPrefix(path, "/run/user/") && strings.Contains(path, "/gvfs/") {
+			return "is on a network share"
+		}
+	}
+	for _, dir := range shares {
+		if pathUnder(path, dir) {
+			return "is on a network share or a removable volume"
+		}
+	}
+
+	return ""
+}
+
+// dropEdgeLines returns the first and the last lines of the file
+func dropEdgeLines(file string, n int) []string {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		return nil
+	}
+
+	head := make([]byte, dropEdgeSize)
+	size, _ := io.ReadFull(f, head)
+	lines := splitEdgeLines(head[:size])
+	if len(lines) > n {
+		lines = lines[:n]
+	}
+
+	var tail []string
+	if info.Size() <= dropEdgeSize {
+		tail = splitEdgeLines(head[:size])
+	} else if _, err := f.Seek(-dropEdgeSize, io.SeekEnd); err == nil {
+		size, _ := io.ReadFull(f, head)
+		tail = splitEdgeLines(head[:size])
+	}
+	if len(tail) > n {
+		tail = tail[len(tail)-n:]
+	}
+
+	return append(lines, tail...)
+}
+
+func splitEdgeLines(data []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, dropEdgeSize), dropEdgeSize)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	return lines
+}
+
+// isDownloaded returns true if the file has the mark of the download of the
+// system, the quarantine of macOS or the zone of Windows
+func isDownloaded(file string) bool {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("xattr", "-p", "com.apple.quarantine", file).Run() == nil
+	case "windows":
+		_, err := os.Stat(file + ":Zone.Identifier")
+		return err == nil
+	}
+
+	return false
+}
+
+// downloadDirs returns the download directories of the user
+func downloadDirs() []string {
+	var dirs []string
+	if home, err := homedir.Dir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, "Downloads"))
+	}
+	if dir := os.Getenv("XDG_DOWNLOAD_DIR"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+
+	return dirs
+}
+
+func expandPaths(paths []string) []string {
+	expanded := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if p, err := homedir.Expand(path); err == nil {
+			path = p
+		}
+		expanded = append(expanded, path)
+	}
+
+	return expanded
+}
+
+// dropRisks returns why the dropped file is risky to open
+func dropRisks(file string) []string {
+	var risks []string
+	config := editor.config.DropGuard
+	location := dropLocation(
+		file,
+		runtime.GOOS,
+		downloadDirs(),
+		expandPaths(config.UntrustedPaths),
+		expandPaths(config.TrustedPaths),
+	)
+	if location == "" && isDownloaded(file) {
+		location = "was downloaded"
+	}
+	if location != "" {
+		risks = append(risks, location)
+	}
+	if isExrcName(filepath.Base(file)) {
+		risks = append(risks, "is an exrc file")
+	}
+	if hasModeline(dropEdgeLines(file, dropModelines)) {
+		risks = append(risks, "has a modeline")
+	}
+
+	return risks
+}
+
+// guardDrop opens the dropped file, or asks how to open it by the policy of
+// [dropGuard] if it is risky. hasBuf is true if the current buffer has a
+// file, which the file can be diffed with. The risks are checked off the GUI
+// thread, since it reads the file and may run xattr.
+func (s *Screen) guardDrop(file string, hasBuf bool) {
+	if editor.config.DropGuard.Policy == "off" {
+		s.openDropped(file, hasBuf)
+		return
+	}
+	go func() {
+		risks := dropRisks(file)
+		s.ws.guiUpdates <- []interface{}{"gonvim_drop_guard", file, hasBuf, risks}
+		s.ws.signal.GuiSignal()
+	}()
+}
+
+// dropGuarded opens the dropped file with the risks checked by guardDrop
+func (s *Screen) dropGuarded(args []interface{}) {
+	if len(args) < 3 {
+		return
+	}
+	file, _ := args[0].(string)
+	hasBuf, _ := args[1].(bool)
+	risks, _ := args[2].([]string)
+	if len(risks) == 0 {
+		s.openDropped(file, hasBuf)
+		return
+	}
+	reason := fmt.Sprintf("%s %s", filepath.Base(file), strings.Join(risks, " and "))
+
+	if editor.config.DropGuard.Policy == "safe" {
+		fileOpenSafely(file)
+		editor.pushNotification(NotifyInfo, 3, fmt.Sprintf("[Goneovim] Opened with 'nomodeline' and 'secure', since %s", reason))
+		return
+	}
+
+	opts := []*NotifyButton{
+		{
+			action: func() {
+				fileOpenSafely(file)
+			},
+			text: "Open safely",
+		},
+		{
+			action: func() {
+				s.openDropped(file, hasBuf)
+			},
+			text: "Open normally",
+		},
+	}
+	message := fmt.Sprintf("[Goneovim] %s. Do you want to open it with 'nomodeline' and 'secure'?", reason)
+	editor.pushNotification(NotifyWarn, 0, message, notifyOptionArg(opts))
+}
+
+func (s *Screen) openDropped(file string, hasBuf bool) {
+	if hasBuf {
+		s.howToOpen(file)
+	} else {
+		fileOpenInBuf(file)
+	}
+}
+
+// fileOpenSafely opens the file as fileOpenInBuf does, without applying its
+// modelines and with 'secure' set. Both options are restored after the file
+// is read.
+func fileOpenSafely(file string) {
+	nvim := editor.workspaces[editor.active].nvim
+	var name string
+	if err := nvim.Call("fnameescape", &name, file); err != nil {
+		return
+	}
+	open := "edit"
+	isModified, _ := nvim.CommandOutput("echo &modified")
+	if isModified == "1" {
+		open = "tabnew"
+	}
+	nvim.Command("let g:gonvim_modeline = &g:modeline | let g:gonvim_secure = &secure | set secure nomodeline")
+	nvim.Command(fmt.Sprintf("%s %s", open, name))
+	nvim.Command("setlocal nomodeline | let &g:modeline = g:gonvim_modeline | let &secure = g:gonvim_secure | unlet g:gonvim_modeline g:gonvim_secure")
+}
@@ -0,0 +1,101 @@
+package editor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHasModeline(t *testing.T) {
+	tests := []struct {
+		lines []string
+		want  bool
+	}{
+		{[]string{"plain text"}, false},
+		{[]string{"# vim: set ts=4 sw=4:"}, true},
+		{[]string{"vi:noai:sw=3 ts=6"}, true},
+		{[]string{"/* vim600: set foldmethod=marker: */"}, true},
+		{[]string{"// Vim: set ft=c:"}, true},
+		{[]string{"  ex: set tw=72:"}, true},
+		{[]string{"ex:set tw=72:"}, false},
+		{[]string{"the envim: is not a modeline"}, false},
+		{[]string{"devices:", "revision: 2"}, false},
+	}
+	for _, tt := range tests {
+		if got := hasModeline(tt.lines); got != tt.want {
+			t.Errorf("hasModeline(%q) = %v, want %v", tt.lines, got, tt.want)
+		}
+	}
+}
+
+func TestIsExrcName(t *testing.T) {
+	for name, want := range map[string]bool{
+		".exrc":     true,
+		".nvimrc":   true,
+		".nvim.lua": true,
+		"_vimrc":    true,
+		"init.lua":  false,
+		"exrc":      false,
+	} {
+		if got := isExrcName(name); got != want {
+			t.Errorf("isExrcName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestDropLocation(t *testing.T) {
+	downloads := []string{"/home/u/Downloads"}
+	tests := []struct {
+		path      string
+		goos      string
+		untrusted []string
+		trusted   []string
+		want      string
+	}{
+		{"/home/u/src/a.txt", "linux", nil, nil, ""},
+		{"/home/u/Downloads/a.txt", "linux", nil, nil, "was downloaded"},
+		{"/home/u/Downloads2/a.txt", "linux", nil, nil, ""},
+		{"/home/u/Downloads/a.txt", "linux", nil, []string{"/home/u/Downloads"}, ""},
+		{"/srv/share/a.txt", "linux", []string{"/srv/share"}, nil, "is in an untrusted folder"},
+		{"/mnt/nas/a.txt", "linux", nil, nil, "is on a network share or a removable volume"},
+		{"/run/user/1000/gvfs/smb-share:server=nas/a.txt", "linux", nil, nil, "is on a network share"},
+		{"/Volumes/share/a.txt", "darwin", nil, nil, "is on a network share or a removable volume"},
+		{"//server/share/a.txt", "windows", nil, nil, "is on a network share"},
+	}
+	for _, tt := range tests {
+		if got := dropLocation(tt.path, tt.goos, downloads, tt.untrusted, tt.trusted); got != tt.want {
+			t.Errorf("dropLocation(%q, %q) = %q, want %q", tt.path, tt.goos, got, tt.want)
+		}
+	}
+}
+
+func TestDropEdgeLines(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dropguard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var lines []string
+	for i := 0; i < 20000; i++ {
+		lines = append(lines, "some text of the line")
+	}
+	lines[len(lines)-1] = "# vim: set ts=2:"
+	file := filepath.Join(dir, "a.txt")
+	if err := ioutil.WriteFile(file, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	edges := dropEdgeLines(file, dropModelines)
+	if len(edges) != dropModelines*2 {
+		t.Errorf("dropEdgeLines() returned %d lines, want %d", len(edges), dropModelines*2)
+	}
+	if !hasModeline(edges) {
+		t.Errorf("hasModeline(dropEdgeLines()) = false, want true")
+	}
+	if got := dropEdgeLines(dir, dropModelines); got != nil {
+		t.Errorf("dropEdgeLines(dir) = %q, want nil", got)
+	}
+}
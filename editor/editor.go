@@ -70,9 +70,17 @@ type Option struct {
 	Fullscreen bool   `long:"fullscreen" description:"Open the window in fullscreen on startup"`
 	Maximized  bool   `long:"maximized" description:"Maximize the window on startup"`
 	Geometry   string `long:"geometry" description:"Initial window geomtry [e.g. 800x600]"`
+	Monitor    string `long:"monitor" description:"Open the window on the monitor of the index from 1 or the name"`
+	Tile       string `long:"tile" description:"Tile the window to the part of the monitor [e.g. left, topright, center]"`
 
-	Server string `long:"server" description:"Remote session address"`
-	Nvim   string `long:"nvim" description:"Excutable nvim path to attach"`
+	Server         string `long:"server" description:"Remote session address"`
+	Ssh            string `long:"ssh" description:"Attach to the --server address on the host over ssh [e.g. user@host]"`
+	AttachedWindow bool   `long:"attached-window" description:"Open as another window of the goneovim which owns the nvim of --server"`
+	Nvim           string `long:"nvim" description:"Excutable nvim path to attach"`
+
+	Record   string `long:"record" description:"Record the redraw events to the file for debugging"`
+	Replay   string `long:"replay" description:"Replay the redraw events recorded by --record"`
+	ReplayTo string `long:"replay-to" description:"Replay the --replay file without the GUI, and write the snapshots of the grids to the directory"`
 }
 
 // Editor is the editor
@@ -93,11 +101,14 @@ type Editor struct {
 
 	workspaces []*Workspace
 	active     int
+	// activeWorkspace is the workspace which was active on the last update
+	activeWorkspace *Workspace
 	nvim       *nvim.Nvim
 	window     *frameless.QFramelessWindow
 	split      *widgets.QSplitter
 	wsWidget   *widgets.QWidget
 	wsSide     *WorkspaceSide
+	wsSplit    *WorkspaceSplit
 	sysTray    *widgets.QSystemTrayIcon
 
 	statuslineHeight int
@@ -124,6 +135,17 @@ type Editor struct {
 	colors        *ColorPalette
 	svgs          map[string]*SvgXML
 
+	viewStore *viewStore
+	theme     *themeScheduler
+	userStyle *userStyleSheet
+	iconTheme *iconTheme
+	sharing   *sharingMode
+
+	thumbnails *thumbnailCache
+	displays   *displayProfiles
+	frameClock *frameClock
+	focusChain *focusChain
+
 	extFontFamily string
 	extFontSize   int
 }
@@ -131,6 +153,7 @@ type Editor struct {
 type editorSignal struct {
 	core.QObject
 	_ func() `signal:"notifySignal"`
+	_ func() `signal:"thumbnailSignal"`
 }
 
 func (hl *Highlight) copy() Highlight {
@@ -162,6 +185,14 @@ func InitEditor() {
 		}
 	}
 
+	if opts.Replay != "" && opts.ReplayTo != "" {
+		if err := replayHeadless(opts.Replay, opts.ReplayTo); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	putEnv()
 
 	home, err := homedir.Dir()
@@ -181,18 +212,34 @@ func InitEditor() {
 		opts:    opts,
 	}
 	e := editor
+	e.viewStore = newViewStore(home)
+	e.sharing = newSharingMode()
 
 	core.QCoreApplication_SetAttribute(core.Qt__AA_EnableHighDpiScaling, true)
+	if e.config.Editor.Renderer == "opengl" {
+		// The windows of all the workspaces share the glyph textures
+		core.QCoreApplication_SetAttribute(core.Qt__AA_ShareOpenGLContexts, true)
+	}
 	e.app = widgets.NewQApplication(len(os.Args), os.Args)
 	e.app.ConnectAboutToQuit(func() {
+		e.viewStore.flush()
 		e.cleanup()
 	})
 
 	e.initFont()
+	e.displays = newDisplayProfiles(e.extFontSize)
+	e.frameClock = newFrameClock()
+	e.focusChain = newFocusChain()
 	e.initSVGS()
 	e.initColorPalette()
 	e.initNotifications()
+	e.thumbnails = newThumbnailCache(e.homeDir)
+	e.initUserStyleSheet()
+	e.initIconTheme()
 	e.initSysTray()
+	e.theme = newThemeScheduler()
+	e.theme.initTrayMenu()
+	e.theme.start()
 
 	e.window = frameless.CreateQFramelessWindow(e.config.Editor.Transparent)
 	e.setWindowSizeFromOpts()
@@ -214,9 +261,7 @@ func InitEditor() {
 	e.initWorkspaces()
 
 	e.wsWidget.ConnectResizeEvent(func(event *gui.QResizeEvent) {
-		for _, ws := range e.workspaces {
-			ws.updateSize()
-		}
+		e.updateWorkspacesSize()
 	})
 
 	e.loadFileInDarwin()
@@ -229,7 +274,10 @@ func InitEditor() {
 		e.app.Quit()
 	}()
 
+	e.connectFocusEvents()
 	e.window.Show()
+	e.displays.connect()
+	e.frameClock.connect()
 	e.wsWidget.SetFocus2()
 	widgets.QApplication_Exec()
 }
@@ -252,7 +300,7 @@ func (e *Editor) newSplitter() {
 func (e *Editor) initWorkspaces() {
 	e.workspaces = []*Workspace{}
 	sessionExists := false
-	if e.config.Workspace.RestoreSession {
+	if e.config.Workspace.RestoreSession && !e.isAttachedWindow() {
 		for i := 0; i <= WorkspaceLen; i++ {
 			path := filepath.Join(e.homeDir, ".goneovim", "sessions", strconv.Itoa(i)+".vim")
 			_, err := os.Stat(path)
@@ -425,7 +473,7 @@ func (e *Editor) pushNotification(level NotifyLevel, p int, message string, opt
 	e.signal.NotifySignal()
 }
 
-func (e *Editor) popupNotification(level NotifyLevel, p int, message string, opt ...NotifyOptionArg) {
+func (e *Editor) popupNotification(level NotifyLevel, p int, message string, opt ...NotifyOptionArg) *Notification {
 	notification := newNotification(level, p, message, opt...)
 	notification.widget.SetParent(e.window)
 	notification.widget.AdjustSize()
@@ -435,6 +483,8 @@ func (e *Editor) popupNotification(level NotifyLevel, p int, message string, opt
 	e.notifyStartPos = core.NewQPoint2(x, y)
 	e.notifications = append(e.notifications, notification)
 	notification.show()
+
+	return notification
 }
 
 func (e *Editor) initColorPalette() {
@@ -552,6 +602,7 @@ func (e *Editor) setWindowOptions() {
 	e.window.ConnectKeyPressEvent(e.keyPress)
 	e.window.SetAttribute(core.Qt__WA_KeyCompression, false)
 	e.window.SetAcceptDrops(true)
+	e.placeWindowFromOpts()
 	if e.config.Editor.StartFullscreen || e.opts.Fullscreen {
 		e.window.ShowFullScreen()
 	} else if e.config.Editor.StartMaximizedWindow || e.opts.Maximized {
@@ -559,6 +610,21 @@ func (e *Editor) setWindowOptions() {
 	}
 }
 
+// placeWindowFromOpts moves the window onto the monitor of --monitor, tiled
+// to the position of --tile
+func (e *Editor) placeWindowFromOpts() {
+	if e.opts.Monitor == "" && e.opts.Tile == "" {
+		return
+	}
+	action := "monitor"
+	if e.opts.Tile != "" {
+		action = "tile"
+	}
+	if err := e.placeWindow(action, e.opts.Monitor, e.opts.Tile); err != nil {
+		fmt.Println(err)
+	}
+}
+
 func isFileExist(filename string) bool {
 	_, err := os.Stat(filename)
 	return err == nil
@@ -618,10 +684,15 @@ func (e *Editor) workspaceUpdate() {
 	if e.wsSide == nil {
 		return
 	}
+	if e.wsSplit != nil {
+		e.wsSplit.syncActive(e.workspaces[e.active])
+	}
 	for i, ws := range e.workspaces {
 		if i == e.active {
 			ws.hide()
 			ws.show()
+		} else if e.isShownInSplit(ws) {
+			ws.show()
 		} else {
 			ws.hide()
 		}
@@ -634,12 +705,19 @@ func (e *Editor) workspaceUpdate() {
 	for i := len(e.workspaces); i < len(e.wsSide.items); i++ {
 		e.wsSide.items[i].hide()
 	}
+	e.emitWorkspaceSwitched()
 }
 
 func (e *Editor) keyPress(event *gui.QKeyEvent) {
 	input := e.convertKey(event)
 	if input != "" {
-		e.workspaces[e.active].nvim.Input(input)
+		ws := e.workspaces[e.active]
+		if ws.paster.intercept(input) {
+			return
+		}
+		ws.cheatsheet.keyInput(input)
+		ws.localEcho.input(input)
+		ws.inputQueue.input(input)
 	}
 }
 
@@ -862,6 +940,10 @@ func (e *Editor) close() {
 }
 
 func (e *Editor) cleanup() {
+	// The sessions belong to the goneovim which owns nvim
+	if e.isAttachedWindow() {
+		return
+	}
 	home, err := homedir.Dir()
 	if err != nil {
 		return
@@ -0,0 +1,137 @@
+package editor
+
+import (
+	"math"
+	"runtime"
+	"unicode/utf8"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+)
+
+const (
+	variationSelectorEmoji = 0xFE0F
+	zeroWidthJoiner        = 0x200D
+)
+
+// defaultEmojiFont returns the color emoji font shipped with the platform
+func defaultEmojiFont() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "Apple Color Emoji"
+	case "windows":
+		return "Segoe UI Emoji"
+	default:
+		return "Noto Color Emoji"
+	}
+}
+
+// emojiFontFamily returns the family of the fallback font of the emoji, or
+// "" if the fallback is disabled
+func emojiFontFamily() string {
+	switch editor.config.Editor.EmojiFont {
+	case "":
+		return defaultEmojiFont()
+	case "none":
+		return ""
+	default:
+		return editor.config.Editor.EmojiFont
+	}
+}
+
+// isEmoji reports whether the text of the cell is drawn as a color emoji,
+// i.e. a pictograph, a flag, or a sequence with the emoji presentation
+// selector or the zero width joiner
+func isEmoji(char string) bool {
+	if len(char) == 0 || char[0] <= 127 {
+		return false
+	}
+	r, size := utf8.DecodeRuneInString(char)
+	if isEmojiRune(r) {
+		return true
+	}
+	for _, c := range char[size:] {
+		if c == variationSelectorEmoji || c == zeroWidthJoiner {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cellNormalWidth reports whether the char of the cell at col of the line is
+// drawn in the normal width. The emoji is drawn by the fallback font in the
+// double width cell only if nvim gives it two cells, i.e. the next cell is
+// the empty text of the right half, as nvim decides the width by its own
+// table and 'emoji'.
+func (w *Window) cellNormalWidth(line []*Cell, col int) bool {
+	char := line[col].char
+	if isEmoji(char) && emojiFontFamily() != "" {
+		return cellColumns(line, col) != 2
+	}
+
+	return w.isNormalWidth(char)
+}
+
+// isEmojiRune reports whether the rune is presented as an emoji by default
+func isEmojiRune(r rune) bool {
+	switch {
+	case r >= 0x1F1E6 && r <= 0x1F1FF: // regional indicators of the flags
+		return true
+	case r >= 0x1F300 && r <= 0x1F5FF: // misc symbols and pictographs
+		return true
+	case r >= 0x1F600 && r <= 0x1F64F: // emoticons
+		return true
+	case r >= 0x1F680 && r <= 0x1F6FF: // transport and map symbols
+		return true
+	case r >= 0x1F900 && r <= 0x1FAFF: // supplemental symbols and pictographs
+		return true
+	case r == 0x1F004 || r == 0x1F0CF || r == 0x1F18E:
+		return true
+	}
+
+	return false
+}
+
+// emojiFont returns the fallback font of the emoji sized to fit the double
+// width cell, or nil if the fallback is disabled
+func (f *Font) emojiFont() *gui.QFont {
+	family := emojiFontFamily()
+	if family == "" {
+		return nil
+	}
+	size := minInt(f.lineHeight, int(math.Floor(f.truewidth*2)))
+	if f.emoji != nil && f.emojiSize == size && f.emoji.Family() == family {
+		return f.emoji
+	}
+	font := gui.NewQFont()
+	font.SetFamily(family)
+	// The color emoji fonts have square glyphs slightly larger than the em
+	font.SetPixelSize(maxInt(1, size*4/5))
+	font.SetStyleStrategy(gui.QFont__PreferAntialias)
+	f.emoji = font
+	f.emojiSize = size
+
+	return font
+}
+
+// drawEmoji draws the emoji of the cell centered in the double width cell at
+// the position by the fallback font, and returns false if the fallback is
+// disabled or nvim gives the emoji a single cell
+func (w *Window) drawEmoji(p *gui.QPainter, cell *Cell, x, y float64) bool {
+	if cell.normalWidth {
+		return false
+	}
+	font := w.getFont()
+	emoji := font.emojiFont()
+	if emoji == nil {
+		return false
+	}
+	rect := core.NewQRectF4(x, y, font.truewidth*2, float64(font.lineHeight))
+	p.Save()
+	p.SetFont(emoji)
+	p.DrawText6(rect, cell.char, gui.NewQTextOption2(core.Qt__AlignCenter))
+	p.Restore()
+
+	return true
+}
@@ -0,0 +1,28 @@
+package editor
+
+import "testing"
+
+func TestIsEmoji(t *testing.T) {
+	tests := []struct {
+		char string
+		want bool
+	}{
+		{"", false},
+		{"a", false},
+		{"#", false},
+		{"あ", false},
+		{"─", false},
+		{"😀", true},
+		{"🚀", true},
+		{"🦀", true},
+		{"🇯🇵", true},
+		{"❤️", true},
+		{"❤", false},
+		{"👩‍💻", true},
+	}
+	for _, tt := range tests {
+		if got := isEmoji(tt.char); got != tt.want {
+			t.Errorf("isEmoji(%q) = %v, want %v", tt.char, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package editor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+// fileLoadSpinner is the frames of the spinner of the file loading
+var fileLoadSpinner = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// fileLoadFlushInterval is the least interval of the repaints while a huge
+// file is loading
+const fileLoadFlushInterval = 100 * time.Millisecond
+
+// fileLoad shows the progress of the loading of a huge file on the bottom of
+// the screen. nvim doesn't redraw while reading the file, so the progress is
+// drawn by the GUI alone, from BufReadPre to BufReadPost. The bytes loaded
+// are read from the file offset of the local nvim process, where the system
+// exposes it.
+type fileLoad struct {
+	ws      *Workspace
+	label   *widgets.QLabel
+	timer   *core.QTimer
+	name    string
+	path    string
+	pid     int
+	fdinfo  string
+	size    int64
+	start   time.Time
+	frame   int
+	loading bool
+}
+
+func newFileLoad(ws *Workspace) *fileLoad {
+	f := &fileLoad{
+		ws: ws,
+	}
+	f.label = widgets.NewQLabel(nil, 0)
+	f.label.SetContentsMargins(8, 2, 8, 2)
+	f.label.Hide()
+	f.timer = core.NewQTimer(nil)
+	f.timer.ConnectTimeout(f.tick)
+
+	return f
+}
+
+// fileLoadAutoCmds returns the autocmds which notify the loading of the files
+// larger than the size in MB
+func fileLoadAutoCmds(size int) string {
+	return fmt.Sprintf(`
+	aug GonvimAuFileLoad | au! | aug END
+	au GonvimAuFileLoad BufReadPre,FileReadPre * if getfsize(expand("<afile>")) >= %d | let g:gonvim_file_loading = 1 | call rpcnotify(0, "Gui", "gonvim_file_load", 1, expand("<afile>:t"), getfsize(expand("<afile>")), expand("<afile>:p"), getpid()) | endif
+	au GonvimAuFileLoad BufReadPost,FileReadPost * if get(g:, "gonvim_file_loading") | unlet g:gonvim_file_loading | call rpcnotify(0, "Gui", "gonvim_file_load", 0) | endif
+	`, size*1024*1024)
+}
+
+// update is called by the gonvim_file_load notification.
+// args: [1, file name, size in bytes, full path, nvim pid] when the loading
+// starts, [0] when done
+func (f *fileLoad) update(args []interface{}) {
+	if len(args) < 1 {
+		return
+	}
+	if util.ReflectToInt(args[0]) == 0 || len(args) < 3 {
+		f.loading = false
+		f.timer.Stop()
+		f.label.Hide()
+		return
+	}
+	f.name, _ = args[1].(string)
+	f.size = int64(util.ReflectToInt(args[2]))
+	f.path = ""
+	f.pid = 0
+	f.fdinfo = ""
+	if len(args) >= 5 && !f.ws.uiRemoteAttached {
+		f.path, _ = args[3].(string)
+		f.pid = util.ReflectToInt(args[4])
+	}
+	f.start = time.Now()
+	f.frame = 0
+	f.loading = true
+
+	f.label.SetParent(f.ws.screen.widget)
+	f.label.SetStyleSheet(fmt.Sprintf(
+		"* { color: %s; background-color: %s; }",
+		editor.colors.fg.String(),
+		editor.colors.selectedBg.String(),
+	))
+	f.tick()
+	f.label.Show()
+	f.label.Raise()
+	f.timer.Start(80)
+}
+
+func (f *fileLoad) tick() {
+	spinner := fileLoadSpinner[f.frame%len(fileLoadSpinner)]
+	f.frame++
+	size := formatFileSize(f.size)
+	if loaded, ok := f.loaded(); ok {
+		size = fmt.Sprintf("%s / %s", formatFileSize(loaded), size)
+	}
+	f.label.SetText(fmt.Sprintf(
+		"%s Loading %s (%s) %.1fs",
+		spinner,
+		f.name,
+		size,
+		time.Since(f.start).Seconds(),
+	))
+	f.label.AdjustSize()
+	parent := f.label.ParentWidget()
+	if parent != nil {
+		f.label.Move2(0, parent.Height()-f.label.Height())
+	}
+}
+
+// loaded returns the bytes of the file read by nvim so far. Only the local
+// nvim on Linux exposes the offset of its files, by procfs.
+func (f *fileLoad) loaded() (int64, bool) {
+	if runtime.GOOS != "linux" || f.pid == 0 || f.path == "" {
+		return 0, false
+	}
+	if f.fdinfo == "" {
+		f.fdinfo = fileLoadFdinfo(f.pid, f.path)
+		if f.fdinfo == "" {
+			return 0, false
+		}
+	}
+	data, err := ioutil.ReadFile(f.fdinfo)
+	if err != nil {
+		f.fdinfo = ""
+		return 0, false
+	}
+	pos, ok := parseFdinfoPos(string(data))
+	if !ok || pos > f.size {
+		return 0, false
+	}
+
+	return pos, true
+}
+
+// fileLoadFdinfo returns the path of the fdinfo of the descriptor the
+// process opened the file by, or "" if it has none
+func fileLoadFdinfo(pid int, path string) string {
+	dir := filepath.Join("/proc", strconv.Itoa(pid), "fd")
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		target, err := os.Readlink(filepath.Join(dir, entry.Name()))
+		if err != nil || target != path {
+			continue
+		}
+		return filepath.Join("/proc", strconv.Itoa(pid), "fdinfo", entry.Name())
+	}
+
+	return ""
+}
+
+// parseFdinfoPos returns the file offset in the content of the fdinfo
+func parseFdinfoPos(fdinfo string) (int64, bool) {
+	for _, line := range strings.Split(fdinfo, "\n") {
+		if !strings.HasPrefix(line, "pos:") {
+			continue
+		}
+		pos, err := strconv.ParseInt(strings.TrimSpace(line[len("pos:"):]), 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		return pos, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,25 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestParseFdinfoPos(t *testing.T) {
+	tests := []struct {
+		fdinfo string
+		want   int64
+		ok     bool
+	}{
+		{"pos:\t1048576\nflags:\t0100000\nmnt_id:\t29\n", 1048576, true},
+		{"pos:\t0\n", 0, true},
+		{"flags:\t0100000\n", 0, false},
+		{"pos:\tabc\n", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseFdinfoPos(tt.fdinfo)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseFdinfoPos(%q) = %d, %v, want %d, %v", tt.fdinfo, got, ok, tt.want, tt.ok)
+		}
+	}
+}
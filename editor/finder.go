@@ -144,6 +144,13 @@ func (f *Finder) showResult(args []interface{}) {
 			resultItem.setItem(text, "file", bufmatch)
 		} else if resultType == "dir" {
 			resultItem.setItem(text, "dir", match[i])
+		} else if resultType == "path" {
+			// the directories completing the typed path end with the separator
+			if strings.HasSuffix(text, "/") {
+				resultItem.setItem(strings.TrimSuffix(text, "/"), "dir", match[i])
+			} else {
+				resultItem.setItem(text, "file", match[i])
+			}
 		} else if resultType == "file_line" {
 			resultItem.setItem(text, itemTypes[i], itemMatches[i])
 		} else {
@@ -0,0 +1,202 @@
+package editor
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+// The ranks of the GUI chrome in the Tab order, from the top left to the
+// bottom right of the window
+const (
+	focusRankPalette = iota
+	focusRankTabline
+	focusRankSidebar
+	focusRankPanel
+	focusRankNotification
+	// focusRankDialog is of the widgets in the dialogs, which are windows of
+	// their own out of the Tab order of the chrome
+	focusRankDialog
+)
+
+// focusTarget is a widget of the GUI chrome which takes the keyboard focus
+type focusTarget struct {
+	widget *widgets.QWidget
+	rank   int
+	// activate is called by Enter and Space, as the click
+	activate func()
+}
+
+// focusChain makes the GUI chrome operable without the mouse. The widgets
+// of the chrome take the focus only by Tab, so that the clicks don't take
+// it from the grid, and the grid keeps Tab for nvim. :GonvimFocusGui moves
+// the focus to the chrome, Tab and Shift-Tab move it in the order of the
+// ranks, and Escape returns it to the grid. The focused widget is outlined
+// by the focus ring.
+type focusChain struct {
+	targets []*focusTarget
+	filter  *core.QObject
+	ring    *widgets.QWidget
+}
+
+func newFocusChain() *focusChain {
+	c := &focusChain{}
+	c.filter = core.NewQObject(nil)
+	c.filter.ConnectEventFilter(c.eventFilter)
+
+	return c
+}
+
+// add makes the widget of the chrome focusable by Tab
+func (c *focusChain) add(widget *widgets.QWidget, rank int, activate func()) {
+	widget.SetFocusPolicy(core.Qt__TabFocus)
+	c.addTarget(widget, rank, activate)
+}
+
+// addDialog makes the widget of a dialog or a settings window outlined by
+// the focus ring, and Escape closes the window. The window takes the focus
+// by the click, so that the widget keeps its focus policy and the Tab order
+// of the window.
+func (c *focusChain) addDialog(widget *widgets.QWidget, activate func()) {
+	c.addTarget(widget, focusRankDialog, activate)
+}
+
+func (c *focusChain) addTarget(widget *widgets.QWidget, rank int, activate func()) {
+	widget.InstallEventFilter(c.filter)
+	target := &focusTarget{
+		widget:   widget,
+		rank:     rank,
+		activate: activate,
+	}
+	c.targets = append(c.targets, target)
+	widget.ConnectDestroyed(func(*core.QObject) {
+		c.remove(target)
+	})
+}
+
+func (c *focusChain) remove(target *focusTarget) {
+	for i, t := range c.targets {
+		if t == target {
+			c.targets = append(c.targets[:i], c.targets[i+1:]...)
+			break
+		}
+	}
+	if c.ring != nil {
+		c.ring.Hide()
+	}
+}
+
+func (c *focusChain) target(object *core.QObject) *focusTarget {
+	for _, t := range c.targets {
+		if t.widget.Pointer() == object.Pointer() {
+			return t
+		}
+	}
+
+	return nil
+}
+
+// visibleTargets returns the targets which can take the focus, in the Tab
+// order
+func (c *focusChain) visibleTargets() []*focusTarget {
+	var targets []*focusTarget
+	for _, t := range c.targets {
+		if t.widget.Pointer() == nil || !t.widget.IsVisible() || t.rank == focusRankDialog {
+			continue
+		}
+		targets = append(targets, t)
+	}
+	sort.SliceStable(targets, func(i, j int) bool {
+		return targets[i].rank < targets[j].rank
+	})
+
+	return targets
+}
+
+// focusGui moves the focus from the grid to the first widget of the chrome.
+// The Tab order is set again, since the widgets are created and destroyed
+// in any order.
+func (c *focusChain) focusGui(ws *Workspace) {
+	targets := c.visibleTargets()
+	if len(targets) == 0 {
+		return
+	}
+	previous := ws.widget
+	for _, t := range targets {
+		widgets.QWidget_SetTabOrder(previous, t.widget)
+		previous = t.widget
+	}
+	targets[0].widget.SetFocus(core.Qt__TabFocusReason)
+}
+
+// focusGrid returns the focus to the grid of the active workspace
+func (e *Editor) focusGrid() {
+	if e.active >= len(e.workspaces) || e.workspaces[e.active] == nil {
+		return
+	}
+	e.workspaces[e.active].widget.SetFocus(core.Qt__OtherFocusReason)
+}
+
+func (c *focusChain) eventFilter(watched *core.QObject, event *core.QEvent) bool {
+	switch event.Type() {
+	case core.QEvent__FocusIn:
+		if t := c.target(watched); t != nil {
+			c.showRing(t.widget)
+		}
+	case core.QEvent__FocusOut:
+		if c.ring != nil {
+			c.ring.Hide()
+		}
+	case core.QEvent__KeyPress:
+		keyEvent := gui.NewQKeyEventFromPointer(event.Pointer())
+		switch core.Qt__Key(keyEvent.Key()) {
+		case core.Qt__Key_Escape:
+			if t := c.target(watched); t != nil && t.rank == focusRankDialog {
+				t.widget.Window().Close()
+			}
+			editor.focusGrid()
+			return true
+		case core.Qt__Key_Return, core.Qt__Key_Enter, core.Qt__Key_Space:
+			t := c.target(watched)
+			if t == nil || t.activate == nil {
+				return false
+			}
+			t.activate()
+			return true
+		}
+	}
+
+	return false
+}
+
+// showRing outlines the focused widget. The ring is a widget over the
+// chrome, so that it looks the same on the widgets with their own styles.
+// It moves to the window of the widget, the main window or a dialog.
+func (c *focusChain) showRing(widget *widgets.QWidget) {
+	window := widget.Window()
+	if c.ring == nil {
+		c.ring = widgets.NewQWidget(window, 0)
+		c.ring.SetObjectName("focusring")
+		c.ring.SetAttribute(core.Qt__WA_TransparentForMouseEvents, true)
+		c.ring.SetAttribute(core.Qt__WA_StyledBackground, true)
+		c.ring.ConnectDestroyed(func(*core.QObject) {
+			c.ring = nil
+		})
+	} else if c.ring.ParentWidget().Pointer() != window.Pointer() {
+		c.ring.SetParent(window)
+	}
+	color := editor.colors.fg
+	if editor.colors.matchFg != nil {
+		color = editor.colors.matchFg
+	}
+	if color != nil {
+		c.ring.SetStyleSheet(withUserStyle(fmt.Sprintf(" #focusring { background: transparent; border: 2px solid %s; border-radius: 3px; } ", color.Hex())))
+	}
+	pos := window.MapFromGlobal(widget.MapToGlobal(core.NewQPoint2(0, 0)))
+	c.ring.SetGeometry2(pos.X()-2, pos.Y()-2, widget.Width()+4, widget.Height()+4)
+	c.ring.Show()
+	c.ring.Raise()
+}
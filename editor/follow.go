@@ -0,0 +1,121 @@
+package editor
+
+import (
+	"fmt"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/therecipe/qt/core"
+)
+
+// followLua calls the callback of the follow mode, which returns the line of
+// the follower window to scroll to, and scrolls the follower to it at once.
+// Without the callback, the follower follows the same line. The window
+// slides to the line by its pixel scroll animation.
+const followLua = `
+local ctx = ...
+local callback = %s
+local line = ctx.line
+if callback ~= nil then
+  line = callback(ctx)
+end
+if type(line) ~= "number" or line <= 0 then
+  return 0
+end
+vim.api.nvim_win_call(ctx.follow_win, function()
+  vim.fn.winrestview({topline = line})
+end)
+return line
+`
+
+// followMode scrolls the follower window, e.g. the documentation or the test
+// output, to the section relevant to the cursor position in the other windows.
+// The section is given by the Lua callback of follow.callback.
+type followMode struct {
+	ws       *Workspace
+	follower int
+
+	cursor map[string]interface{}
+	delay  *core.QTimer
+}
+
+func newFollowMode(ws *Workspace) *followMode {
+	f := &followMode{
+		ws: ws,
+	}
+	f.delay = core.NewQTimer(nil)
+	f.delay.SetSingleShot(true)
+	f.delay.ConnectTimeout(f.follow)
+
+	return f
+}
+
+// toggle starts following in the window, or stops if the window is already
+// the follower or id is 0
+func (f *followMode) toggle(id int) {
+	if id == 0 || id == f.follower {
+		f.stop()
+		return
+	}
+	f.follower = id
+	autocmds := fmt.Sprintf(`aug GonvimAuFollow | au! | aug END
+au GonvimAuFollow CursorMoved,CursorMovedI * if win_getid() != %d | call rpcnotify(0, "Gui", "gonvim_follow_cursor", win_getid(), bufnr(), line("."), col(".")) | endif
+au GonvimAuFollow WinClosed %d call rpcnotify(0, "Gui", "gonvim_follow", 0)`, id, id)
+	f.ws.nvim.Command(fmt.Sprintf(`call execute(%s)`, util.SplitVimscript(autocmds)))
+	editor.pushNotification(NotifyInfo, 3, "[Goneovim] The window follows the cursor")
+}
+
+func (f *followMode) stop() {
+	if f.follower == 0 {
+		return
+	}
+	f.follower = 0
+	f.delay.Stop()
+	f.ws.nvim.Command("aug GonvimAuFollow | au! | aug END")
+}
+
+// cursorMoved is called by the gonvim_follow_cursor notification.
+// args: [winid, bufnr, line, col]
+func (f *followMode) cursorMoved(args []interface{}) {
+	if f.follower == 0 || len(args) < 4 {
+		return
+	}
+	f.cursor = map[string]interface{}{
+		"win":        util.ReflectToInt(args[0]),
+		"buf":        util.ReflectToInt(args[1]),
+		"line":       util.ReflectToInt(args[2]),
+		"col":        util.ReflectToInt(args[3]),
+		"follow_win": f.follower,
+	}
+	// Wait for the cursor to settle before asking the callback
+	f.delay.Start(editor.config.Follow.Delay)
+}
+
+// follow asks the callback for the line and scrolls the follower to it off
+// the GUI thread
+func (f *followMode) follow() {
+	if f.follower == 0 || f.cursor == nil {
+		return
+	}
+	callback := editor.config.Follow.Callback
+	if callback == "" {
+		callback = "nil"
+	}
+	neovim := f.ws.nvim
+	cursor := f.cursor
+	go func() {
+		var line int
+		err := neovim.ExecuteLua(fmt.Sprintf(followLua, callback), &line, cursor)
+		if err == nil {
+			return
+		}
+		editor.pushNotification(NotifyWarn, -1, fmt.Sprintf("[Goneovim] The follow callback failed: %s", err))
+		f.ws.guiUpdates <- []interface{}{"gonvim_follow", 0}
+		f.ws.signal.GuiSignal()
+	}()
+}
+
+// isFollower returns true if the window is the follower, whose scroll is
+// animated for follow.duration
+func (f *followMode) isFollower(id int) bool {
+	return f != nil && f.follower != 0 && f.follower == id
+}
@@ -9,18 +9,24 @@ import (
 // Font is
 type Font struct {
 	ws                 *Workspace
+	family             string
+	size               float64
 	fontNew            *gui.QFont
 	fontMetrics        *gui.QFontMetricsF
 	defaultFont        *gui.QFont
 	defaultFontMetrics *gui.QFontMetricsF
 	width              int
 	truewidth          float64
+	advance            float64
+	letterSpace        float64
 	italicWidth        float64
 	ascent             float64
 	height             int
 	lineHeight         int
 	lineSpace          int
 	shift              int
+	emoji              *gui.QFont
+	emojiSize          int
 }
 
 func fontSizeNew(font *gui.QFont) (int, int, float64, float64, float64) {
@@ -48,7 +54,7 @@ func initFontNew(family string, size float64, lineSpace int, fast bool) *Font {
 	font := gui.NewQFont()
 	font.SetFamily(family)
 	font.SetPointSizeF(size)
-	font.SetWeight(int(gui.QFont__Normal))
+	applyFontRendering(font)
 
 	// font.SetStyleHint(gui.QFont__TypeWriter, gui.QFont__PreferDefault | gui.QFont__ForceIntegerMetrics)
 	font.SetFixedPitch(true)
@@ -69,12 +75,15 @@ func initFontNew(family string, size float64, lineSpace int, fast bool) *Font {
 	}
 	defaultFont := gui.NewQFont()
 	return &Font{
+		family:             family,
+		size:               size,
 		fontNew:            font,
 		fontMetrics:        gui.NewQFontMetricsF(font),
 		defaultFont:        defaultFont,
 		defaultFontMetrics: gui.NewQFontMetricsF(defaultFont),
 		width:              width,
 		truewidth:          truewidth,
+		advance:            truewidth,
 		height:             height,
 		lineHeight:         height + lineSpace,
 		lineSpace:          lineSpace,
@@ -85,13 +94,17 @@ func initFontNew(family string, size float64, lineSpace int, fast bool) *Font {
 }
 
 func (f *Font) change(family string, size float64) {
+	f.family = family
+	f.size = size
 	f.fontNew.SetFamily(family)
 	f.fontNew.SetPointSizeF(size)
+	// The advance is measured without the letter spacing
+	f.fontNew.SetLetterSpacing(gui.QFont__AbsoluteSpacing, 0)
+	_, height, truewidth, ascent, italicWidth := fontSizeNew(f.fontNew)
+	f.fontNew.SetLetterSpacing(gui.QFont__AbsoluteSpacing, f.letterSpace)
 	f.fontMetrics = gui.NewQFontMetricsF(f.fontNew)
-	width, height, truewidth, ascent, italicWidth := fontSizeNew(f.fontNew)
-	f.width = width
 	f.height = height
-	f.truewidth = truewidth
+	f.setAdvance(truewidth)
 	f.lineHeight = height + f.lineSpace
 	f.ascent = ascent
 	f.shift = int(float64(f.lineSpace)/2 + ascent)
@@ -100,6 +113,22 @@ func (f *Font) change(family string, size float64) {
 	f.ws.screen.purgeTextCacheForWins()
 }
 
+// setAdvance sets the advance of the glyphs measured without the letter
+// spacing, and the cell width with it
+func (f *Font) setAdvance(advance float64) {
+	f.advance = advance
+	f.truewidth = cellAdvance(advance, f.letterSpace)
+	f.width = int(math.Ceil(f.truewidth))
+}
+
+// setLetterSpace adds the space in pixels to the cell width. The text runs
+// are spaced by Qt in the same amount, so that the glyphs stay in the cells.
+func (f *Font) setLetterSpace(letterSpace float64) {
+	f.letterSpace = letterSpace
+	f.fontNew.SetLetterSpacing(gui.QFont__AbsoluteSpacing, letterSpace)
+	f.fontMetrics = gui.NewQFontMetricsF(f.fontNew)
+	f.setAdvance(f.advance)
+}
 
 func (f *Font) changeLineSpace(lineSpace int) {
 	f.lineSpace = lineSpace
@@ -0,0 +1,88 @@
+package editor
+
+import (
+	"github.com/therecipe/qt/gui"
+)
+
+// fontWeights are the weights of QFont from the lightest
+var fontWeights = []int{
+	int(gui.QFont__Thin),
+	int(gui.QFont__ExtraLight),
+	int(gui.QFont__Light),
+	int(gui.QFont__Normal),
+	int(gui.QFont__Medium),
+	int(gui.QFont__DemiBold),
+	int(gui.QFont__Bold),
+	int(gui.QFont__ExtraBold),
+	int(gui.QFont__Black),
+}
+
+// applyFontRendering sets the antialiasing, the hinting and the stroke
+// thinning of the config to the font. The font is used both for drawing the
+// text directly and for caching the glyphs, so both look the same.
+func applyFontRendering(font *gui.QFont) {
+	font.SetStyleStrategy(fontStyleStrategy(editor.config.Editor.FontAntialias))
+	font.SetHintingPreference(fontHintingPreference(editor.config.Editor.FontHinting))
+	setFontBold(font, false)
+}
+
+func fontStyleStrategy(antialias string) gui.QFont__StyleStrategy {
+	switch antialias {
+	case "subpixel":
+		return gui.QFont__PreferAntialias
+	case "grayscale":
+		return gui.QFont__PreferAntialias | gui.QFont__NoSubpixelAntialias
+	case "none":
+		return gui.QFont__NoAntialias
+	default:
+		return gui.QFont__PreferDefault
+	}
+}
+
+func fontHintingPreference(hinting string) gui.QFont__HintingPreference {
+	switch hinting {
+	case "none":
+		return gui.QFont__PreferNoHinting
+	case "vertical":
+		return gui.QFont__PreferVerticalHinting
+	case "full":
+		return gui.QFont__PreferFullHinting
+	default:
+		return gui.QFont__PreferDefaultHinting
+	}
+}
+
+// setFontBold sets the weight of the bold or the normal text to the font.
+// With editor.fontThinStrokes, the weight is a step lighter, which thins the
+// strokes of the fonts with the lighter faces, e.g. the variable fonts.
+func setFontBold(font *gui.QFont, bold bool) {
+	weight := int(gui.QFont__Normal)
+	if bold {
+		weight = int(gui.QFont__Bold)
+	}
+	if editor.config.Editor.FontThinStrokes {
+		weight = thinnerWeight(weight)
+	}
+	font.SetWeight(weight)
+}
+
+// thinnerWeight returns the weight a step lighter than the weight
+func thinnerWeight(weight int) int {
+	thinner := fontWeights[0]
+	for _, w := range fontWeights {
+		if w >= weight {
+			break
+		}
+		thinner = w
+	}
+
+	return thinner
+}
+
+// isSubpixelGlyphs reports whether the glyphs are cached with the subpixel
+// antialiasing. The subpixels are blended with the background, so the glyphs
+// are cached on their opaque background, which isn't possible in the
+// transparent windows.
+func isSubpixelGlyphs() bool {
+	return editor.config.Editor.FontAntialias == "subpixel" && editor.config.Editor.Transparent >= 1.0
+}
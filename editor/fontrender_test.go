@@ -0,0 +1,26 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/therecipe/qt/gui"
+)
+
+func TestThinnerWeight(t *testing.T) {
+	tests := []struct {
+		weight int
+		want   int
+	}{
+		{int(gui.QFont__Normal), int(gui.QFont__Light)},
+		{int(gui.QFont__Bold), int(gui.QFont__DemiBold)},
+		// Between the weights
+		{60, int(gui.QFont__Medium)},
+		// No lighter weight
+		{int(gui.QFont__Thin), int(gui.QFont__Thin)},
+	}
+	for _, tt := range tests {
+		if got := thinnerWeight(tt.weight); got != tt.want {
+			t.Errorf("thinnerWeight(%d) = %d, want %d", tt.weight, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package editor
+
+import (
+	"math"
+
+	"github.com/therecipe/qt/core"
+)
+
+// The redraw events of a frame may arrive from nvim in several batches. The
+// grid events only record the damaged area of the windows, and the widgets
+// are updated for the whole frame on the flush event, so that a half drawn
+// frame is never painted.
+
+// flush repaints the damage of the windows accumulated since the last flush
+func (s *Screen) flush() {
+	s.windows.Range(func(grid, winITF interface{}) bool {
+		win := winITF.(*Window)
+		if win == nil {
+			return true
+		}
+		// if grid is dirty, we remove this grid
+		if win.isGridDirty {
+			win.hide()
+			s.windows.Delete(grid)
+		}
+		// Fill entire background if background color changed
+		bg := win.normalBackground()
+		if !win.background.equals(bg) {
+			win.background = bg.copy()
+			win.fill()
+			win.queueRedrawAll()
+		}
+		win.flushDamage()
+
+		return true
+	})
+}
+
+// scrollBlit is the scroll of the pixels of the scroll region on the next
+// flush. The rendered rows are moved with QWidget::scroll, and only the rows
+// exposed by the scroll are repainted.
+type scrollBlit struct {
+	region [4]int
+	count  int
+}
+
+// queueRedrawScroll marks the scroll region scrolled by count rows to
+// repaint on the next flush
+func (w *Window) queueRedrawScroll(count int) {
+	region := w.scrollRegion
+	top, bot := region[0], region[1]
+	left, right := region[2], region[3]
+	if top == 0 && bot == 0 && left == 0 && right == 0 {
+		top, bot, left, right = 0, w.rows-1, 0, w.cols-1
+	}
+
+	if w.blit == nil && w.canBlit(top, bot) {
+		w.blit = &scrollBlit{
+			region: [4]int{top, bot, left, right},
+			count:  count,
+		}
+		w.shiftRowHashes(top, bot, left == 0 && right == w.cols-1, count)
+		// The rows moved by the blit show the content moved by the scroll
+		for row := top; row <= bot && row < len(w.lenOldContent); row++ {
+			w.lenOldContent[row] = maxInt(w.lenOldContent[row], w.lenContent[row])
+		}
+		exposedTop, exposedBot := blitExposedRows(top, bot, count)
+		w.queueRedraw(left, exposedTop, right-left+1, exposedBot-exposedTop+1)
+		return
+	}
+
+	// The pixels of the region no longer match the content to blit, so the
+	// whole region is repainted
+	w.blit = nil
+	w.invalidateRowHashes(top, bot)
+	w.queueRedraw(left, top, right-left+1, bot-top+1)
+}
+
+// canBlit returns true if the pixels of the rows of the scroll region are
+// up to date to be moved by the scroll
+func (w *Window) canBlit(top, bot int) bool {
+	if w.scrollDust[1] != 0 || w.scrollAnim.active() || w.hasBlitOverlay() {
+		return false
+	}
+	area := w.queueRedrawArea
+	if area[2] <= area[0] || area[3] <= area[1] {
+		return true
+	}
+
+	// The damage is outside of the region
+	return area[3] <= top || area[1] > bot
+}
+
+// hasBlitOverlay returns true if the window draws the overlays which do not
+// move with the content, the indicators of the horizontal scroll, the
+// outline of the blockwise visual selection and the read-only tint. The blit
+// would move their pixels with the rows, so the region is repainted instead.
+func (w *Window) hasBlitOverlay() bool {
+	if w.hasHScroll() || w.blockVisual != nil {
+		return true
+	}
+
+	return w.readOnly && editor.config.ReadOnly.Tint != ""
+}
+
+// blitExposedRows returns the rows of the region which the scroll of count
+// rows exposes
+func blitExposedRows(top, bot, count int) (int, int) {
+	if count > 0 {
+		return maxInt(bot-count+1, top), bot
+	}
+
+	return top, minInt(top-count-1, bot)
+}
+
+// shiftRowHashes moves the hashes of the rows with the content. If the
+// region is narrower than the window, the rows are partly scrolled, and
+// their hashes are invalidated.
+func (w *Window) shiftRowHashes(top, bot int, fullWidth bool, count int) {
+	if !fullWidth || bot >= len(w.rowHashes) {
+		w.invalidateRowHashes(top, bot)
+		return
+	}
+	if count > 0 {
+		copy(w.rowHashes[top:bot+1-count], w.rowHashes[top+count:bot+1])
+	} else {
+		copy(w.rowHashes[top-count:bot+1], w.rowHashes[top:bot+1+count])
+	}
+	exposedTop, exposedBot := blitExposedRows(top, bot, count)
+	w.invalidateRowHashes(exposedTop, exposedBot)
+}
+
+// flushDamage repaints the damaged rows of the window
+func (w *Window) flushDamage() {
+	if w.blit != nil {
+		w.flushBlit()
+	}
+	top, bot := damagedRows(w.queueRedrawArea, w.rows, w.scrollDust[1] != 0, editor.config.Editor.IndentGuide)
+	w.queueRedrawArea = [4]int{w.cols, w.rows, 0, 0}
+	if top > bot {
+		return
+	}
+	w.updateRows(top, bot)
+}
+
+// flushBlit moves the rendered pixels of the scroll region
+func (w *Window) flushBlit() {
+	b := w.blit
+	w.blit = nil
+	top, bot, left, right := b.region[0], b.region[1], b.region[2], b.region[3]
+	if w.scrollDust[1] != 0 || w.scrollAnim.active() || w.hasBlitOverlay() {
+		w.queueRedraw(left, top, right-left+1, bot-top+1)
+		return
+	}
+	font := w.getFont()
+	x := int(float64(left) * font.truewidth)
+	width := int(math.Ceil(float64(right+1)*font.truewidth)) - x
+	w.widget.Scroll2(
+		0,
+		-b.count*font.lineHeight,
+		core.NewQRect4(x, top*font.lineHeight, width, (bot-top+1)*font.lineHeight),
+	)
+}
+
+// damagedRows returns the rows to repaint for the damaged area. All the rows
+// are repainted while smooth scrolling, and the indent guides of the row
+// below the damage depend on the damaged row.
+func damagedRows(area [4]int, rows int, scrolling, indentGuide bool) (int, int) {
+	if area[2] <= area[0] || area[3] <= area[1] {
+		return 0, -1
+	}
+	if scrolling {
+		return 0, rows
+	}
+	top, bot := area[1], area[3]-1
+	if indentGuide {
+		bot++
+	}
+	if bot > rows {
+		bot = rows
+	}
+
+	return top, bot
+}
@@ -0,0 +1,54 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestDamagedRows(t *testing.T) {
+	tests := []struct {
+		area                   [4]int
+		rows                   int
+		scrolling, indentGuide bool
+		top, bot               int
+	}{
+		// No damage since the last flush
+		{[4]int{80, 24, 0, 0}, 24, false, false, 0, -1},
+		{[4]int{0, 3, 80, 4}, 24, false, false, 3, 3},
+		{[4]int{0, 3, 80, 6}, 24, false, false, 3, 5},
+		// The indent guide of the next row
+		{[4]int{0, 3, 80, 4}, 24, false, true, 3, 4},
+		{[4]int{0, 0, 80, 24}, 24, false, true, 0, 24},
+		// Smooth scrolling
+		{[4]int{0, 3, 80, 4}, 24, true, false, 0, 24},
+		{[4]int{80, 24, 0, 0}, 24, true, false, 0, -1},
+	}
+	for _, tt := range tests {
+		top, bot := damagedRows(tt.area, tt.rows, tt.scrolling, tt.indentGuide)
+		if top != tt.top || bot != tt.bot {
+			t.Errorf("damagedRows(%v, %d, %v, %v) = %d, %d, want %d, %d", tt.area, tt.rows, tt.scrolling, tt.indentGuide, top, bot, tt.top, tt.bot)
+		}
+	}
+}
+
+func TestBlitExposedRows(t *testing.T) {
+	tests := []struct {
+		top, bot, count  int
+		wantTop, wantBot int
+	}{
+		// <C-e> exposes the bottom rows
+		{0, 39, 1, 39, 39},
+		{0, 39, 3, 37, 39},
+		// <C-y> exposes the top rows
+		{0, 39, -1, 0, 0},
+		{5, 20, -4, 5, 8},
+		// The scroll larger than the region exposes all of it
+		{5, 10, 20, 5, 10},
+		{5, 10, -20, 5, 10},
+	}
+	for _, tt := range tests {
+		top, bot := blitExposedRows(tt.top, tt.bot, tt.count)
+		if top != tt.wantTop || bot != tt.wantBot {
+			t.Errorf("blitExposedRows(%d, %d, %d) = %d, %d, want %d, %d", tt.top, tt.bot, tt.count, top, bot, tt.wantTop, tt.wantBot)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package editor
+
+import (
+	"math"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+)
+
+// defaultFrameInterval is the interval of the frames in milliseconds if the
+// refresh rate of the display is unknown, or the vsync timing is disabled
+const defaultFrameInterval = 16
+
+// frameClock drives all the animations by a single timer, so that they step
+// in the same frame instead of their own timers firing at odd phases. With
+// editor.vsyncAnimation, the interval matches the refresh rate of the display
+// the window is on, and the cursor blinks on the frames too.
+type frameClock struct {
+	timer    *core.QTimer
+	interval int
+	nextID   int
+	// animations are called on every frame while they are subscribed
+	animations map[int]func()
+	// pending are called once on the next frame
+	pending []func()
+}
+
+func newFrameClock() *frameClock {
+	c := &frameClock{
+		interval:   defaultFrameInterval,
+		animations: make(map[int]func()),
+	}
+	c.timer = core.NewQTimer(nil)
+	c.timer.SetTimerType(core.Qt__PreciseTimer)
+	c.timer.ConnectTimeout(c.tick)
+
+	return c
+}
+
+// connect matches the interval to the display the window is on. It must be
+// called after the window is shown.
+func (c *frameClock) connect() {
+	if !editor.config.Editor.VsyncAnimation {
+		return
+	}
+	handle := editor.window.WindowHandle()
+	if handle == nil {
+		return
+	}
+	handle.ConnectScreenChanged(c.screenChanged)
+	c.screenChanged(handle.Screen())
+}
+
+func (c *frameClock) screenChanged(screen *gui.QScreen) {
+	if screen == nil {
+		return
+	}
+	c.interval = frameInterval(screen.RefreshRate())
+	if c.timer.IsActive() {
+		c.timer.SetInterval(c.interval)
+	}
+}
+
+// frameInterval returns the interval in milliseconds of the frames of the
+// refresh rate in Hz
+func frameInterval(rate float64) int {
+	if rate <= 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return defaultFrameInterval
+	}
+	interval := int(math.Round(1000 / rate))
+	if interval < 4 {
+		return 4
+	}
+	if interval > 50 {
+		return 50
+	}
+
+	return interval
+}
+
+// subscribe calls the animation on every frame until it is unsubscribed by
+// the returned id
+func (c *frameClock) subscribe(animation func()) int {
+	c.nextID++
+	c.animations[c.nextID] = animation
+	c.start()
+
+	return c.nextID
+}
+
+func (c *frameClock) unsubscribe(id int) {
+	delete(c.animations, id)
+}
+
+// nextFrame calls fn once on the next frame. Without the vsync timing, fn is
+// called at once.
+func (c *frameClock) nextFrame(fn func()) {
+	if !editor.config.Editor.VsyncAnimation {
+		fn()
+		return
+	}
+	c.pending = append(c.pending, fn)
+	c.start()
+}
+
+func (c *frameClock) start() {
+	if !c.timer.IsActive() {
+		c.timer.Start(c.interval)
+	}
+}
+
+func (c *frameClock) tick() {
+	pending := c.pending
+	c.pending = nil
+	for _, fn := range pending {
+		fn()
+	}
+	for _, animation := range c.animations {
+		animation()
+	}
+	if len(c.animations) == 0 && len(c.pending) == 0 {
+		c.timer.Stop()
+	}
+}
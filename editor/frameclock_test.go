@@ -0,0 +1,31 @@
+package editor
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFrameInterval(t *testing.T) {
+	tests := []struct {
+		rate float64
+		want int
+	}{
+		{60, 17},
+		{59.94, 17},
+		{120, 8},
+		{144, 7},
+		{240, 4},
+		// Unknown rates
+		{0, defaultFrameInterval},
+		{-1, defaultFrameInterval},
+		{math.NaN(), defaultFrameInterval},
+		// Clamped
+		{1000, 4},
+		{10, 50},
+	}
+	for _, tt := range tests {
+		if got := frameInterval(tt.rate); got != tt.want {
+			t.Errorf("frameInterval(%v) = %d, want %d", tt.rate, got, tt.want)
+		}
+	}
+}
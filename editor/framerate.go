@@ -0,0 +1,78 @@
+package editor
+
+import (
+	"math"
+	"time"
+
+	"github.com/therecipe/qt/core"
+)
+
+// flushCoalesceInterval is the shortest interval between the repaints of
+// the flushes. The flushes of the bursts of the redraws, e.g. in the macro
+// playback or the output of :grep, are painted at once after the interval,
+// while the flush after a pause is painted at once.
+const flushCoalesceInterval = 2 * time.Millisecond
+
+// flushInterval returns the shortest interval between the repaints of the
+// flushes for the max FPS, or flushCoalesceInterval if the repaints are not
+// limited. With the vsync timing, the interval is snapped up to the whole
+// frames of the display, so that the repaints keep in step with the refresh.
+func flushInterval(maxFPS, displayInterval int, vsync bool) time.Duration {
+	if maxFPS <= 0 {
+		return flushCoalesceInterval
+	}
+	interval := 1000.0 / float64(maxFPS)
+	if vsync && displayInterval > 0 {
+		frames := math.Max(math.Ceil(interval/float64(displayInterval)-0.05), 1)
+		interval = frames * float64(displayInterval)
+	}
+
+	if interval < float64(flushCoalesceInterval/time.Millisecond) {
+		return flushCoalesceInterval
+	}
+
+	return time.Duration(interval * float64(time.Millisecond))
+}
+
+// flushDelay returns the time to wait for the next repaint after the last
+// one, or 0 if it can be painted now
+func flushDelay(last, now time.Time, interval time.Duration) time.Duration {
+	wait := interval - now.Sub(last)
+	if wait < 0 {
+		return 0
+	}
+
+	return wait
+}
+
+// throttleFlush delays the repaint of the flush to the next frame, and
+// returns true if it is delayed. The damage of the delayed flushes is
+// accumulated and repainted at once by the timer. While a huge file is
+// loading, the repaints are throttled further to keep the UI responsive.
+func (w *Workspace) throttleFlush() bool {
+	interval := flushInterval(editor.config.Editor.MaxFPS, editor.frameClock.interval, editor.config.Editor.VsyncAnimation)
+	if w.fileLoad != nil && w.fileLoad.loading && interval < fileLoadFlushInterval {
+		interval = fileLoadFlushInterval
+	}
+	if w.flushTimer == nil {
+		w.flushTimer = core.NewQTimer(nil)
+		w.flushTimer.SetSingleShot(true)
+		w.flushTimer.SetTimerType(core.Qt__PreciseTimer)
+		w.flushTimer.ConnectTimeout(func() {
+			w.lastFlush = time.Now()
+			w.screen.flush()
+			w.drawOtherUI()
+		})
+	}
+	if w.flushTimer.IsActive() {
+		return true
+	}
+	wait := flushDelay(w.lastFlush, time.Now(), interval)
+	if wait == 0 {
+		w.lastFlush = time.Now()
+		return false
+	}
+	w.flushTimer.Start(int(math.Ceil(float64(wait) / float64(time.Millisecond))))
+
+	return true
+}
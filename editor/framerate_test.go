@@ -0,0 +1,49 @@
+package editor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFlushInterval(t *testing.T) {
+	tests := []struct {
+		maxFPS          int
+		displayInterval int
+		vsync           bool
+		want            time.Duration
+	}{
+		{0, 16, true, 2 * time.Millisecond},
+		{0, 16, false, 2 * time.Millisecond},
+		{1000, 0, false, 2 * time.Millisecond},
+		{60, 16, false, 16666666 * time.Nanosecond},
+		{30, 16, false, 33333333 * time.Nanosecond},
+		{60, 17, true, 17 * time.Millisecond},
+		{120, 17, true, 17 * time.Millisecond},
+		{30, 17, true, 34 * time.Millisecond},
+		{120, 8, true, 8 * time.Millisecond},
+		{45, 8, true, 24 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := flushInterval(tt.maxFPS, tt.displayInterval, tt.vsync); got != tt.want {
+			t.Errorf("flushInterval(%d, %d, %v) = %v, want %v", tt.maxFPS, tt.displayInterval, tt.vsync, got, tt.want)
+		}
+	}
+}
+
+func TestFlushDelay(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		since time.Duration
+		want  time.Duration
+	}{
+		{0, 33 * time.Millisecond},
+		{10 * time.Millisecond, 23 * time.Millisecond},
+		{33 * time.Millisecond, 0},
+		{time.Second, 0},
+	}
+	for _, tt := range tests {
+		if got := flushDelay(now.Add(-tt.since), now, 33*time.Millisecond); got != tt.want {
+			t.Errorf("flushDelay(%v) = %v, want %v", tt.since, got, tt.want)
+		}
+	}
+}
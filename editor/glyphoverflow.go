@@ -0,0 +1,81 @@
+package editor
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// glyphOverflowRanges are the ranges of the chars of the glyphOverflow
+// config, whose glyphs are drawn overflowing into the next cell when it is
+// blank, e.g. the powerline separators and the icons of the Nerd Fonts,
+// which are designed slightly wider than the cell
+var glyphOverflowRanges [][2]rune
+
+// parseRuneRanges returns the ranges of the runes of the config, e.g.
+// "U+E0B0-U+E0D7", "E0A0-E0A3" or "U+F101". The invalid ones are ignored.
+func parseRuneRanges(ranges []string) [][2]rune {
+	var result [][2]rune
+	for _, s := range ranges {
+		first, last := s, s
+		if i := strings.Index(s, "-"); i >= 0 {
+			first, last = s[:i], s[i+1:]
+		}
+		from, ok := parseRune(first)
+		if !ok {
+			continue
+		}
+		to, ok := parseRune(last)
+		if !ok || to < from {
+			continue
+		}
+		result = append(result, [2]rune{from, to})
+	}
+
+	return result
+}
+
+// parseRune returns the rune of the hex code point, with or without "U+"
+func parseRune(s string) (rune, bool) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "U+"), "u+")
+	n, err := strconv.ParseUint(s, 16, 32)
+	if err != nil || n > utf8.MaxRune {
+		return 0, false
+	}
+
+	return rune(n), true
+}
+
+// inRuneRanges returns true if the first rune of the char is in the ranges
+func inRuneRanges(char string, ranges [][2]rune) bool {
+	if len(ranges) == 0 || char == "" {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(char)
+	for _, rr := range ranges {
+		if r >= rr[0] && r <= rr[1] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isGlyphOverflow returns true if the glyph of the char may overflow
+func isGlyphOverflow(char string) bool {
+	return inRuneRanges(char, glyphOverflowRanges)
+}
+
+// canOverflow returns true if the glyph of the cell at the column of the line
+// is drawn overflowing, which is when the next cell is blank
+func canOverflow(line []*Cell, col int) bool {
+	if col < 0 || col >= len(line) || line[col] == nil || !isGlyphOverflow(line[col].char) {
+		return false
+	}
+	if col+1 >= len(line) || line[col+1] == nil {
+		return true
+	}
+
+	return line[col+1].char == " "
+}
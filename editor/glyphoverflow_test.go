@@ -0,0 +1,57 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRuneRanges(t *testing.T) {
+	tests := []struct {
+		ranges []string
+		want   [][2]rune
+	}{
+		{nil, nil},
+		{[]string{"U+E0B0-U+E0D7"}, [][2]rune{{0xe0b0, 0xe0d7}}},
+		{[]string{"e0a0-e0a3", "U+F101"}, [][2]rune{{0xe0a0, 0xe0a3}, {0xf101, 0xf101}}},
+		{[]string{"u+E000 - u+F8FF"}, [][2]rune{{0xe000, 0xf8ff}}},
+		{[]string{"xyz", "E0D7-E0B0", "110000", ""}, nil},
+	}
+	for _, tt := range tests {
+		if got := parseRuneRanges(tt.ranges); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseRuneRanges(%q) = %v, want %v", tt.ranges, got, tt.want)
+		}
+	}
+}
+
+func TestCanOverflow(t *testing.T) {
+	defer func(ranges [][2]rune) { glyphOverflowRanges = ranges }(glyphOverflowRanges)
+	glyphOverflowRanges = [][2]rune{{0xe0b0, 0xe0d7}}
+
+	line := []*Cell{
+		{char: "\ue0b0"},
+		{char: " "},
+		{char: "\ue0b2"},
+		{char: "a"},
+		{char: "b"},
+		nil,
+		{char: "\ue0b4"},
+	}
+	tests := []struct {
+		col  int
+		want bool
+	}{
+		{0, true},
+		{1, false},
+		{2, false},
+		{3, false},
+		{5, false},
+		{6, true},
+		{7, false},
+		{-1, false},
+	}
+	for _, tt := range tests {
+		if got := canOverflow(line, tt.col); got != tt.want {
+			t.Errorf("canOverflow(%d) = %v, want %v", tt.col, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package editor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/akiyosi/goneovim/util"
+)
+
+// GonvimGridContentEvent is the rpcrequest method name which returns the
+// rendered grid contents.
+const GonvimGridContentEvent = "GonvimGridContent"
+
+// registerGridContent registers the request handler and the vim function
+// which external test harnesses and screenshot-diff tools can call to
+// get what goneovim actually displays.
+func (w *Workspace) registerGridContent() {
+	w.nvim.RegisterHandler(GonvimGridContentEvent, func(args ...interface{}) (interface{}, error) {
+		gridid := 0
+		if len(args) > 0 {
+			gridid = util.ReflectToInt(args[0])
+		}
+		return w.screen.exportGridContent(gridid), nil
+	})
+
+	gridContentFunction := fmt.Sprintf(`
+	function! GonvimGridContent(...) abort
+	    return rpcrequest(%d, "%s", get(a:, 1, 0))
+	endfunction
+	`, w.channel, GonvimGridContentEvent)
+	w.nvim.Command(fmt.Sprintf(`call execute(%s)`, util.SplitVimscript(gridContentFunction)))
+}
+
+// exportGridContent returns the contents of the grid specified by gridid.
+// If gridid is 0, the contents of all grids are returned.
+func (s *Screen) exportGridContent(gridid int) map[string]interface{} {
+	grids := []interface{}{}
+	highlights := make(map[string]interface{})
+
+	ids := []int{}
+	s.windows.Range(func(grid, winITF interface{}) bool {
+		win := winITF.(*Window)
+		if win == nil {
+			return true
+		}
+		id := grid.(int)
+		if gridid != 0 && id != gridid {
+			return true
+		}
+		ids = append(ids, id)
+		return true
+	})
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		win, ok := s.getWindow(id)
+		if !ok {
+			continue
+		}
+		grids = append(grids, win.exportContent(highlights))
+	}
+
+	return map[string]interface{}{
+		"grids":      grids,
+		"highlights": highlights,
+	}
+}
+
+// exportContent serializes Window.content. Each cell is represented as
+// [text, hl_id], and the resolved colors of each hl_id are stored into
+// highlights.
+func (w *Window) exportContent(highlights map[string]interface{}) map[string]interface{} {
+	w.paintMutex.Lock()
+	defer w.paintMutex.Unlock()
+
+	lines := []string{}
+	cells := [][]interface{}{}
+	for _, line := range w.content {
+		var buffer strings.Builder
+		lineCells := []interface{}{}
+		for _, cell := range line {
+			if cell == nil {
+				buffer.WriteString(" ")
+				lineCells = append(lineCells, []interface{}{" ", 0})
+				continue
+			}
+			buffer.WriteString(cell.char)
+			lineCells = append(lineCells, []interface{}{cell.char, cell.highlight.id})
+
+			key := fmt.Sprintf("%d", cell.highlight.id)
+			if _, ok := highlights[key]; !ok {
+				highlights[key] = cell.highlight.export()
+			}
+		}
+		lines = append(lines, buffer.String())
+		cells = append(cells, lineCells)
+	}
+
+	return map[string]interface{}{
+		"grid":   w.grid,
+		"win":    int(w.id),
+		"buffer": w.bufName,
+		"pos":    []int{w.pos[0], w.pos[1]},
+		"cols":   w.cols,
+		"rows":   w.rows,
+		"float":  w.isFloatWin,
+		"msg":    w.isMsgGrid,
+		"shown":  w.isShown(),
+		"lines":  lines,
+		"cells":  cells,
+	}
+}
+
+// export returns the highlight with the colors actually used for drawing.
+func (hl *Highlight) export() map[string]interface{} {
+	attrs := map[string]interface{}{
+		"name":          hl.hlName,
+		"fg":            hl.fg().Hex(),
+		"bg":            hl.bg().Hex(),
+		"bold":          hl.bold,
+		"italic":        hl.italic,
+		"underline":     hl.underline,
+		"undercurl":     hl.undercurl,
+		"strikethrough": hl.strikethrough,
+	}
+	if hl.special != nil {
+		attrs["sp"] = hl.special.Hex()
+	}
+
+	return attrs
+}
@@ -0,0 +1,53 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestWindow_exportContent(t *testing.T) {
+	hl := Highlight{
+		id:         3,
+		hlName:     "Comment",
+		foreground: &RGBA{10, 20, 30, 1.0},
+		background: &RGBA{40, 50, 60, 1.0},
+		italic:     true,
+	}
+	content := [][]*Cell{
+		{
+			&Cell{true, "a", hl},
+			&Cell{true, "b", hl},
+			nil,
+		},
+	}
+	w := &Window{
+		grid:    4,
+		content: content,
+		cols:    3,
+		rows:    1,
+	}
+
+	highlights := make(map[string]interface{})
+	got := w.exportContent(highlights)
+
+	lines := got["lines"].([]string)
+	if len(lines) != 1 || lines[0] != "ab " {
+		t.Errorf("lines = %q, want %q", lines, []string{"ab "})
+	}
+	if got["grid"].(int) != 4 {
+		t.Errorf("grid = %v, want %v", got["grid"], 4)
+	}
+
+	attrs, ok := highlights["3"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("highlight 3 is not exported")
+	}
+	if attrs["fg"] != "#0a141e" {
+		t.Errorf("fg = %v, want %v", attrs["fg"], "#0a141e")
+	}
+	if attrs["bg"] != "#28323c" {
+		t.Errorf("bg = %v, want %v", attrs["bg"], "#28323c")
+	}
+	if attrs["italic"] != true {
+		t.Errorf("italic = %v, want %v", attrs["italic"], true)
+	}
+}
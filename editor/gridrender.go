@@ -0,0 +1,366 @@
+package editor
+
+import (
+	"bytes"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+)
+
+// gridRenderer draws the cells of a grid. The layers lay out the cells for
+// it, so that the window paints with the same layout as the snapshot
+// renderer rasterizes without Qt, and the layout of the highlights, the
+// decorations and the wide chars can be tested without the event loop.
+type gridRenderer interface {
+	// fillBackground fills the cells from col to col+cols-1 of the row in
+	// the background of the highlight, which is nil for the empty cells
+	fillBackground(col, row, cols int, hl *Highlight, bg *RGBA)
+	// drawGlyph draws the glyph of the cell at col of the line, which spans
+	// cols cells
+	drawGlyph(line []*Cell, col, row, cols int, fg *RGBA)
+	// drawDecoration draws the underline, the undercurl and the
+	// strikethrough of the highlight over the cells
+	drawDecoration(col, row, cols int, hl *Highlight, special *RGBA)
+}
+
+type cellDecoration int
+
+const (
+	decorationUnderline cellDecoration = iota
+	decorationUndercurl
+	decorationStrikethrough
+)
+
+// cellColors returns the colors of the highlight, with the default colors
+// for the unset ones, and the reverse applied
+func cellColors(hl *Highlight, defaultFg, defaultBg *RGBA) (fg, bg, special *RGBA) {
+	fg, bg = hl.foreground, hl.background
+	if fg == nil {
+		fg = defaultFg
+	}
+	if bg == nil {
+		bg = defaultBg
+	}
+	if hl.reverse {
+		fg, bg = bg, fg
+	}
+	special = hl.special
+	if special == nil {
+		special = fg
+	}
+
+	return fg, bg, special
+}
+
+// cellColumns returns the number of the cells the cell at col spans. nvim
+// sends the empty text for the right half of the double width chars.
+func cellColumns(line []*Cell, col int) int {
+	if col+1 < len(line) && line[col+1] != nil && line[col+1].char == "" {
+		return 2
+	}
+
+	return 1
+}
+
+// renderGrid draws the content in the layers the window paints: the
+// backgrounds, the glyphs and the decorations
+func renderGrid(r gridRenderer, content [][]*Cell, defaultFg, defaultBg *RGBA) {
+	for row, line := range content {
+		renderBackground(r, line, row, 0, len(line), defaultFg, defaultBg)
+	}
+	for row, line := range content {
+		renderGlyphs(r, line, row, 0, len(line), defaultFg, defaultBg)
+	}
+	for row, line := range content {
+		renderDecorations(r, line, row, 0, len(line), defaultFg, defaultBg)
+	}
+}
+
+// renderBackground fills the backgrounds of the cells from col to
+// col+cols-1 of the row. The adjacent cells in the same background are
+// filled at once.
+func renderBackground(r gridRenderer, line []*Cell, row, col, cols int, defaultFg, defaultBg *RGBA) {
+	end := minInt(col+cols, len(line))
+	start := col
+	var startHl *Highlight
+	var startBg *RGBA
+	for x := col; x <= end; x++ {
+		var hl *Highlight
+		bg := defaultBg
+		if x < end && line[x] != nil {
+			hl = &line[x].highlight
+			_, bg, _ = cellColors(hl, defaultFg, defaultBg)
+		}
+		if x > start && (x == end || !sameColor(bg, startBg) || highlightBlend(hl) != highlightBlend(startHl)) {
+			r.fillBackground(start, row, x-start, startHl, startBg)
+			start = x
+		}
+		if x == start {
+			startHl, startBg = hl, bg
+		}
+	}
+}
+
+// renderGlyphs draws the glyphs of the cells from col to col+cols-1 of the
+// row. The right halves of the wide chars are drawn by their left halves.
+func renderGlyphs(r gridRenderer, line []*Cell, row, col, cols int, defaultFg, defaultBg *RGBA) {
+	for x := col; x < minInt(col+cols, len(line)); x++ {
+		cell := line[x]
+		if cell == nil || cell.char == "" {
+			continue
+		}
+		fg, _, _ := cellColors(&cell.highlight, defaultFg, defaultBg)
+		r.drawGlyph(line, x, row, cellColumns(line, x), fg)
+	}
+}
+
+// renderDecorations draws the decorations of the cells from col to
+// col+cols-1 of the row
+func renderDecorations(r gridRenderer, line []*Cell, row, col, cols int, defaultFg, defaultBg *RGBA) {
+	for x := col; x < minInt(col+cols, len(line)); x++ {
+		cell := line[x]
+		if cell == nil {
+			continue
+		}
+		hl := &cell.highlight
+		if !hl.underline && !hl.undercurl && !hl.strikethrough && hl.spell == "" {
+			continue
+		}
+		_, _, special := cellColors(hl, defaultFg, defaultBg)
+		r.drawDecoration(x, row, 1, hl, special)
+	}
+}
+
+func sameColor(a, b *RGBA) bool {
+	return a == b || a.equals(b)
+}
+
+func highlightBlend(hl *Highlight) int {
+	if hl == nil {
+		return 0
+	}
+
+	return hl.blend
+}
+
+// windowRenderer draws the cells of the row of the window with the painter.
+// The glyphs are drawn from the atlas, or with DrawText in the runs of the
+// same highlight if CachedDrawing is false, and the RTL runs are drawn
+// shaped apart from the cells.
+type windowRenderer struct {
+	w *Window
+	p *gui.QPainter
+	// drawDefaultBg is true for the float window and the translucent message
+	// grid, which draw the default background too
+	drawDefaultBg bool
+	rtl           []bool
+	chars         map[Highlight][]int
+	specialChars  []int
+}
+
+func (w *Window) newWindowRenderer(p *gui.QPainter) *windowRenderer {
+	return &windowRenderer{
+		w:             w,
+		p:             p,
+		drawDefaultBg: w.isFloatWin || (w.isMsgGrid && editor.config.Message.Transparent < 1.0),
+	}
+}
+
+func (r *windowRenderer) fillBackground(col, row, cols int, hl *Highlight, bg *RGBA) {
+	w := r.w
+	if !r.drawDefaultBg && bg.equals(w.background) {
+		return
+	}
+	if hl == nil {
+		hl = w.s.hlAttrDef[0]
+	}
+	font := w.getFont()
+	// Set diff pattern
+	pattern, color, transparent := w.getFillpatternAndTransparent(hl)
+	if w.isFloatWin && hl.blend > 0 {
+		w.setBlended()
+		transparent = blendAlpha(transparent, hl.blend)
+	}
+
+	// Fill background with pattern
+	r.p.FillRect(
+		core.NewQRectF4(
+			float64(col)*font.truewidth,
+			float64(row*font.lineHeight+w.scrollDust[1]),
+			float64(cols)*font.truewidth,
+			float64(font.lineHeight),
+		),
+		gui.NewQBrush3(
+			gui.NewQColor3(
+				color.R,
+				color.G,
+				color.B,
+				transparent,
+			),
+			pattern,
+		),
+	)
+}
+
+func (r *windowRenderer) drawGlyph(line []*Cell, col, row, cols int, fg *RGBA) {
+	w := r.w
+	p := r.p
+	font := w.getFont()
+	cell := line[col]
+	x := float64(col) * font.truewidth
+	top := float64(row*font.lineHeight + w.scrollDust[1])
+
+	if w.drawLineNumber(p, line, col, top) {
+		return
+	}
+	if cell.char == " " {
+		return
+	}
+	if r.rtl != nil && r.rtl[col] {
+		return
+	}
+	if w.drawBoxChar(p, cell, x, top) {
+		return
+	}
+	if w.drawFoldIcon(p, cell, x, top) {
+		return
+	}
+	if editor.config.Editor.CachedDrawing {
+		w.drawGlyph(p, w.s.atlas, cell, x, top, canOverflow(line, col))
+		return
+	}
+	if isEmoji(cell.char) && w.drawEmoji(p, cell, x, top) {
+		return
+	}
+	// The narrow ambiguous-width char is drawn apart too, so that its wide
+	// glyph doesn't push the rest of the run
+	if !cell.normalWidth || (cell.char[0] > 127 && isAmbiguousWidth(cell.char)) {
+		r.specialChars = append(r.specialChars, col)
+		return
+	}
+	if r.chars == nil {
+		r.chars = map[Highlight][]int{}
+	}
+	r.chars[cell.highlight] = append(r.chars[cell.highlight], col)
+}
+
+// flushText draws the runs of the glyphs which drawGlyph collected without
+// the atlas
+func (r *windowRenderer) flushText(line []*Cell, row, col, cols int) {
+	w := r.w
+	p := r.p
+	wsfont := w.getFont()
+	font := p.Font()
+	pointF := core.NewQPointF3(
+		float64(col)*wsfont.truewidth,
+		float64(row*wsfont.lineHeight+wsfont.shift+w.scrollDust[1]),
+	)
+
+	for highlight, colorSlice := range r.chars {
+		var buffer bytes.Buffer
+		slice := colorSlice[:]
+		for x := col; x < col+cols; x++ {
+			if len(slice) == 0 {
+				break
+			}
+			index := slice[0]
+			if x < index {
+				buffer.WriteString(" ")
+				continue
+			}
+			if x == index {
+				buffer.WriteString(line[x].char)
+				slice = slice[1:]
+			}
+		}
+
+		text := buffer.String()
+		if text != "" {
+			fg := highlight.fg()
+			if fg != nil {
+				p.SetPen2(fg.QColor())
+			}
+			setFontBold(font, highlight.bold)
+			font.SetItalic(highlight.italic)
+			p.DrawText(pointF, text)
+		}
+	}
+
+	if len(r.specialChars) >= 1 {
+		if w.s.ws.fontwide != nil && w.font == nil {
+			p.SetFont(w.s.ws.fontwide.fontNew)
+			font = p.Font()
+		}
+		for _, x := range r.specialChars {
+			fg := line[x].highlight.fg()
+			p.SetPen2(fg.QColor())
+			pointF.SetX(float64(x) * wsfont.truewidth)
+			setFontBold(font, line[x].highlight.bold)
+			font.SetItalic(line[x].highlight.italic)
+			p.DrawText(pointF, line[x].char)
+		}
+		if w.s.ws.fontwide != nil && w.font == nil {
+			p.SetFont(w.getFont().fontNew)
+		}
+	}
+	r.chars = nil
+	r.specialChars = nil
+}
+
+func (r *windowRenderer) drawDecoration(col, row, cols int, hl *Highlight, special *RGBA) {
+	w := r.w
+	p := r.p
+	underline, double, undercurl, style, sp := spellDecoration(hl, editor.config.Editor.UndercurlStyle)
+	if !underline && !undercurl && !hl.strikethrough {
+		return
+	}
+	// The spell color of the config takes precedence over the special color
+	if sp == nil {
+		sp = special
+	}
+	if sp == nil {
+		sp = editor.colors.fg
+	}
+	color := sp.QColor()
+	font := w.getFont()
+	start := float64(col) * font.truewidth
+	end := float64(col+cols) * font.truewidth
+
+	dpr := w.devicePixelRatio
+	top := float64(row*font.lineHeight + w.scrollDust[1])
+	Y := top + float64(font.height)*(1.04+editor.config.Editor.UnderlineOffset) + float64(font.lineSpace/2)
+	Y = snapToDevice(Y, dpr, 0)
+	halfY := snapToDevice(top+float64(font.height)/2.0+float64(font.lineSpace/2), dpr, 0)
+	weight := decorationThickness(font.lineHeight, editor.config.Editor.UnderlineThickness, dpr)
+	if hl.strikethrough {
+		p.FillRect4(core.NewQRectF4(start, halfY, end-start, weight), color)
+	}
+	if underline {
+		p.FillRect4(core.NewQRectF4(start, Y-weight, end-start, weight), color)
+		if double {
+			p.FillRect4(core.NewQRectF4(start, Y-weight*3, end-start, weight), color)
+		}
+	}
+	if undercurl {
+		w.drawUndercurl(p, color, style, start, end, Y, weight)
+	}
+}
+
+// drawContents draws the glyphs of the cells from col to col+cols-1 of the
+// row, and the RTL runs in them
+func (r *windowRenderer) drawContents(line []*Cell, row, col, cols int) {
+	w := r.w
+	var runs [][2]int
+	if w.isBidiEnabled() {
+		runs = overlappingRuns(rtlRuns(line), col, col+cols-1)
+		r.rtl = rtlMask(runs, len(line))
+	}
+	renderGlyphs(r, line, row, col, cols, editor.colors.fg, editor.colors.bg)
+	if !editor.config.Editor.CachedDrawing {
+		r.flushText(line, row, col, cols)
+	}
+	if len(runs) > 0 {
+		w.drawRTLRuns(r.p, row, col, cols, runs)
+	}
+	r.rtl = nil
+}
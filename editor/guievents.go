@@ -0,0 +1,99 @@
+package editor
+
+import (
+	"github.com/therecipe/qt/core"
+)
+
+// The User autocmds emitted on the GUI events, so that the user configs and
+// the plugins can react to them without polling, e.g.
+//
+//	autocmd User GonvimFontChanged echo g:gonvim_event.family
+//
+//	vim.api.nvim_create_autocmd("User", {
+//	  pattern = "GonvimDropFile",
+//	  callback = function(ev) print(ev.data.path) end,
+//	})
+const (
+	guiEventStarted           = "GonvimStarted"
+	guiEventWorkspaceSwitched = "GonvimWorkspaceSwitched"
+	guiEventFontChanged       = "GonvimFontChanged"
+	guiEventFocusGained       = "GonvimFocusGained"
+	guiEventFocusLost         = "GonvimFocusLost"
+	guiEventDropFile          = "GonvimDropFile"
+	guiEventWebPaneClosed     = "GonvimWebPaneClosed"
+)
+
+// guiEventLua emits the User autocmd with the payload, which is in
+// g:gonvim_event and, on nvim 0.8 or later, in the data of the callbacks
+const guiEventLua = `
+local name, data = ...
+vim.g.gonvim_event = data
+if vim.fn.exists("#User#" .. name) == 0 then
+  return
+end
+local ok = pcall(vim.api.nvim_exec_autocmds, "User", {pattern = name, modeline = false, data = data})
+if not ok then
+  vim.cmd("doautocmd <nomodeline> User " .. name)
+end
+`
+
+// emitGuiEvent emits the User autocmd of the GUI event in the workspace
+func (w *Workspace) emitGuiEvent(name string, data map[string]interface{}) {
+	if w == nil || w.nvim == nil || !w.uiAttached {
+		return
+	}
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	go w.nvim.ExecuteLua(guiEventLua, nil, name, data)
+}
+
+// workspaceIndex returns the 1-based index of the workspace, as in
+// :GonvimWorkspaceSwitch
+func (e *Editor) workspaceIndex(ws *Workspace) int {
+	for i, w := range e.workspaces {
+		if w == ws {
+			return i + 1
+		}
+	}
+
+	return 0
+}
+
+// emitWorkspaceSwitched emits GonvimWorkspaceSwitched in the workspace which
+// becomes active
+func (e *Editor) emitWorkspaceSwitched() {
+	ws := e.workspaces[e.active]
+	previous := e.activeWorkspace
+	e.activeWorkspace = ws
+	if previous == nil || previous == ws {
+		return
+	}
+	ws.emitGuiEvent(guiEventWorkspaceSwitched, map[string]interface{}{
+		"workspace": e.active + 1,
+		"previous":  e.workspaceIndex(previous),
+		"cwd":       ws.cwd,
+	})
+}
+
+// connectFocusEvents emits GonvimFocusGained and GonvimFocusLost when the
+// application is activated and deactivated
+func (e *Editor) connectFocusEvents() {
+	active := true
+	e.app.ConnectApplicationStateChanged(func(state core.Qt__ApplicationState) {
+		if (state == core.Qt__ApplicationActive) == active {
+			return
+		}
+		active = state == core.Qt__ApplicationActive
+		name := guiEventFocusLost
+		if active {
+			name = guiEventFocusGained
+		}
+		if e.active < len(e.workspaces) {
+			e.workspaces[e.active].emitGuiEvent(name, nil)
+			if active {
+				e.workspaces[e.active].activateWindow()
+			}
+		}
+	})
+}
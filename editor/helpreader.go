@@ -0,0 +1,284 @@
+package editor
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/therecipe/qt/core"
+)
+
+// helpReader renders the :help buffers in a reader pane over the window of
+// the help buffer, with the proportional headings, the clickable tags and
+// links, and the code blocks in monospace. The raw buffer stays in its window
+// and keeps the keyboard, and the pane follows its cursor line. It is shown
+// for the help buffers if helpReader.enable, and toggled by
+// :GonvimHelpReader.
+type helpReader struct {
+	ws      *Workspace
+	pane    *readerPane
+	enabled bool
+	// buf is the help buffer rendered in the pane, 0 if none
+	buf  int
+	line int
+}
+
+// helpReaderAutoCmds notify the help buffer entered, or 0 for the other
+// buffers, and its window and the cursor line in the help buffers
+// args: [buf, winid, line]
+const helpReaderAutoCmds = `
+	aug GonvimAuHelpReader | au! | aug END
+	au GonvimAuHelpReader BufEnter * call rpcnotify(0, "Gui", "gonvim_help", &buftype ==# "help" ? bufnr() : 0, win_getid(), line("."))
+	au GonvimAuHelpReader CursorMoved * if &buftype ==# "help" | call rpcnotify(0, "Gui", "gonvim_help", bufnr(), win_getid(), line(".")) | endif
+	`
+
+const helpReaderCommands = `
+	command! GonvimHelpReader call rpcnotify(0, "Gui", "gonvim_help_toggle", &buftype ==# "help" ? bufnr() : 0, win_getid(), line("."))
+	`
+
+func newHelpReader(ws *Workspace) *helpReader {
+	h := &helpReader{
+		ws:      ws,
+		pane:    newReaderPane(ws, "helpreader"),
+		enabled: editor.config.HelpReader.Enable,
+	}
+	h.pane.text.SetOpenLinks(false)
+	h.pane.text.ConnectAnchorClicked(func(link *core.QUrl) {
+		h.jump(link.ToString(core.QUrl__None))
+	})
+
+	return h
+}
+
+// update shows the help buffer of the notification at its cursor line over
+// its window, or hides the pane if the buffer isn't a help buffer. Another
+// buffer is fetched off the GUI thread, and rendered by loaded.
+func (h *helpReader) update(args []interface{}) {
+	if len(args) < 3 {
+		return
+	}
+	buf := util.ReflectToInt(args[0])
+	winid := util.ReflectToInt(args[1])
+	line := util.ReflectToInt(args[2])
+	if buf == 0 || !h.enabled {
+		h.hide()
+		return
+	}
+	h.line = line
+	h.pane.winid = winid
+	if buf != h.buf {
+		h.buf = buf
+		h.pane.fetch("gonvim_help_lines", buf)
+		return
+	}
+	h.pane.updatePos()
+	h.scroll()
+}
+
+// loaded renders the lines fetched for the pane
+func (h *helpReader) loaded(args []interface{}) {
+	buf, lines, ok := h.pane.fetched(args)
+	if !ok || buf != h.buf {
+		return
+	}
+	h.setStyle()
+	h.pane.render(helpToHTML(lines), false)
+	h.pane.updatePos()
+	h.scroll()
+}
+
+func (h *helpReader) scroll() {
+	h.pane.text.ScrollToAnchor(fmt.Sprintf("L%d", h.line))
+}
+
+func (h *helpReader) toggle(args []interface{}) {
+	h.enabled = !h.enabled
+	h.update(args)
+}
+
+// updatePos follows the window of the help buffer
+func (h *helpReader) updatePos() {
+	if h.buf != 0 {
+		h.pane.updatePos()
+	}
+}
+
+func (h *helpReader) hide() {
+	h.buf = 0
+	h.pane.hide()
+}
+
+// jump opens the help of the tag of the link in the raw buffer, which then
+// notifies the reader of the new line
+func (h *helpReader) jump(link string) {
+	if !strings.HasPrefix(link, "help:") {
+		return
+	}
+	tag, err := url.QueryUnescape(strings.TrimPrefix(link, "help:"))
+	if err != nil || tag == "" {
+		return
+	}
+	go h.ws.nvim.Command(fmt.Sprintf("help %s", tag))
+}
+
+func (h *helpReader) setStyle() {
+	bg := editor.colors.widgetBg
+	if bg == nil || editor.colors.inactiveFg == nil {
+		return
+	}
+	h.pane.setStyle(editor.config.HelpReader.FontFamily, fmt.Sprintf(`
+		h1 { font-size: x-large; }
+		h2 { font-size: large; margin-top: 16px; }
+		h3 { font-size: medium; }
+		pre.code { background-color: %s; }
+		.tag { color: %s; }
+		.arg { font-style: italic; }
+		`,
+		warpColor(bg, 10).String(),
+		editor.colors.inactiveFg.String(),
+	))
+}
+
+var (
+	// helpInline matches the *tag*, the |link|, the 'option' and the {arg}
+	helpInline  = regexp.MustCompile(`\*([^*\s|"]+)\*|\|([^|\s"]+)\||'([a-z]{2,})'|\{([^}\s]+)\}`)
+	helpRule    = regexp.MustCompile(`^(=|-){3,}\s*$`)
+	helpTagOnly = regexp.MustCompile(`^\s*(\*[^*\s]+\*\s*)+$`)
+)
+
+// helpToHTML renders the lines of a help file. The column 0 text is joined
+// into the paragraphs, and the indented and the tabulated lines keep their
+// layout in monospace. Each line has the anchor "L" + the line number.
+func helpToHTML(lines []string) string {
+	var b strings.Builder
+	var paragraph []string
+	var pre []string
+	inCode := false
+
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			b.WriteString("<p>" + strings.Join(paragraph, " ") + "</p>\n")
+			paragraph = nil
+		}
+	}
+	flushPre := func(class string) {
+		if len(pre) > 0 {
+			b.WriteString(fmt.Sprintf("<pre class=\"%s\">%s</pre>\n", class, strings.Join(pre, "\n")))
+			pre = nil
+		}
+	}
+
+	for i, line := range lines {
+		anchor := fmt.Sprintf("<a name=\"L%d\"></a>", i+1)
+		line = strings.TrimRight(line, " \t")
+
+		if inCode {
+			// The code block ends at the "<" or the text in column 0
+			if line == "" || line[0] == ' ' || line[0] == '\t' {
+				pre = append(pre, anchor+html.EscapeString(line))
+				continue
+			}
+			flushPre("code")
+			inCode = false
+			if line[0] == '<' {
+				line = line[1:]
+			}
+		}
+
+		code := false
+		if line == ">" || strings.HasSuffix(line, " >") {
+			line = strings.TrimSuffix(line, ">")
+			line = strings.TrimRight(line, " ")
+			code = true
+		}
+
+		switch {
+		case line == "":
+			flushParagraph()
+			flushPre("indent")
+			b.WriteString(anchor)
+		case helpRule.MatchString(line):
+			flushParagraph()
+			flushPre("indent")
+			b.WriteString(anchor + "<hr>\n")
+		case strings.HasSuffix(line, " ~") || line == "~":
+			flushParagraph()
+			flushPre("indent")
+			b.WriteString(fmt.Sprintf("<h3>%s%s</h3>\n", anchor, helpInlineHTML(strings.TrimSpace(strings.TrimSuffix(line, "~")))))
+		case i == 0 && strings.HasPrefix(line, "*"):
+			b.WriteString(fmt.Sprintf("<h1>%s%s</h1>\n", anchor, helpInlineHTML(line)))
+		case isHelpHeading(line):
+			flushParagraph()
+			flushPre("indent")
+			b.WriteString(fmt.Sprintf("<h2>%s%s</h2>\n", anchor, helpInlineHTML(line)))
+		case line[0] == ' ' || line[0] == '\t' || strings.Contains(line, "\t") || helpTagOnly.MatchString(line):
+			flushParagraph()
+			pre = append(pre, anchor+helpInlineHTML(line))
+		default:
+			flushPre("indent")
+			paragraph = append(paragraph, anchor+helpInlineHTML(line))
+		}
+
+		if code {
+			flushParagraph()
+			flushPre("indent")
+			inCode = true
+		}
+	}
+	flushParagraph()
+	if inCode {
+		flushPre("code")
+	}
+	flushPre("indent")
+
+	return b.String()
+}
+
+// isHelpHeading returns true if the text of the line but the tags is the
+// capital words, e.g. "1. INTRODUCTION		*intro*", and not the keys like
+// "CTRL-W CTRL-S"
+func isHelpHeading(line string) bool {
+	text := strings.TrimSpace(helpInline.ReplaceAllString(line, ""))
+	letters := 0
+	for _, r := range text {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			letters++
+		case r >= '0' && r <= '9', r == ' ', r == '\t', r == '.':
+		default:
+			return false
+		}
+	}
+
+	return letters >= 2
+}
+
+// helpInlineHTML escapes the text and renders the tags as the anchors, and
+// the links and the options as the links to their help
+func helpInlineHTML(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range helpInline.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:m[0]]))
+		last = m[1]
+		switch {
+		case m[2] >= 0:
+			tag := text[m[2]:m[3]]
+			b.WriteString(fmt.Sprintf("<a name=\"%s\"></a><span class=\"tag\">%s</span>", html.EscapeString(tag), html.EscapeString(tag)))
+		case m[4] >= 0:
+			tag := text[m[4]:m[5]]
+			b.WriteString(fmt.Sprintf("<a href=\"help:%s\">%s</a>", url.QueryEscape(tag), html.EscapeString(tag)))
+		case m[6] >= 0:
+			option := text[m[0]:m[1]]
+			b.WriteString(fmt.Sprintf("<a href=\"help:%s\">%s</a>", url.QueryEscape(option), html.EscapeString(option)))
+		case m[8] >= 0:
+			b.WriteString(fmt.Sprintf("<span class=\"arg\">%s</span>", html.EscapeString(text[m[0]:m[1]])))
+		}
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHelpInlineHTML(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"plain <text> & more", "plain &lt;text&gt; &amp; more"},
+		{"see |windows.txt|", `see <a href="help:windows.txt">windows.txt</a>`},
+		{"set 'tabstop'", `set <a href="help:%27tabstop%27">&#39;tabstop&#39;</a>`},
+		{"*CTRL-W_s*", `<a name="CTRL-W_s"></a><span class="tag">CTRL-W_s</span>`},
+		{":split {file}", `:split <span class="arg">{file}</span>`},
+		// Not the option in the text
+		{"don't 'a'", "don&#39;t &#39;a&#39;"},
+	}
+	for _, tt := range tests {
+		if got := helpInlineHTML(tt.text); got != tt.want {
+			t.Errorf("helpInlineHTML(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestIsHelpHeading(t *testing.T) {
+	for line, want := range map[string]bool{
+		"1. INTRODUCTION\t\t\t\t*intro*":   true,
+		"INTRODUCTION":                     true,
+		"CTRL-W CTRL-S\t\t*CTRL-W_CTRL-S*": false,
+		"Introduction":                     false,
+		"*tag*":                            false,
+		"A":                                false,
+	} {
+		if got := isHelpHeading(line); got != want {
+			t.Errorf("isHelpHeading(%q) = %v, want %v", line, got, want)
+		}
+	}
+}
+
+func TestHelpToHTML(t *testing.T) {
+	lines := []string{
+		"*example.txt*\tFor Vim version 9.0",
+		"==============================================================================",
+		"1. INTRODUCTION\t\t\t\t\t\t*example-intro*",
+		"",
+		"The first line of the",
+		"paragraph. See |other|.",
+		"",
+		"Example: >",
+		"\t:set ts=4",
+		"\t:echo 1 < 2",
+		"<",
+		"CTRL-W s\t\tSplit the window.",
+		"Usage ~",
+	}
+	got := helpToHTML(lines)
+	for _, want := range []string{
+		`<h1><a name="L1"></a><a name="example.txt"></a>`,
+		`<a name="L2"></a><hr>`,
+		`<h2><a name="L3"></a>1. INTRODUCTION`,
+		`<p><a name="L5"></a>The first line of the <a name="L6"></a>paragraph. See <a href="help:other">other</a>.</p>`,
+		`<p><a name="L8"></a>Example:</p>`,
+		"<pre class=\"code\"><a name=\"L9\"></a>\t:set ts=4\n<a name=\"L10\"></a>\t:echo 1 &lt; 2</pre>",
+		"<pre class=\"indent\"><a name=\"L12\"></a>CTRL-W s\t\tSplit the window.</pre>",
+		`<h3><a name="L13"></a>Usage</h3>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("helpToHTML() doesn't contain %q:\n%s", want, got)
+		}
+	}
+}
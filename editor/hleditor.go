@@ -0,0 +1,350 @@
+package editor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+// HighlightEditor is the panel to edit the highlight groups with live preview.
+// The changes are applied by nvim_set_hl, and can be exported as a colorscheme.
+type HighlightEditor struct {
+	ws     *Workspace
+	widget *widgets.QWidget
+	list   *widgets.QListWidget
+	colors map[string]*widgets.QPushButton
+	styles map[string]*widgets.QCheckBox
+
+	groups  map[string]*hlEdit
+	current string
+	loading bool
+}
+
+// hlEdit is the attributes of a highlight group being edited
+type hlEdit struct {
+	Fg            string
+	Bg            string
+	Sp            string
+	Bold          bool
+	Italic        bool
+	Underline     bool
+	Undercurl     bool
+	Strikethrough bool
+}
+
+var hlEditorStyles = []string{"bold", "italic", "underline", "undercurl", "strikethrough"}
+
+func newHlEdit(hl *Highlight) *hlEdit {
+	h := &hlEdit{
+		Bold:          hl.bold,
+		Italic:        hl.italic,
+		Underline:     hl.underline,
+		Undercurl:     hl.undercurl,
+		Strikethrough: hl.strikethrough,
+	}
+	if hl.foreground != nil {
+		h.Fg = hl.foreground.Hex()
+	}
+	if hl.background != nil {
+		h.Bg = hl.background.Hex()
+	}
+	if hl.special != nil {
+		h.Sp = hl.special.Hex()
+	}
+
+	return h
+}
+
+func (h *hlEdit) style(name string) *bool {
+	switch name {
+	case "bold":
+		return &h.Bold
+	case "italic":
+		return &h.Italic
+	case "underline":
+		return &h.Underline
+	case "undercurl":
+		return &h.Undercurl
+	case "strikethrough":
+		return &h.Strikethrough
+	}
+
+	return nil
+}
+
+func (h *hlEdit) color(name string) *string {
+	switch name {
+	case "fg":
+		return &h.Fg
+	case "bg":
+		return &h.Bg
+	case "sp":
+		return &h.Sp
+	}
+
+	return nil
+}
+
+// attrs returns the dict for nvim_set_hl
+func (h *hlEdit) attrs() map[string]interface{} {
+	attrs := make(map[string]interface{})
+	for _, c := range []string{"fg", "bg", "sp"} {
+		if color := *h.color(c); color != "" {
+			attrs[c] = color
+		}
+	}
+	for _, s := range hlEditorStyles {
+		if *h.style(s) {
+			attrs[s] = true
+		}
+	}
+
+	return attrs
+}
+
+// vimscript returns the ":highlight" command of the group
+func (h *hlEdit) vimscript(group string) string {
+	styles := []string{}
+	for _, s := range hlEditorStyles {
+		if *h.style(s) {
+			styles = append(styles, s)
+		}
+	}
+	attr := "NONE"
+	if len(styles) > 0 {
+		attr = strings.Join(styles, ",")
+	}
+	line := fmt.Sprintf("highlight %s gui=%s", group, attr)
+	for _, c := range [][2]string{{"guifg", h.Fg}, {"guibg", h.Bg}, {"guisp", h.Sp}} {
+		if c[1] != "" {
+			line += fmt.Sprintf(" %s=%s", c[0], c[1])
+		}
+	}
+
+	return line
+}
+
+// lua returns the vim.api.nvim_set_hl call of the group
+func (h *hlEdit) lua(group string) string {
+	attrs := h.attrs()
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fields := make([]string, 0, len(keys))
+	for _, key := range keys {
+		switch value := attrs[key].(type) {
+		case string:
+			fields = append(fields, fmt.Sprintf("%s = %q", key, value))
+		case bool:
+			fields = append(fields, fmt.Sprintf("%s = %t", key, value))
+		}
+	}
+
+	return fmt.Sprintf("vim.api.nvim_set_hl(0, %q, { %s })", group, strings.Join(fields, ", "))
+}
+
+// colorschemeScript returns the content of the colorscheme file
+func colorschemeScript(name string, groups map[string]*hlEdit, isLua bool) string {
+	names := make([]string, 0, len(groups))
+	for group := range groups {
+		names = append(names, group)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	if isLua {
+		b.WriteString("vim.cmd('highlight clear')\n")
+		b.WriteString("if vim.fn.exists('syntax_on') == 1 then vim.cmd('syntax reset') end\n")
+		fmt.Fprintf(&b, "vim.g.colors_name = %q\n\n", name)
+		for _, group := range names {
+			b.WriteString(groups[group].lua(group) + "\n")
+		}
+	} else {
+		b.WriteString("highlight clear\n")
+		b.WriteString("if exists('syntax_on') | syntax reset | endif\n")
+		fmt.Fprintf(&b, "let g:colors_name = '%s'\n\n", name)
+		for _, group := range names {
+			b.WriteString(groups[group].vimscript(group) + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+func newHighlightEditor(ws *Workspace) *HighlightEditor {
+	h := &HighlightEditor{
+		ws:     ws,
+		colors: make(map[string]*widgets.QPushButton),
+		styles: make(map[string]*widgets.QCheckBox),
+		groups: make(map[string]*hlEdit),
+	}
+
+	widget := widgets.NewQWidget(nil, core.Qt__Tool)
+	widget.SetWindowTitle("Highlight Editor")
+	widget.Resize2(420, 480)
+	layout := widgets.NewQHBoxLayout()
+	widget.SetLayout(layout)
+
+	list := widgets.NewQListWidget(nil)
+	list.ConnectCurrentTextChanged(h.selectGroup)
+	editor.focusChain.addDialog(list.QWidget_PTR(), nil)
+	layout.AddWidget(list, 1, 0)
+
+	form := widgets.NewQVBoxLayout()
+	for _, c := range [][2]string{{"fg", "Foreground"}, {"bg", "Background"}, {"sp", "Special"}} {
+		name := c[0]
+		button := widgets.NewQPushButton2(c[1], nil)
+		button.ConnectClicked(func(bool) {
+			h.pickColor(name)
+		})
+		form.AddWidget(button, 0, 0)
+		editor.focusChain.addDialog(button.QWidget_PTR(), button.Click)
+		h.colors[name] = button
+	}
+	for _, s := range hlEditorStyles {
+		name := s
+		check := widgets.NewQCheckBox2(name, nil)
+		check.ConnectToggled(func(checked bool) {
+			h.setStyle(name, checked)
+		})
+		form.AddWidget(check, 0, 0)
+		editor.focusChain.addDialog(check.QWidget_PTR(), check.Click)
+		h.styles[name] = check
+	}
+	form.AddStretch(1)
+	export := widgets.NewQPushButton2("Export...", nil)
+	export.ConnectClicked(func(bool) {
+		h.export()
+	})
+	form.AddWidget(export, 0, 0)
+	editor.focusChain.addDialog(export.QWidget_PTR(), export.Click)
+	layout.AddLayout(form, 0)
+
+	h.widget = widget
+	h.list = list
+
+	return h
+}
+
+// show lists the highlight groups known from the hl_attr_define and
+// hl_group_set events, and shows the panel
+func (h *HighlightEditor) show() {
+	s := h.ws.screen
+	for _, hl := range s.hlAttrDef {
+		if hl == nil || hl.hlName == "" {
+			continue
+		}
+		if _, ok := h.groups[hl.hlName]; !ok {
+			h.groups[hl.hlName] = newHlEdit(hl)
+		}
+	}
+	for name, id := range s.highlightGroup {
+		hl, ok := s.hlAttrDef[id]
+		if !ok || hl == nil {
+			continue
+		}
+		if _, ok := h.groups[name]; !ok {
+			h.groups[name] = newHlEdit(hl)
+		}
+	}
+
+	names := make([]string, 0, len(h.groups))
+	for name := range h.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h.list.Clear()
+	h.list.AddItems(names)
+	h.widget.Show()
+	h.widget.Raise()
+}
+
+func (h *HighlightEditor) selectGroup(name string) {
+	edit, ok := h.groups[name]
+	if !ok {
+		return
+	}
+	h.current = name
+
+	h.loading = true
+	for c, button := range h.colors {
+		color := *edit.color(c)
+		if color == "" {
+			button.SetStyleSheet("")
+			continue
+		}
+		button.SetStyleSheet(fmt.Sprintf("QPushButton { border-left: 16px solid %s; }", color))
+	}
+	for s, check := range h.styles {
+		check.SetChecked(*edit.style(s))
+	}
+	h.loading = false
+}
+
+func (h *HighlightEditor) pickColor(name string) {
+	edit, ok := h.groups[h.current]
+	if !ok {
+		return
+	}
+	initial := gui.NewQColor6(*edit.color(name))
+	color := widgets.QColorDialog_GetColor(initial, h.widget, fmt.Sprintf("%s %s", h.current, name), 0)
+	if color == nil || !color.IsValid() {
+		return
+	}
+	*edit.color(name) = color.Name()
+	h.selectGroup(h.current)
+	h.apply()
+}
+
+func (h *HighlightEditor) setStyle(name string, checked bool) {
+	if h.loading {
+		return
+	}
+	edit, ok := h.groups[h.current]
+	if !ok {
+		return
+	}
+	*edit.style(name) = checked
+	h.apply()
+}
+
+// apply sets the highlight group to neovim, which redraws the grids
+func (h *HighlightEditor) apply() {
+	edit, ok := h.groups[h.current]
+	if !ok {
+		return
+	}
+	go h.ws.nvim.Request("nvim_set_hl", nil, 0, h.current, edit.attrs())
+}
+
+func (h *HighlightEditor) export() {
+	file := widgets.QFileDialog_GetSaveFileName(
+		h.widget,
+		"Export colorscheme",
+		filepath.Join(h.ws.cwd, "mycolors.vim"),
+		"Vim script (*.vim);;Lua (*.lua)",
+		"",
+		0,
+	)
+	if file == "" {
+		return
+	}
+	ext := filepath.Ext(file)
+	name := strings.TrimSuffix(filepath.Base(file), ext)
+	content := colorschemeScript(name, h.groups, ext == ".lua")
+	err := ioutil.WriteFile(file, []byte(content), 0644)
+	if err != nil {
+		editor.pushNotification(NotifyWarn, -1, fmt.Sprintf("[Goneovim] Failed to export the colorscheme: %s", err))
+		return
+	}
+	editor.pushNotification(NotifyInfo, 3, fmt.Sprintf("[Goneovim] Exported the colorscheme to %s", file))
+}
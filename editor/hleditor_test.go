@@ -0,0 +1,32 @@
+package editor
+
+import "testing"
+
+func TestColorschemeScript(t *testing.T) {
+	groups := map[string]*hlEdit{
+		"Normal":  {Fg: "#c0c0c0", Bg: "#101010"},
+		"Comment": {Fg: "#808080", Italic: true},
+	}
+
+	wantVim := `highlight clear
+if exists('syntax_on') | syntax reset | endif
+let g:colors_name = 'mycolors'
+
+highlight Comment gui=italic guifg=#808080
+highlight Normal gui=NONE guifg=#c0c0c0 guibg=#101010
+`
+	if got := colorschemeScript("mycolors", groups, false); got != wantVim {
+		t.Errorf("colorschemeScript() vim =\n%s\nwant\n%s", got, wantVim)
+	}
+
+	wantLua := `vim.cmd('highlight clear')
+if vim.fn.exists('syntax_on') == 1 then vim.cmd('syntax reset') end
+vim.g.colors_name = "mycolors"
+
+vim.api.nvim_set_hl(0, "Comment", { fg = "#808080", italic = true })
+vim.api.nvim_set_hl(0, "Normal", { bg = "#101010", fg = "#c0c0c0" })
+`
+	if got := colorschemeScript("mycolors", groups, true); got != wantLua {
+		t.Errorf("colorschemeScript() lua =\n%s\nwant\n%s", got, wantLua)
+	}
+}
@@ -0,0 +1,147 @@
+package editor
+
+import (
+	"github.com/akiyosi/goneovim/util"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+)
+
+const (
+	// hscrollFadeCells is the width of the edge fade in cells
+	hscrollFadeCells = 2
+	// hscrollBarHeight is the height of the horizontal scrollbar in pixels
+	hscrollBarHeight = 3
+)
+
+// hscrollCommands notify the horizontal scroll state of the window. The
+// scrolled window is the one of <amatch>, which may not be the current one,
+// and the lines typed in the insert mode are measured when it is left.
+const hscrollCommands = `
+	function! GonvimHScroll() abort
+		call rpcnotify(0, "Gui", "gonvim_hscroll", win_getid(), &wrap, winsaveview().leftcol, getwininfo(win_getid())[0].textoff, max(map(range(line("w0"), line("w$")), {_, l -> virtcol([l, "$"]) - 1})))
+	endfunction
+	aug GonvimAuHScroll | au! | aug END
+	au GonvimAuHScroll BufWinEnter,WinEnter,TextChanged,InsertLeave * call GonvimHScroll()
+	au GonvimAuHScroll WinScrolled * call win_execute(str2nr(expand("<amatch>")), "call GonvimHScroll()")
+	au GonvimAuHScroll OptionSet wrap call GonvimHScroll()
+	`
+
+// hscroll is the horizontal scroll state of a 'nowrap' window, which draws
+// the fades on the edges and the thin scrollbar when the lines are truncated
+type hscroll struct {
+	wrap    bool
+	leftcol int
+	textoff int
+	width   int
+}
+
+// updateHScroll is called by the gonvim_hscroll notification.
+// args: [winid, &wrap, leftcol, textoff, the width of the longest visible line]
+func (s *Screen) updateHScroll(args []interface{}) {
+	if len(args) < 5 {
+		return
+	}
+	id := util.ReflectToInt(args[0])
+	state := &hscroll{
+		wrap:    util.ReflectToInt(args[1]) != 0,
+		leftcol: util.ReflectToInt(args[2]),
+		textoff: util.ReflectToInt(args[3]),
+		width:   util.ReflectToInt(args[4]),
+	}
+
+	s.windows.Range(func(_, winITF interface{}) bool {
+		win := winITF.(*Window)
+		if win == nil || int(win.id) != id {
+			return true
+		}
+		win.hscroll = state
+		win.update()
+		return false
+	})
+}
+
+// hasHScroll returns true if the lines of the window are truncated and the
+// indicators of the horizontal scroll are drawn
+func (w *Window) hasHScroll() bool {
+	h := w.hscroll
+	if h == nil || h.wrap || w.isMsgGrid || w.isFloatWin || w.grid == 1 {
+		return false
+	}
+	if !editor.config.HorizontalScroll.Visible {
+		return false
+	}
+	visible := w.cols - h.textoff
+
+	return visible > 0 && (h.leftcol > 0 || h.width > visible)
+}
+
+// drawHScroll draws the fades on the edges where the lines are truncated,
+// and the scrollbar on the bottom of the window
+func (w *Window) drawHScroll(p *gui.QPainter) {
+	if !w.hasHScroll() {
+		return
+	}
+	h := w.hscroll
+	visible := w.cols - h.textoff
+	bg := w.background
+	if bg == nil {
+		bg = w.s.ws.background
+	}
+	if bg == nil {
+		return
+	}
+
+	font := w.getFont()
+	left := float64(h.textoff) * font.truewidth
+	right := float64(w.cols) * font.truewidth
+	height := float64(w.rows * font.lineHeight)
+	fade := hscrollFadeCells * font.truewidth
+
+	opaque := gui.NewQColor3(bg.R, bg.G, bg.B, 255)
+	clear := gui.NewQColor3(bg.R, bg.G, bg.B, 0)
+	if h.leftcol > 0 {
+		gradient := gui.NewQLinearGradient3(left, 0, left+fade, 0)
+		gradient.SetColorAt(0, opaque)
+		gradient.SetColorAt(1, clear)
+		p.FillRect(core.NewQRectF4(left, 0, fade, height), gui.NewQBrush10(gradient))
+	}
+	if h.leftcol+visible < h.width {
+		gradient := gui.NewQLinearGradient3(right-fade, 0, right, 0)
+		gradient.SetColorAt(0, clear)
+		gradient.SetColorAt(1, opaque)
+		p.FillRect(core.NewQRectF4(right-fade, 0, fade, height), gui.NewQBrush10(gradient))
+	}
+
+	color := editor.colors.scrollBarFg
+	if color == nil {
+		return
+	}
+	x, width := hscrollThumb(h.leftcol, visible, h.width, right-left)
+	p.FillRect4(
+		core.NewQRectF4(left+x, height-hscrollBarHeight, width, hscrollBarHeight),
+		color.QColor(),
+	)
+}
+
+// hscrollThumb returns the offset and the width of the scrollbar thumb in the
+// track, for the visible columns from leftcol in the lines of the width
+func hscrollThumb(leftcol, visible, width int, track float64) (float64, float64) {
+	// The view can be scrolled beyond the longest visible line
+	total := width
+	if leftcol+visible > total {
+		total = leftcol + visible
+	}
+	if total <= 0 {
+		return 0, track
+	}
+	x := track * float64(leftcol) / float64(total)
+	thumb := track * float64(visible) / float64(total)
+	if thumb < hscrollBarHeight*2 {
+		thumb = hscrollBarHeight * 2
+	}
+	if x+thumb > track {
+		x = track - thumb
+	}
+
+	return x, thumb
+}
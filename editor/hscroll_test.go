@@ -0,0 +1,33 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestHScrollThumb(t *testing.T) {
+	tests := []struct {
+		leftcol, visible, width int
+		track                   float64
+		x, thumb                float64
+	}{
+		{0, 80, 160, 800, 0, 400},
+		{80, 80, 160, 800, 400, 400},
+		{40, 80, 160, 800, 200, 400},
+		// Scrolled beyond the longest visible line
+		{100, 80, 120, 800, 444.44, 355.56},
+		// The thumb keeps the minimum width
+		{0, 10, 10000, 100, 0, 6},
+		{9990, 10, 10000, 100, 94, 6},
+	}
+	for _, tt := range tests {
+		x, thumb := hscrollThumb(tt.leftcol, tt.visible, tt.width, tt.track)
+		if !almostEqual(x, tt.x) || !almostEqual(thumb, tt.thumb) {
+			t.Errorf("hscrollThumb(%d, %d, %d, %v) = %v, %v, want %v, %v", tt.leftcol, tt.visible, tt.width, tt.track, x, thumb, tt.x, tt.thumb)
+		}
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	d := a - b
+	return d > -0.01 && d < 0.01
+}
@@ -0,0 +1,514 @@
+package editor
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+// hunkHoverDelay is the time in ms the pointer rests on the git sign until
+// the hunk pops up
+const hunkHoverDelay = 700
+
+// hunkCommands define GonvimHunkAt, which notifies the file and the line of
+// the row of the window, and whether the buffer is modified and gitsigns is
+// loaded. The window of the winid 0 is the one at the row and the col of the
+// screen, which are of the global grid without ext_multigrid. The line is
+// the last one starting at or above the row, so that the wrapped lines and
+// the closed folds take their first line.
+const hunkCommands = `
+	function! GonvimHunkAt(winid, row, col) abort
+		let l:row = a:row
+		let l:info = {}
+		for l:i in getwininfo()
+			if l:i.tabnr != tabpagenr()
+				continue
+			endif
+			if a:winid == l:i.winid || (a:winid == 0 && l:row >= l:i.winrow - 1 && l:row < l:i.winrow - 1 + l:i.height && a:col >= l:i.wincol - 1 && a:col < l:i.wincol - 1 + l:i.width)
+				let l:info = l:i
+				break
+			endif
+		endfor
+		if empty(l:info)
+			return
+		endif
+		if a:winid == 0
+			let l:row -= l:info.winrow - 1
+		endif
+		let l:leftcol = 0
+		call win_execute(l:info.winid, "let l:leftcol = winsaveview().leftcol")
+		let l:line = 0
+		for l:lnum in range(l:info.topline, l:info.botline)
+			let l:pos = screenpos(l:info.winid, l:lnum, l:leftcol + 1)
+			if l:pos.row == 0
+				continue
+			endif
+			if l:pos.row - l:info.winrow > l:row
+				break
+			endif
+			let l:line = l:lnum
+		endfor
+		if l:line == 0
+			return
+		endif
+		call rpcnotify(0, "Gui", "gonvim_hunk", fnamemodify(bufname(l:info.bufnr), ":p"), l:line, l:info.winid, getbufvar(l:info.bufnr, "&modified"), has("nvim-0.5") ? luaeval("package.loaded.gitsigns ~= nil") : 0)
+	endfunction
+	`
+
+// gitSignPrefixes are the highlight groups of the signs of the git plugins
+var gitSignPrefixes = []string{"GitSigns", "GitGutter", "Signify"}
+
+// isGitSignHighlight returns true if the highlight group is of a sign of a
+// git plugin, e.g. "GitSignsChange"
+func isGitSignHighlight(name string) bool {
+	for _, prefix := range gitSignPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// diffHunk is a hunk of the unified diff of a file
+type diffHunk struct {
+	oldStart int
+	oldCount int
+	newStart int
+	newCount int
+	// lines are the header of the hunk and its lines
+	lines []string
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// parseHunks returns the header of the file and the hunks of the diff of a
+// file
+func parseHunks(diff string) (string, []*diffHunk) {
+	var header []string
+	var hunks []*diffHunk
+	var hunk *diffHunk
+	for _, line := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+		if m := hunkHeader.FindStringSubmatch(line); m != nil {
+			hunk = &diffHunk{
+				oldStart: atoiOr(m[1], 0),
+				oldCount: atoiOr(m[2], 1),
+				newStart: atoiOr(m[3], 0),
+				newCount: atoiOr(m[4], 1),
+				lines:    []string{line},
+			}
+			hunks = append(hunks, hunk)
+			continue
+		}
+		if hunk == nil {
+			header = append(header, line)
+			continue
+		}
+		hunk.lines = append(hunk.lines, line)
+	}
+	if len(header) == 0 {
+		return "", hunks
+	}
+
+	return strings.Join(header, "\n") + "\n", hunks
+}
+
+func atoiOr(s string, def int) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+
+	return n
+}
+
+// findHunk returns the hunk of the line of the new file. The hunk of the
+// deleted lines is on the line above them, which the sign of the deletion
+// is put on.
+func findHunk(hunks []*diffHunk, line int) *diffHunk {
+	for _, h := range hunks {
+		if h.newCount == 0 {
+			if line == h.newStart || (h.newStart == 0 && line == 1) {
+				return h
+			}
+			continue
+		}
+		if line >= h.newStart && line < h.newStart+h.newCount {
+			return h
+		}
+	}
+
+	return nil
+}
+
+// patch returns the patch of the hunk alone for git apply
+func (h *diffHunk) patch(header string) string {
+	return header + strings.Join(h.lines, "\n") + "\n"
+}
+
+// hunkPopup shows the diff of the hunk of the git sign clicked or hovered
+// in the gutter, with the buttons to stage, unstage or revert it. The
+// actions call gitsigns if it is loaded, or else apply the hunk by git. git
+// runs off the GUI thread.
+type hunkPopup struct {
+	ws      *Workspace
+	widget  *widgets.QWidget
+	text    *widgets.QLabel
+	buttons []*widgets.QPushButton
+	timer   *core.QTimer
+
+	// pos is the position of the pointer on the sign, which the popup is
+	// put under, and sign is the grid and the row of the hovered sign
+	pos  *core.QPoint
+	sign [2]int
+	// seq is the count of the queries, which drops the stale diffs
+	seq int
+
+	file     string
+	line     int
+	winid    int
+	modified bool
+	header   string
+	hunk     *diffHunk
+	staged   bool
+	gitsigns bool
+}
+
+func newHunkPopup(ws *Workspace) *hunkPopup {
+	h := &hunkPopup{
+		ws:   ws,
+		sign: [2]int{-1, -1},
+	}
+	h.timer = core.NewQTimer(nil)
+	h.timer.SetSingleShot(true)
+	h.timer.ConnectTimeout(h.query)
+	widget := widgets.NewQWidget(ws.screen.widget, 0)
+	widget.SetObjectName("hunkpopup")
+	widget.SetAttribute(core.Qt__WA_StyledBackground, true)
+	layout := widgets.NewQVBoxLayout()
+	layout.SetContentsMargins(8, 8, 8, 8)
+	layout.SetSpacing(6)
+	widget.SetLayout(layout)
+
+	text := widgets.NewQLabel(nil, 0)
+	text.SetTextFormat(core.Qt__RichText)
+	layout.AddWidget(text, 1, 0)
+
+	buttons := widgets.NewQHBoxLayout()
+	buttons.SetSpacing(6)
+	for _, action := range []string{"Stage", "Unstage", "Revert", "Close"} {
+		button := widgets.NewQPushButton2(action, nil)
+		button.SetFocusPolicy(core.Qt__NoFocus)
+		name := action
+		button.ConnectClicked(func(bool) {
+			h.run(name)
+		})
+		buttons.AddWidget(button, 0, 0)
+		h.buttons = append(h.buttons, button)
+	}
+	buttons.AddStretch(1)
+	layout.AddLayout(buttons, 0)
+	widget.Hide()
+
+	h.widget = widget
+	h.text = text
+
+	return h
+}
+
+// signAt returns the window, the row and the col of the git sign under the
+// position, or nil if the cell isn't a git sign
+func (h *hunkPopup) signAt(pos *core.QPoint) (*Window, int, int) {
+	s := h.ws.screen
+	win := s.windowAt(pos)
+	if win == nil {
+		return nil, 0, 0
+	}
+	_, row, col := s.gridPos(win, pos)
+	cell, ok := win.cellAt(row, col)
+	if !ok || !isGitSignHighlight(cell.highlight.hlName) {
+		return nil, 0, 0
+	}
+
+	return win, row, col
+}
+
+// gutterClicked queries the hunk if the cell under the click is a git sign
+func (h *hunkPopup) gutterClicked(event *gui.QMouseEvent) {
+	if event.Button() != core.Qt__LeftButton {
+		return
+	}
+	h.timer.Stop()
+	win, row, col := h.signAt(event.Pos())
+	if win == nil {
+		h.hide()
+		return
+	}
+	h.pos = event.Pos()
+	h.sign = [2]int{win.grid, row}
+	h.queryAt(win, row, col)
+}
+
+// moved restarts the wait for the popup on the git sign under the pointer
+func (h *hunkPopup) moved(event *gui.QMouseEvent) {
+	h.timer.Stop()
+	win, row, _ := h.signAt(event.Pos())
+	if win == nil {
+		h.sign = [2]int{-1, -1}
+		return
+	}
+	if h.sign == [2]int{win.grid, row} {
+		return
+	}
+	h.pos = event.Pos()
+	h.timer.Start(hunkHoverDelay)
+}
+
+// query queries the hunk of the sign under the pointer resting on it
+func (h *hunkPopup) query() {
+	win, row, col := h.signAt(h.pos)
+	if win == nil {
+		return
+	}
+	h.sign = [2]int{win.grid, row}
+	h.queryAt(win, row, col)
+}
+
+func (h *hunkPopup) queryAt(win *Window, row, col int) {
+	winid := int(win.id)
+	neovim := h.ws.nvim
+	go neovim.Call("GonvimHunkAt", nil, winid, row, col)
+}
+
+// show is called by the gonvim_hunk notification, and gets the diff of the
+// file off the GUI thread.
+// args: [file, line, winid, modified, gitsigns]
+func (h *hunkPopup) show(args []interface{}) {
+	if len(args) < 5 {
+		return
+	}
+	file, _ := args[0].(string)
+	if file == "" {
+		return
+	}
+	h.file = file
+	h.line = util.ReflectToInt(args[1])
+	h.winid = util.ReflectToInt(args[2])
+	h.modified = util.ReflectToInt(args[3]) != 0
+	h.gitsigns = util.ReflectToInt(args[4]) != 0 || args[4] == true
+
+	h.seq++
+	seq := h.seq
+	line := h.line
+	go func() {
+		staged := false
+		header, hunks := parseHunks(gitDiff(file, false))
+		hunk := findHunk(hunks, line)
+		if hunk == nil {
+			header, hunks = parseHunks(gitDiff(file, true))
+			hunk = findHunk(hunks, line)
+			staged = true
+		}
+		h.ws.guiUpdates <- []interface{}{"gonvim_hunk_diff", seq, header, hunk, staged}
+		h.ws.signal.GuiSignal()
+	}()
+}
+
+// showDiff pops up the hunk of the diff of show.
+// args: [seq, header, hunk, staged]
+func (h *hunkPopup) showDiff(args []interface{}) {
+	if len(args) < 4 {
+		return
+	}
+	if seq, _ := args[0].(int); seq != h.seq {
+		return
+	}
+	h.header, _ = args[1].(string)
+	h.hunk, _ = args[2].(*diffHunk)
+	h.staged, _ = args[3].(bool)
+	if h.hunk == nil || h.pos == nil {
+		h.hide()
+		return
+	}
+
+	h.text.SetText(hunkHTML(h.hunk, h.ws.font.family))
+	h.buttons[0].SetVisible(!h.staged)
+	h.buttons[1].SetVisible(h.staged)
+	// The revert by git rewrites the file under the unsaved changes
+	h.buttons[2].SetVisible(!h.staged && (h.gitsigns || !h.modified))
+	h.setColor()
+
+	h.widget.AdjustSize()
+	screen := h.ws.screen.widget
+	font := h.ws.font
+	x := minInt(h.pos.X(), screen.Width()-h.widget.Width())
+	y := h.pos.Y() + font.lineHeight
+	if y+h.widget.Height() > screen.Height() {
+		y = maxInt(h.pos.Y()-h.widget.Height(), 0)
+	}
+	h.widget.Move2(maxInt(x, 0), y)
+	h.widget.Show()
+	h.widget.Raise()
+}
+
+func (h *hunkPopup) hide() {
+	h.seq++
+	h.hunk = nil
+	h.widget.Hide()
+}
+
+// run runs the action of the button on the hunk off the GUI thread
+func (h *hunkPopup) run(action string) {
+	hunk := h.hunk
+	h.hide()
+	if hunk == nil || action == "Close" {
+		return
+	}
+	neovim := h.ws.nvim
+	if h.gitsigns {
+		// gitsigns acts on the hunk under the cursor, which is put on the
+		// line of the sign
+		command := fmt.Sprintf("call win_gotoid(%d) | call cursor(%d, 1) | ", h.winid, h.line) + map[string]string{
+			"Stage":   "lua require('gitsigns').stage_hunk()",
+			"Unstage": "lua require('gitsigns').undo_stage_hunk()",
+			"Revert":  "lua require('gitsigns').reset_hunk()",
+		}[action]
+		go neovim.Command(command)
+		return
+	}
+
+	var args []string
+	switch action {
+	case "Stage":
+		args = []string{"apply", "--cached", "--unidiff-zero", "-"}
+	case "Unstage":
+		args = []string{"apply", "--cached", "--unidiff-zero", "-R", "-"}
+	case "Revert":
+		args = []string{"apply", "--unidiff-zero", "-R", "-"}
+	}
+	file := h.file
+	patch := hunk.patch(h.header)
+	go func() {
+		if action == "Revert" {
+			// The buffer may be modified since the popup
+			var modified int
+			if err := neovim.Call("getbufvar", &modified, file, "&modified"); err != nil || modified != 0 {
+				editor.pushNotification(NotifyWarn, -1, "[Goneovim] Write the buffer before reverting the hunk")
+				return
+			}
+		}
+		cmd := exec.Command("git", append([]string{"-C", filepath.Dir(file)}, args...)...)
+		util.PrepareRunProc(cmd)
+		cmd.Stdin = strings.NewReader(patch)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			editor.pushNotification(NotifyWarn, -1, fmt.Sprintf("[Goneovim] git failed to %s the hunk: %s", strings.ToLower(action), strings.TrimSpace(stderr.String())))
+			return
+		}
+		if action == "Revert" {
+			neovim.Command("checktime")
+		}
+	}()
+}
+
+// gitDiff returns the diff of the file without the context lines, of the
+// index if staged
+func gitDiff(file string, staged bool) string {
+	args := []string{"-C", filepath.Dir(file), "diff", "--no-color", "--no-ext-diff", "-U0"}
+	if staged {
+		args = append(args, "--cached")
+	}
+	args = append(args, "--", filepath.Base(file))
+	cmd := exec.Command("git", args...)
+	util.PrepareRunProc(cmd)
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	return string(out)
+}
+
+// hunkHTML renders the lines of the hunk in the font of the family, with the
+// added and the deleted lines in the colors of DiffAdd and DiffDelete
+func hunkHTML(hunk *diffHunk, family string) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("<pre style=\"font-family: '%s'; margin: 0;\">", html.EscapeString(family)))
+	for i, line := range hunk.lines {
+		if i > 0 {
+			b.WriteString("<br>")
+		}
+		class := ""
+		switch {
+		case i == 0:
+			class = "header"
+		case strings.HasPrefix(line, "+"):
+			class = "add"
+		case strings.HasPrefix(line, "-"):
+			class = "delete"
+		}
+		if class == "" {
+			b.WriteString(html.EscapeString(line))
+			continue
+		}
+		b.WriteString(fmt.Sprintf("<span class=\"%s\">%s</span>", class, html.EscapeString(line)))
+	}
+	b.WriteString("</pre>")
+
+	return b.String()
+}
+
+// setColor styles the popup, and the lines of the diff set in the label
+func (h *hunkPopup) setColor() {
+	fg := editor.colors.widgetFg
+	bg := editor.colors.widgetBg
+	if fg == nil || bg == nil || editor.colors.inactiveFg == nil {
+		return
+	}
+	add, del := newRGBA(80, 160, 80, 1), newRGBA(200, 80, 80, 1)
+	if bg := h.groupBackground("DiffAdd"); bg != nil {
+		add = bg
+	}
+	if bg := h.groupBackground("DiffDelete"); bg != nil {
+		del = bg
+	}
+	h.widget.SetStyleSheet(withUserStyle(fmt.Sprintf(
+		"QWidget#hunkpopup { border: 1px solid %s; background-color: %s; } * { color: %s; }",
+		editor.colors.inactiveFg.String(),
+		bg.String(),
+		fg.String(),
+	)))
+	style := fmt.Sprintf(
+		"<style>.header { color: %s; } .add { background-color: %s; } .delete { background-color: %s; }</style>",
+		editor.colors.inactiveFg.String(),
+		add.String(),
+		del.String(),
+	)
+	h.text.SetText(style + h.text.Text())
+}
+
+// groupBackground returns the background of the highlight group of the UI
+func (h *hunkPopup) groupBackground(group string) *RGBA {
+	s := h.ws.screen
+	id, ok := s.highlightGroup[group]
+	if !ok {
+		return nil
+	}
+	hl, ok := s.hlAttrDef[id]
+	if !ok || hl == nil {
+		return nil
+	}
+
+	return hl.background
+}
@@ -0,0 +1,118 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+)
+
+const testHunkDiff = `diff --git a/main.go b/main.go
+index 1234567..89abcde 100644
+--- a/main.go
++++ b/main.go
+@@ -0,0 +1,2 @@
++// Package main
++
+@@ -5 +7 @@ func main() {
+-	a := 1
++	a := 2
+@@ -10,2 +11,0 @@ func main() {
+-	b := 1
+-	c := 1
+@@ -20 +19,3 @@
+-	return
++	d := 1
++	e := 1
++	return
+`
+
+func TestParseHunks(t *testing.T) {
+	header, hunks := parseHunks(testHunkDiff)
+	wantHeader := "diff --git a/main.go b/main.go\nindex 1234567..89abcde 100644\n--- a/main.go\n+++ b/main.go\n"
+	if header != wantHeader {
+		t.Errorf("parseHunks() header = %q, want %q", header, wantHeader)
+	}
+	want := [][5]int{
+		{0, 0, 1, 2, 3},
+		{5, 1, 7, 1, 3},
+		{10, 2, 11, 0, 3},
+		{20, 1, 19, 3, 5},
+	}
+	if len(hunks) != len(want) {
+		t.Fatalf("parseHunks() = %d hunks, want %d", len(hunks), len(want))
+	}
+	for i, h := range hunks {
+		got := [5]int{h.oldStart, h.oldCount, h.newStart, h.newCount, len(h.lines)}
+		if got != want[i] {
+			t.Errorf("parseHunks() hunk %d = %v, want %v", i, got, want[i])
+		}
+	}
+}
+
+func TestFindHunk(t *testing.T) {
+	_, hunks := parseHunks(testHunkDiff)
+	tests := []struct {
+		line int
+		want int
+	}{
+		{1, 0},
+		{2, 0},
+		{3, -1},
+		{7, 1},
+		{8, -1},
+		{11, 2},
+		{19, 3},
+		{21, 3},
+		{22, -1},
+	}
+	for _, tt := range tests {
+		got := findHunk(hunks, tt.line)
+		var want *diffHunk
+		if tt.want >= 0 {
+			want = hunks[tt.want]
+		}
+		if got != want {
+			t.Errorf("findHunk(%d) = %v, want %v", tt.line, got, want)
+		}
+	}
+}
+
+func TestFindHunkDeletedAtTop(t *testing.T) {
+	_, hunks := parseHunks("@@ -1,2 +0,0 @@\n-a\n-b\n")
+	if got := findHunk(hunks, 1); got != hunks[0] {
+		t.Errorf("findHunk(1) = %v, want %v", got, hunks[0])
+	}
+}
+
+func TestHunkPatch(t *testing.T) {
+	header, hunks := parseHunks(testHunkDiff)
+	got := hunks[1].patch(header)
+	want := header + "@@ -5 +7 @@ func main() {\n-\ta := 1\n+\ta := 2\n"
+	if got != want {
+		t.Errorf("patch() = %q, want %q", got, want)
+	}
+}
+
+func TestIsGitSignHighlight(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"GitSignsAdd", true},
+		{"GitGutterChange", true},
+		{"SignifySignDelete", true},
+		{"DiffAdd", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isGitSignHighlight(tt.name); got != tt.want {
+			t.Errorf("isGitSignHighlight(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHunkCommands(t *testing.T) {
+	// The commands are executed line by line in single quotes
+	if strings.Contains(hunkCommands, "'") {
+		t.Errorf("the commands have the single quotes: %s", hunkCommands)
+	}
+}
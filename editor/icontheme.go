@@ -0,0 +1,150 @@
+package editor
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/therecipe/qt/core"
+)
+
+// iconTheme is the directory of the user SVG icons. The file <name>.svg
+// replaces the built-in icon of the name, e.g. "go.svg" for the filetype
+// icons in the tabs, the statusline and the palette, and "lsp_function.svg"
+// for the completion kinds. "currentColor" in the SVG is replaced by the
+// color of the icon. The directory is watched, and the icons are reloaded
+// on change.
+type iconTheme struct {
+	dir     string
+	mu      sync.Mutex
+	icons   map[string]string
+	colored map[iconThemeKey]string
+	watcher *core.QFileSystemWatcher
+	reload  *core.QTimer
+}
+
+type iconThemeKey struct {
+	name  string
+	color RGBA
+}
+
+func (e *Editor) initIconTheme() {
+	dir := e.config.Editor.IconTheme
+	if dir == "" {
+		dir = filepath.Join(e.homeDir, ".goneovim", "icons")
+	}
+	if strings.HasPrefix(dir, "~") {
+		dir = filepath.Join(e.homeDir, dir[1:])
+	}
+
+	t := &iconTheme{
+		dir: dir,
+	}
+	t.load()
+	e.iconTheme = t
+	if !isFileExist(dir) {
+		return
+	}
+
+	// Saving a few icons at once changes the directory many times
+	t.reload = core.NewQTimer(nil)
+	t.reload.SetSingleShot(true)
+	t.reload.ConnectTimeout(func() {
+		t.load()
+		t.watch()
+		e.refreshIcons()
+	})
+	t.watcher = core.NewQFileSystemWatcher(nil)
+	t.watcher.ConnectDirectoryChanged(func(string) {
+		t.reload.Start(100)
+	})
+	t.watcher.ConnectFileChanged(func(string) {
+		t.reload.Start(100)
+	})
+	t.watch()
+}
+
+// load reads the SVG files in the directory
+func (t *iconTheme) load() {
+	icons := make(map[string]string)
+	defer func() {
+		t.mu.Lock()
+		t.icons = icons
+		t.colored = make(map[iconThemeKey]string)
+		t.mu.Unlock()
+	}()
+	files, err := ioutil.ReadDir(t.dir)
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || filepath.Ext(name) != ".svg" {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(t.dir, name))
+		if err != nil {
+			continue
+		}
+		icons[strings.TrimSuffix(name, ".svg")] = string(data)
+	}
+}
+
+// watch watches the directory and the files in it, which some editors
+// replace on saving
+func (t *iconTheme) watch() {
+	if paths := t.watcher.Files(); len(paths) > 0 {
+		t.watcher.RemovePaths(paths)
+	}
+	if len(t.watcher.Directories()) == 0 {
+		t.watcher.AddPath(t.dir)
+	}
+	t.mu.Lock()
+	paths := make([]string, 0, len(t.icons))
+	for name := range t.icons {
+		paths = append(paths, filepath.Join(t.dir, name+".svg"))
+	}
+	t.mu.Unlock()
+	if len(paths) > 0 {
+		t.watcher.AddPaths(paths)
+	}
+}
+
+// icon returns the SVG of the icon of the theme in the color, or false if
+// the theme doesn't have the icon
+func (t *iconTheme) icon(name string, color *RGBA) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	xml, ok := t.icons[name]
+	if !ok {
+		return "", false
+	}
+	key := iconThemeKey{name: name, color: *color}
+	if colored, ok := t.colored[key]; ok {
+		return colored, true
+	}
+	colored := strings.ReplaceAll(xml, "currentColor", color.Hex())
+	t.colored[key] = colored
+
+	return colored, true
+}
+
+// refreshIcons loads the icons of the theme again into the GUI
+func (e *Editor) refreshIcons() {
+	if !e.isSetGuiColor {
+		return
+	}
+	for _, ws := range e.workspaces {
+		ws.updateWorkspaceColor()
+		if ws.tabline == nil {
+			continue
+		}
+		for _, tab := range ws.tabline.Tabs {
+			tab.updateFileIcon()
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package editor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIconTheme(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goneovim-icons")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"go.svg":    `<svg><path fill="currentColor"/></svg>`,
+		"notes.txt": "not an icon",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	theme := &iconTheme{dir: dir}
+	theme.load()
+	got, ok := theme.icon("go", newRGBA(255, 0, 0, 1))
+	if !ok || got != `<svg><path fill="#ff0000"/></svg>` {
+		t.Errorf("icon(go) = %q, %v", got, ok)
+	}
+	if _, ok := theme.icon("notes", newRGBA(255, 0, 0, 1)); ok {
+		t.Errorf("icon(notes) is found, want only the SVG files")
+	}
+
+	var none *iconTheme
+	if _, ok := none.icon("go", newRGBA(255, 0, 0, 1)); ok {
+		t.Errorf("icon of no theme is found")
+	}
+}
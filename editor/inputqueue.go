@@ -0,0 +1,269 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+const (
+	// busyIndicatorDelay is the time in msec until the busy indicator is shown
+	busyIndicatorDelay = 500
+	// inputQueueMax is the number of the queued inputs which are sent at once
+	// without waiting for neovim to be ready
+	inputQueueMax = 256
+	// inputQueueTimeout is the longest time the inputs are queued for
+	inputQueueTimeout = 2 * time.Second
+)
+
+// inputQueue holds the inputs while neovim is busy (between busy_start and
+// busy_stop), and sends them at once when neovim is ready. The inputs are
+// held for inputQueueTimeout at most, and up to inputQueueMax of them, and
+// then sent to neovim, which takes them in its typeahead, so that the
+// inputs while neovim hangs are neither held forever nor dropped.
+type inputQueue struct {
+	ws      *Workspace
+	mu      sync.Mutex
+	busy    bool
+	pending []*queuedInput
+	// expiry sends the pending inputs on inputQueueTimeout
+	expiry *time.Timer
+
+	indicator *widgets.QWidget
+	label     *widgets.QLabel
+	timer     *core.QTimer
+}
+
+// queuedInput is keys, an amount of scroll or a mouse input.
+// Consecutive scrolls are coalesced into one.
+type queuedInput struct {
+	keys   string
+	scroll int
+	mouse  *mouseInput
+}
+
+// mouseInput is the arguments of nvim_input_mouse
+type mouseInput struct {
+	button   string
+	action   string
+	modifier string
+	grid     int
+	row      int
+	col      int
+}
+
+func (i *queuedInput) String() string {
+	switch {
+	case i.scroll > 0:
+		return fmt.Sprintf("%d<C-y>", i.scroll)
+	case i.scroll < 0:
+		return fmt.Sprintf("%d<C-e>", -i.scroll)
+	}
+
+	return i.keys
+}
+
+func newInputQueue(ws *Workspace) *inputQueue {
+	q := &inputQueue{
+		ws: ws,
+	}
+
+	widget := widgets.NewQWidget(ws.screen.widget, 0)
+	layout := widgets.NewQHBoxLayout()
+	layout.SetContentsMargins(8, 4, 4, 4)
+	layout.SetSpacing(8)
+	widget.SetLayout(layout)
+	label := widgets.NewQLabel2("nvim is busy", nil, 0)
+	cancel := widgets.NewQPushButton2("Cancel (Ctrl-C)", nil)
+	cancel.ConnectClicked(func(bool) {
+		q.cancel()
+	})
+	layout.AddWidget(label, 0, 0)
+	layout.AddWidget(cancel, 0, 0)
+	editor.focusChain.add(cancel.QWidget_PTR(), focusRankPanel, cancel.Click)
+	widget.Hide()
+	q.indicator = widget
+	q.label = label
+
+	q.timer = core.NewQTimer(nil)
+	q.timer.SetSingleShot(true)
+	q.timer.ConnectTimeout(q.showIndicator)
+
+	return q
+}
+
+// input sends the keys to neovim, or queues them if neovim is busy or the
+// connection is lost
+func (q *inputQueue) input(keys string) {
+	if keys == "" {
+		return
+	}
+	if q.ws.connection.hold(keys) {
+		return
+	}
+	// Ctrl-C is sent immediately to interrupt neovim
+	if keys == "<C-c>" {
+		q.cancel()
+		return
+	}
+	q.send(&queuedInput{keys: keys})
+}
+
+// scroll scrolls the window by lines; positive is up.
+func (q *inputQueue) scroll(lines int) {
+	if lines == 0 {
+		return
+	}
+	if q.ws.connection.hold((&queuedInput{scroll: lines}).String()) {
+		return
+	}
+	q.send(&queuedInput{scroll: lines})
+}
+
+// mouse sends the mouse input in the order of the keys
+func (q *inputQueue) mouse(button, action, modifier string, grid, row, col int) {
+	q.send(&queuedInput{mouse: &mouseInput{
+		button:   button,
+		action:   action,
+		modifier: modifier,
+		grid:     grid,
+		row:      row,
+		col:      col,
+	}})
+}
+
+// send sends the input to neovim, or queues it if neovim is busy
+func (q *inputQueue) send(input *queuedInput) {
+	q.mu.Lock()
+	if !q.busy {
+		q.mu.Unlock()
+		sendInputs(q.ws.nvim, []*queuedInput{input})
+		return
+	}
+	full := q.enqueue(input)
+	if len(q.pending) == 1 && q.expiry == nil {
+		q.expiry = time.AfterFunc(inputQueueTimeout, q.flush)
+	}
+	q.mu.Unlock()
+	if full {
+		q.flush()
+	}
+}
+
+// enqueue queues the input, coalescing the scroll into the last one, and
+// returns true if the queue is full. q.mu is held.
+func (q *inputQueue) enqueue(input *queuedInput) bool {
+	if input.scroll != 0 && len(q.pending) > 0 {
+		last := q.pending[len(q.pending)-1]
+		if last.scroll != 0 {
+			last.scroll += input.scroll
+			if last.scroll == 0 {
+				q.pending = q.pending[:len(q.pending)-1]
+			}
+			return false
+		}
+	}
+	q.pending = append(q.pending, input)
+
+	return len(q.pending) >= inputQueueMax
+}
+
+// flush sends the queued inputs, while neovim may still be busy
+func (q *inputQueue) flush() {
+	q.mu.Lock()
+	pending := q.pending
+	q.pending = nil
+	if q.expiry != nil {
+		q.expiry.Stop()
+		q.expiry = nil
+	}
+	q.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+	go sendInputs(q.ws.nvim, pending)
+}
+
+// sendInputs sends the inputs in order. The consecutive keys are sent at
+// once.
+func sendInputs(neovim *nvim.Nvim, inputs []*queuedInput) {
+	var keys strings.Builder
+	for _, input := range inputs {
+		if input.mouse == nil {
+			keys.WriteString(input.String())
+			continue
+		}
+		if keys.Len() > 0 {
+			neovim.Input(keys.String())
+			keys.Reset()
+		}
+		m := input.mouse
+		neovim.InputMouse(m.button, m.action, m.modifier, m.grid, m.row, m.col)
+	}
+	if keys.Len() > 0 {
+		neovim.Input(keys.String())
+	}
+}
+
+func (q *inputQueue) busyStart() {
+	q.mu.Lock()
+	q.busy = true
+	q.mu.Unlock()
+	q.timer.Start(busyIndicatorDelay)
+}
+
+func (q *inputQueue) busyStop() {
+	q.timer.Stop()
+	q.indicator.Hide()
+
+	q.mu.Lock()
+	q.busy = false
+	q.mu.Unlock()
+	q.flush()
+}
+
+// cancel interrupts neovim and drops the queued inputs
+func (q *inputQueue) cancel() {
+	q.mu.Lock()
+	q.pending = nil
+	if q.expiry != nil {
+		q.expiry.Stop()
+		q.expiry = nil
+	}
+	q.mu.Unlock()
+	q.ws.nvim.Input("<C-c>")
+}
+
+func (q *inputQueue) showIndicator() {
+	q.mu.Lock()
+	busy := q.busy
+	q.mu.Unlock()
+	if !busy {
+		return
+	}
+	q.setColor()
+	q.indicator.AdjustSize()
+	margin := editor.iconSize / 2
+	x := q.ws.screen.widget.Width() - q.indicator.Width() - margin
+	q.indicator.Move2(x, margin)
+	q.indicator.Show()
+	q.indicator.Raise()
+}
+
+func (q *inputQueue) setColor() {
+	if editor.colors.widgetBg == nil || editor.colors.widgetFg == nil {
+		return
+	}
+	q.indicator.SetStyleSheet(fmt.Sprintf(
+		" * { color: %s; background-color: %s; } QPushButton { border: 1px solid %s; padding: 2px 6px; }",
+		editor.colors.widgetFg.String(),
+		editor.colors.widgetBg.String(),
+		editor.colors.inactiveFg.String(),
+	))
+}
@@ -0,0 +1,47 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInputQueue_coalesceScroll(t *testing.T) {
+	q := &inputQueue{busy: true}
+	q.enqueue(&queuedInput{scroll: 3})
+	q.enqueue(&queuedInput{scroll: 2})
+	q.enqueue(&queuedInput{keys: "j"})
+	q.enqueue(&queuedInput{scroll: -1})
+	q.enqueue(&queuedInput{scroll: -2})
+	q.enqueue(&queuedInput{scroll: 4})
+
+	got := []string{}
+	for _, p := range q.pending {
+		got = append(got, p.String())
+	}
+	want := []string{"5<C-y>", "j", "1<C-y>"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pending = %v, want %v", got, want)
+	}
+
+	// The scrolls which cancel each other out are dropped
+	q.enqueue(&queuedInput{scroll: -1})
+	if len(q.pending) != 2 {
+		t.Errorf("len(pending) = %d, want 2", len(q.pending))
+	}
+}
+
+func TestInputQueue_enqueueFull(t *testing.T) {
+	q := &inputQueue{busy: true}
+	for i := 0; i < inputQueueMax-1; i++ {
+		if q.enqueue(&queuedInput{keys: "j"}) {
+			t.Fatalf("enqueue() = true at %d, want false", i)
+		}
+	}
+	// The full queue is sent, not dropped
+	if !q.enqueue(&queuedInput{mouse: &mouseInput{button: "left", action: "press"}}) {
+		t.Errorf("enqueue() = false at %d, want true", inputQueueMax)
+	}
+	if len(q.pending) != inputQueueMax || q.pending[inputQueueMax-1].mouse == nil {
+		t.Errorf("len(pending) = %d, want %d with the mouse last", len(q.pending), inputQueueMax)
+	}
+}
@@ -0,0 +1,20 @@
+package editor
+
+import (
+	"fmt"
+)
+
+// keyCommands returns the command notifying the GUI of the event, and the
+// mapping of the key to the command unless the key is already mapped
+func keyCommands(command, event, key string) string {
+	commands := fmt.Sprintf(`
+	command! %s call rpcnotify(0, "Gui", "%s")
+	`, command, event)
+	if key != "" {
+		commands = commands + fmt.Sprintf(`
+	if empty(maparg("%s", "n")) | nnoremap <silent> %s <Cmd>%s<CR> | endif
+	`, key, key, command)
+	}
+
+	return commands
+}
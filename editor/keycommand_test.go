@@ -0,0 +1,42 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeyCommands(t *testing.T) {
+	tests := []struct {
+		command string
+		event   string
+		key     string
+		want    []string
+	}{
+		{"GonvimZoom", "gonvim_zoom_toggle", "<C-w>m", []string{
+			`command! GonvimZoom call rpcnotify(0, "Gui", "gonvim_zoom_toggle")`,
+			`if empty(maparg("<C-w>m", "n")) | nnoremap <silent> <C-w>m <Cmd>GonvimZoom<CR> | endif`,
+		}},
+		{"GonvimFocusGui", "gonvim_focus_gui", "<F6>", []string{
+			`command! GonvimFocusGui call rpcnotify(0, "Gui", "gonvim_focus_gui")`,
+			`if empty(maparg("<F6>", "n")) | nnoremap <silent> <F6> <Cmd>GonvimFocusGui<CR> | endif`,
+		}},
+		{"GonvimFocusGui", "gonvim_focus_gui", "", []string{
+			`command! GonvimFocusGui call rpcnotify(0, "Gui", "gonvim_focus_gui")`,
+		}},
+	}
+	for _, tt := range tests {
+		commands := keyCommands(tt.command, tt.event, tt.key)
+		for _, want := range tt.want {
+			if !strings.Contains(commands, want) {
+				t.Errorf("keyCommands(%q, %q, %q) = %s, want %s", tt.command, tt.event, tt.key, commands, want)
+			}
+		}
+		if tt.key == "" && strings.Contains(commands, "nnoremap") {
+			t.Errorf("keyCommands(%q, %q, %q) maps the key: %s", tt.command, tt.event, tt.key, commands)
+		}
+		// The commands are executed line by line in single quotes
+		if strings.Contains(commands, "'") {
+			t.Errorf("keyCommands(%q, %q, %q) has the single quotes: %s", tt.command, tt.event, tt.key, commands)
+		}
+	}
+}
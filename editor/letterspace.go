@@ -0,0 +1,70 @@
+package editor
+
+import (
+	"math"
+	"strconv"
+)
+
+// minCellAdvance is the narrowest cell width the negative letter spacing
+// can shrink the cells to
+const minCellAdvance = 1.0
+
+// cellAdvance returns the cell width of the glyph advance with the letter
+// spacing added
+func cellAdvance(advance, letterSpace float64) float64 {
+	return math.Max(advance+letterSpace, minCellAdvance)
+}
+
+// parseLetterspace returns the letter spacing in pixels of the argument of
+// :GonvimLetterSpace or the "Letterspace" notification
+func parseLetterspace(args interface{}) (float64, bool) {
+	switch arg := args.(type) {
+	case string:
+		letterSpace, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return 0, false
+		}
+		return letterSpace, true
+	case int64:
+		return float64(arg), true
+	case uint64:
+		return float64(arg), true
+	case float64:
+		return arg, true
+	default:
+		return 0, false
+	}
+}
+
+// guiLetterspace sets the letter spacing of all the fonts of the grids, and
+// fits the grids, the cursor and the IME tooltip to the new cell width
+func (w *Workspace) guiLetterspace(args interface{}) {
+	letterSpace, ok := parseLetterspace(args)
+	if !ok || letterSpace == w.letterspace {
+		return
+	}
+	w.letterspace = letterSpace
+	w.font.setLetterSpace(letterSpace)
+	if w.fontwide != nil {
+		w.fontwide.setLetterSpace(letterSpace)
+	}
+	w.screen.windows.Range(func(_, winITF interface{}) bool {
+		win := winITF.(*Window)
+		if win != nil && win.font != nil {
+			win.font.setLetterSpace(letterSpace)
+		}
+		return true
+	})
+	w.screen.purgeTextCacheForWins()
+	w.refreshFontGeometry()
+}
+
+// refreshFontGeometry fits the grids, the cursor and the IME tooltip to the
+// changed cell size of the fonts
+func (w *Workspace) refreshFontGeometry() {
+	w.updateSize()
+	w.screen.refreshFont()
+	w.cursor.updateFont(w.font)
+	w.screen.toolTipFont(w.font)
+	w.cursor.update()
+}
@@ -0,0 +1,45 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestCellAdvance(t *testing.T) {
+	tests := []struct {
+		advance, letterSpace float64
+		want                 float64
+	}{
+		{8.4, 0, 8.4},
+		{8.4, 1.5, 9.9},
+		{8.4, -2, 6.4},
+		// The cells never collapse
+		{8.4, -10, minCellAdvance},
+	}
+	for _, tt := range tests {
+		if got := cellAdvance(tt.advance, tt.letterSpace); got != tt.want {
+			t.Errorf("cellAdvance(%v, %v) = %v, want %v", tt.advance, tt.letterSpace, got, tt.want)
+		}
+	}
+}
+
+func TestParseLetterspace(t *testing.T) {
+	tests := []struct {
+		args interface{}
+		want float64
+		ok   bool
+	}{
+		{"1.5", 1.5, true},
+		{"-2", -2, true},
+		{int64(3), 3, true},
+		{uint64(3), 3, true},
+		{0.5, 0.5, true},
+		{"wide", 0, false},
+		{nil, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseLetterspace(tt.args)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseLetterspace(%#v) = (%v, %v), want (%v, %v)", tt.args, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package editor
+
+import (
+	"math"
+	"strings"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+)
+
+// lineFit is the space added to the linespace by editor.adaptiveLineHeight,
+// so that the glyphs taller than the primary font are not clipped
+type lineFit struct {
+	ws      *Workspace
+	space   int
+	pending int
+	timer   *core.QTimer
+}
+
+func newLineFit(ws *Workspace) *lineFit {
+	f := &lineFit{
+		ws: ws,
+	}
+	// The line height is extended after the glyph is cached, not in the
+	// middle of the painting
+	f.timer = core.NewQTimer(nil)
+	f.timer.SetSingleShot(true)
+	f.timer.ConnectTimeout(f.apply)
+
+	return f
+}
+
+// extra returns the space added to the linespace
+func (f *lineFit) extra() int {
+	if f == nil {
+		return 0
+	}
+
+	return f.space
+}
+
+// fitLineHeight sets the line height to fit the tallest font in 'guifont'
+// and 'guifontwide'. The space extended for the cached glyphs is reset, as
+// the glyphs are cached again with the new font.
+func (w *Workspace) fitLineHeight() {
+	if !editor.config.Editor.AdaptiveLineHeight {
+		return
+	}
+	if w.lineFit == nil {
+		w.lineFit = newLineFit(w)
+	}
+	w.lineFit.timer.Stop()
+	w.lineFit.pending = 0
+	w.lineFit.space = maxInt(w.fontsHeight()-w.font.height, 0)
+	w.font.changeLineSpace(editor.displays.linespace(w.linespace) + w.lineFit.space)
+}
+
+// fontsHeight returns the height of the tallest font in 'guifont' and
+// 'guifontwide'. The fallback fonts in 'guifont' are measured in the size
+// of the primary font, which Qt draws them in.
+func (w *Workspace) fontsHeight() int {
+	height := w.font.height
+	size := w.font.fontNew.PointSizeF()
+	for _, gfn := range strings.Split(w.guifont, ",") {
+		family, _ := getFontFamilyAndHeight(strings.TrimSpace(gfn))
+		if family == "" || !checkValidFont(family) {
+			continue
+		}
+		font := gui.NewQFont()
+		font.SetFamily(family)
+		font.SetPointSizeF(size)
+		height = maxInt(height, int(math.Ceil(gui.NewQFontMetricsF(font).Height())))
+	}
+	if w.fontwide != nil {
+		height = maxInt(height, w.fontwide.height)
+	}
+
+	return height
+}
+
+// fitGlyph queues the extension of the line height if the glyphs of the
+// text, which may be drawn in a fallback font, overflow the line
+func (w *Window) fitGlyph(qfont *gui.QFont, text string, font *Font) {
+	f := w.s.ws.lineFit
+	if f == nil || w.font != nil || isASCII(text) {
+		return
+	}
+	fm := gui.NewQFontMetricsF(qfont)
+	rect := fm.TightBoundingRect(text)
+	lineHeight := float64(font.lineHeight)
+	// The text is drawn vertically centered in the line
+	baseline := (lineHeight-fm.Height())/2 + fm.Ascent()
+	f.extend(glyphOverflow(lineHeight, baseline, rect.Top(), rect.Bottom()))
+}
+
+// extend queues the extension of the line height by the overflow
+func (f *lineFit) extend(overflow int) {
+	// Limit the space to the font height not to run away with the odd glyphs
+	overflow = minInt(overflow, f.ws.font.height-f.space)
+	if overflow <= f.pending {
+		return
+	}
+	f.pending = overflow
+	if !f.timer.IsActive() {
+		f.timer.Start(0)
+	}
+}
+
+func (f *lineFit) apply() {
+	if f.pending <= 0 {
+		return
+	}
+	f.space += f.pending
+	f.pending = 0
+	f.ws.font.changeLineSpace(editor.displays.linespace(f.ws.linespace) + f.space)
+	f.ws.updateSize()
+}
+
+// glyphOverflow returns the height to add to the line so that the glyph from
+// top to bottom relative to the baseline fits in it. The line grows on both
+// sides, as the text is vertically centered.
+func glyphOverflow(lineHeight, baseline, top, bottom float64) int {
+	overflow := math.Max(-(baseline + top), baseline+bottom-lineHeight)
+	if overflow <= 0 {
+		return 0
+	}
+
+	return int(math.Ceil(overflow)) * 2
+}
+
+func isASCII(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] >= 0x80 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
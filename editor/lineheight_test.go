@@ -0,0 +1,41 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestGlyphOverflow(t *testing.T) {
+	tests := []struct {
+		lineHeight, baseline, top, bottom float64
+		want                              int
+	}{
+		// The glyph fits in the line
+		{20, 15, -12, 3, 0},
+		{20, 15, -15, 5, 0},
+		// Over the top
+		{20, 15, -17, 3, 4},
+		// Below the bottom
+		{20, 15, -12, 6.5, 4},
+		// Both, the larger overflow wins
+		{20, 15, -16, 8, 6},
+	}
+	for _, tt := range tests {
+		if got := glyphOverflow(tt.lineHeight, tt.baseline, tt.top, tt.bottom); got != tt.want {
+			t.Errorf("glyphOverflow(%v, %v, %v, %v) = %d, want %d", tt.lineHeight, tt.baseline, tt.top, tt.bottom, got, tt.want)
+		}
+	}
+}
+
+func TestIsASCII(t *testing.T) {
+	for text, want := range map[string]bool{
+		"":     true,
+		"func": true,
+		"日本語":  false,
+		"a😀":   false,
+		"é":    false,
+	} {
+		if got := isASCII(text); got != want {
+			t.Errorf("isASCII(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
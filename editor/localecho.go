@@ -0,0 +1,333 @@
+package editor
+
+import (
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+const (
+	// localEchoSampleInterval is the interval of the samples of the round
+	// trip time
+	localEchoSampleInterval = 2 * time.Second
+	// localEchoMinTimeout is the shortest time the predictions wait for the
+	// grid to confirm them
+	localEchoMinTimeout = time.Second
+)
+
+// localEchoCell is a char predicted by the local echo
+type localEchoCell struct {
+	char  string
+	width int
+	at    time.Time
+}
+
+// localEcho renders the chars typed in the insert mode of the remote
+// attachment (--server) before nvim echoes them, as mosh does. The
+// predictions are drawn over the grid with the provisional style from the
+// cursor, and are dropped as the grid_line events confirm them, or all at
+// once if the grid differs from them. It works only while the round trip
+// time is over the threshold.
+type localEcho struct {
+	ws     *Workspace
+	widget *widgets.QWidget
+
+	mu      sync.Mutex
+	rtt     time.Duration
+	sampled bool
+
+	cells []*localEchoCell
+	// grid, row and col are the position of the first cell of cells
+	grid int
+	row  int
+	col  int
+	// blocked stops the predictions while the keys which can't be predicted
+	// are in flight, until a flush arrives after blockedUntil
+	blocked      bool
+	blockedUntil time.Time
+}
+
+func newLocalEcho(ws *Workspace) *localEcho {
+	l := &localEcho{
+		ws: ws,
+	}
+	widget := widgets.NewQWidget(ws.screen.widget, 0)
+	widget.SetAttribute(core.Qt__WA_TransparentForMouseEvents, true)
+	widget.ConnectPaintEvent(l.paint)
+	widget.Hide()
+	l.widget = widget
+
+	return l
+}
+
+// localEchoChar returns the char the keys insert, if the keys are a
+// printable char
+func localEchoChar(keys string) (string, bool) {
+	switch keys {
+	case "<lt>":
+		return "<", true
+	case "<Space>":
+		return " ", true
+	case "<Bslash>":
+		return "\\", true
+	}
+	r, size := utf8.DecodeRuneInString(keys)
+	if r == utf8.RuneError || size != len(keys) || r == '<' || !unicode.IsPrint(r) {
+		return "", false
+	}
+
+	return keys, true
+}
+
+// localEchoReconcile compares the predicted cells from col with the grid
+// whose cursor is at cursorCol, and returns the number of the cells the grid
+// confirms. It returns false if the grid differs from the predictions: a
+// confirmed cell has the other char, or the cursor is not where the rest of
+// the predictions begins.
+func localEchoReconcile(cells []*localEchoCell, col, cursorCol int, cellAt func(int) string) (int, bool) {
+	if cursorCol < col {
+		return 0, false
+	}
+	confirmed := 0
+	for _, cell := range cells {
+		if col+cell.width > cursorCol {
+			break
+		}
+		if cellAt(col) != cell.char {
+			return 0, false
+		}
+		col += cell.width
+		confirmed++
+	}
+	if confirmed < len(cells) && col != cursorCol {
+		return 0, false
+	}
+
+	return confirmed, true
+}
+
+// sample measures the round trip time to nvim while the workspace runs
+func (l *localEcho) sample() {
+	if !editor.config.LocalEcho.Enable || !l.ws.uiRemoteAttached {
+		return
+	}
+	ticker := time.NewTicker(localEchoSampleInterval)
+	defer ticker.Stop()
+	for {
+		start := time.Now()
+		if _, err := l.ws.nvim.Mode(); err == nil {
+			rtt := time.Since(start)
+			l.mu.Lock()
+			if l.sampled {
+				l.rtt = (l.rtt*3 + rtt) / 4
+			} else {
+				l.rtt = rtt
+				l.sampled = true
+			}
+			l.mu.Unlock()
+		}
+		select {
+		case <-l.ws.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (l *localEcho) roundTrip() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.rtt
+}
+
+func (l *localEcho) active() bool {
+	if !editor.config.LocalEcho.Enable || !l.ws.uiRemoteAttached {
+		return false
+	}
+
+	return l.roundTrip() >= time.Duration(editor.config.LocalEcho.Threshold)*time.Millisecond
+}
+
+// input predicts the keys typed in the insert mode, which is called before
+// the keys are sent to nvim
+func (l *localEcho) input(keys string) {
+	if l == nil || !l.active() {
+		return
+	}
+	if l.ws.mode != "insert" || l.blocked {
+		l.block()
+		return
+	}
+	if keys == "<BS>" && len(l.cells) > 0 {
+		l.cells = l.cells[:len(l.cells)-1]
+		l.update()
+		return
+	}
+	char, ok := localEchoChar(keys)
+	if !ok {
+		l.block()
+		return
+	}
+
+	s := l.ws.screen
+	win, ok := s.getWindow(l.ws.cursor.gridid)
+	if !ok || win.isMsgGrid {
+		l.block()
+		return
+	}
+	if len(l.cells) == 0 {
+		l.grid = l.ws.cursor.gridid
+		l.row = s.cursor[0]
+		l.col = s.cursor[1]
+	}
+	width := 1
+	if !win.isNormalWidth(char) {
+		width = 2
+	}
+	end := l.col + width
+	for _, cell := range l.cells {
+		end += cell.width
+	}
+	// The predictions don't wrap the line
+	if end >= win.cols {
+		l.block()
+		return
+	}
+	l.cells = append(l.cells, &localEchoCell{
+		char:  char,
+		width: width,
+		at:    time.Now(),
+	})
+	l.update()
+}
+
+// block drops the predictions, and stops the new ones until the keys in
+// flight are echoed
+func (l *localEcho) block() {
+	l.blocked = true
+	l.blockedUntil = time.Now().Add(l.roundTrip())
+	l.clear()
+}
+
+func (l *localEcho) clear() {
+	if len(l.cells) == 0 {
+		return
+	}
+	l.cells = nil
+	l.widget.Hide()
+}
+
+// flush reconciles the predictions with the grid, which is called on each
+// flush
+func (l *localEcho) flush() {
+	if l == nil || !editor.config.LocalEcho.Enable {
+		return
+	}
+	if l.blocked && time.Now().After(l.blockedUntil) {
+		l.blocked = false
+	}
+	if len(l.cells) == 0 {
+		return
+	}
+	timeout := 4 * l.roundTrip()
+	if timeout < localEchoMinTimeout {
+		timeout = localEchoMinTimeout
+	}
+	s := l.ws.screen
+	if l.ws.mode != "insert" || l.ws.cursor.gridid != l.grid || s.cursor[0] != l.row || time.Since(l.cells[0].at) > timeout {
+		l.block()
+		return
+	}
+	win, ok := s.getWindow(l.grid)
+	if !ok {
+		l.block()
+		return
+	}
+	line, ok := win.rowAt(l.row)
+	if !ok {
+		l.block()
+		return
+	}
+	cellAt := func(col int) string {
+		if col >= len(line) || line[col] == nil {
+			return ""
+		}
+		return line[col].char
+	}
+	confirmed, ok := localEchoReconcile(l.cells, l.col, s.cursor[1], cellAt)
+	if !ok {
+		l.block()
+		return
+	}
+	l.cells = l.cells[confirmed:]
+	l.col = s.cursor[1]
+	if len(l.cells) == 0 {
+		l.widget.Hide()
+		return
+	}
+	l.update()
+}
+
+// update places the widget over the predicted cells
+func (l *localEcho) update() {
+	win, ok := l.ws.screen.getWindow(l.grid)
+	if !ok || len(l.cells) == 0 {
+		l.widget.Hide()
+		return
+	}
+	font := win.getFont()
+	width := 0
+	for _, cell := range l.cells {
+		width += cell.width
+	}
+	l.widget.SetGeometry2(
+		win.widget.X()+int(float64(l.col)*font.truewidth),
+		win.widget.Y()+l.row*font.lineHeight,
+		int(float64(width)*font.truewidth)+2,
+		font.lineHeight,
+	)
+	l.widget.Show()
+	l.widget.Raise()
+	l.widget.Update()
+}
+
+// paint draws the predicted chars dimmed and underlined, with the caret
+// after them
+func (l *localEcho) paint(event *gui.QPaintEvent) {
+	win, ok := l.ws.screen.getWindow(l.grid)
+	if !ok || editor.colors.fg == nil || editor.colors.bg == nil {
+		return
+	}
+	font := win.getFont()
+	p := gui.NewQPainter2(l.widget)
+	defer p.DestroyQPainter()
+
+	bg := editor.colors.bg
+	if win.background != nil {
+		bg = win.background
+	}
+	rect := core.NewQRectF4(0, 0, float64(l.widget.Width()), float64(l.widget.Height()))
+	p.FillRect4(rect, bg.QColor())
+
+	fg := editor.colors.fg
+	pen := gui.NewQColor3(fg.R, fg.G, fg.B, 160)
+	p.SetFont(font.fontNew)
+	p.SetPen2(pen)
+	x := 0.0
+	for _, cell := range l.cells {
+		p.DrawText(core.NewQPointF3(x, float64(font.shift)), cell.char)
+		x += float64(cell.width) * font.truewidth
+	}
+	underline := float64(font.shift) + 2
+	if underline >= float64(font.lineHeight) {
+		underline = float64(font.lineHeight) - 1
+	}
+	p.FillRect4(core.NewQRectF4(0, underline, x, 1), pen)
+	p.FillRect4(core.NewQRectF4(x, 0, 1, float64(font.lineHeight)), pen)
+}
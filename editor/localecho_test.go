@@ -0,0 +1,68 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestLocalEchoChar(t *testing.T) {
+	tests := []struct {
+		keys string
+		want string
+		ok   bool
+	}{
+		{"a", "a", true},
+		{"あ", "あ", true},
+		{"<lt>", "<", true},
+		{"<Space>", " ", true},
+		{"<Bslash>", "\\", true},
+		{"<CR>", "", false},
+		{"<C-w>", "", false},
+		{"<BS>", "", false},
+		{"ab", "", false},
+		{"\t", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := localEchoChar(tt.keys)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("localEchoChar(%q) = %q, %v, want %q, %v", tt.keys, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLocalEchoReconcile(t *testing.T) {
+	cells := []*localEchoCell{
+		{char: "a", width: 1},
+		{char: "あ", width: 2},
+		{char: "b", width: 1},
+	}
+	grid := func(line ...string) func(int) string {
+		return func(col int) string {
+			if col >= len(line) {
+				return ""
+			}
+			return line[col]
+		}
+	}
+	tests := []struct {
+		name      string
+		cursorCol int
+		cellAt    func(int) string
+		want      int
+		ok        bool
+	}{
+		{"none echoed", 4, grid("x", "x", "x", "x"), 0, true},
+		{"first echoed", 5, grid("x", "x", "x", "x", "a"), 1, true},
+		{"wide echoed", 7, grid("x", "x", "x", "x", "a", "あ", ""), 2, true},
+		{"all echoed", 8, grid("x", "x", "x", "x", "a", "あ", "", "b"), 3, true},
+		{"other char", 5, grid("x", "x", "x", "x", "c"), 0, false},
+		{"cursor back", 3, grid("x", "x", "x"), 0, false},
+		{"cursor inside wide", 6, grid("x", "x", "x", "x", "a", "あ"), 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := localEchoReconcile(cells, 4, tt.cursorCol, tt.cellAt)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("localEchoReconcile(%s) = %d, %v, want %d, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package editor
+
+// The limits of the glyph cache in the low memory mode
+const (
+	lowMemoryCacheSize   = 1024
+	lowMemoryCacheMemory = 8
+)
+
+// lowMemoryMaxFPS is the highest rate of the repaints in the low memory mode
+const lowMemoryMaxFPS = 30
+
+// applyLowMemory overrides the config with the low memory profile, for the
+// older machines and the VMs. The glyph cache is shrunk, the image caches,
+// the minimap, the animations and the drop shadows are disabled, and nvim
+// draws all the windows in a single grid, so that no widget is created per
+// window.
+func (c *gonvimConfig) applyLowMemory() {
+	if !c.Editor.LowMemory {
+		return
+	}
+	if c.Editor.CacheSize == 0 || c.Editor.CacheSize > lowMemoryCacheSize {
+		c.Editor.CacheSize = lowMemoryCacheSize
+	}
+	c.Editor.CacheMemory = minInt(c.Editor.CacheMemory, lowMemoryCacheMemory)
+	// The global grid is the only grid without ext_multigrid
+	c.Editor.SkipGlobalId = false
+	c.Editor.VsyncAnimation = false
+	if c.Editor.MaxFPS == 0 || c.Editor.MaxFPS > lowMemoryMaxFPS {
+		c.Editor.MaxFPS = lowMemoryMaxFPS
+	}
+	c.Editor.ClickEffect = false
+	c.Editor.DrawShadowForFloatWindow = false
+	c.Tabline.Preview = false
+	c.MiniMap.Disable = true
+	c.MiniMap.Visible = false
+	c.SmoothScroll.Enable = false
+	c.WindowAnimation.Enable = false
+	c.Follow.Duration = 0
+	c.ActivityBar.DropShadow = false
+	c.SideBar.DropShadow = false
+}
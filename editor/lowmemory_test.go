@@ -0,0 +1,40 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestApplyLowMemory(t *testing.T) {
+	c := &gonvimConfig{}
+	c.Editor.CacheSize = 0
+	c.Editor.CacheMemory = 64
+	c.Editor.SkipGlobalId = true
+	c.SmoothScroll.Enable = true
+	c.Tabline.Preview = true
+	c.applyLowMemory()
+	if c.Editor.CacheSize != 0 || !c.Tabline.Preview {
+		t.Errorf("the config is changed without lowMemory: %+v", c.Editor)
+	}
+
+	c.Editor.LowMemory = true
+	c.applyLowMemory()
+	if c.Editor.CacheSize != lowMemoryCacheSize {
+		t.Errorf("CacheSize = %d, want %d", c.Editor.CacheSize, lowMemoryCacheSize)
+	}
+	if c.Editor.CacheMemory != lowMemoryCacheMemory {
+		t.Errorf("CacheMemory = %d, want %d", c.Editor.CacheMemory, lowMemoryCacheMemory)
+	}
+	if c.Editor.MaxFPS != lowMemoryMaxFPS {
+		t.Errorf("MaxFPS = %d, want %d", c.Editor.MaxFPS, lowMemoryMaxFPS)
+	}
+	if c.Editor.SkipGlobalId || c.SmoothScroll.Enable || c.Tabline.Preview || !c.MiniMap.Disable {
+		t.Errorf("the features are not disabled: %+v", c)
+	}
+
+	c.Editor.CacheSize = 256
+	c.Editor.CacheMemory = 4
+	c.applyLowMemory()
+	if c.Editor.CacheSize != 256 || c.Editor.CacheMemory != 4 {
+		t.Errorf("the smaller cache is enlarged: %d, %d", c.Editor.CacheSize, c.Editor.CacheMemory)
+	}
+}
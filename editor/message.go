@@ -28,6 +28,9 @@ type Message struct {
 	pos      *core.QPoint
 	isDrag   bool
 	isExpand bool
+	// prompt is the notification of the "Press ENTER" prompt, which is
+	// dismissed when the prompt is
+	prompt *Notification
 }
 
 // MessageItem is
@@ -249,6 +252,12 @@ func (m *Message) resizeMessages() bool {
 }
 
 func (m *Message) msgShow(args []interface{}) {
+	m.showMessages(args, editor.config.Message.Notify)
+}
+
+// showMessages shows the messages in the message area, and as the
+// notifications with route
+func (m *Message) showMessages(args []interface{}, route bool) {
 	prevKind := ""
 	isActiveState := editor.window.IsActiveWindow()
 	notifyText := ""
@@ -324,6 +333,16 @@ func (m *Message) msgShow(args []interface{}) {
 			return
 		}
 
+		// The errors, the warnings, the prompts and the long messages are
+		// shown as the notifications
+		if route {
+			text := msgPlainText(arg.([]interface{})[1].([]interface{}))
+			if msgRoute(kind, text, editor.config.Message.ShortLength) == "notify" {
+				m.notify(kind, text)
+				continue
+			}
+		}
+
 		replaceLast := false
 		if len(arg.([]interface{})) > 2 {
 			replaceLast, _ = arg.([]interface{})[2].(bool)
@@ -378,11 +397,19 @@ func (m *Message) msgClear() {
 	for _, item := range m.items {
 		item.hide()
 	}
+	m.dismissPrompt()
 }
 
 func (m *Message) msgHistoryShow(args []interface{}) {
+	if editor.config.Message.HistoryPanel {
+		for _, arg := range args {
+			entries, _ := arg.([]interface{})[0].([]interface{})
+			m.ws.msgHistory.show(entries)
+		}
+		return
+	}
 	for _, arg := range args {
-		m.msgShow((arg.([]interface{})[0]).([]interface{}))
+		m.showMessages((arg.([]interface{})[0]).([]interface{}), false)
 	}
 }
 
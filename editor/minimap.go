@@ -1,94 +1,213 @@
 package editor
 
 import (
-	"bytes"
 	"fmt"
-	"io/ioutil"
-	"math"
-	"os"
 	"runtime"
 	"strings"
-	"sync"
 	"time"
+	"unicode/utf8"
 
-	"github.com/neovim/go-client/nvim"
 	"github.com/therecipe/qt/core"
 	"github.com/therecipe/qt/gui"
 	"github.com/therecipe/qt/widgets"
 )
 
-type miniMapSignal struct {
-	core.QObject
-	_ func() `signal:"stopSignal"`
-	_ func() `signal:"redrawSignal"`
+// minimapSyncDelay is the wait after the last change of the buffer before
+// the minimap fetches the lines
+const minimapSyncDelay = 800 * time.Millisecond
+
+// minimapLua returns the lines of the current buffer around the view of the
+// current window, as many as the rows of the minimap, and the highlights of
+// their chars by the treesitter or the syntax. The highlights are the spans
+// [start byte, end byte, group] of the lines, and the groups are the
+// highlight groups with their colors. The spans are cached by the line
+// numbers in the buffer, and only the lines changed since, or not fetched
+// yet, are highlighted again. The reset argument drops the cache.
+const minimapLua = `
+local rows, cols, reset = ...
+local buf = vim.api.nvim_get_current_buf()
+local count = vim.api.nvim_buf_line_count(buf)
+local top, bot = vim.fn.line("w0"), vim.fn.line("w$")
+local first = math.floor((top + bot) / 2 - rows / 2)
+first = math.max(1, math.min(first, count - rows + 1))
+local last = math.min(count, first + rows - 1)
+local lines = vim.api.nvim_buf_get_lines(buf, first - 1, last, false)
+local ok, parser = pcall(vim.treesitter.get_parser, buf)
+local ts = ok and parser and vim.treesitter.highlighter.active[buf]
+local mode = ts and "treesitter" or vim.bo[buf].syntax
+local cache = _G.gonvim_minimap_cache
+if not cache then
+  cache = {}
+  _G.gonvim_minimap_cache = cache
+end
+local c = cache[buf]
+if not c then
+  c = {spans = {}}
+  cache[buf] = c
+  vim.api.nvim_buf_attach(buf, false, {
+    on_lines = function(_, _, _, s, e, ne)
+      local spans = {}
+      for lnum, v in pairs(c.spans) do
+        if lnum <= s then
+          spans[lnum] = v
+        elseif lnum > e then
+          spans[lnum + ne - e] = v
+        end
+      end
+      c.spans = spans
+    end,
+    on_reload = function()
+      c.spans = {}
+    end,
+    on_detach = function()
+      cache[buf] = nil
+    end,
+  })
+end
+if reset or c.mode ~= mode then
+  c.spans = {}
+  c.mode = mode
+end
+local missing = {}
+for lnum = first, last do
+  if not c.spans[lnum] then
+    c.spans[lnum] = {}
+    table.insert(missing, lnum)
+  end
+end
+if #missing > 0 and ts then
+  local get_query = vim.treesitter.query.get or vim.treesitter.query.get_query or vim.treesitter.get_query
+  local i = 1
+  while i <= #missing do
+    local j = i
+    while j < #missing and missing[j + 1] == missing[j] + 1 do
+      j = j + 1
+    end
+    local from, to = missing[i], missing[j]
+    parser:for_each_tree(function(tree, ltree)
+      local query = get_query(ltree:lang(), "highlights")
+      if not query then
+        return
+      end
+      for id, node in query:iter_captures(tree:root(), buf, from - 1, to) do
+        local sr, sc, er, ec = node:range()
+        for row = math.max(sr, from - 1), math.min(er, to - 1) do
+          local s = row == sr and sc or 0
+          local e = row == er and ec or #lines[row - first + 2]
+          table.insert(c.spans[row + 1], {s, e, "@" .. query.captures[id]})
+        end
+      end
+    end)
+    i = j + 1
+  end
+elseif #missing > 0 and mode ~= "" then
+  for _, lnum in ipairs(missing) do
+    local line = lines[lnum - first + 1]
+    local prev, start = 0, 0
+    local width = math.min(#line, cols * 2)
+    for col = 1, width + 1 do
+      local id = col <= width and vim.fn.synID(lnum, col, 1) or 0
+      if id ~= prev then
+        if prev ~= 0 then
+          table.insert(c.spans[lnum], {start, col - 1, vim.fn.synIDattr(prev, "name")})
+        end
+        prev, start = id, col - 1
+      end
+    end
+  end
+end
+local groups, index = {}, {}
+local function group(name)
+  if not index[name] then
+    local id = vim.fn.synIDtrans(vim.fn.hlID(name))
+    table.insert(groups, {
+      id = id,
+      fg = vim.fn.synIDattr(id, "fg#"),
+      bold = vim.fn.synIDattr(id, "bold") == "1",
+      italic = vim.fn.synIDattr(id, "italic") == "1",
+    })
+    index[name] = #groups
+  end
+  return index[name]
+end
+local spans = {}
+for i = 1, #lines do
+  spans[i] = {}
+  for _, span in ipairs(c.spans[first + i - 1]) do
+    table.insert(spans[i], {span[1], span[2], group(span[3])})
+  end
+end
+return {first = first, tabstop = vim.bo[buf].tabstop, lines = lines, spans = spans, groups = groups}
+`
+
+// minimapLines is the result of minimapLua
+type minimapLines struct {
+	First   int             `msgpack:"first"`
+	Tabstop int             `msgpack:"tabstop"`
+	Lines   []string        `msgpack:"lines"`
+	Spans   [][][]int       `msgpack:"spans"`
+	Groups  []*minimapGroup `msgpack:"groups"`
 }
 
-// MiniMap is
-type MiniMap struct {
-	Screen
-
-	visible bool
-
-	curRegion   *widgets.QWidget
-	currBuf     string
-	colorscheme string
-
-	isSetRuntimepath bool
-	isSetColorscheme bool
-	isProcessSync    bool
+// minimapGroup is a highlight group, whose colors are used if the group
+// isn't defined on the screen yet. fg is "" if it isn't set.
+type minimapGroup struct {
+	ID     int    `msgpack:"id"`
+	Fg     string `msgpack:"fg"`
+	Bold   bool   `msgpack:"bold"`
+	Italic bool   `msgpack:"italic"`
+}
 
-	mu            sync.Mutex
-	signal        *miniMapSignal
-	redrawUpdates chan [][]interface{}
-	stopOnce      sync.Once
-	stop          chan struct{}
+// minimapCell is a cell of a line of the minimap. group is the index from 1
+// of the highlight group of the char, or 0 for the Normal highlight. The cell
+// after a wide char has no char.
+type minimapCell struct {
+	char  string
+	group int
+	wide  bool
+}
 
-	nvim       *nvim.Nvim
-	uiAttached bool
-	rows       int
-	cols       int
+// MiniMap is the overview of the current buffer beside the screen. The
+// lines are fetched from nvim with the colors of their highlight groups,
+// and their glyphs are drawn in the small font by the glyph atlas of the
+// screen.
+type MiniMap struct {
+	ws        *Workspace
+	widget    *widgets.QWidget
+	curRegion *widgets.QWidget
+	font      *Font
+	// canvas is the window of the font of the minimap, which draws the
+	// glyphs by the atlas of the screen
+	canvas *Window
+
+	visible   bool
+	content   [][]*Cell
+	first     int
+	rows      int
+	cols      int
+	fetching  bool
+	pending   bool
+	reset     bool
+	syncTimer *core.QTimer
 }
 
-func newMiniMap() *MiniMap {
+func newMiniMap(ws *Workspace) *MiniMap {
 	widget := widgets.NewQWidget(nil, 0)
 	widget.SetContentsMargins(0, 0, 0, 0)
 	widget.SetAttribute(core.Qt__WA_OpaquePaintEvent, true)
-	widget.SetStyleSheet(" * { background-color: rgba(0, 0, 0, 0);}")
 	widget.SetFixedWidth(editor.config.MiniMap.Width)
 
-	curRegion := widgets.NewQWidget(nil, 0)
-	curRegion.SetAttribute(core.Qt__WA_OpaquePaintEvent, true)
+	curRegion := widgets.NewQWidget(widget, 0)
+	curRegion.SetAttribute(core.Qt__WA_TransparentForMouseEvents, true)
 	curRegion.SetFixedWidth(editor.config.MiniMap.Width)
 	curRegion.SetFixedHeight(1)
 
 	m := &MiniMap{
-		Screen: Screen{
-			name:   "minimap",
-			widget: widget,
-			// windows:        make(map[gridId]*Window),
-			windows:        sync.Map{},
-			cursor:         [2]int{0, 0},
-			highlightGroup: make(map[string]int),
-		},
-		curRegion:     curRegion,
-		stop:          make(chan struct{}),
-		signal:        NewMiniMapSignal(nil),
-		redrawUpdates: make(chan [][]interface{}, 1000),
-	}
-	m.signal.ConnectRedrawSignal(func() {
-		updates := <-m.redrawUpdates
-		m.handleRedraw(updates)
-	})
-	m.signal.ConnectStopSignal(func() {
-	})
-	// m.widget.ConnectPaintEvent(m.paint)
-	m.widget.ConnectResizeEvent(func(event *gui.QResizeEvent) {
-		m.updateSize()
-	})
-	m.widget.ConnectMousePressEvent(m.mouseEvent)
-	m.widget.ConnectWheelEvent(m.wheelEvent)
-	m.widget.Hide()
-
+		ws:        ws,
+		widget:    widget,
+		curRegion: curRegion,
+		visible:   editor.config.MiniMap.Visible && !editor.config.MiniMap.Disable,
+	}
 	switch runtime.GOOS {
 	case "windows":
 		m.font = initFontNew("Consolas", 1.0, 0, false)
@@ -97,220 +216,262 @@ func newMiniMap() *MiniMap {
 	default:
 		m.font = initFontNew("Monospace", 1.0, 0, false)
 	}
+	m.canvas = &Window{
+		s:    ws.screen,
+		font: m.font,
+	}
+	m.syncTimer = core.NewQTimer(nil)
+	m.syncTimer.SetSingleShot(true)
+	m.syncTimer.ConnectTimeout(func() {
+		m.request(false)
+	})
 
-	return m
-}
-
-func (m *MiniMap) startMinimapProc() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	var neovim *nvim.Nvim
-	var err error
-	minimapProcessArgs := nvim.ChildProcessArgs("-u", "NONE", "-n", "--embed", "--headless")
-	if editor.opts.Nvim != "" {
-		// Attaching to /path/to/nvim
-		childProcessCmd := nvim.ChildProcessCommand(editor.opts.Nvim)
-		neovim, err = nvim.NewChildProcess(minimapProcessArgs, childProcessCmd)
-	} else {
-		// Attaching to nvim normaly
-		neovim, err = nvim.NewChildProcess(minimapProcessArgs)
-	}
-	if err != nil {
-		fmt.Println(err)
-	}
-	m.nvim = neovim
-	m.nvim.RegisterHandler("redraw", func(updates ...[]interface{}) {
-		m.redrawUpdates <- updates
-		m.signal.RedrawSignal()
+	m.widget.ConnectPaintEvent(m.paint)
+	m.widget.ConnectResizeEvent(func(event *gui.QResizeEvent) {
+		if m.updateSize() {
+			m.request(false)
+		}
 	})
-	m.width = m.widget.Width()
-	m.height = m.widget.Height()
+	m.widget.ConnectMousePressEvent(m.mouseEvent)
+	m.widget.ConnectWheelEvent(m.wheelEvent)
+	m.widget.SetVisible(m.visible)
 
-	m.updateSize()
+	return m
+}
 
-	go func() {
-		err = m.nvim.Serve()
-		if err != nil {
-			fmt.Println(err)
+// minimapCells returns the cells of the line up to the cols, whose tabs are
+// expanded by the tabstop, with the groups of the spans of the line. The
+// later spans take precedence, as the highlights of the treesitter do.
+func minimapCells(line string, spans [][]int, tabstop, cols int, isWide func(string) bool) []minimapCell {
+	if tabstop < 1 {
+		tabstop = 8
+	}
+	groups := make([]int, len(line))
+	for _, span := range spans {
+		if len(span) < 3 {
+			continue
+		}
+		start := maxInt(span[0], 0)
+		end := minInt(span[1], len(line))
+		for i := start; i < end; i++ {
+			groups[i] = span[2]
 		}
-		m.stopOnce.Do(func() {
-			close(m.stop)
-		})
-		m.signal.StopSignal()
-	}()
-
-	err = m.nvim.AttachUI(m.cols, m.rows, m.attachUIOption())
-	if err != nil {
-		fmt.Println(err)
 	}
-	m.uiAttached = true
-	m.visible = editor.config.MiniMap.Visible
-
-	m.nvim.Subscribe("Gui")
-	m.nvim.Command(":syntax on")
-	m.nvim.Command(":set nobackup noswapfile mouse=nv laststatus=0 noruler nowrap noshowmode virtualedit+=all")
-}
 
-func (m *MiniMap) exit() {
-	go m.nvim.Command(":q!")
-}
+	var cells []minimapCell
+	for i, c := range line {
+		if len(cells) >= cols {
+			break
+		}
+		group := groups[i]
+		if c == '\t' {
+			for n := tabstop - len(cells)%tabstop; n > 0 && len(cells) < cols; n-- {
+				cells = append(cells, minimapCell{char: " ", group: group})
+			}
+			continue
+		}
+		char := string(c)
+		if c == utf8.RuneError {
+			char = "?"
+		}
+		if isWide(char) {
+			if len(cells)+2 > cols {
+				break
+			}
+			cells = append(cells, minimapCell{char: char, group: group, wide: true}, minimapCell{group: group})
+			continue
+		}
+		cells = append(cells, minimapCell{char: char, group: group})
+	}
 
-func (m *MiniMap) attachUIOption() map[string]interface{} {
-	o := make(map[string]interface{})
-	o["rgb"] = true
-	o["ext_linegrid"] = true
-	return o
+	return cells
 }
 
 func (m *MiniMap) setColor() {
 	c := editor.colors.fg
+	if c == nil {
+		return
+	}
 	m.curRegion.SetStyleSheet(fmt.Sprintf(" * { background-color: rgba(%d, %d, %d, 0.1);}", c.R, c.G, c.B))
+	m.request(false)
 }
 
 func (m *MiniMap) toggle() {
-	win, ok := m.getWindow(1)
-	if !ok {
+	if editor.config.MiniMap.Disable {
 		return
 	}
-	if m.visible {
-		m.visible = false
-	} else {
-		m.visible = true
-	}
-	m.curRegion.SetParent(win.widget)
-	m.bufUpdate()
-	m.bufSync()
+	m.visible = !m.visible
+	m.widget.SetVisible(m.visible)
+	m.request(false)
 }
 
-func (m *MiniMap) updateRows() bool {
-	var ret bool
-	m.height = m.widget.Height()
-	rows := m.height / m.font.lineHeight
-
-	if rows != m.rows {
-		ret = true
+// updateSize returns true if the rows or the cols of the minimap change
+func (m *MiniMap) updateSize() bool {
+	rows := m.widget.Height() / m.font.lineHeight
+	cols := int(float64(m.widget.Width()) / m.font.truewidth)
+	if rows == m.rows && cols == m.cols {
+		return false
 	}
 	m.rows = rows
-	return ret
-}
-
-func (m *MiniMap) updateCols() bool {
-	var ret bool
-	m.width = m.widget.Width()
-	cols := int(float64(m.width) / m.font.truewidth)
-
-	if cols != m.cols {
-		ret = true
-	}
 	m.cols = cols
-	return ret
+
+	return true
 }
 
-func (m *MiniMap) updateSize() {
-	isColDiff := m.updateCols()
-	isRowDiff := m.updateRows()
-	isTryResize := isColDiff || isRowDiff
-	if m.uiAttached && isTryResize {
-		m.nvim.TryResizeUI(m.cols, m.rows)
+// sync fetches the lines after the changes of the buffer settle, which is
+// called on each change
+func (m *MiniMap) sync() {
+	if !m.visible {
+		return
 	}
+	m.syncTimer.Start(int(minimapSyncDelay / time.Millisecond))
 }
 
-func (m *MiniMap) bufUpdate() {
+// request fetches the lines of the minimap, which is called when the buffer
+// or the view changes. The reset drops the highlights cached in nvim, e.g.
+// on entering the buffer.
+func (m *MiniMap) request(reset bool) {
+	if !m.visible || m.ws.nvim == nil {
+		return
+	}
 	if strings.Contains(m.ws.filepath, "[denite]") {
 		return
 	}
-	if !m.visible {
-		m.widget.Hide()
+	m.reset = m.reset || reset
+	if m.fetching {
+		m.pending = true
 		return
 	}
-	if m.ws.nvim == nil || m.nvim == nil {
+	m.updateSize()
+	if m.rows == 0 || m.cols == 0 {
 		return
 	}
-	m.setColorscheme()
-	m.widget.Show()
+	m.syncTimer.Stop()
+	m.fetching = true
+	rows, cols, reset := m.rows, m.cols, m.reset
+	m.reset = false
+	go func() {
+		lines := &minimapLines{}
+		err := m.ws.nvim.ExecuteLua(minimapLua, lines, rows, cols, reset)
+		if err != nil {
+			fmt.Println(err)
+			lines = nil
+		}
+		m.ws.guiUpdates <- []interface{}{"gonvim_minimap_lines", lines}
+		m.ws.signal.GuiSignal()
+	}()
+}
 
-	if m.currBuf == m.ws.filepath {
+// setLines is called by the gonvim_minimap_lines update, and sets the cells
+// of the lines with the colors of their highlight groups
+func (m *MiniMap) setLines(lines *minimapLines) {
+	m.fetching = false
+	if m.pending {
+		m.pending = false
+		m.request(false)
+	}
+	if lines == nil {
 		return
 	}
-	m.currBuf = m.ws.filepath
 
-	if m.currBuf == "" {
-		go m.nvim.Command(":e! [No Name]")
-	} else {
-		go m.nvim.Command(":e! " + m.currBuf)
+	isWide := func(char string) bool {
+		return !m.canvas.isNormalWidth(char)
 	}
+	content := m.ws.screen.linesContent(lines, m.cols, isWide)
+	m.content = content
+	m.first = lines.First
+	m.widget.Update()
 	m.mapScroll()
 }
 
-func (m *MiniMap) setColorscheme() {
-	if m.isSetColorscheme {
-		return
+// linesContent returns the cells of the lines fetched with the highlights
+// of minimapLua up to the cols. The groups take the colors of the screen,
+// which nvim defined by hl_attr_define, and the colors from nvim if they
+// aren't drawn yet.
+func (s *Screen) linesContent(lines *minimapLines, cols int, isWide func(string) bool) [][]*Cell {
+	defined := make(map[int]*Highlight)
+	for _, hl := range s.hlAttrDef {
+		if hl != nil && hl.id != 0 {
+			defined[hl.id] = hl
+		}
 	}
-	colo, _ := m.ws.nvim.CommandOutput("colo")
-
-	if !m.isSetRuntimepath {
-		sep := "/"
-		switch runtime.GOOS {
-		case "windows":
-			sep = `\`
-		default:
+	highlights := make([]Highlight, len(lines.Groups)+1)
+	for i, g := range lines.Groups {
+		if g == nil {
+			continue
+		}
+		if hl, ok := defined[g.ID]; ok {
+			highlights[i+1].foreground = hl.foreground
+			highlights[i+1].bold = hl.bold
+			highlights[i+1].italic = hl.italic
+			continue
+		}
+		if g.Fg != "" {
+			highlights[i+1].foreground = hexToRGBA(g.Fg)
 		}
+		highlights[i+1].bold = g.Bold
+		highlights[i+1].italic = g.Italic
+	}
 
-		runtimePaths, _ := m.ws.nvim.RuntimePaths()
-		runtimeDir := ""
-		colorschemePath := ""
-		for _, path := range runtimePaths {
-			lsDirs, _ := ioutil.ReadDir(path)
-			for _, d := range lsDirs {
-				dirname := d.Name()
-				finfo, err := os.Stat(path + sep + dirname)
-				if err != nil {
-					continue
-				}
-				if finfo.IsDir() {
-					packDirs, _ := ioutil.ReadDir(path + sep + dirname)
-					for _, p := range packDirs {
-						plugname := p.Name()
-						if strings.Contains(plugname, colo) {
-							runtimeDir = path
-							colorschemePath = path + sep + dirname + sep + plugname
-							break
-						}
-					}
-					if colorschemePath != "" {
-						break
-					}
-				}
+	content := make([][]*Cell, len(lines.Lines))
+	for y, line := range lines.Lines {
+		var spans [][]int
+		if y < len(lines.Spans) {
+			spans = lines.Spans[y]
+		}
+		cells := minimapCells(line, spans, lines.Tabstop, cols, isWide)
+		content[y] = make([]*Cell, len(cells))
+		for x, c := range cells {
+			group := c.group
+			if group >= len(highlights) {
+				group = 0
+			}
+			content[y][x] = &Cell{
+				char:        c.char,
+				normalWidth: !c.wide,
+				highlight:   highlights[group],
 			}
 		}
-
-		// set runtimepath
-		m.nvim.Command("set runtimepath^=" + runtimeDir)
-		m.isSetRuntimepath = true
 	}
 
-	if m.colorscheme == colo {
+	return content
+}
+
+// follow fetches the lines when the view of the current window leaves them,
+// and places the region of the view, which is called on each flush. The
+// view taller than the minimap follows its center line.
+func (m *MiniMap) follow() {
+	if !m.visible {
+		return
+	}
+	win, ok := m.ws.screen.getWindow(m.ws.cursor.gridid)
+	if !ok {
 		return
 	}
-	// set colorscheme
-	m.nvim.Command(":colorscheme " + colo)
-	m.colorscheme = colo
-	m.isSetColorscheme = true
+	top := m.ws.curLine - m.ws.screen.cursor[0]
+	bottom := top + win.rows
+	if win.rows >= m.rows {
+		top += win.rows / 2
+		bottom = top + 1
+	}
+	if m.first > 0 && len(m.content) == m.rows && (top < m.first || bottom > m.first+len(m.content)) {
+		m.request(false)
+	}
+	m.mapScroll()
 }
 
+// mapScroll places the region of the view of the current window in the
+// minimap
 func (m *MiniMap) mapScroll() {
-	absScreenTop := m.ws.curLine - m.ws.screen.cursor[0]
-
-	var absMapTop int
-	m.nvim.Eval("line('w0')", &absMapTop)
-
-	linePos := absScreenTop-absMapTop
-
+	if !m.visible {
+		return
+	}
 	win, ok := m.ws.screen.getWindow(m.ws.cursor.gridid)
 	if !ok {
 		return
 	}
+	absScreenTop := m.ws.curLine - m.ws.screen.cursor[0]
+	linePos := absScreenTop - m.first
 	regionHeight := win.rows
 
 	if linePos < 0 {
@@ -320,310 +481,78 @@ func (m *MiniMap) mapScroll() {
 	if regionHeight < 0 {
 		regionHeight = 0
 	}
-	m.curRegion.SetFixedHeight(int(float64(regionHeight) * float64(m.font.lineHeight)))
-	pos := int(float64(m.font.lineHeight) * float64(linePos))
-	m.curRegion.Move2(0, pos)
+	m.curRegion.SetFixedHeight(regionHeight * m.font.lineHeight)
+	m.curRegion.Move2(0, m.font.lineHeight*linePos)
 }
 
-func (m *MiniMap) bufSync() {
-	if m.isProcessSync {
-		return
-	}
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	defer func() {
-		m.isProcessSync = false
-	}()
-	m.isProcessSync = true
-	time.Sleep(800 * time.Millisecond)
-	if strings.Contains(m.ws.filepath, "[denite]") {
-		return
-	}
-	if !m.visible {
-		m.widget.Hide()
-		return
-	}
-	if m.ws.nvim == nil || m.nvim == nil {
-		return
-	}
-
-	// Get current buffer
-	buf, err := m.ws.nvim.CurrentBuffer()
-	if err != nil {
-		return
-	}
+func (m *MiniMap) paint(event *gui.QPaintEvent) {
+	p := gui.NewQPainter2(m.widget)
+	defer p.DestroyQPainter()
 
-	// mmWin, ok := m.getWindow(1)
-	// if !ok {
-	// 	return
-	// }
-
-	start := 0
-	end := 0
-	var pos [4]int
-	m.ws.nvim.Eval("getpos('$')", &pos)
-	var minimapPos [4]int
-	m.nvim.Eval("getpos('$')", &minimapPos)
-	if pos[1] > minimapPos[1] {
-		end = pos[1] + 1
-	} else {
-		end = minimapPos[1] + 1
-	}
-
-	// Get buffer contents
-	replacement, err := m.ws.nvim.BufferLines(
-		buf,
-		start,
-		end,
-		false,
-	)
-	if err != nil {
-		return
+	bg := m.ws.background
+	if bg == nil {
+		bg = editor.colors.bg
 	}
-	if len(replacement) < 1 {
-		return
+	if bg != nil {
+		p.FillRect4(
+			core.NewQRectF4(0, 0, float64(m.widget.Width()), float64(m.widget.Height())),
+			bg.QColor(),
+		)
 	}
-
-	// Get current buffer of minimap
-	minimapBuf, err := m.nvim.CurrentBuffer()
-	if err != nil {
-		return
+	if m.canvas.devicePixelRatio == 0 {
+		m.canvas.devicePixelRatio = float64(p.PaintEngine().PaintDevice().DevicePixelRatio())
 	}
 
-	// Set buffer contents
-	m.nvim.SetBufferLines(
-		minimapBuf,
-		start,
-		end,
-		false,
-		replacement,
-	)
-}
-
-func (m *MiniMap) handleRedraw(updates [][]interface{}) {
-	for _, update := range updates {
-		event := update[0].(string)
-		args := update[1:]
-		switch event {
-
-		case "grid_resize":
-			m.gridResize(args)
-		// case "default_colors_set":
-		// 	args := update[1].([]interface{})
-		// 	w.setColorsSet(args)
-		case "hl_attr_define":
-			m.setHlAttrDef(args)
-		case "hl_group_set":
-			m.setHighlightGroup(args)
-			m.setColor()
-		case "grid_line":
-			m.gridLine(args)
-		case "grid_clear":
-			m.gridClear(args)
-		case "grid_destroy":
-			m.gridDestroy(args)
-		case "grid_cursor_goto":
-			// m.gridCursorGoto(args)
-		case "grid_scroll":
-			m.gridScroll(args)
-			m.mapScroll()
-
-		default:
+	font := m.font
+	atlas := m.ws.screen.atlas
+	for y, line := range m.content {
+		for x, cell := range line {
+			if cell == nil || cell.char == " " || cell.char == "" {
+				continue
+			}
+			m.canvas.drawGlyph(p, atlas, cell, float64(x)*font.truewidth, float64(y*font.lineHeight), false)
 		}
 	}
-	m.update()
-}
-
-func (m *MiniMap) transparent(bg *RGBA) int {
-	transparent := int(math.Trunc(editor.config.Editor.Transparent * float64(255)))
-
-	var t int
-	if m.ws.background.equals(bg) {
-		t = 0
-	} else {
-		t = transparent
-	}
-	return t
 }
 
 func (m *MiniMap) wheelEvent(event *gui.QWheelEvent) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	var v, h, vert, horiz, accel int
-	font := m.font
-	win, ok := m.getWindow(1)
-	if !ok {
+	delta := event.AngleDelta().Y()
+	if delta == 0 {
 		return
 	}
-
-	switch runtime.GOOS {
-	case "darwin":
-		pixels := event.PixelDelta()
-		if pixels != nil {
-			v = pixels.Y()
-			h = pixels.X()
-		}
-		if pixels.X() < 0 && win.scrollDust[0] > 0 {
-			win.scrollDust[0] = 0
-		}
-		if pixels.Y() < 0 && win.scrollDust[1] > 0 {
-			win.scrollDust[1] = 0
-		}
-
-		dx := math.Abs(float64(win.scrollDust[0]))
-		dy := math.Abs(float64(win.scrollDust[1]))
-
-		fontheight := float64(font.lineHeight)
-		fontwidth := font.truewidth
-
-		win.scrollDust[0] += h
-		win.scrollDust[1] += v
-
-		if dx >= fontwidth {
-			horiz = int(math.Trunc(float64(win.scrollDust[0]) / fontheight))
-			win.scrollDust[0] = 0
-		}
-		if dy >= fontwidth {
-			vert = int(math.Trunc(float64(win.scrollDust[1]) / fontwidth))
-			win.scrollDust[1] = 0
-		}
-
-		win.scrollDustDeltaY = int(math.Abs(float64(vert)) - float64(win.scrollDustDeltaY))
-		if win.scrollDustDeltaY < 1 {
-			win.scrollDustDeltaY = 0
-		}
-
-		if win.scrollDustDeltaY <= 2 {
-			accel = 1
-		} else if win.scrollDustDeltaY > 2 {
-			accel = int(float64(win.scrollDustDeltaY) / float64(4))
-		}
-
-	default:
-		vert = event.AngleDelta().Y()
-		accel = 16
-	}
-	if vert == 0 && horiz == 0 {
-		return
+	// A notch of the wheel scrolls the window by 16 lines
+	lines := delta * 16 / 120
+	switch {
+	case lines == 0 && delta > 0:
+		lines = 1
+	case lines == 0 && delta < 0:
+		lines = -1
 	}
-
-	if vert > 0 {
-		m.nvim.Input(fmt.Sprintf("%v<C-y>", accel))
-	} else if vert < 0 {
-		m.nvim.Input(fmt.Sprintf("%v<C-e>", accel))
-	}
-	m.mapScroll()
-
+	m.ws.inputQueue.scroll(lines)
 	event.Accept()
 }
 
 func (m *MiniMap) mouseEvent(event *gui.QMouseEvent) {
-	font := m.font
-	y := int(float64(event.Y()) / float64(font.lineHeight))
-	var absMapTop int
-	m.nvim.Eval("line('w0')", &absMapTop)
-	targetPos := absMapTop + y
-	m.ws.nvim.Command(fmt.Sprintf("%d", targetPos))
-
-	mappings, err := m.ws.nvim.KeyMap("normal")
-	if err != nil {
-		return
-	}
-	var isThereZzMap bool
-	for _, mapping := range mappings {
-		if mapping.LHS == "zz" {
-			isThereZzMap = true
-		}
-	}
-	if !isThereZzMap {
-		m.ws.nvim.Input("zz")
-	}
-}
-
-func (w *Window) drawMinimap(p *gui.QPainter, y int, col int, cols int) {
-	if y >= len(w.content) {
+	if m.first == 0 {
 		return
 	}
-	wsfont := w.getFont()
-	font := p.Font()
-	line := w.content[y]
-	chars := map[Highlight][]int{}
-	specialChars := []int{}
-
-	for x := col; x < col+cols; x++ {
-		if x >= len(line) {
-			continue
-		}
-		if line[x] == nil {
-			continue
-		}
-		if line[x].char == " " {
-			continue
-		}
-		if line[x].char == "" {
-			continue
-		}
-		if !line[x].normalWidth {
-			specialChars = append(specialChars, x)
-			continue
-		}
-
-		highlight := line[x].highlight
-		colorSlice, ok := chars[highlight]
-		if !ok {
-			colorSlice = []int{}
-		}
-		colorSlice = append(colorSlice, x)
-		chars[highlight] = colorSlice
-	}
-
-	pointF := core.NewQPointF3(
-		float64(col)*wsfont.truewidth,
-		float64((y)*wsfont.lineHeight+wsfont.shift),
-	)
+	y := int(float64(event.Y()) / float64(m.font.lineHeight))
+	targetPos := m.first + y
+	go func() {
+		m.ws.nvim.Command(fmt.Sprintf("%d", targetPos))
 
-	for highlight, colorSlice := range chars {
-		var buffer bytes.Buffer
-		slice := colorSlice[:]
-		for x := col; x < col+cols; x++ {
-			if len(slice) == 0 {
-				break
-			}
-			index := slice[0]
-			if x < index {
-				buffer.WriteString(" ")
-				continue
-			}
-			if x == index {
-				buffer.WriteString(`@`)
-				slice = slice[1:]
-			}
+		mappings, err := m.ws.nvim.KeyMap("normal")
+		if err != nil {
+			return
 		}
-
-		text := buffer.String()
-		if text != "" {
-			fg := highlight.fg()
-			if fg != nil {
-				p.SetPen2(fg.QColor())
+		var isThereZzMap bool
+		for _, mapping := range mappings {
+			if mapping.LHS == "zz" {
+				isThereZzMap = true
 			}
-			font.SetBold(highlight.bold)
-			font.SetItalic(highlight.italic)
-			p.DrawText(pointF, text)
 		}
-
-	}
-
-	for _, x := range specialChars {
-		if line[x] == nil || line[x].char == " " {
-			continue
+		if !isThereZzMap {
+			m.ws.nvim.Input("zz")
 		}
-		fg := line[x].highlight.fg()
-		p.SetPen2(fg.QColor())
-		pointF.SetX(float64(x) * wsfont.truewidth)
-		pointF.SetY(float64((y)*wsfont.lineHeight + wsfont.shift))
-		font.SetBold(line[x].highlight.bold)
-		font.SetItalic(line[x].highlight.italic)
-		p.DrawText(pointF, line[x].char)
-	}
+	}()
 }
@@ -0,0 +1,66 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMinimapCells(t *testing.T) {
+	isWide := func(char string) bool {
+		return char == "あ"
+	}
+	tests := []struct {
+		line  string
+		spans [][]int
+		cols  int
+		want  []minimapCell
+	}{
+		{
+			"ab",
+			nil,
+			10,
+			[]minimapCell{{char: "a"}, {char: "b"}},
+		},
+		{
+			"abc",
+			[][]int{{0, 2, 1}, {1, 3, 2}},
+			10,
+			[]minimapCell{{char: "a", group: 1}, {char: "b", group: 2}, {char: "c", group: 2}},
+		},
+		{
+			"a\tb",
+			[][]int{{2, 3, 1}},
+			10,
+			[]minimapCell{{char: "a"}, {char: " "}, {char: " "}, {char: " "}, {char: "b", group: 1}},
+		},
+		{
+			"あb",
+			[][]int{{0, 3, 1}},
+			10,
+			[]minimapCell{{char: "あ", group: 1, wide: true}, {group: 1}, {char: "b"}},
+		},
+		{
+			"abcd",
+			nil,
+			2,
+			[]minimapCell{{char: "a"}, {char: "b"}},
+		},
+		{
+			"aあ",
+			nil,
+			2,
+			[]minimapCell{{char: "a"}},
+		},
+		{
+			"ab",
+			[][]int{{-1, 9, 1}, {1}},
+			10,
+			[]minimapCell{{char: "a", group: 1}, {char: "b", group: 1}},
+		},
+	}
+	for _, tt := range tests {
+		if got := minimapCells(tt.line, tt.spans, 4, tt.cols, isWide); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("minimapCells(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package editor
+
+import (
+	"time"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+)
+
+// mouseMoveInterval is the minimum interval to send <MouseMove> to nvim
+const mouseMoveInterval = 30 * time.Millisecond
+
+// mouseMove forwards the mouse moves without buttons as <MouseMove> while
+// 'mousemoveevent' is set. Only the moves to another cell are sent, and they
+// are throttled so that the fast motion doesn't flood the RPC channel.
+type mouseMove struct {
+	s       *Screen
+	enabled bool
+	timer   *core.QTimer
+
+	pos    [3]int
+	mods   string
+	last   [3]int
+	sentAt time.Time
+}
+
+func newMouseMove(s *Screen) *mouseMove {
+	m := &mouseMove{
+		s:    s,
+		last: [3]int{-1, -1, -1},
+	}
+	m.timer = core.NewQTimer(nil)
+	m.timer.SetSingleShot(true)
+	m.timer.ConnectTimeout(m.send)
+
+	return m
+}
+
+// setMouseMoveEvent is called by the gonvim_mousemoveevent notification
+// with the value of 'mousemoveevent'
+func (s *Screen) setMouseMoveEvent(arg interface{}) {
+	enabled := util.ReflectToInt(arg) != 0
+	if s.mouseMove == nil {
+		if !enabled {
+			return
+		}
+		s.mouseMove = newMouseMove(s)
+	}
+	s.mouseMove.enabled = enabled
+	s.mouseMove.last = [3]int{-1, -1, -1}
+	if !enabled {
+		s.mouseMove.timer.Stop()
+	}
+	// The move events without buttons are delivered only with mouse tracking
+	s.widget.SetMouseTracking(enabled || s.hoverTracking())
+}
+
+// hoverTracking returns true if the popups on the hover need the moves
+// without buttons
+func (s *Screen) hoverTracking() bool {
+	return s.spellHover != nil || editor.config.Editor.GitHunkPopup
+}
+
+// mouseMoved queues the move to the cell under the pointer
+func (s *Screen) mouseMoved(event *gui.QMouseEvent) {
+	if s.spellHover != nil {
+		s.spellHover.moved(event)
+	}
+	if editor.config.Editor.GitHunkPopup && s.ws.hunkPopup != nil {
+		s.ws.hunkPopup.moved(event)
+	}
+	m := s.mouseMove
+	if m == nil || !m.enabled {
+		return
+	}
+	grid, row, col := s.gridPos(s.windowAt(event.Pos()), event.Pos())
+	m.pos = [3]int{grid, row, col}
+	m.mods = editor.modPrefix(event.Modifiers())
+	if m.pos == m.last {
+		return
+	}
+
+	wait := mouseMoveInterval - time.Since(m.sentAt)
+	if wait <= 0 {
+		m.send()
+		return
+	}
+	if !m.timer.IsActive() {
+		m.timer.Start(int(wait / time.Millisecond))
+	}
+}
+
+// send sends the latest position, dropping the moves in between
+func (m *mouseMove) send() {
+	if !m.enabled || m.pos == m.last {
+		return
+	}
+	m.last = m.pos
+	m.sentAt = time.Now()
+	go m.s.ws.nvim.InputMouse("move", "", m.mods, m.pos[0], m.pos[1], m.pos[2])
+}
@@ -0,0 +1,152 @@
+package editor
+
+import (
+	"strings"
+
+	clipb "github.com/atotto/clipboard"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/svg"
+)
+
+// msgGridMaxRows returns the maximum number of rows of the msg grid
+// that can be displayed at once.
+func (s *Screen) msgGridMaxRows() int {
+	maxRows := int(float64(s.height/s.font.lineHeight) * editor.config.Message.MaxHeightRatio)
+	if maxRows < 1 {
+		maxRows = 1
+	}
+
+	return maxRows
+}
+
+// msgGridVisibleRows returns the number of the rows used for messages.
+func (w *Window) msgGridVisibleRows() int {
+	rows := w.rows - w.pos[1]
+	if rows < 0 {
+		rows = 0
+	}
+
+	return rows
+}
+
+// msgGridShift caps the height of the msg grid, and returns the y
+// displacement in pixels according to the scroll amount of the messages.
+func (w *Window) msgGridShift() int {
+	font := w.s.font
+	visibleRows := w.msgGridVisibleRows()
+	maxRows := w.s.msgGridMaxRows()
+
+	if visibleRows <= maxRows {
+		w.msgScroll = 0
+		w.msgFirstRow = 0
+		w.widget.ClearMask()
+		w.moveMsgCopyButton()
+		return 0
+	}
+
+	hiddenRows := visibleRows - maxRows
+	if w.msgScroll > hiddenRows {
+		w.msgScroll = hiddenRows
+	}
+	if w.msgScroll < 0 {
+		w.msgScroll = 0
+	}
+	w.msgFirstRow = hiddenRows - w.msgScroll
+
+	w.widget.SetMask2(
+		gui.NewQRegion2(
+			0,
+			w.msgFirstRow*font.lineHeight,
+			w.widget.Width(),
+			maxRows*font.lineHeight,
+			gui.QRegion__Rectangle,
+		),
+	)
+	w.moveMsgCopyButton()
+
+	return w.msgScroll * font.lineHeight
+}
+
+// msgGridWheelEvent scrolls the messages instead of the buffer
+func (w *Window) msgGridWheelEvent(event *gui.QWheelEvent) {
+	event.Accept()
+	font := w.s.font
+
+	delta := 0
+	pixels := event.PixelDelta()
+	if pixels != nil && pixels.Y() != 0 {
+		delta = pixels.Y()
+	} else {
+		// Scroll per 3 lines
+		delta = event.AngleDelta().Y() * font.lineHeight * 3 / 120
+	}
+
+	w.msgScrollDust += delta
+	rows := w.msgScrollDust / font.lineHeight
+	if rows == 0 {
+		return
+	}
+	w.msgScrollDust -= rows * font.lineHeight
+	w.msgScroll += rows
+	w.move(w.pos[0], w.pos[1])
+}
+
+func (w *Window) initMsgCopyButton() {
+	if w.msgCopyButton != nil {
+		return
+	}
+	size := editor.iconSize
+	button := svg.NewQSvgWidget(w.widget)
+	button.SetFixedSize2(size, size)
+	button.SetToolTip("Copy message")
+	svgContent := editor.getSvg("copy", nil)
+	button.Load2(core.NewQByteArray2(svgContent, len(svgContent)))
+	button.ConnectMousePressEvent(func(*gui.QMouseEvent) {
+		clipb.WriteAll(w.msgText())
+	})
+	button.Hide()
+	w.msgCopyButton = button
+
+	w.widget.ConnectEnterEvent(func(event *core.QEvent) {
+		if !w.isMsgGrid {
+			return
+		}
+		w.moveMsgCopyButton()
+		w.msgCopyButton.Show()
+		w.msgCopyButton.Raise()
+	})
+	w.widget.ConnectLeaveEvent(func(event *core.QEvent) {
+		w.msgCopyButton.Hide()
+	})
+}
+
+func (w *Window) moveMsgCopyButton() {
+	if w.msgCopyButton == nil {
+		return
+	}
+	margin := w.s.font.lineHeight / 4
+	x := w.widget.Width() - w.msgCopyButton.Width() - margin
+	y := w.msgFirstRow*w.s.font.lineHeight + margin
+	w.msgCopyButton.Move2(x, y)
+}
+
+// msgText returns the text of the messages displayed in the msg grid
+func (w *Window) msgText() string {
+	w.paintMutex.Lock()
+	defer w.paintMutex.Unlock()
+	lines := []string{}
+	for row := 0; row < w.msgGridVisibleRows() && row < len(w.content); row++ {
+		var builder strings.Builder
+		for _, cell := range w.content[row] {
+			if cell == nil {
+				builder.WriteString(" ")
+				continue
+			}
+			builder.WriteString(cell.char)
+		}
+		lines = append(lines, strings.TrimRight(builder.String(), " "))
+	}
+
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
@@ -0,0 +1,302 @@
+package editor
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/akiyosi/goneovim/util"
+	clipb "github.com/atotto/clipboard"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+// msgHistoryChunk is a chunk of a message of the history, [attr, text]
+type msgHistoryChunk struct {
+	attr int
+	text string
+}
+
+// parseMsgHistory returns the messages of the entries of msg_history_show,
+// [[kind, chunks, append], ...]. The entry with append is joined to the
+// previous message.
+func parseMsgHistory(entries []interface{}) [][]msgHistoryChunk {
+	var messages [][]msgHistoryChunk
+	for _, e := range entries {
+		entry, ok := e.([]interface{})
+		if !ok || len(entry) < 2 {
+			continue
+		}
+		chunks, ok := entry[1].([]interface{})
+		if !ok {
+			continue
+		}
+		var message []msgHistoryChunk
+		for _, c := range chunks {
+			chunk, ok := c.([]interface{})
+			if !ok || len(chunk) < 2 {
+				continue
+			}
+			text, ok := chunk[1].(string)
+			if !ok || text == "" {
+				continue
+			}
+			text = strings.Replace(text, "\r\n", "\n", -1)
+			message = append(message, msgHistoryChunk{attr: util.ReflectToInt(chunk[0]), text: text})
+		}
+		appended := false
+		if len(entry) > 2 {
+			appended, _ = entry[2].(bool)
+		}
+		if appended && len(messages) > 0 {
+			last := len(messages) - 1
+			messages[last] = append(messages[last], message...)
+			continue
+		}
+		messages = append(messages, message)
+	}
+
+	return messages
+}
+
+// msgHistoryCSS returns the style of the text of the highlight
+func msgHistoryCSS(hl *Highlight) string {
+	if hl == nil {
+		return ""
+	}
+	var css []string
+	color := hl.foreground
+	if hl.reverse {
+		color = hl.background
+	}
+	if color != nil {
+		css = append(css, fmt.Sprintf("color: #%02x%02x%02x", color.R, color.G, color.B))
+	}
+	if hl.bold {
+		css = append(css, "font-weight: bold")
+	}
+	if hl.italic {
+		css = append(css, "font-style: italic")
+	}
+	if hl.underline || hl.undercurl {
+		css = append(css, "text-decoration: underline")
+	}
+
+	return strings.Join(css, "; ")
+}
+
+// msgHistoryHTML renders the messages, a paragraph each, with the style of
+// the highlight of the attr of the chunks
+func msgHistoryHTML(messages [][]msgHistoryChunk, style func(attr int) string) string {
+	var b strings.Builder
+	for _, message := range messages {
+		b.WriteString("<pre style=\"margin: 0; white-space: pre-wrap;\">")
+		for _, chunk := range message {
+			text := html.EscapeString(strings.TrimRight(chunk.text, "\n"))
+			if text == "" {
+				continue
+			}
+			css := style(chunk.attr)
+			if css == "" {
+				b.WriteString(text)
+				continue
+			}
+			b.WriteString("<span style=\"" + css + "\">" + text + "</span>")
+		}
+		b.WriteString("</pre>")
+	}
+
+	return b.String()
+}
+
+// msgHistoryText returns the plain text of the messages, a line each
+func msgHistoryText(messages [][]msgHistoryChunk) string {
+	lines := make([]string, len(messages))
+	for i, message := range messages {
+		var b strings.Builder
+		for _, chunk := range message {
+			b.WriteString(chunk.text)
+		}
+		lines[i] = strings.TrimRight(b.String(), "\n")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// msgHistory is the scrollable panel which shows the history of :messages by
+// msg_history_show with the highlights, in which the messages can be
+// searched, copied to the clipboard and cleared
+type msgHistory struct {
+	ws     *Workspace
+	widget *widgets.QWidget
+	search *widgets.QLineEdit
+	text   *widgets.QTextBrowser
+
+	messages [][]msgHistoryChunk
+}
+
+func newMsgHistory(ws *Workspace) *msgHistory {
+	h := &msgHistory{
+		ws: ws,
+	}
+
+	widget := widgets.NewQWidget(ws.screen.widget, 0)
+	widget.SetObjectName("msghistory")
+	widget.SetAttribute(core.Qt__WA_StyledBackground, true)
+	layout := widgets.NewQVBoxLayout()
+	layout.SetContentsMargins(0, 0, 0, 0)
+	layout.SetSpacing(0)
+	widget.SetLayout(layout)
+
+	header := widgets.NewQWidget(nil, 0)
+	headerLayout := widgets.NewQHBoxLayout()
+	headerLayout.SetContentsMargins(8, 2, 4, 2)
+	header.SetLayout(headerLayout)
+	title := widgets.NewQLabel2("Messages", nil, 0)
+	search := widgets.NewQLineEdit(nil)
+	search.SetPlaceholderText("Search messages")
+	search.ConnectTextChanged(func(string) {
+		h.find(true, false)
+	})
+	search.ConnectKeyPressEvent(h.searchKeyPress)
+	copyButton := widgets.NewQPushButton2("Copy", nil)
+	copyButton.ConnectClicked(func(bool) {
+		h.copy()
+	})
+	clearButton := widgets.NewQPushButton2("Clear", nil)
+	clearButton.ConnectClicked(func(bool) {
+		h.clear()
+	})
+	closeButton := widgets.NewQPushButton2("×", nil)
+	closeButton.ConnectClicked(func(bool) {
+		h.hide()
+	})
+	headerLayout.AddWidget(title, 0, 0)
+	headerLayout.AddWidget(search, 1, 0)
+	headerLayout.AddWidget(copyButton, 0, 0)
+	headerLayout.AddWidget(clearButton, 0, 0)
+	headerLayout.AddWidget(closeButton, 0, 0)
+	for _, button := range []*widgets.QPushButton{copyButton, clearButton, closeButton} {
+		editor.focusChain.add(button.QWidget_PTR(), focusRankPanel, button.Click)
+	}
+
+	text := widgets.NewQTextBrowser(nil)
+	text.SetOpenLinks(false)
+	text.SetFocusPolicy(core.Qt__NoFocus)
+	text.SetFrameShape(widgets.QFrame__NoFrame)
+
+	layout.AddWidget(header, 0, 0)
+	layout.AddWidget(text, 1, 0)
+	widget.Hide()
+
+	h.widget = widget
+	h.search = search
+	h.text = text
+
+	return h
+}
+
+// show renders the entries of msg_history_show, and scrolls to the last one
+func (h *msgHistory) show(entries []interface{}) {
+	h.messages = parseMsgHistory(entries)
+	h.setColor()
+	h.render()
+
+	margin := editor.iconSize
+	width := h.ws.screen.widget.Width() - margin*2
+	height := h.ws.screen.widget.Height() / 2
+	h.widget.SetGeometry2(margin, h.ws.screen.widget.Height()-height-margin, width, height)
+	h.widget.Show()
+	h.widget.Raise()
+	h.search.SetText("")
+	h.search.SetFocus2()
+	h.text.VerticalScrollBar().SetValue(h.text.VerticalScrollBar().Maximum())
+}
+
+func (h *msgHistory) render() {
+	if len(h.messages) == 0 {
+		h.text.SetPlainText("No messages")
+		return
+	}
+	hlAttrDef := h.ws.screen.hlAttrDef
+	h.text.SetHtml(msgHistoryHTML(h.messages, func(attr int) string {
+		return msgHistoryCSS(hlAttrDef[attr])
+	}))
+}
+
+func (h *msgHistory) hide() {
+	if !h.widget.IsVisible() {
+		return
+	}
+	h.widget.Hide()
+	h.ws.widget.SetFocus2()
+}
+
+// find selects the next match of the search, from the top if fromStart, and
+// wraps around at the end
+func (h *msgHistory) find(fromStart, backward bool) {
+	query := h.search.Text()
+	if query == "" {
+		return
+	}
+	var flags gui.QTextDocument__FindFlag
+	wrapTo := gui.QTextCursor__Start
+	if backward {
+		flags = gui.QTextDocument__FindBackward
+		wrapTo = gui.QTextCursor__End
+	}
+	if fromStart {
+		h.text.MoveCursor(gui.QTextCursor__Start, gui.QTextCursor__MoveAnchor)
+	}
+	if h.text.Find(query, flags) {
+		return
+	}
+	h.text.MoveCursor(wrapTo, gui.QTextCursor__MoveAnchor)
+	h.text.Find(query, flags)
+}
+
+func (h *msgHistory) searchKeyPress(event *gui.QKeyEvent) {
+	switch core.Qt__Key(event.Key()) {
+	case core.Qt__Key_Escape:
+		h.hide()
+	case core.Qt__Key_Return, core.Qt__Key_Enter:
+		backward := event.Modifiers()&core.Qt__ShiftModifier != 0
+		h.find(false, backward)
+	default:
+		h.search.KeyPressEventDefault(event)
+	}
+}
+
+// copy copies the plain text of the messages to the clipboard
+func (h *msgHistory) copy() {
+	if len(h.messages) == 0 {
+		return
+	}
+	clipb.WriteAll(msgHistoryText(h.messages))
+	editor.pushNotification(NotifyInfo, 3, "[Goneovim] Copied the messages to the clipboard")
+}
+
+// clear clears the history of nvim and the panel
+func (h *msgHistory) clear() {
+	h.messages = nil
+	h.render()
+	go h.ws.nvim.Command("messages clear")
+}
+
+func (h *msgHistory) setColor() {
+	fg := editor.colors.widgetFg
+	bg := editor.colors.widgetBg
+	if fg == nil || bg == nil || editor.colors.inactiveFg == nil || editor.colors.selectedBg == nil {
+		return
+	}
+	h.widget.SetStyleSheet(withUserStyle(fmt.Sprintf(
+		"QWidget#msghistory { border: 1px solid %s; background-color: %s; } * { color: %s; background-color: %s; } QTextBrowser { selection-background-color: %s; }",
+		editor.colors.inactiveFg.String(),
+		bg.String(),
+		fg.String(),
+		bg.String(),
+		editor.colors.selectedBg.String(),
+	)))
+	h.text.SetFont(h.ws.font.fontNew)
+}
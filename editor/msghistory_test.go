@@ -0,0 +1,63 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestParseMsgHistory(t *testing.T) {
+	entries := []interface{}{
+		[]interface{}{"echomsg", []interface{}{[]interface{}{int64(0), "first"}}},
+		[]interface{}{"emsg", []interface{}{
+			[]interface{}{int64(1), "E492: "},
+			[]interface{}{int64(0), "Not an editor command\r\n"},
+		}},
+		[]interface{}{"echomsg", []interface{}{[]interface{}{int64(2), " more"}}, true},
+		"broken",
+	}
+	messages := parseMsgHistory(entries)
+	if len(messages) != 2 {
+		t.Fatalf("parseMsgHistory() returned %d messages, want 2", len(messages))
+	}
+	if got, want := msgHistoryText(messages), "first\nE492: Not an editor command\n more"; got != want {
+		t.Errorf("msgHistoryText() = %q, want %q", got, want)
+	}
+	if got := messages[1][0].attr; got != 1 {
+		t.Errorf("attr = %v, want 1", got)
+	}
+}
+
+func TestMsgHistoryCSS(t *testing.T) {
+	tests := []struct {
+		hl   *Highlight
+		want string
+	}{
+		{nil, ""},
+		{&Highlight{}, ""},
+		{&Highlight{foreground: &RGBA{R: 255, G: 0, B: 16}}, "color: #ff0010"},
+		{&Highlight{foreground: &RGBA{}, background: &RGBA{R: 1, G: 2, B: 3}, reverse: true}, "color: #010203"},
+		{&Highlight{bold: true, italic: true, undercurl: true}, "font-weight: bold; font-style: italic; text-decoration: underline"},
+	}
+	for _, tt := range tests {
+		if got := msgHistoryCSS(tt.hl); got != tt.want {
+			t.Errorf("msgHistoryCSS(%v) = %q, want %q", tt.hl, got, tt.want)
+		}
+	}
+}
+
+func TestMsgHistoryHTML(t *testing.T) {
+	messages := [][]msgHistoryChunk{
+		{{attr: 1, text: "<err>"}, {attr: 0, text: " & text\n"}},
+		{{attr: 0, text: "\n"}},
+	}
+	style := func(attr int) string {
+		if attr == 1 {
+			return "color: #ff0000"
+		}
+		return ""
+	}
+	want := "<pre style=\"margin: 0; white-space: pre-wrap;\"><span style=\"color: #ff0000\">&lt;err&gt;</span> &amp; text</pre>" +
+		"<pre style=\"margin: 0; white-space: pre-wrap;\"></pre>"
+	if got := msgHistoryHTML(messages, style); got != want {
+		t.Errorf("msgHistoryHTML() = %q, want %q", got, want)
+	}
+}
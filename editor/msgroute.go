@@ -0,0 +1,91 @@
+package editor
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// msgPlainText returns the text of the chunks of msg_show, [[attr, text], ...]
+func msgPlainText(chunks []interface{}) string {
+	var b strings.Builder
+	for _, e := range chunks {
+		chunk, ok := e.([]interface{})
+		if !ok || len(chunk) < 2 {
+			continue
+		}
+		text, _ := chunk[1].(string)
+		b.WriteString(text)
+	}
+
+	return strings.TrimRight(strings.Replace(b.String(), "\r\n", "\n", -1), "\n")
+}
+
+// msgNotifyLevel returns the level of the notification of the kind of the
+// message
+func msgNotifyLevel(kind string) NotifyLevel {
+	switch kind {
+	case "emsg", "echoerr", "lua_error", "rpc_error":
+		return NotifyError
+	case "wmsg":
+		return NotifyWarn
+	}
+
+	return NotifyInfo
+}
+
+// msgRoute returns "notify" for the messages shown as the notifications,
+// which are the errors, the warnings, the "Press ENTER" prompts and the
+// messages of multiple lines or longer than shortLength chars, and "message"
+// for the others, which are shown in the message area
+func msgRoute(kind, text string, shortLength int) string {
+	switch kind {
+	case "return_prompt", "emsg", "echoerr", "lua_error", "rpc_error", "wmsg":
+		return "notify"
+	case "search_count":
+		return "message"
+	}
+	if strings.Contains(text, "\n") || utf8.RuneCountInString(text) > shortLength {
+		return "notify"
+	}
+
+	return "message"
+}
+
+// notify shows the message as the notification of its severity. The
+// notification of the "Press ENTER" prompt has the button to continue, and
+// is dismissed by dismissPrompt.
+func (m *Message) notify(kind, text string) {
+	if text == "" {
+		return
+	}
+	level := msgNotifyLevel(kind)
+	if kind != "return_prompt" {
+		editor.pushNotification(level, -1, text)
+		return
+	}
+	m.dismissPrompt()
+	m.prompt = editor.popupNotification(level, -1, text, notifyOptionArg([]*NotifyButton{
+		{
+			text: "Continue",
+			action: func() {
+				m.ws.nvim.Input("<CR>")
+			},
+		},
+	}))
+}
+
+// dismissPrompt closes the notification of the "Press ENTER" prompt, which
+// is answered in nvim when the messages are cleared or the mode changes
+func (m *Message) dismissPrompt() {
+	if m.prompt == nil {
+		return
+	}
+	m.prompt.closeNotification()
+	m.prompt = nil
+}
+
+// isPromptMode returns true for the modes of the cursor shape while nvim
+// waits at the "Press ENTER" and the "-- More --" prompts
+func isPromptMode(mode string) bool {
+	return mode == "more" || mode == "more_lastline"
+}
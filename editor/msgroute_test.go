@@ -0,0 +1,70 @@
+package editor
+
+import "testing"
+
+func TestMsgPlainText(t *testing.T) {
+	chunks := []interface{}{
+		[]interface{}{int64(0), "E492: "},
+		[]interface{}{int64(3), "Not an editor command\r\n"},
+		[]interface{}{int64(0)},
+	}
+	if got, want := msgPlainText(chunks), "E492: Not an editor command"; got != want {
+		t.Errorf("msgPlainText() = %q, want %q", got, want)
+	}
+}
+
+func TestMsgRoute(t *testing.T) {
+	tests := []struct {
+		kind string
+		text string
+		want string
+	}{
+		{"emsg", "E492: Not an editor command", "notify"},
+		{"wmsg", "W10: Warning", "notify"},
+		{"return_prompt", "Press ENTER or type command to continue", "notify"},
+		{"echo", "hello", "message"},
+		{"echomsg", "line 1\nline 2", "notify"},
+		{"", "0123456789012", "notify"},
+		{"", "0123456789", "message"},
+		{"search_count", "[1/20000000]", "message"},
+	}
+	for _, tt := range tests {
+		if got := msgRoute(tt.kind, tt.text, 10); got != tt.want {
+			t.Errorf("msgRoute(%q, %q) = %q, want %q", tt.kind, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestMsgNotifyLevel(t *testing.T) {
+	tests := []struct {
+		kind string
+		want NotifyLevel
+	}{
+		{"emsg", NotifyError},
+		{"lua_error", NotifyError},
+		{"wmsg", NotifyWarn},
+		{"echo", NotifyInfo},
+	}
+	for _, tt := range tests {
+		if got := msgNotifyLevel(tt.kind); got != tt.want {
+			t.Errorf("msgNotifyLevel(%q) = %v, want %v", tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestIsPromptMode(t *testing.T) {
+	tests := []struct {
+		mode string
+		want bool
+	}{
+		{"more", true},
+		{"more_lastline", true},
+		{"normal", false},
+		{"cmdline_normal", false},
+	}
+	for _, tt := range tests {
+		if got := isPromptMode(tt.mode); got != tt.want {
+			t.Errorf("isPromptMode(%q) = %v, want %v", tt.mode, got, tt.want)
+		}
+	}
+}
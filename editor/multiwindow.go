@@ -0,0 +1,107 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/akiyosi/goneovim/util"
+)
+
+// multiWindowCommands notify only the UI of the active OS window, whose
+// channel is g:gonvim_active_channel, so that the other windows attached to
+// nvim don't run the command too. The tabpage entered is notified to it as
+// the tabpage it brings back when it is activated; the attached windows
+// still share the current tabpage of nvim.
+const multiWindowCommands = `
+	command! GonvimWindowNew call rpcnotify(get(g:, "gonvim_active_channel", 0), "Gui", "gonvim_window_new")
+	aug GonvimAuWindow | au! | aug END
+	au GonvimAuWindow TabEnter * if exists("g:gonvim_active_channel") | call rpcnotify(g:gonvim_active_channel, "Gui", "gonvim_tabpage", nvim_get_current_tabpage()) | endif
+	`
+
+// openAttachedWindow opens another OS window attached to the nvim of the
+// workspace as a second UI, by starting goneovim with --server. nvim draws
+// the same current tabpage on every attached UI, so both windows show one
+// tabpage at a time: the window remembers the tabpage it opened and makes it
+// the current one again when it is activated.
+func (w *Workspace) openAttachedWindow() {
+	neovim := w.nvim
+	go func() {
+		address := editor.opts.Server
+		if address == "" {
+			err := neovim.Eval("v:servername", &address)
+			if err != nil || address == "" {
+				editor.pushNotification(NotifyWarn, -1, "[Goneovim] The server address of nvim is not available")
+				return
+			}
+		}
+
+		exe, err := os.Executable()
+		if err != nil {
+			editor.pushNotification(NotifyWarn, -1, fmt.Sprintf("[Goneovim] Failed to open a new window: %s", err))
+			return
+		}
+		args := []string{"--server", address, "--attached-window"}
+		if editor.opts.Ssh != "" {
+			args = append(args, "--ssh", editor.opts.Ssh)
+		}
+		cmd := exec.Command(exe, args...)
+		util.PrepareRunProc(cmd)
+		err = cmd.Start()
+		if err != nil {
+			editor.pushNotification(NotifyWarn, -1, fmt.Sprintf("[Goneovim] Failed to open a new window: %s", err))
+			return
+		}
+		cmd.Wait()
+	}()
+}
+
+// updateChannel gets the channel of the UI in nvim
+func (w *Workspace) updateChannel() {
+	w.channel = 1
+	if apiInfo, err := w.nvim.APIInfo(); err == nil && len(apiInfo) > 0 {
+		w.channel = util.ReflectToInt(apiInfo[0])
+	}
+}
+
+// openWindowTabpage opens the tabpage of the attached window, which the
+// TabEnter notifies to it as the active window
+func (w *Workspace) openWindowTabpage() {
+	w.nvim.SetVar("gonvim_active_channel", w.channel)
+	w.nvim.Command("tabnew")
+}
+
+// activateWindow makes the UI of the OS window the active one in nvim, and
+// makes its tabpage the current one again, which the other attached windows
+// then show too, or takes the current one as its tabpage
+func (w *Workspace) activateWindow() {
+	if w.nvim == nil || !w.uiAttached {
+		return
+	}
+	neovim := w.nvim
+	channel := w.channel
+	tabpage := w.tabpage
+	go func() {
+		neovim.SetVar("gonvim_active_channel", channel)
+		if tabpage != 0 {
+			valid, err := neovim.IsTabpageValid(tabpage)
+			if err == nil && valid {
+				neovim.SetCurrentTabpage(tabpage)
+				return
+			}
+		}
+		current, err := neovim.CurrentTabpage()
+		if err != nil {
+			return
+		}
+		w.guiUpdates <- []interface{}{"gonvim_tabpage", int(current)}
+		w.signal.GuiSignal()
+	}()
+}
+
+// isAttachedWindow reports whether this goneovim is a window attached to the
+// nvim owned by another goneovim, which must not save or restore the
+// sessions. The other goneovims of --server own their sessions.
+func (e *Editor) isAttachedWindow() bool {
+	return e.opts.AttachedWindow
+}
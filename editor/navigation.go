@@ -0,0 +1,192 @@
+package editor
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/svg"
+	"github.com/therecipe/qt/widgets"
+)
+
+// jumpLocationsExpr returns the locations of the jumplist of the current window
+const jumpLocationsExpr = `map(getjumplist()[0], {_, v -> {` +
+	`'file': fnamemodify(bufname(v.bufnr), ':~:.'), ` +
+	`'lnum': v.lnum, ` +
+	`'col': v.col, ` +
+	`'text': trim(get(getbufline(v.bufnr, v.lnum), 0, ''))}})`
+
+// Navigation is the browser-like back/forward navigation
+// on top of the jumplist of neovim
+type Navigation struct {
+	ws      *Workspace
+	widget  *widgets.QWidget
+	back    *svg.QSvgWidget
+	forward *svg.QSvgWidget
+	history *svg.QSvgWidget
+}
+
+// jumpLocation is an entry of the jumplist
+type jumpLocation struct {
+	File string `msgpack:"file"`
+	Line int    `msgpack:"lnum"`
+	Col  int    `msgpack:"col"`
+	Text string `msgpack:"text"`
+}
+
+func (l *jumpLocation) label() string {
+	file := l.File
+	if file == "" {
+		file = "[No Name]"
+	}
+
+	return fmt.Sprintf("%s:%d  %s", filepath.ToSlash(file), l.Line, l.Text)
+}
+
+// jumpCommand returns the command to move in the jumplist by delta
+func jumpCommand(delta int) string {
+	if delta == 0 {
+		return ""
+	}
+	key := "\\<C-i>"
+	if delta < 0 {
+		key = "\\<C-o>"
+		delta = -delta
+	}
+
+	return fmt.Sprintf(`execute "normal! %d%s"`, delta, key)
+}
+
+func initNavigation(ws *Workspace) *Navigation {
+	n := &Navigation{
+		ws: ws,
+	}
+	if !editor.config.Navigation.Visible {
+		return n
+	}
+
+	widget := widgets.NewQWidget(ws.tabline.widget, 0)
+	layout := widgets.NewQHBoxLayout()
+	layout.SetContentsMargins(0, 0, 0, 0)
+	layout.SetSpacing(2)
+	widget.SetLayout(layout)
+
+	newButton := func(tooltip string) *svg.QSvgWidget {
+		button := svg.NewQSvgWidget(nil)
+		button.SetFixedSize2(editor.iconSize, editor.iconSize)
+		button.SetToolTip(tooltip)
+		layout.AddWidget(button, 0, 0)
+		return button
+	}
+	n.back = newButton("Go back")
+	n.back.ConnectMousePressEvent(func(*gui.QMouseEvent) {
+		n.goBack()
+	})
+	n.forward = newButton("Go forward")
+	n.forward.ConnectMousePressEvent(func(*gui.QMouseEvent) {
+		n.goForward()
+	})
+	n.history = newButton("Recent locations")
+	n.history.ConnectMousePressEvent(func(*gui.QMouseEvent) {
+		n.showHistory()
+	})
+
+	n.widget = widget
+	n.setColor()
+	widget.Show()
+
+	return n
+}
+
+func (n *Navigation) move() {
+	if n.widget == nil {
+		return
+	}
+	n.widget.AdjustSize()
+	parent := n.ws.tabline.widget
+	x := parent.Width() - n.widget.Width() - 5
+	y := (parent.Height() - n.widget.Height()) / 2
+	n.widget.Move2(x, y)
+	n.widget.Raise()
+	n.ws.tabline.reserve(n.widget.Width() + 5)
+}
+
+func (n *Navigation) setColor() {
+	if n.widget == nil {
+		return
+	}
+	color := editor.colors.inactiveFg
+	for icon, button := range map[string]*svg.QSvgWidget{
+		"chevron-left":  n.back,
+		"chevron-right": n.forward,
+		"chevron-down":  n.history,
+	} {
+		svgContent := editor.getSvg(icon, color)
+		button.Load2(core.NewQByteArray2(svgContent, len(svgContent)))
+	}
+}
+
+func (n *Navigation) goBack() {
+	n.jump(-1)
+}
+
+func (n *Navigation) goForward() {
+	n.jump(1)
+}
+
+func (n *Navigation) jump(delta int) {
+	command := jumpCommand(delta)
+	if command == "" {
+		return
+	}
+	go n.ws.nvim.Command(command)
+}
+
+// jumpLocations returns the jumplist and the current position in it
+func jumpLocations(neovim *nvim.Nvim) ([]*jumpLocation, int, error) {
+	var locations []*jumpLocation
+	err := neovim.Eval(jumpLocationsExpr, &locations)
+	if err != nil {
+		return nil, 0, err
+	}
+	var current int
+	err = neovim.Eval("getjumplist()[1]", &current)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return locations, current, nil
+}
+
+// showHistory fetches the jumplist off the GUI thread, which the
+// gonvim_jump_history update shows
+func (n *Navigation) showHistory() {
+	neovim := n.ws.nvim
+	go func() {
+		locations, current, err := jumpLocations(neovim)
+		if err != nil || len(locations) == 0 {
+			return
+		}
+		n.ws.guiUpdates <- []interface{}{"gonvim_jump_history", locations, current}
+		n.ws.signal.GuiSignal()
+	}()
+}
+
+// popupHistory shows the dropdown of the recent locations, newest first
+func (n *Navigation) popupHistory(locations []*jumpLocation, current int) {
+	menu := widgets.NewQMenu(n.widget)
+	for i := len(locations) - 1; i >= 0; i-- {
+		delta := i - current
+		location := locations[i]
+		action := menu.AddAction(strings.TrimSpace(location.label()))
+		action.SetCheckable(true)
+		action.SetChecked(delta == 0)
+		action.ConnectTriggered(func(bool) {
+			n.jump(delta)
+		})
+	}
+	menu.Popup(n.history.MapToGlobal(core.NewQPoint2(0, n.history.Height())), nil)
+}
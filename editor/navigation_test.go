@@ -0,0 +1,19 @@
+package editor
+
+import "testing"
+
+func TestJumpCommand(t *testing.T) {
+	tests := []struct {
+		delta int
+		want  string
+	}{
+		{0, ""},
+		{1, `execute "normal! 1\<C-i>"`},
+		{-3, `execute "normal! 3\<C-o>"`},
+	}
+	for _, tt := range tests {
+		if got := jumpCommand(tt.delta); got != tt.want {
+			t.Errorf("jumpCommand(%d) = %q, want %q", tt.delta, got, tt.want)
+		}
+	}
+}
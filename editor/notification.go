@@ -20,6 +20,8 @@ const (
 	NotifyInfo NotifyLevel = 0
 	// NotifyWarn is a type of "warning"
 	NotifyWarn NotifyLevel = 1
+	// NotifyError is a type of "error"
+	NotifyError NotifyLevel = 2
 )
 
 // Notification is
@@ -50,6 +52,7 @@ func newNotification(l NotifyLevel, p int, message string, options ...NotifyOpti
 	e := editor
 
 	widget := widgets.NewQWidget(nil, 0)
+	widget.SetObjectName("notification")
 	layout := widgets.NewQVBoxLayout()
 	widget.SetLayout(layout)
 	widget.SetFixedWidth(e.notificationWidth)
@@ -71,6 +74,8 @@ func newNotification(l NotifyLevel, p int, message string, options ...NotifyOpti
 		level = e.getSvg("info", newRGBA(27, 161, 226, 1))
 	case NotifyWarn:
 		level = e.getSvg("warn", newRGBA(255, 205, 0, 1))
+	case NotifyError:
+		level = e.getSvg("emsg", newRGBA(229, 57, 53, 1))
 	default:
 		level = e.getSvg("info", newRGBA(27, 161, 226, 1))
 	}
@@ -154,6 +159,10 @@ func newNotification(l NotifyLevel, p int, message string, options ...NotifyOpti
 				go fn()
 				notification.closeNotification()
 			})
+			editor.focusChain.add(button, focusRankNotification, func() {
+				go fn()
+				notification.closeNotification()
+			})
 			button.ConnectEnterEvent(func(event *core.QEvent) {
 				hoverColor := "#1177bb"
 				button.SetStyleSheet(fmt.Sprintf(" #button QLabel { color: #ffffff; background: %s;} ", hoverColor))
@@ -189,6 +198,7 @@ func newNotification(l NotifyLevel, p int, message string, options ...NotifyOpti
 	notification.closeIcon.ConnectMouseReleaseEvent(func(event *gui.QMouseEvent) {
 		notification.closeNotification()
 	})
+	editor.focusChain.add(closeIcon.QWidget_PTR(), focusRankNotification, notification.closeNotification)
 	notification.widget.ConnectEnterEvent(func(event *core.QEvent) {
 		svgContent := e.getSvg("cross", nil)
 		notification.closeIcon.Load2(core.NewQByteArray2(svgContent, len(svgContent)))
@@ -349,10 +359,14 @@ func (n *Notification) show() {
 			time.Sleep(100 * time.Millisecond)
 		}
 	}
+	n.setColor()
+	n.widget.Show()
+}
+
+func (n *Notification) setColor() {
 	fg := editor.colors.widgetFg.String()
 	bg := editor.colors.widgetBg
 	// transparent := editor.config.Editor.Transparent / 2.0
 	transparent := transparent()
-	n.widget.SetStyleSheet(fmt.Sprintf(" * {color: %s; background: rgba(%d, %d, %d, %f);}", fg, bg.R, bg.G, bg.B, transparent))
-	n.widget.Show()
+	n.widget.SetStyleSheet(withUserStyle(fmt.Sprintf(" * {color: %s; background: rgba(%d, %d, %d, %f);}", fg, bg.R, bg.G, bg.B, transparent)))
 }
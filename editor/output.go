@@ -0,0 +1,445 @@
+package editor
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+// OutputPanel runs a shell command asynchronously and streams the output,
+// as a lighter alternative to :terminal for the build and test output.
+// The ANSI colors are rendered, and the error locations can be clicked.
+type OutputPanel struct {
+	ws     *Workspace
+	widget *widgets.QWidget
+	title  *widgets.QLabel
+	text   *widgets.QTextBrowser
+	rerun  *widgets.QPushButton
+	stop   *widgets.QPushButton
+
+	visible bool
+	height  int
+
+	mu        sync.Mutex
+	command   string
+	dir       string
+	cmd       *exec.Cmd
+	gen       int
+	lines     chan string
+	style     *ansiStyle
+	locations []*outputLocation
+}
+
+// outputLocation is the error location in the output
+type outputLocation struct {
+	file string
+	line int
+	col  int
+}
+
+func newOutputPanel(ws *Workspace) *OutputPanel {
+	o := &OutputPanel{
+		ws:    ws,
+		lines: make(chan string, 1000),
+		style: &ansiStyle{},
+	}
+
+	widget := widgets.NewQWidget(nil, 0)
+	widget.SetObjectName("output")
+	widget.SetAttribute(core.Qt__WA_StyledBackground, true)
+	layout := widgets.NewQVBoxLayout()
+	layout.SetContentsMargins(0, 0, 0, 0)
+	layout.SetSpacing(0)
+	widget.SetLayout(layout)
+
+	header := widgets.NewQWidget(nil, 0)
+	headerLayout := widgets.NewQHBoxLayout()
+	headerLayout.SetContentsMargins(8, 2, 4, 2)
+	header.SetLayout(headerLayout)
+	title := widgets.NewQLabel(nil, 0)
+	rerun := widgets.NewQPushButton2("Rerun", nil)
+	rerun.ConnectClicked(func(bool) {
+		o.run(o.command)
+	})
+	stop := widgets.NewQPushButton2("Stop", nil)
+	stop.ConnectClicked(func(bool) {
+		o.kill()
+	})
+	closeButton := widgets.NewQPushButton2("×", nil)
+	closeButton.ConnectClicked(func(bool) {
+		o.hide()
+	})
+	headerLayout.AddWidget(title, 1, 0)
+	headerLayout.AddWidget(rerun, 0, 0)
+	headerLayout.AddWidget(stop, 0, 0)
+	headerLayout.AddWidget(closeButton, 0, 0)
+	for _, button := range []*widgets.QPushButton{rerun, stop, closeButton} {
+		editor.focusChain.add(button.QWidget_PTR(), focusRankPanel, button.Click)
+	}
+
+	text := widgets.NewQTextBrowser(nil)
+	text.SetOpenLinks(false)
+	text.SetFocusPolicy(core.Qt__NoFocus)
+	text.SetLineWrapMode(widgets.QTextEdit__NoWrap)
+	text.SetFrameShape(widgets.QFrame__NoFrame)
+	text.ConnectAnchorClicked(func(link *core.QUrl) {
+		o.openLocation(link.Fragment(core.QUrl__FullyDecoded))
+	})
+
+	layout.AddWidget(header, 0, 0)
+	layout.AddWidget(text, 1, 0)
+	widget.Hide()
+
+	o.widget = widget
+	o.title = title
+	o.text = text
+	o.rerun = rerun
+	o.stop = stop
+
+	ws.signal.ConnectOutputSignal(o.flush)
+
+	return o
+}
+
+// run runs the command in the current directory of the workspace
+func (o *OutputPanel) run(command string) {
+	if command == "" {
+		return
+	}
+	o.kill()
+
+	o.mu.Lock()
+	o.gen++
+	gen := o.gen
+	o.command = command
+	o.dir = o.ws.cwd
+	o.style = &ansiStyle{}
+	o.locations = nil
+	o.mu.Unlock()
+
+	o.show()
+	o.text.Clear()
+	o.title.SetText(fmt.Sprintf("$ %s", command))
+	o.stop.SetEnabled(true)
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	util.PrepareProcGroup(cmd)
+	cmd.Dir = o.dir
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		o.stop.SetEnabled(false)
+		o.push(gen, fmt.Sprintf("[Failed to run: %s]", err))
+		return
+	}
+	cmd.Stderr = cmd.Stdout
+	err = cmd.Start()
+	if err != nil {
+		o.stop.SetEnabled(false)
+		o.push(gen, fmt.Sprintf("[Failed to run: %s]", err))
+		return
+	}
+	o.mu.Lock()
+	o.cmd = cmd
+	o.mu.Unlock()
+
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			o.push(gen, scanner.Text())
+		}
+		err := cmd.Wait()
+
+		o.mu.Lock()
+		if o.cmd == cmd {
+			o.cmd = nil
+		}
+		o.mu.Unlock()
+		code := 0
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			code = exitErr.ExitCode()
+		} else if err != nil {
+			code = -1
+		}
+		o.push(gen, fmt.Sprintf("\x1b[2m[Process exited with code %d]\x1b[0m", code))
+	}()
+}
+
+// push sends the line to the GUI thread, unless the command was run again
+func (o *OutputPanel) push(gen int, line string) {
+	o.mu.Lock()
+	current := gen == o.gen
+	o.mu.Unlock()
+	if !current {
+		return
+	}
+	o.lines <- line
+	o.ws.signal.OutputSignal()
+}
+
+// flush appends the lines received so far
+func (o *OutputPanel) flush() {
+	for {
+		select {
+		case line := <-o.lines:
+			o.mu.Lock()
+			text := ansiToHTML(o.style, line, &o.locations)
+			running := o.cmd != nil
+			o.mu.Unlock()
+			o.text.Append(text)
+			o.stop.SetEnabled(running)
+		default:
+			return
+		}
+	}
+}
+
+func (o *OutputPanel) kill() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	// The shell is killed with the processes it runs
+	if o.cmd != nil {
+		util.KillProcGroup(o.cmd)
+	}
+	o.cmd = nil
+}
+
+func (o *OutputPanel) show() {
+	if o.visible {
+		return
+	}
+	o.visible = true
+	o.setColor()
+	o.text.SetFont(o.ws.font.fontNew)
+	o.widget.SetFixedHeight(o.ws.height / 3)
+	o.widget.Show()
+	o.ws.updateSize()
+}
+
+func (o *OutputPanel) hide() {
+	if !o.visible {
+		return
+	}
+	o.visible = false
+	o.widget.Hide()
+	o.ws.updateSize()
+}
+
+func (o *OutputPanel) toggle() {
+	if o.visible {
+		o.hide()
+	} else {
+		o.show()
+	}
+}
+
+func (o *OutputPanel) updateHeight() {
+	if o.visible {
+		o.height = o.widget.Height()
+	} else {
+		o.height = 0
+	}
+}
+
+// openLocation opens the location of the link "loc{index}" in nvim
+func (o *OutputPanel) openLocation(fragment string) {
+	index, err := strconv.Atoi(strings.TrimPrefix(fragment, "loc"))
+	if err != nil {
+		return
+	}
+	o.mu.Lock()
+	if index < 0 || index >= len(o.locations) {
+		o.mu.Unlock()
+		return
+	}
+	loc := o.locations[index]
+	dir := o.dir
+	o.mu.Unlock()
+
+	file := loc.file
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	file = strings.Replace(file, "'", "''", -1)
+	col := loc.col
+	if col == 0 {
+		col = 1
+	}
+	go o.ws.nvim.Command(fmt.Sprintf(
+		"execute 'edit ' . fnameescape('%s') | call cursor(%d, %d) | normal! zz",
+		file, loc.line, col,
+	))
+}
+
+func (o *OutputPanel) setColor() {
+	fg := editor.colors.fg
+	bg := editor.colors.widgetBg
+	if fg == nil || bg == nil {
+		return
+	}
+	o.widget.SetStyleSheet(withUserStyle(fmt.Sprintf(
+		"QWidget#output { border-top: 1px solid %s; background-color: %s; } * { color: %s; background-color: %s; } a { color: %s; }",
+		editor.config.SideBar.AccentColor,
+		bg.String(),
+		fg.String(),
+		bg.String(),
+		editor.config.SideBar.AccentColor,
+	)))
+}
+
+// ansiStyle is the current SGR state of the output, which is kept across lines
+type ansiStyle struct {
+	fg   string
+	bg   string
+	bold bool
+	dim  bool
+}
+
+var ansiColors = [16]string{
+	"#000000", "#cd3131", "#0dbc79", "#e5e510", "#2472c8", "#bc3fbc", "#11a8cd", "#e5e5e5",
+	"#666666", "#f14c4c", "#23d18b", "#f5f543", "#3b8eea", "#d670d6", "#29b8db", "#ffffff",
+}
+
+var (
+	ansiEscapeRegexp     = regexp.MustCompile(`\x1b\[([0-9;]*)([A-Za-z])`)
+	outputLocationRegexp = regexp.MustCompile(`((?:[A-Za-z]:)?[\w./\\-]+\.\w+):(\d+)(?::(\d+))?`)
+)
+
+// apply updates the style by the parameters of the SGR sequence
+func (s *ansiStyle) apply(params string) {
+	if params == "" {
+		params = "0"
+	}
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		n, _ := strconv.Atoi(codes[i])
+		switch {
+		case n == 0:
+			*s = ansiStyle{}
+		case n == 1:
+			s.bold = true
+		case n == 2:
+			s.dim = true
+		case n == 22:
+			s.bold = false
+			s.dim = false
+		case n >= 30 && n <= 37:
+			s.fg = ansiColors[n-30]
+		case n >= 90 && n <= 97:
+			s.fg = ansiColors[n-90+8]
+		case n == 39:
+			s.fg = ""
+		case n >= 40 && n <= 47:
+			s.bg = ansiColors[n-40]
+		case n >= 100 && n <= 107:
+			s.bg = ansiColors[n-100+8]
+		case n == 49:
+			s.bg = ""
+		case (n == 38 || n == 48) && i+4 < len(codes) && codes[i+1] == "2":
+			// 24-bit color: 38;2;r;g;b
+			r, _ := strconv.Atoi(codes[i+2])
+			g, _ := strconv.Atoi(codes[i+3])
+			b, _ := strconv.Atoi(codes[i+4])
+			color := fmt.Sprintf("#%02x%02x%02x", r, g, b)
+			if n == 38 {
+				s.fg = color
+			} else {
+				s.bg = color
+			}
+			i += 4
+		case (n == 38 || n == 48) && i+2 < len(codes) && codes[i+1] == "5":
+			// 256 colors; only the first 16 colors are mapped
+			c, _ := strconv.Atoi(codes[i+2])
+			color := ""
+			if c >= 0 && c < 16 {
+				color = ansiColors[c]
+			}
+			if n == 38 {
+				s.fg = color
+			} else {
+				s.bg = color
+			}
+			i += 2
+		}
+	}
+}
+
+func (s *ansiStyle) css() string {
+	var css []string
+	switch {
+	case s.fg != "":
+		css = append(css, "color: "+s.fg)
+	case s.dim:
+		// The rich text of Qt has no opacity
+		css = append(css, "color: "+ansiColors[8])
+	}
+	if s.bg != "" {
+		css = append(css, "background-color: "+s.bg)
+	}
+	if s.bold {
+		css = append(css, "font-weight: bold")
+	}
+
+	return strings.Join(css, "; ")
+}
+
+// ansiToHTML converts the line with the ANSI escape sequences into HTML.
+// The error locations are linked to "#loc{index}" of the locations.
+func ansiToHTML(style *ansiStyle, line string, locations *[]*outputLocation) string {
+	var b strings.Builder
+	b.WriteString("<pre style=\"margin: 0;\">")
+	last := 0
+	for _, m := range ansiEscapeRegexp.FindAllStringSubmatchIndex(line, -1) {
+		writeOutputText(&b, style, line[last:m[0]], locations)
+		if line[m[4]:m[5]] == "m" {
+			style.apply(line[m[2]:m[3]])
+		}
+		last = m[1]
+	}
+	writeOutputText(&b, style, line[last:], locations)
+	b.WriteString("</pre>")
+
+	return b.String()
+}
+
+func writeOutputText(b *strings.Builder, style *ansiStyle, text string, locations *[]*outputLocation) {
+	if text == "" {
+		return
+	}
+	css := style.css()
+	if css != "" {
+		fmt.Fprintf(b, "<span style=\"%s\">", css)
+	}
+	last := 0
+	for _, m := range outputLocationRegexp.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:m[0]]))
+		loc := &outputLocation{file: text[m[2]:m[3]]}
+		loc.line, _ = strconv.Atoi(text[m[4]:m[5]])
+		if m[6] >= 0 {
+			loc.col, _ = strconv.Atoi(text[m[6]:m[7]])
+		}
+		fmt.Fprintf(b, "<a href=\"#loc%d\">%s</a>", len(*locations), html.EscapeString(text[m[0]:m[1]]))
+		*locations = append(*locations, loc)
+		last = m[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	if css != "" {
+		b.WriteString("</span>")
+	}
+}
@@ -0,0 +1,66 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnsiToHTML(t *testing.T) {
+	tests := []struct {
+		lines []string
+		want  []string
+	}{
+		{
+			[]string{"plain <text>"},
+			[]string{`<pre style="margin: 0;">plain &lt;text&gt;</pre>`},
+		},
+		{
+			[]string{"\x1b[31mFAIL\x1b[0m ok"},
+			[]string{`<pre style="margin: 0;"><span style="color: #cd3131">FAIL</span> ok</pre>`},
+		},
+		{
+			// The style is kept across lines
+			[]string{"\x1b[1;32mgreen", "still\x1b[m"},
+			[]string{
+				`<pre style="margin: 0;"><span style="color: #0dbc79; font-weight: bold">green</span></pre>`,
+				`<pre style="margin: 0;"><span style="color: #0dbc79; font-weight: bold">still</span></pre>`,
+			},
+		},
+		{
+			[]string{"\x1b[38;2;255;128;0mrgb\x1b[2K"},
+			[]string{`<pre style="margin: 0;"><span style="color: #ff8000">rgb</span></pre>`},
+		},
+	}
+	for _, tt := range tests {
+		style := &ansiStyle{}
+		var locations []*outputLocation
+		for i, line := range tt.lines {
+			if got := ansiToHTML(style, line, &locations); got != tt.want[i] {
+				t.Errorf("ansiToHTML(%q) = %q, want %q", line, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestAnsiToHTMLLocations(t *testing.T) {
+	style := &ansiStyle{}
+	var locations []*outputLocation
+	got := ansiToHTML(style, "editor/output.go:12:5: undefined: foo", &locations)
+	want := `<pre style="margin: 0;"><a href="#loc0">editor/output.go:12:5</a>: undefined: foo</pre>`
+	if got != want {
+		t.Errorf("ansiToHTML() = %q, want %q", got, want)
+	}
+	got = ansiToHTML(style, "\x1b[31m--- FAIL: main_test.go:40\x1b[0m", &locations)
+	want = `<pre style="margin: 0;"><span style="color: #cd3131">--- FAIL: <a href="#loc1">main_test.go:40</a></span></pre>`
+	if got != want {
+		t.Errorf("ansiToHTML() = %q, want %q", got, want)
+	}
+
+	wantLocations := []*outputLocation{
+		{file: "editor/output.go", line: 12, col: 5},
+		{file: "main_test.go", line: 40},
+	}
+	if !reflect.DeepEqual(locations, wantLocations) {
+		t.Errorf("locations = %+v, want %+v", locations, wantLocations)
+	}
+}
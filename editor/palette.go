@@ -3,6 +3,7 @@ package editor
 import (
 	"fmt"
 	"math"
+	"path/filepath"
 	"runtime"
 
 	"github.com/akiyosi/goneovim/fuzzy"
@@ -22,7 +23,6 @@ type Palette struct {
 	widget           *widgets.QWidget
 	padding          int
 	patternText      string
-	isHTMLText       bool
 	resultItems      []*PaletteResultItem
 	resultWidget     *widgets.QWidget
 	resultMainWidget *widgets.QWidget
@@ -40,6 +40,10 @@ type Palette struct {
 	scrollBar        *widgets.QWidget
 	scrollBarPos     int
 	scrollCol        *widgets.QWidget
+	// pathColumns are the columns of the parent directories of the typed
+	// path in the file finder
+	pathColumns       []*widgets.QLabel
+	pathColumnsWidget *widgets.QWidget
 }
 
 // PaletteResultItem is the result item
@@ -53,6 +57,7 @@ type PaletteResultItem struct {
 	baseText   string
 	widget     *widgets.QWidget
 	selected   bool
+	path       string
 }
 
 func initPalette() *Palette {
@@ -91,9 +96,18 @@ func initPalette() *Palette {
 	scrollBar := widgets.NewQWidget(scrollCol, 0)
 	scrollBar.SetFixedWidth(5)
 
+	pathColumnsLayout := widgets.NewQHBoxLayout()
+	pathColumnsLayout.SetContentsMargins(0, 0, 0, 0)
+	pathColumnsLayout.SetSpacing(0)
+	pathColumnsWidget := widgets.NewQWidget(nil, 0)
+	pathColumnsWidget.SetLayout(pathColumnsLayout)
+	pathColumnsWidget.SetContentsMargins(0, 0, 0, 0)
+	pathColumnsWidget.Hide()
+
 	resultMainWidget := widgets.NewQWidget(nil, 0)
 	resultMainWidget.SetStyleSheet(" * { background-color: rgba(0, 0, 0, 0); }")
 	resultMainWidget.SetContentsMargins(0, 0, 0, 0)
+	resultMainLayout.AddWidget(pathColumnsWidget, 0, core.Qt__AlignTop)
 	resultMainLayout.AddWidget(resultWidget, 0, 0)
 	resultMainLayout.AddWidget(scrollCol, 0, 0)
 	resultMainWidget.SetLayout(resultMainLayout)
@@ -131,6 +145,7 @@ func initPalette() *Palette {
 		scrollCol:        scrollCol,
 		scrollBar:        scrollBar,
 		// cursor:           cursor,
+		pathColumnsWidget: pathColumnsWidget,
 	}
 
 	resultItems := []*PaletteResultItem{}
@@ -161,6 +176,14 @@ func initPalette() *Palette {
 			icon:   icon,
 			base:   base,
 		}
+		itemWidget.ConnectEnterEvent(resultItem.enter)
+		itemWidget.ConnectLeaveEvent(func(event *core.QEvent) {
+			editor.thumbnails.hide()
+		})
+		index := i
+		editor.focusChain.add(itemWidget, focusRankPalette, func() {
+			palette.choose(index)
+		})
 		resultItems = append(resultItems, resultItem)
 	}
 	palette.max = max
@@ -176,7 +199,7 @@ func (p *Palette) setColor() {
 	if editor.config.Palette.Transparent < 1.0 {
 		transparent = editor.config.Palette.Transparent * editor.config.Palette.Transparent
 	}
-	p.widget.SetStyleSheet(fmt.Sprintf(" .QWidget { background-color: rgba(%d, %d, %d, %f); } * { color: %s; } ", bg.R, bg.G, bg.B, transparent, fg))
+	p.widget.SetStyleSheet(withUserStyle(fmt.Sprintf(" .QWidget { background-color: rgba(%d, %d, %d, %f); } * { color: %s; } ", bg.R, bg.G, bg.B, transparent, fg)))
 	p.scrollBar.SetStyleSheet(fmt.Sprintf("background-color: rgba(%d, %d, %d, %f);", inactiveFg.R, inactiveFg.G, inactiveFg.B, transparent))
 	for _, item := range p.resultItems {
 		item.widget.SetStyleSheet(fmt.Sprintf(" .QWidget { background-color: rgba(0, 0, 0, 0.0); } * { color: %s; } ", fg))
@@ -252,6 +275,12 @@ func (p *Palette) setPattern(text string) {
 	p.pattern.SetText(text)
 }
 
+// setPatternHTML sets the html of the pattern, whose plain text is the text
+func (p *Palette) setPatternHTML(html, text string) {
+	p.patternText = text
+	p.pattern.SetText(html)
+}
+
 func (p *Palette) cursorMove(x int) {
 	X := p.textLength()
 	var stickOutLen int
@@ -297,50 +326,17 @@ func (p *Palette) updateFont() {
 
 func (p *Palette) textLength() int {
 	font := gui.NewQFontMetricsF(gui.NewQFont2(editor.extFontFamily, editor.extFontSize, 1, false))
-	l := 0
-	if p.isHTMLText {
-		t := gui.NewQTextDocument(nil)
-		t.SetHtml(p.patternText)
-		l = int(
-			font.HorizontalAdvance(
-				t.ToPlainText(),
-				-1,
-			),
-		)
-	} else {
-		l = int(
-			font.HorizontalAdvance(
-				p.patternText,
-				-1,
-			),
-		)
-	}
 
-	return l
+	return int(font.HorizontalAdvance(p.patternText, -1))
 }
 
 func (p *Palette) cursorPos(x int) int {
 	font := gui.NewQFontMetricsF(gui.NewQFont2(editor.extFontFamily, editor.extFontSize, 1, false))
-	l := 0
-	if p.isHTMLText {
-		t := gui.NewQTextDocument(nil)
-		t.SetHtml(p.patternText)
-		l = int(
-			font.HorizontalAdvance(
-				t.ToPlainText()[:x],
-				-1,
-			),
-		)
-	} else {
-		l = int(
-			font.HorizontalAdvance(
-				p.patternText[:x],
-				-1,
-			),
-		)
+	if x > len(p.patternText) {
+		x = len(p.patternText)
 	}
 
-	return l
+	return int(font.HorizontalAdvance(p.patternText[:x], -1))
 }
 
 func (p *Palette) showSelected(selected int) {
@@ -357,6 +353,40 @@ func (p *Palette) showSelected(selected int) {
 	}
 }
 
+// resultIndex returns the index in the shown results of the item, or -1 if
+// the item is the file heading the lines of the file_line results
+func (p *Palette) resultIndex(item int) int {
+	if p.resultType != "file_line" {
+		return item
+	}
+	if item >= len(p.itemTypes) || p.itemTypes[item] == "file" {
+		return -1
+	}
+	index := -1
+	for i := 0; i <= item; i++ {
+		if p.itemTypes[i] != "file" {
+			index++
+		}
+	}
+
+	return index
+}
+
+// choose selects the result of the item in the fuzzy finder, and confirms
+// it by Enter, which the finder waiting on the keys in nvim takes
+func (p *Palette) choose(item int) {
+	index := p.resultIndex(item)
+	if index < 0 {
+		return
+	}
+	neovim := p.ws.nvim
+	go func() {
+		neovim.Call("rpcnotify", nil, 0, "GonvimFuzzy", "select", index)
+		neovim.Input("<CR>")
+	}()
+	editor.focusGrid()
+}
+
 func (f *PaletteResultItem) update() {
 	c := editor.colors.selectedBg
 	// transparent := editor.config.Editor.Transparent
@@ -396,12 +426,14 @@ func (f *PaletteResultItem) hide() {
 func (f *PaletteResultItem) setItem(text string, itemType string, match []int) {
 	iconType := ""
 	path := false
+	f.path = ""
 	if itemType == "dir" {
 		iconType = "folder"
 		path = true
 	} else if itemType == "file" {
 		iconType = getFileType(text)
 		path = true
+		f.path = text
 	} else if itemType == "file_line" {
 		iconType = "empty"
 	}
@@ -422,6 +454,18 @@ func (f *PaletteResultItem) setItem(text string, itemType string, match []int) {
 	}
 }
 
+// enter shows the thumbnail of the image file on hover
+func (f *PaletteResultItem) enter(event *core.QEvent) {
+	if f.path == "" {
+		return
+	}
+	path := f.path
+	if !filepath.IsAbs(path) && f.p.ws != nil {
+		path = filepath.Join(f.p.ws.cwd, path)
+	}
+	editor.thumbnails.show(path, f.widget, f.widget.Rect())
+}
+
 func (f *PaletteResultItem) updateIcon() {
 	svgContent := editor.getSvg(f.iconType, nil)
 	f.icon.Load2(core.NewQByteArray2(svgContent, len(svgContent)))
@@ -0,0 +1,27 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestPaletteResultIndex(t *testing.T) {
+	tests := []struct {
+		resultType string
+		itemTypes  []string
+		item       int
+		want       int
+	}{
+		{"file", nil, 3, 3},
+		{"file_line", []string{"file", "file_line", "file_line", "file", "file_line"}, 0, -1},
+		{"file_line", []string{"file", "file_line", "file_line", "file", "file_line"}, 2, 1},
+		{"file_line", []string{"file", "file_line", "file_line", "file", "file_line"}, 3, -1},
+		{"file_line", []string{"file", "file_line", "file_line", "file", "file_line"}, 4, 2},
+		{"file_line", []string{"file", "file_line"}, 5, -1},
+	}
+	for _, tt := range tests {
+		p := &Palette{resultType: tt.resultType, itemTypes: tt.itemTypes}
+		if got := p.resultIndex(tt.item); got != tt.want {
+			t.Errorf("resultIndex(%v, %d) = %d, want %d", tt.itemTypes, tt.item, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	clipb "github.com/atotto/clipboard"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+// pasteCommands paste the clipboard through the GUI, in chunks if it is large
+const pasteCommands = `
+	command! GonvimPaste call rpcnotify(0, "Gui", "gonvim_paste")
+	`
+
+// pasteLines returns the number of the lines of the text
+func pasteLines(text string) int {
+	if text == "" {
+		return 0
+	}
+	n := strings.Count(text, "\n")
+	if !strings.HasSuffix(text, "\n") {
+		n++
+	}
+
+	return n
+}
+
+// isLargePaste returns true if the text has the lines of the threshold or
+// more. The threshold of 0 disables the chunked paste.
+func isLargePaste(text string, threshold int) bool {
+	if threshold <= 0 {
+		return false
+	}
+
+	return pasteLines(text) >= threshold
+}
+
+// pasteChunks splits the text into the chunks of the lines. The chunks keep
+// the newlines, so that they are joined into the text again.
+func pasteChunks(text string, lines int) []string {
+	if lines < 1 {
+		lines = 1
+	}
+	var chunks []string
+	for text != "" {
+		end := 0
+		for n := 0; n < lines; n++ {
+			i := strings.IndexByte(text[end:], '\n')
+			if i < 0 {
+				end = len(text)
+				break
+			}
+			end += i + 1
+		}
+		chunks = append(chunks, text[:end])
+		text = text[end:]
+	}
+
+	return chunks
+}
+
+// pastePhase returns the phase of nvim_paste of the i-th of the n chunks
+func pastePhase(i, n int) int {
+	switch {
+	case n == 1:
+		return -1
+	case i == 0:
+		return 1
+	case i == n-1:
+		return 3
+	}
+
+	return 2
+}
+
+// paster pastes the large text by nvim_paste in chunks, instead of feeding
+// it to nvim_input, with the progress and the button to cancel it. The GUI
+// keeps redrawing between the chunks.
+type paster struct {
+	ws     *Workspace
+	widget *widgets.QWidget
+	label  *widgets.QLabel
+	bar    *widgets.QProgressBar
+
+	// running is set while the chunks are sent, and cancelled by the
+	// button to stop sending them
+	running   int32
+	cancelled int32
+}
+
+func newPaster(ws *Workspace) *paster {
+	p := &paster{
+		ws: ws,
+	}
+	widget := widgets.NewQWidget(ws.screen.widget, 0)
+	layout := widgets.NewQHBoxLayout()
+	layout.SetContentsMargins(8, 4, 4, 4)
+	layout.SetSpacing(8)
+	widget.SetLayout(layout)
+	label := widgets.NewQLabel2("Pasting", nil, 0)
+	bar := widgets.NewQProgressBar(nil)
+	bar.SetTextVisible(false)
+	bar.SetFixedWidth(120)
+	bar.SetFixedHeight(6)
+	cancel := widgets.NewQPushButton2("Cancel", nil)
+	cancel.SetFocusPolicy(core.Qt__NoFocus)
+	cancel.ConnectClicked(func(bool) {
+		atomic.StoreInt32(&p.cancelled, 1)
+	})
+	layout.AddWidget(label, 0, 0)
+	layout.AddWidget(bar, 0, 0)
+	layout.AddWidget(cancel, 0, 0)
+	widget.Hide()
+	p.widget = widget
+	p.label = label
+	p.bar = bar
+
+	return p
+}
+
+// intercept pastes the clipboard in chunks on the paste keys, if it is large,
+// and returns true. Otherwise the keys go to nvim as usual.
+func (p *paster) intercept(keys string) bool {
+	if keys != "<D-v>" && keys != "<S-Insert>" {
+		return false
+	}
+	if editor.config.Editor.LargePasteLines <= 0 {
+		return false
+	}
+	text, err := clipb.ReadAll()
+	if err != nil || !isLargePaste(text, editor.config.Editor.LargePasteLines) {
+		return false
+	}
+	p.paste(text)
+
+	return true
+}
+
+// pasteClipboard is called by the gonvim_paste notification
+func (p *paster) pasteClipboard() {
+	text, err := clipb.ReadAll()
+	if err != nil {
+		editor.pushNotification(NotifyWarn, -1, fmt.Sprintf("[Goneovim] Failed to read the clipboard: %s", err))
+		return
+	}
+	p.paste(text)
+}
+
+// paste pastes the text at once if it is small, and else in chunks
+func (p *paster) paste(text string) {
+	if text == "" {
+		return
+	}
+	if !isLargePaste(text, editor.config.Editor.LargePasteLines) {
+		go p.ws.nvim.Paste(text, true, -1)
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&p.running, 0, 1) {
+		editor.pushNotification(NotifyWarn, -1, "[Goneovim] The previous paste is still in progress")
+		return
+	}
+	atomic.StoreInt32(&p.cancelled, 0)
+	chunks := pasteChunks(text, editor.config.Editor.PasteChunkLines)
+	total := pasteLines(text)
+	p.progress(0, total)
+
+	go func() {
+		defer atomic.StoreInt32(&p.running, 0)
+		done := 0
+		for i, chunk := range chunks {
+			if atomic.LoadInt32(&p.cancelled) == 1 {
+				// Ends the paste of nvim with the chunks sent so far
+				if i > 0 {
+					p.ws.nvim.Paste("", true, 3)
+				}
+				break
+			}
+			ok, err := p.ws.nvim.Paste(chunk, true, pastePhase(i, len(chunks)))
+			// nvim cancels the paste by returning false, e.g. by Ctrl-C
+			if err != nil || !ok {
+				break
+			}
+			done += pasteLines(chunk)
+			p.ws.guiUpdates <- []interface{}{"gonvim_paste_progress", done, total}
+			p.ws.signal.GuiSignal()
+		}
+		p.ws.guiUpdates <- []interface{}{"gonvim_paste_progress", -1, total}
+		p.ws.signal.GuiSignal()
+	}()
+}
+
+// progress shows the lines pasted of the total, or hides the indicator on -1
+func (p *paster) progress(done, total int) {
+	if done < 0 {
+		p.widget.Hide()
+		return
+	}
+	p.label.SetText(fmt.Sprintf("Pasting %d / %d lines", minInt(done, total), total))
+	p.bar.SetMaximum(total)
+	p.bar.SetValue(minInt(done, total))
+	if p.widget.IsVisible() {
+		return
+	}
+	p.setColor()
+	p.widget.AdjustSize()
+	margin := editor.iconSize / 2
+	x := p.ws.screen.widget.Width() - p.widget.Width() - margin
+	p.widget.Move2(maxInt(x, 0), margin)
+	p.widget.Show()
+	p.widget.Raise()
+}
+
+func (p *paster) setColor() {
+	if editor.colors.widgetBg == nil || editor.colors.widgetFg == nil {
+		return
+	}
+	p.widget.SetStyleSheet(fmt.Sprintf(
+		" * { color: %s; background-color: %s; } QPushButton { border: 1px solid %s; padding: 2px 6px; } QProgressBar::chunk { background-color: %s; }",
+		editor.colors.widgetFg.String(),
+		editor.colors.widgetBg.String(),
+		editor.colors.inactiveFg.String(),
+		editor.colors.widgetFg.String(),
+	))
+}
@@ -0,0 +1,86 @@
+package editor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPasteLines(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"a", 1},
+		{"a\n", 1},
+		{"a\nb", 2},
+		{"a\nb\n", 2},
+		{"\n\n", 2},
+	}
+	for _, tt := range tests {
+		if got := pasteLines(tt.text); got != tt.want {
+			t.Errorf("pasteLines(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestIsLargePaste(t *testing.T) {
+	tests := []struct {
+		text      string
+		threshold int
+		want      bool
+	}{
+		{"a\nb\nc", 3, true},
+		{"a\nb\n", 3, false},
+		{"a\nb\nc", 0, false},
+		{"", 1, false},
+	}
+	for _, tt := range tests {
+		if got := isLargePaste(tt.text, tt.threshold); got != tt.want {
+			t.Errorf("isLargePaste(%q, %d) = %v, want %v", tt.text, tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func TestPasteChunks(t *testing.T) {
+	tests := []struct {
+		text  string
+		lines int
+		want  []string
+	}{
+		{"", 2, nil},
+		{"a\nb\nc\nd\ne", 2, []string{"a\nb\n", "c\nd\n", "e"}},
+		{"a\nb\nc\nd\n", 2, []string{"a\nb\n", "c\nd\n"}},
+		{"a\nb", 0, []string{"a\n", "b"}},
+		{"abc", 5, []string{"abc"}},
+	}
+	for _, tt := range tests {
+		got := pasteChunks(tt.text, tt.lines)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("pasteChunks(%q, %d) = %q, want %q", tt.text, tt.lines, got, tt.want)
+		}
+		if strings.Join(got, "") != tt.text {
+			t.Errorf("pasteChunks(%q, %d) doesn't join into the text", tt.text, tt.lines)
+		}
+	}
+}
+
+func TestPastePhase(t *testing.T) {
+	tests := []struct {
+		i, n int
+		want int
+	}{
+		{0, 1, -1},
+		{0, 3, 1},
+		{1, 3, 2},
+		{2, 3, 3},
+		{0, 2, 1},
+		{1, 2, 3},
+	}
+	for _, tt := range tests {
+		if got := pastePhase(tt.i, tt.n); got != tt.want {
+			t.Errorf("pastePhase(%d, %d) = %d, want %d", tt.i, tt.n, got, tt.want)
+		}
+	}
+}
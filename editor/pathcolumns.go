@@ -0,0 +1,104 @@
+package editor
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+// pathColumnWidth is the width of a column of the parent directories in the
+// column browser of the typed path
+const pathColumnWidth = 160
+
+// showPathColumns shows the parent directories of the typed path as the
+// columns on the left of the results, like the Miller columns. It is called
+// by the finder_path_columns notification, and hides the columns if no
+// columns are given.
+// args: [[{"name": dir, "entries": [names], "selected": index}, ...]]
+func (f *Finder) showPathColumns(args []interface{}) {
+	palette := f.ws.fpalette
+	var columns []interface{}
+	if len(args) > 0 {
+		columns, _ = args[0].([]interface{})
+	}
+	for len(palette.pathColumns) < len(columns) {
+		palette.addPathColumn()
+	}
+	for i, label := range palette.pathColumns {
+		if i >= len(columns) {
+			label.Hide()
+			continue
+		}
+		column, ok := columns[i].(map[string]interface{})
+		if !ok {
+			label.Hide()
+			continue
+		}
+		var entries []string
+		if rawEntries, ok := column["entries"].([]interface{}); ok {
+			for _, entry := range rawEntries {
+				if s, ok := entry.(string); ok {
+					entries = append(entries, s)
+				}
+			}
+		}
+		selected := util.ReflectToInt(column["selected"])
+		lines, selected := pathColumnLines(entries, selected, palette.showTotal)
+		label.SetText(formatPathColumn(lines, selected))
+		label.Show()
+	}
+	if len(columns) == 0 {
+		palette.pathColumnsWidget.Hide()
+	} else {
+		palette.pathColumnsWidget.Show()
+	}
+}
+
+// pathColumnLines returns the entries of the column which fit in the max
+// lines, scrolled so that the selected entry is in the middle, and the index
+// of the selected entry in them
+func pathColumnLines(entries []string, selected, max int) ([]string, int) {
+	if max <= 0 || len(entries) <= max {
+		return entries, selected
+	}
+	start := 0
+	if selected >= 0 {
+		start = selected - max/2
+	}
+	start = minInt(maxInt(start, 0), len(entries)-max)
+
+	return entries[start : start+max], selected - start
+}
+
+func formatPathColumn(lines []string, selected int) string {
+	fg := editor.colors.inactiveFg.Hex()
+	text := ""
+	for i, line := range lines {
+		line = html.EscapeString(line)
+		if i == selected {
+			c := editor.colors.selectedBg
+			line = fmt.Sprintf("<span style='background-color: rgba(%d, %d, %d, %f);'>%s/</span>", c.R, c.G, c.B, transparent(), line)
+		} else {
+			line = fmt.Sprintf("<font color='%s'>%s/</font>", fg, line)
+		}
+		text += line + "<br>"
+	}
+
+	return strings.TrimSuffix(text, "<br>")
+}
+
+// addPathColumn adds a column of the column browser to the palette
+func (p *Palette) addPathColumn() {
+	label := widgets.NewQLabel(nil, 0)
+	label.SetContentsMargins(p.padding, p.padding, p.padding, p.padding)
+	label.SetFixedWidth(pathColumnWidth)
+	label.SetAlignment(core.Qt__AlignLeft | core.Qt__AlignTop)
+	label.SetTextFormat(core.Qt__RichText)
+	label.SetSizePolicy2(widgets.QSizePolicy__Fixed, widgets.QSizePolicy__Preferred)
+	p.pathColumnsWidget.Layout().AddWidget(label)
+	p.pathColumns = append(p.pathColumns, label)
+}
@@ -0,0 +1,28 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPathColumnLines(t *testing.T) {
+	entries := []string{"a", "b", "c", "d", "e", "f", "g"}
+	tests := []struct {
+		selected     int
+		max          int
+		want         []string
+		wantSelected int
+	}{
+		{2, 10, entries, 2},
+		{0, 3, []string{"a", "b", "c"}, 0},
+		{3, 3, []string{"c", "d", "e"}, 1},
+		{6, 3, []string{"e", "f", "g"}, 2},
+		{-1, 3, []string{"a", "b", "c"}, -1},
+	}
+	for _, tt := range tests {
+		got, selected := pathColumnLines(entries, tt.selected, tt.max)
+		if !reflect.DeepEqual(got, tt.want) || selected != tt.wantSelected {
+			t.Errorf("pathColumnLines(%d, %d) = %v, %d, want %v, %d", tt.selected, tt.max, got, selected, tt.want, tt.wantSelected)
+		}
+	}
+}
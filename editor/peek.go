@@ -0,0 +1,414 @@
+package editor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+const (
+	// peekRows is the height of the peek window in lines
+	peekRows = 12
+	// peekContext is the number of the lines read above and below the line
+	// of the location, which the peek window scrolls in
+	peekContext = 50
+)
+
+// peekLua returns the lines from first to last of the file, from its
+// buffer if it is loaded, or else read by nvim, with the highlights of the
+// treesitter or the syntax in the format of minimapLua. The file which isn't
+// loaded is highlighted by the treesitter parser of its filetype. The
+// buffer is found by its full path, as bufnr() takes a pattern.
+const peekLua = `
+local file, first, last, cols = ...
+local path = vim.fn.fnamemodify(file, ":p")
+local buf
+for _, b in ipairs(vim.api.nvim_list_bufs()) do
+  if vim.api.nvim_buf_is_loaded(b) and vim.api.nvim_buf_get_name(b) == path then
+    buf = b
+    break
+  end
+end
+local lines, tabstop = {}, vim.o.tabstop
+local parser, source, base
+if buf then
+  lines = vim.api.nvim_buf_get_lines(buf, first - 1, last, false)
+  tabstop = vim.bo[buf].tabstop
+  local ok, p = pcall(vim.treesitter.get_parser, buf)
+  if ok and p and vim.treesitter.highlighter.active[buf] then
+    parser, source, base = p, buf, first - 1
+  end
+elseif vim.fn.filereadable(path) == 1 then
+  lines = vim.list_slice(vim.fn.readfile(path, "", last), first, last)
+  local ft = vim.filetype and vim.filetype.match({filename = path})
+  local get_lang = vim.treesitter.language and vim.treesitter.language.get_lang
+  local lang = ft and (get_lang and get_lang(ft) or ft)
+  if lang then
+    local text = table.concat(lines, "\n")
+    local ok, p = pcall(vim.treesitter.get_string_parser, text, lang)
+    if ok and p then
+      parser, source, base = p, text, 0
+    end
+  end
+end
+local names = {}
+for i = 1, #lines do
+  names[i] = {}
+end
+if parser and #lines > 0 then
+  local get_query = vim.treesitter.query.get or vim.treesitter.query.get_query or vim.treesitter.get_query
+  pcall(function()
+    parser:parse()
+    parser:for_each_tree(function(tree, ltree)
+      local query = get_query(ltree:lang(), "highlights")
+      if not query then
+        return
+      end
+      for id, node in query:iter_captures(tree:root(), source, base, base + #lines) do
+        local sr, sc, er, ec = node:range()
+        for row = math.max(sr, base), math.min(er, base + #lines - 1) do
+          local i = row - base + 1
+          local s = row == sr and sc or 0
+          local e = row == er and ec or #lines[i]
+          table.insert(names[i], {s, e, "@" .. query.captures[id]})
+        end
+      end
+    end)
+  end)
+elseif buf and vim.bo[buf].syntax ~= "" then
+  vim.api.nvim_buf_call(buf, function()
+    for i, line in ipairs(lines) do
+      local prev, start = 0, 0
+      local width = math.min(#line, cols * 2)
+      for col = 1, width + 1 do
+        local id = col <= width and vim.fn.synID(first + i - 1, col, 1) or 0
+        if id ~= prev then
+          if prev ~= 0 then
+            table.insert(names[i], {start, col - 1, vim.fn.synIDattr(prev, "name")})
+          end
+          prev, start = id, col - 1
+        end
+      end
+    end
+  end)
+end
+local groups, index = {}, {}
+local function group(name)
+  if not index[name] then
+    local id = vim.fn.synIDtrans(vim.fn.hlID(name))
+    table.insert(groups, {
+      id = id,
+      fg = vim.fn.synIDattr(id, "fg#"),
+      bold = vim.fn.synIDattr(id, "bold") == "1",
+      italic = vim.fn.synIDattr(id, "italic") == "1",
+    })
+    index[name] = #groups
+  end
+  return index[name]
+end
+local spans = {}
+for i = 1, #lines do
+  spans[i] = {}
+  for _, span in ipairs(names[i]) do
+    table.insert(spans[i], {span[1], span[2], group(span[3])})
+  end
+end
+return {first = first, tabstop = tabstop, lines = lines, spans = spans, groups = groups}
+`
+
+// Peek is the inline read-only preview of a location in another file
+// (e.g. the LSP definition), shown in the current window. The lines are
+// drawn as a mini grid with their highlights by the glyph atlas of the
+// screen, and scrolled by the wheel.
+// It is invoked by rpcnotify(0, "Gui", "gonvim_peek", {file}, {lnum}, {col}).
+type Peek struct {
+	ws     *Workspace
+	widget *widgets.QWidget
+	title  *widgets.QLabel
+	view   *widgets.QWidget
+	// canvas is the window of the font of the current window, which draws
+	// the glyphs by the atlas of the screen
+	canvas *Window
+
+	file string
+	line int
+	col  int
+	// seq drops the lines of the previous peeks fetched after the last one
+	seq int
+
+	content [][]*Cell
+	// first is the line number of the first line of the content, and top
+	// is the index of the line of the content at the top of the view
+	first int
+	top   int
+}
+
+func initPeek() *Peek {
+	widget := widgets.NewQWidget(nil, 0)
+	widget.SetObjectName("peek")
+	widget.SetAttribute(core.Qt__WA_StyledBackground, true)
+	layout := widgets.NewQVBoxLayout()
+	layout.SetContentsMargins(0, 0, 0, 0)
+	layout.SetSpacing(0)
+	widget.SetLayout(layout)
+
+	header := widgets.NewQWidget(nil, 0)
+	headerLayout := widgets.NewQHBoxLayout()
+	headerLayout.SetContentsMargins(8, 2, 4, 2)
+	header.SetLayout(headerLayout)
+	title := widgets.NewQLabel(nil, 0)
+	jump := widgets.NewQPushButton2("Jump", nil)
+	closeButton := widgets.NewQPushButton2("×", nil)
+	headerLayout.AddWidget(title, 1, 0)
+	headerLayout.AddWidget(jump, 0, 0)
+	headerLayout.AddWidget(closeButton, 0, 0)
+	editor.focusChain.add(jump.QWidget_PTR(), focusRankPanel, jump.Click)
+	editor.focusChain.add(closeButton.QWidget_PTR(), focusRankPanel, closeButton.Click)
+
+	view := widgets.NewQWidget(nil, 0)
+	view.SetAttribute(core.Qt__WA_OpaquePaintEvent, true)
+
+	layout.AddWidget(header, 0, 0)
+	layout.AddWidget(view, 1, 0)
+	widget.Hide()
+
+	p := &Peek{
+		widget: widget,
+		title:  title,
+		view:   view,
+		canvas: &Window{},
+	}
+	view.ConnectPaintEvent(p.paint)
+	view.ConnectWheelEvent(p.wheelEvent)
+	jump.ConnectClicked(func(bool) {
+		p.jump()
+	})
+	closeButton.ConnectClicked(func(bool) {
+		p.hide()
+	})
+
+	return p
+}
+
+// show fetches the lines around the location, and shows the peek window
+// when they arrive.
+// args: [file, lnum, col]
+func (p *Peek) show(args []interface{}) {
+	if len(args) < 2 {
+		return
+	}
+	file, ok := args[0].(string)
+	if !ok || file == "" {
+		return
+	}
+	win, ok := p.ws.screen.getWindow(p.ws.cursor.gridid)
+	if !ok {
+		return
+	}
+	p.file = file
+	p.line = peekArgToInt(args[1])
+	p.col = 1
+	if len(args) > 2 {
+		p.col = peekArgToInt(args[2])
+	}
+
+	p.seq++
+	seq := p.seq
+	first, last := peekRange(p.line)
+	cols := win.cols
+	neovim := p.ws.nvim
+	go func() {
+		lines := &minimapLines{}
+		err := neovim.ExecuteLua(peekLua, lines, file, first, last, cols)
+		if err != nil {
+			lines = nil
+		}
+		p.ws.guiUpdates <- []interface{}{"gonvim_peek_lines", seq, lines}
+		p.ws.signal.GuiSignal()
+	}()
+}
+
+// setLines is called by the gonvim_peek_lines update, and shows the lines
+// below the cursor
+func (p *Peek) setLines(seq int, lines *minimapLines) {
+	if seq != p.seq || lines == nil || len(lines.Lines) == 0 {
+		return
+	}
+	win, ok := p.ws.screen.getWindow(p.ws.cursor.gridid)
+	if !ok {
+		return
+	}
+	p.canvas.s = p.ws.screen
+	p.canvas.font = win.getFont()
+	font := p.canvas.font
+
+	numbered := *lines
+	numbered.Lines, numbered.Spans = peekNumberLines(lines.Lines, lines.Spans, lines.First)
+	isWide := func(char string) bool {
+		return !p.canvas.isNormalWidth(char)
+	}
+	p.content = p.ws.screen.linesContent(&numbered, win.cols, isWide)
+	p.first = lines.First
+	p.top = peekTop(p.line, p.first, len(p.content), peekRows)
+
+	p.setColor()
+	p.title.SetText(fmt.Sprintf("%s:%d", p.file, p.line))
+
+	// Place it below the cursor line, or above if there is no space
+	height := peekRows * font.lineHeight
+	cursorRow := p.ws.screen.cursor[0]
+	y := (cursorRow + 1) * font.lineHeight
+	if y+height > win.widget.Height() && cursorRow*font.lineHeight-height >= 0 {
+		y = cursorRow*font.lineHeight - height
+	}
+	p.widget.SetParent(win.widget)
+	p.widget.SetGeometry2(0, y, win.widget.Width(), height)
+	p.widget.Show()
+	p.widget.Raise()
+	p.view.Update()
+}
+
+// peekNumberLines returns the lines prefixed with their line numbers from
+// first, and the spans of the highlights shifted by the prefixes
+func peekNumberLines(lines []string, spans [][][]int, first int) ([]string, [][][]int) {
+	numbered := make([]string, len(lines))
+	shifted := make([][][]int, len(lines))
+	for i, line := range lines {
+		prefix := fmt.Sprintf("%4d  ", first+i)
+		numbered[i] = prefix + line
+		if i >= len(spans) {
+			continue
+		}
+		for _, span := range spans[i] {
+			if len(span) < 3 {
+				continue
+			}
+			shifted[i] = append(shifted[i], []int{span[0] + len(prefix), span[1] + len(prefix), span[2]})
+		}
+	}
+
+	return numbered, shifted
+}
+
+// peekTop returns the index of the line of the content at the top of the
+// view of the rows, which shows the line in its upper third
+func peekTop(line, first, count, rows int) int {
+	return peekClamp(line-first-rows/3, count, rows)
+}
+
+// peekClamp keeps the view of the rows in the count lines
+func peekClamp(top, count, rows int) int {
+	return maxInt(minInt(top, count-rows), 0)
+}
+
+func (p *Peek) paint(event *gui.QPaintEvent) {
+	painter := gui.NewQPainter2(p.view)
+	defer painter.DestroyQPainter()
+
+	bg := editor.colors.widgetBg
+	if bg == nil {
+		bg = editor.colors.bg
+	}
+	if bg != nil {
+		painter.FillRect4(
+			core.NewQRectF4(0, 0, float64(p.view.Width()), float64(p.view.Height())),
+			bg.QColor(),
+		)
+	}
+	if p.canvas.s == nil || len(p.content) == 0 {
+		return
+	}
+	if p.canvas.devicePixelRatio == 0 {
+		p.canvas.devicePixelRatio = float64(painter.PaintEngine().PaintDevice().DevicePixelRatio())
+	}
+
+	font := p.canvas.font
+	atlas := p.ws.screen.atlas
+	rows := p.view.Height()/font.lineHeight + 1
+	for y := 0; y < rows && p.top+y < len(p.content); y++ {
+		top := float64(y * font.lineHeight)
+		if p.first+p.top+y == p.line && editor.colors.selectedBg != nil {
+			painter.FillRect4(
+				core.NewQRectF4(0, top, float64(p.view.Width()), float64(font.lineHeight)),
+				editor.colors.selectedBg.QColor(),
+			)
+		}
+		line := p.content[p.top+y]
+		for x, cell := range line {
+			if cell == nil || cell.char == " " || cell.char == "" {
+				continue
+			}
+			p.canvas.drawGlyph(painter, atlas, cell, float64(x)*font.truewidth, top, false)
+		}
+	}
+}
+
+func (p *Peek) wheelEvent(event *gui.QWheelEvent) {
+	delta := event.AngleDelta().Y()
+	if delta == 0 || p.canvas.font == nil {
+		return
+	}
+	// A notch of the wheel scrolls the peek by 3 lines
+	lines := -delta * 3 / 120
+	switch {
+	case lines == 0 && delta > 0:
+		lines = -1
+	case lines == 0 && delta < 0:
+		lines = 1
+	}
+	rows := p.view.Height() / p.canvas.font.lineHeight
+	p.top = peekClamp(p.top+lines, len(p.content), rows)
+	p.view.Update()
+}
+
+// peekArgToInt accepts the number given as a string by :GonvimPeek
+func peekArgToInt(arg interface{}) int {
+	if s, ok := arg.(string); ok {
+		i, _ := strconv.Atoi(s)
+		return i
+	}
+
+	return util.ReflectToInt(arg)
+}
+
+func (p *Peek) hide() {
+	// Drop the lines still being fetched
+	p.seq++
+	p.widget.Hide()
+}
+
+// peekRange returns the first and the last lines read around the line
+func peekRange(line int) (int, int) {
+	return maxInt(line-peekContext, 1), line + peekContext
+}
+
+// jump opens the location in the current window
+func (p *Peek) jump() {
+	p.hide()
+	file := strings.Replace(p.file, "'", "''", -1)
+	go p.ws.nvim.Command(fmt.Sprintf(
+		"execute 'edit ' . fnameescape('%s') | call cursor(%d, %d) | normal! zz",
+		file, p.line, p.col,
+	))
+}
+
+func (p *Peek) setColor() {
+	fg := editor.colors.fg
+	bg := editor.colors.widgetBg
+	if fg == nil || bg == nil {
+		return
+	}
+	p.widget.SetStyleSheet(fmt.Sprintf(
+		"QWidget#peek { border-top: 1px solid %s; border-bottom: 1px solid %s; background-color: %s; } * { color: %s; background-color: %s; }",
+		editor.config.SideBar.AccentColor,
+		editor.config.SideBar.AccentColor,
+		bg.String(),
+		fg.String(),
+		bg.String(),
+	))
+}
@@ -0,0 +1,61 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPeekNumberLines(t *testing.T) {
+	lines, spans := peekNumberLines(
+		[]string{"package main", "", "func f() {}"},
+		[][][]int{{{0, 7, 1}}, nil, {{0, 4, 1}, {5, 6, 2}}},
+		9,
+	)
+	wantLines := []string{"   9  package main", "  10  ", "  11  func f() {}"}
+	wantSpans := [][][]int{{{6, 13, 1}}, nil, {{6, 10, 1}, {11, 12, 2}}}
+	if !reflect.DeepEqual(lines, wantLines) || !reflect.DeepEqual(spans, wantSpans) {
+		t.Errorf("peekNumberLines() = %q, %v, want %q, %v", lines, spans, wantLines, wantSpans)
+	}
+}
+
+func TestPeekTop(t *testing.T) {
+	tests := []struct {
+		line, first, count, rows int
+		want                     int
+	}{
+		{1, 1, 51, 12, 0},
+		{51, 1, 101, 12, 46},
+		{108, 50, 60, 12, 48},
+		{3, 1, 5, 12, 0},
+	}
+	for _, tt := range tests {
+		if got := peekTop(tt.line, tt.first, tt.count, tt.rows); got != tt.want {
+			t.Errorf("peekTop(%d, %d, %d, %d) = %d, want %d", tt.line, tt.first, tt.count, tt.rows, got, tt.want)
+		}
+	}
+}
+
+func TestPeekArgToInt(t *testing.T) {
+	if got := peekArgToInt("42"); got != 42 {
+		t.Errorf("peekArgToInt(\"42\") = %d, want 42", got)
+	}
+	if got := peekArgToInt(int64(7)); got != 7 {
+		t.Errorf("peekArgToInt(int64(7)) = %d, want 7", got)
+	}
+}
+
+func TestPeekRange(t *testing.T) {
+	tests := []struct {
+		line        int
+		first, last int
+	}{
+		{1, 1, 1 + peekContext},
+		{peekContext, 1, peekContext * 2},
+		{peekContext + 10, 10, peekContext*2 + 10},
+	}
+	for _, tt := range tests {
+		if first, last := peekRange(tt.line); first != tt.first || last != tt.last {
+			t.Errorf("peekRange(%d) = %d, %d, want %d, %d", tt.line, first, last, tt.first, tt.last)
+		}
+	}
+}
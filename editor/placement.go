@@ -0,0 +1,200 @@
+package editor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+)
+
+// placementCommands move the window onto a monitor, in fullscreen or tiled
+// to a part of it. The monitor is the index from 1 or a part of the name,
+// and the current one if omitted.
+const placementCommands = `
+	command! -nargs=? GonvimMonitor call rpcnotify(0, "Gui", "gonvim_place", "monitor", <q-args>, "")
+	command! -bang -nargs=? GonvimFullscreen call rpcnotify(0, "Gui", "gonvim_place", <bang>0 ? "windowed" : "fullscreen", <q-args>, "")
+	command! -nargs=+ -complete=custom,GonvimTilePositions GonvimTile call rpcnotify(0, "Gui", "gonvim_place", "tile", get([<f-args>], 1, ""), [<f-args>][0])
+	function! GonvimTilePositions(A, L, P) abort
+		return join(["left", "right", "top", "bottom", "topleft", "topright", "bottomleft", "bottomright", "center", "maximize"], "\n")
+	endfunction
+	`
+
+// tilePositions are the parts of the monitor the window is tiled to, in the
+// fractions x, y, width and height of the available area
+var tilePositions = map[string][4]float64{
+	"left":        {0, 0, 0.5, 1},
+	"right":       {0.5, 0, 0.5, 1},
+	"top":         {0, 0, 1, 0.5},
+	"bottom":      {0, 0.5, 1, 0.5},
+	"topleft":     {0, 0, 0.5, 0.5},
+	"topright":    {0.5, 0, 0.5, 0.5},
+	"bottomleft":  {0, 0.5, 0.5, 0.5},
+	"bottomright": {0.5, 0.5, 0.5, 0.5},
+	"maximize":    {0, 0, 1, 1},
+}
+
+// findMonitor returns the index of the monitor of the names by the index
+// from 1 or a part of the name, ignoring the case. The empty arg is the
+// current monitor, and -1 is returned if no monitor matches.
+func findMonitor(names []string, arg string, current int) int {
+	arg = strings.TrimSpace(arg)
+	if arg == "" {
+		return current
+	}
+	if n, err := strconv.Atoi(arg); err == nil {
+		if n < 1 || n > len(names) {
+			return -1
+		}
+		return n - 1
+	}
+	for i, name := range names {
+		if strings.Contains(strings.ToLower(name), strings.ToLower(arg)) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// tileRect returns the geometry of the window tiled to the position in the
+// available area of the monitor. "center" keeps the size of the window,
+// fitted in the area.
+func tileRect(area, window [4]int, position string) ([4]int, bool) {
+	if position == "center" {
+		return centerRect(area, window), true
+	}
+	f, ok := tilePositions[position]
+	if !ok {
+		return window, false
+	}
+	x := area[0] + int(float64(area[2])*f[0])
+	y := area[1] + int(float64(area[3])*f[1])
+	// The right and the bottom edges are rounded from the area, so that the
+	// tiles of the halves meet without a gap
+	right := area[0] + int(float64(area[2])*(f[0]+f[2]))
+	bottom := area[1] + int(float64(area[3])*(f[1]+f[3]))
+
+	return [4]int{x, y, right - x, bottom - y}, true
+}
+
+// centerRect returns the geometry of the window of the size centered in the
+// area, shrunk to fit in it
+func centerRect(area, window [4]int) [4]int {
+	width := minInt(window[2], area[2])
+	height := minInt(window[3], area[3])
+
+	return [4]int{
+		area[0] + (area[2]-width)/2,
+		area[1] + (area[3]-height)/2,
+		width,
+		height,
+	}
+}
+
+func monitorNames(screens []*gui.QScreen) []string {
+	names := make([]string, len(screens))
+	for i, screen := range screens {
+		names[i] = screen.Name() + " " + screen.Model()
+	}
+
+	return names
+}
+
+// currentMonitor returns the index of the monitor the window is on
+func (e *Editor) currentMonitor(screens []*gui.QScreen) int {
+	handle := e.window.WindowHandle()
+	if handle == nil || handle.Screen() == nil {
+		return 0
+	}
+	for i, screen := range screens {
+		if screen.Pointer() == handle.Screen().Pointer() {
+			return i
+		}
+	}
+
+	return 0
+}
+
+// placeWindow moves the window onto the monitor, and shows it in fullscreen,
+// windowed or tiled to the position. It is called by the gonvim_place
+// notification, and on startup by --monitor and --tile.
+func (e *Editor) placeWindow(action, monitor, position string) error {
+	screens := gui.QGuiApplication_Screens()
+	if len(screens) == 0 {
+		return nil
+	}
+	names := monitorNames(screens)
+	current := e.currentMonitor(screens)
+	index := findMonitor(names, monitor, current)
+	if index < 0 {
+		return fmt.Errorf("no monitor matches %q, the monitors are %s", monitor, monitorList(names, current))
+	}
+	screen := screens[index]
+	area := screen.AvailableGeometry()
+	areaRect := [4]int{area.X(), area.Y(), area.Width(), area.Height()}
+	geometry := e.window.Geometry()
+	windowRect := [4]int{geometry.X(), geometry.Y(), geometry.Width(), geometry.Height()}
+
+	rect := centerRect(areaRect, windowRect)
+	if action == "tile" {
+		var ok bool
+		rect, ok = tileRect(areaRect, windowRect, position)
+		if !ok {
+			return fmt.Errorf("unknown tile position %q", position)
+		}
+	}
+
+	fullscreen := e.window.IsFullScreen()
+	if fullscreen || e.window.IsMaximized() {
+		e.window.ShowNormal()
+	}
+	if handle := e.window.WindowHandle(); handle != nil {
+		handle.SetScreen(screen)
+	}
+	e.window.SetGeometry(core.NewQRect4(rect[0], rect[1], rect[2], rect[3]))
+	// The window moved to another monitor stays in fullscreen
+	if action == "fullscreen" || (action == "monitor" && fullscreen) {
+		e.window.ShowFullScreen()
+	}
+
+	return nil
+}
+
+// monitorList returns the list of the monitors for the messages, with the
+// current one marked by "*"
+func monitorList(names []string, current int) string {
+	list := make([]string, len(names))
+	for i, name := range names {
+		mark := ""
+		if i == current {
+			mark = "*"
+		}
+		list[i] = fmt.Sprintf("%s%d: %s", mark, i+1, strings.TrimSpace(name))
+	}
+
+	return strings.Join(list, ", ")
+}
+
+// guiPlace is called by the gonvim_place notification.
+// args: [action, monitor, position]
+func (w *Workspace) guiPlace(args []interface{}) {
+	if len(args) < 3 {
+		return
+	}
+	action, _ := args[0].(string)
+	monitor, _ := args[1].(string)
+	position, _ := args[2].(string)
+
+	// :GonvimMonitor without the argument lists the monitors
+	if action == "monitor" && strings.TrimSpace(monitor) == "" {
+		screens := gui.QGuiApplication_Screens()
+		editor.pushNotification(NotifyInfo, -1, "[Goneovim] "+monitorList(monitorNames(screens), editor.currentMonitor(screens)))
+		return
+	}
+	err := editor.placeWindow(action, monitor, position)
+	if err != nil {
+		editor.pushNotification(NotifyWarn, -1, "[Goneovim] "+err.Error())
+	}
+}
@@ -0,0 +1,69 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestFindMonitor(t *testing.T) {
+	names := []string{"eDP-1 Built-in Retina Display", "DP-2 DELL U2720Q", "HDMI-1 LG HDR 4K"}
+	tests := []struct {
+		arg  string
+		want int
+	}{
+		{"", 1},
+		{"1", 0},
+		{"3", 2},
+		{"0", -1},
+		{"4", -1},
+		{"dell", 1},
+		{"HDMI", 2},
+		{"retina", 0},
+		{"benq", -1},
+	}
+	for _, tt := range tests {
+		if got := findMonitor(names, tt.arg, 1); got != tt.want {
+			t.Errorf("findMonitor(%q) = %d, want %d", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestTileRect(t *testing.T) {
+	area := [4]int{1920, 25, 1441, 875}
+	window := [4]int{100, 100, 800, 600}
+	tests := []struct {
+		position string
+		want     [4]int
+		ok       bool
+	}{
+		{"left", [4]int{1920, 25, 720, 875}, true},
+		{"right", [4]int{2640, 25, 721, 875}, true},
+		{"top", [4]int{1920, 25, 1441, 437}, true},
+		{"bottom", [4]int{1920, 462, 1441, 438}, true},
+		{"bottomright", [4]int{2640, 462, 721, 438}, true},
+		{"maximize", area, true},
+		{"center", [4]int{2240, 162, 800, 600}, true},
+		{"middle", window, false},
+	}
+	for _, tt := range tests {
+		got, ok := tileRect(area, window, tt.position)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("tileRect(%q) = %v, %v, want %v, %v", tt.position, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCenterRect(t *testing.T) {
+	got := centerRect([4]int{0, 0, 1280, 800}, [4]int{0, 0, 1600, 600})
+	want := [4]int{0, 100, 1280, 600}
+	if got != want {
+		t.Errorf("centerRect() = %v, want %v", got, want)
+	}
+}
+
+func TestMonitorList(t *testing.T) {
+	got := monitorList([]string{"eDP-1 ", "DP-2 DELL U2720Q"}, 1)
+	want := "1: eDP-1, *2: DP-2 DELL U2720Q"
+	if got != want {
+		t.Errorf("monitorList() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,149 @@
+package editor
+
+import (
+	"fmt"
+)
+
+// pmenuColors are the colors of the popupmenu, which follow the Pmenu
+// highlight groups: the items, the selected item, the menu and the info
+// columns as the extra ones, and the thumb and the track of the scrollbar
+type pmenuColors struct {
+	fg         *RGBA
+	bg         *RGBA
+	selFg      *RGBA
+	selBg      *RGBA
+	extraFg    *RGBA
+	extraSelFg *RGBA
+	thumb      *RGBA
+	sbar       *RGBA
+}
+
+// groupColors returns the foreground and the background of the highlight,
+// reversed if it is, and nil for the colors the group doesn't set
+func groupColors(hl *Highlight) (*RGBA, *RGBA) {
+	if hl == nil {
+		return nil, nil
+	}
+	if hl.reverse {
+		return hl.background, hl.foreground
+	}
+
+	return hl.foreground, hl.background
+}
+
+// resolvePmenuColors returns the colors of the groups, which take the
+// defaults for the colors the groups don't set. The selected extra columns
+// take the foreground of PmenuSel, and the extra columns of PmenuSel take
+// the foreground of PmenuExtra, as nvim draws them.
+func resolvePmenuColors(groups map[string]*Highlight, def pmenuColors) pmenuColors {
+	c := def
+	pick := func(dst **RGBA, color *RGBA) {
+		if color != nil {
+			*dst = color
+		}
+	}
+
+	fg, bg := groupColors(groups["Pmenu"])
+	pick(&c.fg, fg)
+	pick(&c.bg, bg)
+	c.selFg = c.fg
+	fg, bg = groupColors(groups["PmenuSel"])
+	pick(&c.selFg, fg)
+	pick(&c.selBg, bg)
+	fg, _ = groupColors(groups["PmenuExtra"])
+	pick(&c.extraFg, fg)
+	c.extraSelFg = c.selFg
+	fg, _ = groupColors(groups["PmenuExtraSel"])
+	pick(&c.extraSelFg, fg)
+	_, bg = groupColors(groups["PmenuThumb"])
+	pick(&c.thumb, bg)
+	_, bg = groupColors(groups["PmenuSbar"])
+	pick(&c.sbar, bg)
+
+	return c
+}
+
+// pmenuGroups returns the highlights of the Pmenu groups set by hl_group_set
+func (s *Screen) pmenuGroups() map[string]*Highlight {
+	groups := make(map[string]*Highlight)
+	for _, name := range []string{"Pmenu", "PmenuSel", "PmenuExtra", "PmenuExtraSel", "PmenuThumb", "PmenuSbar"} {
+		id, ok := s.highlightGroup[name]
+		if !ok {
+			continue
+		}
+		if hl, ok := s.hlAttrDef[id]; ok && hl != nil {
+			groups[name] = hl
+		}
+	}
+
+	return groups
+}
+
+// updatePmenuColors resolves the colors of the popupmenu, and restyles it if
+// they changed, e.g. by the colorscheme
+func (p *PopupMenu) updatePmenuColors() {
+	def := pmenuColors{
+		fg:         editor.colors.widgetFg,
+		bg:         editor.colors.widgetBg,
+		selFg:      editor.colors.widgetFg,
+		selBg:      editor.colors.selectedBg,
+		extraFg:    editor.colors.inactiveFg,
+		extraSelFg: editor.colors.inactiveFg,
+		thumb:      editor.colors.inactiveFg,
+	}
+	colors := def
+	if editor.config.Popupmenu.FollowPmenu {
+		colors = resolvePmenuColors(p.ws.screen.pmenuGroups(), def)
+	}
+	if p.colors == colors {
+		return
+	}
+	p.colors = colors
+	p.setStyle()
+	for _, item := range p.items {
+		item.selected = !item.selectedRequest
+		item.updateContent()
+	}
+}
+
+// setStyle styles the popupmenu by the colors, with the opacity of 'pumblend'
+func (p *PopupMenu) setStyle() {
+	c := p.colors
+	if c.fg == nil || c.bg == nil || c.thumb == nil || c.extraFg == nil {
+		return
+	}
+	detail := warpColor(c.fg, 10).String()
+	p.setScrollBarStyle()
+	p.widget.SetStyleSheet(
+		fmt.Sprintf(`
+			* { background-color: rgba(%d, %d, %d, %f); color: %s; }
+			.QLabel { background-color: rgba(0, 0, 0, 0.0); color: %s; }
+			#wordlabel { color: %s; }
+			`,
+			c.bg.R, c.bg.G, c.bg.B, p.alpha, c.fg.String(), c.extraFg.String(), c.fg.String(),
+		),
+	)
+	p.detailLabel.SetStyleSheet(
+		fmt.Sprintf(
+			"* { background-color: rgba(0, 0, 0, 0.0); color: %s; }",
+			detail,
+		),
+	)
+}
+
+// itemStyle returns the style sheet of the label of the item, the word or
+// the extra columns, selected or not
+func (c pmenuColors) itemStyle(selected, extra bool) string {
+	if !selected || c.selBg == nil {
+		return "background-color: rgba(0, 0, 0, 0);"
+	}
+	fg := c.selFg
+	if extra {
+		fg = c.extraSelFg
+	}
+	if fg == nil {
+		return fmt.Sprintf("background-color: %s;", c.selBg.StringTransparent())
+	}
+
+	return fmt.Sprintf("background-color: %s; color: %s;", c.selBg.StringTransparent(), fg.String())
+}
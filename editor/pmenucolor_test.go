@@ -0,0 +1,56 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestResolvePmenuColors(t *testing.T) {
+	fg, bg, sel, extra := newRGBA(200, 200, 200, 1), newRGBA(30, 30, 30, 1), newRGBA(60, 60, 60, 1), newRGBA(120, 120, 120, 1)
+	def := pmenuColors{fg: fg, bg: bg, selFg: fg, selBg: sel, extraFg: extra, extraSelFg: extra, thumb: extra}
+
+	pmenuFg, pmenuBg := newRGBA(1, 1, 1, 1), newRGBA(2, 2, 2, 1)
+	selFg, selBg := newRGBA(3, 3, 3, 1), newRGBA(4, 4, 4, 1)
+	extraFg, thumb := newRGBA(5, 5, 5, 1), newRGBA(6, 6, 6, 1)
+
+	tests := []struct {
+		name   string
+		groups map[string]*Highlight
+		want   pmenuColors
+	}{
+		{
+			"no groups",
+			nil,
+			pmenuColors{fg: fg, bg: bg, selFg: fg, selBg: sel, extraFg: extra, extraSelFg: fg, thumb: extra},
+		},
+		{
+			"all groups",
+			map[string]*Highlight{
+				"Pmenu":      {foreground: pmenuFg, background: pmenuBg},
+				"PmenuSel":   {foreground: selFg, background: selBg},
+				"PmenuExtra": {foreground: extraFg},
+				"PmenuThumb": {background: thumb},
+			},
+			pmenuColors{fg: pmenuFg, bg: pmenuBg, selFg: selFg, selBg: selBg, extraFg: extraFg, extraSelFg: selFg, thumb: thumb},
+		},
+		{
+			"reversed PmenuSel",
+			map[string]*Highlight{
+				"Pmenu":    {foreground: pmenuFg},
+				"PmenuSel": {foreground: selFg, reverse: true},
+			},
+			pmenuColors{fg: pmenuFg, bg: bg, selFg: pmenuFg, selBg: selFg, extraFg: extra, extraSelFg: pmenuFg, thumb: extra},
+		},
+		{
+			"PmenuExtraSel",
+			map[string]*Highlight{
+				"PmenuExtraSel": {foreground: extraFg},
+			},
+			pmenuColors{fg: fg, bg: bg, selFg: fg, selBg: sel, extraFg: extra, extraSelFg: extraFg, thumb: extra},
+		},
+	}
+	for _, tt := range tests {
+		if got := resolvePmenuColors(tt.groups, def); got != tt.want {
+			t.Errorf("resolvePmenuColors(%s) = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package editor
+
+import (
+	"fmt"
+
+	"github.com/akiyosi/goneovim/util"
+)
+
+// pmenuMinThumb is the minimum height of the thumb of the scrollbar of the
+// popupmenu in pixels
+const pmenuMinThumb = 8
+
+// pmenuPlace returns the top of the popupmenu of the items, the number of the
+// items shown and whether it is put over the line of the anchor. The menu is
+// put under the line if the items up to maxItems fit there, else over the
+// line if they fit there, else on the side with more room with the items
+// which fit, and it is clamped within the area.
+func pmenuPlace(anchorY, lineHeight, itemHeight, padding, items, maxItems, areaHeight int) (int, int, bool) {
+	want := minInt(items, maxItems)
+	if want < 1 || itemHeight < 1 {
+		return anchorY + lineHeight, 0, false
+	}
+	fit := func(room int) int {
+		return maxInt(0, minInt((room-padding)/itemHeight, want))
+	}
+	below := fit(areaHeight - anchorY - lineHeight)
+	above := fit(anchorY)
+
+	shown, isAbove := below, false
+	switch {
+	case below == want:
+	case above == want || above > below:
+		shown, isAbove = above, true
+	}
+	shown = maxInt(shown, 1)
+	height := shown*itemHeight + padding
+
+	y := anchorY + lineHeight
+	if isAbove {
+		y = anchorY - height
+	}
+	y = maxInt(0, minInt(y, areaHeight-height))
+
+	return y, shown, isAbove
+}
+
+// pmenuTop returns the index of the first item shown, so that the selected
+// item is shown and the shown items don't run out of the items
+func pmenuTop(selected, shown, items int) int {
+	top := 0
+	if selected >= shown {
+		top = selected - shown + 1
+	}
+
+	return maxInt(0, minInt(top, items-shown))
+}
+
+// pmenuThumb returns the position and the height of the thumb of the
+// scrollbar in the track of the height, for the shown items from the top
+func pmenuThumb(top, shown, items, track int) (int, int) {
+	if items <= shown || shown < 1 || track < 1 {
+		return 0, 0
+	}
+	height := minInt(maxInt(track*shown/items, pmenuMinThumb), track)
+	pos := (track - height) * top / (items - shown)
+
+	return pos, height
+}
+
+// placeWidget sizes the popupmenu for the shown items, and moves it to the
+// top, with the left edge clamped within the area
+func (p *PopupMenu) placeWidget(x, y int, above bool) {
+	area := p.widget.ParentWidget()
+	width := p.widget.Width()
+	if x+width >= area.Width() {
+		x = area.Width() - width - 5
+	}
+	x = maxInt(x, 0)
+	p.widget.SetFixedHeight(p.showTotal*p.itemHeight + p.padding())
+
+	// The shadow is cast away from the line
+	if above {
+		p.widget.SetGraphicsEffect(util.DropShadow(-2, -6, 40, 200))
+	} else {
+		p.widget.SetGraphicsEffect(util.DropShadow(-2, 6, 40, 200))
+	}
+
+	p.widget.Move2(x, y)
+}
+
+// padding returns the height of the popupmenu other than the items
+func (p *PopupMenu) padding() int {
+	return 2 + editor.iconSize*2/5
+}
+
+// updateScrollBar shows the scrollbar if the items are more than the shown
+// ones, with the thumb at the shown items
+func (p *PopupMenu) updateScrollBar() {
+	track := p.showTotal * p.itemHeight
+	pos, height := pmenuThumb(p.top, p.showTotal, len(p.rawItems), track)
+	if height == 0 {
+		p.scrollCol.Hide()
+		return
+	}
+	p.scrollBarPos = pos
+	p.scrollBarHeight = height
+	p.scrollCol.SetFixedHeight(track)
+	p.scrollBar.SetFixedHeight(height)
+	p.scrollBar.Move2(0, pos)
+	p.scrollCol.Show()
+}
+
+// setScrollBarStyle colors the track of the scrollbar by PmenuSbar, and the
+// thumb by PmenuThumb
+func (p *PopupMenu) setScrollBarStyle() {
+	c := p.colors
+	p.scrollBar.SetStyleSheet(fmt.Sprintf("background-color: %s;", c.thumb.String()))
+	if c.sbar == nil {
+		p.scrollCol.SetStyleSheet("background-color: rgba(0, 0, 0, 0);")
+		return
+	}
+	p.scrollCol.SetStyleSheet(fmt.Sprintf("background-color: %s;", c.sbar.String()))
+}
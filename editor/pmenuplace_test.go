@@ -0,0 +1,79 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestPmenuPlace(t *testing.T) {
+	// The lines are 20px, the items 20px and the padding 10px
+	tests := []struct {
+		name      string
+		anchorY   int
+		items     int
+		maxItems  int
+		wantY     int
+		wantShown int
+		wantAbove bool
+	}{
+		{"under the line", 100, 5, 20, 120, 5, false},
+		{"over the line", 400, 5, 20, 290, 5, true},
+		{"the max items", 100, 50, 10, 120, 10, false},
+		{"more room under the line", 150, 50, 50, 170, 16, false},
+		{"more room over the line", 300, 50, 50, 10, 14, true},
+		{"no items", 100, 0, 20, 120, 0, false},
+	}
+	for _, tt := range tests {
+		y, shown, above := pmenuPlace(tt.anchorY, 20, 20, 10, tt.items, tt.maxItems, 500)
+		if y != tt.wantY || shown != tt.wantShown || above != tt.wantAbove {
+			t.Errorf("%s: pmenuPlace(%d, %d, %d) = (%d, %d, %v), want (%d, %d, %v)",
+				tt.name, tt.anchorY, tt.items, tt.maxItems, y, shown, above, tt.wantY, tt.wantShown, tt.wantAbove)
+		}
+	}
+
+	// No room on both sides
+	y, shown, above := pmenuPlace(5, 20, 20, 10, 3, 20, 35)
+	if y != 5 || shown != 1 || above {
+		t.Errorf("pmenuPlace() in the small area = (%d, %d, %v), want (5, 1, false)", y, shown, above)
+	}
+}
+
+func TestPmenuTop(t *testing.T) {
+	tests := []struct {
+		selected, shown, items int
+		want                   int
+	}{
+		{-1, 10, 50, 0},
+		{0, 10, 50, 0},
+		{9, 10, 50, 0},
+		{10, 10, 50, 1},
+		{30, 10, 50, 21},
+		{49, 10, 50, 40},
+		{3, 10, 5, 0},
+	}
+	for _, tt := range tests {
+		if got := pmenuTop(tt.selected, tt.shown, tt.items); got != tt.want {
+			t.Errorf("pmenuTop(%d, %d, %d) = %d, want %d", tt.selected, tt.shown, tt.items, got, tt.want)
+		}
+	}
+}
+
+func TestPmenuThumb(t *testing.T) {
+	tests := []struct {
+		top, shown, items, track int
+		wantPos, wantHeight      int
+	}{
+		{0, 10, 10, 200, 0, 0},
+		{0, 10, 40, 200, 0, 50},
+		{30, 10, 40, 200, 150, 50},
+		{15, 10, 40, 200, 75, 50},
+		{0, 10, 1000, 200, 0, pmenuMinThumb},
+		{990, 10, 1000, 200, 200 - pmenuMinThumb, pmenuMinThumb},
+	}
+	for _, tt := range tests {
+		pos, height := pmenuThumb(tt.top, tt.shown, tt.items, tt.track)
+		if pos != tt.wantPos || height != tt.wantHeight {
+			t.Errorf("pmenuThumb(%d, %d, %d, %d) = (%d, %d), want (%d, %d)",
+				tt.top, tt.shown, tt.items, tt.track, pos, height, tt.wantPos, tt.wantHeight)
+		}
+	}
+}
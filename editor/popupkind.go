@@ -0,0 +1,213 @@
+package editor
+
+import (
+	"html"
+	"sort"
+
+	"github.com/akiyosi/goneovim/util"
+)
+
+// completionKind is the svg icon of a completion kind, and the highlight
+// group whose foreground colors the icon
+type completionKind struct {
+	svg   string
+	group string
+}
+
+var completionKinds = map[string]completionKind{
+	"text":          {"lsp_text", "String"},
+	"method":        {"lsp_function", "Function"},
+	"function":      {"lsp_function", "Function"},
+	"constructor":   {"lsp_function", "Function"},
+	"field":         {"lsp_variable", "Function"},
+	"variable":      {"lsp_variable", "Function"},
+	"property":      {"lsp_variable", "Function"},
+	"class":         {"lsp_class", "Function"},
+	"interface":     {"lsp_interface", "Function"},
+	"module":        {"lsp_module", "String"},
+	"unit":          {"lsp_unit", "Statement"},
+	"value":         {"lsp_value", "String"},
+	"enum":          {"lsp_enum", "Type"},
+	"keyword":       {"lsp_keyword", "String"},
+	"snippet":       {"lsp_snippet", "String"},
+	"color":         {"lsp_color", "String"},
+	"file":          {"lsp_file", "Type"},
+	"reference":     {"lsp_reference", "Type"},
+	"folder":        {"lsp_folder", "Type"},
+	"enummember":    {"lsp_enumMember", "String"},
+	"constant":      {"lsp_constant", "String"},
+	"struct":        {"lsp_struct", "Statement"},
+	"event":         {"lsp_event", "Statement"},
+	"operator":      {"lsp_operator", "Statement"},
+	"typeparameter": {"lsp_typeParameter", "Type"},
+}
+
+// nerdFontKindIcons is the codicons of the Nerd Fonts
+var nerdFontKindIcons = map[string]string{
+	"text":          "",
+	"method":        "",
+	"function":      "",
+	"constructor":   "",
+	"field":         "",
+	"variable":      "",
+	"class":         "",
+	"interface":     "",
+	"module":        "",
+	"property":      "",
+	"unit":          "",
+	"value":         "",
+	"enum":          "",
+	"keyword":       "",
+	"snippet":       "",
+	"color":         "",
+	"file":          "",
+	"reference":     "",
+	"folder":        "",
+	"enummember":    "",
+	"constant":      "",
+	"struct":        "",
+	"event":         "",
+	"operator":      "",
+	"typeparameter": "",
+}
+
+var emojiKindIcons = map[string]string{
+	"text":          "📝",
+	"method":        "🔧",
+	"function":      "🔧",
+	"constructor":   "🏗",
+	"field":         "🏷",
+	"variable":      "📦",
+	"class":         "🏛",
+	"interface":     "🔌",
+	"module":        "📚",
+	"property":      "🏷",
+	"unit":          "📏",
+	"value":         "💎",
+	"enum":          "🔢",
+	"keyword":       "🔑",
+	"snippet":       "✂",
+	"color":         "🎨",
+	"file":          "📄",
+	"reference":     "🔗",
+	"folder":        "📁",
+	"enummember":    "🔢",
+	"constant":      "🔒",
+	"struct":        "🧱",
+	"event":         "⚡",
+	"operator":      "➕",
+	"typeparameter": "🔤",
+}
+
+// completeItemsLua returns the user_data of the items of the completion
+const completeItemsLua = `
+local items = vim.fn.complete_info({"items"}).items or {}
+local data = {}
+for i, item in ipairs(items) do
+  data[i] = type(item.user_data) == "table" and item.user_data or vim.NIL
+end
+return data
+`
+
+// completeItemMeta is the extra metadata of a complete item, which plugins
+// pass in the user_data, e.g. {"source": "lsp", "deprecated": v:true}
+type completeItemMeta struct {
+	source     string
+	deprecated bool
+}
+
+// kindTextIcon returns the text icon of the normalized kind, or false if
+// the kind is drawn by the svg icon
+func kindTextIcon(kind string, c popupMenuConfig) (string, bool) {
+	if k, ok := c.Kinds[kind]; ok && k.Icon != "" {
+		return k.Icon, true
+	}
+	var icons map[string]string
+	switch c.KindIcons {
+	case "nerdfont":
+		icons = nerdFontKindIcons
+	case "emoji":
+		icons = emojiKindIcons
+	default:
+		return "", false
+	}
+	icon, ok := icons[kind]
+
+	return icon, ok
+}
+
+// kindColor returns the color of the normalized kind, which is the color of
+// the config or the foreground of the highlight group of the kind
+func kindColor(kind string) *RGBA {
+	if k, ok := editor.config.Popupmenu.Kinds[kind]; ok && k.Color != "" {
+		return hexToRGBA(k.Color)
+	}
+	group := completionKinds[kind].group
+	if group == "" {
+		return nil
+	}
+
+	hiAttrDef := editor.workspaces[editor.active].screen.hlAttrDef
+	var keys []int
+	for k := range hiAttrDef {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	var color *RGBA
+	for _, k := range keys {
+		hi := hiAttrDef[k]
+		if hi.hlName == group {
+			color = hi.fg()
+		}
+	}
+
+	return color
+}
+
+// parseCompleteItemMeta returns the metadata in the user_data of an item
+func parseCompleteItemMeta(userData interface{}) completeItemMeta {
+	var meta completeItemMeta
+	data, ok := userData.(map[string]interface{})
+	if !ok {
+		return meta
+	}
+	meta.source, _ = data["source"].(string)
+	switch deprecated := data["deprecated"].(type) {
+	case bool:
+		meta.deprecated = deprecated
+	case nil:
+	default:
+		meta.deprecated = util.ReflectToInt(deprecated) != 0
+	}
+
+	return meta
+}
+
+// completeItemMetas returns the metadata of the items of the completion
+func (p *PopupMenu) completeItemMetas() []completeItemMeta {
+	var data []interface{}
+	err := p.ws.nvim.ExecuteLua(completeItemsLua, &data)
+	if err != nil {
+		return nil
+	}
+	metas := make([]completeItemMeta, len(data))
+	for i, userData := range data {
+		metas[i] = parseCompleteItemMeta(userData)
+	}
+
+	return metas
+}
+
+// itemMeta returns the metadata of the item of the index
+func (p *PopupMenu) itemMeta(i int) completeItemMeta {
+	if i < 0 || i >= len(p.metas) {
+		return completeItemMeta{}
+	}
+
+	return p.metas[i]
+}
+
+// deprecatedText returns the rich text of the word struck through
+func deprecatedText(word string) string {
+	return "<s>" + html.EscapeString(word) + "</s>"
+}
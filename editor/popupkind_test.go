@@ -0,0 +1,64 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestKindTextIcon(t *testing.T) {
+	kinds := map[string]completionKindConfig{
+		"function": {Icon: "ƒ"},
+		"copilot":  {Icon: "C"},
+		"class":    {Color: "#ff0000"},
+	}
+	tests := []struct {
+		kind  string
+		icons string
+		icon  string
+		ok    bool
+	}{
+		{"function", "svg", "ƒ", true},
+		{"copilot", "svg", "C", true},
+		{"variable", "svg", "", false},
+		{"class", "svg", "", false},
+		{"class", "emoji", "🏛", true},
+		{"folder", "nerdfont", "", true},
+		{"unknown", "emoji", "", false},
+	}
+	for _, tt := range tests {
+		c := popupMenuConfig{KindIcons: tt.icons, Kinds: kinds}
+		icon, ok := kindTextIcon(tt.kind, c)
+		if icon != tt.icon || ok != tt.ok {
+			t.Errorf("kindTextIcon(%q, %q) = %q, %v, want %q, %v", tt.kind, tt.icons, icon, ok, tt.icon, tt.ok)
+		}
+	}
+}
+
+func TestKindIconSetsCoverKinds(t *testing.T) {
+	for kind := range completionKinds {
+		if _, ok := nerdFontKindIcons[kind]; !ok {
+			t.Errorf("no nerd font icon for %q", kind)
+		}
+		if _, ok := emojiKindIcons[kind]; !ok {
+			t.Errorf("no emoji icon for %q", kind)
+		}
+	}
+}
+
+func TestParseCompleteItemMeta(t *testing.T) {
+	tests := []struct {
+		userData interface{}
+		want     completeItemMeta
+	}{
+		{nil, completeItemMeta{}},
+		{"lsp", completeItemMeta{}},
+		{map[string]interface{}{"source": "lsp"}, completeItemMeta{source: "lsp"}},
+		{map[string]interface{}{"deprecated": true}, completeItemMeta{deprecated: true}},
+		{map[string]interface{}{"source": "buffer", "deprecated": int64(1)}, completeItemMeta{source: "buffer", deprecated: true}},
+		{map[string]interface{}{"deprecated": int64(0)}, completeItemMeta{}},
+	}
+	for _, tt := range tests {
+		if got := parseCompleteItemMeta(tt.userData); got != tt.want {
+			t.Errorf("parseCompleteItemMeta(%v) = %+v, want %+v", tt.userData, got, tt.want)
+		}
+	}
+}
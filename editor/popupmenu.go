@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"math"
-	"sort"
 	"strconv"
 	"strings"
 
@@ -31,11 +30,16 @@ type PopupMenu struct {
 	scrollBar       *widgets.QWidget
 	scrollBarPos    int
 	scrollBarHeight int
+	itemHeight      int
 	scrollCol       *widgets.QWidget
 	detailLabel     *widgets.QLabel
 	x               int
 	y               int
 	hideItemIdx     [2]bool
+	metas           []completeItemMeta
+	colors          pmenuColors
+	alpha           float64
+	doc             *completionDoc
 }
 
 // PopupItem is
@@ -49,6 +53,7 @@ type PopupItem struct {
 	kind     string
 	kindwidget *widgets.QWidget
 	kindIcon *svg.QSvgWidget
+	kindLabel *widgets.QLabel
 
 	menuLabel   *widgets.QLabel
 	menu        string
@@ -58,6 +63,10 @@ type PopupItem struct {
 	info        string
 	infoRequest string
 
+	sourceLabel *widgets.QLabel
+	meta        completeItemMeta
+	metaRequest completeItemMeta
+
 	selected        bool
 	selectedRequest bool
 
@@ -109,6 +118,7 @@ func initPopupmenuNew() *PopupMenu {
 		total:       total,
 		scrollBar:   scrollBar,
 		scrollCol:   scrollCol,
+		alpha:       transparent(),
 	}
 
 	var popupItems []*PopupItem
@@ -120,6 +130,10 @@ func initPopupmenuNew() *PopupMenu {
 		kindIcon := svg.NewQSvgWidget(nil)
 		kindIcon.SetFixedSize2(editor.iconSize, editor.iconSize)
 		iconlayout.AddWidget(kindIcon, 0, 0)
+		kindLabel := widgets.NewQLabel(nil, 0)
+		kindLabel.SetAlignment(core.Qt__AlignCenter)
+		kindLabel.Hide()
+		iconlayout.AddWidget(kindLabel, 0, 0)
 		iconwidget.SetContentsMargins(margin, margin, margin, margin)
 
 		word := widgets.NewQLabel(widget, 0)
@@ -132,18 +146,25 @@ func initPopupmenuNew() *PopupMenu {
 		info := widgets.NewQLabel(widget, 0)
 		info.SetContentsMargins(margin, margin, margin, margin)
 
+		source := widgets.NewQLabel(widget, 0)
+		source.SetContentsMargins(margin, margin, margin, margin)
+		source.SetAlignment(core.Qt__AlignRight | core.Qt__AlignVCenter)
+
 		itemLayout.AddWidget2(iconwidget, i, 0, 0)
 		itemLayout.AddWidget2(word, i, 1, 0)
 		itemLayout.AddWidget2(menu, i, 2, core.Qt__AlignLeft)
 		itemLayout.AddWidget2(info, i, 3, core.Qt__AlignLeft)
+		itemLayout.AddWidget2(source, i, 4, core.Qt__AlignRight)
 
 		popupItem := &PopupItem{
 			p:         popup,
 			kindIcon:  kindIcon,
+			kindLabel: kindLabel,
 			kindwidget:  iconwidget,
 			wordLabel: word,
 			menuLabel: menu,
 			infoLabel: info,
+			sourceLabel: source,
 		}
 		popupItems = append(popupItems, popupItem)
 	}
@@ -161,33 +182,18 @@ func (p *PopupMenu) updateFont(font *Font) {
 		popupItem.wordLabel.SetFont(font.fontNew)
 		popupItem.menuLabel.SetFont(font.fontNew)
 		popupItem.infoLabel.SetFont(font.fontNew)
+		popupItem.sourceLabel.SetFont(font.fontNew)
+		popupItem.kindLabel.SetFont(font.fontNew)
+		popupItem.kindLabel.SetFixedSize2(font.lineHeight, font.lineHeight)
 		popupItem.kindIcon.SetFixedSize2(font.lineHeight, font.lineHeight)
 		popupItem.kindwidget.SetFixedWidth(font.lineHeight+editor.config.Editor.Linespace*2)
 	}
 }
 
 func (p *PopupMenu) setColor() {
-	fg := editor.colors.widgetFg.String()
-	detail := warpColor(editor.colors.widgetFg, 10).String()
-	inactiveFg := editor.colors.inactiveFg.String()
-	bg := editor.colors.widgetBg
-	transparent := transparent()
-	p.scrollBar.SetStyleSheet(fmt.Sprintf("background-color: %s;", inactiveFg))
-	p.widget.SetStyleSheet(
-		fmt.Sprintf(`
-			* { background-color: rgba(%d, %d, %d, %f); color: %s; } 
-			.QLabel { background-color: rgba(0, 0, 0, 0.0); color: %s; }
-			#wordlabel { color: %s; }
-			`,
-			bg.R, bg.G, bg.B, transparent, fg, inactiveFg, fg,
-		),
-	)
-	p.detailLabel.SetStyleSheet(
-		fmt.Sprintf(
-			"* { background-color: rgba(0, 0, 0, 0.0); color: %s; }",
-			detail,
-		),
-	)
+	p.alpha = transparent()
+	p.colors = pmenuColors{}
+	p.updatePmenuColors()
 }
 
 func (p *PopupMenu) setPumblend(arg interface{}) {
@@ -210,27 +216,8 @@ func (p *PopupMenu) setPumblend(arg interface{}) {
 	if alpha < 0 {
 		alpha = 0
 	}
-
-	fg := editor.colors.widgetFg.String()
-	detail := warpColor(editor.colors.widgetFg, 10).String()
-	inactiveFg := editor.colors.inactiveFg.String()
-	bg := editor.colors.widgetBg
-	p.scrollBar.SetStyleSheet(fmt.Sprintf("background-color: %s;", inactiveFg))
-	p.widget.SetStyleSheet(
-		fmt.Sprintf(`
-			* { background-color: rgba(%d, %d, %d, %f); color: %s; } 
-			.QLabel { background-color: rgba(0, 0, 0, 0.0); color: %s; }
-			#wordlabel { color: %s; }
-			`,
-			bg.R, bg.G, bg.B, alpha, fg, inactiveFg, fg,
-		),
-	)
-	p.detailLabel.SetStyleSheet(
-		fmt.Sprintf(
-			"* { background-color: rgba(0, 0, 0, 0.0); color: %s; }",
-			detail,
-		),
-	)
+	p.alpha = alpha
+	p.setStyle()
 }
 
 func (p *PopupMenu) showItems(args []interface{}) {
@@ -244,48 +231,36 @@ func (p *PopupMenu) showItems(args []interface{}) {
 	p.rawItems = items
 	p.selected = selected
 	p.top = 0
+	p.updatePmenuColors()
 
-	x, y, lineHeight, isCursorBelowTheCenter := p.ws.getPointInWidget(col, row, gridid)
+	x, y, lineHeight, _ := p.ws.getPointInWidget(col, row, gridid)
 
 	// Detect vim complete mode
 	completeMode, err := detectVimCompleteMode()
 	if err == nil {
 		p.completeMode = completeMode
 	}
+	p.metas = p.completeItemMetas()
 
 	p.detailLabel.SetText("")
 
 	popupItems := p.items
-	itemHeight := (lineHeight)+(editor.config.Editor.Linespace+4)
-
-	// Calc the maximum completion items
-	//   where,
-	//     `row` is the anchor position, where the first character of the completed word will be
-	//     `p.ws.screen.height` is the entire screen height
-	heightLeft := 0
-	if isCursorBelowTheCenter {
-		heightLeft = row*lineHeight
-	} else {
-		heightLeft = p.ws.screen.height - (row+1)*lineHeight
-	}
-	total := heightLeft / itemHeight
-	if total < p.total {
-		p.showTotal = total
-	} else {
-		p.showTotal = p.total
-	}
+	p.itemHeight = lineHeight + editor.config.Editor.Linespace + 2
 
-	startNum := 0
-	if selected >= len(items) - p.showTotal && len(items) > p.total {
-		startNum = len(items) - p.showTotal
-	}
+	// The menu is put under or over the line, with as many items as fit
+	top, shown, above := pmenuPlace(
+		y, lineHeight, p.itemHeight, p.padding(),
+		len(items), p.total, p.widget.ParentWidget().Height(),
+	)
+	p.showTotal = shown
+	p.top = pmenuTop(selected, shown, len(items))
+	startNum := p.top
 
-	itemNum := 0
 	maxItemLen := 0
 	for i := startNum; i < p.total+startNum; i++ {
 		j := i - startNum
 		popupItem := popupItems[j]
-		if i >= len(items) || j >= total {
+		if i >= len(items) || j >= shown {
 			popupItem.hide()
 			continue
 		}
@@ -296,10 +271,9 @@ func (p *PopupMenu) showItems(args []interface{}) {
 			maxItemLen = itemLen
 		}
 
-		popupItem.setItem(item, selected == i)
+		popupItem.setItem(item, selected == i, p.itemMeta(i))
 		popupItem.hide()
 		popupItem.show()
-		itemNum++
 	}
 
 	switch maxItemLen {
@@ -311,20 +285,18 @@ func (p *PopupMenu) showItems(args []interface{}) {
 		p.hideItemIdx = [2]bool{true, true}
 	}
 
-	if len(items) > p.showTotal {
-		p.scrollBarHeight = int(float64(p.showTotal) / float64(len(items)) * float64(itemHeight*p.showTotal))
-		p.scrollBarPos = 0
-		p.scrollBar.SetFixedHeight(p.scrollBarHeight)
-		p.scrollBar.Move2(0, p.scrollBarPos)
-		p.scrollCol.Show()
-	} else {
-		p.scrollCol.Hide()
-	}
+	p.updateScrollBar()
 
 	p.hide()
 	p.show()
 	p.setWidgetWidth()
-	p.moveWidget(x, y, lineHeight, isCursorBelowTheCenter, itemNum)
+	p.placeWidget(x, top, above)
+	if selected >= 0 && selected < len(items) {
+		if item, ok := items[selected].([]interface{}); ok && len(item) > 3 {
+			info, _ := item[3].(string)
+			p.doc.show(info)
+		}
+	}
 }
 
 func (p *PopupMenu) detectItemLen(item []interface{}) int {
@@ -345,6 +317,7 @@ func (p *PopupMenu) show() {
 
 func (p *PopupMenu) setWidgetWidth() {
 	maxWordLabelLen := 0
+	maxSourceLabelLen := 0
 	isMenuHidden := p.hideItemIdx[0]
 	isInfoHidden := p.hideItemIdx[1]
 	for _, item := range p.items {
@@ -362,10 +335,16 @@ func (p *PopupMenu) setWidgetWidth() {
 			item.infoLabel.Show()
 		}
 
-		wordLabelLen := int(math.Ceil(p.ws.font.fontMetrics.HorizontalAdvance(item.wordLabel.Text(), -1)))
+		wordLabelLen := int(math.Ceil(p.ws.font.fontMetrics.HorizontalAdvance(item.word, -1)))
 		if wordLabelLen > maxWordLabelLen {
 			maxWordLabelLen = wordLabelLen
 		}
+		if item.meta.source != "" {
+			sourceLabelLen := int(math.Ceil(p.ws.font.fontMetrics.HorizontalAdvance(item.meta.source, -1)))
+			if sourceLabelLen > maxSourceLabelLen {
+				maxSourceLabelLen = sourceLabelLen
+			}
+		}
 	}
 	if isMenuHidden && isInfoHidden {
 		p.detailLabel.Hide()
@@ -390,34 +369,19 @@ func (p *PopupMenu) setWidgetWidth() {
 
 	margin := editor.config.Editor.Linespace/2 + 2
 
-	p.widget.SetFixedWidth(
-		editor.iconSize*2 + maxWordLabelLen + menuWidth + infoWidth + detailWidth + 5 + margin*4 + editor.iconSize/5*4,
-	)
-}
-
-func (p *PopupMenu) moveWidget(x int, y int, lineHeight int, isCursorBelowTheCenter bool, itemNum int) {
-	popupWidth := p.widget.Width()
-
-	// x, y, lineHeight, isCursorBelowTheCenter := p.ws.getPointInWidget(col, row, gridid)
-	y += lineHeight
-
-	if x+popupWidth >= p.ws.screen.widget.Width() {
-		x = p.ws.screen.widget.Width() - popupWidth - 5
-	}
-
-	popupHeight := itemNum*(lineHeight+editor.config.Editor.Linespace+2) + 2 + editor.iconSize*2/5
-	p.widget.SetFixedHeight(popupHeight)
-
-	if isCursorBelowTheCenter {
-		y = y - (lineHeight + popupHeight)
-		p.widget.SetGraphicsEffect(util.DropShadow(-2, -6, 40, 200))
+	sourceWidth := 0
+	if maxSourceLabelLen > 0 {
+		sourceWidth = maxSourceLabelLen + margin*2
 	}
 
-	p.widget.Move2(x, y)
+	p.widget.SetFixedWidth(
+		editor.iconSize*2 + maxWordLabelLen + menuWidth + infoWidth + sourceWidth + detailWidth + 5 + margin*4 + editor.iconSize/5*4,
+	)
 }
 
 func (p *PopupMenu) hide() {
 	p.widget.Hide()
+	p.doc.hide()
 }
 
 func (p *PopupMenu) selectItem(args []interface{}) {
@@ -431,7 +395,7 @@ func (p *PopupMenu) selectItem(args []interface{}) {
 	}
 
 	if selected >= 0 && selected-p.top < 0 {
-		p.scroll(-1)
+		p.scroll(selected - p.top)
 	}
 
 	isMenuHidden := p.hideItemIdx[0]
@@ -442,7 +406,10 @@ func (p *PopupMenu) selectItem(args []interface{}) {
 
 		popupItem.setSelected(isSelected)
 		if isSelected {
-			if editor.config.Popupmenu.ShowDetail {
+			p.doc.show(popupItem.infoRequest)
+			if p.doc.enabled() {
+				popupItem.p.detailLabel.Hide()
+			} else if editor.config.Popupmenu.ShowDetail {
 				if !(isMenuHidden && isInfoHidden) {
 					popupItem.p.detailLabel.SetText(popupItem.detailText)
 					popupItem.p.detailLabel.Show()
@@ -466,7 +433,7 @@ func (p *PopupMenu) scroll(n int) {
 		if itemLen > maxItemLen {
 			maxItemLen = itemLen
 		}
-		popupItem.setItem(item, false)
+		popupItem.setItem(item, false, p.itemMeta(i+p.top))
 	}
 
 	switch maxItemLen {
@@ -478,8 +445,7 @@ func (p *PopupMenu) scroll(n int) {
 		p.hideItemIdx = [2]bool{true, true}
 	}
 
-	p.scrollBarPos = int((float64(p.top) / float64(len(items))) * float64(p.widget.Height()))
-	p.scrollBar.Move2(0, p.scrollBarPos)
+	p.updateScrollBar()
 	p.hide()
 	p.show()
 	p.setWidgetWidth()
@@ -488,23 +454,26 @@ func (p *PopupMenu) scroll(n int) {
 func (p *PopupItem) updateContent() {
 	if p.selected != p.selectedRequest {
 		p.selected = p.selectedRequest
-		if p.selected {
-			p.kindwidget.SetStyleSheet(fmt.Sprintf("background-color: %s;", editor.colors.selectedBg.StringTransparent()))
-			p.wordLabel.SetStyleSheet(fmt.Sprintf("background-color: %s;", editor.colors.selectedBg.StringTransparent()))
-			p.menuLabel.SetStyleSheet(fmt.Sprintf("background-color: %s;", editor.colors.selectedBg.StringTransparent()))
-			p.infoLabel.SetStyleSheet(fmt.Sprintf("background-color: %s;", editor.colors.selectedBg.StringTransparent()))
-		} else {
-			p.kindwidget.SetStyleSheet("background-color: rgba(0, 0, 0, 0);")
-			p.wordLabel.SetStyleSheet("background-color: rgba(0, 0, 0, 0);")
-			p.menuLabel.SetStyleSheet("background-color: rgba(0, 0, 0, 0);")
-			p.infoLabel.SetStyleSheet("background-color: rgba(0, 0, 0, 0);")
-		}
-	}
-	if p.wordRequest != p.word {
+		colors := p.p.colors
+		p.kindwidget.SetStyleSheet(colors.itemStyle(p.selected, false))
+		p.wordLabel.SetStyleSheet(colors.itemStyle(p.selected, false))
+		p.menuLabel.SetStyleSheet(colors.itemStyle(p.selected, true))
+		p.infoLabel.SetStyleSheet(colors.itemStyle(p.selected, true))
+		p.sourceLabel.SetStyleSheet(colors.itemStyle(p.selected, true))
+	}
+	if p.wordRequest != p.word || p.metaRequest != p.meta {
 		p.word = p.wordRequest
 		p.menu = p.menuRequest
 		p.info = p.infoRequest
-		p.wordLabel.SetText(p.word)
+		p.meta = p.metaRequest
+		if p.meta.deprecated {
+			p.wordLabel.SetTextFormat(core.Qt__RichText)
+			p.wordLabel.SetText(deprecatedText(p.word))
+		} else {
+			p.wordLabel.SetTextFormat(core.Qt__PlainText)
+			p.wordLabel.SetText(p.word)
+		}
+		p.sourceLabel.SetText(p.meta.source)
 
 		menuLines := strings.Split(p.menuRequest, "\n")
 		infoLines := strings.Split(p.infoRequest, "\n")
@@ -531,7 +500,7 @@ func (p *PopupItem) setSelected(selected bool) {
 	p.updateContent()
 }
 
-func (p *PopupItem) setItem(item []interface{}, selected bool) {
+func (p *PopupItem) setItem(item []interface{}, selected bool, meta completeItemMeta) {
 	word := item[0].(string)
 	kind := item[1].(string)
 	menu := item[2].(string)
@@ -540,6 +509,7 @@ func (p *PopupItem) setItem(item []interface{}, selected bool) {
 	p.wordRequest = word
 	p.menuRequest = menu
 	p.infoRequest = info
+	p.metaRequest = meta
 	p.setKind(kind, selected)
 	p.setSelected(selected)
 }
@@ -568,77 +538,26 @@ func detectVimCompleteMode() (string, error) {
 }
 
 func (p *PopupItem) setKind(kind string, selected bool) {
-	var colorOfFunc, colorOfStatement, colorOfType, colorOfKeyword *RGBA
-
-	hiAttrDef := editor.workspaces[editor.active].screen.hlAttrDef
-	var keys []int
-	for k := range hiAttrDef {
-		keys = append(keys, k)
-	}
-	sort.Ints(keys)
-	for _, k := range keys {
-		hi := hiAttrDef[k]
-		switch hi.hlName {
-		case "Function":
-			colorOfFunc = hi.fg()
-		case "Statement":
-			colorOfStatement = hi.fg()
-		case "Type":
-			colorOfType = hi.fg()
-		case "String":
-			colorOfKeyword = hi.fg()
-		default:
-			continue
+	formattedKind := normalizeKind(kind)
+	color := kindColor(formattedKind)
+
+	if text, ok := kindTextIcon(formattedKind, editor.config.Popupmenu); ok {
+		p.kindIcon.Hide()
+		if color == nil {
+			color = editor.colors.fg
 		}
+		p.kindLabel.SetStyleSheet(fmt.Sprintf("background-color: rgba(0, 0, 0, 0); color: %s;", color.String()))
+		p.kindLabel.SetText(text)
+		p.kindLabel.Show()
+		return
 	}
-
-	formattedKind := normalizeKind(kind)
+	p.kindLabel.Hide()
+	p.kindIcon.Show()
 
 	icon := ""
-	switch formattedKind {
-	case "text" :
-		icon = editor.getSvg("lsp_"+formattedKind, colorOfKeyword)
-	case "method", "function", "constructor" :
-		icon = editor.getSvg("lsp_function", colorOfFunc)
-	case "field", "variable", "property" :
-		icon = editor.getSvg("lsp_variable", colorOfFunc)
-	case "class" :
-		icon = editor.getSvg("lsp_"+formattedKind, colorOfFunc)
-	case "interface" :
-		icon = editor.getSvg("lsp_"+formattedKind, colorOfFunc)
-	case "module" :
-		icon = editor.getSvg("lsp_"+formattedKind, colorOfKeyword)
-	case "unit" :
-		icon = editor.getSvg("lsp_"+formattedKind, colorOfStatement)
-	case "value" :
-		icon = editor.getSvg("lsp_"+formattedKind, colorOfKeyword)
-	case "enum" :
-		icon = editor.getSvg("lsp_"+formattedKind, colorOfType)
-	case "keyword" :
-		icon = editor.getSvg("lsp_"+formattedKind, colorOfKeyword)
-	case "snippet" :
-		icon = editor.getSvg("lsp_"+formattedKind, colorOfKeyword)
-	case "color" :
-		icon = editor.getSvg("lsp_"+formattedKind, colorOfKeyword)
-	case "file" :
-		icon = editor.getSvg("lsp_"+formattedKind, colorOfType)
-	case "reference" :
-		icon = editor.getSvg("lsp_"+formattedKind, colorOfType)
-	case "folder" :
-		icon = editor.getSvg("lsp_"+formattedKind, colorOfType)
-	case "enummember" :
-		icon = editor.getSvg("lsp_"+formattedKind, colorOfKeyword)
-	case "constant" :
-		icon = editor.getSvg("lsp_"+formattedKind, colorOfKeyword)
-	case "struct" :
-		icon = editor.getSvg("lsp_"+formattedKind, colorOfStatement)
-	case "event" :
-		icon = editor.getSvg("lsp_"+formattedKind, colorOfStatement)
-	case "operato" :
-		icon = editor.getSvg("lsp_"+formattedKind, colorOfStatement)
-	case "typeparameter" :
-		icon = editor.getSvg("lsp_"+formattedKind, colorOfType)
-	default:
+	if k, ok := completionKinds[formattedKind]; ok {
+		icon = editor.getSvg(k.svg, color)
+	} else {
 		iconColor := warpColor(editor.colors.fg, -45)
 		switch p.p.completeMode {
 		case "keyword",
@@ -655,14 +574,11 @@ func (p *PopupItem) setKind(kind string, selected bool) {
 			"spell",
 			"eval":
 			icon = editor.getSvg("vim_"+p.p.completeMode, iconColor)
-			p.kindIcon.Load2(core.NewQByteArray2(icon, len(icon)))
 		default:
 			icon = editor.getSvg("vim_unknown", iconColor)
-			p.kindIcon.Load2(core.NewQByteArray2(icon, len(icon)))
 		}
 	}
 	p.kindIcon.Load2(core.NewQByteArray2(icon, len(icon)))
-
 }
 
 func normalizeKind(kind string) string {
@@ -745,6 +661,7 @@ func (p *PopupItem) hide() {
 	p.wordLabel.Hide()
 	p.menuLabel.Hide()
 	p.infoLabel.Hide()
+	p.sourceLabel.Hide()
 }
 
 func (p *PopupItem) show() {
@@ -756,4 +673,5 @@ func (p *PopupItem) show() {
 	p.wordLabel.Show()
 	p.menuLabel.Show()
 	p.infoLabel.Show()
+	p.sourceLabel.Show()
 }
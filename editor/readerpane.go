@@ -0,0 +1,170 @@
+package editor
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+// readerPane is the read-only pane of the rendered buffer, which the help
+// reader and the reading mode show. It covers a window of the grid, the one
+// of winid, or else the one of the buffer of bufName, and follows it as the
+// windows are resized. The buffer keeps the keyboard.
+type readerPane struct {
+	ws     *Workspace
+	widget *widgets.QWidget
+	text   *widgets.QTextBrowser
+	name   string
+	// winid is the window covered by the pane, 0 for the one of bufName
+	winid   int
+	bufName string
+	// seq is the count of the fetches, which drops the stale lines
+	seq int
+}
+
+func newReaderPane(ws *Workspace, name string) *readerPane {
+	p := &readerPane{
+		ws:   ws,
+		name: name,
+	}
+
+	widget := widgets.NewQWidget(ws.screen.widget, 0)
+	widget.SetObjectName(name)
+	widget.SetAttribute(core.Qt__WA_StyledBackground, true)
+	layout := widgets.NewQVBoxLayout()
+	layout.SetContentsMargins(0, 0, 0, 0)
+	widget.SetLayout(layout)
+
+	text := widgets.NewQTextBrowser(nil)
+	text.SetFocusPolicy(core.Qt__NoFocus)
+	text.SetFrameShape(widgets.QFrame__NoFrame)
+	layout.AddWidget(text, 1, 0)
+	widget.Hide()
+
+	p.widget = widget
+	p.text = text
+
+	return p
+}
+
+// fetch gets the lines of the buffer off the GUI thread, and sends them by
+// the update of the name with the buffer, which fetched takes
+func (p *readerPane) fetch(update string, buf int) {
+	p.seq++
+	seq := p.seq
+	neovim := p.ws.nvim
+	go func() {
+		lines, err := neovim.BufferLines(nvim.Buffer(buf), 0, -1, false)
+		if err != nil {
+			return
+		}
+		text := make([]string, len(lines))
+		for i, l := range lines {
+			text[i] = string(l)
+		}
+		p.ws.guiUpdates <- []interface{}{update, buf, seq, text}
+		p.ws.signal.GuiSignal()
+	}()
+}
+
+// fetched returns the buffer and the lines of the update of fetch, and
+// false if another fetch followed it
+func (p *readerPane) fetched(args []interface{}) (int, []string, bool) {
+	if len(args) < 3 {
+		return 0, nil, false
+	}
+	buf, _ := args[0].(int)
+	seq, _ := args[1].(int)
+	lines, _ := args[2].([]string)
+	if seq != p.seq {
+		return 0, nil, false
+	}
+
+	return buf, lines, true
+}
+
+// render sets the html, keeping the scroll position if keepScroll
+func (p *readerPane) render(body string, keepScroll bool) {
+	scroll := p.text.VerticalScrollBar().Value()
+	p.text.SetHtml(body)
+	if keepScroll {
+		p.text.VerticalScrollBar().SetValue(scroll)
+	}
+}
+
+// updatePos puts the pane over its window, and hides it if the window is
+// not shown
+func (p *readerPane) updatePos() {
+	if p.winid == 0 && p.bufName == "" {
+		return
+	}
+	var target *Window
+	p.ws.screen.windows.Range(func(_, winITF interface{}) bool {
+		win := winITF.(*Window)
+		if win == nil || win.isFloatWin || win.isMsgGrid || !win.isShown() {
+			return true
+		}
+		if (p.winid != 0 && int(win.id) == p.winid) || (p.winid == 0 && filepath.Base(win.bufName) == p.bufName) {
+			target = win
+			return false
+		}
+		return true
+	})
+	if target == nil {
+		p.widget.Hide()
+		return
+	}
+	font := p.ws.screen.font
+	x := int(float64(target.pos[0]) * font.truewidth)
+	y := target.pos[1] * font.lineHeight
+	width := int(float64(target.cols) * target.getFont().truewidth)
+	height := target.rows * target.getFont().lineHeight
+	geometry := p.widget.Geometry()
+	if geometry.X() != x || geometry.Y() != y || geometry.Width() != width || geometry.Height() != height {
+		p.widget.SetGeometry2(x, y, width, height)
+	}
+	if !p.widget.IsVisible() {
+		p.widget.Show()
+		p.widget.Raise()
+	}
+}
+
+func (p *readerPane) hide() {
+	p.winid = 0
+	p.bufName = ""
+	p.seq++
+	p.widget.Hide()
+}
+
+// setStyle sets the colors of the pane, and the style sheet of the document
+// in the font family, followed by the rules of css
+func (p *readerPane) setStyle(family string, css string) {
+	fg := editor.colors.widgetFg
+	bg := editor.colors.widgetBg
+	if fg == nil || bg == nil || editor.colors.inactiveFg == nil || editor.colors.selectedBg == nil {
+		return
+	}
+	if family == "" {
+		family = "sans-serif"
+	}
+	p.widget.SetStyleSheet(withUserStyle(fmt.Sprintf(
+		"QWidget#%s { border-left: 1px solid %s; background-color: %s; } * { color: %s; background-color: %s; }",
+		p.name,
+		editor.colors.inactiveFg.String(),
+		bg.String(),
+		fg.String(),
+		bg.String(),
+	)))
+	p.text.Document().SetDefaultStyleSheet(fmt.Sprintf(`
+		body { font-family: "%s"; }
+		pre, code { font-family: "%s"; }
+		a { color: %s; text-decoration: none; }
+		`,
+		family,
+		p.ws.font.family,
+		editor.colors.selectedBg.String(),
+	) + css)
+}
@@ -0,0 +1,341 @@
+package editor
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/shurcooL/github_flavored_markdown"
+	"github.com/therecipe/qt/core"
+)
+
+// readingPaneBufName is the name of the buffer of the window beside the
+// buffer in the reading mode, which the pane covers in the "side" view
+const readingPaneBufName = "__GonvimReading__"
+
+// readingRenderDelay is the wait after the last change of the buffer before
+// the pane renders it again
+const readingRenderDelay = 300 * time.Millisecond
+
+// readingModeAutoCmds notify the buffer entered, or 0 if its reading mode is
+// off, the cursor line and the changes of the buffers in the reading mode.
+// The window of the pane itself is skipped.
+// args: [buf, winid, filetype, line, changed]
+const readingModeAutoCmds = `
+	aug GonvimAuReading | au! | aug END
+	au GonvimAuReading BufEnter,WinEnter * if !get(b:, "gonvim_reading_pane", 0) | call rpcnotify(0, "Gui", "gonvim_reading", get(b:, "gonvim_reading", 0) ? bufnr() : 0, win_getid(), &filetype, line("."), 0) | endif
+	au GonvimAuReading CursorMoved,CursorMovedI,WinScrolled * if get(b:, "gonvim_reading", 0) | call rpcnotify(0, "Gui", "gonvim_reading", bufnr(), win_getid(), &filetype, line("."), 0) | endif
+	au GonvimAuReading TextChanged,TextChangedI * if get(b:, "gonvim_reading", 0) | call rpcnotify(0, "Gui", "gonvim_reading", bufnr(), win_getid(), &filetype, line("."), 1) | endif
+	`
+
+// readingModeCommands toggle the reading mode of the current buffer.
+// GonvimReadingPane opens the window of the pane beside the current window,
+// or closes it.
+const readingModeCommands = `
+	command! GonvimReadingMode let b:gonvim_reading = !get(b:, "gonvim_reading", 0) | call rpcnotify(0, "Gui", "gonvim_reading", b:gonvim_reading ? bufnr() : 0, win_getid(), &filetype, line("."), 1)
+	function! GonvimReadingPane(show) abort
+		let l:win = bufwinid("` + readingPaneBufName + `")
+		if !a:show
+			if l:win != -1 && winnr("$") > 1
+				call nvim_win_close(l:win, v:true)
+			endif
+			return
+		endif
+		if l:win != -1
+			return
+		endif
+		let l:cur = win_getid()
+		noautocmd rightbelow vnew
+		setlocal buftype=nofile bufhidden=wipe noswapfile nobuflisted nonumber norelativenumber nolist nocursorline signcolumn=no foldcolumn=0 winfixwidth
+		silent file ` + readingPaneBufName + `
+		let b:gonvim_reading_pane = 1
+		noautocmd call win_gotoid(l:cur)
+	endfunction
+	`
+
+// readingBlock is a block of the buffer rendered at once, from its line
+type readingBlock struct {
+	line int
+	text string
+}
+
+// isReadableFiletype returns true for markdown and the prose filetypes,
+// which can be read in the reading mode
+func isReadableFiletype(filetype string) bool {
+	if filetype == "markdown" {
+		return true
+	}
+	for _, prose := range editor.config.Statusline.ProseFiletypes {
+		if filetype == prose {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readingBlocks splits the lines into the blocks separated by the blank
+// lines. The fenced code blocks of markdown are not split, and the indented
+// lines after the blank lines stay in the block before them, as the
+// continuations of the list items or the indented code.
+func readingBlocks(lines []string, markdown bool) []readingBlock {
+	var blocks []readingBlock
+	var block []string
+	start := 0
+	blank := 0
+	fence := ""
+	flush := func() {
+		if len(block) > 0 {
+			blocks = append(blocks, readingBlock{line: start, text: strings.Join(block, "\n")})
+			block = nil
+		}
+	}
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if markdown {
+			marker := ""
+			switch {
+			case strings.HasPrefix(trimmed, "```"):
+				marker = "```"
+			case strings.HasPrefix(trimmed, "~~~"):
+				marker = "~~~"
+			}
+			if marker != "" && (fence == "" || fence == marker) {
+				if fence == "" {
+					fence = marker
+				} else {
+					fence = ""
+				}
+			}
+		}
+		if trimmed == "" && fence == "" {
+			if len(block) > 0 {
+				blank++
+			}
+			continue
+		}
+		if blank > 0 && !(markdown && isIndentedLine(line)) {
+			flush()
+		}
+		for ; blank > 0 && len(block) > 0; blank-- {
+			block = append(block, "")
+		}
+		blank = 0
+		if len(block) == 0 {
+			start = i + 1
+		}
+		block = append(block, line)
+	}
+	flush()
+
+	return blocks
+}
+
+func isIndentedLine(line string) bool {
+	return strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")
+}
+
+// readingMarker matches the markers of the headings, the quotes, the list
+// items and the task list items, after which the anchors are put
+var readingMarker = regexp.MustCompile(`^ {0,3}(#{1,6}[ \t]+|>[ \t]?|([-*+]|[0-9]+[.)])[ \t]+(\[[ xX]\][ \t]+)?)`)
+
+// readingMarkdown joins the blocks of markdown into a document, with the
+// anchor "L" + the number of the first line of each block. The anchor is
+// put in the text of the first line, or in a block of its own before the
+// code blocks, the tables and the html, whose text would show it.
+func readingMarkdown(blocks []readingBlock) string {
+	var b strings.Builder
+	for _, block := range blocks {
+		anchor := fmt.Sprintf("<a name=\"L%d\"></a>", block.line)
+		trimmed := strings.TrimSpace(block.text)
+		switch {
+		case strings.HasPrefix(trimmed, "```"), strings.HasPrefix(trimmed, "~~~"),
+			strings.HasPrefix(trimmed, "|"), strings.HasPrefix(trimmed, "<"),
+			isIndentedLine(block.text):
+			b.WriteString("<div>" + anchor + "</div>\n\n" + block.text)
+		default:
+			n := len(readingMarker.FindString(block.text))
+			b.WriteString(block.text[:n] + anchor + block.text[n:])
+		}
+		b.WriteString("\n\n")
+	}
+
+	return b.String()
+}
+
+// readingHTML renders the lines of markdown at once by the markdown renderer
+// of the preview, and the other text as the paragraphs. Each block has the
+// anchor "L" + the number of its first line.
+func readingHTML(blocks []readingBlock, markdown bool) string {
+	if markdown {
+		return string(github_flavored_markdown.Markdown([]byte(readingMarkdown(blocks))))
+	}
+	var b strings.Builder
+	for _, block := range blocks {
+		b.WriteString(fmt.Sprintf("<a name=\"L%d\"></a>", block.line))
+		text := strings.Join(strings.Fields(block.text), " ")
+		b.WriteString("<p>" + html.EscapeString(text) + "</p>\n")
+	}
+
+	return b.String()
+}
+
+// readingAnchor returns the anchor of the block of the line
+func readingAnchor(blocks []readingBlock, line int) string {
+	anchor := 0
+	for _, block := range blocks {
+		if block.line > line {
+			break
+		}
+		anchor = block.line
+	}
+	if anchor == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("L%d", anchor)
+}
+
+// readingMode renders the markdown and the prose buffers typographically, in
+// the proportional font with the sized headings and the markup concealed,
+// in a read-only pane over the window of the pane beside the buffer, or
+// over the window of the buffer. The buffer keeps the keyboard, and the
+// pane follows its cursor line and its changes. It is toggled per buffer by
+// :GonvimReadingMode.
+type readingMode struct {
+	ws   *Workspace
+	pane *readerPane
+	// buf is the buffer rendered in the pane, 0 if none
+	buf         int
+	markdown    bool
+	line        int
+	blocks      []readingBlock
+	renderTimer *core.QTimer
+}
+
+func newReadingMode(ws *Workspace) *readingMode {
+	r := &readingMode{
+		ws:   ws,
+		pane: newReaderPane(ws, "readingmode"),
+	}
+	r.pane.text.SetOpenExternalLinks(true)
+
+	r.renderTimer = core.NewQTimer(nil)
+	r.renderTimer.SetSingleShot(true)
+	r.renderTimer.ConnectTimeout(func() {
+		if r.buf != 0 {
+			r.pane.fetch("gonvim_reading_lines", r.buf)
+		}
+	})
+
+	return r
+}
+
+// update is called by the gonvim_reading notification. The buffer is
+// fetched again if it is another one, or after the changes settle.
+func (r *readingMode) update(args []interface{}) {
+	if len(args) < 5 {
+		return
+	}
+	buf := util.ReflectToInt(args[0])
+	winid := util.ReflectToInt(args[1])
+	filetype, _ := args[2].(string)
+	line := util.ReflectToInt(args[3])
+	changed := util.ReflectToInt(args[4]) != 0
+	if buf == 0 {
+		r.hide()
+		return
+	}
+	if !isReadableFiletype(filetype) {
+		if changed {
+			editor.pushNotification(NotifyWarn, -1, fmt.Sprintf("[Goneovim] The reading mode is not supported for the filetype %q", filetype))
+		}
+		r.hide()
+		return
+	}
+	r.line = line
+	if editor.config.ReadingMode.View == "replace" {
+		r.pane.winid = winid
+	} else if buf != r.buf {
+		r.pane.bufName = readingPaneBufName
+		go r.ws.nvim.Command("call GonvimReadingPane(1)")
+	}
+	switch {
+	case buf != r.buf:
+		r.buf = buf
+		r.markdown = filetype == "markdown"
+		r.blocks = nil
+		r.renderTimer.Stop()
+		r.pane.fetch("gonvim_reading_lines", buf)
+	case changed:
+		r.renderTimer.Start(int(readingRenderDelay / time.Millisecond))
+	}
+	r.pane.updatePos()
+	r.scroll()
+}
+
+// rendered renders the lines fetched for the pane
+func (r *readingMode) rendered(args []interface{}) {
+	buf, lines, ok := r.pane.fetched(args)
+	if !ok || buf != r.buf {
+		return
+	}
+	// The changes keep the scroll position, and another buffer is shown
+	// from its cursor line
+	keepScroll := r.blocks != nil
+	r.blocks = readingBlocks(lines, r.markdown)
+	r.setStyle()
+	r.pane.render(readingHTML(r.blocks, r.markdown), keepScroll)
+	r.pane.updatePos()
+	r.scroll()
+}
+
+func (r *readingMode) scroll() {
+	if anchor := readingAnchor(r.blocks, r.line); anchor != "" {
+		r.pane.text.ScrollToAnchor(anchor)
+	}
+}
+
+// updatePos follows the window covered by the pane
+func (r *readingMode) updatePos() {
+	if r.buf != 0 {
+		r.pane.updatePos()
+	}
+}
+
+func (r *readingMode) hide() {
+	if r.buf == 0 {
+		return
+	}
+	r.buf = 0
+	r.blocks = nil
+	r.renderTimer.Stop()
+	if r.pane.bufName != "" {
+		go r.ws.nvim.Command("call GonvimReadingPane(0)")
+	}
+	r.pane.hide()
+}
+
+func (r *readingMode) setStyle() {
+	bg := editor.colors.widgetBg
+	if bg == nil || editor.colors.inactiveFg == nil {
+		return
+	}
+	r.pane.text.Document().SetDocumentMargin(float64(editor.iconSize))
+	r.pane.setStyle(editor.config.ReadingMode.FontFamily, fmt.Sprintf(`
+		body { font-size: %dpt; }
+		h1 { font-size: xx-large; }
+		h2 { font-size: x-large; margin-top: 16px; }
+		h3 { font-size: large; }
+		p, li { line-height: 140%%; }
+		pre { background-color: %s; }
+		blockquote { color: %s; }
+		`,
+		editor.extFontSize+2,
+		warpColor(bg, 10).String(),
+		editor.colors.inactiveFg.String(),
+	))
+}
@@ -0,0 +1,100 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadingBlocks(t *testing.T) {
+	lines := []string{
+		"# Title",
+		"",
+		"The first",
+		"paragraph.",
+		"",
+		"",
+		"```go",
+		"a := 1",
+		"",
+		"b := 2",
+		"```",
+		"",
+		"~~~",
+		"```",
+		"~~~",
+		"The end.",
+	}
+	got := readingBlocks(lines, true)
+	want := []readingBlock{
+		{1, "# Title"},
+		{3, "The first\nparagraph."},
+		{7, "```go\na := 1\n\nb := 2\n```"},
+		{13, "~~~\n```\n~~~\nThe end."},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readingBlocks() = %q, want %q", got, want)
+	}
+
+	// The indented lines continue the block before them
+	got = readingBlocks([]string{"- item", "", "    more", "", "", "next"}, true)
+	want = []readingBlock{{1, "- item\n\n    more"}, {6, "next"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readingBlocks() of the list = %q, want %q", got, want)
+	}
+
+	// The fences are text in the other filetypes
+	got = readingBlocks([]string{"```", "", "a"}, false)
+	want = []readingBlock{{1, "```"}, {3, "a"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readingBlocks() of the text = %q, want %q", got, want)
+	}
+}
+
+func TestReadingHTML(t *testing.T) {
+	blocks := []readingBlock{{1, "The  first\nline & <b>"}, {4, "second"}}
+	got := readingHTML(blocks, false)
+	want := "<a name=\"L1\"></a><p>The first line &amp; &lt;b&gt;</p>\n<a name=\"L4\"></a><p>second</p>\n"
+	if got != want {
+		t.Errorf("readingHTML() = %q, want %q", got, want)
+	}
+}
+
+func TestReadingMarkdown(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"a\nb", "<a name=\"L1\"></a>a\nb\n\n"},
+		{"## Title ##", "## <a name=\"L1\"></a>Title ##\n\n"},
+		{"- [ ] task", "- [ ] <a name=\"L1\"></a>task\n\n"},
+		{"1. item", "1. <a name=\"L1\"></a>item\n\n"},
+		{"> quote", "> <a name=\"L1\"></a>quote\n\n"},
+		{"```go\na\n```", "<div><a name=\"L1\"></a></div>\n\n```go\na\n```\n\n"},
+		{"| a | b |", "<div><a name=\"L1\"></a></div>\n\n| a | b |\n\n"},
+		{"    code", "<div><a name=\"L1\"></a></div>\n\n    code\n\n"},
+	}
+	for _, tt := range tests {
+		if got := readingMarkdown([]readingBlock{{1, tt.text}}); got != tt.want {
+			t.Errorf("readingMarkdown(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestReadingAnchor(t *testing.T) {
+	blocks := []readingBlock{{3, ""}, {7, ""}, {12, ""}}
+	tests := []struct {
+		line int
+		want string
+	}{
+		{1, ""},
+		{3, "L3"},
+		{6, "L3"},
+		{7, "L7"},
+		{100, "L12"},
+	}
+	for _, tt := range tests {
+		if got := readingAnchor(blocks, tt.line); got != tt.want {
+			t.Errorf("readingAnchor(%d) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package editor
+
+import (
+	"github.com/akiyosi/goneovim/util"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+)
+
+// readOnlyTintAlpha is the alpha of the tint over the background of the
+// read-only windows, which is light enough to keep the text readable
+const readOnlyTintAlpha = 16
+
+// updateReadOnly is called by the gonvim_readonly notification, which is sent
+// when the current window, or 'readonly' or 'modifiable' of its buffer
+// changes. Only the normal buffers are reported as locked, so that the
+// terminal and the plugin buffers are not marked.
+// args: [winid, tabpage, locked]
+func (w *Workspace) updateReadOnly(args []interface{}) {
+	if len(args) < 3 {
+		return
+	}
+	id := util.ReflectToInt(args[0])
+	tabID := util.ReflectToInt(args[1])
+	locked := util.ReflectToInt(args[2]) != 0
+
+	w.screen.windows.Range(func(_, winITF interface{}) bool {
+		win := winITF.(*Window)
+		if win == nil || int(win.id) != id {
+			return true
+		}
+		if win.readOnly != locked {
+			win.readOnly = locked
+			win.update()
+		}
+		return false
+	})
+
+	// The notification is sent from the current window
+	if w.cursor.readOnly != locked {
+		w.cursor.readOnly = locked
+		w.cursor.setBlink()
+	}
+
+	if w.tabline == nil {
+		return
+	}
+	for _, tab := range w.tabline.Tabs {
+		if tab.ID == tabID {
+			tab.setReadOnly(locked)
+			break
+		}
+	}
+}
+
+// drawReadOnlyTint tints the background of the row of the read-only window
+func (w *Window) drawReadOnlyTint(p *gui.QPainter, y int) {
+	if !w.readOnly || editor.config.ReadOnly.Tint == "" {
+		return
+	}
+	if w.isMsgGrid || w.grid == 1 {
+		return
+	}
+	font := w.getFont()
+	color := hexToRGBA(editor.config.ReadOnly.Tint)
+	p.FillRect4(
+		core.NewQRectF4(
+			0,
+			float64(y*font.lineHeight)+float64(w.scrollDust[1]),
+			float64(w.cols)*font.truewidth,
+			float64(font.lineHeight),
+		),
+		gui.NewQColor3(color.R, color.G, color.B, readOnlyTintAlpha),
+	)
+}
+
+// setReadOnly shows the padlock badge in the tab whose current buffer is locked
+func (t *Tab) setReadOnly(locked bool) {
+	if t.readOnly == locked {
+		return
+	}
+	t.readOnly = locked
+	if locked && editor.config.ReadOnly.Badge {
+		t.lockIcon.Show()
+	} else {
+		t.lockIcon.Hide()
+	}
+	t.updateSize()
+}
@@ -0,0 +1,375 @@
+package editor
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/akiyosi/goneovim/filer"
+	"github.com/akiyosi/goneovim/fuzzy"
+	"github.com/akiyosi/goneovim/util"
+	"github.com/neovim/go-client/nvim"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+const (
+	// reconnectInitialDelay is the wait before the first reconnection
+	reconnectInitialDelay = 500 * time.Millisecond
+	// reconnectMaxDelay is the longest wait between the reconnections
+	reconnectMaxDelay = 30 * time.Second
+)
+
+const (
+	connectionConnected = iota
+	connectionReconnecting
+	connectionLost
+)
+
+// reconnectAutoCmds notify that neovim is quitting, so that the connection
+// closed by :qa isn't taken for a dropped one
+const reconnectAutoCmds = `
+	aug GonvimAuReconnect | au! | aug END
+	au GonvimAuReconnect VimLeavePre * call rpcnotify(0, "Gui", "gonvim_leave")
+	`
+
+// connectionMonitor reconnects the remote attachments (--server, and --ssh)
+// when the connection drops. The drop is detected by the end of the
+// connection, or by the heartbeat which neovim doesn't answer in time on the
+// half-open connection. The keys typed in the first moments after the drop
+// are held and sent after the reconnection, and the state of the connection
+// is shown in the indicator instead of the frozen screen.
+type connectionMonitor struct {
+	ws        *Workspace
+	mu        sync.Mutex
+	leaving   bool
+	lost      bool
+	lostAt    time.Time
+	attempt   int
+	pending   []string
+	indicator *widgets.QLabel
+}
+
+func newConnectionMonitor(ws *Workspace) *connectionMonitor {
+	c := &connectionMonitor{
+		ws: ws,
+	}
+	indicator := widgets.NewQLabel(ws.screen.widget, 0)
+	indicator.SetContentsMargins(8, 4, 8, 4)
+	indicator.SetAttribute(core.Qt__WA_TransparentForMouseEvents, true)
+	indicator.Hide()
+	c.indicator = indicator
+
+	return c
+}
+
+// reconnectDelay returns the wait before the reconnection of the attempt
+// from 0, which doubles from reconnectInitialDelay up to reconnectMaxDelay
+func reconnectDelay(attempt int) time.Duration {
+	delay := reconnectInitialDelay
+	for i := 0; i < attempt; i++ {
+		delay *= 2
+		if delay >= reconnectMaxDelay {
+			return reconnectMaxDelay
+		}
+	}
+
+	return delay
+}
+
+// leave records the gonvim_leave notification, and returns true if the
+// updates are it
+func (c *connectionMonitor) leave(updates []interface{}) bool {
+	if len(updates) == 0 || updates[0] != "gonvim_leave" {
+		return false
+	}
+	if c != nil {
+		c.mu.Lock()
+		c.leaving = true
+		c.mu.Unlock()
+	}
+
+	return true
+}
+
+func (c *connectionMonitor) shouldReconnect() bool {
+	if c == nil || !c.ws.uiRemoteAttached || !editor.config.Reconnect.Enable {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return !c.leaving
+}
+
+// hold queues the keys while the connection is lost, and returns false if
+// the connection is up. The keys typed after the buffer time are dropped.
+func (c *connectionMonitor) hold(keys string) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.lost {
+		return false
+	}
+	buffer := time.Duration(editor.config.Reconnect.InputBuffer) * time.Millisecond
+	if time.Since(c.lostAt) < buffer && len(c.pending) < inputQueueMax {
+		c.pending = append(c.pending, keys)
+	}
+
+	return true
+}
+
+// dialServer connects to the server of the remote attachment, over ssh if
+// --ssh is given
+func dialServer() (*nvim.Nvim, error) {
+	if editor.opts.Ssh != "" {
+		return dialSSH(editor.opts.Ssh, editor.opts.Server)
+	}
+
+	return nvim.Dial(editor.opts.Server)
+}
+
+// sshConn is the connection to the server forwarded by the ssh process
+type sshConn struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+func (s *sshConn) Close() error {
+	s.stdin.Close()
+	if s.cmd.Process != nil {
+		s.cmd.Process.Kill()
+	}
+	s.cmd.Wait()
+
+	return nil
+}
+
+// dialSSH connects to the server of the address on the host of the
+// destination, by the stdio forwarding of ssh, which needs no tools on the
+// host. The address is the TCP address of the server seen from the host.
+func dialSSH(destination, address string) (*nvim.Nvim, error) {
+	cmd := exec.Command("ssh", "-T", "-o", "BatchMode=yes", "-W", address, destination)
+	util.PrepareRunProc(cmd)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	err = cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	return nvim.New(stdout, stdin, &sshConn{cmd: cmd, stdin: stdin}, log.Printf)
+}
+
+// serve serves the connection to neovim, and reconnects the remote
+// attachment when the connection drops. The workspace is stopped when
+// neovim quits or the reconnection fails.
+func (w *Workspace) serve(neovim *nvim.Nvim) {
+	for neovim != nil {
+		done := make(chan struct{})
+		if w.connection.shouldReconnect() {
+			go w.heartbeat(neovim, done)
+		}
+		err := neovim.Serve()
+		close(done)
+		if err != nil {
+			fmt.Println(err)
+		}
+		if !w.connection.shouldReconnect() {
+			break
+		}
+		neovim = w.reconnect()
+	}
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+	w.signal.StopSignal()
+}
+
+// heartbeat closes the connection when neovim doesn't answer the request in
+// the interval of the heartbeat, so that the half-open connection, whose
+// end never comes, is taken for a dropped one. nvim_get_mode is answered
+// even while neovim is blocked, e.g. waiting for the input.
+func (w *Workspace) heartbeat(neovim *nvim.Nvim, done chan struct{}) {
+	interval := time.Duration(editor.config.Reconnect.Heartbeat) * time.Millisecond
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+		answered := make(chan struct{})
+		go func() {
+			neovim.Mode()
+			close(answered)
+		}()
+		select {
+		case <-answered:
+		case <-done:
+			return
+		case <-time.After(interval):
+			neovim.Close()
+			return
+		}
+	}
+}
+
+// reconnect dials the server with the exponential backoff, and returns the
+// new connection, or nil if the attempts run out. The attempts are counted
+// until the UI is attached again, so that the connection which drops while
+// attaching doesn't restart them.
+func (w *Workspace) reconnect() *nvim.Nvim {
+	c := w.connection
+	c.mu.Lock()
+	if !c.lost {
+		c.lost = true
+		c.lostAt = time.Now()
+		c.pending = nil
+	}
+	c.mu.Unlock()
+
+	for {
+		c.mu.Lock()
+		attempt := c.attempt
+		c.attempt++
+		c.mu.Unlock()
+		if attempt >= editor.config.Reconnect.MaxAttempts {
+			break
+		}
+		w.postConnectionState(connectionReconnecting, attempt+1)
+		time.Sleep(reconnectDelay(attempt))
+		neovim, err := dialServer()
+		if err != nil {
+			continue
+		}
+		w.registerHandlers(neovim)
+		// The connection is replaced on the GUI thread, which reads it
+		w.guiUpdates <- []interface{}{"gonvim_reconnected", neovim}
+		w.signal.GuiSignal()
+		return neovim
+	}
+	w.postConnectionState(connectionLost, 0)
+
+	return nil
+}
+
+// reconnected replaces the connection on the GUI thread, and attaches the UI
+// on it
+func (w *Workspace) reconnected(args []interface{}) {
+	if len(args) < 1 {
+		return
+	}
+	neovim, ok := args[0].(*nvim.Nvim)
+	if !ok {
+		return
+	}
+	w.nvim = neovim
+	go w.reattachUI()
+}
+
+// reattachUI registers the notifications and the plugins of the GUI on the
+// new connection, and attaches the UI. The connection which fails to attach
+// is closed, and dialed again by the next attempt.
+func (w *Workspace) reattachUI() {
+	w.nvim.Subscribe("Gui")
+	w.initGonvim()
+	w.statusline.registerHandler()
+	finder := fuzzy.RegisterPlugin(w.nvim, w.uiRemoteAttached)
+	if w.index != nil {
+		finder.SetIndex(w.index)
+	}
+	filer.RegisterPlugin(w.nvim)
+	w.updateChannel()
+	w.registerGridContent()
+
+	err := w.nvim.AttachUI(w.cols, w.rows, w.attachUIOption())
+	if err != nil {
+		fmt.Println(err)
+		w.nvim.Close()
+		return
+	}
+	w.postConnectionState(connectionConnected, 0)
+}
+
+// postConnectionState passes the state of the connection to the GUI thread
+func (w *Workspace) postConnectionState(state, attempt int) {
+	w.guiUpdates <- []interface{}{"gonvim_connection", state, attempt}
+	w.signal.GuiSignal()
+}
+
+// update shows the state of the connection, and sends the held
+// keys once reconnected
+func (c *connectionMonitor) update(args []interface{}) {
+	if len(args) < 2 {
+		return
+	}
+	state := util.ReflectToInt(args[0])
+	attempt := util.ReflectToInt(args[1])
+
+	switch state {
+	case connectionConnected:
+		c.mu.Lock()
+		c.lost = false
+		c.attempt = 0
+		pending := c.pending
+		c.pending = nil
+		c.mu.Unlock()
+		c.indicator.Hide()
+		// The busy_stop of the dropped connection never comes
+		c.ws.inputQueue.busyStop()
+		for _, keys := range pending {
+			c.ws.inputQueue.input(keys)
+		}
+		return
+	case connectionReconnecting:
+		c.indicator.SetText(fmt.Sprintf("Connection lost. Reconnecting to %s (attempt %d/%d)",
+			serverName(), attempt, editor.config.Reconnect.MaxAttempts))
+	case connectionLost:
+		c.indicator.SetText(fmt.Sprintf("Could not reconnect to %s", serverName()))
+	}
+	c.show()
+}
+
+func (c *connectionMonitor) show() {
+	if editor.colors.widgetBg != nil && editor.colors.widgetFg != nil {
+		c.indicator.SetStyleSheet(fmt.Sprintf(
+			" * { color: %s; background-color: %s; border: 1px solid %s; }",
+			editor.colors.widgetFg.String(),
+			editor.colors.widgetBg.String(),
+			editor.colors.inactiveFg.String(),
+		))
+	}
+	c.indicator.AdjustSize()
+	margin := editor.iconSize / 2
+	x := c.ws.screen.widget.Width() - c.indicator.Width() - margin
+	c.indicator.Move2(x, margin)
+	c.indicator.Show()
+	c.indicator.Raise()
+}
+
+// serverName returns the name of the server of the remote attachment
+func serverName() string {
+	if editor.opts.Ssh != "" {
+		return editor.opts.Ssh + " " + editor.opts.Server
+	}
+
+	return editor.opts.Server
+}
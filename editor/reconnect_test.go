@@ -0,0 +1,41 @@
+package editor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReconnectDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, 500 * time.Millisecond},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{5, 16 * time.Second},
+		{6, 30 * time.Second},
+		{100, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := reconnectDelay(tt.attempt); got != tt.want {
+			t.Errorf("reconnectDelay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestConnectionMonitorLeave(t *testing.T) {
+	c := &connectionMonitor{}
+	if c.leave([]interface{}{"gonvim_enter", "/"}) {
+		t.Errorf("leave(gonvim_enter) = true, want false")
+	}
+	if c.leaving {
+		t.Errorf("leaving = true after gonvim_enter, want false")
+	}
+	if !c.leave([]interface{}{"gonvim_leave"}) {
+		t.Errorf("leave(gonvim_leave) = false, want true")
+	}
+	if !c.leaving {
+		t.Errorf("leaving = false after gonvim_leave, want true")
+	}
+}
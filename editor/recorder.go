@@ -0,0 +1,385 @@
+package editor
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/neovim/go-client/nvim"
+)
+
+func init() {
+	// Concrete types which may be contained in the redraw events
+	gob.Register([]interface{}{})
+	gob.Register(map[string]interface{}{})
+	gob.Register(nvim.Window(0))
+	gob.Register(nvim.Buffer(0))
+	gob.Register(nvim.Tabpage(0))
+}
+
+// redrawRecord is a batch of redraw events received at once from neovim
+type redrawRecord struct {
+	Elapsed time.Duration
+	Updates [][]interface{}
+}
+
+// redrawRecorder records the raw redraw event stream to a file
+type redrawRecorder struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	encoder *gob.Encoder
+	start   time.Time
+}
+
+func newRedrawRecorder(path string) (*redrawRecorder, error) {
+	if path == "" {
+		path = filepath.Join(
+			editor.homeDir,
+			".goneovim",
+			fmt.Sprintf("redraw-%s.rec", time.Now().Format("20060102-150405")),
+		)
+	}
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &redrawRecorder{
+		path:    path,
+		file:    file,
+		encoder: gob.NewEncoder(file),
+		start:   time.Now(),
+	}, nil
+}
+
+func (r *redrawRecorder) record(updates [][]interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.encoder == nil {
+		return
+	}
+	err := r.encoder.Encode(redrawRecord{
+		Elapsed: time.Since(r.start),
+		Updates: updates,
+	})
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+func (r *redrawRecorder) close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.encoder = nil
+	return r.file.Close()
+}
+
+// readRedrawRecords reads the records in the file and calls fn in order.
+// If fn returns false, stop reading.
+func readRedrawRecords(path string, fn func(record redrawRecord) bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := gob.NewDecoder(file)
+	for {
+		var record redrawRecord
+		err := decoder.Decode(&record)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !fn(record) {
+			return nil
+		}
+	}
+}
+
+func (w *Workspace) startRecording(path string) {
+	w.stopRecording()
+	recorder, err := newRedrawRecorder(path)
+	if err != nil {
+		editor.pushNotification(NotifyWarn, -1, fmt.Sprintf("[Goneovim] Failed to start recording: %s", err))
+		return
+	}
+	w.recorderMutex.Lock()
+	w.recorder = recorder
+	w.recorderMutex.Unlock()
+
+	// Redraw the entire screen so that the record contains all grid contents.
+	// Note that highlight definitions are only contained if recording
+	// is started from the startup (--record option).
+	if w.uiAttached {
+		go w.nvim.Command("redraw!")
+	}
+	editor.pushNotification(NotifyInfo, -1, fmt.Sprintf("[Goneovim] Recording redraw events to %s", recorder.path))
+}
+
+func (w *Workspace) stopRecording() {
+	w.recorderMutex.Lock()
+	recorder := w.recorder
+	w.recorder = nil
+	w.recorderMutex.Unlock()
+	if recorder == nil {
+		return
+	}
+	recorder.close()
+	editor.pushNotification(NotifyInfo, -1, fmt.Sprintf("[Goneovim] Recorded redraw events to %s", recorder.path))
+}
+
+// replay replays the recorded redraw events in this workspace.
+// While replaying, redraw events from neovim are discarded and the screen
+// is redrawn after the replay is finished.
+func (w *Workspace) replay(path string) {
+	if !atomic.CompareAndSwapInt32(&w.replaying, 0, 1) {
+		return
+	}
+	go func() {
+		start := time.Now()
+		err := readRedrawRecords(path, func(record redrawRecord) bool {
+			select {
+			case <-w.stop:
+				return false
+			default:
+			}
+			if d := record.Elapsed - time.Since(start); d > 0 {
+				time.Sleep(d)
+			}
+			w.redrawUpdates <- newRedrawEvents(record.Updates)
+			return true
+		})
+		atomic.StoreInt32(&w.replaying, 0)
+		if err != nil {
+			editor.pushNotification(NotifyWarn, -1, fmt.Sprintf("[Goneovim] Failed to replay %s: %s", path, err))
+		}
+		w.nvim.Command("redraw!")
+	}()
+}
+
+// Replayed grids are rasterized in the cells of the size by the snapshot
+// renderer
+const (
+	replayCellWidth  = 8
+	replayCellHeight = 16
+)
+
+// headlessReplay applies the recorded redraw events to the contents of the
+// grids without the GUI, so that a record is replayed offline, e.g. on CI.
+// Only the events of the contents of the grids are applied.
+type headlessReplay struct {
+	grids map[int][][]*Cell
+	hls   map[int]*Highlight
+	fg    *RGBA
+	bg    *RGBA
+}
+
+func newHeadlessReplay() *headlessReplay {
+	return &headlessReplay{
+		grids: make(map[int][][]*Cell),
+		hls:   map[int]*Highlight{0: {}},
+		fg:    calcColor(0xffffff),
+		bg:    calcColor(0),
+	}
+}
+
+// replayHeadless replays the records in the file without the GUI, and
+// writes the snapshots of the grids at the end to the directory as
+// grid-{id}.png
+func replayHeadless(path, dir string) error {
+	r := newHeadlessReplay()
+	err := readRedrawRecords(path, func(record redrawRecord) bool {
+		r.apply(record.Updates)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.writeSnapshots(dir)
+}
+
+func (r *headlessReplay) apply(updates [][]interface{}) {
+	for _, update := range updates {
+		if len(update) == 0 {
+			continue
+		}
+		name, _ := update[0].(string)
+		for _, a := range update[1:] {
+			args, ok := a.([]interface{})
+			if !ok {
+				continue
+			}
+			r.applyEvent(name, args)
+		}
+	}
+}
+
+func (r *headlessReplay) applyEvent(name string, args []interface{}) {
+	switch name {
+	case "default_colors_set":
+		if len(args) < 2 {
+			return
+		}
+		if fg := util.ReflectToInt(args[0]); fg >= 0 {
+			r.fg = calcColor(fg)
+		}
+		if bg := util.ReflectToInt(args[1]); bg >= 0 {
+			r.bg = calcColor(bg)
+		}
+	case "hl_attr_define":
+		if len(args) < 4 {
+			return
+		}
+		r.hls[util.ReflectToInt(args[0])] = parseHighlight(args, nil, nil)
+	case "grid_resize":
+		if len(args) < 3 {
+			return
+		}
+		grid := util.ReflectToInt(args[0])
+		r.grids[grid] = resizeContent(r.grids[grid], util.ReflectToInt(args[1]), util.ReflectToInt(args[2]))
+	case "grid_clear":
+		if len(args) < 1 {
+			return
+		}
+		grid := util.ReflectToInt(args[0])
+		content := r.grids[grid]
+		if len(content) == 0 {
+			return
+		}
+		r.grids[grid] = resizeContent(nil, len(content[0]), len(content))
+	case "grid_destroy":
+		if len(args) < 1 {
+			return
+		}
+		delete(r.grids, util.ReflectToInt(args[0]))
+	case "grid_line":
+		if len(args) < 4 {
+			return
+		}
+		content := r.grids[util.ReflectToInt(args[0])]
+		row := util.ReflectToInt(args[1])
+		if row < 0 || row >= len(content) {
+			return
+		}
+		cells, _ := args[3].([]interface{})
+		r.writeLine(content[row], util.ReflectToInt(args[2]), newGridCells(cells))
+	case "grid_scroll":
+		if len(args) < 6 {
+			return
+		}
+		scrollContent(
+			r.grids[util.ReflectToInt(args[0])],
+			util.ReflectToInt(args[1]),
+			util.ReflectToInt(args[2]),
+			util.ReflectToInt(args[3]),
+			util.ReflectToInt(args[4]),
+			util.ReflectToInt(args[5]),
+		)
+	}
+}
+
+// writeLine writes the cells of grid_line from the col. The cell without
+// hl_id takes the one of the cell before it.
+func (r *headlessReplay) writeLine(line []*Cell, col int, cells []gridCell) {
+	hl := 0
+	for _, cell := range cells {
+		if cell.hl != -1 {
+			hl = cell.hl
+		}
+		repeat := maxInt(cell.repeat, 1)
+		for i := 0; i < repeat && col < len(line); i++ {
+			if col >= 0 {
+				c := &Cell{char: cell.text, normalWidth: true}
+				if highlight, ok := r.hls[hl]; ok {
+					c.highlight = *highlight
+				}
+				line[col] = c
+			}
+			col++
+		}
+	}
+}
+
+// resizeContent returns the content of the size, keeping the cells of the
+// content in it
+func resizeContent(content [][]*Cell, cols, rows int) [][]*Cell {
+	resized := make([][]*Cell, rows)
+	for row := range resized {
+		resized[row] = make([]*Cell, cols)
+		if row < len(content) {
+			copy(resized[row], content[row])
+		}
+	}
+
+	return resized
+}
+
+// scrollContent scrolls the region of the rows from top to bot-1 and the
+// cols from left to right-1 by count, as grid_scroll. The rows scrolled in
+// are left for the grid_line which follows.
+func scrollContent(content [][]*Cell, top, bot, left, right, count int) {
+	if len(content) == 0 {
+		return
+	}
+	left, right = maxInt(left, 0), minInt(right, len(content[0]))
+	if left >= right {
+		return
+	}
+	if count > 0 {
+		for row := top; row < bot-count; row++ {
+			if row+count < len(content) {
+				copy(content[row][left:right], content[row+count][left:right])
+			}
+		}
+		return
+	}
+	for row := bot - 1; row >= top-count; row-- {
+		if row < len(content) {
+			copy(content[row][left:right], content[row+count][left:right])
+		}
+	}
+}
+
+// writeSnapshots writes the snapshots of the grids to the directory
+func (r *headlessReplay) writeSnapshots(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	grids := make([]int, 0, len(r.grids))
+	for grid := range r.grids {
+		grids = append(grids, grid)
+	}
+	sort.Ints(grids)
+	for _, grid := range grids {
+		img := snapshotGrid(r.grids[grid], replayCellWidth, replayCellHeight, r.fg, r.bg)
+		file, err := os.Create(filepath.Join(dir, fmt.Sprintf("grid-%d.png", grid)))
+		if err != nil {
+			return err
+		}
+		err = png.Encode(file, img)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package editor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/neovim/go-client/nvim"
+)
+
+func TestRedrawRecorder_roundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goneovim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "redraw.rec")
+
+	want := [][][]interface{}{
+		{
+			{"grid_resize", []interface{}{int64(2), int64(80), int64(24)}},
+			{"win_pos", []interface{}{int64(2), nvim.Window(1000), int64(0), int64(0), int64(80), int64(24)}},
+		},
+		{
+			{"grid_line", []interface{}{int64(2), int64(0), int64(0), []interface{}{[]interface{}{"a", int64(1)}}}},
+			{"flush"},
+		},
+	}
+
+	r, err := newRedrawRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, updates := range want {
+		r.record(updates)
+	}
+	if err := r.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := [][][]interface{}{}
+	err = readRedrawRecords(path, func(record redrawRecord) bool {
+		got = append(got, record.Updates)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readRedrawRecords() = %v, want %v", got, want)
+	}
+}
+
+func TestHeadlessReplay(t *testing.T) {
+	r := newHeadlessReplay()
+	r.apply([][]interface{}{
+		{"default_colors_set", []interface{}{int64(0x000000), int64(0xffffff), int64(-1), int64(0), int64(0)}},
+		{"hl_attr_define", []interface{}{int64(1), map[string]interface{}{"bold": true}, map[string]interface{}{}, []interface{}{}}},
+		{"grid_resize", []interface{}{int64(2), int64(4), int64(3)}},
+		{"grid_line",
+			[]interface{}{int64(2), int64(0), int64(0), []interface{}{[]interface{}{"a", int64(1), int64(2)}, []interface{}{"b"}}},
+			[]interface{}{int64(2), int64(1), int64(1), []interface{}{[]interface{}{"c", int64(0)}}},
+		},
+		{"grid_scroll", []interface{}{int64(2), int64(0), int64(3), int64(0), int64(4), int64(1), int64(0)}},
+	})
+
+	content := r.grids[2]
+	chars := make([]string, len(content))
+	for row, line := range content {
+		for _, cell := range line {
+			if cell == nil {
+				chars[row] += "."
+				continue
+			}
+			chars[row] += cell.char
+		}
+	}
+	// The first row scrolled out, and the last row is left to grid_line
+	if want := []string{".c..", "....", "...."}; !reflect.DeepEqual(chars, want) {
+		t.Errorf("the chars of the grid = %q, want %q", chars, want)
+	}
+	if !r.bg.equals(calcColor(0xffffff)) {
+		t.Errorf("the default background = %v, want white", r.bg)
+	}
+
+	r.apply([][]interface{}{
+		{"grid_line", []interface{}{int64(2), int64(2), int64(0), []interface{}{[]interface{}{"x", int64(1)}}}},
+	})
+	if cell := r.grids[2][2][0]; cell == nil || cell.char != "x" || !cell.highlight.bold {
+		t.Errorf("the cell of the hl_id 1 = %+v, want the bold x", cell)
+	}
+
+	r.apply([][]interface{}{{"grid_destroy", []interface{}{int64(2)}}})
+	if _, ok := r.grids[2]; ok {
+		t.Errorf("the grid is not destroyed")
+	}
+}
+
+func TestReplayHeadless(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goneovim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "redraw.rec")
+
+	r, err := newRedrawRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.record([][]interface{}{
+		{"grid_resize", []interface{}{int64(2), int64(4), int64(2)}},
+		{"grid_line", []interface{}{int64(2), int64(0), int64(0), []interface{}{[]interface{}{"a", int64(0)}}}},
+	})
+	if err := r.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "snapshots")
+	if err := replayHeadless(path, out); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "grid-2.png")); err != nil {
+		t.Errorf("the snapshot of the grid is not written: %s", err)
+	}
+}
@@ -0,0 +1,265 @@
+package editor
+
+import (
+	"fmt"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/neovim/go-client/msgpack"
+)
+
+// redrawEvent is an event of the redraw notification, [name, args...].
+// grid_line, which is the most of the redraw events and has an item per
+// cell, is decoded directly from the msgpack stream into the typed lines.
+// The other events are decoded into the generic values as before.
+type redrawEvent struct {
+	name  string
+	args  []interface{}
+	lines []gridLineEvent
+}
+
+// gridLineEvent is the argument of grid_line, [grid, row, col_start, cells]
+type gridLineEvent struct {
+	grid  int
+	row   int
+	col   int
+	cells []gridCell
+}
+
+// gridCell is a cell of grid_line, [text(, hl_id, repeat)].
+// hl is -1 if hl_id is not present, and repeat is 0 if not present.
+type gridCell struct {
+	text   string
+	hl     int
+	repeat int
+}
+
+// UnmarshalMsgPack implements msgpack.Unmarshaler
+func (e *redrawEvent) UnmarshalMsgPack(dec *msgpack.Decoder) error {
+	if dec.Type() != msgpack.ArrayLen {
+		dec.Skip()
+		return fmt.Errorf("redraw event is not an array: %v", dec.Type())
+	}
+	n := dec.Len()
+	if n == 0 {
+		return nil
+	}
+	if err := dec.Unpack(); err != nil {
+		return err
+	}
+	e.name = dec.String()
+	n--
+
+	if e.name != "grid_line" {
+		e.args = make([]interface{}, n)
+		for i := range e.args {
+			if err := dec.Decode(&e.args[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	e.lines = make([]gridLineEvent, n)
+	for i := range e.lines {
+		if err := e.lines[i].decode(dec); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *gridLineEvent) decode(dec *msgpack.Decoder) error {
+	n, err := decodeArrayLen(dec)
+	if err != nil {
+		return err
+	}
+	if n < 4 {
+		return fmt.Errorf("grid_line has %d items", n)
+	}
+	if l.grid, err = decodeInt(dec); err != nil {
+		return err
+	}
+	if l.row, err = decodeInt(dec); err != nil {
+		return err
+	}
+	if l.col, err = decodeInt(dec); err != nil {
+		return err
+	}
+	m, err := decodeArrayLen(dec)
+	if err != nil {
+		return err
+	}
+	l.cells = make([]gridCell, m)
+	for i := range l.cells {
+		if err := l.cells[i].decode(dec); err != nil {
+			return err
+		}
+	}
+
+	// e.g. the wrap flag of nvim 0.10
+	return skipItems(dec, n-4)
+}
+
+func (c *gridCell) decode(dec *msgpack.Decoder) error {
+	n, err := decodeArrayLen(dec)
+	if err != nil {
+		return err
+	}
+	if n < 1 {
+		return fmt.Errorf("grid_line cell is empty")
+	}
+	if err := dec.Unpack(); err != nil {
+		return err
+	}
+	c.text = dec.String()
+	c.hl = -1
+	c.repeat = 0
+	if n >= 2 {
+		if c.hl, err = decodeInt(dec); err != nil {
+			return err
+		}
+	}
+	if n >= 3 {
+		if c.repeat, err = decodeInt(dec); err != nil {
+			return err
+		}
+	}
+	if n > 3 {
+		return skipItems(dec, n-3)
+	}
+
+	return nil
+}
+
+func decodeArrayLen(dec *msgpack.Decoder) (int, error) {
+	if err := dec.Unpack(); err != nil {
+		return 0, err
+	}
+	if dec.Type() != msgpack.ArrayLen {
+		dec.Skip()
+		return 0, fmt.Errorf("expected an array, got %v", dec.Type())
+	}
+
+	return dec.Len(), nil
+}
+
+func decodeInt(dec *msgpack.Decoder) (int, error) {
+	if err := dec.Unpack(); err != nil {
+		return 0, err
+	}
+	switch dec.Type() {
+	case msgpack.Int:
+		return int(dec.Int()), nil
+	case msgpack.Uint:
+		return int(dec.Uint()), nil
+	}
+	dec.Skip()
+
+	return 0, fmt.Errorf("expected an integer, got %v", dec.Type())
+}
+
+func skipItems(dec *msgpack.Decoder, n int) error {
+	for i := 0; i < n; i++ {
+		if err := dec.Unpack(); err != nil {
+			return err
+		}
+		if err := dec.Skip(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// update returns the event in the generic form of handleRedraw and the
+// redraw records
+func (e redrawEvent) update() []interface{} {
+	update := make([]interface{}, 0, len(e.args)+len(e.lines)+1)
+	update = append(update, e.name)
+	if e.name != "grid_line" {
+		return append(update, e.args...)
+	}
+	for _, l := range e.lines {
+		cells := make([]interface{}, len(l.cells))
+		for i, c := range l.cells {
+			cell := []interface{}{c.text}
+			if c.hl != -1 {
+				cell = append(cell, int64(c.hl))
+				if c.repeat != 0 {
+					cell = append(cell, int64(c.repeat))
+				}
+			}
+			cells[i] = cell
+		}
+		update = append(update, []interface{}{int64(l.grid), int64(l.row), int64(l.col), cells})
+	}
+
+	return update
+}
+
+// newRedrawEvents converts the redraw events in the generic form, e.g. of the
+// redraw records, to the typed events
+func newRedrawEvents(updates [][]interface{}) []redrawEvent {
+	events := make([]redrawEvent, 0, len(updates))
+	for _, update := range updates {
+		if len(update) == 0 {
+			continue
+		}
+		e := redrawEvent{}
+		e.name, _ = update[0].(string)
+		if e.name != "grid_line" {
+			e.args = update[1:]
+			events = append(events, e)
+			continue
+		}
+		for _, arg := range update[1:] {
+			line, ok := arg.([]interface{})
+			if !ok || len(line) < 4 {
+				continue
+			}
+			cells, _ := line[3].([]interface{})
+			e.lines = append(e.lines, gridLineEvent{
+				grid:  util.ReflectToInt(line[0]),
+				row:   util.ReflectToInt(line[1]),
+				col:   util.ReflectToInt(line[2]),
+				cells: newGridCells(cells),
+			})
+		}
+		events = append(events, e)
+	}
+
+	return events
+}
+
+// rawRedrawEvents converts the typed events back to the generic form
+func rawRedrawEvents(events []redrawEvent) [][]interface{} {
+	updates := make([][]interface{}, len(events))
+	for i, e := range events {
+		updates[i] = e.update()
+	}
+
+	return updates
+}
+
+// newGridCells converts the cells of grid_line in the generic form
+func newGridCells(cells []interface{}) []gridCell {
+	typed := make([]gridCell, 0, len(cells))
+	for _, arg := range cells {
+		cell, ok := arg.([]interface{})
+		if !ok || len(cell) == 0 {
+			continue
+		}
+		c := gridCell{hl: -1}
+		c.text, _ = cell[0].(string)
+		if len(cell) >= 2 {
+			c.hl = util.ReflectToInt(cell[1])
+		}
+		if len(cell) >= 3 {
+			c.repeat = util.ReflectToInt(cell[2])
+		}
+		typed = append(typed, c)
+	}
+
+	return typed
+}
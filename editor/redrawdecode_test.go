@@ -0,0 +1,128 @@
+package editor
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/neovim/go-client/msgpack"
+)
+
+func encodeRedraw(t testing.TB, updates [][]interface{}) []byte {
+	var buf bytes.Buffer
+	err := msgpack.NewEncoder(&buf).Encode(updates)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestRedrawEvent_UnmarshalMsgPack(t *testing.T) {
+	updates := [][]interface{}{
+		{"hl_attr_define", []interface{}{int64(1), map[string]interface{}{"bold": true}, map[string]interface{}{}, []interface{}{}}},
+		{
+			"grid_line",
+			[]interface{}{int64(2), int64(3), int64(4), []interface{}{
+				[]interface{}{"a", int64(5)},
+				[]interface{}{"b"},
+				[]interface{}{" ", int64(6), int64(10)},
+			}},
+			// nvim 0.10 has the wrap flag
+			[]interface{}{int64(2), int64(4), int64(0), []interface{}{[]interface{}{"c", int64(0)}}, false},
+		},
+		{"flush"},
+	}
+
+	var events []redrawEvent
+	err := msgpack.NewDecoder(bytes.NewReader(encodeRedraw(t, updates))).Decode(&events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d events", len(events))
+	}
+	if events[0].name != "hl_attr_define" || len(events[0].args) != 1 {
+		t.Errorf("hl_attr_define: got %#v", events[0])
+	}
+	want := []gridLineEvent{
+		{grid: 2, row: 3, col: 4, cells: []gridCell{{"a", 5, 0}, {"b", -1, 0}, {" ", 6, 10}}},
+		{grid: 2, row: 4, col: 0, cells: []gridCell{{"c", 0, 0}}},
+	}
+	if !reflect.DeepEqual(events[1].lines, want) {
+		t.Errorf("grid_line: got %#v, want %#v", events[1].lines, want)
+	}
+	if events[2].name != "flush" || len(events[2].args) != 0 {
+		t.Errorf("flush: got %#v", events[2])
+	}
+}
+
+func TestNewRedrawEvents(t *testing.T) {
+	updates := [][]interface{}{
+		{"grid_line", []interface{}{int64(1), int64(0), int64(2), []interface{}{
+			[]interface{}{"x", int64(3), int64(2)},
+			[]interface{}{"y"},
+		}}},
+		{"grid_cursor_goto", []interface{}{int64(1), int64(0), int64(4)}},
+	}
+	events := newRedrawEvents(updates)
+	want := []gridLineEvent{
+		{grid: 1, row: 0, col: 2, cells: []gridCell{{"x", 3, 2}, {"y", -1, 0}}},
+	}
+	if !reflect.DeepEqual(events[0].lines, want) {
+		t.Errorf("got %#v, want %#v", events[0].lines, want)
+	}
+
+	// The records are written in the generic form
+	if got := rawRedrawEvents(events); !reflect.DeepEqual(got, updates) {
+		t.Errorf("got %#v, want %#v", got, updates)
+	}
+}
+
+// pasteRedraw returns the redraw notification of pasting the lines of
+// the text, which has a cell per char
+func pasteRedraw(rows, cols int) [][]interface{} {
+	update := []interface{}{"grid_line"}
+	for row := 0; row < rows; row++ {
+		cells := make([]interface{}, 0, cols)
+		for col := 0; col < cols; col++ {
+			cell := []interface{}{string(rune('a' + col%26))}
+			if col%8 == 0 {
+				cell = append(cell, int64(col%5))
+			}
+			cells = append(cells, cell)
+		}
+		update = append(update, []interface{}{int64(2), int64(row), int64(0), cells})
+	}
+
+	return [][]interface{}{update, {"flush"}}
+}
+
+func BenchmarkDecodeGridLine(b *testing.B) {
+	data := encodeRedraw(b, pasteRedraw(200, 160))
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var events []redrawEvent
+		err := msgpack.NewDecoder(bytes.NewReader(data)).Decode(&events)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDecodeGridLineGeneric is the decoding into interface{} and the
+// type assertions of the cells, as before the typed decoding
+func BenchmarkDecodeGridLineGeneric(b *testing.B) {
+	data := encodeRedraw(b, pasteRedraw(200, 160))
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var updates [][]interface{}
+		err := msgpack.NewDecoder(bytes.NewReader(data)).Decode(&updates)
+		if err != nil {
+			b.Fatal(err)
+		}
+		newRedrawEvents(updates)
+	}
+}
@@ -0,0 +1,187 @@
+package editor
+
+// redrawBatch is the part of the redraw notification handed to the GUI thread.
+// The grid_line events are already applied to the grid contents by the worker,
+// and only their damage is in the batch. The other events are handled on the
+// GUI thread, after the damage.
+type redrawBatch struct {
+	damage  []gridDamage
+	updates [][]interface{}
+	done    chan struct{}
+}
+
+// gridDamage is the row of the window updated by the worker
+type gridDamage struct {
+	win *Window
+	row int
+	// wide is true if the row has the non-ASCII chars, whose widths are
+	// measured with the font metrics on the GUI thread
+	wide bool
+	// unchanged is true if the row looks the same as before
+	unchanged bool
+}
+
+// processRedraw is the worker which decodes the redraw notifications and
+// applies grid_line to the grid contents off the GUI thread. The events which
+// touch the widgets are handed to the GUI thread in order, and the worker
+// waits for them before applying the following grid_line, so the grids are
+// never written while the GUI thread resizes or destroys them.
+func (w *Workspace) processRedraw() {
+	for {
+		select {
+		case <-w.stop:
+			return
+		case events := <-w.redrawUpdates:
+			w.processRedrawUpdates(events)
+		}
+	}
+}
+
+func (w *Workspace) processRedrawUpdates(events []redrawEvent) {
+	batch := &redrawBatch{}
+	for _, event := range events {
+		if event.name != "grid_line" {
+			batch.updates = append(batch.updates, event.update())
+			continue
+		}
+		// The events before grid_line, e.g. grid_resize and
+		// hl_attr_define, must be applied first
+		if len(batch.updates) > 0 {
+			if !w.handOffRedraw(batch) {
+				return
+			}
+			batch = &redrawBatch{}
+		}
+		batch.damage = append(batch.damage, w.screen.writeGridLine(event.lines)...)
+	}
+	if len(batch.updates) > 0 || len(batch.damage) > 0 {
+		w.handOffRedraw(batch)
+	}
+}
+
+// handOffRedraw hands the batch to the GUI thread and waits until it is
+// applied. It returns false if the workspace is stopped.
+func (w *Workspace) handOffRedraw(batch *redrawBatch) bool {
+	batch.done = make(chan struct{})
+	w.redrawBatches <- batch
+	w.signal.RedrawSignal()
+	select {
+	case <-batch.done:
+		return true
+	case <-w.stop:
+		return false
+	}
+}
+
+// applyRedrawBatch is called on the GUI thread
+func (w *Workspace) applyRedrawBatch(batch *redrawBatch) {
+	for _, d := range batch.damage {
+		win := d.win
+		if d.wide {
+			win.measureLine(d.row)
+		}
+		if !d.unchanged {
+			win.queueRedraw(0, d.row, win.cols, 1)
+		}
+		if !win.isShown() {
+			win.show()
+		}
+	}
+	w.handleRedraw(batch.updates)
+	close(batch.done)
+}
+
+// writeGridLine applies grid_line to the grid contents on the worker, and
+// returns the damaged rows
+func (s *Screen) writeGridLine(lines []gridLineEvent) []gridDamage {
+	var damage []gridDamage
+	for _, line := range lines {
+		gridid := line.grid
+		row := line.row
+		colStart := line.col
+		if isSkipGlobalId(gridid) || colStart < 0 {
+			continue
+		}
+		if editor.config.Editor.DrawBorder && gridid == 1 {
+			continue
+		}
+		win, ok := s.getWindow(gridid)
+		if !ok {
+			continue
+		}
+
+		win.paintMutex.Lock()
+		if row >= win.rows || row >= len(win.content) {
+			win.paintMutex.Unlock()
+			continue
+		}
+		wide := win.writeLine(colStart, row, line.cells, true)
+		win.countContent(row)
+		if !win.isMsgGrid && win.grid != 1 && win.maxLenContent < win.lenContent[row] {
+			win.maxLenContent = win.lenContent[row]
+		}
+		unchanged := !win.rowChanged(row)
+		win.paintMutex.Unlock()
+
+		damage = append(damage, gridDamage{win: win, row: row, wide: wide, unchanged: unchanged})
+	}
+
+	return damage
+}
+
+// cellAt returns a copy of the cell of the grid contents. The cells are
+// written by the redraw worker in place, so they are copied under the lock.
+// It must not be called while painting, which holds the lock.
+func (w *Window) cellAt(row, col int) (Cell, bool) {
+	w.paintMutex.Lock()
+	defer w.paintMutex.Unlock()
+	if row < 0 || row >= len(w.content) || col < 0 || col >= len(w.content[row]) || w.content[row][col] == nil {
+		return Cell{}, false
+	}
+
+	return *w.content[row][col], true
+}
+
+// rowAt returns the snapshot of the row of the grid contents, which the
+// redraw worker never writes. It must not be called while painting, which
+// holds the lock.
+func (w *Window) rowAt(row int) ([]*Cell, bool) {
+	w.paintMutex.Lock()
+	defer w.paintMutex.Unlock()
+	if row < 0 || row >= len(w.content) {
+		return nil, false
+	}
+
+	return copyCells(w.content[row]), true
+}
+
+// copyCells returns the deep copy of the cells
+func copyCells(line []*Cell) []*Cell {
+	cells := make([]*Cell, len(line))
+	for i, cell := range line {
+		if cell == nil {
+			continue
+		}
+		c := *cell
+		cells[i] = &c
+	}
+
+	return cells
+}
+
+// measureLine sets the widths of the non-ASCII chars in the row, which the
+// worker leaves to the GUI thread
+func (w *Window) measureLine(row int) {
+	w.paintMutex.Lock()
+	defer w.paintMutex.Unlock()
+	if row >= len(w.content) {
+		return
+	}
+	line := w.content[row]
+	for x, cell := range line {
+		if cell == nil || isASCII(cell.char) {
+			continue
+		}
+		cell.normalWidth = w.cellNormalWidth(line, x)
+	}
+}
@@ -0,0 +1,312 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+// reflowCommitDelay is the time in msec after the last resize event until
+// the size is sent to nvim
+const reflowCommitDelay = 250
+
+// reflowBreakat is the default of 'breakat', where 'linebreak' wraps the lines
+const reflowBreakat = " \t!@*-+;:,./?"
+
+// reflowLua returns the visible lines of the non-floating windows of the
+// prose filetypes with 'wrap', whose tabs are expanded by 'tabstop'
+const reflowLua = `
+local filetypes = ...
+local prose = {}
+for _, ft in ipairs(filetypes) do
+  prose[ft] = true
+end
+local wins = {}
+for _, win in ipairs(vim.api.nvim_tabpage_list_wins(0)) do
+  local buf = vim.api.nvim_win_get_buf(win)
+  if vim.api.nvim_win_get_config(win).relative == "" and vim.wo[win].wrap and prose[vim.bo[buf].filetype] then
+    local info = vim.fn.getwininfo(win)[1]
+    local tab = string.rep(" ", vim.bo[buf].tabstop)
+    local lines = vim.api.nvim_buf_get_lines(buf, info.topline - 1, info.topline - 1 + info.height, false)
+    for i, line in ipairs(lines) do
+      lines[i] = (line:gsub("\t", tab))
+    end
+    table.insert(wins, {
+      id = win,
+      linebreak = vim.wo[win].linebreak,
+      textoff = info.textoff,
+      lines = lines,
+    })
+  end
+end
+return wins
+`
+
+// reflowWin is the snapshot of a prose window taken when the resize starts
+type reflowWin struct {
+	ID        int      `msgpack:"id"`
+	Linebreak bool     `msgpack:"linebreak"`
+	Textoff   int      `msgpack:"textoff"`
+	Lines     []string `msgpack:"lines"`
+}
+
+// reflowWrap wraps the line into the rows of the width in cells. With
+// linebreak, the rows break after the last char of 'breakat' in them, as
+// 'linebreak' does.
+func reflowWrap(line string, width int, linebreak bool, charWidth func(rune) int) []string {
+	if width < 1 {
+		width = 1
+	}
+	var rows []string
+	runes := []rune(line)
+	for len(runes) > 0 {
+		cells, end := 0, 0
+		for end < len(runes) {
+			w := charWidth(runes[end])
+			if cells+w > width {
+				break
+			}
+			cells += w
+			end++
+		}
+		// The char wider than the row takes a row
+		if end == 0 {
+			end = 1
+		}
+		if linebreak && end < len(runes) {
+			for i := end; i > 0; i-- {
+				if strings.ContainsRune(reflowBreakat, runes[i-1]) {
+					end = i
+					break
+				}
+			}
+		}
+		rows = append(rows, string(runes[:end]))
+		runes = runes[end:]
+	}
+	if len(rows) == 0 {
+		return []string{""}
+	}
+
+	return rows
+}
+
+// reflowText returns the rows of the lines wrapped at the width, up to the
+// rows of the window
+func reflowText(lines []string, width, height int, linebreak bool, charWidth func(rune) int) []string {
+	var rows []string
+	for _, line := range lines {
+		if len(rows) >= height {
+			break
+		}
+		rows = append(rows, reflowWrap(line, width, linebreak, charWidth)...)
+	}
+	if len(rows) > height {
+		rows = rows[:height]
+	}
+
+	return rows
+}
+
+// reflowCols returns the columns of the window after the screen of the
+// committed columns is resized to the target. nvim gives the change of the
+// width to the windows on the right edge.
+func reflowCols(pos, cols, committed, target int) int {
+	if pos+cols != committed {
+		return cols
+	}
+
+	return maxInt(cols+target-committed, 1)
+}
+
+// reflowPreview draws the prose windows wrapped at the width they will have
+// while the GUI is resized, and holds back the size from nvim until the
+// resize settles, so that the writers can judge the length of the lines
+// while dragging
+type reflowPreview struct {
+	s      *Screen
+	widget *widgets.QWidget
+	timer  *core.QTimer
+
+	// wins is the snapshot of the resize, which is nil until it is taken
+	wins     []*reflowWin
+	fetching bool
+	// committed is the columns of the screen nvim knows, and cols and rows
+	// are the size to be sent
+	committed int
+	cols      int
+	rows      int
+	pending   bool
+}
+
+func newReflowPreview(s *Screen) *reflowPreview {
+	r := &reflowPreview{
+		s: s,
+	}
+	widget := widgets.NewQWidget(s.widget, 0)
+	widget.SetAttribute(core.Qt__WA_TransparentForMouseEvents, true)
+	widget.ConnectPaintEvent(r.paint)
+	widget.Hide()
+	r.widget = widget
+
+	r.timer = core.NewQTimer(nil)
+	r.timer.SetSingleShot(true)
+	r.timer.ConnectTimeout(r.commit)
+
+	return r
+}
+
+// deferResize holds back the resize to the cols and the rows from nvim if
+// the snapshot has the prose windows, and returns true. The resizes until
+// the snapshot is taken are sent as usual.
+func (s *Screen) deferResize(cols, rows int) bool {
+	if !editor.config.Editor.ReflowPreview || s.ws.uiRemoteAttached {
+		return false
+	}
+	if s.reflow == nil {
+		s.reflow = newReflowPreview(s)
+	}
+	r := s.reflow
+	r.timer.Start(reflowCommitDelay)
+	if len(r.wins) == 0 {
+		if r.wins == nil && !r.fetching {
+			r.fetching = true
+			go r.snapshot()
+		}
+		r.committed = cols
+		return false
+	}
+	r.cols, r.rows = cols, rows
+	r.pending = true
+	r.widget.SetGeometry2(0, 0, s.widget.Width(), s.widget.Height())
+	r.widget.Show()
+	r.widget.Raise()
+	r.widget.Update()
+
+	return true
+}
+
+// snapshot takes the lines of the prose windows, and posts them by the
+// gonvim_reflow_snapshot update
+func (r *reflowPreview) snapshot() {
+	wins := []*reflowWin{}
+	err := r.s.ws.nvim.ExecuteLua(reflowLua, &wins, editor.config.Statusline.ProseFiletypes)
+	if err != nil {
+		wins = []*reflowWin{}
+	}
+	r.s.ws.guiUpdates <- []interface{}{"gonvim_reflow_snapshot", wins}
+	r.s.ws.signal.GuiSignal()
+}
+
+// setSnapshot is called by the gonvim_reflow_snapshot update
+func (r *reflowPreview) setSnapshot(wins []*reflowWin) {
+	if !r.fetching {
+		return
+	}
+	r.fetching = false
+	r.wins = wins
+}
+
+// commit sends the held back size to nvim when the resize settles
+func (r *reflowPreview) commit() {
+	r.wins = nil
+	r.fetching = false
+	r.widget.Hide()
+	if !r.pending {
+		return
+	}
+	r.pending = false
+	r.s.uiTryResize(r.cols, r.rows)
+}
+
+func (r *reflowPreview) paint(event *gui.QPaintEvent) {
+	p := gui.NewQPainter2(r.widget)
+	defer p.DestroyQPainter()
+
+	fg := editor.colors.fg
+	if fg == nil {
+		return
+	}
+	for _, snapshot := range r.wins {
+		win := r.window(snapshot.ID)
+		if win == nil {
+			continue
+		}
+		r.drawWindow(p, win, snapshot, fg)
+	}
+}
+
+// window returns the window of the id
+func (r *reflowPreview) window(id int) *Window {
+	var found *Window
+	r.s.windows.Range(func(_, winITF interface{}) bool {
+		win := winITF.(*Window)
+		if win != nil && int(win.id) == id && !win.isFloatWin && !win.isMsgGrid {
+			found = win
+			return false
+		}
+		return true
+	})
+
+	return found
+}
+
+// drawWindow draws the lines of the window wrapped at its columns after the
+// resize, with the guide and the columns on its right edge
+func (r *reflowPreview) drawWindow(p *gui.QPainter, win *Window, snapshot *reflowWin, fg *RGBA) {
+	cols := reflowCols(win.pos[0], win.cols, r.committed, r.cols)
+	if cols == win.cols {
+		return
+	}
+	bg := win.background
+	if bg == nil {
+		bg = r.s.ws.background
+	}
+	if bg == nil {
+		return
+	}
+	font := win.getFont()
+	screenFont := r.s.font
+	x := float64(win.pos[0]) * screenFont.truewidth
+	y := float64(win.pos[1] * screenFont.lineHeight)
+	width := float64(cols) * font.truewidth
+	height := float64(win.rows * font.lineHeight)
+	p.FillRect4(core.NewQRectF4(x, y, width, height), bg.QColor())
+
+	charWidth := func(c rune) int {
+		if win.isNormalWidth(string(c)) {
+			return 1
+		}
+		return 2
+	}
+	rows := reflowText(snapshot.Lines, cols-snapshot.Textoff, win.rows, snapshot.Linebreak, charWidth)
+	p.SetFont(font.fontNew)
+	p.SetPen2(fg.QColor())
+	left := x + float64(snapshot.Textoff)*font.truewidth
+	for i, row := range rows {
+		p.DrawText(
+			core.NewQPointF3(left, y+float64(i*font.lineHeight+font.shift)),
+			row,
+		)
+	}
+
+	guide := editor.colors.windowSeparator
+	if guide == nil {
+		guide = fg
+	}
+	p.FillRect4(core.NewQRectF4(x+width-1, y, 1, height), guide.QColor())
+	label := fmt.Sprintf("%d cols", cols-snapshot.Textoff)
+	labelWidth := font.fontMetrics.HorizontalAdvance(label, -1) + font.truewidth
+	p.FillRect4(
+		core.NewQRectF4(x+width-labelWidth-1, y, labelWidth, float64(font.lineHeight)),
+		guide.QColor(),
+	)
+	p.DrawText6(
+		core.NewQRectF4(x+width-labelWidth-1, y, labelWidth, float64(font.lineHeight)),
+		label,
+		gui.NewQTextOption2(core.Qt__AlignCenter),
+	)
+}
@@ -0,0 +1,65 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testCharWidth(r rune) int {
+	if r >= 0x3000 {
+		return 2
+	}
+	return 1
+}
+
+func TestReflowWrap(t *testing.T) {
+	tests := []struct {
+		line      string
+		width     int
+		linebreak bool
+		want      []string
+	}{
+		{"", 5, false, []string{""}},
+		{"abc", 5, false, []string{"abc"}},
+		{"abcdefgh", 3, false, []string{"abc", "def", "gh"}},
+		{"the quick brown fox", 10, false, []string{"the quick ", "brown fox"}},
+		{"the quick brown fox", 8, false, []string{"the quic", "k brown ", "fox"}},
+		{"the quick brown fox", 8, true, []string{"the ", "quick ", "brown ", "fox"}},
+		{"abcdefgh", 3, true, []string{"abc", "def", "gh"}},
+		{"日本語です", 5, false, []string{"日本", "語で", "す"}},
+		{"日本", 1, false, []string{"日", "本"}},
+	}
+	for _, tt := range tests {
+		got := reflowWrap(tt.line, tt.width, tt.linebreak, testCharWidth)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("reflowWrap(%q, %d, %v) = %q, want %q", tt.line, tt.width, tt.linebreak, got, tt.want)
+		}
+	}
+}
+
+func TestReflowText(t *testing.T) {
+	lines := []string{"abcdef", "", "gh", "ijk"}
+	got := reflowText(lines, 4, 4, false, testCharWidth)
+	want := []string{"abcd", "ef", "", "gh"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("reflowText() = %q, want %q", got, want)
+	}
+}
+
+func TestReflowCols(t *testing.T) {
+	tests := []struct {
+		pos, cols, committed, target int
+		want                         int
+	}{
+		{0, 80, 80, 100, 100},
+		{0, 80, 80, 60, 60},
+		{41, 39, 80, 60, 19},
+		{0, 40, 80, 60, 40},
+		{0, 80, 80, -10, 1},
+	}
+	for _, tt := range tests {
+		if got := reflowCols(tt.pos, tt.cols, tt.committed, tt.target); got != tt.want {
+			t.Errorf("reflowCols(%d, %d, %d, %d) = %d, want %d", tt.pos, tt.cols, tt.committed, tt.target, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package editor
+
+import (
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+// openGLState is the result of the check of the OpenGL renderer, which is
+// done once when the first window is created
+type openGLState int
+
+const (
+	openGLUnchecked openGLState = iota
+	openGLAvailable
+	openGLUnavailable
+)
+
+var openGL openGLState
+
+// useOpenGL returns true if the windows are painted on the OpenGL surface.
+// The raster renderer is used if OpenGL is not available, or the editor is
+// transparent, which the OpenGL surface can't be blended with.
+func useOpenGL() bool {
+	if editor.config.Editor.Renderer != "opengl" {
+		return false
+	}
+	if openGL == openGLUnchecked {
+		openGL = checkOpenGL()
+	}
+
+	return openGL == openGLAvailable
+}
+
+func checkOpenGL() openGLState {
+	reason := ""
+	if editor.config.Editor.Transparent < 1.0 {
+		reason = "the editor is transparent"
+	} else {
+		context := gui.NewQOpenGLContext(nil)
+		if !context.Create() || !context.IsValid() {
+			reason = "OpenGL is not available"
+		}
+		context.DestroyQOpenGLContext()
+	}
+	if reason != "" {
+		editor.pushNotification(NotifyWarn, -1, "[Goneovim] The opengl renderer falls back to raster: "+reason)
+		return openGLUnavailable
+	}
+
+	return openGLAvailable
+}
+
+// newGLWindowWidget returns the widget of the window painted on the OpenGL
+// surface. The glyphs are blitted from the atlas pages as textures, and the
+// whole window is painted in a frame.
+func newGLWindowWidget(w *Window) *widgets.QWidget {
+	glw := widgets.NewQOpenGLWidget(nil, 0)
+	glw.SetUpdateBehavior(widgets.QOpenGLWidget__NoPartialUpdate)
+	glw.ConnectPaintGL(func() {
+		w.paintRect(glw.Rect())
+	})
+
+	return glw.QWidget_PTR()
+}
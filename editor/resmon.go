@@ -0,0 +1,259 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/neovim/go-client/nvim"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+// resourceMonitorCommands toggle the resource monitor
+const resourceMonitorCommands = `
+	command! GonvimResourceMonitor call rpcnotify(0, "Gui", "gonvim_resource_monitor")
+	`
+
+// resourceSample is the usage of the resources sampled at an interval
+type resourceSample struct {
+	// nvimCPU is the CPU usage of nvim in percent, and nvimRSS is its
+	// resident memory in bytes. nvimErr is why they are unknown, e.g. for the
+	// remote nvim.
+	nvimCPU float64
+	nvimRSS int64
+	nvimErr error
+	// heap is the memory of the Go heap of the GUI, and sys the memory
+	// obtained from the OS
+	heap uint64
+	sys  uint64
+	// atlas is the bytes of the pages of the glyph cache, and glyphs the
+	// number of the cached glyphs
+	atlas  int
+	glyphs int
+	// rpc is the number of the notifications from nvim per second
+	rpc float64
+}
+
+// parsePsOutput returns the CPU usage in percent and the resident memory in
+// bytes of the output of `ps -o %cpu=,rss=`, which is in KB
+func parsePsOutput(out string) (float64, int64, error) {
+	fields := strings.Fields(out)
+	if len(fields) < 2 {
+		return 0, 0, errors.New("no process")
+	}
+	cpu, err := strconv.ParseFloat(strings.ReplaceAll(fields[0], ",", "."), 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	rss, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return cpu, rss * 1024, nil
+}
+
+// processUsage returns the CPU usage and the resident memory of the process
+func processUsage(pid int) (float64, int64, error) {
+	if runtime.GOOS == "windows" {
+		return 0, 0, errors.New("not supported on Windows")
+	}
+	cmd := exec.Command("ps", "-o", "%cpu=,rss=", "-p", strconv.Itoa(pid))
+	util.PrepareRunProc(cmd)
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return parsePsOutput(string(out))
+}
+
+// text returns the lines of the sample shown in the monitor
+func (r resourceSample) text() string {
+	nvim := fmt.Sprintf("nvim  CPU %.1f%%  RSS %s", r.nvimCPU, formatFileSize(r.nvimRSS))
+	if r.nvimErr != nil {
+		nvim = "nvim  n/a (" + r.nvimErr.Error() + ")"
+	}
+
+	return strings.Join([]string{
+		nvim,
+		fmt.Sprintf("GUI   heap %s  sys %s", formatFileSize(int64(r.heap)), formatFileSize(int64(r.sys))),
+		fmt.Sprintf("glyph cache  %s  %d glyphs", formatFileSize(int64(r.atlas)), r.glyphs),
+		fmt.Sprintf("RPC   %.0f notifications/s", r.rpc),
+	}, "\n")
+}
+
+// resourceMonitor shows the usage of the resources of nvim and the GUI, and
+// the rate of the notifications from nvim, in the corner of the screen, so
+// that a runaway plugin can be told from the overhead of the GUI. The caches
+// of the GUI are purged by its button.
+type resourceMonitor struct {
+	ws     *Workspace
+	widget *widgets.QWidget
+	label  *widgets.QLabel
+	timer  *core.QTimer
+
+	// pid is the pid of the nvim of the connection pidOf, which is asked
+	// again after the reconnection
+	pid   int
+	pidOf *nvim.Nvim
+	// rpc is the count of the notifications at the last sample
+	rpc uint64
+	// sampling is set while a sample is taken, so that the slow samples
+	// don't pile up
+	sampling int32
+}
+
+func newResourceMonitor(ws *Workspace) *resourceMonitor {
+	m := &resourceMonitor{
+		ws: ws,
+	}
+	widget := widgets.NewQWidget(ws.screen.widget, 0)
+	layout := widgets.NewQVBoxLayout()
+	layout.SetContentsMargins(8, 4, 8, 4)
+	layout.SetSpacing(4)
+	widget.SetLayout(layout)
+	label := widgets.NewQLabel(nil, 0)
+	purge := widgets.NewQPushButton2("Purge caches", nil)
+	purge.SetFocusPolicy(core.Qt__NoFocus)
+	purge.ConnectClicked(func(bool) {
+		m.purge()
+	})
+	layout.AddWidget(label, 0, 0)
+	layout.AddWidget(purge, 0, core.Qt__AlignRight)
+	widget.Hide()
+	m.widget = widget
+	m.label = label
+
+	m.timer = core.NewQTimer(nil)
+	m.timer.ConnectTimeout(m.sample)
+
+	return m
+}
+
+// toggle is called by the gonvim_resource_monitor notification
+func (m *resourceMonitor) toggle() {
+	if m.widget.IsVisible() {
+		m.timer.Stop()
+		m.widget.Hide()
+		return
+	}
+	m.rpc = atomic.LoadUint64(&m.ws.rpcCount)
+	m.label.SetText("sampling...")
+	m.setColor()
+	m.place()
+	m.widget.Show()
+	m.widget.Raise()
+	m.timer.Start(maxInt(editor.config.ResourceMonitor.Interval, 100))
+}
+
+// sample takes the sample of the GUI on the GUI thread, and of nvim in the
+// background, and posts it by the gonvim_resource_sample update
+func (m *resourceMonitor) sample() {
+	if !atomic.CompareAndSwapInt32(&m.sampling, 0, 1) {
+		return
+	}
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	atlas := m.ws.screen.atlas
+	count := atomic.LoadUint64(&m.ws.rpcCount)
+	seconds := float64(maxInt(editor.config.ResourceMonitor.Interval, 100)) / 1000
+	sample := resourceSample{
+		heap:   stats.HeapAlloc,
+		sys:    stats.Sys,
+		atlas:  atlas.bytes(),
+		glyphs: len(atlas.glyphs),
+		rpc:    float64(count-m.rpc) / seconds,
+	}
+	m.rpc = count
+
+	go func() {
+		defer atomic.StoreInt32(&m.sampling, 0)
+		sample.nvimCPU, sample.nvimRSS, sample.nvimErr = m.nvimUsage()
+		m.ws.guiUpdates <- []interface{}{"gonvim_resource_sample", sample}
+		m.ws.signal.GuiSignal()
+	}()
+}
+
+// nvimUsage returns the usage of the resources of the nvim process
+func (m *resourceMonitor) nvimUsage() (float64, int64, error) {
+	if m.ws.uiRemoteAttached {
+		return 0, 0, errors.New("remote")
+	}
+	neovim := m.ws.nvim
+	if m.pid == 0 || m.pidOf != neovim {
+		var pid int
+		if err := neovim.Call("getpid", &pid); err != nil {
+			return 0, 0, err
+		}
+		m.pid = pid
+		m.pidOf = neovim
+	}
+
+	return processUsage(m.pid)
+}
+
+// update shows the sample
+func (m *resourceMonitor) update(sample resourceSample) {
+	if !m.widget.IsVisible() {
+		return
+	}
+	m.label.SetText(sample.text())
+	m.place()
+}
+
+func (m *resourceMonitor) place() {
+	m.widget.AdjustSize()
+	margin := editor.iconSize / 2
+	x := m.ws.screen.widget.Width() - m.widget.Width() - margin
+	y := m.ws.screen.widget.Height() - m.widget.Height() - margin
+	m.widget.Move2(maxInt(x, 0), maxInt(y, 0))
+}
+
+// purge drops the glyph caches of the workspaces and the image caches, and
+// returns the freed memory to the OS
+func (m *resourceMonitor) purge() {
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+	for _, ws := range editor.workspaces {
+		if ws == nil || ws.screen == nil {
+			continue
+		}
+		ws.screen.purgeTextCacheForWins()
+	}
+	if editor.thumbnails != nil {
+		editor.thumbnails.purge()
+	}
+	gui.QPixmapCache_Clear()
+	debug.FreeOSMemory()
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+
+	freed := int64(before.HeapAlloc) - int64(after.HeapAlloc)
+	if freed < 0 {
+		freed = 0
+	}
+	editor.pushNotification(NotifyInfo, -1, fmt.Sprintf("[Goneovim] Purged the caches, %s of the heap is freed", formatFileSize(freed)))
+	m.sample()
+}
+
+func (m *resourceMonitor) setColor() {
+	if editor.colors.widgetBg == nil || editor.colors.widgetFg == nil {
+		return
+	}
+	m.widget.SetStyleSheet(fmt.Sprintf(
+		" * { color: %s; background-color: %s; font-family: \"%s\"; } QPushButton { border: 1px solid %s; padding: 2px 6px; }",
+		editor.colors.widgetFg.String(),
+		editor.colors.widgetBg.String(),
+		m.ws.font.family,
+		editor.colors.inactiveFg.String(),
+	))
+}
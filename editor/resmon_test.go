@@ -0,0 +1,30 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestParsePsOutput(t *testing.T) {
+	tests := []struct {
+		out     string
+		cpu     float64
+		rss     int64
+		wantErr bool
+	}{
+		{" 12.5 20480\n", 12.5, 20480 * 1024, false},
+		{"0,3 1024", 0.3, 1024 * 1024, false},
+		{"", 0, 0, true},
+		{"abc 1024", 0, 0, true},
+		{"1.0 abc", 0, 0, true},
+	}
+	for _, tt := range tests {
+		cpu, rss, err := parsePsOutput(tt.out)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePsOutput(%q) error = %v, wantErr %v", tt.out, err, tt.wantErr)
+			continue
+		}
+		if cpu != tt.cpu || rss != tt.rss {
+			t.Errorf("parsePsOutput(%q) = %v, %v, want %v, %v", tt.out, cpu, rss, tt.cpu, tt.rss)
+		}
+	}
+}
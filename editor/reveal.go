@@ -0,0 +1,172 @@
+package editor
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+// revealPath returns the path to act on, falling back to the cwd of the
+// workspace for the unnamed buffers
+func (w *Workspace) revealPath(args []interface{}) string {
+	path := ""
+	if len(args) > 0 {
+		path, _ = args[0].(string)
+	}
+	if path == "" {
+		return w.cwd
+	}
+
+	return path
+}
+
+// revealInFileManager shows the file selected in the file manager of the OS
+func revealInFileManager(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		if info.IsDir() {
+			cmd = exec.Command("open", path)
+		} else {
+			cmd = exec.Command("open", "-R", path)
+		}
+	case "windows":
+		if info.IsDir() {
+			cmd = exec.Command("explorer", path)
+		} else {
+			cmd = exec.Command("explorer", "/select,"+path)
+		}
+	default:
+		// The file managers implementing org.freedesktop.FileManager1
+		// select the file, otherwise the directory is opened
+		u := url.URL{Scheme: "file", Path: path}
+		cmd = exec.Command(
+			"dbus-send", "--session", "--type=method_call",
+			"--dest=org.freedesktop.FileManager1",
+			"/org/freedesktop/FileManager1",
+			"org.freedesktop.FileManager1.ShowItems",
+			"array:string:"+u.String(), "string:",
+		)
+		if _, err := exec.LookPath("dbus-send"); err != nil || cmd.Run() != nil {
+			cmd = exec.Command("xdg-open", revealDir(path, info.IsDir()))
+		} else {
+			return nil
+		}
+	}
+
+	return startDetached(cmd)
+}
+
+// openTerminal opens the terminal in the directory of the path. The command
+// of workspace.terminal is run by the shell if it is set.
+func openTerminal(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	dir := revealDir(path, info.IsDir())
+
+	var cmd *exec.Cmd
+	command := editor.config.Workspace.Terminal
+	switch {
+	case command != "" && runtime.GOOS == "windows":
+		cmd = exec.Command("cmd", "/C", command)
+	case command != "":
+		cmd = exec.Command("sh", "-c", command)
+	case runtime.GOOS == "darwin":
+		cmd = exec.Command("open", "-a", "Terminal", dir)
+	case runtime.GOOS == "windows":
+		cmd = exec.Command("cmd", "/C", "start", "cmd")
+	default:
+		terminal := os.Getenv("TERMINAL")
+		if terminal == "" {
+			terminal = "x-terminal-emulator"
+		}
+		cmd = exec.Command(terminal)
+	}
+	cmd.Dir = dir
+
+	return startDetached(cmd)
+}
+
+// revealDir returns the directory of the file, or the path if it is a directory
+func revealDir(path string, isDir bool) string {
+	if isDir {
+		return path
+	}
+
+	return filepath.Dir(path)
+}
+
+func startDetached(cmd *exec.Cmd) error {
+	err := cmd.Start()
+	if err != nil {
+		return err
+	}
+	go cmd.Wait()
+
+	return nil
+}
+
+// revealLabel returns the name of the reveal action on the OS
+func revealLabel() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "Reveal in Finder"
+	case "windows":
+		return "Show in Explorer"
+	default:
+		return "Open Containing Folder"
+	}
+}
+
+func (w *Workspace) reveal(args []interface{}) {
+	path := w.revealPath(args)
+	if err := revealInFileManager(path); err != nil {
+		editor.pushNotification(NotifyWarn, -1, fmt.Sprintf("[Goneovim] Failed to reveal %s: %s", path, err))
+	}
+}
+
+func (w *Workspace) terminalHere(args []interface{}) {
+	path := w.revealPath(args)
+	if err := openTerminal(path); err != nil {
+		editor.pushNotification(NotifyWarn, -1, fmt.Sprintf("[Goneovim] Failed to open the terminal: %s", err))
+	}
+}
+
+// showContextMenu shows the menu of the file under the position, or of the
+// directory of the item if no file is there
+func (i *WorkspaceSideItem) showContextMenu(pos *core.QPoint) {
+	path := i.cwdpath
+	if item := i.content.ItemAt(pos); item != nil && item.Pointer() != nil {
+		path = filepath.Join(i.cwdpath, strings.TrimSuffix(item.Text(), "/"))
+	}
+	if path == "" {
+		return
+	}
+
+	menu := widgets.NewQMenu(i.content)
+	menu.AddAction(revealLabel()).ConnectTriggered(func(bool) {
+		if err := revealInFileManager(path); err != nil {
+			editor.pushNotification(NotifyWarn, -1, fmt.Sprintf("[Goneovim] Failed to reveal %s: %s", path, err))
+		}
+	})
+	menu.AddAction("Open Terminal Here").ConnectTriggered(func(bool) {
+		if err := openTerminal(path); err != nil {
+			editor.pushNotification(NotifyWarn, -1, fmt.Sprintf("[Goneovim] Failed to open the terminal: %s", err))
+		}
+	})
+	menu.Popup(i.content.Viewport().MapToGlobal(pos), nil)
+}
@@ -0,0 +1,106 @@
+package editor
+
+import (
+	"math"
+)
+
+// The rows written by grid_line are hashed with the chars and the attributes
+// of the cells, and the rows whose hash is unchanged are not repainted. nvim
+// sends the whole line for many changes which don't change its look, e.g.
+// the statusline updates and the moves of the cursorline.
+
+const (
+	rowHashOffset = 14695981039346656037
+	rowHashPrime  = 1099511628211
+)
+
+// rowChanged stores the hash of the row, and returns true if the row may look
+// different from the last time
+func (w *Window) rowChanged(row int) bool {
+	if len(w.rowHashes) != w.rows {
+		w.rowHashes = make([]uint64, w.rows)
+	}
+	if row >= len(w.content) || row >= len(w.rowHashes) {
+		return true
+	}
+	hash := rowHash(w.content[row])
+	if hash == w.rowHashes[row] {
+		return false
+	}
+	w.rowHashes[row] = hash
+
+	return true
+}
+
+// invalidateRowHashes forgets the hashes of the rows, whose pixels no longer
+// match the hashed content, e.g. after the scroll or the clear
+func (w *Window) invalidateRowHashes(top, bot int) {
+	for row := top; row <= bot && row < len(w.rowHashes); row++ {
+		if row >= 0 {
+			w.rowHashes[row] = 0
+		}
+	}
+}
+
+// rowHash returns the FNV-1a hash of the row. It is never 0, which is the
+// hash of the unknown rows.
+func rowHash(line []*Cell) uint64 {
+	h := uint64(rowHashOffset)
+	for _, cell := range line {
+		if cell == nil {
+			h = hashUint64(h, 0)
+			continue
+		}
+		h = hashString(h, cell.char)
+		hl := &cell.highlight
+		h = hashUint64(h, uint64(hl.id))
+		// The default colors are resolved, as the rows are redrawn with the
+		// same cells when they are changed
+		h = hashRGBA(h, hl.fg())
+		h = hashRGBA(h, hl.bg())
+		h = hashRGBA(h, hl.special)
+		var flags uint64
+		for i, flag := range []bool{hl.reverse, hl.italic, hl.bold, hl.underline, hl.undercurl, hl.strikethrough, hl.visual} {
+			if flag {
+				flags |= 1 << uint(i)
+			}
+		}
+		h = hashUint64(h, flags)
+	}
+	if h == 0 {
+		h = 1
+	}
+
+	return h
+}
+
+func hashString(h uint64, s string) uint64 {
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= rowHashPrime
+	}
+	// Separate the strings, e.g. "ab" + "c" and "a" + "bc"
+	h ^= 0xff
+	h *= rowHashPrime
+
+	return h
+}
+
+func hashUint64(h, v uint64) uint64 {
+	for i := 0; i < 8; i++ {
+		h ^= v & 0xff
+		h *= rowHashPrime
+		v >>= 8
+	}
+
+	return h
+}
+
+func hashRGBA(h uint64, c *RGBA) uint64 {
+	if c == nil {
+		return hashUint64(h, 1<<63)
+	}
+	h = hashUint64(h, uint64(c.R)<<16|uint64(c.G)<<8|uint64(c.B))
+
+	return hashUint64(h, math.Float64bits(c.A))
+}
@@ -0,0 +1,40 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestRowHash(t *testing.T) {
+	fg := newRGBA(200, 200, 200, 1)
+	bg := newRGBA(10, 10, 10, 1)
+	cursorline := newRGBA(30, 30, 30, 1)
+	row := func(bg *RGBA, chars ...string) []*Cell {
+		line := make([]*Cell, len(chars)+1)
+		for i, char := range chars {
+			line[i] = &Cell{
+				normalWidth: true,
+				char:        char,
+				highlight:   Highlight{foreground: fg, background: bg},
+			}
+		}
+		return line
+	}
+
+	if rowHash(row(bg, "a", "b")) != rowHash(row(bg, "a", "b")) {
+		t.Errorf("the same rows have the different hashes")
+	}
+	if rowHash(row(bg, "ab", "c")) == rowHash(row(bg, "a", "bc")) {
+		t.Errorf("the rows of the different chars have the same hash")
+	}
+	if rowHash(row(bg, "a", "b")) == rowHash(row(cursorline, "a", "b")) {
+		t.Errorf("the rows of the different backgrounds have the same hash")
+	}
+	bold := row(bg, "a", "b")
+	bold[0].highlight.bold = true
+	if rowHash(row(bg, "a", "b")) == rowHash(bold) {
+		t.Errorf("the rows of the different attributes have the same hash")
+	}
+	if rowHash(nil) == 0 {
+		t.Errorf("the hash is 0, which is the hash of the unknown rows")
+	}
+}
@@ -1,7 +1,6 @@
 package editor
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"math"
@@ -12,10 +11,10 @@ import (
 	"time"
 
 	"github.com/akiyosi/goneovim/util"
-	"github.com/bluele/gcache"
 	"github.com/neovim/go-client/nvim"
 	"github.com/therecipe/qt/core"
 	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/svg"
 	"github.com/therecipe/qt/widgets"
 )
 
@@ -36,18 +35,19 @@ type Highlight struct {
 	underline     bool
 	undercurl     bool
 	strikethrough bool
+	// blend is the transparency in 0..100 of the background in the floating
+	// windows, from 'winblend' and 'pumblend'
+	blend int
+	// visual is true if the highlight is combined with the visual selection
+	visual bool
+	// spell is the spell highlight group combined in the highlight, e.g.
+	// "SpellBad"
+	spell string
+	// whitespace is the whitespace group combined in the highlight, e.g.
+	// "GonvimWhitespaceTrail"
+	whitespace string
 }
 
-// HlChar is used in screen cache
-type HlChars struct {
-	text string
-	fg   *RGBA
-	// bg     *RGBA
-	italic bool
-	bold   bool
-}
-
-
 // Cell is
 type Cell struct {
 	normalWidth bool
@@ -58,6 +58,9 @@ type Cell struct {
 // Window is
 type Window struct {
 	rwMutex     sync.RWMutex
+	// paintMutex guards content against the redraw worker, which writes
+	// grid_line off the GUI thread. The readers on the GUI thread outside of
+	// the paint read the cells by cellAt and rowAt, or take the lock.
 	paintMutex  sync.Mutex
 	redrawMutex sync.Mutex
 
@@ -79,21 +82,57 @@ type Window struct {
 
 	isMsgGrid  bool
 	isFloatWin bool
+	// blended is true if the floating window is drawn over the grid with
+	// the translucent backgrounds of the blend highlight attribute
+	blended bool
+
+	msgScroll     int
+	msgScrollDust int
+	msgFirstRow   int
+	msgCopyButton *svg.QSvgWidget
 
 	widget           *widgets.QWidget
 	shown            bool
 	queueRedrawArea  [4]int
+	rowHashes        []uint64
+	blit             *scrollBlit
 	scrollRegion     []int
 	scrollDust       [2]int
 	scrollDustDeltaY int
+	scrollAnim       *scrollAnim
+	geomAnim         *winAnim
 	devicePixelRatio float64
-	textCache        gcache.Cache
 
 	font         *Font
 	background   *RGBA
 	width        float64
 	height       int
 	localWindows *[4]localWindow
+	colorColumn  *colorColumn
+	readOnly     bool
+	// emptyBuffer is true if the buffer of the window is empty, and
+	// watermarkTextoff is the width of its number and sign columns
+	emptyBuffer      bool
+	watermarkTextoff int
+	// bidi is the shaping mode of the RTL text of the buffer
+	bidi int
+	winhl        *winhighlight
+	hscroll      *hscroll
+	blockVisual  *blockVisual
+	// isFontScaled is true while the grid is resized by the change of
+	// its font, which doesn't change the size of the window in pixels
+	isFontScaled bool
+	// fontResize is the size of the grid last requested by the change of its
+	// font, which the resizes sent one by one catch up with
+	fontResize fontResize
+}
+
+// fontResize coalesces the resizes of the grid by the change of its font
+type fontResize struct {
+	mu      sync.Mutex
+	size    [2]int
+	sent    [2]int
+	sending bool
 }
 
 type localWindow struct {
@@ -121,31 +160,46 @@ type Screen struct {
 
 	tooltip *widgets.QLabel
 
-	textCache       gcache.Cache
+	atlas           *glyphAtlas
+
+	mouseGrid gridId
+	fontDrag  *gridFontDrag
+	textDrag  *textDrag
+	mouseMove *mouseMove
+	// reflow previews the prose windows while the GUI is resized
+	reflow *reflowPreview
+	// spellHover pops up the suggestions for the misspelled words
+	spellHover *spellHover
 
 	resizeCount uint
 }
 
+const (
+	gridFontMinSize = 4.0
+	gridFontMaxSize = 96.0
+)
+
+// gridFontDrag is the state of Ctrl-dragging the border of a window
+// which has its own font, to scale the font of the window
+type gridFontDrag struct {
+	win      *Window
+	vertical bool
+	start    int
+	extent   int
+	size     float64
+}
+
 func newScreen() *Screen {
 	widget := widgets.NewQWidget(nil, 0)
 	widget.SetContentsMargins(0, 0, 0, 0)
 	widget.SetStyleSheet(" * { background-color: rgba(0, 0, 0, 0);}")
 
-	purgeQimage := func(key, value interface{}) {
-		image := value.(*gui.QImage)
-		image.DestroyQImage()
-	}
-	cache := gcache.New(editor.config.Editor.CacheSize).LRU().
-		EvictedFunc(purgeQimage).
-		PurgeVisitorFunc(purgeQimage).
-		Build()
-
 	screen := &Screen{
 		widget: widget,
 		windows:        sync.Map{},
 		cursor:         [2]int{0, 0},
 		highlightGroup: make(map[string]int),
-		textCache:      cache,
+		atlas:          newGlyphAtlas(editor.config.Editor.CacheSize, editor.config.Editor.CacheMemory),
 	}
 
 	widget.SetAcceptDrops(true)
@@ -158,6 +212,10 @@ func newScreen() *Screen {
 	widget.ConnectResizeEvent(func(event *gui.QResizeEvent) {
 		screen.updateSize()
 	})
+	if editor.config.Spell.Hover {
+		screen.spellHover = newSpellHover(screen)
+	}
+	widget.SetMouseTracking(screen.hoverTracking())
 
 	return screen
 }
@@ -204,11 +262,10 @@ func (s *Screen) dropEvent(e *gui.QDropEvent) {
 					}
 				}
 
-				if bufName != "" {
-					s.howToOpen(filepath)
-				} else {
-					fileOpenInBuf(filepath)
-				}
+				s.ws.emitGuiEvent(guiEventDropFile, map[string]interface{}{
+					"path": filepath,
+				})
+				s.guardDrop(filepath, bufName != "")
 			default:
 			}
 		}
@@ -320,9 +377,32 @@ func (s *Screen) updateSize() {
 		return true
 	})
 
+	if s.deferResize(currentCols, currentRows) {
+		return
+	}
 	s.uiTryResize(currentCols, currentRows)
 }
 
+// refreshFont fits the windows to the changed font. nvim resizes only the
+// grids whose cols or rows change, so the others are fitted here.
+func (s *Screen) refreshFont() {
+	s.windows.Range(func(_, winITF interface{}) bool {
+		win := winITF.(*Window)
+		if win == nil || win.font != nil {
+			return true
+		}
+		font := win.getFont()
+		width := int(float64(win.cols) * font.truewidth)
+		height := win.rows * font.lineHeight
+		win.setGeometryAndPalette(core.NewQRect4(0, 0, width, height))
+		win.move(win.pos[0], win.pos[1])
+		win.queueRedrawAll()
+		win.update()
+
+		return true
+	})
+}
+
 func (s *Screen) uiTryResize(width, height int) {
 	if width <= 0 || height <= 0 {
 		return
@@ -405,6 +485,12 @@ func (s *Screen) gridFont(update interface{}) {
 		}
 	}
 
+	s.setGridFont(win, fontfamily, float64(height))
+}
+
+// setGridFont sets the font of the window, and resizes the grid so that
+// the window keeps its size in pixels
+func (s *Screen) setGridFont(win *Window, family string, size float64) {
 	oldWidth := float64(win.cols) * win.getFont().truewidth
 	oldHeight := win.rows * win.getFont().lineHeight
 	win.width = oldWidth
@@ -412,48 +498,152 @@ func (s *Screen) gridFont(update interface{}) {
 	win.localWindows = &[4]localWindow{}
 
 	// fontMetrics := gui.NewQFontMetricsF(gui.NewQFont2(fontfamily, height, 1, false))
-	win.font = initFontNew(fontfamily, float64(height), 1, false)
+	win.font = initFontNew(family, size, 1, false)
+	win.font.setLetterSpace(s.ws.letterspace)
 
 	// Calculate new cols, rows of current grid
 	newCols := int(oldWidth / win.font.truewidth)
 	newRows := oldHeight / win.font.lineHeight
 
-	// Cache
-	if win.textCache == nil {
-		purgeQimage := func(key, value interface{}) {
-			image := value.(*gui.QImage)
-			image.DestroyQImage()
-		}
-		cache := gcache.New(editor.config.Editor.CacheSize).LRU().
-			EvictedFunc(purgeQimage).
-			PurgeVisitorFunc(purgeQimage).
-			Build()
-		win.textCache = cache
-	} else {
-		win.textCache.Purge()
-	}
+	// The neighbor windows measure this window again with the new font
+	s.windows.Range(func(_, winITF interface{}) bool {
+		w := winITF.(*Window)
+		if w == nil || w.localWindows == nil {
+			return true
+		}
+		for i := range w.localWindows {
+			if w.localWindows[i].grid == win.grid {
+				w.localWindows[i].isResized = false
+			}
+		}
+		return true
+	})
 
-	_ = s.ws.nvim.TryResizeUIGrid(s.ws.cursor.gridid, newCols, newRows)
-	font := win.getFont()
-	s.ws.cursor.updateFont(font)
+	if newCols != win.cols || newRows != win.rows {
+		win.isFontScaled = true
+		s.resizeGridFont(win, newCols, newRows)
+	}
+	if s.ws.cursor.gridid == win.grid {
+		s.ws.cursor.updateFont(win.getFont())
+	}
+	win.update()
 }
 
-func (s *Screen) purgeTextCacheForWins() {
-	if !editor.config.Editor.CachedDrawing {
+// resizeGridFont resizes the grid off the GUI thread. The sizes requested
+// while a resize is sent, e.g. by dragging the font, are coalesced into the
+// last one.
+func (s *Screen) resizeGridFont(win *Window, cols, rows int) {
+	r := &win.fontResize
+	r.mu.Lock()
+	r.size = [2]int{cols, rows}
+	sending := r.sending
+	r.sending = true
+	r.mu.Unlock()
+	if sending {
 		return
 	}
-	s.textCache.Purge()
+
+	neovim := s.ws.nvim
+	go func() {
+		for {
+			r.mu.Lock()
+			size := r.size
+			if size == r.sent {
+				// The next change of the font sends its size even if nvim
+				// has resized the grid back to it since
+				r.sent = [2]int{}
+				r.sending = false
+				r.mu.Unlock()
+				return
+			}
+			r.sent = size
+			r.mu.Unlock()
+			neovim.TryResizeUIGrid(win.grid, size[0], size[1])
+		}
+	}()
+}
+
+// startGridFontDrag starts scaling the font of the window if the position is
+// on the right or bottom border of a window which has its own font
+func (s *Screen) startGridFontDrag(pos *core.QPoint) bool {
+	s.fontDrag = nil
 	s.windows.Range(func(_, winITF interface{}) bool {
 		win := winITF.(*Window)
-		if win == nil {
+		if win == nil || win.font == nil || win.widget == nil || !win.widget.IsVisible() {
 			return true
 		}
-		if win.font == nil {
+		local := win.widget.MapFrom(s.widget, pos)
+		width := int(float64(win.cols) * win.font.truewidth)
+		height := win.rows * win.font.lineHeight
+		vertical, ok := onGridBorder(local.X(), local.Y(), width, height, int(math.Ceil(win.font.truewidth)), win.font.lineHeight)
+		if !ok {
 			return true
 		}
-		win.textCache.Purge()
-		return true
+		drag := &gridFontDrag{
+			win:      win,
+			vertical: vertical,
+			start:    pos.X(),
+			extent:   width,
+			size:     win.font.fontNew.PointSizeF(),
+		}
+		if vertical {
+			drag.start = pos.Y()
+			drag.extent = height
+		}
+		s.fontDrag = drag
+		return false
 	})
+
+	return s.fontDrag != nil
+}
+
+// dragGridFont scales the font of the dragged window continuously
+func (s *Screen) dragGridFont(event *gui.QMouseEvent) {
+	drag := s.fontDrag
+	if event.Type() == core.QEvent__MouseButtonRelease {
+		s.fontDrag = nil
+	}
+	delta := event.Pos().X() - drag.start
+	if drag.vertical {
+		delta = event.Pos().Y() - drag.start
+	}
+	size := scaledFontSize(drag.size, drag.extent, delta)
+	if math.Abs(size-drag.win.font.fontNew.PointSizeF()) < 0.25 {
+		return
+	}
+	s.setGridFont(drag.win, drag.win.font.fontNew.Family(), size)
+}
+
+// onGridBorder reports whether the position local to a window is on its
+// right border or, if vertical, on its bottom border
+func onGridBorder(x, y, width, height, marginX, marginY int) (bool, bool) {
+	if y >= 0 && y <= height && x >= width-marginX && x <= width+marginX {
+		return false, true
+	}
+	if x >= 0 && x <= width && y >= height-marginY && y <= height+marginY {
+		return true, true
+	}
+
+	return false, false
+}
+
+// scaledFontSize returns the font size scaled by the ratio of the dragged
+// extent of the window
+func scaledFontSize(size float64, extent, delta int) float64 {
+	if extent <= 0 {
+		return size
+	}
+	scaled := size * float64(extent+delta) / float64(extent)
+
+	return math.Max(gridFontMinSize, math.Min(gridFontMaxSize, scaled))
+}
+
+func (s *Screen) purgeTextCacheForWins() {
+	if !editor.config.Editor.CachedDrawing {
+		return
+	}
+	s.atlas.purge()
+	s.ws.warmup.schedule()
 }
 
 func (s *Screen) toolTipPos() (int, int, int, int) {
@@ -525,6 +715,12 @@ func (s *Screen) toolTip(text string) {
 }
 
 func (w *Window) paint(event *gui.QPaintEvent) {
+	w.paintRect(event.Rect())
+}
+
+// paintRect paints the rect of the window. The OpenGL surface always paints
+// the whole window.
+func (w *Window) paintRect(rect *core.QRect) {
 	w.paintMutex.Lock()
 
 	p := gui.NewQPainter2(w.widget)
@@ -535,13 +731,12 @@ func (w *Window) paint(event *gui.QPaintEvent) {
 		w.devicePixelRatio = float64(p.PaintEngine().PaintDevice().DevicePixelRatio())
 	}
 
-	// Draw text with DrawText if screen name is "minimap" or CachedDrawing is false
-	if w.s.name == "minimap" || !editor.config.Editor.CachedDrawing {
+	// Draw text with DrawText if CachedDrawing is false
+	if !editor.config.Editor.CachedDrawing {
 		p.SetFont(font.fontNew)
 	}
 
 	// Draw contents
-	rect := event.Rect()
 	col := int(float64(rect.Left()) / font.truewidth)
 	row := int(float64(rect.Top()) / float64(font.lineHeight))
 	cols := int(math.Ceil(float64(rect.Width()) / font.truewidth))
@@ -551,9 +746,16 @@ func (w *Window) paint(event *gui.QPaintEvent) {
 		if y >= w.rows {
 			continue
 		}
-		w.fillBackground(p, y, col, cols)
-		w.drawContents(p, y, col, cols)
-		w.drawTextDecoration(p, y, col, cols)
+		w.paintRow(p, y, col, cols)
+	}
+
+	// Draw the watermark over the blank grid of the empty buffer
+	w.drawWatermark(p)
+
+	// Slide the scrolled region over the snapshot before the scroll
+	animating := w.scrollAnim.active()
+	if animating {
+		w.paintScrollAnim(p)
 	}
 
 	// If Window is Message Area, draw separator
@@ -570,13 +772,25 @@ func (w *Window) paint(event *gui.QPaintEvent) {
 	w.drawBorders(p, row, col, rows, cols)
 
 	// Draw indent guide
-	if editor.config.Editor.IndentGuide {
+	if editor.config.Editor.IndentGuide && !animating {
 		w.drawIndentguide(p, row, rows)
 	}
 
-	// Update markdown preview
+	// Draw the rectangle of the blockwise visual selection
+	if !animating {
+		w.drawBlockVisual(p)
+	}
+
+	// Draw horizontal scroll indicators of the 'nowrap' window
+	if editor.config.HorizontalScroll.Visible {
+		w.drawHScroll(p)
+	}
+
+	// Update markdown preview, the reading mode pane and the help reader
 	if w.grid != 1 {
 		w.s.ws.markdown.updatePos()
+		w.s.ws.reading.updatePos()
+		w.s.ws.helpReader.updatePos()
 	}
 
 	// Reset to 0 after drawing is complete.
@@ -595,6 +809,29 @@ func (w *Window) paint(event *gui.QPaintEvent) {
 
 }
 
+func (w *Window) paintRow(p *gui.QPainter, y int, col int, cols int) {
+	w.fillWinhlBackground(p, y)
+	if y >= len(w.content) {
+		return
+	}
+	line := w.content[y]
+	// The cells from col to col+cols are repainted
+	cols++
+	r := w.newWindowRenderer(p)
+	renderBackground(r, line, y, col, cols, editor.colors.fg, editor.colors.bg)
+	if w.readOnly {
+		w.drawReadOnlyTint(p, y)
+	}
+	if editor.config.ColorColumn.Style != "" {
+		w.drawColorColumn(p, y)
+	}
+	if editor.config.Whitespace.Enable {
+		w.drawWhitespace(p, y)
+	}
+	r.drawContents(line, y, col, cols)
+	renderDecorations(r, line, y, col, cols, editor.colors.fg, editor.colors.bg)
+}
+
 func (w *Window) getFont() *Font {
 	if w.font == nil {
 		return w.s.font
@@ -777,7 +1014,7 @@ func (w *Window) drawMsgSeparator(p *gui.QPainter) {
 	p.FillRect4(
 		core.NewQRectF4(
 			0,
-			0,
+			float64(w.msgFirstRow*w.s.font.lineHeight),
 			float64(w.widget.Width()),
 			1,
 		),
@@ -958,6 +1195,12 @@ func (win *Window) wheelEvent(event *gui.QWheelEvent) {
 	var horizKey string
 	font := win.getFont()
 
+	// Scroll the messages if the window is message area
+	if win.isMsgGrid {
+		win.msgGridWheelEvent(event)
+		return
+	}
+
 	// Detect current mode
 	mode := win.s.ws.mode
 	if mode == "terminal-input" {
@@ -996,14 +1239,14 @@ func (win *Window) wheelEvent(event *gui.QWheelEvent) {
 		return
 	}
 	if vert > 0 {
-		vertKey = "Up"
+		vertKey = "up"
 	} else {
-		vertKey = "Down"
+		vertKey = "down"
 	}
 	if horiz > 0 {
-		horizKey = "Left"
+		horizKey = "left"
 	} else {
-		horizKey = "Right"
+		horizKey = "right"
 	}
 
 	// If the window at the mouse pointer is not the current window
@@ -1022,18 +1265,16 @@ func (win *Window) wheelEvent(event *gui.QWheelEvent) {
 	}
 
 
-	mod := event.Modifiers()
+	mod := editor.modPrefix(event.Modifiers())
+	// The position of the event is local to the widget of the window
+	row, col := cellAt(event.Pos(), font)
 
 	if win.s.ws.isMappingScrollKey {
 		if vert != 0 {
-			win.s.ws.nvim.Input(fmt.Sprintf("<%sScrollWheel%s>", editor.modPrefix(mod), vertKey))
+			win.s.ws.inputQueue.mouse("wheel", vertKey, mod, win.grid, row, col)
 		}
 	} else {
-		if vert > 0 {
-			win.s.ws.nvim.Input(fmt.Sprintf("%v<C-y>", int(math.Abs(float64(vert)))))
-		} else if vert < 0 {
-			win.s.ws.nvim.Input(fmt.Sprintf("%v<C-e>", int(math.Abs(float64(vert)))))
-		}
+		win.s.ws.inputQueue.scroll(vert)
 	}
 
 	// Do not scroll horizontal if vertical scroll amount is greater than horizontal that
@@ -1041,12 +1282,8 @@ func (win *Window) wheelEvent(event *gui.QWheelEvent) {
 		return
 	}
 
-	x := int(float64(event.X()) / font.truewidth)
-	y := int(float64(event.Y()) / float64(font.lineHeight))
-	pos := []int{x, y}
-
 	if horiz != 0 {
-		win.s.ws.nvim.Input(fmt.Sprintf("<%sScrollWheel%s><%d,%d>", editor.modPrefix(mod), horizKey, pos[0], pos[1]))
+		win.s.ws.inputQueue.mouse("wheel", horizKey, mod, win.grid, row, col)
 	}
 
 	event.Accept()
@@ -1113,7 +1350,27 @@ func (w *Window) smoothUpdate(v, h int, isStopScroll bool) (int, int) {
 }
 
 func (s *Screen) mousePressEvent(event *gui.QMouseEvent) {
+	editor.focusWorkspace(s.ws)
+	switch event.Button() {
+	case core.Qt__BackButton:
+		s.ws.navigation.goBack()
+		return
+	case core.Qt__ForwardButton:
+		s.ws.navigation.goForward()
+		return
+	}
+	if event.Button() == core.Qt__LeftButton && event.Modifiers()&core.Qt__ControlModifier > 0 {
+		if s.startGridFontDrag(event.Pos()) {
+			return
+		}
+	}
+	if event.Button() == core.Qt__LeftButton && s.startTextDrag(event) {
+		return
+	}
 	s.mouseEvent(event)
+	if editor.config.Editor.GitHunkPopup {
+		s.ws.hunkPopup.gutterClicked(event)
+	}
 	if !editor.config.Editor.ClickEffect {
 		return
 	}
@@ -1207,61 +1464,157 @@ func (s *Screen) mousePressEvent(event *gui.QMouseEvent) {
 }
 
 func (s *Screen) mouseEvent(event *gui.QMouseEvent) {
-	inp := s.convertMouse(event)
-	if inp == "" {
+	if s.fontDrag != nil {
+		s.dragGridFont(event)
 		return
 	}
-	s.ws.nvim.Input(inp)
+	if s.holdTextDrag(event) {
+		return
+	}
+	if event.Type() == core.QEvent__MouseMove && event.Buttons() == core.Qt__NoButton {
+		s.mouseMoved(event)
+		return
+	}
+	button, action := mouseButtonAction(event.Type(), event.Button(), event.Buttons())
+	if button == "" {
+		return
+	}
+
+	// Drag and release are sent to the grid where the button was pressed
+	var win *Window
+	if action == "press" {
+		win = s.windowAt(event.Pos())
+		s.mouseGrid = 0
+		if win != nil {
+			s.mouseGrid = win.grid
+		}
+	} else {
+		win, _ = s.getWindow(s.mouseGrid)
+	}
+
+	grid, row, col := s.gridPos(win, event.Pos())
+	s.ws.inputQueue.mouse(button, action, editor.modPrefix(event.Modifiers()), grid, row, col)
 }
 
-func (s *Screen) convertMouse(event *gui.QMouseEvent) string {
-	font := s.font
-	x := int(float64(event.X()) / font.truewidth)
-	y := int(float64(event.Y()) / float64(font.lineHeight))
-	pos := []int{x, y}
+// windowAt returns the window displayed at the position of the screen widget
+func (s *Screen) windowAt(pos *core.QPoint) *Window {
+	child := s.widget.ChildAt(pos)
+	for child != nil && child.Pointer() != s.widget.Pointer() {
+		var found *Window
+		s.windows.Range(func(_, winITF interface{}) bool {
+			win := winITF.(*Window)
+			if win == nil || win.widget == nil {
+				return true
+			}
+			if win.widget.Pointer() == child.Pointer() {
+				found = win
+				return false
+			}
+			return true
+		})
+		if found != nil {
+			return found
+		}
+		child = child.ParentWidget()
+	}
+
+	return nil
+}
 
-	bt := event.Button()
-	if event.Type() == core.QEvent__MouseMove {
-		if event.Buttons()&core.Qt__LeftButton > 0 {
+// gridPos converts the position of the screen widget into the grid-local
+// row and column, using the font metrics of the window.
+func (s *Screen) gridPos(win *Window, pos *core.QPoint) (int, int, int) {
+	if win == nil {
+		font := s.font
+		return 1, int(float64(pos.Y()) / float64(font.lineHeight)), int(float64(pos.X()) / font.truewidth)
+	}
+	var local *core.QPoint
+	if win.isFloatWin {
+		// The widget of a float is not a descendant of the screen widget
+		local = win.widget.MapFromGlobal(s.widget.MapToGlobal(pos))
+	} else {
+		local = win.widget.MapFrom(s.widget, pos)
+	}
+	row, col := cellAt(local, win.getFont())
+
+	return win.grid, row, col
+}
+
+// mouseEvent sends the mouse event on the widget of the floating window.
+// Drag and release are sent to the grid where the button was pressed, as
+// Screen.mouseEvent does.
+func (win *Window) mouseEvent(event *gui.QMouseEvent) {
+	s := win.s
+	button, action := mouseButtonAction(event.Type(), event.Button(), event.Buttons())
+	if button == "" {
+		return
+	}
+
+	target := win
+	if action == "press" {
+		editor.focusWorkspace(s.ws)
+		s.mouseGrid = win.grid
+	} else if pressed, ok := s.getWindow(s.mouseGrid); ok {
+		target = pressed
+	}
+
+	row, col := cellAt(target.widget.MapFromGlobal(event.GlobalPos()), target.getFont())
+	s.ws.inputQueue.mouse(button, action, editor.modPrefix(event.Modifiers()), target.grid, row, col)
+}
+
+// cellAt returns the row and the column of the cell at the position local
+// to the widget of the window
+func cellAt(pos *core.QPoint, font *Font) (int, int) {
+	return cellIndex(pos.X(), pos.Y(), font.truewidth, font.lineHeight)
+}
+
+// cellIndex returns the row and the column of the cell at the pixel
+func cellIndex(x, y int, width float64, lineHeight int) (int, int) {
+	row := int(math.Floor(float64(y) / float64(lineHeight)))
+	col := int(math.Floor(float64(x) / width))
+
+	return row, col
+}
+
+// mouseButtonAction returns the button and action names of nvim_input_mouse
+func mouseButtonAction(evType core.QEvent__Type, bt core.Qt__MouseButton, buttons core.Qt__MouseButton) (string, string) {
+	if evType == core.QEvent__MouseMove {
+		if buttons&core.Qt__LeftButton > 0 {
 			bt = core.Qt__LeftButton
-		} else if event.Buttons()&core.Qt__RightButton > 0 {
+		} else if buttons&core.Qt__RightButton > 0 {
 			bt = core.Qt__RightButton
-		} else if event.Buttons()&core.Qt__MidButton > 0 {
+		} else if buttons&core.Qt__MidButton > 0 {
 			bt = core.Qt__MidButton
 		} else {
-			return ""
+			return "", ""
 		}
 	}
 
-	mod := event.Modifiers()
-	buttonName := ""
+	button := ""
 	switch bt {
 	case core.Qt__LeftButton:
-		buttonName += "Left"
+		button = "left"
 	case core.Qt__RightButton:
-		buttonName += "Right"
+		button = "right"
 	case core.Qt__MidButton:
-		buttonName += "Middle"
-	case core.Qt__NoButton:
+		button = "middle"
 	default:
-		return ""
+		return "", ""
 	}
 
-	evType := ""
-	switch event.Type() {
-	case core.QEvent__MouseButtonDblClick:
-		evType += "Mouse"
-	case core.QEvent__MouseButtonPress:
-		evType += "Mouse"
+	action := ""
+	switch evType {
+	case core.QEvent__MouseButtonDblClick, core.QEvent__MouseButtonPress:
+		action = "press"
 	case core.QEvent__MouseButtonRelease:
-		evType += "Release"
+		action = "release"
 	case core.QEvent__MouseMove:
-		evType += "Drag"
+		action = "drag"
 	default:
-		return ""
+		return "", ""
 	}
 
-	return fmt.Sprintf("<%s%s%s><%d,%d>", editor.modPrefix(mod), buttonName, evType, pos[0], pos[1])
+	return button, action
 }
 
 func (s *Screen) gridResize(args []interface{}) {
@@ -1324,12 +1677,10 @@ func (s *Screen) resizeWindow(gridid gridId, cols int, rows int) {
 		win.grid = gridid
 
 		// set scroll
-		if s.name != "minimap" {
-			win.widget.ConnectWheelEvent(win.wheelEvent)
-		}
+		win.widget.ConnectWheelEvent(win.wheelEvent)
 
 		// first cursor pos at startup app
-		if gridid == 1 && s.name != "minimap" {
+		if gridid == 1 {
 			s.ws.cursor.widget.SetParent(win.widget)
 		}
 	}
@@ -1342,6 +1693,7 @@ func (s *Screen) resizeWindow(gridid gridId, cols int, rows int) {
 	win.content = content
 	win.cols = cols
 	win.rows = rows
+	win.queueRedrawAll()
 
 	s.resizeIndependentFontGrid(win, winOldCols, winOldRows)
 
@@ -1357,6 +1709,10 @@ func (s *Screen) resizeWindow(gridid gridId, cols int, rows int) {
 }
 
 func (s *Screen) resizeIndependentFontGrid(win *Window, oldCols, oldRows int) {
+	if win.isFontScaled {
+		win.isFontScaled = false
+		return
+	}
 	var isExistMsgGrid bool
 	s.windows.Range(func(_, winITF interface{}) bool {
 		win := winITF.(*Window)
@@ -1594,6 +1950,12 @@ func (s *Screen) setHighlightGroup(args []interface{}) {
 }
 
 func (s *Screen) getHighlight(args interface{}) *Highlight {
+	return parseHighlight(args, s.ws.foreground, s.ws.background)
+}
+
+// parseHighlight returns the highlight of the args of hl_attr_define, with
+// the default colors for the unset ones
+func parseHighlight(args interface{}, defaultFg, defaultBg *RGBA) *Highlight {
 	arg := args.([]interface{})
 	highlight := Highlight{}
 
@@ -1624,6 +1986,20 @@ func (s *Screen) getHighlight(args interface{}) *Highlight {
 		highlight.hlName = hlName.(string)
 	}
 
+	for _, arg := range arg[3].([]interface{}) {
+		state, ok := arg.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch state["hi_name"] {
+		case "Visual", "VisualNOS":
+			highlight.visual = true
+		}
+	}
+
+	highlight.spell = spellKind(arg[3].([]interface{}))
+	highlight.whitespace = whitespaceKind(arg[3].([]interface{}))
+
 	italic := hl["italic"]
 	if italic != nil {
 		highlight.italic = true
@@ -1672,7 +2048,7 @@ func (s *Screen) getHighlight(args interface{}) *Highlight {
 		highlight.foreground = rgba
 	}
 	if highlight.foreground == nil {
-		highlight.foreground = s.ws.foreground
+		highlight.foreground = defaultFg
 	}
 
 	bg, ok := hl["background"]
@@ -1681,7 +2057,7 @@ func (s *Screen) getHighlight(args interface{}) *Highlight {
 		highlight.background = rgba
 	}
 	if highlight.background == nil {
-		highlight.background = s.ws.background
+		highlight.background = defaultBg
 	}
 
 	sp, ok := hl["special"]
@@ -1690,7 +2066,10 @@ func (s *Screen) getHighlight(args interface{}) *Highlight {
 		highlight.special = rgba
 	}
 
-	// TODO: brend, ok := hl["blend"]
+	blend, ok := hl["blend"]
+	if ok {
+		highlight.blend = util.ReflectToInt(blend)
+	}
 
 	return &highlight
 }
@@ -1753,6 +2132,7 @@ func (s *Screen) gridClear(args []interface{}) {
 			win.content[i] = make([]*Cell, win.cols)
 			win.lenContent[i] = win.cols - 1
 		}
+		win.queueRedrawAll()
 	}
 }
 
@@ -1775,7 +2155,7 @@ func (s *Screen) updateGridContent(arg []interface{}) {
 	if isSkipGlobalId(gridid) {
 		return
 	}
-	if editor.config.Editor.DrawBorder && gridid == 1 && s.name != "minimap" {
+	if editor.config.Editor.DrawBorder && gridid == 1 {
 		return
 	}
 	if colStart < 0 {
@@ -1793,6 +2173,9 @@ func (s *Screen) updateGridContent(arg []interface{}) {
 	cells := arg[3].([]interface{})
 	win.updateLine(colStart, row, cells)
 	win.countContent(row)
+	if win.rowChanged(row) {
+		win.queueRedraw(0, row, win.cols, 1)
+	}
 	if !win.isShown() {
 		win.show()
 	}
@@ -1809,23 +2192,22 @@ func (s *Screen) updateGridContent(arg []interface{}) {
 }
 
 func (w *Window) updateLine(col, row int, cells []interface{}) {
+	w.writeLine(col, row, newGridCells(cells), false)
+}
+
+// writeLine writes the cells of grid_line to the row. If deferWidth is true,
+// e.g. on the redraw worker, the widths of the non-ASCII chars are not
+// measured, and it returns true if the row has them.
+func (w *Window) writeLine(col, row int, cells []gridCell, deferWidth bool) bool {
 	line := w.content[row]
-	for _, arg := range cells {
+	wide := false
+	for _, cell := range cells {
 		if col >= len(line) {
 			continue
 		}
-		cell := arg.([]interface{})
 
-		var hl, repeat int
-		hl = -1
-		text := cell[0]
-		if len(cell) >= 2 {
-			hl = util.ReflectToInt(cell[1])
-		}
-
-		if len(cell) == 3 {
-			repeat = util.ReflectToInt(cell[2])
-		}
+		hl := cell.hl
+		repeat := cell.repeat
 
 		// If `repeat` is present, the cell should be
 		// repeated `repeat` times (including the first time), otherwise just
@@ -1843,8 +2225,19 @@ func (w *Window) updateLine(col, row int, cells []interface{}) {
 				line[col] = &Cell{}
 			}
 
-			line[col].char = text.(string)
-			line[col].normalWidth = w.isNormalWidth(line[col].char)
+			line[col].char = cell.text
+			if deferWidth && !isASCII(line[col].char) {
+				line[col].normalWidth = true
+				wide = true
+			} else if isEmoji(line[col].char) && emojiFontFamily() != "" {
+				// The emoji is double width if the right half follows it
+				line[col].normalWidth = true
+			} else {
+				line[col].normalWidth = w.isNormalWidth(line[col].char)
+			}
+			if cell.text == "" && !deferWidth && col > 0 && line[col-1] != nil && isEmoji(line[col-1].char) {
+				line[col-1].normalWidth = w.cellNormalWidth(line, col-1)
+			}
 
 			// If `hl_id` is not present the most recently seen `hl_id` in
 			//	the same call should be used (it is always sent for the first
@@ -1865,6 +2258,7 @@ func (w *Window) updateLine(col, row int, cells []interface{}) {
 		}
 	}
 
+	return wide
 }
 
 func (w *Window) countContent(row int) {
@@ -1984,7 +2378,9 @@ func (s *Screen) gridScroll(args []interface{}) {
 		win.scrollRegion[2] = util.ReflectToInt(arg.([]interface{})[3])     // left
 		win.scrollRegion[3] = util.ReflectToInt(arg.([]interface{})[4]) - 1 // right
 		rows = util.ReflectToInt(arg.([]interface{})[5])
+		win.startScrollAnim(rows)
 		win.scroll(rows)
+		win.queueRedrawScroll(rows)
 	}
 }
 
@@ -2047,9 +2443,17 @@ func (w *Window) update() {
 	if w == nil {
 		return
 	}
+	w.updateRows(0, w.rows)
+}
+
+// updateRows repaints the rows from top to bot. The rows are updated in a
+// single rectangle as wide as the widest row, which is the area Qt repaints
+// for the region of the rows anyway.
+func (w *Window) updateRows(top, bot int) {
 	font := w.getFont()
 
-	for i := 0; i <= w.rows; i++ {
+	first, last, maxWidth := -1, -1, 0
+	for i := top; i <= bot; i++ {
 		if len(w.content) <= i {
 			continue
 		}
@@ -2069,11 +2473,6 @@ func (w *Window) update() {
 			}
 		}
 
-		// If screen is minimap
-		if w.s.name == "minimap" {
-			width = w.cols
-		}
-
 		// If scroll is smooth
 		if w.scrollDust[1] != 0 {
 			width = w.maxLenContent
@@ -2081,13 +2480,22 @@ func (w *Window) update() {
 
 		width++
 
-		w.widget.Update2(
-			0,
-			i * font.lineHeight,
-			int(float64(width) * font.truewidth),
-			font.lineHeight,
-		)
+		if first < 0 {
+			first = i
+		}
+		last = i
+		maxWidth = maxInt(maxWidth, width)
 	}
+	if first < 0 {
+		return
+	}
+
+	w.widget.Update2(
+		0,
+		first*font.lineHeight,
+		int(float64(maxWidth)*font.truewidth),
+		(last-first+1)*font.lineHeight,
+	)
 }
 
 func (s *Screen) update() {
@@ -2109,8 +2517,9 @@ func (s *Screen) update() {
 		}
 		if win != nil {
 			// Fill entire background if background color changed
-			if !win.background.equals(s.ws.background) {
-				win.background = s.ws.background.copy()
+			bg := win.normalBackground()
+			if !win.background.equals(bg) {
+				win.background = bg.copy()
 				win.fill()
 			}
 			win.update()
@@ -2120,12 +2529,14 @@ func (s *Screen) update() {
 	})
 }
 
-// deprecated method
+// queueRedrawAll marks the whole window to repaint on the next flush
 func (w *Window) queueRedrawAll() {
 	w.queueRedrawArea = [4]int{0, 0, w.cols, w.rows}
+	w.rowHashes = nil
+	w.blit = nil
 }
 
-// deprecated method
+// queueRedraw adds the area in cells to repaint on the next flush
 func (w *Window) queueRedraw(x, y, width, height int) {
 	if x < w.queueRedrawArea[0] {
 		w.queueRedrawArea[0] = x
@@ -2141,513 +2552,6 @@ func (w *Window) queueRedraw(x, y, width, height int) {
 	}
 }
 
-func (w *Window) fillBackground(p *gui.QPainter, y int, col int, cols int) {
-	if y >= len(w.content) {
-		return
-	}
-	font := w.getFont()
-	line := w.content[y]
-	var bg *RGBA
-
-	// draw default background color if window is float window or msg grid
-	idDrawDefaultBg := false
-	if w.isFloatWin || (w.isMsgGrid && editor.config.Message.Transparent < 1.0) {
-		idDrawDefaultBg = true
-	}
-
-	// // Simply paint the color into a rectangle
-	// for x := col; x <= col+cols; x++ {
-	// 	if x >= len(line) {
-	// 		continue
-	// 	}
-
-	// 	var highlight *Highlight
-	// 	if line[x] == nil {
-	// 		highlight = w.s.hlAttrDef[0]
-	// 	} else {
-	// 		highlight = &line[x].highlight
-	// 	}
-	// 	if !bg.equals(w.s.ws.background) || idDrawDefaultBg {
-	// 	     // Set diff pattern
-	// 	     pattern, color, transparent := w.getFillpatternAndTransparent(highlight)
-	// 	     // Fill background with pattern
-	// 	     rectF := core.NewQRectF4(
-	// 	             float64(x)*font.truewidth,
-	// 	             float64((y)*font.lineHeight),
-	// 	             font.truewidth,
-	// 	             float64(font.lineHeight),
-	// 	     )
-	// 	     p.FillRect(
-	// 	             rectF,
-	// 	             gui.NewQBrush3(
-	// 	                     gui.NewQColor3(
-	// 	                             color.R,
-	// 	                             color.G,
-	// 	                             color.B,
-	// 	                             transparent,
-	// 	                     ),
-	// 	                     pattern,
-	// 	             ),
-	// 	     )
-	// 	}
-
-	// The same color combines the rectangular areas and paints at once
-	var start, end, width int
-	var lastBg *RGBA
-	var lastHighlight, highlight *Highlight
-
-	for x := col; x <= col+cols; x++ {
-		fillCellRect := func() {
-			if lastHighlight == nil {
-				return
-			}
-			width = end - start + 1
-			if width < 0 {
-				width = 0
-			}
-			if !idDrawDefaultBg && lastBg.equals(w.background) {
-				width = 0
-			}
-			if width > 0 {
-				// Set diff pattern
-				pattern, color, transparent := w.getFillpatternAndTransparent(lastHighlight)
-
-				// Fill background with pattern
-				rectF := core.NewQRectF4(
-					float64(start)*font.truewidth,
-					float64((y)*font.lineHeight+w.scrollDust[1]),
-					float64(width)*font.truewidth,
-					float64(font.lineHeight),
-				)
-				p.FillRect(
-					rectF,
-					gui.NewQBrush3(
-						gui.NewQColor3(
-							color.R,
-							color.G,
-							color.B,
-							transparent,
-						),
-						pattern,
-					),
-				)
-				width = 0
-			}
-		}
-
-		if x >= len(line)+1 {
-			continue
-		}
-
-		if x < len(line) {
-			if line[x] == nil {
-				highlight = w.s.hlAttrDef[0]
-			} else {
-				highlight = &line[x].highlight
-			}
-		} else {
-			highlight = w.s.hlAttrDef[0]
-		}
-
-		bg = highlight.bg()
-
-		if lastBg == nil {
-			start = x
-			end = x
-			lastBg = bg
-			lastHighlight = highlight
-		}
-		if lastBg != nil {
-			if lastBg.equals(bg) {
-				end = x
-			}
-			if !lastBg.equals(bg) || x == col+cols {
-				fillCellRect()
-
-				start = x
-				end = x
-				lastBg = bg
-				lastHighlight = highlight
-
-				if !lastBg.equals(bg) && x == col+cols {
-					fillCellRect()
-				}
-			}
-		}
-
-	}
-}
-
-func (w *Window) drawTextWithCache(p *gui.QPainter, y int, col int, cols int) {
-	if y >= len(w.content) {
-		return
-	}
-	wsfont := w.getFont()
-	// font := p.Font()
-	line := w.content[y]
-	chars := map[Highlight][]int{}
-	specialChars := []int{}
-	textCache := w.getCache()
-	var image *gui.QImage
-
-	for x := col; x <= col+cols; x++ {
-		if x >= len(line) {
-			continue
-		}
-		if line[x] == nil {
-			continue
-		}
-		if line[x].char == "" {
-			continue
-		}
-		if line[x].char == " " {
-			continue
-		}
-		if !line[x].normalWidth {
-			specialChars = append(specialChars, x)
-			continue
-		}
-
-		highlight := line[x].highlight
-		colorSlice, ok := chars[highlight]
-		if !ok {
-			colorSlice = []int{}
-		}
-		colorSlice = append(colorSlice, x)
-		chars[highlight] = colorSlice
-
-	}
-
-	pointF := core.NewQPointF3(
-		float64(col)*wsfont.truewidth,
-		float64(y*wsfont.lineHeight+w.scrollDust[1]),
-	)
-
-	for highlight, colorSlice := range chars {
-		var buffer bytes.Buffer
-		slice := colorSlice[:]
-		for x := col; x <= col+cols; x++ {
-			if len(slice) == 0 {
-				break
-			}
-			index := slice[0]
-			if x < index {
-				buffer.WriteString(" ")
-				continue
-			}
-			if x == index {
-				buffer.WriteString(line[x].char)
-				slice = slice[1:]
-			}
-		}
-
-		text := buffer.String()
-		if text != "" {
-			imagev, err := textCache.Get(HlChars{
-				text:   text,
-				fg:     highlight.fg(),
-				italic: highlight.italic,
-				bold:   highlight.bold,
-			})
-			if err != nil {
-				image = w.newTextCache(text, highlight, true)
-			} else {
-				image = imagev.(*gui.QImage)
-			}
-			p.DrawImage7(
-				pointF,
-				image,
-			)
-		}
-
-	}
-
-	for _, x := range specialChars {
-		if line[x] == nil || line[x].char == " " {
-			continue
-		}
-		imagev, err := textCache.Get(HlChars{
-			text:   line[x].char,
-			fg:     line[x].highlight.fg(),
-			italic: line[x].highlight.italic,
-			bold:   line[x].highlight.bold,
-		})
-		if err != nil {
-			image = w.newTextCache(line[x].char, line[x].highlight, false)
-		} else {
-			image = imagev.(*gui.QImage)
-		}
-		p.DrawImage7(
-			core.NewQPointF3(
-				float64(x)*wsfont.truewidth,
-				float64(y*wsfont.lineHeight+w.scrollDust[1]),
-			),
-			image,
-		)
-	}
-}
-
-func (w *Window) newTextCache(text string, highlight Highlight, isNormalWidth bool) *gui.QImage {
-	// * Ref: https://stackoverflow.com/questions/40458515/a-best-way-to-draw-a-lot-of-independent-characters-in-qt5/40476430#40476430
-
-	font := w.getFont()
-
-	width := float64(len(text)) * font.italicWidth
-	fg := highlight.fg()
-	if !isNormalWidth {
-		width = math.Ceil(font.fontMetrics.HorizontalAdvance(text, -1))
-	}
-
-	// QImage default device pixel ratio is 1.0,
-	// So we set the correct device pixel ratio
-	image := gui.NewQImage2(
-		core.NewQRectF4(
-			0,
-			0,
-			w.devicePixelRatio*width,
-			w.devicePixelRatio*float64(font.lineHeight),
-		).Size().ToSize(),
-		gui.QImage__Format_ARGB32_Premultiplied,
-	)
-	image.SetDevicePixelRatio(w.devicePixelRatio)
-	image.Fill3(core.Qt__transparent)
-
-	pi := gui.NewQPainter2(image)
-	pi.SetPen2(fg.QColor())
-
-	if !isNormalWidth && w.font == nil && w.s.ws.fontwide != nil {
-		pi.SetFont(w.s.ws.fontwide.fontNew)
-	} else {
-		pi.SetFont(font.fontNew)
-	}
-
-	if highlight.bold {
-		pi.Font().SetBold(true)
-	}
-	if highlight.italic {
-		pi.Font().SetItalic(true)
-	}
-
-	pi.DrawText6(
-		core.NewQRectF4(
-			0,
-			0,
-			width,
-			float64(font.lineHeight),
-		), text, gui.NewQTextOption2(core.Qt__AlignVCenter),
-	)
-
-	if w.font != nil {
-		// If window has own font setting
-		w.textCache.Set(
-			HlChars{
-				text:   text,
-				fg:     highlight.fg(),
-				italic: highlight.italic,
-				bold:   highlight.bold,
-			},
-			image,
-		)
-	} else {
-		// screen text cache
-		w.s.textCache.Set(
-			HlChars{
-				text:   text,
-				fg:     highlight.fg(),
-				italic: highlight.italic,
-				bold:   highlight.bold,
-			},
-			image,
-		)
-	}
-
-	pi.DestroyQPainter()
-	return image
-}
-
-func (w *Window) drawText(p *gui.QPainter, y int, col int, cols int) {
-	if y >= len(w.content) {
-		return
-	}
-	wsfont := w.getFont()
-	font := p.Font()
-	line := w.content[y]
-	chars := map[Highlight][]int{}
-	specialChars := []int{}
-
-	for x := col; x <= col+cols; x++ {
-		if x >= len(line) {
-			continue
-		}
-		if line[x] == nil {
-			continue
-		}
-		if line[x].char == " " {
-			continue
-		}
-		if line[x].char == "" {
-			continue
-		}
-		if !line[x].normalWidth {
-			specialChars = append(specialChars, x)
-			continue
-		}
-
-		highlight := line[x].highlight
-		colorSlice, ok := chars[highlight]
-		if !ok {
-			colorSlice = []int{}
-		}
-		colorSlice = append(colorSlice, x)
-		chars[highlight] = colorSlice
-	}
-
-	pointF := core.NewQPointF3(
-		float64(col)*wsfont.truewidth,
-		float64((y)*wsfont.lineHeight+wsfont.shift+w.scrollDust[1]),
-	)
-
-	for highlight, colorSlice := range chars {
-		var buffer bytes.Buffer
-		slice := colorSlice[:]
-		for x := col; x <= col+cols; x++ {
-			if len(slice) == 0 {
-				break
-			}
-			index := slice[0]
-			if x < index {
-				buffer.WriteString(" ")
-				continue
-			}
-			if x == index {
-				buffer.WriteString(line[x].char)
-				slice = slice[1:]
-			}
-		}
-
-		text := buffer.String()
-		if text != "" {
-			fg := highlight.fg()
-			if fg != nil {
-				p.SetPen2(fg.QColor())
-			}
-			font.SetBold(highlight.bold)
-			font.SetItalic(highlight.italic)
-			p.DrawText(pointF, text)
-		}
-
-	}
-
-	if len(specialChars) >= 1 {
-		if w.s.ws.fontwide != nil && w.font == nil && w.s.ws.fontwide != nil {
-			p.SetFont(w.s.ws.fontwide.fontNew)
-			font = p.Font()
-		}
-		for _, x := range specialChars {
-			if line[x] == nil || line[x].char == " " {
-				continue
-			}
-			fg := line[x].highlight.fg()
-			p.SetPen2(fg.QColor())
-			pointF.SetX(float64(x) * wsfont.truewidth)
-			pointF.SetY(float64((y)*wsfont.lineHeight + wsfont.shift + w.scrollDust[1]))
-			font.SetBold(line[x].highlight.bold)
-			font.SetItalic(line[x].highlight.italic)
-			p.DrawText(pointF, line[x].char)
-		}
-		if w.s.ws.fontwide != nil && w.font == nil && w.s.ws.fontwide != nil {
-			p.SetFont(w.getFont().fontNew)
-		}
-	}
-}
-
-func (w *Window) drawContents(p *gui.QPainter, y int, col int, cols int) {
-	if w.s.name == "minimap" {
-		w.drawMinimap(p, y, col, cols)
-	} else if !editor.config.Editor.CachedDrawing {
-		w.drawText(p, y, col, cols)
-	} else {
-		// w.drawChars(p, y, col, cols)
-		w.drawTextWithCache(p, y, col, cols)
-	}
-}
-
-func (w *Window) drawTextDecoration(p *gui.QPainter, y int, col int, cols int) {
-	if y >= len(w.content) {
-		return
-	}
-	line := w.content[y]
-	font := w.getFont()
-	for x := col; x <= col+cols; x++ {
-		if x >= len(line) {
-			continue
-		}
-		if line[x] == nil {
-			continue
-		}
-		if !line[x].highlight.underline && !line[x].highlight.undercurl && !line[x].highlight.strikethrough {
-			continue
-		}
-		pen := gui.NewQPen()
-		var color *gui.QColor
-		sp := line[x].highlight.special
-		if sp != nil {
-			color = sp.QColor()
-			pen.SetColor(color)
-		} else {
-			fg := editor.colors.fg
-			color = fg.QColor()
-			pen.SetColor(color)
-		}
-		p.SetPen(pen)
-		start := float64(x) * font.truewidth
-		end := float64(x+1) * font.truewidth
-
-		Y := float64(y*font.lineHeight+w.scrollDust[1]) + float64(font.height)*1.04 + float64(font.lineSpace/2)
-		halfY := float64(y*font.lineHeight+w.scrollDust[1]) + float64(font.height)/2.0 + float64(font.lineSpace/2)
-		weight := font.lineHeight / 14
-		if weight < 1 {
-			weight = 1
-		}
-		if line[x].highlight.strikethrough {
-			// strikeLinef := core.NewQLineF3(start, halfY, end, halfY)
-			// p.DrawLine(strikeLinef)
-			p.FillRect5(
-				int(start),
-				int(halfY),
-				int(math.Ceil(font.truewidth)),
-				weight,
-				color,
-			)
-		}
-		if line[x].highlight.underline {
-			// linef := core.NewQLineF3(start, Y, end, Y)
-			// p.DrawLine(linef)
-			p.FillRect5(
-				int(start),
-				int(Y)-weight,
-				int(math.Ceil(font.truewidth)),
-				weight,
-				color,
-			)
-		}
-		if line[x].highlight.undercurl {
-			height := 0.0
-			amplitude := font.ascent / 8.0
-			freq := 1.0
-			phase := 0.0
-			y := Y + height/2 + amplitude*math.Sin(0)
-			point := core.NewQPointF3(start, y)
-			path := gui.NewQPainterPath2(point)
-			for i := int(point.X()); i <= int(end); i++ {
-				y = Y + height/2 + amplitude*math.Sin(2*math.Pi*freq*float64(i)/font.truewidth+phase)
-				path.LineTo(core.NewQPointF3(float64(i), y))
-			}
-			p.DrawPath(path)
-		}
-	}
-}
-
 func (w *Window) getFillpatternAndTransparent(hl *Highlight) (core.Qt__BrushStyle, *RGBA, int) {
 	color := hl.bg()
 	pattern := core.Qt__BrushStyle(1)
@@ -2689,6 +2593,9 @@ func (w *Window) isNormalWidth(char string) bool {
 	if char[0] <= 127 {
 		return true
 	}
+	if normal, ok := ambiguousNormalWidth(char, w.s.ws.ambiwidth); ok {
+		return normal
+	}
 	font := w.getFont()
 	return font.fontMetrics.HorizontalAdvance(char, -1) == font.truewidth
 }
@@ -2715,6 +2622,7 @@ func (s *Screen) windowPosition(args []interface{}) {
 		win.move(col, row)
 		// win.hideOverlappingWindows()
 		win.show()
+		win.animateGeometry()
 	}
 }
 
@@ -2796,6 +2704,13 @@ func (s *Screen) windowFloatPosition(args []interface{}) {
 		// focusable := arg.([]interface{})[6]
 
 		win.widget.SetParent(editor.wsWidget)
+		if !win.isFloatWin {
+			// The float is out of the screen widget, so its clicks no
+			// longer propagate to Screen.mouseEvent
+			win.widget.ConnectMousePressEvent(win.mouseEvent)
+			win.widget.ConnectMouseReleaseEvent(win.mouseEvent)
+			win.widget.ConnectMouseMoveEvent(win.mouseEvent)
+		}
 		win.isFloatWin = true
 
 		anchorwin, ok := s.getWindow(anchorGrid)
@@ -2818,6 +2733,11 @@ func (s *Screen) windowFloatPosition(args []interface{}) {
 			x = anchorwin.pos[0] + anchorCol - win.cols
 			y = anchorwin.pos[1] + anchorRow - win.rows
 		}
+
+		if editor.config.Editor.AutoShrinkFloatWindow {
+			s.shrinkFloatWindow(win)
+		}
+		x, y = clampFloatPos(x, y, win.cols, win.rows, s.ws.cols, s.ws.rows)
 		win.pos[0] = x
 		win.pos[1] = y
 
@@ -2827,6 +2747,42 @@ func (s *Screen) windowFloatPosition(args []interface{}) {
 	}
 }
 
+// clampFloatPos clamps the position of the float window so that
+// the float window fits in the visible area.
+// If the float window is larger than the area, it is aligned to the top left.
+func clampFloatPos(x, y, cols, rows, maxCols, maxRows int) (int, int) {
+	if x+cols > maxCols {
+		x = maxCols - cols
+	}
+	if x < 0 {
+		x = 0
+	}
+	if y+rows > maxRows {
+		y = maxRows - rows
+	}
+	if y < 0 {
+		y = 0
+	}
+
+	return x, y
+}
+
+// shrinkFloatWindow requests to resize the float window which exceeds the workspace size
+func (s *Screen) shrinkFloatWindow(win *Window) {
+	cols := win.cols
+	rows := win.rows
+	if cols > s.ws.cols {
+		cols = s.ws.cols
+	}
+	if rows > s.ws.rows {
+		rows = s.ws.rows
+	}
+	if cols == win.cols && rows == win.rows {
+		return
+	}
+	go s.ws.nvim.TryResizeUIGrid(win.grid, cols, rows)
+}
+
 func (s *Screen) windowHide(args []interface{}) {
 	for _, arg := range args {
 		gridid := util.ReflectToInt(arg.([]interface{})[0])
@@ -2851,6 +2807,8 @@ func (s *Screen) msgSetPos(args []interface{}) {
 			continue
 		}
 		win.isMsgGrid = true
+		win.initMsgCopyButton()
+		win.msgScroll = 0
 		win.pos[1] = msgCount
 		win.move(win.pos[0], win.pos[1])
 		win.show()
@@ -2859,7 +2817,25 @@ func (s *Screen) msgSetPos(args []interface{}) {
 	}
 }
 
-func (s *Screen) windowClose() {
+// windowClose is called by win_close, and hides the window closed, which
+// collapses with the window animation
+func (s *Screen) windowClose(args []interface{}) {
+	for _, arg := range args {
+		gridid := util.ReflectToInt(arg.([]interface{})[0])
+		if isSkipGlobalId(gridid) {
+			continue
+		}
+		win, ok := s.getWindow(gridid)
+		if !ok {
+			continue
+		}
+		win.collapse()
+		if win.geomAnim != nil {
+			win.geomAnim.stop()
+			win.geomAnim.known = false
+		}
+		win.hide()
+	}
 }
 
 func (s *Screen) setColor() {
@@ -2872,27 +2848,21 @@ func (s *Screen) setColor() {
 	)
 }
 
-func (w *Window) getCache() gcache.Cache {
-	if w.font != nil {
-		return w.textCache
-	}
-
-	return w.s.textCache
-}
-
 func newWindow() *Window {
-	widget := widgets.NewQWidget(nil, 0)
-	widget.SetContentsMargins(0, 0, 0, 0)
-	widget.SetAttribute(core.Qt__WA_OpaquePaintEvent, true)
-	widget.SetStyleSheet(" * { background-color: rgba(0, 0, 0, 0);}")
-
 	w := &Window{
-		widget:       widget,
 		scrollRegion: []int{0, 0, 0, 0},
 		background:   editor.colors.bg,
 	}
 
-	widget.ConnectPaintEvent(w.paint)
+	if useOpenGL() {
+		w.widget = newGLWindowWidget(w)
+	} else {
+		w.widget = widgets.NewQWidget(nil, 0)
+		w.widget.SetAttribute(core.Qt__WA_OpaquePaintEvent, true)
+		w.widget.ConnectPaintEvent(w.paint)
+	}
+	w.widget.SetContentsMargins(0, 0, 0, 0)
+	w.widget.SetStyleSheet(" * { background-color: rgba(0, 0, 0, 0);}")
 
 	return w
 }
@@ -2985,6 +2955,9 @@ func (w *Window) fill() {
 	if w.isMsgGrid && editor.config.Message.Transparent < 1.0 {
 		return
 	}
+	if w.blended {
+		return
+	}
 	if w.background != nil {
 		w.widget.SetAutoFillBackground(true)
 		p := gui.NewQPalette()
@@ -2997,7 +2970,16 @@ func (w *Window) setShadow() {
 	if !editor.config.Editor.DrawShadowForFloatWindow {
 		return
 	}
-	w.widget.SetGraphicsEffect(util.DropShadow(0, 25, 125, 110))
+
+	// The float window's parent is the workspace widget,
+	// so clip the shadow so that it does not draw over the tabline
+	offset := 25.0
+	radius := 125.0
+	top := float64(w.pos[1] * w.s.font.lineHeight)
+	if radius-offset > top {
+		radius = top + offset
+	}
+	w.widget.SetGraphicsEffect(util.DropShadow(0, offset, radius, 110))
 }
 
 func (w *Window) move(col int, row int) {
@@ -3011,6 +2993,9 @@ func (w *Window) move(col int, row int) {
 	if res < 0 {
 		res = 0
 	}
+	if w.isMsgGrid {
+		res += w.msgGridShift()
+	}
 	x := int(float64(col) * font.truewidth)
 	y := (row * font.lineHeight) + res
 	if w.isFloatWin {
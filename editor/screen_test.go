@@ -5,8 +5,8 @@ import (
 	"testing"
 	"sync"
 
-	"github.com/bluele/gcache"
 	"github.com/neovim/go-client/nvim"
+	"github.com/therecipe/qt/core"
 	"github.com/therecipe/qt/widgets"
 )
 
@@ -125,7 +125,7 @@ func TestWindow_updateLine(t *testing.T) {
 		queueRedrawArea  [4]int
 		scrollRegion     []int
 		devicePixelRatio float64
-		textCache        gcache.Cache
+		atlas            *glyphAtlas
 		font             *Font
 		background       *RGBA
 		width            float64
@@ -344,3 +344,111 @@ func TestWindow_updateLine(t *testing.T) {
 		})
 	}
 }
+
+func TestClampFloatPos(t *testing.T) {
+	tests := []struct {
+		name         string
+		x, y         int
+		cols, rows   int
+		wantX, wantY int
+	}{
+		{"inside", 10, 5, 20, 5, 10, 5},
+		{"right edge", 70, 5, 20, 5, 60, 5},
+		{"bottom edge", 10, 22, 20, 5, 10, 19},
+		{"negative position", -3, -2, 20, 5, 0, 0},
+		{"larger than area", 10, 5, 100, 30, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, y := clampFloatPos(tt.x, tt.y, tt.cols, tt.rows, 80, 24)
+			if x != tt.wantX || y != tt.wantY {
+				t.Errorf("clampFloatPos() = (%v, %v), want (%v, %v)", x, y, tt.wantX, tt.wantY)
+			}
+		})
+	}
+}
+
+func TestMouseButtonAction(t *testing.T) {
+	tests := []struct {
+		evType     core.QEvent__Type
+		button     core.Qt__MouseButton
+		buttons    core.Qt__MouseButton
+		wantButton string
+		wantAction string
+	}{
+		{core.QEvent__MouseButtonPress, core.Qt__LeftButton, core.Qt__LeftButton, "left", "press"},
+		{core.QEvent__MouseButtonDblClick, core.Qt__LeftButton, core.Qt__LeftButton, "left", "press"},
+		{core.QEvent__MouseButtonRelease, core.Qt__RightButton, core.Qt__NoButton, "right", "release"},
+		{core.QEvent__MouseMove, core.Qt__NoButton, core.Qt__MidButton, "middle", "drag"},
+		{core.QEvent__MouseMove, core.Qt__NoButton, core.Qt__NoButton, "", ""},
+		{core.QEvent__MouseButtonPress, core.Qt__BackButton, core.Qt__BackButton, "", ""},
+	}
+	for _, tt := range tests {
+		button, action := mouseButtonAction(tt.evType, tt.button, tt.buttons)
+		if button != tt.wantButton || action != tt.wantAction {
+			t.Errorf("mouseButtonAction(%v, %v, %v) = (%q, %q), want (%q, %q)",
+				tt.evType, tt.button, tt.buttons, button, action, tt.wantButton, tt.wantAction)
+		}
+	}
+}
+
+func TestCellIndex(t *testing.T) {
+	tests := []struct {
+		x, y             int
+		wantRow, wantCol int
+	}{
+		{0, 0, 0, 0},
+		{15, 19, 0, 1},
+		{16, 20, 1, 2},
+		{79, 45, 2, 9},
+		// The position left of or above the widget
+		{-1, -1, -1, -1},
+	}
+	for _, tt := range tests {
+		row, col := cellIndex(tt.x, tt.y, 8, 20)
+		if row != tt.wantRow || col != tt.wantCol {
+			t.Errorf("cellIndex(%d, %d) = (%d, %d), want (%d, %d)", tt.x, tt.y, row, col, tt.wantRow, tt.wantCol)
+		}
+	}
+}
+
+func TestOnGridBorder(t *testing.T) {
+	tests := []struct {
+		name         string
+		x, y         int
+		wantVertical bool
+		wantOk       bool
+	}{
+		{"inside", 100, 100, false, false},
+		{"right border", 398, 100, false, true},
+		{"bottom border", 100, 302, true, true},
+		{"outside", 500, 500, false, false},
+	}
+	for _, tt := range tests {
+		vertical, ok := onGridBorder(tt.x, tt.y, 400, 300, 8, 16)
+		if vertical != tt.wantVertical || ok != tt.wantOk {
+			t.Errorf("%s: onGridBorder() = (%v, %v), want (%v, %v)", tt.name, vertical, ok, tt.wantVertical, tt.wantOk)
+		}
+	}
+}
+
+func TestScaledFontSize(t *testing.T) {
+	tests := []struct {
+		size   float64
+		extent int
+		delta  int
+		want   float64
+	}{
+		{12, 400, 0, 12},
+		{12, 400, 200, 18},
+		{12, 400, -100, 9},
+		{12, 400, -400, gridFontMinSize},
+		{12, 100, 1000, gridFontMaxSize},
+		{12, 0, 100, 12},
+	}
+	for _, tt := range tests {
+		if got := scaledFontSize(tt.size, tt.extent, tt.delta); got != tt.want {
+			t.Errorf("scaledFontSize(%v, %v, %v) = %v, want %v", tt.size, tt.extent, tt.delta, got, tt.want)
+		}
+	}
+}
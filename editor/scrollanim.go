@@ -0,0 +1,164 @@
+package editor
+
+import (
+	"math"
+	"time"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+)
+
+// scrollAnim animates grid_scroll by the pixels. The scrolled region slides
+// from the old position to the new one, and the rows scrolled out are drawn
+// from the snapshot of the window taken before the scroll.
+type scrollAnim struct {
+	w *Window
+	// frame is the subscription to the frame clock
+	frame    int
+	snapshot *gui.QPixmap
+	region   [4]int
+	count    int
+	start    time.Time
+	duration time.Duration
+	running  bool
+}
+
+// startScrollAnim starts the animation of the scroll of the count rows in the
+// scroll region of the window
+func (w *Window) startScrollAnim(count int) {
+	duration := editor.config.SmoothScroll.Duration
+	following := w.s.ws.follow.isFollower(int(w.id))
+	if following {
+		duration = editor.config.Follow.Duration
+	}
+	if !(editor.config.SmoothScroll.Enable || following) || count == 0 {
+		return
+	}
+	if w.isMsgGrid || !w.isShown() || w.scrollDust[1] != 0 {
+		return
+	}
+	var region [4]int
+	if len(w.scrollRegion) == 4 {
+		copy(region[:], w.scrollRegion)
+	}
+	if region == [4]int{} {
+		region = [4]int{0, w.rows - 1, 0, w.cols - 1}
+	}
+	// Nothing slides if the whole region is scrolled out
+	if count >= region[1]-region[0]+1 || -count >= region[1]-region[0]+1 {
+		return
+	}
+
+	if w.scrollAnim == nil {
+		w.scrollAnim = newScrollAnim(w)
+	}
+	a := w.scrollAnim
+	// The snapshot is the window as it is shown now, including the frame of
+	// the running animation
+	snapshot := w.widget.Grab(w.widget.Rect())
+	a.stop()
+	a.snapshot = snapshot
+	a.region = region
+	a.count = count
+	a.start = time.Now()
+	a.duration = time.Duration(duration) * time.Millisecond
+	a.running = true
+	a.frame = editor.frameClock.subscribe(a.tick)
+}
+
+func newScrollAnim(w *Window) *scrollAnim {
+	return &scrollAnim{
+		w: w,
+	}
+}
+
+func (a *scrollAnim) active() bool {
+	return a != nil && a.running
+}
+
+func (a *scrollAnim) tick() {
+	if time.Since(a.start) >= a.duration {
+		a.stop()
+	}
+	a.w.widget.Update()
+}
+
+func (a *scrollAnim) stop() {
+	a.running = false
+	if a.frame != 0 {
+		editor.frameClock.unsubscribe(a.frame)
+		a.frame = 0
+	}
+	if a.snapshot != nil {
+		a.snapshot.DestroyQPixmap()
+		a.snapshot = nil
+	}
+}
+
+// offset returns the vertical offset in pixels of the scrolled region from
+// its final position
+func (a *scrollAnim) offset() float64 {
+	t := 1.0
+	if a.duration > 0 {
+		t = float64(time.Since(a.start)) / float64(a.duration)
+	}
+	lineHeight := float64(a.w.getFont().lineHeight)
+
+	return float64(a.count) * lineHeight * (1 - scrollEasing(editor.config.SmoothScroll.Easing, t))
+}
+
+// paintScrollAnim draws the frame of the animation over the scroll region
+func (w *Window) paintScrollAnim(p *gui.QPainter) {
+	a := w.scrollAnim
+	font := w.getFont()
+	top, bot, left, right := a.region[0], a.region[1], a.region[2], a.region[3]
+	rect := core.NewQRectF4(
+		float64(left)*font.truewidth,
+		float64(top*font.lineHeight),
+		float64(right-left+1)*font.truewidth,
+		float64((bot-top+1)*font.lineHeight),
+	)
+	offset := a.offset()
+
+	p.Save()
+	p.SetClipRect(rect, core.Qt__ReplaceClip)
+	// The rows scrolled out slide out of the region with the old content
+	width := float64(a.snapshot.Width())
+	height := float64(a.snapshot.Height())
+	dpr := a.snapshot.DevicePixelRatio()
+	p.DrawPixmap(
+		core.NewQRectF4(0, offset-float64(a.count*font.lineHeight), width/dpr, height/dpr),
+		a.snapshot,
+		core.NewQRectF4(0, 0, width, height),
+	)
+
+	p.Translate3(0, offset)
+	bg := w.normalBackground()
+	if bg != nil {
+		p.FillRect4(rect, bg.QColor())
+	}
+	for y := top; y <= bot && y < w.rows; y++ {
+		w.paintRow(p, y, left, right-left+1)
+	}
+	p.Restore()
+}
+
+// scrollEasing returns the progress of the animation at t in [0, 1]
+func scrollEasing(easing string, t float64) float64 {
+	if t >= 1 {
+		return 1
+	}
+	if t <= 0 {
+		return 0
+	}
+	switch easing {
+	case "linear":
+		return t
+	case "outQuad":
+		return 1 - (1-t)*(1-t)
+	case "outExpo":
+		return 1 - math.Pow(2, -10*t)
+	default:
+		return 1 - (1-t)*(1-t)*(1-t)
+	}
+}
@@ -0,0 +1,34 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestScrollEasing(t *testing.T) {
+	for _, easing := range []string{"linear", "outQuad", "outCubic", "outExpo", ""} {
+		if got := scrollEasing(easing, 0); got != 0 {
+			t.Errorf("scrollEasing(%q, 0) = %v, want 0", easing, got)
+		}
+		if got := scrollEasing(easing, 1); got != 1 {
+			t.Errorf("scrollEasing(%q, 1) = %v, want 1", easing, got)
+		}
+		if got := scrollEasing(easing, 1.5); got != 1 {
+			t.Errorf("scrollEasing(%q, 1.5) = %v, want 1", easing, got)
+		}
+		// The progress never goes back
+		prev := 0.0
+		for i := 1; i < 10; i++ {
+			got := scrollEasing(easing, float64(i)/10)
+			if got < prev || got > 1 {
+				t.Errorf("scrollEasing(%q, %v) = %v, after %v", easing, float64(i)/10, got, prev)
+			}
+			prev = got
+		}
+	}
+	if got := scrollEasing("linear", 0.25); !almostEqual(got, 0.25) {
+		t.Errorf("scrollEasing(linear, 0.25) = %v, want 0.25", got)
+	}
+	if got := scrollEasing("outCubic", 0.5); !almostEqual(got, 0.875) {
+		t.Errorf("scrollEasing(outCubic, 0.5) = %v, want 0.875", got)
+	}
+}
@@ -28,8 +28,10 @@ func newScrollBar() *ScrollBar {
 	widget := widgets.NewQWidget(nil, 0)
 	widget.SetContentsMargins(0, 0, 0, 0)
 	widget.SetFixedWidth(10)
+	widget.SetObjectName("scrollbar")
 	thumb := widgets.NewQWidget(widget, 0)
 	thumb.SetFixedWidth(8)
+	thumb.SetObjectName("scrollbarthumb")
 
 	scrollBar := &ScrollBar{
 		widget: widget,
@@ -48,12 +50,12 @@ func newScrollBar() *ScrollBar {
 
 func (s *ScrollBar) thumbEnter(e *core.QEvent) {
 	color := editor.config.SideBar.AccentColor
-	s.thumb.SetStyleSheet(fmt.Sprintf(" * { background: %s;}", color))
+	s.thumb.SetStyleSheet(withUserStyle(fmt.Sprintf(" * { background: %s;}", color)))
 }
 
 func (s *ScrollBar) thumbLeave(e *core.QEvent) {
 	color := editor.colors.scrollBarFg.String()
-	s.thumb.SetStyleSheet(fmt.Sprintf(" * { background: %s;}", color))
+	s.thumb.SetStyleSheet(withUserStyle(fmt.Sprintf(" * { background: %s;}", color)))
 }
 
 func (s *ScrollBar) thumbPress(e *gui.QMouseEvent) {
@@ -149,8 +151,8 @@ func (s *ScrollBar) scroll(v, h int) {
 
 func (s *ScrollBar) setColor() {
 	fg := editor.colors.scrollBarFg.String()
-	s.thumb.SetStyleSheet(fmt.Sprintf(" * { background: %s;}", fg))
-	s.widget.SetStyleSheet(" * { background: rgba(0, 0, 0, 0);}")
+	s.thumb.SetStyleSheet(withUserStyle(fmt.Sprintf(" * { background: %s;}", fg)))
+	s.widget.SetStyleSheet(withUserStyle(" * { background: rgba(0, 0, 0, 0);}"))
 }
 
 func (s *ScrollBar) update() {
@@ -0,0 +1,497 @@
+package editor
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/denormal/go-gitignore"
+)
+
+// indexEntryOverhead is the estimated bytes of an entry of the index other
+// than its strings, for the memory limit
+const indexEntryOverhead = 48
+
+// searchIndexAutoCmds notify the files written or renamed, the changes
+// outside nvim which may have happened, and the workspace symbols of the
+// language servers. The working directory is notified at once, since the
+// index is built for it.
+const searchIndexAutoCmds = `
+	aug GonvimAuIndex | au! | aug END
+	au GonvimAuIndex BufWritePost,BufFilePost * call rpcnotify(0, "Gui", "gonvim_index_update", expand("<afile>:p"))
+	au GonvimAuIndex FocusGained,ShellCmdPost,TermClose * call rpcnotify(0, "Gui", "gonvim_index_refresh")
+	if exists("##LspAttach")
+	au GonvimAuIndex LspAttach,BufWritePost * lua pcall(vim.lsp.buf_request_all, 0, "workspace/symbol", {query = ""}, function(responses) local symbols = {} for _, response in pairs(responses or {}) do for _, symbol in ipairs(response.result or {}) do if symbol.location and symbol.location.range then table.insert(symbols, {vim.uri_to_fname(symbol.location.uri), symbol.location.range.start.line + 1, vim.lsp.protocol.SymbolKind[symbol.kind] or "", symbol.name}) end end end vim.rpcnotify(0, "Gui", "gonvim_index_lsp", symbols) end)
+	endif
+	call rpcnotify(0, "Gui", "gonvim_workspace_cwd", getcwd())
+	`
+
+// searchIndexCommands search the index by the fuzzy finder, and build it
+// again. GonvimIndexJump opens the symbol selected, "file:line:kind:name".
+const searchIndexCommands = `
+	command! GonvimIndexFiles call gonvim_fuzzy#run({"index": "files", "sink": "edit", "type": "file", "pwd": getcwd()})
+	command! GonvimIndexSymbols call gonvim_fuzzy#run({"index": "symbols", "sink": "GonvimIndexJump", "type": "file_line", "pwd": getcwd()})
+	command! -nargs=+ GonvimIndexJump execute "edit +" . split(<q-args>, ":")[1] . " " . fnameescape(split(<q-args>, ":")[0])
+	command! GonvimIndexRebuild call rpcnotify(0, "Gui", "gonvim_index_rebuild")
+	command! GonvimIndexStatus call rpcnotify(0, "Gui", "gonvim_index_status")
+	`
+
+// indexSymbol is a symbol in a file of the index
+type indexSymbol struct {
+	name string
+	kind string
+	line int
+}
+
+// indexDir is an indexed directory, which is read again only if its
+// modification time changes
+type indexDir struct {
+	mtime time.Time
+	files []string
+	dirs  []string
+}
+
+// indexExcluded returns true if the directory of the path relative to the
+// root is excluded by the patterns, which match its name or its path
+func indexExcluded(rel string, patterns []string) bool {
+	rel = filepath.ToSlash(rel)
+	base := rel[strings.LastIndex(rel, "/")+1:]
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		if pattern == "" {
+			continue
+		}
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// indexRelative returns the path relative to the root, and false if it is
+// not under the root
+func indexRelative(root, path string) (string, bool) {
+	if root == "" {
+		return "", false
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+
+	return rel, true
+}
+
+// parseCtagsLine parses the line of the tags of ctags with the line numbers
+// and the long kinds, e.g. "main\tmain.go\t/^func main() {$/;\"\tfunction\tline:8".
+func parseCtagsLine(line string) (string, indexSymbol, bool) {
+	if line == "" || strings.HasPrefix(line, "!_TAG_") {
+		return "", indexSymbol{}, false
+	}
+	fields := strings.SplitN(line, "\t", 3)
+	if len(fields) < 3 {
+		return "", indexSymbol{}, false
+	}
+	symbol := indexSymbol{name: fields[0]}
+	// The address may have the tabs, the extension fields follow it
+	ext := strings.Index(fields[2], ";\"\t")
+	if ext < 0 {
+		return "", indexSymbol{}, false
+	}
+	for _, field := range strings.Split(fields[2][ext+3:], "\t") {
+		switch {
+		case strings.HasPrefix(field, "line:"):
+			symbol.line, _ = strconv.Atoi(field[len("line:"):])
+		case strings.HasPrefix(field, "kind:"):
+			symbol.kind = field[len("kind:"):]
+		case !strings.Contains(field, ":"):
+			symbol.kind = field
+		}
+	}
+	if symbol.line < 1 {
+		return "", indexSymbol{}, false
+	}
+
+	return fields[1], symbol, true
+}
+
+// indexSymbolEntry returns the entry of the symbol for the finder of the
+// "file_line" type, which matches the name
+func indexSymbolEntry(file string, symbol indexSymbol) string {
+	return fmt.Sprintf("%s:%d:%s:%s", file, symbol.line, symbol.kind, symbol.name)
+}
+
+// searchIndex is the index of the files and the symbols of the working
+// directory of the workspace, which is built in the background and updated
+// by the files written in nvim and the directories changed outside it. The
+// fuzzy finder takes the files from it instead of walking the directory.
+type searchIndex struct {
+	// jobs serializes the builds and the updates
+	jobs sync.Mutex
+
+	mu   sync.RWMutex
+	root string
+	// generation is increased when the root changes, and the jobs of the
+	// previous root are dropped
+	generation int
+	ready      bool
+	truncated  bool
+	dirs       map[string]*indexDir
+	symbols    map[string][]indexSymbol
+	tagged     map[string]time.Time // the modification times of the files tagged
+	lsp        []string
+	files      int
+	size       int
+	built      time.Duration
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{}
+}
+
+// setRoot builds the index of the new working directory
+func (x *searchIndex) setRoot(root string) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return
+	}
+	x.mu.Lock()
+	if root == x.root {
+		x.mu.Unlock()
+		return
+	}
+	x.root = root
+	x.generation++
+	x.ready = false
+	x.dirs = nil
+	x.symbols = nil
+	x.tagged = nil
+	x.lsp = nil
+	x.mu.Unlock()
+
+	go x.build(false)
+}
+
+// rebuild is called by :GonvimIndexRebuild, and reads all the directories
+// and tags all the files again
+func (x *searchIndex) rebuild() {
+	go x.build(true)
+}
+
+// refresh reads again the directories modified since the last build
+func (x *searchIndex) refresh() {
+	go x.build(false)
+}
+
+// build walks the root, reading only the directories whose modification
+// times changed and tagging only the files added or modified since they were
+// tagged, e.g. outside nvim, unless it is full
+func (x *searchIndex) build(full bool) {
+	x.jobs.Lock()
+	defer x.jobs.Unlock()
+
+	x.mu.RLock()
+	root, generation, old, oldSymbols, oldTagged := x.root, x.generation, x.dirs, x.symbols, x.tagged
+	x.mu.RUnlock()
+	if root == "" {
+		return
+	}
+	if full {
+		old, oldSymbols, oldTagged = nil, nil, nil
+	}
+
+	start := time.Now()
+	config := editor.config.SearchIndex
+	limit := config.MaxMemory * 1024 * 1024
+	ignore, _ := gitignore.NewRepository(root)
+	dirs := map[string]*indexDir{}
+	symbols := map[string][]indexSymbol{}
+	tagged := map[string]time.Time{}
+	added := []string{}
+	files, size := 0, 0
+	truncated := false
+
+	var walk func(rel string)
+	walk = func(rel string) {
+		if truncated {
+			return
+		}
+		path := filepath.Join(root, rel)
+		info, err := os.Stat(path)
+		if err != nil {
+			return
+		}
+		dir, ok := old[rel]
+		if !ok || !dir.mtime.Equal(info.ModTime()) {
+			dir = &indexDir{mtime: info.ModTime()}
+			entries, _ := ioutil.ReadDir(path)
+			for _, entry := range entries {
+				child := filepath.Join(rel, entry.Name())
+				isDir := entry.IsDir()
+				if isDir && indexExcluded(child, config.Exclude) {
+					continue
+				}
+				if ignore != nil && ignore.Relative(child, isDir) != nil {
+					continue
+				}
+				if isDir {
+					dir.dirs = append(dir.dirs, child)
+				} else if entry.Mode().IsRegular() {
+					dir.files = append(dir.files, child)
+				}
+			}
+		}
+		for i, file := range dir.files {
+			if files >= config.MaxFiles || size >= limit {
+				// The directory is read again by the next build
+				truncated = true
+				dir = &indexDir{files: dir.files[:i]}
+				break
+			}
+			files++
+			size += len(file) + indexEntryOverhead
+			var mtime time.Time
+			if info, err := os.Stat(filepath.Join(root, file)); err == nil {
+				mtime = info.ModTime()
+			}
+			tagged[file] = mtime
+			if fileSymbols, ok := oldSymbols[file]; ok && oldTagged[file].Equal(mtime) {
+				symbols[file] = fileSymbols
+				for _, symbol := range fileSymbols {
+					size += len(symbol.name) + len(symbol.kind) + indexEntryOverhead
+				}
+			} else {
+				added = append(added, file)
+			}
+		}
+		dirs[rel] = dir
+		for _, child := range dir.dirs {
+			walk(child)
+		}
+	}
+	walk("")
+
+	for file, fileSymbols := range x.tag(root, added) {
+		for _, symbol := range fileSymbols {
+			if size >= limit {
+				truncated = true
+				break
+			}
+			size += len(symbol.name) + len(symbol.kind) + indexEntryOverhead
+			symbols[file] = append(symbols[file], symbol)
+		}
+	}
+
+	x.mu.Lock()
+	if generation != x.generation {
+		x.mu.Unlock()
+		return
+	}
+	warn := truncated && !x.truncated
+	x.dirs = dirs
+	x.symbols = symbols
+	x.tagged = tagged
+	x.files = files
+	x.size = size
+	x.truncated = truncated
+	x.ready = true
+	x.built = time.Since(start)
+	x.mu.Unlock()
+
+	if warn {
+		editor.pushNotification(NotifyWarn, -1, fmt.Sprintf("[Goneovim] The search index of %s is over its limit, and has %d files", root, files))
+	}
+	if full {
+		x.status()
+	}
+}
+
+// tag returns the symbols of the files relative to the root by ctags
+func (x *searchIndex) tag(root string, files []string) map[string][]indexSymbol {
+	symbols := map[string][]indexSymbol{}
+	ctags := editor.config.SearchIndex.Ctags
+	if ctags == "" || len(files) == 0 {
+		return symbols
+	}
+	if _, err := exec.LookPath(ctags); err != nil {
+		return symbols
+	}
+	cmd := exec.Command(ctags, "-f", "-", "--fields=+nK", "--sort=no", "-L", "-")
+	util.PrepareRunProc(cmd)
+	cmd.Dir = root
+	cmd.Stdin = strings.NewReader(strings.Join(files, "\n"))
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return symbols
+	}
+	if err := cmd.Start(); err != nil {
+		return symbols
+	}
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		file, symbol, ok := parseCtagsLine(scanner.Text())
+		if !ok {
+			continue
+		}
+		symbols[filepath.Clean(file)] = append(symbols[filepath.Clean(file)], symbol)
+	}
+	cmd.Wait()
+
+	return symbols
+}
+
+// update is called by the gonvim_index_update notification, and adds,
+// tags again or removes the file written or renamed in nvim
+func (x *searchIndex) update(path string) {
+	x.mu.RLock()
+	root, generation, ready := x.root, x.generation, x.ready
+	x.mu.RUnlock()
+	rel, ok := indexRelative(root, path)
+	if !ok || !ready {
+		return
+	}
+
+	go func() {
+		x.jobs.Lock()
+		defer x.jobs.Unlock()
+
+		info, err := os.Stat(path)
+		exists := err == nil && info.Mode().IsRegular()
+		var fileSymbols []indexSymbol
+		if exists {
+			fileSymbols = x.tag(root, []string{rel})[rel]
+		}
+
+		x.mu.Lock()
+		defer x.mu.Unlock()
+		if generation != x.generation || x.dirs == nil {
+			return
+		}
+		parent := filepath.Dir(rel)
+		if parent == "." {
+			parent = ""
+		}
+		if dir, ok := x.dirs[parent]; ok {
+			i := sort.SearchStrings(dir.files, rel)
+			found := i < len(dir.files) && dir.files[i] == rel
+			switch {
+			case exists && !found:
+				dir.files = append(dir.files[:i], append([]string{rel}, dir.files[i:]...)...)
+				x.files++
+			case !exists && found:
+				dir.files = append(dir.files[:i], dir.files[i+1:]...)
+				x.files--
+			}
+		}
+		if exists {
+			x.symbols[rel] = fileSymbols
+			x.tagged[rel] = info.ModTime()
+		} else {
+			delete(x.symbols, rel)
+			delete(x.tagged, rel)
+		}
+	}()
+}
+
+// setLSPSymbols is called by the gonvim_index_lsp notification with the
+// workspace symbols of the language servers, [[file, line, kind, name], ...]
+func (x *searchIndex) setLSPSymbols(args []interface{}) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if !editor.config.SearchIndex.Lsp {
+		return
+	}
+	lsp := []string{}
+	for _, arg := range args {
+		symbol, ok := arg.([]interface{})
+		if len(symbol) < 4 || !ok {
+			continue
+		}
+		path, _ := symbol[0].(string)
+		rel, ok := indexRelative(x.root, path)
+		if !ok {
+			continue
+		}
+		kind, _ := symbol[2].(string)
+		name, _ := symbol[3].(string)
+		lsp = append(lsp, indexSymbolEntry(rel, indexSymbol{
+			name: name,
+			kind: strings.ToLower(kind),
+			line: util.ReflectToInt(symbol[1]),
+		}))
+	}
+	x.lsp = lsp
+}
+
+// Entries returns the files or the symbols of the index for the fuzzy
+// finder, if the index of the directory is built
+func (x *searchIndex) Entries(kind, dir string) ([]string, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	if !x.ready || dir != x.root {
+		return nil, false
+	}
+	entries := []string{}
+	switch kind {
+	case "files":
+		for _, d := range x.dirs {
+			entries = append(entries, d.files...)
+		}
+		sort.Strings(entries)
+	case "symbols":
+		seen := map[string]bool{}
+		for file, fileSymbols := range x.symbols {
+			for _, symbol := range fileSymbols {
+				entry := indexSymbolEntry(file, symbol)
+				seen[entry] = true
+				entries = append(entries, entry)
+			}
+		}
+		for _, entry := range x.lsp {
+			if !seen[entry] {
+				entries = append(entries, entry)
+			}
+		}
+		sort.Strings(entries)
+	default:
+		return nil, false
+	}
+
+	return entries, true
+}
+
+// status is called by :GonvimIndexStatus
+func (x *searchIndex) status() {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	if !x.ready {
+		editor.pushNotification(NotifyInfo, 3, fmt.Sprintf("[Goneovim] The search index of %s is being built", x.root))
+		return
+	}
+	symbols := len(x.lsp)
+	for _, fileSymbols := range x.symbols {
+		symbols += len(fileSymbols)
+	}
+	text := fmt.Sprintf(
+		"[Goneovim] The search index of %s has %d files and %d symbols in %.1f MB, built in %s",
+		x.root, x.files, symbols, float64(x.size)/(1024*1024), x.built.Round(time.Millisecond),
+	)
+	if x.truncated {
+		text += ", and is over its limit"
+	}
+	editor.pushNotification(NotifyInfo, 5, text)
+}
@@ -0,0 +1,81 @@
+package editor
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIndexExcluded(t *testing.T) {
+	patterns := []string{".git", "node_modules/", "build/out", "*.cache"}
+	tests := []struct {
+		rel  string
+		want bool
+	}{
+		{".git", true},
+		{"web/node_modules", true},
+		{"build/out", true},
+		{"src/build/out", false},
+		{"tmp/go.cache", true},
+		{"src", false},
+		{"src/gitx", false},
+	}
+	for _, tt := range tests {
+		if got := indexExcluded(filepath.FromSlash(tt.rel), patterns); got != tt.want {
+			t.Errorf("indexExcluded(%q) = %v, want %v", tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestIndexRelative(t *testing.T) {
+	root := filepath.FromSlash("/work/repo")
+	tests := []struct {
+		root string
+		path string
+		want string
+		ok   bool
+	}{
+		{root, "/work/repo/main.go", "main.go", true},
+		{root, "/work/repo/cmd/app/main.go", filepath.FromSlash("cmd/app/main.go"), true},
+		{root, "/work/repo", "", false},
+		{root, "/work/other/main.go", "", false},
+		{root, "/work/repo2/main.go", "", false},
+		{"", "/work/repo/main.go", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := indexRelative(tt.root, filepath.FromSlash(tt.path))
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("indexRelative(%q, %q) = %q, %v, want %q, %v", tt.root, tt.path, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseCtagsLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		file   string
+		symbol indexSymbol
+		ok     bool
+	}{
+		{"main\tmain.go\t/^func main() {$/;\"\tfunction\tline:8", "main.go", indexSymbol{"main", "function", 8}, true},
+		{"Screen\teditor/screen.go\t/^type Screen struct {$/;\"\tkind:struct\tline:20\ttyperef:typename:struct", "editor/screen.go", indexSymbol{"Screen", "struct", 20}, true},
+		{"tab\ta.txt\t/^a\tb$/;\"\tvariable\tline:3", "a.txt", indexSymbol{"tab", "variable", 3}, true},
+		{"!_TAG_FILE_FORMAT\t2\t/extended format/", "", indexSymbol{}, false},
+		{"nolines\tmain.go\t/^x$/;\"\tfunction", "", indexSymbol{}, false},
+		{"broken\tmain.go", "", indexSymbol{}, false},
+		{"", "", indexSymbol{}, false},
+	}
+	for _, tt := range tests {
+		file, symbol, ok := parseCtagsLine(tt.line)
+		if file != tt.file || symbol != tt.symbol || ok != tt.ok {
+			t.Errorf("parseCtagsLine(%q) = %q, %v, %v, want %q, %v, %v", tt.line, file, symbol, ok, tt.file, tt.symbol, tt.ok)
+		}
+	}
+}
+
+func TestIndexSymbolEntry(t *testing.T) {
+	got := indexSymbolEntry("editor/screen.go", indexSymbol{"Screen", "struct", 20})
+	want := "editor/screen.go:20:struct:Screen"
+	if got != want {
+		t.Errorf("indexSymbolEntry() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,145 @@
+package editor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// sharingMode makes the editor easy to see on video calls and pairing sessions.
+// It bumps the font size, emphasizes the cursor, uses high-contrast selection
+// colors and disables the transparency, and reverts them when turned off.
+type sharingMode struct {
+	active      bool
+	transparent float64
+	fonts       map[*Workspace]string
+}
+
+func newSharingMode() *sharingMode {
+	return &sharingMode{
+		fonts: make(map[*Workspace]string),
+	}
+}
+
+func (sm *sharingMode) toggle() {
+	if sm.active {
+		sm.disable()
+	} else {
+		sm.enable()
+	}
+}
+
+func (sm *sharingMode) enable() {
+	e := editor
+	sm.active = true
+
+	sm.transparent = e.config.Editor.Transparent
+	e.config.Editor.Transparent = 1.0
+
+	for _, ws := range e.workspaces {
+		sm.enableWorkspace(ws)
+	}
+	sm.redraw()
+	e.pushNotification(NotifyInfo, 3, "[Goneovim] Sharing mode on")
+}
+
+func (sm *sharingMode) disable() {
+	e := editor
+	sm.active = false
+
+	e.config.Editor.Transparent = sm.transparent
+
+	for _, ws := range e.workspaces {
+		sm.disableWorkspace(ws)
+	}
+	sm.fonts = make(map[*Workspace]string)
+	sm.redraw()
+	e.pushNotification(NotifyInfo, 3, "[Goneovim] Sharing mode off")
+}
+
+func (sm *sharingMode) enableWorkspace(w *Workspace) {
+	c := editor.config.Sharing
+
+	family := w.font.fontNew.Family()
+	size := w.font.fontNew.PointSizeF()
+	sm.fonts[w] = fmt.Sprintf("%s:h%f", family, size)
+	w.guiFont(fmt.Sprintf("%s:h%f", family, size*c.FontScale))
+
+	if w.nvim == nil {
+		return
+	}
+	// nvim keeps the definition of Visual to restore, so that the GUI
+	// doesn't wait for it
+	go w.nvim.Command(fmt.Sprintf(
+		`let g:gonvim_sharing_visual = execute("highlight Visual") | highlight Visual gui=NONE guifg=%s guibg=%s`,
+		c.VisualFg, c.VisualBg,
+	))
+}
+
+func (sm *sharingMode) disableWorkspace(w *Workspace) {
+	if font, ok := sm.fonts[w]; ok {
+		w.guiFont(font)
+	}
+	if w.nvim == nil {
+		return
+	}
+	neovim := w.nvim
+	go func() {
+		var output string
+		err := neovim.Eval(`get(g:, "gonvim_sharing_visual", "")`, &output)
+		if err != nil || output == "" {
+			return
+		}
+		neovim.Command(highlightRestoreCommand("Visual", output) + " | unlet g:gonvim_sharing_visual")
+	}()
+}
+
+// redraw applies the transparency and the cursor style again
+func (sm *sharingMode) redraw() {
+	e := editor
+	if e.isSetGuiColor {
+		e.updateGUIColor()
+		e.updateChromeStyle()
+	}
+	for _, ws := range e.workspaces {
+		ws.cursor.isNeedUpdateModeInfo = true
+		ws.cursor.update()
+		ws.screen.purgeTextCacheForWins()
+		ws.screen.windows.Range(func(_, winITF interface{}) bool {
+			win := winITF.(*Window)
+			if win != nil {
+				win.update()
+			}
+			return true
+		})
+	}
+}
+
+var highlightOutputRegexp = regexp.MustCompile(`^\s*(\S+)\s+xxx\s*(.*)$`)
+
+// highlightRestoreCommand returns the command to restore the highlight group
+// from the output of ":highlight {group}"
+func highlightRestoreCommand(group, output string) string {
+	clear := fmt.Sprintf("highlight clear %s", group)
+	line := ""
+	for _, l := range strings.Split(output, "\n") {
+		if strings.TrimSpace(l) != "" {
+			line = l
+			break
+		}
+	}
+	matches := highlightOutputRegexp.FindStringSubmatch(line)
+	if matches == nil {
+		return clear
+	}
+	attrs := strings.TrimSpace(matches[2])
+
+	switch {
+	case attrs == "" || attrs == "cleared":
+		return clear
+	case strings.HasPrefix(attrs, "links to "):
+		return fmt.Sprintf("%s | highlight! link %s %s", clear, group, strings.TrimPrefix(attrs, "links to "))
+	}
+
+	return fmt.Sprintf("%s | highlight %s %s", clear, group, attrs)
+}
@@ -0,0 +1,32 @@
+package editor
+
+import "testing"
+
+func TestHighlightRestoreCommand(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{
+			"\nVisual         xxx ctermbg=242 guibg=#3e4452",
+			"highlight clear Visual | highlight Visual ctermbg=242 guibg=#3e4452",
+		},
+		{
+			"\nVisual         xxx links to CursorLine",
+			"highlight clear Visual | highlight! link Visual CursorLine",
+		},
+		{
+			"\nVisual         xxx cleared",
+			"highlight clear Visual",
+		},
+		{
+			"",
+			"highlight clear Visual",
+		},
+	}
+	for _, tt := range tests {
+		if got := highlightRestoreCommand("Visual", tt.output); got != tt.want {
+			t.Errorf("highlightRestoreCommand(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+}
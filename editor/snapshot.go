@@ -0,0 +1,154 @@
+package editor
+
+import (
+	"hash/fnv"
+	"image"
+	"image/color"
+)
+
+// snapshotRenderer rasterizes the cells into an image. The glyphs are drawn
+// as the patterns of the hashes of their text instead of the font, so that
+// the snapshots are the same on any machine, and differ by the text, the
+// bold and the italic.
+type snapshotRenderer struct {
+	image      *image.RGBA
+	cellWidth  int
+	cellHeight int
+}
+
+func newSnapshotRenderer(cols, rows, cellWidth, cellHeight int) *snapshotRenderer {
+	return &snapshotRenderer{
+		image:      image.NewRGBA(image.Rect(0, 0, cols*cellWidth, rows*cellHeight)),
+		cellWidth:  cellWidth,
+		cellHeight: cellHeight,
+	}
+}
+
+// snapshotGrid rasterizes the content into an image of the cells of the size
+func snapshotGrid(content [][]*Cell, cellWidth, cellHeight int, defaultFg, defaultBg *RGBA) *image.RGBA {
+	cols := 0
+	for _, line := range content {
+		cols = maxInt(cols, len(line))
+	}
+	r := newSnapshotRenderer(cols, len(content), cellWidth, cellHeight)
+	renderGrid(r, content, defaultFg, defaultBg)
+
+	return r.image
+}
+
+// snapshot rasterizes the content of the window in the default colors of
+// the editor
+func (w *Window) snapshot(cellWidth, cellHeight int) *image.RGBA {
+	w.paintMutex.Lock()
+	content := make([][]*Cell, len(w.content))
+	for row, line := range w.content {
+		content[row] = copyCells(line)
+	}
+	w.paintMutex.Unlock()
+
+	return snapshotGrid(content, cellWidth, cellHeight, editor.colors.fg, editor.colors.bg)
+}
+
+func snapshotColor(c *RGBA) color.RGBA {
+	if c == nil {
+		return color.RGBA{A: 0xff}
+	}
+
+	return color.RGBA{R: uint8(c.R), G: uint8(c.G), B: uint8(c.B), A: 0xff}
+}
+
+func (r *snapshotRenderer) fillBackground(col, row, cols int, hl *Highlight, bg *RGBA) {
+	c := snapshotColor(bg)
+	for y := row * r.cellHeight; y < (row+1)*r.cellHeight; y++ {
+		for x := col * r.cellWidth; x < (col+cols)*r.cellWidth; x++ {
+			r.image.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// drawGlyph draws the pattern of the glyph in the box of the middle half of
+// the cells. The bold pattern is thickened by a pixel, and the italic one is
+// slanted.
+func (r *snapshotRenderer) drawGlyph(line []*Cell, col, row, cols int, fg *RGBA) {
+	cell := line[col]
+	if cell.char == " " {
+		return
+	}
+	c := snapshotColor(fg)
+	h := fnv.New64a()
+	h.Write([]byte(cell.char))
+	pattern := h.Sum64()
+
+	left := col*r.cellWidth + 1
+	width := cols*r.cellWidth - 2
+	top := row*r.cellHeight + r.cellHeight/4
+	height := r.cellHeight / 2
+	for j := 0; j < height; j++ {
+		slant := 0
+		if cell.highlight.italic {
+			slant = (height - j) / 3
+		}
+		for i := 0; i < width; i++ {
+			bit := uint((j*width + i) % 64)
+			if pattern&(1<<bit) == 0 {
+				continue
+			}
+			x := left + i + slant
+			r.image.SetRGBA(x, top+j, c)
+			if cell.highlight.bold {
+				r.image.SetRGBA(x+1, top+j, c)
+			}
+		}
+	}
+}
+
+func (r *snapshotRenderer) drawDecoration(col, row, cols int, hl *Highlight, special *RGBA) {
+	if hl.underline {
+		r.drawLine(col, row, cols, decorationUnderline, special)
+	}
+	if hl.undercurl {
+		r.drawLine(col, row, cols, decorationUndercurl, special)
+	}
+	if hl.strikethrough {
+		r.drawLine(col, row, cols, decorationStrikethrough, special)
+	}
+}
+
+func (r *snapshotRenderer) drawLine(col, row, cols int, decoration cellDecoration, color *RGBA) {
+	c := snapshotColor(color)
+	bottom := (row+1)*r.cellHeight - 1
+	for x := col * r.cellWidth; x < (col+cols)*r.cellWidth; x++ {
+		switch decoration {
+		case decorationUnderline:
+			r.image.SetRGBA(x, bottom, c)
+		case decorationUndercurl:
+			r.image.SetRGBA(x, bottom-(x/2)%2, c)
+		case decorationStrikethrough:
+			r.image.SetRGBA(x, row*r.cellHeight+r.cellHeight/2, c)
+		}
+	}
+}
+
+// diffSnapshots compares the snapshots pixel by pixel. It returns the count
+// of the pixels which differ, and an image of the differences in red over
+// the faded expected snapshot, so that the failing tests can save it.
+func diffSnapshots(expected, actual *image.RGBA) (int, *image.RGBA) {
+	bounds := expected.Bounds().Union(actual.Bounds())
+	diff := image.NewRGBA(bounds)
+	count := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			p := image.Pt(x, y)
+			e := expected.RGBAAt(x, y)
+			if !p.In(expected.Bounds()) || !p.In(actual.Bounds()) || e != actual.RGBAAt(x, y) {
+				count++
+				diff.SetRGBA(x, y, color.RGBA{R: 0xff, A: 0xff})
+				continue
+			}
+			gray := uint8((uint16(e.R) + uint16(e.G) + uint16(e.B)) / 3 / 4)
+			diff.SetRGBA(x, y, color.RGBA{R: gray, G: gray, B: gray, A: 0xff})
+		}
+	}
+
+	return count, diff
+}
@@ -0,0 +1,203 @@
+package editor
+
+import (
+	"flag"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateSnapshots = flag.Bool("update-snapshots", false, "write the golden snapshots of the grid rendering")
+
+// snapshotDiffDir is where the differences of the failing snapshots are
+// saved, which is kept after the tests to be inspected or uploaded by CI
+var snapshotDiffDir = filepath.Join("testdata", "snapshots", "diff")
+
+// checkSnapshot compares the image with the golden snapshot of the name in
+// testdata/snapshots, and saves the differences in snapshotDiffDir on the
+// failure
+func checkSnapshot(t *testing.T, name string, img *image.RGBA) {
+	t.Helper()
+	path := filepath.Join("testdata", "snapshots", name+".png")
+	if *updateSnapshots {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeSnapshot(t, path, img)
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("no golden snapshot %s, run the tests with -update-snapshots: %v", path, err)
+	}
+	defer file.Close()
+	decoded, err := png.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := image.NewRGBA(decoded.Bounds())
+	for y := golden.Rect.Min.Y; y < golden.Rect.Max.Y; y++ {
+		for x := golden.Rect.Min.X; x < golden.Rect.Max.X; x++ {
+			golden.Set(x, y, decoded.At(x, y))
+		}
+	}
+
+	count, diff := diffSnapshots(golden, img)
+	if count == 0 {
+		return
+	}
+	diffPath := filepath.Join(snapshotDiffDir, name+".diff.png")
+	if err := os.MkdirAll(snapshotDiffDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeSnapshot(t, diffPath, diff)
+	t.Errorf("%d pixels differ from the golden snapshot %s, see %s", count, path, diffPath)
+}
+
+func writeSnapshot(t *testing.T, path string, img *image.RGBA) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func snapshotCells(hl Highlight, chars ...string) []*Cell {
+	line := make([]*Cell, len(chars))
+	for i, char := range chars {
+		line[i] = &Cell{
+			normalWidth: true,
+			char:        char,
+			highlight:   hl,
+		}
+	}
+	return line
+}
+
+var (
+	snapshotFg      = newRGBA(200, 200, 200, 1)
+	snapshotBg      = newRGBA(10, 10, 10, 1)
+	snapshotRed     = newRGBA(220, 50, 50, 1)
+	snapshotBlue    = newRGBA(40, 80, 200, 1)
+	snapshotDefault = Highlight{}
+)
+
+func TestSnapshotGolden(t *testing.T) {
+	tests := []struct {
+		name    string
+		content [][]*Cell
+	}{
+		{
+			"highlight",
+			[][]*Cell{
+				snapshotCells(snapshotDefault, "a", "b", "c", " "),
+				snapshotCells(Highlight{foreground: snapshotRed, background: snapshotBlue}, "d", "e", "f", " "),
+				snapshotCells(Highlight{foreground: snapshotRed, background: snapshotBlue, reverse: true}, "g", "h", "i", " "),
+				snapshotCells(Highlight{bold: true, italic: true}, "j", "k", "l", " "),
+			},
+		},
+		{
+			"decoration",
+			[][]*Cell{
+				snapshotCells(Highlight{underline: true}, "a", "b", "c"),
+				snapshotCells(Highlight{undercurl: true, special: snapshotRed}, "d", "e", "f"),
+				snapshotCells(Highlight{strikethrough: true}, "g", "h", "i"),
+			},
+		},
+		{
+			"widechar",
+			[][]*Cell{
+				snapshotCells(snapshotDefault, "あ", "", "い", "", "a"),
+				snapshotCells(Highlight{background: snapshotBlue}, "x", "漢", "", "y", " "),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkSnapshot(t, tt.name, snapshotGrid(tt.content, 8, 16, snapshotFg, snapshotBg))
+		})
+	}
+}
+
+func TestSnapshotWideChar(t *testing.T) {
+	wide := snapshotGrid([][]*Cell{snapshotCells(snapshotDefault, "あ", "")}, 8, 16, snapshotFg, snapshotBg)
+	narrow := snapshotGrid([][]*Cell{snapshotCells(snapshotDefault, "あ", " ")}, 8, 16, snapshotFg, snapshotBg)
+
+	// The glyph of the wide char spills into the right half, and the one of
+	// the narrow char stays in its cell
+	fg := snapshotColor(snapshotFg)
+	spilled := func(img *image.RGBA) bool {
+		for y := 0; y < 16; y++ {
+			for x := 8; x < 16; x++ {
+				if img.RGBAAt(x, y) == fg {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if !spilled(wide) {
+		t.Errorf("the wide char is drawn in a single cell")
+	}
+	if spilled(narrow) {
+		t.Errorf("the narrow char is drawn over the next cell")
+	}
+}
+
+func TestSnapshotAttributes(t *testing.T) {
+	render := func(hl Highlight) *image.RGBA {
+		return snapshotGrid([][]*Cell{snapshotCells(hl, "a")}, 8, 16, snapshotFg, snapshotBg)
+	}
+	normal := render(snapshotDefault)
+	if count, _ := diffSnapshots(normal, render(snapshotDefault)); count != 0 {
+		t.Errorf("the same cells are rendered differently in %d pixels", count)
+	}
+	for name, hl := range map[string]Highlight{
+		"bold":          {bold: true},
+		"italic":        {italic: true},
+		"underline":     {underline: true},
+		"undercurl":     {undercurl: true},
+		"strikethrough": {strikethrough: true},
+		"reverse":       {reverse: true},
+	} {
+		if count, _ := diffSnapshots(normal, render(hl)); count == 0 {
+			t.Errorf("the %s cell is rendered as the normal one", name)
+		}
+	}
+
+	// The special color is used for the decorations only
+	plain := render(Highlight{special: snapshotRed})
+	if count, _ := diffSnapshots(normal, plain); count != 0 {
+		t.Errorf("the special color without the decorations changes %d pixels", count)
+	}
+	underline := render(Highlight{underline: true, special: snapshotRed})
+	if got := underline.RGBAAt(0, 15); got != snapshotColor(snapshotRed) {
+		t.Errorf("the underline is drawn in %v, want the special color", got)
+	}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	a := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	b := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	if count, _ := diffSnapshots(a, b); count != 0 {
+		t.Errorf("diffSnapshots() of the same images = %d, want 0", count)
+	}
+	b.SetRGBA(1, 2, snapshotColor(snapshotRed))
+	count, diff := diffSnapshots(a, b)
+	if count != 1 {
+		t.Errorf("diffSnapshots() = %d, want 1", count)
+	}
+	if got := diff.RGBAAt(1, 2); got.R != 0xff || got.G != 0 {
+		t.Errorf("the differing pixel is %v in the diff, want red", got)
+	}
+	if count, _ := diffSnapshots(a, image.NewRGBA(image.Rect(0, 0, 4, 5))); count != 4 {
+		t.Errorf("diffSnapshots() of the different sizes = %d, want 4", count)
+	}
+}
@@ -0,0 +1,234 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+const (
+	// spellHoverDelay is the time in ms the pointer rests on the misspelled
+	// word until the suggestions pop up
+	spellHoverDelay = 700
+	// spellSuggestMax is the number of the suggestions in the popup
+	spellSuggestMax = 8
+)
+
+// spellKind returns the spell highlight group of the info of the
+// hl_attr_define event, or "" if the highlight isn't of the spell errors
+func spellKind(info []interface{}) string {
+	for _, i := range info {
+		state, ok := i.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, key := range []string{"hi_name", "ui_name"} {
+			name, _ := state[key].(string)
+			switch name {
+			case "SpellBad", "SpellCap", "SpellRare", "SpellLocal":
+				return name
+			}
+		}
+	}
+
+	return ""
+}
+
+// spellStyle returns the decoration style and the color of the spell kind in
+// the config. The empty style keeps the decoration of the colorscheme, and
+// the empty color keeps the guisp.
+func spellStyle(kind string, c spellConfig) (string, string) {
+	switch kind {
+	case "SpellBad":
+		return c.Bad, c.BadColor
+	case "SpellCap":
+		return c.Cap, c.CapColor
+	case "SpellRare":
+		return c.Rare, c.RareColor
+	case "SpellLocal":
+		return c.Local, c.LocalColor
+	}
+
+	return "", ""
+}
+
+// spellDecoration returns the decoration of the cell of the spell highlight:
+// whether it is underlined or curled, in which undercurl style, and the
+// color. The highlight of the other cells is returned as it is.
+func spellDecoration(hl *Highlight, undercurlStyle string) (underline, double, undercurl bool, style string, color *RGBA) {
+	underline, undercurl, style, color = hl.underline, hl.undercurl, undercurlStyle, hl.special
+	if hl.spell == "" {
+		return
+	}
+	s, c := spellStyle(hl.spell, editor.config.Spell)
+	if c != "" {
+		if rgba := hexToRGBA(c); rgba != nil {
+			color = rgba
+		}
+	}
+	switch s {
+	case "":
+	case "none":
+		underline, undercurl = false, false
+	case "underline", "double":
+		underline, double, undercurl = true, s == "double", false
+	default:
+		underline, undercurl, style = false, true, s
+	}
+
+	return
+}
+
+// spellWordAt returns the text of the misspelled word under the column of
+// the line, and its first and last columns. The word is the run of the cells
+// in the same spell highlight.
+func spellWordAt(line []*Cell, col int) (string, int, int) {
+	if col < 0 || col >= len(line) || line[col] == nil || line[col].highlight.spell == "" {
+		return "", -1, -1
+	}
+	kind := line[col].highlight.spell
+	start, end := col, col
+	for start > 0 && line[start-1] != nil && line[start-1].highlight.spell == kind {
+		start--
+	}
+	for end < len(line)-1 && line[end+1] != nil && line[end+1].highlight.spell == kind {
+		end++
+	}
+	var b strings.Builder
+	for _, cell := range line[start : end+1] {
+		b.WriteString(cell.char)
+	}
+
+	return strings.TrimSpace(b.String()), start, end
+}
+
+// spellHover pops up the suggestions for the misspelled word under the
+// resting pointer. A suggestion replaces the word by z= with its count.
+type spellHover struct {
+	s     *Screen
+	timer *core.QTimer
+	win   *Window
+	row   int
+	col   int
+	pos   *core.QPoint
+	// word is the position of the word of the last popup, so that the popup
+	// isn't shown again while the pointer stays on the word
+	word [3]int
+	// seq is the count of the waits, which drops the stale suggestions
+	seq int
+}
+
+func newSpellHover(s *Screen) *spellHover {
+	h := &spellHover{
+		s:    s,
+		word: [3]int{-1, -1, -1},
+	}
+	h.timer = core.NewQTimer(nil)
+	h.timer.SetSingleShot(true)
+	h.timer.ConnectTimeout(h.popup)
+
+	return h
+}
+
+// moved restarts the wait for the popup at the cell under the pointer
+func (h *spellHover) moved(event *gui.QMouseEvent) {
+	h.timer.Stop()
+	word := [3]int{-1, -1, -1}
+	win := h.s.windowAt(event.Pos())
+	_, row, col := h.s.gridPos(win, event.Pos())
+	if win != nil {
+		if line, ok := win.rowAt(row); ok {
+			if _, start, _ := spellWordAt(line, col); start >= 0 {
+				word = [3]int{win.grid, row, start}
+			}
+		}
+	}
+	if word == h.word {
+		return
+	}
+	// The pointer left the word, and its suggestions are dropped
+	h.seq++
+	h.word = [3]int{-1, -1, -1}
+	if word[0] < 0 {
+		return
+	}
+	h.win, h.row, h.col = win, row, col
+	h.pos = event.GlobalPos()
+	h.timer.Start(spellHoverDelay)
+}
+
+// popup asks nvim for the suggestions off the GUI thread, which suggested
+// pops up
+func (h *spellHover) popup() {
+	win := h.win
+	if win == nil {
+		return
+	}
+	line, ok := win.rowAt(h.row)
+	if !ok {
+		return
+	}
+	word, start, _ := spellWordAt(line, h.col)
+	if word == "" {
+		return
+	}
+	h.word = [3]int{win.grid, h.row, start}
+
+	seq := h.seq
+	ws := h.s.ws
+	go func() {
+		var suggestions []string
+		err := ws.nvim.Call("spellsuggest", &suggestions, word, spellSuggestMax)
+		if err != nil {
+			return
+		}
+		ws.guiUpdates <- []interface{}{"gonvim_spell_suggest", seq, word, suggestions}
+		ws.signal.GuiSignal()
+	}()
+}
+
+// suggested pops up the suggestions for the word, unless the pointer left
+// it in the meantime
+func (h *spellHover) suggested(args []interface{}) {
+	if len(args) < 3 {
+		return
+	}
+	seq, _ := args[0].(int)
+	word, _ := args[1].(string)
+	suggestions, _ := args[2].([]string)
+	if seq != h.seq || h.win == nil {
+		return
+	}
+
+	grid, row, col := h.win.grid, h.row, h.col
+	menu := widgets.NewQMenu(h.s.widget)
+	if len(suggestions) == 0 {
+		menu.AddAction("(no suggestions)").SetEnabled(false)
+	}
+	for i, suggestion := range suggestions {
+		count := i + 1
+		menu.AddAction(suggestion).ConnectTriggered(func(bool) {
+			h.replace(grid, row, col, fmt.Sprintf("%dz=", count))
+		})
+	}
+	menu.AddSeparator()
+	menu.AddAction(fmt.Sprintf("Add \"%s\" to the Dictionary", word)).ConnectTriggered(func(bool) {
+		h.replace(grid, row, col, "zg")
+	})
+	menu.Popup(h.pos, nil)
+}
+
+// replace puts the cursor on the word by the click, and runs the normal
+// command on it. The click and the command are in the same input queue, so
+// the command runs after the cursor is moved.
+func (h *spellHover) replace(grid, row, col int, command string) {
+	nvim := h.s.ws.nvim
+	go func() {
+		nvim.InputMouse("left", "press", "", grid, row, col)
+		nvim.InputMouse("left", "release", "", grid, row, col)
+		nvim.Input(fmt.Sprintf("<Cmd>normal! %s<CR>", command))
+	}()
+}
@@ -0,0 +1,94 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestSpellKind(t *testing.T) {
+	tests := []struct {
+		name string
+		info []interface{}
+		want string
+	}{
+		{"none", nil, ""},
+		{
+			"syntax",
+			[]interface{}{map[string]interface{}{"kind": "syntax", "hi_name": "Comment"}},
+			"",
+		},
+		{
+			"combined with syntax",
+			[]interface{}{
+				map[string]interface{}{"kind": "syntax", "hi_name": "Comment"},
+				map[string]interface{}{"kind": "ui", "ui_name": "SpellCap", "hi_name": "SpellCap"},
+			},
+			"SpellCap",
+		},
+		{
+			"ui name only",
+			[]interface{}{map[string]interface{}{"kind": "ui", "ui_name": "SpellBad"}},
+			"SpellBad",
+		},
+	}
+	for _, tt := range tests {
+		if got := spellKind(tt.info); got != tt.want {
+			t.Errorf("%s: spellKind() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSpellStyle(t *testing.T) {
+	c := spellConfig{Bad: "curl", Cap: "dashes", Rare: "dots", LocalColor: "#00ff00"}
+	tests := []struct {
+		kind  string
+		style string
+		color string
+	}{
+		{"SpellBad", "curl", ""},
+		{"SpellCap", "dashes", ""},
+		{"SpellRare", "dots", ""},
+		{"SpellLocal", "", "#00ff00"},
+		{"Comment", "", ""},
+	}
+	for _, tt := range tests {
+		style, color := spellStyle(tt.kind, c)
+		if style != tt.style || color != tt.color {
+			t.Errorf("spellStyle(%q) = %q, %q, want %q, %q", tt.kind, style, color, tt.style, tt.color)
+		}
+	}
+}
+
+func TestSpellWordAt(t *testing.T) {
+	bad := Highlight{spell: "SpellBad"}
+	rare := Highlight{spell: "SpellRare"}
+	var line []*Cell
+	for _, c := range "a teh wrod" {
+		line = append(line, &Cell{normalWidth: true, char: string(c)})
+	}
+	for col := 2; col <= 4; col++ {
+		line[col].highlight = bad
+	}
+	for col := 6; col <= 9; col++ {
+		line[col].highlight = rare
+	}
+
+	tests := []struct {
+		col   int
+		word  string
+		start int
+		end   int
+	}{
+		{0, "", -1, -1},
+		{1, "", -1, -1},
+		{2, "teh", 2, 4},
+		{4, "teh", 2, 4},
+		{7, "wrod", 6, 9},
+		{10, "", -1, -1},
+	}
+	for _, tt := range tests {
+		word, start, end := spellWordAt(line, tt.col)
+		if word != tt.word || start != tt.start || end != tt.end {
+			t.Errorf("spellWordAt(%d) = %q, %d, %d, want %q, %d, %d", tt.col, word, start, end, tt.word, tt.start, tt.end)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+const (
+	// startupStall is the time to wait for the first frame after the UI is
+	// attached, before checking if nvim is blocked by a prompt
+	startupStall = 3 * time.Second
+	// startupMessageLines is the number of the lines of the messages shown
+	// in the dialog
+	startupMessageLines = 20
+)
+
+// startupProgress shows the stage of the startup and the elapsed time on the
+// workspace until the first frame is flushed. The errors which would leave
+// the window black, e.g. nvim not found or a prompt of the errors in init.vim,
+// are shown in a dialog.
+type startupProgress struct {
+	ws    *Workspace
+	label *widgets.QLabel
+	timer *core.QTimer
+	start time.Time
+
+	mu      sync.Mutex
+	stage   string
+	err     error
+	blocked bool
+
+	done     bool
+	prompted bool
+}
+
+func newStartupProgress(ws *Workspace) *startupProgress {
+	p := &startupProgress{
+		ws:    ws,
+		start: time.Now(),
+		stage: "Starting nvim",
+	}
+	p.label = widgets.NewQLabel(nil, 0)
+	p.label.SetContentsMargins(12, 6, 12, 6)
+	p.label.SetAlignment(core.Qt__AlignCenter)
+	p.timer = core.NewQTimer(nil)
+	p.timer.ConnectTimeout(p.tick)
+
+	return p
+}
+
+// show starts showing the progress on the workspace widget
+func (p *startupProgress) show(parent *widgets.QWidget) {
+	p.label.SetParent(parent)
+	p.label.SetStyleSheet(fmt.Sprintf(
+		"* { color: %s; background-color: rgba(0, 0, 0, 0); }",
+		warpColor(editor.colors.fg, -30).String(),
+	))
+	p.tick()
+	p.label.Show()
+	p.timer.Start(100)
+}
+
+// setStage is called from any goroutine
+func (p *startupProgress) setStage(stage string) {
+	p.mu.Lock()
+	p.stage = stage
+	p.mu.Unlock()
+}
+
+// fail is called from any goroutine when the workspace can't start
+func (p *startupProgress) fail(err error) {
+	p.mu.Lock()
+	p.err = err
+	p.mu.Unlock()
+}
+
+// watch checks if nvim is blocked, e.g. by the hit-enter prompt of the errors
+// in init.vim, when the first frame doesn't arrive
+func (p *startupProgress) watch() {
+	time.Sleep(startupStall)
+	p.mu.Lock()
+	failed := p.err != nil
+	p.mu.Unlock()
+	if failed {
+		return
+	}
+	// nvim_get_mode is answered even while nvim is blocked
+	mode, err := p.ws.nvim.Mode()
+	if err != nil || !mode.Blocking {
+		return
+	}
+	p.mu.Lock()
+	p.blocked = true
+	p.mu.Unlock()
+}
+
+// finish hides the progress on the first flush
+func (p *startupProgress) finish() {
+	if p == nil || p.done {
+		return
+	}
+	p.done = true
+	p.timer.Stop()
+	p.label.Hide()
+}
+
+func (p *startupProgress) tick() {
+	p.mu.Lock()
+	stage, err, blocked := p.stage, p.err, p.blocked
+	p.mu.Unlock()
+
+	if err != nil {
+		p.finish()
+		widgets.QMessageBox_Critical(
+			editor.window,
+			"Goneovim",
+			fmt.Sprintf("Failed to start nvim:\n%s", err),
+			widgets.QMessageBox__Ok,
+			widgets.QMessageBox__Ok,
+		)
+		if len(editor.workspaces) <= 1 {
+			editor.close()
+		}
+		return
+	}
+	if blocked && !p.prompted {
+		p.prompted = true
+		p.timer.Stop()
+		p.prompt()
+		p.timer.Start(100)
+		return
+	}
+
+	elapsed := time.Since(p.start).Seconds()
+	p.label.SetText(fmt.Sprintf("%s… %.1fs", stage, elapsed))
+	p.label.AdjustSize()
+	parent := p.label.ParentWidget()
+	if parent != nil {
+		p.label.Move2(
+			(parent.Width()-p.label.Width())/2,
+			(parent.Height()-p.label.Height())/2,
+		)
+	}
+}
+
+// prompt shows the messages nvim is waiting on, instead of the black window
+func (p *startupProgress) prompt() {
+	box := widgets.NewQMessageBox2(
+		widgets.QMessageBox__Warning,
+		"Goneovim",
+		"nvim is waiting for input during the startup. There may be errors in init.vim.",
+		widgets.QMessageBox__Ok|widgets.QMessageBox__Close,
+		editor.window,
+		core.Qt__Dialog,
+	)
+	continueButton := box.Button(widgets.QMessageBox__Ok)
+	quitButton := box.Button(widgets.QMessageBox__Close)
+	continueButton.SetText("Continue")
+	quitButton.SetText("Quit")
+	// Escape returns to the grid, as in the other dialogs, instead of quitting
+	box.SetEscapeButton(continueButton)
+	editor.focusChain.addDialog(continueButton.QWidget_PTR(), continueButton.Click)
+	editor.focusChain.addDialog(quitButton.QWidget_PTR(), quitButton.Click)
+	if messages := p.ws.startupMessages(); messages != "" {
+		box.SetDetailedText(messages)
+	}
+	if box.Exec() == int(widgets.QMessageBox__Close) {
+		// nvim blocked in the prompt doesn't run the commands, but takes the
+		// input, which dismisses the prompt first
+		p.ws.nvim.Input("<CR>:qa!<CR>")
+		return
+	}
+	// Dismiss the prompt, the first frame follows
+	p.ws.nvim.Input("<CR>")
+}
+
+// startupMessages returns the messages on the msg grid, or on the bottom of
+// the global grid without multigrid
+func (w *Workspace) startupMessages() string {
+	text := ""
+	w.screen.windows.Range(func(_, winITF interface{}) bool {
+		win := winITF.(*Window)
+		if win != nil && win.isMsgGrid {
+			text = win.msgText()
+			return false
+		}
+		return true
+	})
+	if text != "" {
+		return text
+	}
+
+	win, ok := w.screen.getWindow(1)
+	if !ok {
+		return ""
+	}
+	win.paintMutex.Lock()
+	defer win.paintMutex.Unlock()
+	lines := make([]string, len(win.content))
+	for row, line := range win.content {
+		var builder strings.Builder
+		for _, cell := range line {
+			if cell == nil {
+				builder.WriteString(" ")
+				continue
+			}
+			builder.WriteString(cell.char)
+		}
+		lines[row] = builder.String()
+	}
+
+	return lastLines(lines, startupMessageLines)
+}
+
+// lastLines returns the last n lines which are not blank at the bottom
+func lastLines(lines []string, n int) string {
+	end := len(lines)
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	start := end - n
+	if start < 0 {
+		start = 0
+	}
+	for start < end && strings.TrimSpace(lines[start]) == "" {
+		start++
+	}
+	trimmed := make([]string, 0, end-start)
+	for _, line := range lines[start:end] {
+		trimmed = append(trimmed, strings.TrimRight(line, " "))
+	}
+
+	return strings.Join(trimmed, "\n")
+}
@@ -0,0 +1,25 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestLastLines(t *testing.T) {
+	tests := []struct {
+		lines []string
+		n     int
+		want  string
+	}{
+		{nil, 3, ""},
+		{[]string{"   ", "  "}, 3, ""},
+		{[]string{"~", "Error detected while processing init.vim:  ", "E492: Not an editor command  ", "Press ENTER", "   "}, 3, "Error detected while processing init.vim:\nE492: Not an editor command\nPress ENTER"},
+		{[]string{"a", "b", "c", "d"}, 2, "c\nd"},
+		// The blank lines at the top of the last lines are dropped
+		{[]string{"a", "", "b"}, 2, "b"},
+	}
+	for _, tt := range tests {
+		if got := lastLines(tt.lines, tt.n); got != tt.want {
+			t.Errorf("lastLines(%q, %d) = %q, want %q", tt.lines, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package editor
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+)
+
+// The elements of the status column, which are the minwid of their click
+// regions passed to GonvimStcClick
+const (
+	stcNumber = iota + 1
+	stcSign
+	stcFold
+)
+
+var stcElements = map[string]int{
+	"number": stcNumber,
+	"sign":   stcSign,
+	"fold":   stcFold,
+}
+
+// statusColumnCommands set 'statuscolumn' to g:gonvim_statuscolumn in the
+// normal windows, so that nvim lays out the columns the GUI draws, and
+// notify the clicks on the elements. GonvimStcNumber returns the number of
+// the line as 'number' and 'relativenumber' show it, and GonvimStcSep
+// returns g:gonvim_statuscolumn_separator unless the column of the element
+// is empty.
+const statusColumnCommands = `
+	function! GonvimStcClick(minwid, clicks, button, mods) abort
+		let pos = getmousepos()
+		call rpcnotify(0, "Gui", "gonvim_stc_click", a:minwid, pos.winid, pos.line, a:button)
+	endfunction
+	function! GonvimStcNumber(width) abort
+		if !&number && !&relativenumber
+			return ""
+		endif
+		if v:virtnum
+			return repeat(" ", a:width)
+		endif
+		return printf("%" . a:width . "d", &relativenumber && (v:relnum || !&number) ? v:relnum : v:lnum)
+	endfunction
+	function! GonvimStcSep(element) abort
+		if a:element == 1 && !&number && !&relativenumber
+			return ""
+		elseif a:element == 2 && (&signcolumn =~# "^no" || (&signcolumn =~# "^auto" && empty(sign_getplaced(bufnr(), {"group": "*"})[0].signs) && empty(nvim_buf_get_extmarks(0, -1, 0, -1, {"details": v:false, "limit": 1, "type": "sign"}))))
+			return ""
+		elseif a:element == 3 && &foldcolumn ==# "0"
+			return ""
+		endif
+		return g:gonvim_statuscolumn_separator
+	endfunction
+	function! GonvimStcApply(wid) abort
+		if win_gettype(a:wid) ==# "" && getbufvar(winbufnr(a:wid), "&buftype") ==# ""
+			call setwinvar(a:wid, "&statuscolumn", g:gonvim_statuscolumn)
+		elseif getwinvar(a:wid, "&statuscolumn") ==# g:gonvim_statuscolumn
+			call setwinvar(a:wid, "&statuscolumn", "")
+		endif
+	endfunction
+	if exists("+statuscolumn")
+		for wid in nvim_list_wins() | call GonvimStcApply(wid) | endfor
+		aug GonvimAuStc | au! | aug END
+		au GonvimAuStc BufWinEnter,WinNew,TermOpen * call GonvimStcApply(win_getid())
+	endif
+	`
+
+// statusColumnExpr returns 'statuscolumn' of the layout of the config. The
+// elements are in the order of the layout, each followed by the separator
+// of GonvimStcSep, and the elements of the click commands are the click
+// regions.
+func statusColumnExpr(c statusColumnConfig) string {
+	var b strings.Builder
+	for _, name := range c.Layout {
+		element, ok := stcElements[name]
+		if !ok {
+			continue
+		}
+		var item string
+		switch element {
+		case stcNumber:
+			item = fmt.Sprintf("%%{GonvimStcNumber(%d)}", maxInt(c.NumberWidth, 1))
+		case stcSign:
+			item = "%s"
+		case stcFold:
+			item = "%C"
+		}
+		if statusColumnClick(c, element) != "" {
+			item = fmt.Sprintf("%%%d@GonvimStcClick@%s%%X", element, item)
+		}
+		b.WriteString(item)
+		if c.Separator != "" {
+			b.WriteString(fmt.Sprintf("%%{GonvimStcSep(%d)}", element))
+		}
+	}
+
+	return b.String()
+}
+
+// statusColumnClick returns the command run on the click on the element
+func statusColumnClick(c statusColumnConfig, element int) string {
+	switch element {
+	case stcNumber:
+		return c.NumberClick
+	case stcSign:
+		return c.SignClick
+	case stcFold:
+		return c.FoldClick
+	}
+
+	return ""
+}
+
+// statusColumnClickCommand returns the command run in the clicked window on
+// the click on the element, with the cursor on the clicked line. "{lnum}" in
+// the click command is the line.
+func statusColumnClickCommand(c statusColumnConfig, element, winid, line int) string {
+	command := statusColumnClick(c, element)
+	if command == "" || line < 1 {
+		return ""
+	}
+	command = strings.ReplaceAll(command, "{lnum}", fmt.Sprint(line))
+
+	return fmt.Sprintf("call win_gotoid(%d) | call cursor(%d, 1) | %s", winid, line, command)
+}
+
+// setStatusColumn sets g:gonvim_statuscolumn and its separator for
+// statusColumnCommands
+func (w *Workspace) setStatusColumn() {
+	w.nvim.SetVar("gonvim_statuscolumn", statusColumnExpr(editor.config.StatusColumn))
+	w.nvim.SetVar("gonvim_statuscolumn_separator", editor.config.StatusColumn.Separator)
+}
+
+// statusColumnClicked is called by the gonvim_stc_click notification.
+// args: [element, winid, line, button]
+func (w *Workspace) statusColumnClicked(args []interface{}) {
+	if len(args) < 4 {
+		return
+	}
+	if button, _ := args[3].(string); button != "l" {
+		return
+	}
+	command := statusColumnClickCommand(
+		editor.config.StatusColumn,
+		util.ReflectToInt(args[0]),
+		util.ReflectToInt(args[1]),
+		util.ReflectToInt(args[2]),
+	)
+	if command == "" {
+		return
+	}
+	go w.nvim.Command(command)
+}
+
+// foldIcon returns the direction of the chevron drawn for the char of the
+// fold column, "right" for the closed fold and "down" for the open one, or
+// "" for the other chars
+func foldIcon(char string) string {
+	switch char {
+	case "+", "▸", "▶", "›":
+		return "right"
+	case "-", "▾", "▼", "⌄":
+		return "down"
+	}
+
+	return ""
+}
+
+// drawFoldIcon draws the chevron of the open or the closed fold in the cell
+// of the fold column in place of the char of 'fillchars', and returns false
+// for the other cells
+func (w *Window) drawFoldIcon(p *gui.QPainter, cell *Cell, x, y float64) bool {
+	if !editor.config.StatusColumn.FoldIcons {
+		return false
+	}
+	if cell.highlight.hlName != "FoldColumn" && cell.highlight.uiName != "FoldColumn" {
+		return false
+	}
+	direction := foldIcon(cell.char)
+	if direction == "" {
+		return false
+	}
+	fg := cell.highlight.fg()
+	if fg == nil {
+		return false
+	}
+	font := w.getFont()
+	width := font.truewidth
+	height := float64(font.lineHeight)
+	cx := x + width/2
+	cy := y + height/2
+	// The half of the size of the chevron
+	r := math.Max(math.Min(width, height)/4, 2)
+
+	path := gui.NewQPainterPath()
+	if direction == "right" {
+		path.MoveTo2(cx-r/2, cy-r)
+		path.LineTo2(cx+r/2, cy)
+		path.LineTo2(cx-r/2, cy+r)
+	} else {
+		path.MoveTo2(cx-r, cy-r/2)
+		path.LineTo2(cx, cy+r/2)
+		path.LineTo2(cx+r, cy-r/2)
+	}
+	p.Save()
+	p.SetRenderHint(gui.QPainter__Antialiasing, true)
+	pen := gui.NewQPen3(fg.QColor())
+	pen.SetWidthF(boxThickness(width))
+	pen.SetCapStyle(core.Qt__RoundCap)
+	pen.SetJoinStyle(core.Qt__RoundJoin)
+	p.StrokePath(path, pen)
+	p.Restore()
+
+	return true
+}
+
+// isLineNrHighlight reports whether the highlight is of the number column
+func isLineNrHighlight(hl *Highlight) bool {
+	for _, name := range []string{hl.hlName, hl.uiName} {
+		switch name {
+		case "LineNr", "CursorLineNr", "LineNrAbove", "LineNrBelow":
+			return true
+		}
+	}
+
+	return false
+}
+
+// stcNumberRun returns the end of the run of the cells of the number column
+// which starts at x, and the number in it. It returns false if x doesn't
+// start the run of a number.
+func stcNumberRun(line []*Cell, x int) (int, string, bool) {
+	isNumberCell := func(i int) bool {
+		return i >= 0 && i < len(line) && line[i] != nil && isLineNrHighlight(&line[i].highlight)
+	}
+	if !isNumberCell(x) || isNumberCell(x-1) {
+		return 0, "", false
+	}
+	end := x
+	var b strings.Builder
+	for ; isNumberCell(end); end++ {
+		b.WriteString(line[end].char)
+	}
+	number := strings.TrimSpace(b.String())
+	if number == "" {
+		return 0, "", false
+	}
+	for _, c := range number {
+		if c < '0' || c > '9' {
+			return 0, "", false
+		}
+	}
+
+	return end, number, true
+}
+
+// stcNumberOffset returns the offset in the cells of the number in the run
+// of the width, by the alignment of numberAlign
+func stcNumberOffset(number string, width int, align string) int {
+	space := maxInt(width-len(number), 0)
+	switch align {
+	case "left":
+		return 0
+	case "center":
+		return space / 2
+	}
+
+	return space
+}
+
+// drawLineNumber draws the digit of the number at the cell of the number
+// column, which nvim lays out by 'statuscolumn', in the alignment of
+// numberAlign. It returns false for the cells out of the number column.
+func (w *Window) drawLineNumber(p *gui.QPainter, line []*Cell, x int, y float64) bool {
+	if !editor.config.StatusColumn.Enable || line[x] == nil || !isLineNrHighlight(&line[x].highlight) {
+		return false
+	}
+	start := x
+	for start > 0 && line[start-1] != nil && isLineNrHighlight(&line[start-1].highlight) {
+		start--
+	}
+	end, number, ok := stcNumberRun(line, start)
+	if !ok {
+		return false
+	}
+	i := x - start - stcNumberOffset(number, end-start, editor.config.StatusColumn.NumberAlign)
+	if i < 0 || i >= len(number) {
+		return true
+	}
+	cell := &Cell{
+		char:        number[i : i+1],
+		normalWidth: true,
+		highlight:   line[x].highlight,
+	}
+	width, height := w.glyphSize(cell)
+	rect := core.NewQRectF4(float64(x)*w.getFont().truewidth, y, float64(width), float64(height))
+	w.paintGlyph(p, rect, cell)
+
+	return true
+}
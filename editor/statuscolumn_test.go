@@ -0,0 +1,128 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestStatusColumnExpr(t *testing.T) {
+	tests := []struct {
+		config statusColumnConfig
+		want   string
+	}{
+		{
+			statusColumnConfig{Layout: []string{"fold", "sign", "number"}, NumberWidth: 3, Separator: " "},
+			`%C%{GonvimStcSep(3)}%s%{GonvimStcSep(2)}%{GonvimStcNumber(3)}%{GonvimStcSep(1)}`,
+		},
+		{
+			statusColumnConfig{Layout: []string{"number", "fold"}, NumberWidth: 4, Separator: "│", FoldClick: "normal! za"},
+			`%{GonvimStcNumber(4)}%{GonvimStcSep(1)}%3@GonvimStcClick@%C%X%{GonvimStcSep(3)}`,
+		},
+		{
+			statusColumnConfig{Layout: []string{"sign", "unknown"}, Separator: "%", SignClick: "echo 1"},
+			`%2@GonvimStcClick@%s%X%{GonvimStcSep(2)}`,
+		},
+		{
+			statusColumnConfig{Layout: []string{"number"}},
+			`%{GonvimStcNumber(1)}`,
+		},
+	}
+	for _, tt := range tests {
+		if got := statusColumnExpr(tt.config); got != tt.want {
+			t.Errorf("statusColumnExpr(%v) = %q, want %q", tt.config, got, tt.want)
+		}
+	}
+}
+
+func TestStatusColumnClickCommand(t *testing.T) {
+	c := statusColumnConfig{
+		NumberClick: "call ToggleBreakpoint({lnum})",
+		FoldClick:   "normal! za",
+	}
+	tests := []struct {
+		element int
+		winid   int
+		line    int
+		want    string
+	}{
+		{stcNumber, 1000, 12, "call win_gotoid(1000) | call cursor(12, 1) | call ToggleBreakpoint(12)"},
+		{stcFold, 1001, 3, "call win_gotoid(1001) | call cursor(3, 1) | normal! za"},
+		{stcSign, 1000, 12, ""},
+		{stcNumber, 1000, 0, ""},
+		{0, 1000, 12, ""},
+	}
+	for _, tt := range tests {
+		if got := statusColumnClickCommand(c, tt.element, tt.winid, tt.line); got != tt.want {
+			t.Errorf("statusColumnClickCommand(%d, %d, %d) = %q, want %q", tt.element, tt.winid, tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestFoldIcon(t *testing.T) {
+	tests := []struct {
+		char string
+		want string
+	}{
+		{"+", "right"},
+		{"▸", "right"},
+		{"-", "down"},
+		{"▾", "down"},
+		{"|", ""},
+		{" ", ""},
+	}
+	for _, tt := range tests {
+		if got := foldIcon(tt.char); got != tt.want {
+			t.Errorf("foldIcon(%q) = %q, want %q", tt.char, got, tt.want)
+		}
+	}
+}
+
+func TestStcNumberRun(t *testing.T) {
+	lineNr := Highlight{hlName: "LineNr"}
+	cells := func(chars string, hl Highlight) []*Cell {
+		var line []*Cell
+		for _, c := range chars {
+			line = append(line, &Cell{char: string(c), highlight: hl})
+		}
+		return line
+	}
+	tests := []struct {
+		name   string
+		line   []*Cell
+		x      int
+		end    int
+		number string
+		ok     bool
+	}{
+		{"padded", append(cells(" 12", lineNr), cells(" x", Highlight{})...), 0, 3, "12", true},
+		{"inside the run", cells(" 12", lineNr), 1, 0, "", false},
+		{"wrapped line", cells("   ", lineNr), 0, 0, "", false},
+		{"not a number", cells("ab", lineNr), 0, 0, "", false},
+		{"other highlight", cells("12", Highlight{}), 0, 0, "", false},
+	}
+	for _, tt := range tests {
+		end, number, ok := stcNumberRun(tt.line, tt.x)
+		if end != tt.end || number != tt.number || ok != tt.ok {
+			t.Errorf("stcNumberRun(%s) = %d, %q, %v, want %d, %q, %v", tt.name, end, number, ok, tt.end, tt.number, tt.ok)
+		}
+	}
+}
+
+func TestStcNumberOffset(t *testing.T) {
+	tests := []struct {
+		number string
+		width  int
+		align  string
+		want   int
+	}{
+		{"12", 5, "right", 3},
+		{"12", 5, "", 3},
+		{"12", 5, "left", 0},
+		{"12", 5, "center", 1},
+		{"1234", 3, "right", 0},
+	}
+	for _, tt := range tests {
+		if got := stcNumberOffset(tt.number, tt.width, tt.align); got != tt.want {
+			t.Errorf("stcNumberOffset(%q, %d, %q) = %d, want %d", tt.number, tt.width, tt.align, got, tt.want)
+		}
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/akiyosi/goneovim/util"
 	"github.com/therecipe/qt/core"
@@ -44,6 +46,7 @@ type Statusline struct {
 	encoding   *StatuslineEncoding
 	fileFormat *StatuslineFileFormat
 	lint       *StatuslineLint
+	wordcount  *StatuslineWordcount
 
 	updates chan []interface{}
 }
@@ -147,6 +150,34 @@ type StatuslineFileFormat struct {
 	c          *StatuslineComponent
 }
 
+// StatuslineWordcount shows the word and character counts of the buffer,
+// or of the selection in visual mode, for the prose filetypes.
+// The counts are requested asynchronously and debounced.
+type StatuslineWordcount struct {
+	s *Statusline
+	c *StatuslineComponent
+
+	mu    sync.Mutex
+	timer *time.Timer
+	text  string
+}
+
+// wordCount is the result of wordcount() with the number of selected lines
+type wordCount struct {
+	Words       int `msgpack:"words"`
+	Chars       int `msgpack:"chars"`
+	VisualWords int `msgpack:"visual_words"`
+	VisualChars int `msgpack:"visual_chars"`
+	VisualLines int `msgpack:"visual_lines"`
+}
+
+const (
+	// wordcountDelay is the debounce time of requesting the word count
+	wordcountDelay = 300 * time.Millisecond
+
+	wordcountExpr = `extend(wordcount(), {'visual_lines': mode() =~# "^[vV\<C-v>]" ? abs(line('v') - line('.')) + 1 : 0})`
+)
+
 func initStatusline() *Statusline {
 	widget := widgets.NewQWidget(nil, 0)
 	widget.SetContentsMargins(0, 0, 0, 0)
@@ -327,6 +358,16 @@ func initStatusline() *Statusline {
 	s.lint = lint
 	s.lint.c.hide()
 
+	wordcountLabel := widgets.NewQLabel(nil, 0)
+	wordcount := &StatuslineWordcount{
+		s: s,
+		c: &StatuslineComponent{
+			label: wordcountLabel,
+		},
+	}
+	s.wordcount = wordcount
+	s.wordcount.c.hide()
+
 	s.setContentsMarginsForWidgets(0, 1, 0, 1)
 	left.widget.SetLayout(leftLayout)
 
@@ -390,6 +431,10 @@ func (s *Statusline) setWidget() {
 			s.widget.Layout().AddWidget(s.lint.c.widget)
 			s.lint.c.isInclude = true
 			s.lint.c.show()
+		case "wordcount":
+			s.widget.Layout().AddWidget(s.wordcount.c.label)
+			s.wordcount.c.isInclude = true
+			s.wordcount.c.show()
 		default:
 		}
 	}
@@ -436,6 +481,10 @@ func (left *LeftStatusItem) setWidget() {
 			left.widget.Layout().AddWidget(left.s.lint.c.widget)
 			left.s.lint.c.isInclude = true
 			left.s.lint.c.show()
+		case "wordcount":
+			left.widget.Layout().AddWidget(left.s.wordcount.c.label)
+			left.s.wordcount.c.isInclude = true
+			left.s.wordcount.c.show()
 		default:
 		}
 	}
@@ -449,6 +498,7 @@ func (s *Statusline) setContentsMarginsForWidgets(l int, u int, r int, d int) {
 	s.fileFormat.c.label.SetContentsMargins(l, u, r, d)
 	s.encoding.c.label.SetContentsMargins(l, u, r, d)
 	s.lint.c.widget.SetContentsMargins(l, u, r, d)
+	s.wordcount.c.label.SetContentsMargins(l, u, r, d)
 }
 
 func (s *Statusline) setColor() {
@@ -499,6 +549,7 @@ func (s *Statusline) setColor() {
 	s.git.c.setColor(fg, bg)
 	s.encoding.c.setColor(fg, bg)
 	s.fileFormat.c.setColor(fg, bg)
+	s.wordcount.c.setColor(fg, bg)
 	s.pos.c.setColor(fg, bg)
 
 	s.lint.c.fg = fg
@@ -546,6 +597,7 @@ func (s *Statusline) updateFont() {
 	s.git.c.label.SetFont(font)
 	s.encoding.c.label.SetFont(font)
 	s.fileFormat.c.label.SetFont(font)
+	s.wordcount.c.label.SetFont(font)
 }
 
 func (s *Statusline) subscribe() {
@@ -563,6 +615,18 @@ func (s *Statusline) subscribe() {
 	s.ws.signal.ConnectGitSignal(func() {
 		s.git.update()
 	})
+	s.ws.signal.ConnectWordcountSignal(func() {
+		s.wordcount.update()
+	})
+	s.registerHandler()
+}
+
+// registerHandler subscribes the statusline notifications of the current
+// connection to neovim
+func (s *Statusline) registerHandler() {
+	if !s.ws.drawStatusline {
+		return
+	}
 	s.ws.nvim.RegisterHandler("statusline", func(updates ...interface{}) {
 		s.updates <- updates
 		s.ws.signal.StatuslineSignal()
@@ -905,6 +969,88 @@ func (s *StatuslineFiletype) redraw(filetype string) {
 	s.c.show()
 }
 
+func (s *StatuslineWordcount) isProse() bool {
+	for _, filetype := range editor.config.Statusline.ProseFiletypes {
+		if filetype == s.s.filetype.filetype {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redraw requests the word count after the buffer or the cursor stops
+// changing for wordcountDelay, so that large files don't stall the GUI
+func (s *StatuslineWordcount) redraw() {
+	if !s.c.isInclude {
+		return
+	}
+	if !s.isProse() {
+		s.mu.Lock()
+		if s.timer != nil {
+			s.timer.Stop()
+		}
+		s.text = ""
+		s.mu.Unlock()
+		s.c.label.SetText("")
+		s.c.hide()
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.timer = time.AfterFunc(wordcountDelay, s.request)
+}
+
+func (s *StatuslineWordcount) request() {
+	var wc wordCount
+	err := s.s.ws.nvim.Eval(wordcountExpr, &wc)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	s.text = wordcountText(wc)
+	s.mu.Unlock()
+	s.s.ws.signal.WordcountSignal()
+}
+
+func (s *StatuslineWordcount) update() {
+	s.mu.Lock()
+	text := s.text
+	s.mu.Unlock()
+	if !s.isProse() || text == s.c.label.Text() {
+		return
+	}
+	s.c.label.SetText(text)
+	s.c.show()
+}
+
+// wordcountText returns the text of the word count; it shows the counts
+// of the selection in visual mode
+func wordcountText(wc wordCount) string {
+	if wc.VisualLines > 0 {
+		return fmt.Sprintf(
+			"%s, %s, %s",
+			pluralize(wc.VisualLines, "line"),
+			pluralize(wc.VisualWords, "word"),
+			pluralize(wc.VisualChars, "char"),
+		)
+	}
+
+	return fmt.Sprintf("%s, %s", pluralize(wc.Words, "word"), pluralize(wc.Chars, "char"))
+}
+
+func pluralize(n int, word string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, word)
+	}
+
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
 func (s *StatuslineLint) update() {
 	s.errorLabel.SetText(strconv.Itoa(s.errors))
 	s.warnLabel.SetText(strconv.Itoa(s.warnings))
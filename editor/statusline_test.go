@@ -0,0 +1,27 @@
+package editor
+
+import "testing"
+
+func TestWordcountText(t *testing.T) {
+	tests := []struct {
+		wc   wordCount
+		want string
+	}{
+		{wordCount{Words: 120, Chars: 640}, "120 words, 640 chars"},
+		{wordCount{Words: 1, Chars: 1}, "1 word, 1 char"},
+		{wordCount{Words: 0, Chars: 0}, "0 words, 0 chars"},
+		{
+			wordCount{Words: 120, Chars: 640, VisualWords: 12, VisualChars: 64, VisualLines: 3},
+			"3 lines, 12 words, 64 chars",
+		},
+		{
+			wordCount{Words: 120, Chars: 640, VisualWords: 1, VisualChars: 5, VisualLines: 1},
+			"1 line, 1 word, 5 chars",
+		},
+	}
+	for _, tt := range tests {
+		if got := wordcountText(tt.wc); got != tt.want {
+			t.Errorf("wordcountText(%+v) = %q, want %q", tt.wc, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package editor
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/therecipe/qt/core"
+)
+
+// userStyleSheet is the QSS file given by the user to theme the GUI chrome.
+// The object names #tabline, #tab, #palette, #notification, #sidebar,
+// #scrollbar, #scrollbarthumb, #cheatsheet, #output, #helpreader and
+// #focusring can be used as the selectors.
+type userStyleSheet struct {
+	path    string
+	content string
+	watcher *core.QFileSystemWatcher
+}
+
+func (e *Editor) initUserStyleSheet() {
+	path := e.config.Editor.StyleSheet
+	if path == "" {
+		path = filepath.Join(e.homeDir, ".goneovim", "style.qss")
+	}
+	if strings.HasPrefix(path, "~") {
+		path = filepath.Join(e.homeDir, path[1:])
+	}
+
+	e.userStyle = &userStyleSheet{
+		path: path,
+	}
+	if !isFileExist(path) {
+		return
+	}
+	e.userStyle.load()
+
+	// Hot reload
+	watcher := core.NewQFileSystemWatcher2([]string{path}, nil)
+	watcher.ConnectFileChanged(func(file string) {
+		// Some editors replace the file on saving, so watch it again
+		if len(watcher.Files()) == 0 {
+			watcher.AddPath(file)
+		}
+		e.userStyle.load()
+		e.updateChromeStyle()
+	})
+	e.userStyle.watcher = watcher
+}
+
+func (u *userStyleSheet) load() {
+	data, err := ioutil.ReadFile(u.path)
+	if err != nil {
+		return
+	}
+	u.content = string(data)
+}
+
+// withUserStyle appends the user stylesheet to the style of the GUI chrome
+// so that the user style takes precedence.
+func withUserStyle(style string) string {
+	if editor.userStyle == nil || editor.userStyle.content == "" {
+		return style
+	}
+
+	return style + "\n" + editor.userStyle.content
+}
+
+// updateChromeStyle applies the style again to the GUI chrome of all workspaces
+func (e *Editor) updateChromeStyle() {
+	if !e.isSetGuiColor {
+		return
+	}
+	for _, ws := range e.workspaces {
+		ws.updateWorkspaceColor()
+		for _, tab := range ws.tabline.Tabs {
+			tab.updateStyle()
+		}
+	}
+	for _, n := range e.notifications {
+		n.setColor()
+	}
+}
@@ -248,6 +248,16 @@ func (e *Editor) getSvg(name string, color *RGBA) string {
 		color = newRGBA(255, 255, 255, 1)
 	}
 
+	// The icon theme takes precedence over the built-in icons
+	if xml, ok := e.iconTheme.icon(name, color); ok {
+		return xml
+	}
+	if e.svgs[name] == nil {
+		if xml, ok := e.iconTheme.icon("default", color); ok {
+			return xml
+		}
+	}
+
 	return fmt.Sprintf(svg.xml, color.Hex())
 }
 
@@ -562,6 +572,12 @@ func (e *Editor) initSVGS() {
 		xml:    `<svg style="width:24px;height:24px" viewBox="0 0 24 24"><path fill="%s" d="M12,17A2,2 0 0,0 14,15C14,13.89 13.1,13 12,13A2,2 0 0,0 10,15A2,2 0 0,0 12,17M18,8A2,2 0 0,1 20,10V20A2,2 0 0,1 18,22H6A2,2 0 0,1 4,20V10C4,8.89 4.9,8 6,8H7V6A5,5 0 0,1 12,1A5,5 0 0,1 17,6V8H18M12,3A3,3 0 0,0 9,6V8H15V6A3,3 0 0,0 12,3Z" /></svg>`,
 	}
 
+	e.svgs["zoom"] = &SvgXML{
+		width:  24,
+		height: 24,
+		xml:    `<svg style="width:24px;height:24px" viewBox="0 0 24 24"><path fill="%s" d="M9.5,13.09L10.91,14.5L6.41,19H10V21H3V14H5V17.59L9.5,13.09M10.91,9.5L9.5,10.91L5,6.41V10H3V3H10V5H6.41L10.91,9.5M14.5,13.09L19,17.59V14H21V21H14V19H17.59L13.09,14.5L14.5,13.09M13.09,9.5L17.59,5H14V3H21V10H19V6.41L14.5,10.91L13.09,9.5Z" /></svg>`,
+	}
+
 	e.svgs["thought"] = &SvgXML{
 		width:  24,
 		height: 24,
@@ -592,6 +608,24 @@ func (e *Editor) initSVGS() {
 		xml:    `<svg width="24" height="24" viewBox="0 0 24 24"><path fill="%s" d="M7.41,8.58L12,13.17L16.59,8.58L18,10L12,16L6,10L7.41,8.58Z" /></svg>`,
 	}
 
+	e.svgs["copy"] = &SvgXML{
+		width:  24,
+		height: 24,
+		xml:    `<svg width="24" height="24" viewBox="0 0 24 24"><path fill="%s" d="M16,1H4C2.9,1,2,1.9,2,3v14h2V3h12V1z M19,5H8C6.9,5,6,5.9,6,7v14c0,1.1,0.9,2,2,2h11c1.1,0,2-0.9,2-2V7C21,5.9,20.1,5,19,5z M19,21H8V7h11V21z" /></svg>`,
+	}
+
+	e.svgs["microphone"] = &SvgXML{
+		width:  24,
+		height: 24,
+		xml:    `<svg width="24" height="24" viewBox="0 0 24 24"><path fill="%s" d="M12,2A3,3 0 0,1 15,5V11A3,3 0 0,1 12,14A3,3 0 0,1 9,11V5A3,3 0 0,1 12,2M19,11C19,14.53 16.39,17.44 13,17.93V21H11V17.93C7.61,17.44 5,14.53 5,11H7A5,5 0 0,0 12,16A5,5 0 0,0 17,11H19Z" /></svg>`,
+	}
+
+	e.svgs["chevron-left"] = &SvgXML{
+		width:  24,
+		height: 24,
+		xml:    `<svg width="24" height="24" viewBox="0 0 24 24"><path fill="%s" d="M15.41,16.58L10.83,12L15.41,7.41L14,6L8,12L14,18L15.41,16.58Z" /></svg>`,
+	}
+
 	e.svgs["chevron-right"] = &SvgXML{
 		width:  24,
 		height: 24,
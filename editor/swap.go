@@ -0,0 +1,191 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+// swapAutoCmds open the file whose swap file exists read-only instead of the
+// console prompt of E325, and notify the GUI which asks what to do with it.
+// args: [file, swap file, swapinfo(), buf, the process of the swap file is
+// running, the mtime of the file]
+const swapAutoCmds = `
+	aug GonvimAuSwap | au! | aug END
+	au GonvimAuSwap SwapExists * let v:swapchoice = "o" | call rpcnotify(0, "Gui", "gonvim_swap_exists", expand("<afile>:p"), v:swapname, swapinfo(v:swapname), bufnr(), luaeval("_A.pid ~= nil and _A.pid > 0 and _A.host == vim.loop.os_gethostname() and vim.loop.kill(_A.pid, 0) == 0", swapinfo(v:swapname)), getftime(expand("<afile>:p")))
+	`
+
+// swapInfo is the result of swapinfo()
+type swapInfo struct {
+	user  string
+	host  string
+	pid   int
+	mtime int64
+	dirty bool
+	err   string
+}
+
+// swapChoice is a button of the swap dialog
+type swapChoice struct {
+	choice string
+	label  string
+}
+
+// swapChoices are the choices of E325, and "diff" which recovers the swap
+// file and diffs it against the file in a split
+var swapChoices = []swapChoice{
+	{"readonly", "Open Read-Only"},
+	{"edit", "Edit anyway"},
+	{"recover", "Recover"},
+	{"diff", "Diff against swap"},
+	{"delete", "Delete it"},
+	{"quit", "Quit"},
+}
+
+func parseSwapInfo(arg interface{}) swapInfo {
+	var info swapInfo
+	dict, ok := arg.(map[string]interface{})
+	if !ok {
+		return info
+	}
+	info.user, _ = dict["user"].(string)
+	info.host, _ = dict["host"].(string)
+	info.err, _ = dict["error"].(string)
+	if pid, ok := dict["pid"]; ok {
+		info.pid = util.ReflectToInt(pid)
+	}
+	if mtime, ok := dict["mtime"]; ok {
+		info.mtime = int64(util.ReflectToInt(mtime))
+	}
+	if dirty, ok := dict["dirty"]; ok {
+		info.dirty = util.ReflectToInt(dirty) != 0
+	}
+
+	return info
+}
+
+// swapSummary returns the lines of the details of the swap file shown in the
+// dialog, as E325 shows them
+func swapSummary(swapname string, info swapInfo, running bool, fileMtime int64) string {
+	lines := []string{fmt.Sprintf("Swap file: %s", swapname)}
+	if info.err != "" {
+		lines = append(lines, fmt.Sprintf("The swap file cannot be read: %s", info.err))
+		return strings.Join(lines, "\n")
+	}
+	if info.user != "" || info.host != "" {
+		lines = append(lines, fmt.Sprintf("Owned by: %s@%s", info.user, info.host))
+	}
+	if info.pid > 0 {
+		pid := fmt.Sprintf("Process ID: %d", info.pid)
+		if running {
+			pid += " (still running)"
+		}
+		lines = append(lines, pid)
+	}
+	if info.mtime > 0 {
+		modified := fmt.Sprintf("Modified: %s", time.Unix(info.mtime, 0).Format("2006-01-02 15:04:05"))
+		if fileMtime > 0 && info.mtime > fileMtime {
+			modified += " (newer than the file)"
+		}
+		lines = append(lines, modified)
+	}
+	if info.dirty {
+		lines = append(lines, "The swap file has the changes which are not written.")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// swapCommands returns the Ex commands which apply the choice to the buffer,
+// which is opened read-only. swap is the swap file escaped by fnameescape().
+func swapCommands(choice string, buf int, swap string) []string {
+	focus := fmt.Sprintf("if bufwinid(%d) > 0 | call win_gotoid(bufwinid(%d)) | else | buffer %d | endif", buf, buf, buf)
+	switch choice {
+	case "edit", "delete":
+		return []string{focus, "setlocal noreadonly"}
+	case "recover":
+		return []string{focus, "recover! " + swap, "setlocal noreadonly"}
+	case "diff":
+		return []string{
+			focus,
+			"recover! " + swap,
+			"setlocal noreadonly",
+			"vertical new",
+			"setlocal buftype=nofile bufhidden=wipe noswapfile",
+			"read ++edit #",
+			"0delete _",
+			"diffthis",
+			"wincmd p",
+			"diffthis",
+		}
+	case "quit":
+		return []string{fmt.Sprintf("bwipeout %d", buf)}
+	}
+
+	return nil
+}
+
+// swapExists is called by the gonvim_swap_exists notification, and asks what
+// to do with the swap file in the native dialog instead of the console prompt
+func (w *Workspace) swapExists(args []interface{}) {
+	if len(args) < 6 {
+		return
+	}
+	file, _ := args[0].(string)
+	swapname, _ := args[1].(string)
+	info := parseSwapInfo(args[2])
+	buf := util.ReflectToInt(args[3])
+	running, _ := args[4].(bool)
+	fileMtime := int64(util.ReflectToInt(args[5]))
+
+	box := widgets.NewQMessageBox2(
+		widgets.QMessageBox__Warning,
+		"Goneovim",
+		fmt.Sprintf("Found a swap file for %s", file),
+		widgets.QMessageBox__NoButton,
+		editor.window,
+		core.Qt__Dialog,
+	)
+	box.SetInformativeText(swapSummary(swapname, info, running, fileMtime))
+	buttons := make([]*widgets.QPushButton, len(swapChoices))
+	for i, c := range swapChoices {
+		buttons[i] = box.AddButton2(c.label, widgets.QMessageBox__ActionRole)
+		editor.focusChain.addDialog(buttons[i].QWidget_PTR(), buttons[i].Click)
+	}
+	// The file is already open read-only, which Escape keeps
+	box.SetDefaultButton(buttons[0])
+	box.SetEscapeButton(buttons[0])
+	box.Exec()
+
+	choice := ""
+	clicked := box.ClickedButton()
+	for i, button := range buttons {
+		if clicked != nil && clicked.Pointer() == button.Pointer() {
+			choice = swapChoices[i].choice
+		}
+	}
+	if choice == "" || choice == "readonly" {
+		return
+	}
+
+	go func() {
+		var swap string
+		if err := w.nvim.Call("fnameescape", &swap, swapname); err != nil {
+			return
+		}
+		if choice == "delete" {
+			var result int
+			w.nvim.Call("delete", &result, swapname)
+		}
+		for _, command := range swapCommands(choice, buf, swap) {
+			if err := w.nvim.Command(command); err != nil {
+				editor.pushNotification(NotifyWarn, -1, fmt.Sprintf("[Goneovim] %s", err))
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,90 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSwapInfo(t *testing.T) {
+	info := parseSwapInfo(map[string]interface{}{
+		"user":  "u",
+		"host":  "h",
+		"pid":   int64(42),
+		"mtime": int64(1700000000),
+		"dirty": int64(1),
+	})
+	want := swapInfo{user: "u", host: "h", pid: 42, mtime: 1700000000, dirty: true}
+	if info != want {
+		t.Errorf("parseSwapInfo() = %+v, want %+v", info, want)
+	}
+	if got := parseSwapInfo("broken"); got != (swapInfo{}) {
+		t.Errorf("parseSwapInfo(broken) = %+v, want zero", got)
+	}
+}
+
+func TestSwapSummary(t *testing.T) {
+	info := swapInfo{user: "u", host: "h", pid: 42, mtime: 1700000000, dirty: true}
+	modified := time.Unix(1700000000, 0).Format("2006-01-02 15:04:05")
+	tests := []struct {
+		info      swapInfo
+		running   bool
+		fileMtime int64
+		want      []string
+	}{
+		{
+			info, true, 1600000000,
+			[]string{
+				"Swap file: /tmp/.a.swp",
+				"Owned by: u@h",
+				"Process ID: 42 (still running)",
+				"Modified: " + modified + " (newer than the file)",
+				"The swap file has the changes which are not written.",
+			},
+		},
+		{
+			swapInfo{pid: 42, mtime: 1700000000}, false, 1800000000,
+			[]string{
+				"Swap file: /tmp/.a.swp",
+				"Process ID: 42",
+				"Modified: " + modified,
+			},
+		},
+		{
+			swapInfo{err: "Cannot open file"}, false, 0,
+			[]string{
+				"Swap file: /tmp/.a.swp",
+				"The swap file cannot be read: Cannot open file",
+			},
+		},
+	}
+	for _, tt := range tests {
+		want := strings.Join(tt.want, "\n")
+		if got := swapSummary("/tmp/.a.swp", tt.info, tt.running, tt.fileMtime); got != want {
+			t.Errorf("swapSummary(%+v) = %q, want %q", tt.info, got, want)
+		}
+	}
+}
+
+func TestSwapCommands(t *testing.T) {
+	focus := "if bufwinid(3) > 0 | call win_gotoid(bufwinid(3)) | else | buffer 3 | endif"
+	tests := []struct {
+		choice string
+		want   []string
+	}{
+		{"readonly", nil},
+		{"edit", []string{focus, "setlocal noreadonly"}},
+		{"recover", []string{focus, `recover! /tmp/.a\ b.swp`, "setlocal noreadonly"}},
+		{"quit", []string{"bwipeout 3"}},
+	}
+	for _, tt := range tests {
+		got := swapCommands(tt.choice, 3, `/tmp/.a\ b.swp`)
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("swapCommands(%q) = %q, want %q", tt.choice, got, tt.want)
+		}
+	}
+	diff := swapCommands("diff", 3, "s.swp")
+	if diff[1] != "recover! s.swp" || diff[len(diff)-1] != "diffthis" {
+		t.Errorf("swapCommands(diff) = %q", diff)
+	}
+}
@@ -0,0 +1,116 @@
+package editor
+
+import (
+	"fmt"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+// tabDrag is the tab pressed in the tabline, which is dragged to reorder the
+// tabpages once the pointer moves beyond the drag distance
+type tabDrag struct {
+	tab  *Tab
+	from int
+	// pressX is the global x of the press, and originX is the x of the tab
+	pressX   int
+	originX  int
+	dragging bool
+}
+
+// tabDropIndex returns the index the tab of the index from is moved to when
+// its center is dropped at x, among the tabs of the centers
+func tabDropIndex(centers []int, from, x int) int {
+	to := 0
+	for i, center := range centers {
+		if i != from && center < x {
+			to++
+		}
+	}
+
+	return to
+}
+
+// tabMoveArg returns the argument of :tabmove which moves the current
+// tabpage from the index to the other, both 0-based. :tabmove N moves it
+// after the tabpage N counted before the move.
+func tabMoveArg(from, to int) int {
+	if to > from {
+		return to + 1
+	}
+
+	return to
+}
+
+// startDrag is called when the tab is pressed by the left button
+func (t *Tab) startDrag(event *gui.QMouseEvent) {
+	for i, tab := range t.t.Tabs {
+		if tab != t {
+			continue
+		}
+		t.t.drag = &tabDrag{
+			tab:     t,
+			from:    i,
+			pressX:  event.GlobalPos().X(),
+			originX: t.widget.X(),
+		}
+		return
+	}
+}
+
+func (t *Tab) dragMoveEvent(event *gui.QMouseEvent) {
+	drag := t.t.drag
+	if drag == nil || drag.tab != t || event.Buttons()&core.Qt__LeftButton == 0 {
+		return
+	}
+	dx := event.GlobalPos().X() - drag.pressX
+	if !drag.dragging {
+		if dx < widgets.QApplication_StartDragDistance() && -dx < widgets.QApplication_StartDragDistance() {
+			return
+		}
+		drag.dragging = true
+		if t.t.preview != nil {
+			t.t.preview.hide()
+		}
+		cursor := gui.NewQCursor()
+		cursor.SetShape(core.Qt__ClosedHandCursor)
+		t.widget.SetCursor(cursor)
+	}
+	t.widget.Move2(drag.originX+dx, t.widget.Y())
+	t.widget.Raise()
+}
+
+// dragReleaseEvent drops the dragged tab, and moves its tabpage by :tabmove.
+// The tabline is laid out again by the order nvim sends back.
+func (t *Tab) dragReleaseEvent(event *gui.QMouseEvent) {
+	drag := t.t.drag
+	t.t.drag = nil
+	if drag == nil || drag.tab != t || !drag.dragging {
+		return
+	}
+	cursor := gui.NewQCursor()
+	cursor.SetShape(core.Qt__ArrowCursor)
+	t.widget.SetCursor(cursor)
+
+	var centers []int
+	for _, tab := range t.t.Tabs {
+		if tab.hidden {
+			break
+		}
+		centers = append(centers, tab.widget.X()+tab.widget.Width()/2)
+	}
+	to := tabDropIndex(centers, drag.from, t.widget.X()+t.widget.Width()/2)
+	t.t.layout.Invalidate()
+	t.t.layout.Activate()
+	if to == drag.from {
+		return
+	}
+
+	targetTab := nvim.Tabpage(t.ID)
+	go func() {
+		t.t.ws.nvim.SetCurrentTabpage(targetTab)
+		t.t.ws.nvim.Command(fmt.Sprintf("tabmove %d", tabMoveArg(drag.from, to)))
+	}()
+}
@@ -0,0 +1,47 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestTabDropIndex(t *testing.T) {
+	centers := []int{50, 150, 250, 350}
+	tests := []struct {
+		from int
+		x    int
+		want int
+	}{
+		{0, 60, 0},
+		{0, 200, 1},
+		{0, 400, 3},
+		{3, 10, 0},
+		{3, 200, 2},
+		{1, 140, 1},
+		{2, 100, 1},
+	}
+	for _, tt := range tests {
+		if got := tabDropIndex(centers, tt.from, tt.x); got != tt.want {
+			t.Errorf("tabDropIndex(%v, %d, %d) = %d, want %d", centers, tt.from, tt.x, got, tt.want)
+		}
+	}
+}
+
+func TestTabMoveArg(t *testing.T) {
+	tests := []struct {
+		from int
+		to   int
+		want int
+	}{
+		// [a, b, c]: moving a after b is :tabmove 2
+		{0, 1, 2},
+		{0, 2, 3},
+		// moving c first is :tabmove 0, and after a is :tabmove 1
+		{2, 0, 0},
+		{2, 1, 1},
+	}
+	for _, tt := range tests {
+		if got := tabMoveArg(tt.from, tt.to); got != tt.want {
+			t.Errorf("tabMoveArg(%d, %d) = %d, want %d", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/akiyosi/goneovim/util"
 	shortpath "github.com/akiyosi/short_path"
 	"github.com/neovim/go-client/nvim"
 	"github.com/therecipe/qt/core"
@@ -30,6 +29,12 @@ type Tabline struct {
 	font       *gui.QFont
 	fontfamily string
 	fontsize   int
+
+	preview *tabPreview
+	// zoomed is the tabpages whose window is zoomed
+	zoomed map[int]bool
+	drag   *tabDrag
+	scroll *tabScroll
 }
 
 // Tab in the tabline
@@ -45,6 +50,10 @@ type Tab struct {
 	fileIcon  *svg.QSvgWidget
 	fileType  string
 	closeIcon *svg.QSvgWidget
+	lockIcon  *svg.QSvgWidget
+	readOnly  bool
+	zoomIcon  *svg.QSvgWidget
+	zoomed    bool
 	file      *widgets.QLabel
 	fileText  string
 	hidden    bool
@@ -63,18 +72,19 @@ func (t *Tabline) subscribe() {
 
 func (t *Tabline) setColor() {
 	inactiveFg := editor.colors.inactiveFg.String()
-	t.widget.SetStyleSheet(fmt.Sprintf(`
+	t.widget.SetStyleSheet(withUserStyle(fmt.Sprintf(`
 	.QWidget { 
 		border-bottom: 0px solid;
 		border-right: 0px solid;
-		background-color: rgba(0, 0, 0, 0); } QWidget { color: %s; } `, inactiveFg))
+		background-color: rgba(0, 0, 0, 0); } QWidget { color: %s; } `, inactiveFg)))
+	t.scroll.setColor()
 }
 
 func initTabline() *Tabline {
 	widget := widgets.NewQWidget(nil, 0)
 	widget.SetContentsMargins(5, 5, 5, 5)
+	widget.SetObjectName("tabline")
 
-	layout := util.NewVFlowLayout(16, 10, 1, 0, 0)
 	// layout := widgets.NewQLayout2()
 	// layout.SetSpacing(0)
 	// layout.SetContentsMargins(0, 0, 0, 0)
@@ -113,19 +123,33 @@ func initTabline() *Tabline {
 	// 	return nil
 	// })
 
-	widget.SetLayout(layout)
-
 	marginDefault := 10
 	marginTop := int(float64(editor.extFontSize) / 2.2) // No effect now
 	marginBot := int(float64(editor.extFontSize) / 1.8) // No effect now
 	tabline := &Tabline{
 		widget:        widget,
-		layout:        layout,
 		marginDefault: marginDefault,
 		marginTop:     marginTop,
 		marginBottom:  marginBot,
 	}
 
+	// The tabs are laid out in the viewport, which is scrolled when they
+	// overflow
+	scroll := newTabScroll(tabline)
+	layout := newTabLayout(scroll)
+	scroll.viewport.SetLayout(layout)
+	tabline.scroll = scroll
+	tabline.layout = layout
+	outer := widgets.NewQHBoxLayout()
+	outer.SetContentsMargins(0, 0, 0, 0)
+	outer.SetSpacing(2)
+	outer.AddWidget(scroll.left, 0, core.Qt__AlignVCenter)
+	outer.AddWidget(scroll.viewport, 1, 0)
+	outer.AddWidget(scroll.right, 0, core.Qt__AlignVCenter)
+	outer.AddWidget(scroll.list, 0, core.Qt__AlignVCenter)
+	outer.AddWidget(scroll.reserved, 0, 0)
+	widget.SetLayout(outer)
+
 	tabs := []*Tab{}
 	for i := 0; i < 24; i++ {
 		tab := newTab()
@@ -148,6 +172,7 @@ func initTabline() *Tabline {
 func newTab() *Tab {
 	w := widgets.NewQWidget(nil, 0)
 	w.SetContentsMargins(5, 0, 10, 0)
+	w.SetObjectName("tab")
 	l := widgets.NewQHBoxLayout()
 	l.SetContentsMargins(0, 0, 0, 0) // tab margins
 	l.SetSpacing(0)
@@ -159,7 +184,14 @@ func newTab() *Tab {
 	closeIcon := svg.NewQSvgWidget(nil)
 	closeIcon.SetFixedWidth(editor.iconSize)
 	closeIcon.SetFixedHeight(editor.iconSize)
+	lockIcon := svg.NewQSvgWidget(nil)
+	lockIcon.SetFixedWidth(editor.iconSize)
+	lockIcon.SetFixedHeight(editor.iconSize)
+	lockIcon.Hide()
+	zoomIcon := newZoomIcon()
 	// l.AddWidget(fileIcon, 0, 0)
+	l.AddWidget(lockIcon, 0, 0)
+	l.AddWidget(zoomIcon, 0, 0)
 	l.AddWidget(file, 1, 0)
 	l.AddWidget(closeIcon, 0, 0)
 	w.SetLayout(l)
@@ -169,12 +201,17 @@ func newTab() *Tab {
 		file:   file,
 		// fileIcon:  fileIcon,
 		closeIcon: closeIcon,
+		lockIcon:  lockIcon,
+		zoomIcon:  zoomIcon,
 	}
 	tab.closeIcon.Hide()
 
 	tab.widget.ConnectEnterEvent(tab.enterEvent)
 	tab.widget.ConnectLeaveEvent(tab.leaveEvent)
 	tab.widget.ConnectMousePressEvent(tab.pressEvent)
+	tab.widget.ConnectMouseMoveEvent(tab.dragMoveEvent)
+	tab.widget.ConnectMouseReleaseEvent(tab.dragReleaseEvent)
+	editor.focusChain.add(tab.widget, focusRankTabline, tab.activate)
 
 	closeIcon.ConnectMousePressEvent(tab.closeIconPressEvent)
 	closeIcon.ConnectMouseReleaseEvent(tab.closeIconReleaseEvent)
@@ -198,18 +235,26 @@ func (t *Tab) updateStyle() {
 			border-bottom: 2.0px solid %s; 
 			background-color: rgba(0, 0, 0, 0); 
 		} QWidget{ color: %s; } `, accent, warpColor(fg, -30))
-		t.widget.SetStyleSheet(activeStyle)
+		t.widget.SetStyleSheet(withUserStyle(activeStyle))
 		svgContent := editor.getSvg("cross", nil)
 		t.closeIcon.Load2(core.NewQByteArray2(svgContent, len(svgContent)))
+		lockContent := editor.getSvg("lock", nil)
+		t.lockIcon.Load2(core.NewQByteArray2(lockContent, len(lockContent)))
+		zoomContent := editor.getSvg("zoom", nil)
+		t.zoomIcon.Load2(core.NewQByteArray2(zoomContent, len(zoomContent)))
 	} else {
 		inActiveStyle := fmt.Sprintf(`
 		.QWidget { 
 			border: 0px solid %s; 
 			background-color: rgba(0, 0, 0, 0); 
 		} QWidget{ color: %s; } `, accent, warpColor(inactiveFg, -30))
-		t.widget.SetStyleSheet(inActiveStyle)
+		t.widget.SetStyleSheet(withUserStyle(inActiveStyle))
 		svgContent := editor.getSvg("cross", inactiveFg)
 		t.closeIcon.Load2(core.NewQByteArray2(svgContent, len(svgContent)))
+		lockContent := editor.getSvg("lock", inactiveFg)
+		t.lockIcon.Load2(core.NewQByteArray2(lockContent, len(lockContent)))
+		zoomContent := editor.getSvg("zoom", inactiveFg)
+		t.zoomIcon.Load2(core.NewQByteArray2(zoomContent, len(zoomContent)))
 	}
 }
 
@@ -271,6 +316,12 @@ func (t *Tab) updateSize() {
 		-1,
 	))
 	height := int(fontmetrics.Height()) + t.t.marginTop + t.t.marginBottom
+	if t.readOnly && editor.config.ReadOnly.Badge {
+		width += editor.iconSize
+	}
+	if t.zoomed {
+		width += editor.iconSize
+	}
 	t.widget.SetFixedSize2(width+editor.iconSize+5+10+5, height)
 }
 
@@ -309,7 +360,7 @@ func (t *Tabline) update(args []interface{}) {
 			continue
 		}
 		if i > len(t.Tabs)-1 {
-			return
+			t.addTab()
 		}
 
 		tab := t.Tabs[i]
@@ -328,6 +379,7 @@ func (t *Tabline) update(args []interface{}) {
 		}
 
 		tab.setActive(tab.ID == t.CurrentID)
+		tab.setZoomed(t.zoomed[tab.ID])
 		if tab.ID == t.CurrentID {
 			t.currentFileText = text
 		}
@@ -338,6 +390,21 @@ func (t *Tabline) update(args []interface{}) {
 		tab.setActive(false)
 		tab.hide()
 	}
+	t.showCurrentTab()
+}
+
+// addTab adds a tab when the tabpages outnumber the tabs
+func (t *Tabline) addTab() {
+	tab := newTab()
+	tab.t = t
+	tab.hidden = true
+	tab.widget.Hide()
+	if t.font != nil {
+		tab.file.SetFont(t.font)
+	}
+	tab.file.SetContentsMargins(0, t.marginTop, 0, t.marginBottom)
+	t.layout.AddWidget(tab.widget)
+	t.Tabs = append(t.Tabs, tab)
 }
 
 func getFileType(text string) string {
@@ -358,13 +425,37 @@ func getFileType(text string) string {
 
 func (t *Tab) enterEvent(event *core.QEvent) {
 	t.closeIcon.Show()
+	if editor.config.Tabline.Preview {
+		if t.t.preview == nil {
+			t.t.preview = newTabPreview(t.t)
+		}
+		t.t.preview.hover(t)
+	}
 }
 
 func (t *Tab) leaveEvent(event *core.QEvent) {
 	t.closeIcon.Hide()
+	if t.t.preview != nil {
+		t.t.preview.hide()
+	}
 }
 
 func (t *Tab) pressEvent(event *gui.QMouseEvent) {
+	if t.t.preview != nil {
+		t.t.preview.hide()
+	}
+	if event.Button() == core.Qt__RightButton {
+		t.showContextMenu(event)
+		return
+	}
+	if event.Button() == core.Qt__LeftButton {
+		t.startDrag(event)
+	}
+	t.activate()
+}
+
+// activate switches to the tabpage of the tab
+func (t *Tab) activate() {
 	targetTab := nvim.Tabpage(t.ID)
 	go t.t.ws.nvim.SetCurrentTabpage(targetTab)
 }
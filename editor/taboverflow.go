@@ -0,0 +1,256 @@
+package editor
+
+import (
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/svg"
+	"github.com/therecipe/qt/widgets"
+)
+
+const (
+	// tabSpacing is the space between the tabs, and tabPadding is the space
+	// before the first tab and after the last one
+	tabSpacing = 16
+	tabPadding = 10
+	// tabPaddingTop is the space above the tabs
+	tabPaddingTop = 1
+)
+
+// tabScroll scrolls the tabs horizontally when they are wider than the
+// tabline, by the wheel and the scroll buttons, and lists all of them in the
+// dropdown. The tabs keep their widths instead of shrinking to fit.
+type tabScroll struct {
+	offset   int
+	content  int
+	viewport *widgets.QWidget
+	left     *svg.QSvgWidget
+	right    *svg.QSvgWidget
+	list     *svg.QSvgWidget
+	reserved *widgets.QWidget
+}
+
+// tabOffsets returns the x of the tabs of the widths, and the width of all
+// of them. The tabs of the width 0 are hidden, and take no space.
+func tabOffsets(widths []int, spacing, padding int) ([]int, int) {
+	lefts := make([]int, len(widths))
+	x := padding
+	last := 0
+	for i, width := range widths {
+		lefts[i] = x
+		if width > 0 {
+			last = x + width
+			x += width + spacing
+		}
+	}
+	if last == 0 {
+		return lefts, 0
+	}
+
+	return lefts, last + padding
+}
+
+// tabScrollClamp returns the offset within the range the content of the
+// width can be scrolled in the view
+func tabScrollClamp(offset, content, view int) int {
+	if offset > content-view {
+		offset = content - view
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	return offset
+}
+
+// tabScrollToShow returns the offset which shows the span from left to
+// right in the view, scrolling as little as it can
+func tabScrollToShow(offset, left, right, view int) int {
+	switch {
+	case left < offset:
+		return left
+	case right > offset+view:
+		return right - view
+	}
+
+	return offset
+}
+
+// newTabLayout returns the layout which lines up the tabs from the offset of
+// the scroll
+func newTabLayout(s *tabScroll) *widgets.QLayout {
+	layout := widgets.NewQLayout2()
+	items := []*widgets.QLayoutItem{}
+	layout.ConnectSizeHint(func() *core.QSize {
+		size := core.NewQSize()
+		for _, item := range items {
+			size = size.ExpandedTo(item.MinimumSize())
+		}
+		return size
+	})
+	layout.ConnectAddItem(func(item *widgets.QLayoutItem) {
+		items = append(items, item)
+	})
+	layout.ConnectSetGeometry(func(r *core.QRect) {
+		widths := make([]int, len(items))
+		heights := make([]int, len(items))
+		maxHeight := 0
+		for i, item := range items {
+			sizeHint := item.SizeHint()
+			widths[i] = sizeHint.Width()
+			heights[i] = sizeHint.Height()
+			if heights[i] > maxHeight {
+				maxHeight = heights[i]
+			}
+		}
+		lefts, content := tabOffsets(widths, tabSpacing, tabPadding)
+		s.content = content
+		s.offset = tabScrollClamp(s.offset, content, r.Width())
+		for i, item := range items {
+			y := (maxHeight-heights[i])/2 + tabPaddingTop
+			item.SetGeometry(core.NewQRect4(r.X()+lefts[i]-s.offset, r.Y()+y, widths[i], heights[i]))
+		}
+		s.updateButtons(r.Width())
+	})
+
+	return layout
+}
+
+func newTabScroll(t *Tabline) *tabScroll {
+	s := &tabScroll{}
+
+	viewport := widgets.NewQWidget(nil, 0)
+	viewport.ConnectWheelEvent(func(event *gui.QWheelEvent) {
+		delta := event.AngleDelta().Y()
+		if delta == 0 {
+			delta = event.AngleDelta().X()
+		}
+		t.scrollTabs(-delta / 2)
+	})
+	s.viewport = viewport
+
+	newButton := func(tooltip string) *svg.QSvgWidget {
+		button := svg.NewQSvgWidget(nil)
+		button.SetFixedSize2(editor.iconSize, editor.iconSize)
+		button.SetToolTip(tooltip)
+		button.Hide()
+		return button
+	}
+	s.left = newButton("Scroll tabs left")
+	s.left.ConnectMousePressEvent(func(*gui.QMouseEvent) {
+		t.scrollTabs(-s.viewport.Width() / 2)
+	})
+	s.right = newButton("Scroll tabs right")
+	s.right.ConnectMousePressEvent(func(*gui.QMouseEvent) {
+		t.scrollTabs(s.viewport.Width() / 2)
+	})
+	s.list = newButton("All tabs")
+	s.list.ConnectMousePressEvent(func(*gui.QMouseEvent) {
+		t.showTabList()
+	})
+
+	// reserved keeps the space of the navigation buttons over the tabline
+	s.reserved = widgets.NewQWidget(nil, 0)
+	s.reserved.SetFixedWidth(0)
+
+	return s
+}
+
+// updateButtons shows the buttons while the tabs are wider than the view
+func (s *tabScroll) updateButtons(view int) {
+	overflow := s.content > view
+	if s.left.IsVisible() == overflow {
+		return
+	}
+	s.left.SetVisible(overflow)
+	s.right.SetVisible(overflow)
+	s.list.SetVisible(overflow)
+}
+
+func (s *tabScroll) setColor() {
+	color := editor.colors.inactiveFg
+	for icon, button := range map[string]*svg.QSvgWidget{
+		"chevron-left":  s.left,
+		"chevron-right": s.right,
+		"chevron-down":  s.list,
+	} {
+		svgContent := editor.getSvg(icon, color)
+		button.Load2(core.NewQByteArray2(svgContent, len(svgContent)))
+	}
+}
+
+// reserve keeps the width on the right of the tabline for the widget over
+// it
+func (t *Tabline) reserve(width int) {
+	if t.scroll.reserved.Width() == width {
+		return
+	}
+	t.scroll.reserved.SetFixedWidth(width)
+}
+
+// scrollTabs scrolls the tabs by dx pixels; positive is right
+func (t *Tabline) scrollTabs(dx int) {
+	s := t.scroll
+	offset := tabScrollClamp(s.offset+dx, s.content, s.viewport.Width())
+	if offset == s.offset {
+		return
+	}
+	s.offset = offset
+	t.layout.Invalidate()
+	t.layout.Activate()
+}
+
+// showCurrentTab scrolls the tabs so that the tab of the current tabpage is
+// in the view
+func (t *Tabline) showCurrentTab() {
+	s := t.scroll
+	view := s.viewport.Width()
+	if view <= 0 {
+		return
+	}
+	var widths []int
+	current := -1
+	for i, tab := range t.Tabs {
+		if tab.hidden {
+			break
+		}
+		widths = append(widths, tab.widget.MinimumWidth())
+		if tab.ID == t.CurrentID {
+			current = i
+		}
+	}
+	if current < 0 {
+		return
+	}
+	lefts, content := tabOffsets(widths, tabSpacing, tabPadding)
+	offset := tabScrollToShow(s.offset, lefts[current], lefts[current]+widths[current], view)
+	offset = tabScrollClamp(offset, content, view)
+	if offset == s.offset {
+		return
+	}
+	s.offset = offset
+	t.layout.Invalidate()
+	t.layout.Activate()
+}
+
+// showTabList shows the dropdown of all the tabs, which switches to the
+// tabpage of the chosen one
+func (t *Tabline) showTabList() {
+	if t.preview != nil {
+		t.preview.hide()
+	}
+	list := t.scroll.list
+	menu := widgets.NewQMenu(list)
+	for _, tab := range t.Tabs {
+		if tab.hidden {
+			break
+		}
+		tab := tab
+		action := menu.AddAction(tab.file.Text())
+		action.SetCheckable(true)
+		action.SetChecked(tab.ID == t.CurrentID)
+		action.ConnectTriggered(func(bool) {
+			tab.activate()
+		})
+	}
+	menu.Popup(list.MapToGlobal(core.NewQPoint2(0, list.Height())), nil)
+}
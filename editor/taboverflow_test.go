@@ -0,0 +1,60 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTabOffsets(t *testing.T) {
+	tests := []struct {
+		widths  []int
+		lefts   []int
+		content int
+	}{
+		{[]int{100, 50}, []int{10, 126}, 186},
+		{[]int{100, 0, 50}, []int{10, 126, 126}, 186},
+		{[]int{100}, []int{10}, 120},
+		{[]int{0, 0}, []int{10, 10}, 0},
+		{[]int{}, []int{}, 0},
+	}
+	for _, tt := range tests {
+		lefts, content := tabOffsets(tt.widths, 16, 10)
+		if !reflect.DeepEqual(lefts, tt.lefts) || content != tt.content {
+			t.Errorf("tabOffsets(%v) = %v, %d, want %v, %d", tt.widths, lefts, content, tt.lefts, tt.content)
+		}
+	}
+}
+
+func TestTabScrollClamp(t *testing.T) {
+	tests := []struct {
+		offset, content, view int
+		want                  int
+	}{
+		{50, 500, 300, 50},
+		{-10, 500, 300, 0},
+		{300, 500, 300, 200},
+		{100, 200, 300, 0},
+	}
+	for _, tt := range tests {
+		if got := tabScrollClamp(tt.offset, tt.content, tt.view); got != tt.want {
+			t.Errorf("tabScrollClamp(%d, %d, %d) = %d, want %d", tt.offset, tt.content, tt.view, got, tt.want)
+		}
+	}
+}
+
+func TestTabScrollToShow(t *testing.T) {
+	tests := []struct {
+		offset, left, right, view int
+		want                      int
+	}{
+		{0, 10, 110, 300, 0},
+		{100, 10, 110, 300, 10},
+		{0, 250, 350, 300, 50},
+		{100, 150, 250, 300, 100},
+	}
+	for _, tt := range tests {
+		if got := tabScrollToShow(tt.offset, tt.left, tt.right, tt.view); got != tt.want {
+			t.Errorf("tabScrollToShow(%d, %d, %d, %d) = %d, want %d", tt.offset, tt.left, tt.right, tt.view, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package editor
+
+import (
+	"math"
+
+	"github.com/neovim/go-client/nvim"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+const (
+	// tabPreviewWidth is the width of the tab preview in pixels
+	tabPreviewWidth = 240
+	// tabPreviewDelay is the time in msec to hover a tab before the preview
+	tabPreviewDelay = 400
+)
+
+// tabPreviewLua returns the layout of the non-floating windows of the tabpage
+const tabPreviewLua = `
+local tab = ...
+local wins = {}
+for _, win in ipairs(vim.api.nvim_tabpage_list_wins(tab)) do
+  if vim.api.nvim_win_get_config(win).relative == "" then
+    local pos = vim.api.nvim_win_get_position(win)
+    local name = vim.api.nvim_buf_get_name(vim.api.nvim_win_get_buf(win))
+    table.insert(wins, {
+      id = win,
+      row = pos[1],
+      col = pos[2],
+      width = vim.api.nvim_win_get_width(win),
+      height = vim.api.nvim_win_get_height(win),
+      name = vim.fn.fnamemodify(name, ":t"),
+    })
+  end
+end
+return wins
+`
+
+// tabPreview is the thumbnail of the layout of a tabpage, which is shown on
+// hovering the tab. The windows are drawn from the cached grid content.
+type tabPreview struct {
+	t      *Tabline
+	widget *widgets.QWidget
+	timer  *core.QTimer
+	tab    *Tab
+	wins   []*tabPreviewWin
+}
+
+// tabPreviewWin is a window in the layout of the tabpage
+type tabPreviewWin struct {
+	ID     int    `msgpack:"id"`
+	Row    int    `msgpack:"row"`
+	Col    int    `msgpack:"col"`
+	Width  int    `msgpack:"width"`
+	Height int    `msgpack:"height"`
+	Name   string `msgpack:"name"`
+}
+
+func newTabPreview(t *Tabline) *tabPreview {
+	p := &tabPreview{
+		t: t,
+	}
+	widget := widgets.NewQWidget(nil, core.Qt__ToolTip)
+	widget.SetAttribute(core.Qt__WA_ShowWithoutActivating, true)
+	widget.ConnectPaintEvent(p.paint)
+	p.widget = widget
+
+	p.timer = core.NewQTimer(nil)
+	p.timer.SetSingleShot(true)
+	p.timer.ConnectTimeout(p.show)
+
+	return p
+}
+
+// hover shows the preview of the tab after the delay
+func (p *tabPreview) hover(tab *Tab) {
+	p.hide()
+	if tab.active {
+		return
+	}
+	p.tab = tab
+	p.timer.Start(tabPreviewDelay)
+}
+
+func (p *tabPreview) hide() {
+	p.timer.Stop()
+	p.tab = nil
+	p.widget.Hide()
+}
+
+func (p *tabPreview) show() {
+	tab := p.tab
+	if tab == nil || tab.hidden {
+		return
+	}
+	var wins []*tabPreviewWin
+	err := p.t.ws.nvim.ExecuteLua(tabPreviewLua, &wins, nvim.Tabpage(tab.ID))
+	if err != nil || len(wins) == 0 {
+		return
+	}
+	p.wins = wins
+
+	cols, rows := tabPreviewExtent(wins)
+	font := p.t.ws.font
+	cellHeight := float64(font.lineHeight) / font.truewidth
+	height := int(math.Ceil(float64(tabPreviewWidth) * float64(rows) * cellHeight / float64(cols)))
+	p.widget.SetFixedSize2(tabPreviewWidth, height)
+
+	pos := tab.widget.MapToGlobal(core.NewQPoint2(0, tab.widget.Height()))
+	p.widget.Move(pos)
+	p.widget.Show()
+	p.widget.Update()
+}
+
+func (p *tabPreview) paint(event *gui.QPaintEvent) {
+	painter := gui.NewQPainter2(p.widget)
+	defer painter.DestroyQPainter()
+
+	bg := p.t.ws.background
+	if bg == nil || len(p.wins) == 0 {
+		return
+	}
+	painter.FillRect4(
+		core.NewQRectF4(0, 0, float64(p.widget.Width()), float64(p.widget.Height())),
+		bg.QColor(),
+	)
+
+	cols, _ := tabPreviewExtent(p.wins)
+	cellWidth := float64(p.widget.Width()) / float64(cols)
+	font := p.t.ws.font
+	cellHeight := cellWidth * float64(font.lineHeight) / font.truewidth
+
+	titleFont := gui.NewQFont2(editor.extFontFamily, maxInt(editor.extFontSize-4, 6), 1, false)
+	painter.SetFont(titleFont)
+	for _, win := range p.wins {
+		rect := core.NewQRectF4(
+			float64(win.Col)*cellWidth,
+			float64(win.Row)*cellHeight,
+			float64(win.Width)*cellWidth,
+			float64(win.Height)*cellHeight,
+		)
+		p.drawContent(painter, win, cellWidth, cellHeight)
+		if editor.colors.windowSeparator != nil {
+			painter.SetPen2(editor.colors.windowSeparator.QColor())
+			painter.DrawRect(rect)
+		}
+		if editor.colors.fg != nil {
+			painter.SetPen2(editor.colors.fg.QColor())
+			painter.DrawText6(rect, win.Name, gui.NewQTextOption2(core.Qt__AlignHCenter|core.Qt__AlignBottom))
+		}
+	}
+}
+
+// drawContent draws the cached cells of the window by the glyph atlas of the
+// screen, scaled down to the cells of the preview
+func (p *tabPreview) drawContent(painter *gui.QPainter, win *tabPreviewWin, cellWidth, cellHeight float64) {
+	var cached *Window
+	p.t.ws.screen.windows.Range(func(_, winITF interface{}) bool {
+		w := winITF.(*Window)
+		if w != nil && int(w.id) == win.ID {
+			cached = w
+			return false
+		}
+		return true
+	})
+	// The window which has never been painted has no glyphs in the atlas
+	if cached == nil || cached.devicePixelRatio == 0 {
+		return
+	}
+	cached.paintMutex.Lock()
+	defer cached.paintMutex.Unlock()
+
+	font := cached.getFont()
+	atlas := p.t.ws.screen.atlas
+	painter.Save()
+	painter.SetRenderHint(gui.QPainter__SmoothPixmapTransform, true)
+	painter.Translate3(float64(win.Col)*cellWidth, float64(win.Row)*cellHeight)
+	painter.Scale(cellWidth/font.truewidth, cellHeight/float64(font.lineHeight))
+	for y, line := range cached.content {
+		if y >= win.Height {
+			break
+		}
+		for x, cell := range line {
+			if x >= win.Width {
+				break
+			}
+			if cell == nil || cell.char == " " || cell.char == "" {
+				continue
+			}
+			cached.drawGlyph(painter, atlas, cell, float64(x)*font.truewidth, float64(y*font.lineHeight), false)
+		}
+	}
+	painter.Restore()
+}
+
+// tabPreviewExtent returns the columns and the rows the windows cover
+func tabPreviewExtent(wins []*tabPreviewWin) (int, int) {
+	cols, rows := 1, 1
+	for _, win := range wins {
+		cols = maxInt(cols, win.Col+win.Width)
+		rows = maxInt(rows, win.Row+win.Height)
+	}
+
+	return cols, rows
+}
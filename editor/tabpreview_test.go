@@ -0,0 +1,22 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestTabPreviewExtent(t *testing.T) {
+	wins := []*tabPreviewWin{
+		{Row: 0, Col: 0, Width: 80, Height: 20},
+		{Row: 0, Col: 81, Width: 40, Height: 10},
+		{Row: 11, Col: 81, Width: 40, Height: 9},
+	}
+	cols, rows := tabPreviewExtent(wins)
+	if cols != 121 || rows != 20 {
+		t.Errorf("tabPreviewExtent() = %d, %d, want 121, 20", cols, rows)
+	}
+
+	cols, rows = tabPreviewExtent(nil)
+	if cols != 1 || rows != 1 {
+		t.Errorf("tabPreviewExtent(nil) = %d, %d, want 1, 1", cols, rows)
+	}
+}
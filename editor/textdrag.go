@@ -0,0 +1,228 @@
+package editor
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+// pasteFileMax is the size of the largest file pasted by pasteFile
+const pasteFileMax = 16 * 1024 * 1024
+
+// selectionLua returns the text of the visual selection
+const selectionLua = `
+local mode = vim.fn.mode()
+local s, e = vim.fn.getpos('v'), vim.fn.getpos('.')
+if vim.fn.exists('*getregion') == 1 then
+  return table.concat(vim.fn.getregion(s, e, {type = mode}), '\n')
+end
+if s[2] > e[2] or (s[2] == e[2] and s[3] > e[3]) then
+  s, e = e, s
+end
+local lines = vim.api.nvim_buf_get_lines(0, s[2] - 1, e[2], false)
+if mode == 'V' then
+  return table.concat(lines, '\n')
+end
+if mode == '\22' then
+  local left, right = math.min(s[3], e[3]), math.max(s[3], e[3])
+  for i, line in ipairs(lines) do
+    lines[i] = line:sub(left, right)
+  end
+  return table.concat(lines, '\n')
+end
+local last = lines[#lines]
+lines[#lines] = last:sub(1, e[3] - 1 + #vim.fn.matchstr(last, '.', e[3] - 1))
+lines[1] = lines[1]:sub(s[3])
+return table.concat(lines, '\n')
+`
+
+// textDrag is the state of the long-press on the visual selection. The press
+// is held back from nvim until it turns out to be a click or a drag to
+// select, or the selected text is dragged out of the editor.
+type textDrag struct {
+	timer     *core.QTimer
+	pos       *core.QPoint
+	modifiers core.Qt__KeyboardModifier
+	pending   bool
+	// fetching is set while the selected text is fetched for the drag, and
+	// the mouse events are held back meanwhile
+	fetching bool
+}
+
+// startTextDrag starts waiting for the long-press if the selection is pressed
+func (s *Screen) startTextDrag(event *gui.QMouseEvent) bool {
+	if !strings.HasPrefix(s.ws.mode, "visual") || event.Modifiers() != core.Qt__NoModifier {
+		return false
+	}
+	win := s.windowAt(event.Pos())
+	if win == nil {
+		return false
+	}
+	_, row, col := s.gridPos(win, event.Pos())
+	if !win.isSelected(row, col) {
+		return false
+	}
+
+	if s.textDrag == nil {
+		s.textDrag = &textDrag{
+			timer: core.NewQTimer(nil),
+		}
+		s.textDrag.timer.SetSingleShot(true)
+		s.textDrag.timer.ConnectTimeout(s.dragSelection)
+	}
+	s.textDrag.pos = core.NewQPoint2(event.Pos().X(), event.Pos().Y())
+	s.textDrag.modifiers = event.Modifiers()
+	s.textDrag.pending = true
+	s.textDrag.timer.Start(widgets.QApplication_StartDragTime())
+
+	return true
+}
+
+// holdTextDrag returns true while the press is held still on the selection.
+// Otherwise it sends the held press to nvim so the event is handled as usual.
+func (s *Screen) holdTextDrag(event *gui.QMouseEvent) bool {
+	d := s.textDrag
+	if d != nil && d.fetching {
+		return true
+	}
+	if d == nil || !d.pending {
+		return false
+	}
+	if event.Type() == core.QEvent__MouseMove {
+		moved := core.NewQPoint2(event.Pos().X()-d.pos.X(), event.Pos().Y()-d.pos.Y())
+		if moved.ManhattanLength() < widgets.QApplication_StartDragDistance() {
+			return true
+		}
+	}
+	d.pending = false
+	d.timer.Stop()
+	s.mouseEvent(gui.NewQMouseEvent(
+		core.QEvent__MouseButtonPress,
+		core.NewQPointF2(d.pos),
+		core.Qt__LeftButton,
+		core.Qt__LeftButton,
+		d.modifiers,
+	))
+
+	return false
+}
+
+// dragSelection fetches the selected text off the GUI thread, which the
+// gonvim_drag_selection update drags
+func (s *Screen) dragSelection() {
+	d := s.textDrag
+	if d == nil || !d.pending {
+		return
+	}
+	d.pending = false
+	d.fetching = true
+
+	neovim := s.ws.nvim
+	go func() {
+		var text string
+		err := neovim.ExecuteLua(selectionLua, &text)
+		if err != nil {
+			text = ""
+		}
+		s.ws.guiUpdates <- []interface{}{"gonvim_drag_selection", text}
+		s.ws.signal.GuiSignal()
+	}()
+}
+
+// startSelectionDrag drags the selected text out of the editor as
+// text/plain, if the button is still held
+func (s *Screen) startSelectionDrag(text string) {
+	d := s.textDrag
+	if d == nil || !d.fetching {
+		return
+	}
+	d.fetching = false
+	if text == "" || gui.QGuiApplication_MouseButtons()&core.Qt__LeftButton == 0 {
+		return
+	}
+	mime := core.NewQMimeData()
+	mime.SetText(text)
+	drag := gui.NewQDrag(s.widget)
+	drag.SetMimeData(mime)
+	drag.Exec(core.Qt__CopyAction)
+}
+
+// isSelected reports whether the cell is in the visual selection
+func (w *Window) isSelected(row, col int) bool {
+	cell, ok := w.cellAt(row, col)
+
+	return ok && cell.highlight.visual
+}
+
+// pasteFile inserts the contents of the file after the cursor. If the file is
+// not given, it is chosen in the file dialog. The file is read off the GUI
+// thread, and the large one is pasted in chunks by the paster.
+func (w *Workspace) pasteFile(file string) {
+	if file == "" {
+		file = widgets.QFileDialog_GetOpenFileName(
+			w.widget,
+			"Paste file",
+			w.cwd,
+			"",
+			"",
+			0,
+		)
+		if file == "" {
+			return
+		}
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(w.cwd, file)
+	}
+
+	go func() {
+		info, err := os.Stat(file)
+		if err == nil && info.Size() > pasteFileMax {
+			err = fmt.Errorf("%s is larger than %s", filepath.Base(file), formatFileSize(pasteFileMax))
+		}
+		var data []byte
+		if err == nil {
+			data, err = ioutil.ReadFile(file)
+		}
+		if err != nil {
+			editor.pushNotification(NotifyWarn, -1, fmt.Sprintf("[Goneovim] Failed to read the file: %s", err))
+			return
+		}
+		if bytes.IndexByte(data, 0) >= 0 {
+			editor.pushNotification(NotifyWarn, -1, fmt.Sprintf("[Goneovim] %s is not a text file", filepath.Base(file)))
+			return
+		}
+		w.guiUpdates <- []interface{}{"gonvim_paste_file_read", string(data)}
+		w.signal.GuiSignal()
+	}()
+}
+
+// putFile is called by the gonvim_paste_file_read update, and puts the contents
+// of the file, or pastes them in chunks if they are large
+func (w *Workspace) putFile(data string) {
+	text := strings.ReplaceAll(data, "\r\n", "\n")
+	if isLargePaste(text, editor.config.Editor.LargePasteLines) {
+		w.paster.paste(text)
+		return
+	}
+	lines, typ := fileLines([]byte(data))
+	go w.nvim.Put(lines, typ, true, true)
+}
+
+// fileLines splits the contents of a file into the lines for nvim_put. The
+// contents which end with a newline are put linewise, otherwise charwise.
+func fileLines(data []byte) ([]string, string) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if strings.HasSuffix(text, "\n") {
+		return strings.Split(strings.TrimSuffix(text, "\n"), "\n"), "l"
+	}
+
+	return strings.Split(text, "\n"), "c"
+}
@@ -0,0 +1,26 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFileLines(t *testing.T) {
+	tests := []struct {
+		data  string
+		lines []string
+		typ   string
+	}{
+		{"foo\nbar\n", []string{"foo", "bar"}, "l"},
+		{"foo\r\nbar\r\n", []string{"foo", "bar"}, "l"},
+		{"foo\nbar", []string{"foo", "bar"}, "c"},
+		{"foo", []string{"foo"}, "c"},
+		{"\n", []string{""}, "l"},
+	}
+	for _, tt := range tests {
+		lines, typ := fileLines([]byte(tt.data))
+		if !reflect.DeepEqual(lines, tt.lines) || typ != tt.typ {
+			t.Errorf("fileLines(%q) = %q, %q, want %q, %q", tt.data, lines, typ, tt.lines, tt.typ)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package editor
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+const (
+	themeAuto  = ""
+	themeLight = "light"
+	themeDark  = "dark"
+)
+
+// themeScheduler switches the colorscheme between light and dark
+// by the local sunrise/sunset or the fixed times.
+type themeScheduler struct {
+	mu       sync.Mutex
+	override string
+	current  string
+	actions  map[string]*widgets.QAction
+	timer    *core.QTimer
+}
+
+func newThemeScheduler() *themeScheduler {
+	return &themeScheduler{
+		actions: make(map[string]*widgets.QAction),
+	}
+}
+
+func (t *themeScheduler) isEnabled() bool {
+	c := editor.config.Theme
+	return c.Light != "" || c.Dark != ""
+}
+
+// start checks the schedule every minute. The timer runs on the GUI
+// thread, which update and applyTo need for the workspaces.
+func (t *themeScheduler) start() {
+	if !t.isEnabled() {
+		return
+	}
+	t.timer = core.NewQTimer(nil)
+	t.timer.ConnectTimeout(t.update)
+	t.timer.Start(int(time.Minute / time.Millisecond))
+}
+
+// mode returns the theme which should be applied at the time
+func (t *themeScheduler) mode(now time.Time) string {
+	t.mu.Lock()
+	override := t.override
+	t.mu.Unlock()
+	if override != themeAuto {
+		return override
+	}
+
+	return scheduledTheme(now, editor.config.Theme)
+}
+
+// update applies the theme to all workspaces if the mode has changed
+func (t *themeScheduler) update() {
+	mode := t.mode(time.Now())
+	t.mu.Lock()
+	if mode == t.current {
+		t.mu.Unlock()
+		return
+	}
+	t.current = mode
+	t.mu.Unlock()
+
+	for _, ws := range editor.workspaces {
+		t.applyTo(ws)
+	}
+}
+
+// applyTo applies the current theme to the workspace
+func (t *themeScheduler) applyTo(w *Workspace) {
+	if !t.isEnabled() || w.nvim == nil {
+		return
+	}
+	mode := t.mode(time.Now())
+	if mode == "" {
+		return
+	}
+	t.mu.Lock()
+	t.current = mode
+	t.mu.Unlock()
+
+	colorscheme := editor.config.Theme.Dark
+	if mode == themeLight {
+		colorscheme = editor.config.Theme.Light
+	}
+	command := fmt.Sprintf("set background=%s", mode)
+	if colorscheme != "" {
+		command += fmt.Sprintf(" | silent! colorscheme %s", colorscheme)
+	}
+	go w.nvim.Command(command)
+}
+
+func (t *themeScheduler) setOverride(mode string) {
+	t.mu.Lock()
+	t.override = mode
+	t.mu.Unlock()
+	for m, action := range t.actions {
+		action.SetChecked(m == mode)
+	}
+	t.update()
+}
+
+// toggle cycles the manual override: auto -> light -> dark -> auto
+func (t *themeScheduler) toggle() {
+	t.mu.Lock()
+	override := t.override
+	t.mu.Unlock()
+	switch override {
+	case themeAuto:
+		t.setOverride(themeLight)
+	case themeLight:
+		t.setOverride(themeDark)
+	default:
+		t.setOverride(themeAuto)
+	}
+}
+
+// initTrayMenu adds the manual override to the context menu of the systray
+func (t *themeScheduler) initTrayMenu() {
+	if !t.isEnabled() || editor.sysTray == nil {
+		return
+	}
+	menu := widgets.NewQMenu(nil)
+	group := widgets.NewQActionGroup(menu)
+	for _, item := range []struct {
+		mode  string
+		label string
+	}{
+		{themeAuto, "Theme: Auto"},
+		{themeLight, "Theme: Light"},
+		{themeDark, "Theme: Dark"},
+	} {
+		mode := item.mode
+		action := menu.AddAction(item.label)
+		action.SetCheckable(true)
+		action.SetChecked(mode == themeAuto)
+		action.SetActionGroup(group)
+		action.ConnectTriggered(func(bool) {
+			t.setOverride(mode)
+		})
+		t.actions[mode] = action
+	}
+	editor.sysTray.SetContextMenu(menu)
+}
+
+// scheduledTheme returns "light" or "dark" according to the schedule,
+// or "" if the schedule is not configured.
+func scheduledTheme(now time.Time, c themeConfig) string {
+	switch c.Schedule {
+	case "sun":
+		sunrise, sunset, ok := sunTimes(now, c.Latitude, c.Longitude)
+		if ok {
+			if !now.Before(sunrise) && now.Before(sunset) {
+				return themeLight
+			}
+			return themeDark
+		}
+		// Polar day or night; use the fixed times instead
+		fallthrough
+	case "time":
+		light, err := parseClock(c.LightTime)
+		if err != nil {
+			return ""
+		}
+		dark, err := parseClock(c.DarkTime)
+		if err != nil {
+			return ""
+		}
+		minutes := now.Hour()*60 + now.Minute()
+		isLight := minutes >= light && minutes < dark
+		if light > dark {
+			isLight = minutes >= light || minutes < dark
+		}
+		if isLight {
+			return themeLight
+		}
+		return themeDark
+	}
+
+	return ""
+}
+
+// parseClock parses "HH:MM" into minutes from midnight
+func parseClock(s string) (int, error) {
+	clock, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+
+	return clock.Hour()*60 + clock.Minute(), nil
+}
+
+// sunTimes returns the sunrise and sunset of the day of the date
+// at the location, by the sunrise equation.
+// ok is false during the polar day and the polar night.
+func sunTimes(date time.Time, latitude, longitude float64) (sunrise, sunset time.Time, ok bool) {
+	rad := math.Pi / 180
+	noon := time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, time.UTC)
+	julianDate := float64(noon.Unix())/86400 + 2440587.5
+
+	n := math.Round(julianDate - 2451545.0)
+	meanSolarTime := n - longitude/360
+	anomaly := math.Mod(357.5291+0.98560028*meanSolarTime, 360)
+	center := 1.9148*math.Sin(anomaly*rad) + 0.02*math.Sin(2*anomaly*rad) + 0.0003*math.Sin(3*anomaly*rad)
+	ecliptic := math.Mod(anomaly+center+180+102.9372, 360)
+	transit := 2451545.0 + meanSolarTime + 0.0053*math.Sin(anomaly*rad) - 0.0069*math.Sin(2*ecliptic*rad)
+	declination := math.Asin(math.Sin(ecliptic*rad) * math.Sin(23.44*rad))
+
+	cosHourAngle := (math.Sin(-0.83*rad) - math.Sin(latitude*rad)*math.Sin(declination)) /
+		(math.Cos(latitude*rad) * math.Cos(declination))
+	if cosHourAngle < -1 || cosHourAngle > 1 {
+		return time.Time{}, time.Time{}, false
+	}
+	hourAngle := math.Acos(cosHourAngle) / rad
+
+	toTime := func(julian float64) time.Time {
+		seconds := (julian - 2440587.5) * 86400
+		return time.Unix(int64(seconds), 0).In(date.Location())
+	}
+	sunrise = toTime(transit - hourAngle/360)
+	sunset = toTime(transit + hourAngle/360)
+
+	return sunrise, sunset, true
+}
@@ -0,0 +1,66 @@
+package editor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduledTheme_time(t *testing.T) {
+	c := themeConfig{
+		Schedule:  "time",
+		LightTime: "07:00",
+		DarkTime:  "19:00",
+	}
+	tests := []struct {
+		hour   int
+		minute int
+		want   string
+	}{
+		{6, 59, themeDark},
+		{7, 0, themeLight},
+		{18, 59, themeLight},
+		{19, 0, themeDark},
+	}
+	for _, tt := range tests {
+		now := time.Date(2020, 6, 21, tt.hour, tt.minute, 0, 0, time.UTC)
+		if got := scheduledTheme(now, c); got != tt.want {
+			t.Errorf("scheduledTheme(%02d:%02d) = %q, want %q", tt.hour, tt.minute, got, tt.want)
+		}
+	}
+
+	// Light theme at night
+	c.LightTime, c.DarkTime = "22:00", "06:00"
+	now := time.Date(2020, 6, 21, 23, 0, 0, 0, time.UTC)
+	if got := scheduledTheme(now, c); got != themeLight {
+		t.Errorf("scheduledTheme(23:00) = %q, want %q", got, themeLight)
+	}
+
+	c.Schedule = ""
+	if got := scheduledTheme(now, c); got != "" {
+		t.Errorf("scheduledTheme() without schedule = %q, want empty", got)
+	}
+}
+
+func TestSunTimes(t *testing.T) {
+	// Tokyo at the summer solstice: sunrise 04:25, sunset 19:00
+	jst := time.FixedZone("JST", 9*60*60)
+	date := time.Date(2020, 6, 21, 12, 0, 0, 0, jst)
+	sunrise, sunset, ok := sunTimes(date, 35.68, 139.76)
+	if !ok {
+		t.Fatal("sunTimes() returned no sunrise")
+	}
+	wantSunrise := time.Date(2020, 6, 21, 4, 25, 0, 0, jst)
+	wantSunset := time.Date(2020, 6, 21, 19, 0, 0, 0, jst)
+	if d := sunrise.Sub(wantSunrise); d < -5*time.Minute || d > 5*time.Minute {
+		t.Errorf("sunrise = %v, want about %v", sunrise, wantSunrise)
+	}
+	if d := sunset.Sub(wantSunset); d < -5*time.Minute || d > 5*time.Minute {
+		t.Errorf("sunset = %v, want about %v", sunset, wantSunset)
+	}
+
+	// Polar day
+	_, _, ok = sunTimes(time.Date(2020, 6, 21, 12, 0, 0, 0, time.UTC), 80, 0)
+	if ok {
+		t.Error("sunTimes() returned sunrise during the polar day")
+	}
+}
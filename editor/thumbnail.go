@@ -0,0 +1,301 @@
+package editor
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+const (
+	// thumbnailSize is the maximum width and height of the thumbnails
+	thumbnailSize = 256
+	// thumbnailCacheMax is the total size of the thumbnails cached on disk.
+	// The least recently used ones over it are removed on startup.
+	thumbnailCacheMax = 64 * 1024 * 1024
+)
+
+var (
+	thumbnailImageExts = []string{".png", ".jpg", ".jpeg", ".gif", ".bmp", ".webp", ".svg", ".ico", ".tga", ".tif", ".tiff"}
+	thumbnailMediaExts = []string{".mp4", ".mov", ".mkv", ".webm", ".avi", ".mp3", ".wav", ".ogg", ".flac", ".m4a"}
+)
+
+// thumbnailCache shows the thumbnail and the metadata of the image and media
+// files on hover in the file explorer and the palette. The thumbnails are
+// generated asynchronously and cached in ~/.goneovim/thumbnails.
+type thumbnailCache struct {
+	dir string
+
+	mu    sync.Mutex
+	infos map[string]*thumbnailInfo
+	// generating is the paths whose thumbnails are being generated
+	generating map[string]bool
+	pending    string
+	widget     *widgets.QWidget
+	rect       *core.QRect
+	ready      chan *thumbnailInfo
+}
+
+// thumbnailInfo is the thumbnail and the metadata of a file
+type thumbnailInfo struct {
+	path      string
+	thumbnail string
+	width     int
+	height    int
+	size      int64
+}
+
+func newThumbnailCache(home string) *thumbnailCache {
+	t := &thumbnailCache{
+		dir:        filepath.Join(home, ".goneovim", "thumbnails"),
+		infos:      make(map[string]*thumbnailInfo),
+		generating: make(map[string]bool),
+		ready:      make(chan *thumbnailInfo, 16),
+	}
+	editor.signal.ConnectThumbnailSignal(func() {
+		t.showReady(<-t.ready)
+	})
+	go t.prune()
+
+	return t
+}
+
+func hasExt(path string, exts []string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range exts {
+		if ext == e {
+			return true
+		}
+	}
+
+	return false
+}
+
+// show shows the thumbnail of the file beside the rect of the widget, if the
+// file is an image or a media file
+func (t *thumbnailCache) show(path string, widget *widgets.QWidget, rect *core.QRect) {
+	if editor.config.Editor.LowMemory {
+		return
+	}
+	if !hasExt(path, thumbnailImageExts) && !hasExt(path, thumbnailMediaExts) {
+		t.hide()
+		return
+	}
+
+	t.mu.Lock()
+	t.pending = path
+	t.widget = widget
+	t.rect = rect
+	info, ok := t.infos[path]
+	generating := t.generating[path]
+	if !ok && !generating {
+		t.generating[path] = true
+	}
+	t.mu.Unlock()
+	if ok {
+		t.showReady(info)
+		return
+	}
+	// The thumbnail being generated is shown when it is ready
+	if generating {
+		return
+	}
+
+	go func() {
+		info, err := t.generate(path)
+		t.mu.Lock()
+		delete(t.generating, path)
+		if err == nil {
+			t.infos[path] = info
+		}
+		t.mu.Unlock()
+		if err != nil {
+			return
+		}
+		t.ready <- info
+		editor.signal.ThumbnailSignal()
+	}()
+}
+
+// purge drops the thumbnails loaded in memory, which are read again from
+// the disk
+func (t *thumbnailCache) purge() {
+	t.mu.Lock()
+	t.infos = make(map[string]*thumbnailInfo)
+	t.mu.Unlock()
+}
+
+func (t *thumbnailCache) hide() {
+	t.mu.Lock()
+	t.pending = ""
+	t.widget = nil
+	t.rect = nil
+	t.mu.Unlock()
+	widgets.QToolTip_HideText()
+}
+
+// showReady shows the tooltip if the mouse is still on the file
+func (t *thumbnailCache) showReady(info *thumbnailInfo) {
+	t.mu.Lock()
+	widget := t.widget
+	rect := t.rect
+	current := t.pending == info.path
+	t.mu.Unlock()
+	if !current || widget == nil || rect == nil {
+		return
+	}
+	pos := widget.MapToGlobal(core.NewQPoint2(rect.Right(), rect.Top()))
+	widgets.QToolTip_ShowText(pos, info.html(), widget, rect, -1)
+}
+
+// generate reads the metadata of the file, and creates the thumbnail of the
+// image unless it is cached on disk
+func (t *thumbnailCache) generate(path string) (*thumbnailInfo, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	info := &thumbnailInfo{
+		path: path,
+		size: stat.Size(),
+	}
+	if !hasExt(path, thumbnailImageExts) {
+		return info, nil
+	}
+
+	// QImageReader reads only the header for the size
+	reader := gui.NewQImageReader3(path, core.NewQByteArray())
+	size := reader.Size()
+	info.width = size.Width()
+	info.height = size.Height()
+
+	cached := filepath.Join(t.dir, thumbnailKey(path, stat.ModTime().UnixNano(), stat.Size())+".png")
+	if isFileExist(cached) {
+		// The time of the cached thumbnail is the last use, which prune keeps
+		// the recent ones by
+		now := time.Now()
+		os.Chtimes(cached, now, now)
+		info.thumbnail = cached
+		return info, nil
+	}
+	if info.width > 0 && info.height > 0 {
+		w, h := thumbnailScale(info.width, info.height, thumbnailSize)
+		reader.SetScaledSize(core.NewQSize2(w, h))
+	}
+	image := reader.Read()
+	if image == nil || image.IsNull() {
+		return info, nil
+	}
+	os.MkdirAll(t.dir, 0755)
+	if image.Save(cached, "PNG", -1) {
+		info.thumbnail = cached
+	}
+
+	return info, nil
+}
+
+// prune removes the least recently used thumbnails on disk over
+// thumbnailCacheMax
+func (t *thumbnailCache) prune() {
+	entries, err := ioutil.ReadDir(t.dir)
+	if err != nil {
+		return
+	}
+	for _, name := range thumbnailsToPrune(entries, thumbnailCacheMax) {
+		os.Remove(filepath.Join(t.dir, name))
+	}
+}
+
+// thumbnailsToPrune returns the names of the files over the total size of
+// max, keeping the most recently modified ones
+func thumbnailsToPrune(files []os.FileInfo, max int64) []string {
+	sorted := make([]os.FileInfo, 0, len(files))
+	for _, f := range files {
+		if !f.IsDir() {
+			sorted = append(sorted, f)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ModTime().After(sorted[j].ModTime())
+	})
+
+	var names []string
+	var total int64
+	for _, f := range sorted {
+		total += f.Size()
+		if total > max {
+			names = append(names, f.Name())
+		}
+	}
+
+	return names
+}
+
+func (info *thumbnailInfo) html() string {
+	var b strings.Builder
+	if info.thumbnail != "" {
+		u := url.URL{Scheme: "file", Path: filepath.ToSlash(info.thumbnail)}
+		fmt.Fprintf(&b, "<img src=\"%s\"><br>", html.EscapeString(u.String()))
+	}
+	b.WriteString(html.EscapeString(filepath.Base(info.path)))
+	b.WriteString("<br>")
+	if info.width > 0 && info.height > 0 {
+		fmt.Fprintf(&b, "%d × %d, ", info.width, info.height)
+	}
+	b.WriteString(formatFileSize(info.size))
+
+	return b.String()
+}
+
+// thumbnailKey returns the name of the cached thumbnail, which changes when
+// the file is modified
+func thumbnailKey(path string, modTime int64, size int64) string {
+	return fmt.Sprintf("%x", sha1.Sum([]byte(fmt.Sprintf("%s\x00%d\x00%d", path, modTime, size))))
+}
+
+// thumbnailScale returns the size which fits in max, keeping the aspect ratio.
+// Smaller images are not scaled up.
+func thumbnailScale(width, height, max int) (int, int) {
+	if width <= max && height <= max {
+		return width, height
+	}
+	if width >= height {
+		return max, maxInt(1, height*max/width)
+	}
+
+	return maxInt(1, width*max/height), max
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+// formatFileSize returns the human readable file size
+func formatFileSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,91 @@
+package editor
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestThumbnailScale(t *testing.T) {
+	tests := []struct {
+		width, height int
+		wantW, wantH  int
+	}{
+		{100, 50, 100, 50},
+		{1024, 512, 256, 128},
+		{512, 1024, 128, 256},
+		{4000, 1, 256, 1},
+	}
+	for _, tt := range tests {
+		w, h := thumbnailScale(tt.width, tt.height, 256)
+		if w != tt.wantW || h != tt.wantH {
+			t.Errorf("thumbnailScale(%d, %d) = (%d, %d), want (%d, %d)", tt.width, tt.height, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestFormatFileSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KB"},
+		{1536, "1.5 KB"},
+		{5 * 1024 * 1024, "5.0 MB"},
+	}
+	for _, tt := range tests {
+		if got := formatFileSize(tt.size); got != tt.want {
+			t.Errorf("formatFileSize(%d) = %q, want %q", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestThumbnailKey(t *testing.T) {
+	key := thumbnailKey("/a/b.png", 1, 2)
+	if key != thumbnailKey("/a/b.png", 1, 2) {
+		t.Errorf("thumbnailKey() is not stable")
+	}
+	if key == thumbnailKey("/a/b.png", 3, 2) {
+		t.Errorf("thumbnailKey() doesn't change when the file is modified")
+	}
+}
+
+// thumbnailFile is the os.FileInfo of a cached thumbnail for the tests
+type thumbnailFile struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (f thumbnailFile) Name() string       { return f.name }
+func (f thumbnailFile) Size() int64        { return f.size }
+func (f thumbnailFile) Mode() os.FileMode  { return 0644 }
+func (f thumbnailFile) ModTime() time.Time { return f.modTime }
+func (f thumbnailFile) IsDir() bool        { return false }
+func (f thumbnailFile) Sys() interface{}   { return nil }
+
+func TestThumbnailsToPrune(t *testing.T) {
+	now := time.Now()
+	files := []os.FileInfo{
+		thumbnailFile{"old.png", 40, now.Add(-2 * time.Hour)},
+		thumbnailFile{"new.png", 40, now},
+		thumbnailFile{"mid.png", 40, now.Add(-time.Hour)},
+	}
+	tests := []struct {
+		max  int64
+		want []string
+	}{
+		{120, nil},
+		{100, []string{"old.png"}},
+		{40, []string{"mid.png", "old.png"}},
+		{0, []string{"new.png", "mid.png", "old.png"}},
+	}
+	for _, tt := range tests {
+		if got := thumbnailsToPrune(files, tt.max); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("thumbnailsToPrune(%d) = %v, want %v", tt.max, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package editor
+
+import (
+	"fmt"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+// TouchBar is the on-screen command bar for touch and pen devices
+type TouchBar struct {
+	ws      *Workspace
+	widget  *widgets.QWidget
+	buttons []*widgets.QPushButton
+	visible bool
+	height  int
+}
+
+// touchBarButton is a button of the touch bar which sends the keys to neovim
+type touchBarButton struct {
+	Label string
+	Keys  string
+}
+
+var defaultTouchBarButtons = []touchBarButton{
+	{Label: "Esc", Keys: "<Esc>"},
+	{Label: ":", Keys: ":"},
+	{Label: "←", Keys: "<Left>"},
+	{Label: "↓", Keys: "<Down>"},
+	{Label: "↑", Keys: "<Up>"},
+	{Label: "→", Keys: "<Right>"},
+}
+
+// isTouchScreenAvailable reports whether a touch screen is connected
+func isTouchScreenAvailable() bool {
+	for _, device := range gui.QTouchDevice_Devices() {
+		if device.Type() == gui.QTouchDevice__TouchScreen {
+			return true
+		}
+	}
+
+	return false
+}
+
+func initTouchBar() *TouchBar {
+	widget := widgets.NewQWidget(nil, 0)
+	widget.SetObjectName("touchbar")
+	widget.SetAttribute(core.Qt__WA_StyledBackground, true)
+	layout := widgets.NewQHBoxLayout()
+	layout.SetContentsMargins(4, 4, 4, 4)
+	layout.SetSpacing(4)
+	widget.SetLayout(layout)
+	widget.SetFixedHeight(editor.iconSize*2 + 8)
+
+	t := &TouchBar{
+		widget: widget,
+	}
+
+	buttons := append(defaultTouchBarButtons, editor.config.TouchBar.Buttons...)
+	for _, b := range buttons {
+		keys := b.Keys
+		button := widgets.NewQPushButton2(b.Label, nil)
+		// Do not steal the focus from the workspace
+		button.SetFocusPolicy(core.Qt__NoFocus)
+		button.SetSizePolicy2(widgets.QSizePolicy__Expanding, widgets.QSizePolicy__Expanding)
+		button.ConnectClicked(func(bool) {
+			t.input(keys)
+		})
+		layout.AddWidget(button, 1, 0)
+		t.buttons = append(t.buttons, button)
+	}
+
+	t.visible = editor.config.TouchBar.Force || (editor.config.TouchBar.Visible && isTouchScreenAvailable())
+	if t.visible {
+		widget.Show()
+	} else {
+		widget.Hide()
+	}
+
+	return t
+}
+
+func (t *TouchBar) input(keys string) {
+	if t.ws.nvim == nil {
+		return
+	}
+	go t.ws.nvim.Input(keys)
+}
+
+func (t *TouchBar) updateHeight() {
+	if t.visible {
+		t.height = t.widget.Height()
+	} else {
+		t.height = 0
+	}
+}
+
+func (t *TouchBar) setColor() {
+	if editor.colors.widgetBg == nil || editor.colors.fg == nil {
+		return
+	}
+	t.widget.SetStyleSheet(fmt.Sprintf(
+		`QWidget#touchbar { background-color: %s; }
+		QPushButton { color: %s; background-color: %s; border: 0px; border-radius: 4px; }
+		QPushButton:pressed { background-color: %s; }`,
+		editor.colors.bg.String(),
+		editor.colors.fg.String(),
+		editor.colors.widgetBg.String(),
+		editor.colors.selectedBg.String(),
+	))
+}
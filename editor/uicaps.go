@@ -0,0 +1,106 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/akiyosi/goneovim/util"
+)
+
+// uiCapability is an ext_* option of nvim_ui_attach, which is available if
+// nvim has the ui event of the option
+type uiCapability struct {
+	option  string
+	event   string
+	feature string
+}
+
+var uiCapabilities = []uiCapability{
+	{"ext_linegrid", "grid_line", "the grid rendering"},
+	{"ext_multigrid", "win_pos", "the per window features, e.g. the grid fonts, the smooth scroll and the float window borders"},
+	{"ext_hlstate", "hl_attr_define", "the highlight groups of the cells"},
+	{"ext_cmdline", "cmdline_show", "the external cmdline"},
+	{"ext_messages", "msg_show", "the external messages"},
+	{"ext_popupmenu", "popupmenu_show", "the external popupmenu"},
+	{"ext_tabline", "tabline_update", "the external tabline"},
+}
+
+// uiCapabilityWarning shows the disabled features once in the session, not
+// for every workspace
+var uiCapabilityWarning sync.Once
+
+// negotiateUIOptions returns the ui options of the wanted ext_* options which
+// nvim supports, and the capabilities which are wanted but not supported
+func negotiateUIOptions(events map[string]bool, wanted map[string]bool) (map[string]interface{}, []uiCapability) {
+	options := make(map[string]interface{})
+	var missing []uiCapability
+	for _, c := range uiCapabilities {
+		if !wanted[c.option] {
+			continue
+		}
+		if !events[c.event] {
+			missing = append(missing, c)
+			continue
+		}
+		options[c.option] = true
+	}
+
+	return options, missing
+}
+
+// uiEventsAndVersion returns the names of the ui events and the version of
+// nvim from the api info
+func uiEventsAndVersion(apiInfo []interface{}) (map[string]bool, string) {
+	events := make(map[string]bool)
+	version := ""
+	for _, item := range apiInfo {
+		info, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if v, ok := info["version"].(map[string]interface{}); ok {
+			version = fmt.Sprintf(
+				"%d.%d.%d",
+				util.ReflectToInt(v["major"]),
+				util.ReflectToInt(v["minor"]),
+				util.ReflectToInt(v["patch"]),
+			)
+		}
+		uiEvents, ok := info["ui_events"].([]interface{})
+		if !ok {
+			continue
+		}
+		for _, event := range uiEvents {
+			function, ok := event.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if name, ok := function["name"].(string); ok {
+				events[name] = true
+			}
+		}
+	}
+
+	return events, version
+}
+
+// capabilityWarning returns the message of the features disabled for the
+// missing capabilities
+func capabilityWarning(version string, missing []uiCapability) string {
+	if version == "" {
+		version = "of this version"
+	} else {
+		version = "v" + version
+	}
+	lines := make([]string, 0, len(missing))
+	for _, c := range missing {
+		lines = append(lines, fmt.Sprintf("%s (%s)", c.feature, c.option))
+	}
+
+	return fmt.Sprintf(
+		"[Goneovim] nvim %s doesn't support these UI capabilities, so the GUI features are disabled: %s. Update nvim to enable them.",
+		version,
+		strings.Join(lines, ", "),
+	)
+}
@@ -0,0 +1,56 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNegotiateUIOptions(t *testing.T) {
+	apiInfo := []interface{}{
+		int64(1),
+		map[string]interface{}{
+			"version": map[string]interface{}{"major": int64(0), "minor": int64(3), "patch": int64(8)},
+			"ui_events": []interface{}{
+				map[string]interface{}{"name": "grid_line"},
+				map[string]interface{}{"name": "hl_attr_define"},
+				map[string]interface{}{"name": "cmdline_show"},
+				map[string]interface{}{"name": "popupmenu_show"},
+				map[string]interface{}{"name": "tabline_update"},
+			},
+		},
+	}
+	events, version := uiEventsAndVersion(apiInfo)
+	if version != "0.3.8" {
+		t.Errorf("version = %q, want 0.3.8", version)
+	}
+
+	wanted := map[string]bool{
+		"ext_linegrid":  true,
+		"ext_multigrid": true,
+		"ext_hlstate":   true,
+		"ext_cmdline":   true,
+		"ext_messages":  true,
+		"ext_popupmenu": false,
+		"ext_tabline":   true,
+	}
+	options, missing := negotiateUIOptions(events, wanted)
+	for _, option := range []string{"ext_linegrid", "ext_hlstate", "ext_cmdline", "ext_tabline"} {
+		if options[option] != true {
+			t.Errorf("%s is not attached", option)
+		}
+	}
+	// Not wanted, or not supported
+	for _, option := range []string{"ext_popupmenu", "ext_multigrid", "ext_messages"} {
+		if _, ok := options[option]; ok {
+			t.Errorf("%s is attached", option)
+		}
+	}
+	if len(missing) != 2 || missing[0].option != "ext_multigrid" || missing[1].option != "ext_messages" {
+		t.Errorf("missing = %v, want ext_multigrid and ext_messages", missing)
+	}
+
+	message := capabilityWarning(version, missing)
+	if !strings.Contains(message, "v0.3.8") || !strings.Contains(message, "(ext_messages)") {
+		t.Errorf("capabilityWarning() = %q", message)
+	}
+}
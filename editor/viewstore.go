@@ -0,0 +1,210 @@
+package editor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/akiyosi/goneovim/util"
+)
+
+// bufferView is the viewport and cursor position of a buffer
+type bufferView struct {
+	TopLine    int `json:"topline"`
+	Line       int `json:"line"`
+	Col        int `json:"col"`
+	ScrollDust int `json:"scrolldust"`
+}
+
+// viewStoreSaveDelay is the delay to save the viewports after they change,
+// which writes the changes in the meantime at once
+const viewStoreSaveDelay = 2 * time.Second
+
+// viewStore persists the viewport of each file per project (working directory).
+// This is independent of the shada marks.
+type viewStore struct {
+	mu       sync.Mutex
+	path     string
+	loaded   bool
+	projects map[string]map[string]*bufferView
+	// dirty is set until the changes are saved by the timer or flush
+	dirty bool
+	timer *time.Timer
+}
+
+func newViewStore(home string) *viewStore {
+	return &viewStore{
+		path:     filepath.Join(home, ".goneovim", "viewports.json"),
+		projects: make(map[string]map[string]*bufferView),
+	}
+}
+
+func (v *viewStore) load() {
+	if v.loaded {
+		return
+	}
+	v.loaded = true
+	data, err := ioutil.ReadFile(v.path)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &v.projects)
+	if err != nil {
+		fmt.Println(err)
+	}
+	if v.projects == nil {
+		v.projects = make(map[string]map[string]*bufferView)
+	}
+}
+
+func (v *viewStore) save() {
+	data, err := json.Marshal(v.projects)
+	if err != nil {
+		return
+	}
+	err = os.MkdirAll(filepath.Dir(v.path), 0755)
+	if err != nil {
+		return
+	}
+	err = ioutil.WriteFile(v.path, data, 0644)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+func (v *viewStore) set(project, file string, view *bufferView) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.load()
+	views, ok := v.projects[project]
+	if !ok {
+		views = make(map[string]*bufferView)
+		v.projects[project] = views
+	}
+	views[file] = view
+	v.dirty = true
+	if v.timer == nil {
+		v.timer = time.AfterFunc(viewStoreSaveDelay, v.flush)
+	}
+}
+
+// flush saves the changes not saved yet, which is also called on quit
+func (v *viewStore) flush() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.timer != nil {
+		v.timer.Stop()
+		v.timer = nil
+	}
+	if !v.dirty {
+		return
+	}
+	v.dirty = false
+	v.save()
+}
+
+func (v *viewStore) get(project, file string) (*bufferView, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.load()
+	views, ok := v.projects[project]
+	if !ok {
+		return nil, false
+	}
+	view, ok := views[file]
+
+	return view, ok
+}
+
+// isRestoreViewport reports whether the viewport should be saved and restored.
+// The value of g:gonvim_restore_viewport (set in a project local vimrc)
+// overrides the setting of the config file.
+func isRestoreViewport(arg interface{}) bool {
+	switch util.ReflectToInt(arg) {
+	case 0:
+		return false
+	case 1:
+		return true
+	default:
+		return editor.config.Workspace.RestoreViewport
+	}
+}
+
+// saveViewport stores the viewport of the current window.
+// args: [file, cwd, topline, line, col, enabled]
+func (w *Workspace) saveViewport(args []interface{}) {
+	if len(args) < 6 {
+		return
+	}
+	if !isRestoreViewport(args[5]) {
+		return
+	}
+	file, _ := args[0].(string)
+	cwd, _ := args[1].(string)
+	if file == "" {
+		return
+	}
+
+	view := &bufferView{
+		TopLine: util.ReflectToInt(args[2]),
+		Line:    util.ReflectToInt(args[3]),
+		Col:     util.ReflectToInt(args[4]),
+	}
+	win, ok := w.screen.getWindow(w.cursor.gridid)
+	if ok {
+		view.ScrollDust = win.scrollDust[1]
+	}
+	go editor.viewStore.set(cwd, file, view)
+}
+
+// restoreViewport restores the viewport of the current window.
+// args: [file, cwd, enabled]
+func (w *Workspace) restoreViewport(args []interface{}) {
+	if len(args) < 3 {
+		return
+	}
+	if !isRestoreViewport(args[2]) {
+		return
+	}
+	file, _ := args[0].(string)
+	cwd, _ := args[1].(string)
+	view, ok := editor.viewStore.get(cwd, file)
+	if !ok {
+		return
+	}
+
+	// The displacement of the smooth scroll is restored after the view,
+	// which would reset it
+	neovim := w.nvim
+	go func() {
+		err := neovim.Command(fmt.Sprintf(
+			"call winrestview({'topline': %d, 'lnum': %d, 'col': %d})",
+			view.TopLine, view.Line, view.Col-1,
+		))
+		if err != nil {
+			return
+		}
+		w.guiUpdates <- []interface{}{"gonvim_viewport_restored", view.ScrollDust}
+		w.signal.GuiSignal()
+	}()
+}
+
+// applyRestoredDust restores the displacement of the smooth scroll of the
+// current window, which is called on each flush
+func (w *Workspace) applyRestoredDust() {
+	if w.restoredDust == nil {
+		return
+	}
+	dust := *w.restoredDust
+	w.restoredDust = nil
+	win, ok := w.screen.getWindow(w.cursor.gridid)
+	if !ok {
+		return
+	}
+	win.scrollDust[1] = dust
+	win.update()
+}
@@ -0,0 +1,153 @@
+package editor
+
+import (
+	"github.com/therecipe/qt/core"
+)
+
+const (
+	// warmupSettleDelay is the time in ms without the highlight changes
+	// after which the glyph cache is warmed up, so that the colorscheme and
+	// the first screen have settled
+	warmupSettleDelay = 500
+	// warmupInterval is the time in ms between the slices of the warm-up
+	warmupInterval = 4
+	// warmupBatch is the number of the glyphs cached in a slice
+	warmupBatch = 32
+)
+
+// glyphWarmup caches the glyphs of the ASCII set and the visible chars in
+// the highlights on the screen ahead of the painting, so that the first
+// scroll through a file doesn't stutter from the cold glyph cache. The glyphs
+// are painted into the atlas on the GUI thread, so the pass is run in small
+// slices between the events, and stops before it would evict any glyph.
+type glyphWarmup struct {
+	ws     *Workspace
+	settle *core.QTimer
+	step   *core.QTimer
+	queue  []warmupGlyph
+}
+
+type warmupGlyph struct {
+	win  *Window
+	cell *Cell
+}
+
+func newGlyphWarmup(ws *Workspace) *glyphWarmup {
+	if !editor.config.Editor.CachedDrawing {
+		return nil
+	}
+	g := &glyphWarmup{
+		ws: ws,
+	}
+	g.settle = core.NewQTimer(nil)
+	g.settle.SetSingleShot(true)
+	g.settle.ConnectTimeout(g.start)
+	g.step = core.NewQTimer(nil)
+	g.step.ConnectTimeout(g.run)
+
+	return g
+}
+
+// schedule restarts the warm-up after the highlights settle
+func (g *glyphWarmup) schedule() {
+	if g == nil {
+		return
+	}
+	g.step.Stop()
+	g.queue = nil
+	g.settle.Start(warmupSettleDelay)
+}
+
+func (g *glyphWarmup) start() {
+	g.queue = nil
+	g.ws.screen.windows.Range(func(_, winITF interface{}) bool {
+		win := winITF.(*Window)
+		if !win.isShown() || win.isMsgGrid {
+			return true
+		}
+		win.paintMutex.Lock()
+		cells := warmupCells(win.content, editor.config.Editor.BuiltinBoxDrawing)
+		win.paintMutex.Unlock()
+		for _, cell := range cells {
+			g.queue = append(g.queue, warmupGlyph{win: win, cell: cell})
+		}
+		return true
+	})
+	if len(g.queue) > 0 {
+		g.step.Start(warmupInterval)
+	}
+}
+
+func (g *glyphWarmup) run() {
+	atlas := g.ws.screen.atlas
+	for i := 0; i < warmupBatch && len(g.queue) > 0; i++ {
+		if !atlas.hasRoom() {
+			g.queue = nil
+			break
+		}
+		glyph := g.queue[0]
+		g.queue = g.queue[1:]
+		glyph.win.warmGlyph(atlas, glyph.cell)
+	}
+	if len(g.queue) == 0 {
+		g.step.Stop()
+	}
+}
+
+// warmupCells returns the copies of the distinct cells drawn from the atlas
+// in the content, followed by the printable ASCII chars in each highlight of
+// the content. The box chars are drawn without the atlas if boxDrawing.
+func warmupCells(content [][]*Cell, boxDrawing bool) []*Cell {
+	type glyph struct {
+		char        string
+		highlight   Highlight
+		normalWidth bool
+	}
+	seen := make(map[glyph]bool)
+	var cells []*Cell
+	add := func(cell Cell) {
+		g := glyph{cell.char, cell.highlight, cell.normalWidth}
+		if seen[g] {
+			return
+		}
+		seen[g] = true
+		cells = append(cells, &cell)
+	}
+	var highlights []Highlight
+	seenHighlights := make(map[Highlight]bool)
+	for _, line := range content {
+		for _, cell := range line {
+			if cell == nil || cell.char == "" || cell.char == " " {
+				continue
+			}
+			if _, ok := boxRune(cell.char); ok && boxDrawing {
+				continue
+			}
+			add(*cell)
+			if !seenHighlights[cell.highlight] {
+				seenHighlights[cell.highlight] = true
+				highlights = append(highlights, cell.highlight)
+			}
+		}
+	}
+	for _, hl := range highlights {
+		for c := '!'; c <= '~'; c++ {
+			add(Cell{normalWidth: true, char: string(c), highlight: hl})
+		}
+	}
+
+	return cells
+}
+
+// warmGlyph caches the glyph of the cell unless it is in the atlas. The
+// window must have been painted once, for the device pixel ratio.
+func (w *Window) warmGlyph(a *glyphAtlas, cell *Cell) {
+	if w.devicePixelRatio == 0 || a.dpr != w.devicePixelRatio {
+		return
+	}
+	key := newGlyphKey(w.glyphFont(), cell)
+	if _, ok := a.glyphs[key]; ok {
+		return
+	}
+	w.cacheGlyph(a, cell, key)
+}
@@ -0,0 +1,49 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestWarmupCells(t *testing.T) {
+	normal := Highlight{id: 1, foreground: newRGBA(200, 200, 200, 1)}
+	keyword := Highlight{id: 2, foreground: newRGBA(200, 100, 100, 1), bold: true}
+	cell := func(char string, hl Highlight) *Cell {
+		return &Cell{normalWidth: true, char: char, highlight: hl}
+	}
+	content := [][]*Cell{
+		{cell("i", keyword), cell("f", keyword), cell(" ", normal), cell("x", normal), nil},
+		{cell("x", normal), cell("│", normal), cell("あ", normal), {char: ""}},
+	}
+	content[1][2].normalWidth = false
+
+	cells := warmupCells(content, true)
+	asciiCount := '~' - '!' + 1
+	// The visible "i", "f", "x" and "あ", and the ASCII chars of the two
+	// highlights but the visible ones
+	want := 4 + int(asciiCount)*2 - 3
+	if len(cells) != want {
+		t.Fatalf("warmupCells() returned %d cells, want %d", len(cells), want)
+	}
+	for i, char := range []string{"i", "f", "x", "あ"} {
+		if cells[i].char != char {
+			t.Errorf("cells[%d] = %q, want the visible %q first", i, cells[i].char, char)
+		}
+	}
+	if cells[3].normalWidth {
+		t.Errorf("the wide char is warmed up in the normal width")
+	}
+	for _, c := range cells {
+		if c.char == "│" {
+			t.Errorf("the box char is warmed up with the builtin box drawing")
+		}
+	}
+	if got := len(warmupCells(content, false)); got != want+1 {
+		t.Errorf("warmupCells() without the builtin box drawing returned %d cells, want %d", got, want+1)
+	}
+
+	// The cells are copies, not the cells of the grid
+	cells[0].char = "z"
+	if content[0][0].char != "i" {
+		t.Errorf("the cell of the grid is changed")
+	}
+}
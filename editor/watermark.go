@@ -0,0 +1,169 @@
+package editor
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+)
+
+// watermarkAutoCmds notify whether the buffer of the window is empty, so
+// that the watermark is hidden as soon as the text is typed
+const watermarkAutoCmds = `
+	aug GonvimAuWatermark | au! | aug END
+	au GonvimAuWatermark BufWinEnter,WinEnter,TextChanged,TextChangedI * call rpcnotify(0, "Gui", "gonvim_watermark", win_getid(), &buftype ==# "" && line("$") == 1 && getline(1) ==# "", getwininfo(win_getid())[0].textoff)
+	`
+
+// watermarkImage is the logo of the watermark, loaded once
+var watermarkImage *gui.QImage
+
+// updateWatermark is called by the gonvim_watermark notification.
+// args: [winid, empty, textoff]
+func (w *Workspace) updateWatermark(args []interface{}) {
+	if len(args) < 3 {
+		return
+	}
+	id := util.ReflectToInt(args[0])
+	empty := util.ReflectToInt(args[1]) != 0
+	textoff := util.ReflectToInt(args[2])
+
+	w.screen.windows.Range(func(_, winITF interface{}) bool {
+		win := winITF.(*Window)
+		if win == nil || int(win.id) != id {
+			return true
+		}
+		if win.emptyBuffer != empty || win.watermarkTextoff != textoff {
+			win.emptyBuffer = empty
+			win.watermarkTextoff = textoff
+			win.update()
+		}
+		return false
+	})
+}
+
+// drawWatermark draws the logo, the text and the hints faintly in the middle
+// of the window of the empty buffer. It is drawn over the blank grid, and
+// not drawn once any text is on the grid, e.g. the intro message.
+func (w *Window) drawWatermark(p *gui.QPainter) {
+	if !editor.config.Watermark.Enable || !w.emptyBuffer {
+		return
+	}
+	if w.isMsgGrid || w.isFloatWin || w.grid == 1 {
+		return
+	}
+	if !isBlankGrid(w.content, w.watermarkTextoff) {
+		return
+	}
+
+	font := w.getFont()
+	left := float64(w.watermarkTextoff) * font.truewidth
+	width := float64(w.cols)*font.truewidth - left
+	height := float64(w.rows * font.lineHeight)
+	fg := editor.colors.fg
+	if fg == nil {
+		return
+	}
+
+	p.Save()
+	defer p.Restore()
+	p.SetOpacity(editor.config.Watermark.Opacity)
+	p.SetPen2(fg.QColor())
+
+	// The logo, the text and the hints are stacked in the middle
+	image := loadWatermarkImage()
+	var imageWidth, imageHeight int
+	if image != nil {
+		imageWidth, imageHeight = thumbnailScale(image.Width(), image.Height(), int(minFloat(width, height)/3))
+	}
+	text := watermarkText(editor.config.Watermark.Text, w.s.ws.cwd)
+	textFont := gui.NewQFont2(font.family, int(font.size*2), int(gui.QFont__Normal), false)
+	textHeight := 0.0
+	if text != "" {
+		textHeight = gui.NewQFontMetricsF(textFont).Height() * 1.5
+	}
+	hints := editor.config.Watermark.Hints
+	hintsHeight := float64(len(hints) * font.lineHeight)
+
+	top := (height - float64(imageHeight) - textHeight - hintsHeight) / 2
+	if image != nil {
+		p.DrawImage(
+			core.NewQRectF4(left+(width-float64(imageWidth))/2, top, float64(imageWidth), float64(imageHeight)),
+			image,
+			core.NewQRectF4(0, 0, float64(image.Width()), float64(image.Height())),
+			core.Qt__AutoColor,
+		)
+		top += float64(imageHeight)
+	}
+	if text != "" {
+		p.SetFont(textFont)
+		p.DrawText6(core.NewQRectF4(left, top, width, textHeight), text, gui.NewQTextOption2(core.Qt__AlignCenter))
+		top += textHeight
+	}
+	p.SetFont(font.fontNew)
+	for _, hint := range hints {
+		p.DrawText6(core.NewQRectF4(left, top, width, float64(font.lineHeight)), hint, gui.NewQTextOption2(core.Qt__AlignCenter))
+		top += float64(font.lineHeight)
+	}
+}
+
+func loadWatermarkImage() *gui.QImage {
+	path := editor.config.Watermark.Image
+	if path == "" {
+		return nil
+	}
+	if watermarkImage == nil {
+		if strings.HasPrefix(path, "~") {
+			path = filepath.Join(editor.homeDir, path[1:])
+		}
+		watermarkImage = gui.NewQImage9(path, "")
+	}
+	if watermarkImage.IsNull() {
+		return nil
+	}
+
+	return watermarkImage
+}
+
+// watermarkText returns the text with "{project}" replaced by the name of
+// the current directory
+func watermarkText(text, cwd string) string {
+	if !strings.Contains(text, "{project}") {
+		return text
+	}
+	project := ""
+	if cwd != "" {
+		project = filepath.Base(cwd)
+	}
+
+	return strings.TrimSpace(strings.ReplaceAll(text, "{project}", project))
+}
+
+// isBlankGrid returns true if no text is on the grid but the number column,
+// the sign column and the fold column, which are textoff wide, and the "~" of
+// the lines after the end of the buffer
+func isBlankGrid(content [][]*Cell, textoff int) bool {
+	for _, line := range content {
+		for col := textoff; col < len(line); col++ {
+			cell := line[col]
+			if cell == nil || cell.char == "" || cell.char == " " {
+				continue
+			}
+			if col == textoff && cell.char == "~" {
+				continue
+			}
+			return false
+		}
+	}
+
+	return true
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+
+	return b
+}
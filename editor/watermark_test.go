@@ -0,0 +1,50 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestWatermarkText(t *testing.T) {
+	tests := []struct {
+		text, cwd string
+		want      string
+	}{
+		{"{project}", "/home/user/goneovim", "goneovim"},
+		{"Welcome to {project}", "/home/user/goneovim", "Welcome to goneovim"},
+		{"{project}", "", ""},
+		{"goneovim", "/home/user/other", "goneovim"},
+	}
+	for _, tt := range tests {
+		if got := watermarkText(tt.text, tt.cwd); got != tt.want {
+			t.Errorf("watermarkText(%q, %q) = %q, want %q", tt.text, tt.cwd, got, tt.want)
+		}
+	}
+}
+
+func TestIsBlankGrid(t *testing.T) {
+	row := func(chars ...string) []*Cell {
+		line := make([]*Cell, len(chars))
+		for i, char := range chars {
+			line[i] = &Cell{normalWidth: true, char: char}
+		}
+		return line
+	}
+	tests := []struct {
+		content [][]*Cell
+		textoff int
+		want    bool
+	}{
+		{[][]*Cell{row(" ", " "), row("~", " "), {nil, nil}}, 0, true},
+		// The number column
+		{[][]*Cell{row(" ", "1", " ", " "), row("~", " ", " ", " ")}, 3, true},
+		{[][]*Cell{row(" ", "1", " ", "a")}, 3, false},
+		// The intro message
+		{[][]*Cell{row(" ", " "), row("~", "N")}, 0, false},
+		{nil, 0, true},
+	}
+	for i, tt := range tests {
+		if got := isBlankGrid(tt.content, tt.textoff); got != tt.want {
+			t.Errorf("isBlankGrid() of the case %d = %v, want %v", i, got, tt.want)
+		}
+	}
+}
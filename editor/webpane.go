@@ -0,0 +1,347 @@
+package editor
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/webengine"
+	"github.com/therecipe/qt/widgets"
+)
+
+// webPaneCommands open the file or the URL in the web pane of the id
+// "webpane"
+const webPaneCommands = `
+	command! -nargs=1 -complete=file GonvimWebPane call rpcnotify(0, "Gui", "gonvim_webpane", "open", {"id": "webpane", "url": <q-args>, "cwd": getcwd()})
+	`
+
+// webPaneOpts are the options of the gonvim_webpane notification
+type webPaneOpts struct {
+	id    string
+	title string
+	url   string
+	html  string
+	base  string
+	js    string
+	cwd   string
+	// dock is "right", "left", "top", "bottom", "float", or "window" which
+	// covers the nvim window of winid
+	dock  string
+	size  int
+	winid int
+}
+
+func parseWebPaneOpts(arg interface{}) (webPaneOpts, bool) {
+	opts := webPaneOpts{
+		dock: "right",
+		size: 40,
+	}
+	dict, ok := arg.(map[string]interface{})
+	if !ok {
+		return opts, false
+	}
+	opts.id, _ = dict["id"].(string)
+	opts.title, _ = dict["title"].(string)
+	opts.url, _ = dict["url"].(string)
+	opts.html, _ = dict["html"].(string)
+	opts.base, _ = dict["base"].(string)
+	opts.js, _ = dict["js"].(string)
+	opts.cwd, _ = dict["cwd"].(string)
+	if dock, ok := dict["dock"].(string); ok {
+		switch dock {
+		case "right", "left", "top", "bottom", "float", "window":
+			opts.dock = dock
+		}
+	}
+	if size, ok := dict["size"]; ok {
+		opts.size = util.ReflectToInt(size)
+	}
+	if opts.size < 10 {
+		opts.size = 10
+	}
+	if opts.size > 100 {
+		opts.size = 100
+	}
+	if winid, ok := dict["winid"]; ok {
+		opts.winid = util.ReflectToInt(winid)
+	}
+	if opts.title == "" {
+		opts.title = opts.id
+	}
+
+	return opts, opts.id != ""
+}
+
+// webPaneRect returns the geometry, [x, y, width, height], of the pane
+// docked on the edge of the screen or floating in its center, whose size is
+// in percent of the screen
+func webPaneRect(dock string, size, width, height int) [4]int {
+	w := width * size / 100
+	h := height * size / 100
+	switch dock {
+	case "left":
+		return [4]int{0, 0, w, height}
+	case "top":
+		return [4]int{0, 0, width, h}
+	case "bottom":
+		return [4]int{0, height - h, width, h}
+	case "float":
+		return [4]int{(width - w) / 2, (height - h) / 2, w, h}
+	}
+
+	return [4]int{width - w, 0, w, height}
+}
+
+// webPanes are the web view panes which the plugins open to show the rich
+// UI, e.g. the coverage reports, the API docs and the dashboards, by the
+// gonvim_webpane notification:
+//
+//	vim.rpcnotify(0, "Gui", "gonvim_webpane", "open", {
+//	  id = "coverage", url = "coverage/index.html", dock = "right", size = 40,
+//	})
+//
+// "open" opens the pane of the id at the url, or with the html whose
+// relative links are resolved from base, docked on the edge of the screen,
+// floating, or over the nvim window of winid with dock = "window". "update"
+// changes the content of the pane, "eval" runs the js in it, and "close"
+// closes it. The GonvimWebPaneClosed User autocmd is emitted when the pane
+// is closed by its close button or with its window.
+type webPanes struct {
+	ws    *Workspace
+	panes map[string]*webPane
+}
+
+// webPane is a pane of webPanes, which shows its content by the web engine
+// as the markdown preview does. The keys typed in it are sent to nvim.
+type webPane struct {
+	ws      *Workspace
+	opts    webPaneOpts
+	widget  *widgets.QWidget
+	header  *widgets.QWidget
+	title   *widgets.QLabel
+	webview *webengine.QWebEngineView
+	webpage *webengine.QWebEnginePage
+}
+
+func newWebPanes(ws *Workspace) *webPanes {
+	return &webPanes{
+		ws:    ws,
+		panes: make(map[string]*webPane),
+	}
+}
+
+// handle is called by the gonvim_webpane notification, [op, opts]
+func (p *webPanes) handle(args []interface{}) {
+	if len(args) < 2 {
+		return
+	}
+	op, _ := args[0].(string)
+	opts, ok := parseWebPaneOpts(args[1])
+	if !ok {
+		editor.pushNotification(NotifyWarn, -1, "[Goneovim] gonvim_webpane needs the id of the pane")
+		return
+	}
+	pane := p.panes[opts.id]
+
+	switch op {
+	case "open":
+		if pane == nil {
+			pane = newWebPane(p.ws)
+			p.panes[opts.id] = pane
+		}
+		pane.opts = opts
+		pane.title.SetText(opts.title)
+		pane.load(opts)
+		p.place()
+	case "update":
+		if pane != nil {
+			pane.load(opts)
+		}
+	case "eval":
+		if pane != nil && opts.js != "" {
+			pane.webpage.RunJavaScript(opts.js)
+		}
+	case "close":
+		p.close(opts.id, false)
+	}
+}
+
+// place places the panes, which is called on each flush so that the panes
+// over the windows follow the layout
+func (p *webPanes) place() {
+	for id, pane := range p.panes {
+		if !pane.place() {
+			p.close(id, true)
+		}
+	}
+}
+
+// close closes the pane, and emits GonvimWebPaneClosed if the pane is not
+// closed by the plugin
+func (p *webPanes) close(id string, emit bool) {
+	pane := p.panes[id]
+	if pane == nil {
+		return
+	}
+	delete(p.panes, id)
+	pane.widget.Hide()
+	pane.widget.DeleteLater()
+	if emit {
+		p.ws.emitGuiEvent(guiEventWebPaneClosed, map[string]interface{}{
+			"id": id,
+		})
+	}
+}
+
+func (p *webPanes) setColor() {
+	for _, pane := range p.panes {
+		pane.setColor()
+	}
+}
+
+func newWebPane(ws *Workspace) *webPane {
+	pane := &webPane{
+		ws: ws,
+	}
+
+	widget := widgets.NewQWidget(ws.screen.widget, 0)
+	widget.SetObjectName("webpane")
+	widget.SetAttribute(core.Qt__WA_StyledBackground, true)
+	layout := widgets.NewQVBoxLayout()
+	layout.SetContentsMargins(0, 0, 0, 0)
+	layout.SetSpacing(0)
+	widget.SetLayout(layout)
+
+	header := widgets.NewQWidget(nil, 0)
+	headerLayout := widgets.NewQHBoxLayout()
+	headerLayout.SetContentsMargins(8, 2, 4, 2)
+	header.SetLayout(headerLayout)
+	title := widgets.NewQLabel(nil, 0)
+	closeButton := widgets.NewQPushButton2("×", nil)
+	closeButton.ConnectClicked(func(bool) {
+		ws.webPanes.close(pane.opts.id, true)
+	})
+	headerLayout.AddWidget(title, 1, 0)
+	headerLayout.AddWidget(closeButton, 0, 0)
+	editor.focusChain.add(closeButton.QWidget_PTR(), focusRankPanel, closeButton.Click)
+
+	webview := webengine.NewQWebEngineView(nil)
+	if runtime.GOOS == "windows" {
+		webview.SetAttribute(core.Qt__WA_NativeWindow, true)
+	}
+	webpage := webengine.NewQWebEnginePage(nil)
+	webview.SetPage(webpage)
+	// The keys typed in the pane are sent to nvim, as in the markdown preview
+	webview.ConnectEventFilter(func(watched *core.QObject, event *core.QEvent) bool {
+		if event.Type() == core.QEvent__KeyPress {
+			editor.keyPress(gui.NewQKeyEventFromPointer(event.Pointer()))
+			return true
+		}
+		return webview.EventFilterDefault(watched, event)
+	})
+	webview.ConnectEvent(func(event *core.QEvent) bool {
+		if event.Type() == core.QEvent__ChildAdded {
+			core.NewQChildEventFromPointer(event.Pointer()).Child().InstallEventFilter(webview)
+		}
+		return webview.EventDefault(event)
+	})
+
+	layout.AddWidget(header, 0, 0)
+	layout.AddWidget(webview, 1, 0)
+	widget.Hide()
+
+	pane.widget = widget
+	pane.header = header
+	pane.title = title
+	pane.webview = webview
+	pane.webpage = webpage
+	pane.setColor()
+
+	return pane
+}
+
+// load shows the html, or the url if no html is given. The relative paths
+// are the files in the cwd of nvim.
+func (pane *webPane) load(opts webPaneOpts) {
+	cwd := opts.cwd
+	if cwd == "" {
+		cwd = pane.ws.cwd
+	}
+	switch {
+	case opts.html != "":
+		base := core.NewQUrl()
+		if opts.base != "" {
+			base = core.QUrl_FromUserInput2(opts.base, cwd, core.QUrl__DefaultResolution)
+		}
+		pane.webpage.SetHtml(opts.html, base)
+	case opts.url != "":
+		pane.webpage.Load(core.QUrl_FromUserInput2(opts.url, cwd, core.QUrl__DefaultResolution))
+	}
+	if opts.js != "" {
+		pane.webpage.RunJavaScript(opts.js)
+	}
+}
+
+// place places the pane by its dock, and returns false if the window the
+// pane is over is closed
+func (pane *webPane) place() bool {
+	screen := pane.ws.screen.widget
+	rect := webPaneRect(pane.opts.dock, pane.opts.size, screen.Width(), screen.Height())
+	if pane.opts.dock == "window" {
+		win, ok := pane.window()
+		if !ok {
+			return false
+		}
+		if !win.isShown() {
+			pane.widget.Hide()
+			return true
+		}
+		font := pane.ws.screen.font
+		rect = [4]int{
+			int(float64(win.pos[0]) * font.truewidth),
+			win.pos[1] * font.lineHeight,
+			int(float64(win.cols) * win.getFont().truewidth),
+			win.rows * win.getFont().lineHeight,
+		}
+	}
+	pane.header.SetVisible(pane.opts.dock != "window")
+	pane.widget.SetGeometry2(rect[0], rect[1], rect[2], rect[3])
+	if !pane.widget.IsVisible() {
+		pane.widget.Show()
+		pane.widget.Raise()
+	}
+
+	return true
+}
+
+// window returns the window of the winid of the pane
+func (pane *webPane) window() (*Window, bool) {
+	var found *Window
+	pane.ws.screen.windows.Range(func(_, winITF interface{}) bool {
+		win := winITF.(*Window)
+		if win != nil && int(win.id) == pane.opts.winid && !win.isMsgGrid {
+			found = win
+			return false
+		}
+		return true
+	})
+
+	return found, found != nil
+}
+
+func (pane *webPane) setColor() {
+	fg := editor.colors.widgetFg
+	bg := editor.colors.widgetBg
+	if fg == nil || bg == nil || editor.colors.inactiveFg == nil {
+		return
+	}
+	pane.widget.SetStyleSheet(withUserStyle(fmt.Sprintf(
+		"QWidget#webpane { border: 1px solid %s; background-color: %s; } QLabel, QPushButton { color: %s; background-color: %s; }",
+		editor.colors.inactiveFg.String(),
+		bg.String(),
+		fg.String(),
+		bg.String(),
+	)))
+}
@@ -0,0 +1,65 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestParseWebPaneOpts(t *testing.T) {
+	tests := []struct {
+		arg  interface{}
+		want webPaneOpts
+		ok   bool
+	}{
+		{
+			map[string]interface{}{"id": "cov", "url": "coverage/index.html", "cwd": "/src/app"},
+			webPaneOpts{id: "cov", title: "cov", url: "coverage/index.html", cwd: "/src/app", dock: "right", size: 40},
+			true,
+		},
+		{
+			map[string]interface{}{"id": "docs", "title": "API", "html": "<p>x</p>", "dock": "window", "winid": int64(1000), "size": int64(200)},
+			webPaneOpts{id: "docs", title: "API", html: "<p>x</p>", dock: "window", size: 100, winid: 1000},
+			true,
+		},
+		{
+			map[string]interface{}{"id": "d", "dock": "middle", "size": int64(1)},
+			webPaneOpts{id: "d", title: "d", dock: "right", size: 10},
+			true,
+		},
+		{
+			map[string]interface{}{"url": "x"},
+			webPaneOpts{url: "x", dock: "right", size: 40},
+			false,
+		},
+		{
+			"broken",
+			webPaneOpts{dock: "right", size: 40},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		got, ok := parseWebPaneOpts(tt.arg)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseWebPaneOpts(%v) = %+v, %v, want %+v, %v", tt.arg, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestWebPaneRect(t *testing.T) {
+	tests := []struct {
+		dock string
+		size int
+		want [4]int
+	}{
+		{"right", 40, [4]int{600, 0, 400, 500}},
+		{"left", 40, [4]int{0, 0, 400, 500}},
+		{"top", 20, [4]int{0, 0, 1000, 100}},
+		{"bottom", 20, [4]int{0, 400, 1000, 100}},
+		{"float", 50, [4]int{250, 125, 500, 250}},
+		{"unknown", 100, [4]int{0, 0, 1000, 500}},
+	}
+	for _, tt := range tests {
+		if got := webPaneRect(tt.dock, tt.size, 1000, 500); got != tt.want {
+			t.Errorf("webPaneRect(%q, %d) = %v, want %v", tt.dock, tt.size, got, tt.want)
+		}
+	}
+}
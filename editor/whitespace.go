@@ -0,0 +1,149 @@
+package editor
+
+import (
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+)
+
+// The whitespace is matched in the windows by nvim, which draws the cells
+// of the trailing whitespace and of the mixed indentation in their own
+// highlight groups. The groups only set the special color, so that they
+// have their own attributes, and the cells of the grid are marked at paint
+// time from the groups combined in their highlights.
+const (
+	whitespaceTrail = "GonvimWhitespaceTrail"
+	whitespaceMixed = "GonvimWhitespaceMixed"
+)
+
+// whitespaceCommands match the trailing whitespace, but the one at the
+// cursor being typed in the insert mode, and the indentation which mixes
+// the tabs and the spaces: a space is before a tab, or the spaces after the
+// tabs are as wide as a tab. The fewer spaces after the tabs align the
+// text, and are not mixed. The windows of the special buffers and of
+// g:gonvim_whitespace_disabled are not matched.
+const whitespaceCommands = `
+	function! GonvimWhitespaceHighlight() abort
+		execute "hi default ` + whitespaceTrail + ` guisp=" . g:gonvim_whitespace_color
+		execute "hi default ` + whitespaceMixed + ` guisp=" . g:gonvim_whitespace_color
+	endfunction
+	function! GonvimWhitespaceMatch() abort
+		for l:id in get(w:, "gonvim_whitespace", [])
+			silent! call matchdelete(l:id)
+		endfor
+		let w:gonvim_whitespace = []
+		if &buftype !=# "" || !&modifiable || index(g:gonvim_whitespace_disabled, &filetype) >= 0
+			return
+		endif
+		if g:gonvim_whitespace_trailing
+			call add(w:gonvim_whitespace, matchadd("` + whitespaceTrail + `", "\\s\\+\\%#\\@<!$", -1))
+		endif
+		if g:gonvim_whitespace_mixed
+			call add(w:gonvim_whitespace, matchadd("` + whitespaceMixed + `", "^\\(\\s* \\t\\|\\t\\+ \\{" . &tabstop . ",}\\)\\s*", -1))
+		endif
+	endfunction
+	call GonvimWhitespaceHighlight()
+	call map(nvim_list_wins(), {_, wid -> win_execute(wid, "call GonvimWhitespaceMatch()")})
+	aug GonvimAuWhitespace | au! | aug END
+	au GonvimAuWhitespace BufWinEnter,WinEnter,FileType * call GonvimWhitespaceMatch()
+	au GonvimAuWhitespace OptionSet tabstop,modifiable,buftype call GonvimWhitespaceMatch()
+	au GonvimAuWhitespace ColorScheme * call GonvimWhitespaceHighlight()
+	`
+
+// setWhitespace sets the variables of the config for whitespaceCommands
+func (w *Workspace) setWhitespace() {
+	config := editor.config.Whitespace
+	disabled := config.DisabledFiletypes
+	if disabled == nil {
+		disabled = []string{}
+	}
+	w.nvim.SetVar("gonvim_whitespace_color", config.Color)
+	w.nvim.SetVar("gonvim_whitespace_trailing", config.Trailing)
+	w.nvim.SetVar("gonvim_whitespace_mixed", config.MixedIndent)
+	w.nvim.SetVar("gonvim_whitespace_disabled", disabled)
+}
+
+// whitespaceKind returns the whitespace group of the info of the
+// hl_attr_define event, or "" if the highlight isn't of the whitespace
+func whitespaceKind(info []interface{}) string {
+	for _, i := range info {
+		state, ok := i.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := state["hi_name"].(string)
+		switch name {
+		case whitespaceTrail, whitespaceMixed:
+			return name
+		}
+	}
+
+	return ""
+}
+
+// whitespaceCells returns the runs [first, last] of the cells of the
+// trailing whitespace in the line, and the first column of the mixed
+// indentation, or -1 if the line has none
+func whitespaceCells(line []*Cell) ([][2]int, int) {
+	var trail [][2]int
+	mixed := -1
+	for x, cell := range line {
+		if cell == nil {
+			continue
+		}
+		switch cell.highlight.whitespace {
+		case whitespaceTrail:
+			if n := len(trail); n > 0 && trail[n-1][1] == x-1 {
+				trail[n-1][1] = x
+			} else {
+				trail = append(trail, [2]int{x, x})
+			}
+		case whitespaceMixed:
+			if mixed < 0 {
+				mixed = x
+			}
+		}
+	}
+
+	return trail, mixed
+}
+
+// drawWhitespace tints the trailing whitespace in the row, and draws the dot
+// of the mixed indentation in the gutter before it
+func (w *Window) drawWhitespace(p *gui.QPainter, y int) {
+	if w.isMsgGrid || w.isFloatWin || y >= len(w.content) {
+		return
+	}
+	trail, mixed := whitespaceCells(w.content[y])
+	if len(trail) == 0 && mixed < 0 {
+		return
+	}
+	font := w.getFont()
+	color := hexToRGBA(editor.config.Whitespace.Color)
+	top := float64(y*font.lineHeight) + float64(w.scrollDust[1])
+
+	for _, run := range trail {
+		p.FillRect4(
+			core.NewQRectF4(
+				float64(run[0])*font.truewidth,
+				top,
+				float64(run[1]-run[0]+1)*font.truewidth,
+				float64(font.lineHeight),
+			),
+			gui.NewQColor3(color.R, color.G, color.B, 48),
+		)
+	}
+
+	if mixed >= 0 {
+		r := float64(font.lineHeight) / 8
+		x := r + 1
+		if mixed > 0 {
+			x = (float64(mixed) - 0.5) * font.truewidth
+		}
+		p.Save()
+		p.SetRenderHint(gui.QPainter__Antialiasing, true)
+		p.SetPen2(color.QColor())
+		p.SetBrush(gui.NewQBrush3(color.QColor(), core.Qt__SolidPattern))
+		p.DrawEllipse4(core.NewQPointF3(x, top+float64(font.lineHeight)/2), r, r)
+		p.Restore()
+	}
+}
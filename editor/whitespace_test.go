@@ -0,0 +1,54 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWhitespaceKind(t *testing.T) {
+	tests := []struct {
+		info []interface{}
+		want string
+	}{
+		{nil, ""},
+		{[]interface{}{map[string]interface{}{"hi_name": "Normal"}}, ""},
+		{[]interface{}{map[string]interface{}{"hi_name": "CursorLine"}, map[string]interface{}{"hi_name": whitespaceTrail}}, whitespaceTrail},
+		{[]interface{}{map[string]interface{}{"hi_name": whitespaceMixed}}, whitespaceMixed},
+		{[]interface{}{"broken"}, ""},
+	}
+	for _, tt := range tests {
+		if got := whitespaceKind(tt.info); got != tt.want {
+			t.Errorf("whitespaceKind(%v) = %q, want %q", tt.info, got, tt.want)
+		}
+	}
+}
+
+func TestWhitespaceCells(t *testing.T) {
+	cells := func(kinds ...string) []*Cell {
+		line := make([]*Cell, len(kinds))
+		for i, kind := range kinds {
+			if kind == "nil" {
+				continue
+			}
+			line[i] = &Cell{char: " ", highlight: Highlight{whitespace: kind}}
+		}
+		return line
+	}
+	tests := []struct {
+		line  []*Cell
+		trail [][2]int
+		mixed int
+	}{
+		{cells("", "", ""), nil, -1},
+		{cells("", whitespaceTrail, whitespaceTrail), [][2]int{{1, 2}}, -1},
+		{cells(whitespaceTrail, "", whitespaceTrail), [][2]int{{0, 0}, {2, 2}}, -1},
+		{cells("", "", whitespaceMixed, whitespaceMixed, ""), nil, 2},
+		{cells(whitespaceMixed, "nil", whitespaceTrail), [][2]int{{2, 2}}, 0},
+	}
+	for _, tt := range tests {
+		trail, mixed := whitespaceCells(tt.line)
+		if !reflect.DeepEqual(trail, tt.trail) || mixed != tt.mixed {
+			t.Errorf("whitespaceCells() = %v, %d, want %v, %d", trail, mixed, tt.trail, tt.mixed)
+		}
+	}
+}
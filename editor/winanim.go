@@ -0,0 +1,230 @@
+package editor
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+// winAnimEasing is the easing of the animations of the window layout
+const winAnimEasing = "outCubic"
+
+var (
+	reducedMotionOnce sync.Once
+	reducedMotionSet  bool
+)
+
+// reducedMotion returns true if the system asks the applications to reduce
+// the motion, which is read once
+func reducedMotion() bool {
+	reducedMotionOnce.Do(func() {
+		var cmd *exec.Cmd
+		switch runtime.GOOS {
+		case "darwin":
+			cmd = exec.Command("defaults", "read", "com.apple.universalaccess", "reduceMotion")
+		case "windows":
+			cmd = exec.Command("reg", "query", `HKCU\Control Panel\Desktop\WindowMetrics`, "/v", "MinAnimate")
+		default:
+			cmd = exec.Command("gsettings", "get", "org.gnome.desktop.interface", "enable-animations")
+		}
+		out, err := cmd.Output()
+		if err != nil {
+			return
+		}
+		reducedMotionSet = isReducedMotion(runtime.GOOS, string(out))
+	})
+
+	return reducedMotionSet
+}
+
+// isReducedMotion returns true if the output of the command reading the
+// setting of the system asks to reduce the motion
+func isReducedMotion(goos, out string) bool {
+	out = strings.TrimSpace(out)
+	switch goos {
+	case "darwin":
+		return out == "1"
+	case "windows":
+		fields := strings.Fields(out)
+		return len(fields) > 0 && fields[len(fields)-1] == "0"
+	default:
+		return out == "false"
+	}
+}
+
+// winAnimFrom returns the geometry, [x, y, width, height], from which the
+// window animates to the target, and false if it doesn't animate. The moved
+// window slides from where it is shown, and it grows to the target size but
+// shrinks at once, since its content already has the target size. The new
+// split grows from its edge along the split, and the new window spanning the
+// width and the height of the screen is shown at once.
+func winAnimFrom(shown, target [4]int, isNew, spansWidth, spansHeight bool) ([4]int, bool) {
+	from := target
+	switch {
+	case !isNew:
+		from = [4]int{
+			shown[0],
+			shown[1],
+			minInt(shown[2], target[2]),
+			minInt(shown[3], target[3]),
+		}
+	case !spansWidth:
+		from[2] = 0
+	case !spansHeight:
+		from[3] = 0
+	}
+
+	return from, from != target
+}
+
+// winAnimLerp returns the geometry at the progress p from the geometry to
+// the target
+func winAnimLerp(from, target [4]int, p float64) [4]int {
+	var rect [4]int
+	for i := range rect {
+		rect[i] = from[i] + int(float64(target[i]-from[i])*p+0.5)
+	}
+
+	return rect
+}
+
+// winAnim animates the geometry of the window when the layout changes, so
+// that the new split slides open and the moved windows slide to their places
+// instead of jumping there
+type winAnim struct {
+	w *Window
+	// frame is the subscription to the frame clock
+	frame  int
+	start  time.Time
+	from   [4]int
+	target [4]int
+	// shown is the geometry the window is shown at, and known is false
+	// until the window is placed by win_pos
+	shown [4]int
+	known bool
+}
+
+// windowAnimation returns true if the layout changes are animated
+func windowAnimation() bool {
+	return editor.config.WindowAnimation.Enable && editor.config.WindowAnimation.Duration > 0 && !reducedMotion()
+}
+
+// animateGeometry is called after win_pos places the window, and animates
+// the window from where it was shown to the new place
+func (w *Window) animateGeometry() {
+	if w.isFloatWin || w.isMsgGrid {
+		return
+	}
+	if w.geomAnim == nil {
+		w.geomAnim = &winAnim{w: w}
+	}
+	a := w.geomAnim
+	target := [4]int{w.widget.X(), w.widget.Y(), w.widget.Width(), w.widget.Height()}
+	isNew := !a.known
+	shown := a.shown
+	a.known = true
+	a.shown = target
+	if !windowAnimation() || !w.s.ws.uiAttached {
+		a.stop()
+		return
+	}
+	// The split has the columns of the screen but the separator, and the
+	// rows but the statusline and the cmdline
+	cols, rows := w.s.ws.cols, w.s.ws.rows
+	from, ok := winAnimFrom(shown, target, isNew, w.cols >= cols-1, w.rows >= rows*3/4)
+	if !ok {
+		if a.frame == 0 {
+			return
+		}
+		// The running animation heads to the new place
+		from = shown
+	}
+	a.from = from
+	a.target = target
+	a.start = time.Now()
+	a.apply(from)
+	if a.frame == 0 {
+		a.frame = editor.frameClock.subscribe(a.tick)
+	}
+}
+
+// progress returns the eased progress of the animation in [0, 1]
+func (a *winAnim) progress() float64 {
+	duration := float64(editor.config.WindowAnimation.Duration)
+	t := float64(time.Since(a.start)/time.Millisecond) / duration
+
+	return scrollEasing(winAnimEasing, t)
+}
+
+func (a *winAnim) tick() {
+	p := a.progress()
+	if p >= 1 {
+		a.apply(a.target)
+		a.stop()
+		return
+	}
+	a.apply(winAnimLerp(a.from, a.target, p))
+}
+
+func (a *winAnim) apply(rect [4]int) {
+	a.shown = rect
+	a.w.widget.SetGeometry2(rect[0], rect[1], rect[2], rect[3])
+}
+
+func (a *winAnim) stop() {
+	if a.frame == 0 {
+		return
+	}
+	editor.frameClock.unsubscribe(a.frame)
+	a.frame = 0
+	a.shown = a.target
+	a.w.widget.SetGeometry2(a.target[0], a.target[1], a.target[2], a.target[3])
+}
+
+// collapse draws the window closed collapsing to its left or top edge, by
+// its snapshot over the screen
+func (w *Window) collapse() {
+	if w.isFloatWin || w.isMsgGrid || !w.isShown() {
+		return
+	}
+	if !windowAnimation() {
+		return
+	}
+	rect := [4]int{w.widget.X(), w.widget.Y(), w.widget.Width(), w.widget.Height()}
+	if rect[2] <= 0 || rect[3] <= 0 {
+		return
+	}
+	target := rect
+	if w.cols < w.s.ws.cols-1 {
+		target[2] = 0
+	} else {
+		target[3] = 0
+	}
+
+	snapshot := widgets.NewQLabel(w.s.widget, 0)
+	snapshot.SetAttribute(core.Qt__WA_TransparentForMouseEvents, true)
+	snapshot.SetAlignment(core.Qt__AlignLeft | core.Qt__AlignTop)
+	snapshot.SetPixmap(w.widget.Grab(w.widget.Rect()))
+	snapshot.SetGeometry2(rect[0], rect[1], rect[2], rect[3])
+	snapshot.Show()
+	snapshot.Raise()
+
+	start := time.Now()
+	duration := float64(editor.config.WindowAnimation.Duration)
+	var frame int
+	frame = editor.frameClock.subscribe(func() {
+		t := float64(time.Since(start)/time.Millisecond) / duration
+		if t >= 1 {
+			editor.frameClock.unsubscribe(frame)
+			snapshot.DeleteLater()
+			return
+		}
+		r := winAnimLerp(rect, target, scrollEasing(winAnimEasing, t))
+		snapshot.SetGeometry2(r[0], r[1], r[2], r[3])
+	})
+}
@@ -0,0 +1,68 @@
+package editor
+
+import "testing"
+
+func TestWinAnimFrom(t *testing.T) {
+	target := [4]int{100, 0, 300, 400}
+	tests := []struct {
+		name        string
+		shown       [4]int
+		isNew       bool
+		spansWidth  bool
+		spansHeight bool
+		want        [4]int
+		ok          bool
+	}{
+		{"moved", [4]int{0, 0, 300, 400}, false, false, true, [4]int{0, 0, 300, 400}, true},
+		{"grown", [4]int{100, 0, 200, 400}, false, false, true, [4]int{100, 0, 200, 400}, true},
+		{"shrunk", [4]int{100, 0, 500, 400}, false, false, true, target, false},
+		{"unchanged", target, false, false, true, target, false},
+		{"vsplit", [4]int{}, true, false, true, [4]int{100, 0, 0, 400}, true},
+		{"split", [4]int{}, true, true, false, [4]int{100, 0, 300, 0}, true},
+		{"whole", [4]int{}, true, true, true, target, false},
+	}
+	for _, tt := range tests {
+		got, ok := winAnimFrom(tt.shown, target, tt.isNew, tt.spansWidth, tt.spansHeight)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: winAnimFrom() = %v, %v, want %v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestWinAnimLerp(t *testing.T) {
+	from := [4]int{0, 0, 0, 400}
+	target := [4]int{100, 0, 300, 400}
+	tests := []struct {
+		p    float64
+		want [4]int
+	}{
+		{0, from},
+		{0.5, [4]int{50, 0, 150, 400}},
+		{1, target},
+	}
+	for _, tt := range tests {
+		if got := winAnimLerp(from, target, tt.p); got != tt.want {
+			t.Errorf("winAnimLerp(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestIsReducedMotion(t *testing.T) {
+	tests := []struct {
+		goos string
+		out  string
+		want bool
+	}{
+		{"darwin", "1\n", true},
+		{"darwin", "0\n", false},
+		{"linux", "false\n", true},
+		{"linux", "true\n", false},
+		{"windows", "\r\nHKEY_CURRENT_USER\\Control Panel\\Desktop\\WindowMetrics\r\n    MinAnimate    REG_SZ    0\r\n", true},
+		{"windows", "    MinAnimate    REG_SZ    1\r\n", false},
+	}
+	for _, tt := range tests {
+		if got := isReducedMotion(tt.goos, tt.out); got != tt.want {
+			t.Errorf("isReducedMotion(%q, %q) = %v, want %v", tt.goos, tt.out, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package editor
+
+import (
+	"strings"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/neovim/go-client/nvim"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+)
+
+// winhighlight is the highlight groups which replace Normal and NormalNC
+// in the window by 'winhighlight', and their background colors
+type winhighlight struct {
+	normal     string
+	normalNC   string
+	normalBg   *RGBA
+	normalNCBg *RGBA
+	current    bool
+}
+
+// updateWinhighlight is called by the gonvim_winhl notification.
+// args: [winid, &winhighlight, current]
+func (s *Screen) updateWinhighlight(args []interface{}) {
+	if len(args) < 3 {
+		return
+	}
+	id := util.ReflectToInt(args[0])
+	winhl, _ := args[1].(string)
+	current := util.ReflectToInt(args[2]) != 0
+
+	groups := parseWinhighlight(winhl)
+	s.windows.Range(func(_, winITF interface{}) bool {
+		win := winITF.(*Window)
+		if win == nil || int(win.id) != id {
+			return true
+		}
+		hl := &winhighlight{
+			normal:   groups["Normal"],
+			normalNC: groups["NormalNC"],
+			current:  current,
+		}
+		// Entering and leaving the window keep the resolved colors
+		old := win.winhl
+		if old != nil && old.normal == hl.normal && old.normalNC == hl.normalNC {
+			hl.normalBg, hl.normalNCBg = old.normalBg, old.normalNCBg
+			win.winhl = hl
+			win.applyBackground()
+			return false
+		}
+		win.winhl = hl
+		s.resolveWinhighlight(win, hl)
+		return false
+	})
+}
+
+// refreshWinhighlight resolves the background colors again after the
+// colorscheme is changed
+func (s *Screen) refreshWinhighlight() {
+	s.windows.Range(func(_, winITF interface{}) bool {
+		win := winITF.(*Window)
+		if win == nil || win.winhl == nil {
+			return true
+		}
+		s.resolveWinhighlight(win, win.winhl)
+		return true
+	})
+}
+
+// resolveWinhighlight gets the background colors of the groups off the GUI
+// thread, which the gonvim_winhl_resolved update applies to the window
+func (s *Screen) resolveWinhighlight(win *Window, hl *winhighlight) {
+	neovim := s.ws.nvim
+	normal, normalNC := hl.normal, hl.normalNC
+	go func() {
+		normalBg := groupBackground(neovim, normal)
+		normalNCBg := groupBackground(neovim, normalNC)
+		s.ws.guiUpdates <- []interface{}{"gonvim_winhl_resolved", win, hl, normalBg, normalNCBg}
+		s.ws.signal.GuiSignal()
+	}()
+}
+
+// winhighlightResolved is called by the gonvim_winhl_resolved update, and
+// applies the background colors unless 'winhighlight' of the window has
+// changed since
+func (s *Screen) winhighlightResolved(args []interface{}) {
+	if len(args) < 4 {
+		return
+	}
+	win, _ := args[0].(*Window)
+	hl, _ := args[1].(*winhighlight)
+	if win == nil || hl == nil || win.winhl != hl {
+		return
+	}
+	hl.normalBg, _ = args[2].(*RGBA)
+	hl.normalNCBg, _ = args[3].(*RGBA)
+	win.applyBackground()
+}
+
+// groupBackground returns the background color of the highlight group,
+// or nil if the group is empty or has no background
+func groupBackground(neovim *nvim.Nvim, group string) *RGBA {
+	if group == "" {
+		return nil
+	}
+	attrs := make(map[string]interface{})
+	err := neovim.Request("nvim_get_hl_by_name", &attrs, group, true)
+	if err != nil {
+		return nil
+	}
+	bg, ok := attrs["background"]
+	if !ok {
+		return nil
+	}
+
+	return calcColor(util.ReflectToInt(bg))
+}
+
+// normalBackground returns the background color of Normal in the window,
+// which is replaced by 'winhighlight'
+func (w *Window) normalBackground() *RGBA {
+	if w.winhl == nil {
+		return w.s.ws.background
+	}
+	if !w.winhl.current && w.winhl.normalNCBg != nil {
+		return w.winhl.normalNCBg
+	}
+	if w.winhl.normalBg != nil {
+		return w.winhl.normalBg
+	}
+
+	return w.s.ws.background
+}
+
+// applyBackground fills the widget with the background color of the window
+func (w *Window) applyBackground() {
+	bg := w.normalBackground()
+	if bg == nil || bg.equals(w.background) {
+		return
+	}
+	w.background = bg.copy()
+	w.fill()
+	w.update()
+}
+
+// fillWinhlBackground fills the row with the background color replaced by
+// 'winhighlight'. The widget background isn't filled when the window is
+// transparent, so the color is painted with the same transparency as the
+// other cells instead.
+func (w *Window) fillWinhlBackground(p *gui.QPainter, y int) {
+	if !editor.config.Editor.DrawBorder || w.winhl == nil {
+		return
+	}
+	if w.isMsgGrid || w.isFloatWin || w.grid == 1 {
+		return
+	}
+	bg := w.normalBackground()
+	if bg == nil || bg.equals(w.s.ws.background) {
+		return
+	}
+	font := w.getFont()
+	p.FillRect4(
+		core.NewQRectF4(
+			0,
+			float64(y*font.lineHeight)+float64(w.scrollDust[1]),
+			float64(w.cols)*font.truewidth,
+			float64(font.lineHeight),
+		),
+		gui.NewQColor3(bg.R, bg.G, bg.B, int(transparent()*255.0)),
+	)
+}
+
+// parseWinhighlight returns the map of the highlight groups of the
+// 'winhighlight' option value, e.g. "Normal:Float,NormalNC:FloatNC"
+func parseWinhighlight(winhl string) map[string]string {
+	groups := make(map[string]string)
+	for _, item := range strings.Split(winhl, ",") {
+		parts := strings.SplitN(strings.TrimSpace(item), ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			continue
+		}
+		groups[parts[0]] = parts[1]
+	}
+
+	return groups
+}
@@ -0,0 +1,23 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseWinhighlight(t *testing.T) {
+	tests := []struct {
+		winhl string
+		want  map[string]string
+	}{
+		{"", map[string]string{}},
+		{"Normal:NvimTreeNormal", map[string]string{"Normal": "NvimTreeNormal"}},
+		{"Normal:Float, NormalNC:FloatNC,EndOfBuffer:", map[string]string{"Normal": "Float", "NormalNC": "FloatNC"}},
+		{"Normal", map[string]string{}},
+	}
+	for _, tt := range tests {
+		if got := parseWinhighlight(tt.winhl); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseWinhighlight(%q) = %v, want %v", tt.winhl, got, tt.want)
+		}
+	}
+}
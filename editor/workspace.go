@@ -3,11 +3,13 @@ package editor
 import (
 	"errors"
 	"fmt"
+	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/akiyosi/goneovim/filer"
@@ -31,6 +33,8 @@ type workspaceSignal struct {
 	_ func() `signal:"locpopupSignal"`
 	_ func() `signal:"lintSignal"`
 	_ func() `signal:"gitSignal"`
+	_ func() `signal:"wordcountSignal"`
+	_ func() `signal:"outputSignal"`
 	_ func() `signal:"messageSignal"`
 }
 
@@ -40,8 +44,14 @@ type Workspace struct {
 	font       *Font
 	fontwide   *Font
 	cursor     *Cursor
+	inputQueue *inputQueue
+	hunkPopup  *hunkPopup
+	resources  *resourceMonitor
+	paster     *paster
 	tabline    *Tabline
 	statusline *Statusline
+	touchBar   *TouchBar
+	navigation *Navigation
 	screen     *Screen
 	scrollBar  *ScrollBar
 	markdown   *Markdown
@@ -54,11 +64,42 @@ type Workspace struct {
 	signature  *Signature
 	message    *Message
 	minimap    *MiniMap
+	dictation  *Dictation
+	hlEditor   *HighlightEditor
+	peek       *Peek
+	cheatsheet *Cheatsheet
+	output     *OutputPanel
+	follow     *followMode
+	fileLoad   *fileLoad
+	warmup     *glyphWarmup
+	connection *connectionMonitor
+	localEcho  *localEcho
+	helpReader *helpReader
+	reading    *readingMode
+	msgHistory *msgHistory
+	webPanes   *webPanes
+	index      *searchIndex
 
 	width  int
 	height int
 	hidden bool
 
+	// guifont and linespace are the values set in nvim, which the display
+	// profile may override
+	guifont   string
+	linespace int
+	// letterspace is the space in pixels added to the cell width
+	letterspace float64
+	startup     *startupProgress
+	lineFit     *lineFit
+	// channel is the channel of the UI in nvim, and tabpage is the tabpage
+	// of the OS window, which is shown again when the window is activated
+	channel int
+	tabpage nvim.Tabpage
+	// restoredDust is the displacement of the smooth scroll of the restored
+	// viewport, which is applied by the flush of its winrestview
+	restoredDust *int
+
 	nvim               *nvim.Nvim
 	rows               int
 	cols               int
@@ -81,6 +122,8 @@ type Workspace struct {
 	curPosMutex        sync.RWMutex
 	cursorStyleEnabled bool
 	modeInfo           []map[string]interface{}
+	ambiwidth          string
+	rpcCount           uint64
 	normalMappings     []*nvim.Mapping
 	insertMappings     []*nvim.Mapping
 	ts                 int
@@ -90,23 +133,34 @@ type Workspace struct {
 	isMappingScrollKey bool
 
 	signal        *workspaceSignal
-	redrawUpdates chan [][]interface{}
+	redrawUpdates chan []redrawEvent
+	redrawBatches chan *redrawBatch
 	guiUpdates    chan []interface{}
 	doneNvimStart chan bool
 	stopOnce      sync.Once
 	stop          chan struct{}
 	fontMutex     sync.Mutex
+	// flushTimer repaints the flush delayed by the frame rate limit
+	flushTimer *core.QTimer
+	lastFlush  time.Time
 
 	drawStatusline bool
 	drawTabline    bool
 	drawLint       bool
+
+	// recorder is set on the GUI thread and read by the handler of redraw
+	recorder      *redrawRecorder
+	recorderMutex sync.Mutex
+	// replaying is 1 while replaying, and accessed atomically
+	replaying int32
 }
 
 func newWorkspace(path string) (*Workspace, error) {
 	w := &Workspace{
 		stop:          make(chan struct{}),
 		signal:        NewWorkspaceSignal(nil),
-		redrawUpdates: make(chan [][]interface{}, 1000),
+		redrawUpdates: make(chan []redrawEvent, 1000),
+		redrawBatches: make(chan *redrawBatch, 1),
 		guiUpdates:    make(chan []interface{}, 1000),
 		doneNvimStart: make(chan bool, 1000),
 		foreground:    newRGBA(180, 185, 190, 1),
@@ -114,13 +168,15 @@ func newWorkspace(path string) (*Workspace, error) {
 		special:       newRGBA(255, 255, 255, 1),
 	}
 	w.font = initFontNew(editor.extFontFamily, float64(editor.extFontSize), editor.config.Editor.Linespace, true)
+	w.linespace = editor.config.Editor.Linespace
+	w.letterspace = editor.config.Editor.Letterspacing
 	go func() {
 		w.fontMutex.Lock()
 		defer w.fontMutex.Unlock()
-		width, height, truewidth, ascent, italicWidth := fontSizeNew(w.font.fontNew)
-		w.font.width = width
+		_, height, truewidth, ascent, italicWidth := fontSizeNew(w.font.fontNew)
 		w.font.height = height
-		w.font.truewidth = truewidth
+		w.font.advance = truewidth
+		w.font.setLetterSpace(w.letterspace)
 		w.font.lineHeight = height + w.font.lineSpace
 		w.font.ascent = ascent
 		w.font.italicWidth = italicWidth
@@ -133,8 +189,11 @@ func newWorkspace(path string) (*Workspace, error) {
 	// Basic Workspace UI component
 	w.tabline = initTabline()
 	w.tabline.ws = w
+	w.navigation = initNavigation(w)
 	w.statusline = initStatusline()
 	w.statusline.ws = w
+	w.touchBar = initTouchBar()
+	w.touchBar.ws = w
 	w.loc = initLocpopup()
 	w.loc.ws = w
 	w.message = initMessage()
@@ -144,13 +203,41 @@ func newWorkspace(path string) (*Workspace, error) {
 	w.fpalette = initPalette()
 	w.fpalette.ws = w
 
-	go w.startNvim(path)
+	w.startup = newStartupProgress(w)
+	go func() {
+		err := w.startNvim(path)
+		if err != nil {
+			w.startup.fail(err)
+			if runtime.GOOS == "windows" {
+				w.doneNvimStart <- true
+			}
+		}
+	}()
 	w.registerSignal()
 
 	w.screen = newScreen()
 	w.screen.ws = w
 	w.screen.font = w.font
 	w.screen.initInputMethodWidget()
+	w.inputQueue = newInputQueue(w)
+	w.hunkPopup = newHunkPopup(w)
+	w.resources = newResourceMonitor(w)
+	w.paster = newPaster(w)
+	w.cheatsheet = newCheatsheet(w)
+	w.helpReader = newHelpReader(w)
+	w.reading = newReadingMode(w)
+	w.msgHistory = newMsgHistory(w)
+	w.webPanes = newWebPanes(w)
+	if editor.config.SearchIndex.Enable {
+		w.index = newSearchIndex()
+	}
+	w.output = newOutputPanel(w)
+	w.follow = newFollowMode(w)
+	w.fileLoad = newFileLoad(w)
+	w.warmup = newGlyphWarmup(w)
+	w.connection = newConnectionMonitor(w)
+	w.localEcho = newLocalEcho(w)
+	go w.processRedraw()
 
 	w.loc.widget.SetParent(editor.wsWidget)
 	w.message.widget.SetParent(editor.window)
@@ -166,21 +253,28 @@ func newWorkspace(path string) (*Workspace, error) {
 	w.popup = initPopupmenuNew()
 	w.popup.widget.SetParent(editor.wsWidget)
 	w.popup.ws = w
+	w.popup.doc = newCompletionDoc(w.popup)
 	w.finder = initFinder()
 	w.finder.ws = w
+	w.peek = initPeek()
+	w.peek.ws = w
 	w.signature = initSignature()
 	w.signature.widget.SetParent(editor.wsWidget)
 	w.signature.ws = w
 	w.cmdline = initCmdline()
 	w.cmdline.ws = w
-	w.minimap = newMiniMap()
-	w.minimap.ws = w
+	w.minimap = newMiniMap(w)
+	w.dictation = newDictation(w)
 
 	layout := widgets.NewQVBoxLayout()
 	w.widget = widgets.NewQWidget(nil, 0)
 	w.widget.SetContentsMargins(0, 0, 0, 0)
 	w.widget.SetLayout(layout)
 	w.widget.SetFocusPolicy(core.Qt__WheelFocus)
+	// Tab in the grid is for nvim, not for moving the focus to the chrome
+	w.widget.ConnectFocusNextPrevChild(func(next bool) bool {
+		return false
+	})
 	w.widget.SetAttribute(core.Qt__WA_InputMethodEnabled, true)
 	w.widget.ConnectInputMethodEvent(w.InputMethodEvent)
 	w.widget.ConnectInputMethodQuery(w.InputMethodQuery)
@@ -199,6 +293,8 @@ func newWorkspace(path string) (*Workspace, error) {
 
 	layout.AddWidget(w.tabline.widget, 0, 0)
 	layout.AddWidget(scrWidget, 1, 0)
+	layout.AddWidget(w.output.widget, 0, 0)
+	layout.AddWidget(w.touchBar.widget, 0, 0)
 	layout.AddWidget(w.statusline.widget, 0, 0)
 	layout.SetContentsMargins(0, 0, 0, 0)
 	layout.SetSpacing(0)
@@ -212,15 +308,7 @@ func newWorkspace(path string) (*Workspace, error) {
 	w.widget.SetParent(editor.wsWidget)
 	w.widget.Move2(0, 0)
 	w.updateSize()
-
-	if !w.uiRemoteAttached && !editor.config.MiniMap.Disable {
-		go func() {
-			if !editor.config.MiniMap.Visible {
-				time.Sleep(1500 * time.Millisecond)
-			}
-			w.minimap.startMinimapProc()
-		}()
-	}
+	w.startup.show(w.widget)
 
 	if runtime.GOOS == "windows" {
 		<-w.doneNvimStart
@@ -231,17 +319,14 @@ func newWorkspace(path string) (*Workspace, error) {
 
 func (w *Workspace) registerSignal() {
 	w.signal.ConnectRedrawSignal(func() {
-		updates := <-w.redrawUpdates
-		w.handleRedraw(updates)
+		batch := <-w.redrawBatches
+		w.applyRedrawBatch(batch)
 	})
 	w.signal.ConnectGuiSignal(func() {
 		updates := <-w.guiUpdates
 		w.handleRPCGui(updates)
 	})
 	w.signal.ConnectStopSignal(func() {
-		if !w.uiRemoteAttached {
-			editor.workspaces[editor.active].minimap.exit()
-		}
 		workspaces := []*Workspace{}
 		index := 0
 		for i, ws := range editor.workspaces {
@@ -268,6 +353,9 @@ func (w *Workspace) registerSignal() {
 			}
 			editor.workspaceUpdate()
 		}
+		if editor.isShownInSplit(w) {
+			editor.workspaceUnsplit()
+		}
 	})
 }
 
@@ -302,8 +390,8 @@ func (w *Workspace) startNvim(path string) error {
 		}, editor.args...)...,
 	)
 	if editor.opts.Server != "" {
-		// Attaching to remote nvim session
-		neovim, err = nvim.Dial(editor.opts.Server)
+		// Attaching to remote nvim session, over ssh if --ssh is given
+		neovim, err = dialServer()
 		w.uiRemoteAttached = true
 	} else if editor.opts.Nvim != "" {
 		// Attaching to /path/to/nvim
@@ -317,25 +405,9 @@ func (w *Workspace) startNvim(path string) error {
 		return err
 	}
 	w.nvim = neovim
-	w.nvim.RegisterHandler("Gui", func(updates ...interface{}) {
-		w.guiUpdates <- updates
-		w.signal.GuiSignal()
-	})
-	w.nvim.RegisterHandler("redraw", func(updates ...[]interface{}) {
-		w.redrawUpdates <- updates
-		w.signal.RedrawSignal()
-	})
+	w.registerHandlers(neovim)
 
-	go func() {
-		err := w.nvim.Serve()
-		if err != nil {
-			fmt.Println(err)
-		}
-		w.stopOnce.Do(func() {
-			close(w.stop)
-		})
-		w.signal.StopSignal()
-	}()
+	go w.serve(neovim)
 
 	go w.init(path)
 
@@ -346,11 +418,39 @@ func (w *Workspace) startNvim(path string) error {
 	return nil
 }
 
+// registerHandlers registers the handlers of the notifications of the
+// connection to neovim
+func (w *Workspace) registerHandlers(neovim *nvim.Nvim) {
+	neovim.RegisterHandler("Gui", func(updates ...interface{}) {
+		atomic.AddUint64(&w.rpcCount, 1)
+		if w.connection.leave(updates) {
+			return
+		}
+		w.guiUpdates <- updates
+		w.signal.GuiSignal()
+	})
+	neovim.RegisterHandler("redraw", func(events ...redrawEvent) {
+		atomic.AddUint64(&w.rpcCount, 1)
+		w.recorderMutex.Lock()
+		recorder := w.recorder
+		w.recorderMutex.Unlock()
+		if recorder != nil {
+			recorder.record(rawRedrawEvents(events))
+		}
+		if atomic.LoadInt32(&w.replaying) == 1 {
+			return
+		}
+		w.redrawUpdates <- events
+	})
+}
+
 func (w *Workspace) init(path string) {
+	w.startup.setStage("Attaching UI")
 	w.configure()
 	w.attachUI(path)
 	w.loadGinitVim()
 	w.getNvimOptions()
+	go w.localEcho.sample()
 }
 
 func (w *Workspace) configure() {
@@ -378,19 +478,37 @@ func (w *Workspace) attachUI(path string) error {
 	w.message.subscribe()
 
 	// Add editor feature
-	fuzzy.RegisterPlugin(w.nvim, w.uiRemoteAttached)
+	finder := fuzzy.RegisterPlugin(w.nvim, w.uiRemoteAttached)
+	if w.index != nil {
+		finder.SetIndex(w.index)
+	}
 	filer.RegisterPlugin(w.nvim)
+	w.updateChannel()
+	w.registerGridContent()
+
+	if editor.opts.Record != "" {
+		w.startRecording(editor.opts.Record)
+	}
 
 	w.uiAttached = true
 	err := w.nvim.AttachUI(w.cols, w.rows, w.attachUIOption())
 	if err != nil {
-		fmt.Println(err)
-		editor.close()
+		w.startup.fail(err)
 		return err
 	}
+	w.startup.setStage("Loading plugins")
+	go w.startup.watch()
+	if editor.isAttachedWindow() {
+		go w.openWindowTabpage()
+	} else {
+		w.activateWindow()
+	}
 	if path != "" {
 		go w.nvim.Command("so " + path)
 	}
+	if editor.opts.Replay != "" {
+		w.replay(editor.opts.Replay)
+	}
 
 	return nil
 }
@@ -405,14 +523,17 @@ func (w *Workspace) initGonvim() {
 	au GonvimAuWorkspace DirChanged * call rpcnotify(0, "Gui", "gonvim_workspace_cwd", getcwd())
 	aug GonvimAuFilepath | au! | aug END
 	au GonvimAuFilepath BufEnter,TabEnter,DirChanged,TermOpen,TermClose * silent call rpcnotify(0, "Gui", "gonvim_workspace_filepath", expand("%:p"))
+	aug GonvimAuViewport | au! | aug END
+	au GonvimAuViewport BufLeave,VimLeavePre * if &buftype == "" | call rpcnotify(0, "Gui", "gonvim_viewport_save", expand("%:p"), getcwd(), line("w0"), line("."), col("."), get(g:, "gonvim_restore_viewport", -1)) | endif
+	au GonvimAuViewport BufReadPost * if &buftype == "" | call rpcnotify(0, "Gui", "gonvim_viewport_restore", expand("%:p"), getcwd(), get(g:, "gonvim_restore_viewport", -1)) | endif
 	aug GonvimAuMd | au! | aug END
 	au GonvimAuMd TextChanged,TextChangedI *.md call rpcnotify(0, "Gui", "gonvim_markdown_update")
 	au GonvimAuMd BufEnter *.md call rpcnotify(0, "Gui", "gonvim_markdown_new_buffer")
 	`
-	if !w.uiRemoteAttached {
+	if !editor.config.MiniMap.Disable {
 		gonvimAutoCmds = gonvimAutoCmds + `
 		aug GonvimAuMinimap | au! | aug END
-		au GonvimAuMinimap BufEnter,BufWrite * call rpcnotify(0, "Gui", "gonvim_minimap_update")
+		au GonvimAuMinimap BufEnter,BufWrite,ColorScheme * call rpcnotify(0, "Gui", "gonvim_minimap_update")
 		aug GonvimAuMinimapSync | au! | aug END
 		au GonvimAuMinimapSync TextChanged,TextChangedI * call rpcnotify(0, "Gui", "gonvim_minimap_sync")
 		`
@@ -424,6 +545,51 @@ func (w *Workspace) initGonvim() {
 	au GonvimAuScrollbar TextChanged,TextChangedI,BufReadPost * call rpcnotify(0, "Gui", "gonvim_get_maxline", line("$"))
 	`
 	}
+	if editor.config.ColorColumn.Style != "" {
+		gonvimAutoCmds = gonvimAutoCmds + `
+	aug GonvimAuColorColumn | au! | aug END
+	au GonvimAuColorColumn BufWinEnter,WinEnter,WinScrolled,FileType * call rpcnotify(0, "Gui", "gonvim_colorcolumn", win_getid(), &colorcolumn, &textwidth, &filetype, getwininfo(win_getid())[0].textoff, winsaveview().leftcol)
+	au GonvimAuColorColumn OptionSet colorcolumn,textwidth,number,relativenumber,signcolumn,foldcolumn call rpcnotify(0, "Gui", "gonvim_colorcolumn", win_getid(), &colorcolumn, &textwidth, &filetype, getwininfo(win_getid())[0].textoff, winsaveview().leftcol)
+	`
+	}
+	gonvimAutoCmds = gonvimAutoCmds + `
+	aug GonvimAuWinhl | au! | aug END
+	au GonvimAuWinhl WinEnter,BufWinEnter * call rpcnotify(0, "Gui", "gonvim_winhl", win_getid(), &winhighlight, 1)
+	au GonvimAuWinhl WinLeave * call rpcnotify(0, "Gui", "gonvim_winhl", win_getid(), &winhighlight, 0)
+	au GonvimAuWinhl OptionSet winhighlight call rpcnotify(0, "Gui", "gonvim_winhl", win_getid(), &winhighlight, 1)
+	au GonvimAuWinhl ColorScheme * call rpcnotify(0, "Gui", "gonvim_winhl_refresh")
+	`
+	gonvimAutoCmds = gonvimAutoCmds + `
+	aug GonvimAuReadOnly | au! | aug END
+	au GonvimAuReadOnly BufEnter,WinEnter,BufWinEnter,TabEnter * call rpcnotify(0, "Gui", "gonvim_readonly", win_getid(), nvim_get_current_tabpage(), &buftype ==# "" && (&readonly || !&modifiable))
+	au GonvimAuReadOnly OptionSet readonly,modifiable,buftype call rpcnotify(0, "Gui", "gonvim_readonly", win_getid(), nvim_get_current_tabpage(), &buftype ==# "" && (&readonly || !&modifiable))
+	`
+	gonvimAutoCmds = gonvimAutoCmds + `
+	aug GonvimAuMouseMove | au! | aug END
+	if exists("&mousemoveevent")
+	au GonvimAuMouseMove OptionSet mousemoveevent call rpcnotify(0, "Gui", "gonvim_mousemoveevent", &mousemoveevent)
+	call rpcnotify(0, "Gui", "gonvim_mousemoveevent", &mousemoveevent)
+	endif
+	`
+	if editor.config.Editor.SwapDialog {
+		gonvimAutoCmds = gonvimAutoCmds + swapAutoCmds
+	}
+	if editor.config.Editor.FileLoadProgress > 0 {
+		gonvimAutoCmds = gonvimAutoCmds + fileLoadAutoCmds(editor.config.Editor.FileLoadProgress)
+	}
+	gonvimAutoCmds = gonvimAutoCmds + zoomAutoCmds
+	gonvimAutoCmds = gonvimAutoCmds + helpReaderAutoCmds
+	gonvimAutoCmds = gonvimAutoCmds + readingModeAutoCmds
+	if w.index != nil && !w.uiRemoteAttached {
+		gonvimAutoCmds = gonvimAutoCmds + searchIndexAutoCmds
+	}
+	gonvimAutoCmds = gonvimAutoCmds + bidiAutoCmds
+	if editor.config.Watermark.Enable {
+		gonvimAutoCmds = gonvimAutoCmds + watermarkAutoCmds
+	}
+	if w.uiRemoteAttached && editor.config.Reconnect.Enable {
+		gonvimAutoCmds = gonvimAutoCmds + reconnectAutoCmds
+	}
 	if editor.config.Editor.Clipboard {
 		gonvimAutoCmds = gonvimAutoCmds + `
 	aug GonvimAuClipboard | au! | aug END
@@ -444,21 +610,79 @@ func (w *Workspace) initGonvim() {
 	command! -nargs=1 GonvimResize call rpcnotify(0, "Gui", "gonvim_resize", <args>)
 	command! GonvimSidebarShow call rpcnotify(0, "Gui", "side_open")
 	command! GonvimMarkdown call rpcnotify(0, "Gui", "gonvim_markdown_toggle")
+	command! GonvimDictation call rpcnotify(0, "Gui", "gonvim_dictation_toggle")
+	command! GonvimThemeToggle call rpcnotify(0, "Gui", "gonvim_theme_toggle")
+	command! GonvimSharingMode call rpcnotify(0, "Gui", "gonvim_sharing_mode_toggle")
+	command! GonvimHighlightEditor call rpcnotify(0, "Gui", "gonvim_highlight_editor")
+	command! -nargs=+ -complete=file GonvimPeek call rpcnotify(0, "Gui", "gonvim_peek", <f-args>)
+	command! GonvimPeekClose call rpcnotify(0, "Gui", "gonvim_peek_close")
+	command! GonvimCheatsheet call rpcnotify(0, "Gui", "gonvim_cheatsheet")
+	command! -nargs=+ -complete=shellcmd GonvimRun call rpcnotify(0, "Gui", "gonvim_run", <q-args>)
+	command! GonvimRunStop call rpcnotify(0, "Gui", "gonvim_run_stop")
+	command! GonvimOutput call rpcnotify(0, "Gui", "gonvim_output_toggle")
+	command! -nargs=? -complete=file GonvimReveal call rpcnotify(0, "Gui", "gonvim_reveal", <q-args> == "" ? expand("%%:p") : fnamemodify(expand(<q-args>), ":p"))
+	command! -nargs=? -complete=dir GonvimTerminalHere call rpcnotify(0, "Gui", "gonvim_terminal_here", <q-args> == "" ? expand("%%:p") : fnamemodify(expand(<q-args>), ":p"))
+	command! -bang GonvimFollow call rpcnotify(0, "Gui", "gonvim_follow", <bang>0 ? 0 : win_getid())
+	command! -nargs=? -complete=file GonvimPasteFile call rpcnotify(0, "Gui", "gonvim_paste_file", expand(<q-args>))
+	command! -nargs=1 GonvimLetterSpace call rpcnotify(0, "Gui", "Letterspace", <q-args>)
 	command! GonvimVersion echo "%s"`, editor.version)
-	if !w.uiRemoteAttached {
-		if !editor.config.MiniMap.Disable {
+	if !editor.config.MiniMap.Disable {
 		gonvimCommands = gonvimCommands + `
 		command! GonvimMiniMap call rpcnotify(0, "Gui", "gonvim_minimap_toggle")
 		`
-		}
+	}
+	if !w.uiRemoteAttached {
 		gonvimCommands = gonvimCommands + `
 	command! GonvimWorkspaceNew call rpcnotify(0, "Gui", "gonvim_workspace_new")
 	command! GonvimWorkspaceNext call rpcnotify(0, "Gui", "gonvim_workspace_next")
 	command! GonvimWorkspacePrevious call rpcnotify(0, "Gui", "gonvim_workspace_previous")
 	command! -nargs=1 GonvimWorkspaceSwitch call rpcnotify(0, "Gui", "gonvim_workspace_switch", <args>)
+	command! -nargs=? GonvimWorkspaceSplit call rpcnotify(0, "Gui", "gonvim_workspace_split", <q-args>)
+	command! GonvimWorkspaceUnsplit call rpcnotify(0, "Gui", "gonvim_workspace_unsplit")
 	command! -nargs=1 GonvimGridFont call rpcnotify(0, "Gui", "gonvim_grid_font", <args>)
+	command! -nargs=? -complete=file GonvimRecordStart call rpcnotify(0, "Gui", "gonvim_record_start", <q-args>)
+	command! GonvimRecordStop call rpcnotify(0, "Gui", "gonvim_record_stop")
+	command! -nargs=1 -complete=file GonvimReplay call rpcnotify(0, "Gui", "gonvim_replay", <q-args>)
 	`
 	}
+	gonvimCommands = gonvimCommands + keyCommands("GonvimZoom", "gonvim_zoom_toggle", editor.config.Editor.ZoomKey)
+	gonvimCommands = gonvimCommands + keyCommands("GonvimFocusGui", "gonvim_focus_gui", editor.config.Editor.FocusGuiKey)
+	gonvimCommands = gonvimCommands + helpReaderCommands
+	if editor.config.Editor.GitHunkPopup {
+		gonvimCommands = gonvimCommands + hunkCommands
+	}
+	gonvimCommands = gonvimCommands + readingModeCommands
+	if w.index != nil && !w.uiRemoteAttached {
+		gonvimCommands = gonvimCommands + searchIndexCommands
+	}
+	gonvimCommands = gonvimCommands + placementCommands
+	gonvimCommands = gonvimCommands + bidiCommands(editor.config.Editor.Bidi)
+	gonvimCommands = gonvimCommands + ambiWidthCommands(editor.config.Editor.AmbiWidth)
+	gonvimCommands = gonvimCommands + resourceMonitorCommands
+	gonvimCommands = gonvimCommands + pasteCommands
+	gonvimCommands = gonvimCommands + webPaneCommands
+	gonvimCommands = gonvimCommands + multiWindowCommands
+	if editor.config.HorizontalScroll.Visible {
+		gonvimCommands = gonvimCommands + hscrollCommands
+	}
+	if editor.config.Editor.BlockSelectionOverlay {
+		gonvimCommands = gonvimCommands + blockVisualCommands
+	}
+	if editor.config.StatusColumn.Enable {
+		w.setStatusColumn()
+		gonvimCommands = gonvimCommands + statusColumnCommands
+	}
+	if editor.config.Whitespace.Enable {
+		w.setWhitespace()
+		gonvimCommands = gonvimCommands + whitespaceCommands
+	}
+	// nvim has no file dialog for :browse, so the command is replaced by
+	// GonvimBrowse which opens the native one
+	gonvimCommands = gonvimCommands + `
+	command! -nargs=+ -complete=command GonvimBrowse call rpcnotify(0, "Gui", "gonvim_browse", <q-args>, expand("%:p:h"), expand("%:t"), &filetype, get(b:, "browsefilter", get(g:, "browsefilter", "")))
+	cnoreabbrev <expr> browse getcmdtype() ==# ":" && getcmdline() ==# "browse" ? "GonvimBrowse" : "browse"
+	cnoreabbrev <expr> bro getcmdtype() ==# ":" && getcmdline() ==# "bro" ? "GonvimBrowse" : "bro"
+	`
 	if runtime.GOOS == "darwin" {
 		gonvimCommands = gonvimCommands + `
 		command! GonvimMaximize call rpcnotify(0, "Gui", "gonvim_maximize")
@@ -470,7 +694,7 @@ func (w *Workspace) initGonvim() {
 	gonvimInitNotify := `
 	call rpcnotify(0, "statusline", "bufenter", expand("%:p"), &filetype, &fileencoding, &fileformat, &ro)
 	`
-	if !w.uiRemoteAttached {
+	if !editor.config.MiniMap.Disable {
 		gonvimInitNotify = gonvimInitNotify + `
 		call rpcnotify(0, "Gui", "gonvim_minimap_update")
 		`
@@ -595,6 +819,9 @@ func (w *Workspace) nvimEval(s string) (interface{}, error) {
 
 func (w *Workspace) setCwd(cwd string) {
 	w.cwd = cwd
+	if w.index != nil && !w.uiRemoteAttached {
+		w.index.setRoot(cwd)
+	}
 	if editor.wsSide == nil {
 		return
 	}
@@ -632,60 +859,50 @@ func (w *Workspace) setCwd(cwd string) {
 }
 
 func (w *Workspace) attachUIOption() map[string]interface{} {
-	o := make(map[string]interface{})
+	wanted := map[string]bool{
+		"ext_linegrid":  true,
+		"ext_multigrid": !editor.config.Editor.LowMemory,
+		"ext_hlstate":   true,
+		"ext_cmdline":   editor.config.Editor.ExtCmdline,
+		"ext_messages":  editor.config.Editor.ExtMessages,
+		"ext_popupmenu": editor.config.Editor.ExtPopupmenu,
+		"ext_tabline":   editor.config.Editor.ExtTabline,
+	}
+
+	// nvim rejects the ext_* options it doesn't know, so only the supported
+	// ones are attached
+	apiInfo, err := w.nvim.APIInfo()
+	if err != nil {
+		return map[string]interface{}{
+			"rgb":           true,
+			"ext_multigrid": !editor.config.Editor.LowMemory,
+			"ext_hlstate":   true,
+		}
+	}
+	events, version := uiEventsAndVersion(apiInfo)
+	o, missing := negotiateUIOptions(events, wanted)
 	o["rgb"] = true
-	// o["ext_multigrid"] = editor.config.Editor.ExtMultigrid
-	o["ext_multigrid"] = true
-	o["ext_hlstate"] = true
 
-	apiInfo, err := w.nvim.APIInfo()
-	if err == nil {
-		for _, item := range apiInfo {
-			i, ok := item.(map[string]interface{})
-			if !ok {
-				continue
-			}
-			for k, v := range i {
-				if k != "ui_events" {
-					continue
-				}
-				events, ok := v.([]interface{})
-				if !ok {
-					continue
-				}
-				for _, event := range events {
-					function, ok := event.(map[string]interface{})
-					if !ok {
-						continue
-					}
-					name, ok := function["name"]
-					if !ok {
-						continue
-					}
-
-					switch name {
-					// case "wildmenu_show" :
-					// 	o["ext_wildmenu"] = editor.config.Editor.ExtCmdline
-					case "cmdline_show":
-						o["ext_cmdline"] = editor.config.Editor.ExtCmdline
-					case "msg_show":
-						o["ext_messages"] = editor.config.Editor.ExtMessages
-					case "popupmenu_show":
-						o["ext_popupmenu"] = editor.config.Editor.ExtPopupmenu
-					case "tabline_update":
-						o["ext_tabline"] = editor.config.Editor.ExtTabline
-					}
-				}
-			}
+	if len(missing) == 0 {
+		return o
+	}
+	for _, c := range missing {
+		// nvim draws the tabline in the grid
+		if c.option == "ext_tabline" {
+			w.drawTabline = false
 		}
 	}
+	uiCapabilityWarning.Do(func() {
+		editor.pushNotification(NotifyWarn, -1, capabilityWarning(version, missing))
+	})
+
 	return o
 }
 
 func (w *Workspace) updateSize() {
 	e := editor
-	width := e.wsWidget.Width()
-	height := e.wsWidget.Height()
+	x, y, width, height := e.workspaceGeometry(w)
+	w.widget.Move2(x, y)
 	if width != w.width || height != w.height {
 		w.width = width
 		w.height = height
@@ -702,12 +919,16 @@ func (w *Workspace) updateSize() {
 	if w.drawTabline {
 		w.tabline.height = w.tabline.widget.Height()
 	}
+	w.navigation.move()
 	if w.drawStatusline {
 		w.statusline.height = w.statusline.widget.Height()
 	}
 
+	w.touchBar.updateHeight()
+	w.output.updateHeight()
+
 	if w.screen != nil {
-		w.screen.height = w.height - w.tabline.height - w.statusline.height
+		w.screen.height = w.height - w.tabline.height - w.statusline.height - w.touchBar.height - w.output.height
 		w.screen.updateSize()
 	}
 	if w.palette != nil {
@@ -719,6 +940,9 @@ func (w *Workspace) updateSize() {
 	if w.message != nil {
 		w.message.resize()
 	}
+	if w.dictation != nil {
+		w.dictation.move()
+	}
 
 	// notification
 	e.updateNotificationPos()
@@ -744,6 +968,7 @@ func (e *Editor) updateNotificationPos() {
 
 func (w *Workspace) handleRedraw(updates [][]interface{}) {
 	s := w.screen
+	flushed := false
 	for _, update := range updates {
 		event := update[0].(string)
 		args := update[1:]
@@ -772,16 +997,25 @@ func (w *Workspace) handleRedraw(updates [][]interface{}) {
 				w.cursor.update()
 			}
 			w.disableImeInNormal()
+			if !isPromptMode(w.mode) {
+				w.message.dismissPrompt()
+			}
 		case "mouse_on":
 		case "mouse_off":
 		case "busy_start":
+			w.inputQueue.busyStart()
 		case "busy_stop":
+			w.inputQueue.busyStop()
 		case "suspend":
 		case "update_menu":
 		case "bell":
 		case "visual_bell":
 		case "flush":
+			flushed = true
+			w.startup.finish()
 			w.cursor.update()
+			w.applyRestoredDust()
+			w.localEcho.flush()
 
 		// Grid Events
 		case "grid_resize":
@@ -790,8 +1024,10 @@ func (w *Workspace) handleRedraw(updates [][]interface{}) {
 			for _, u := range update[1:] {
 				w.setColorsSet(u.([]interface{}))
 			}
+			w.warmup.schedule()
 		case "hl_attr_define":
 			s.setHlAttrDef(args)
+			w.warmup.schedule()
 		case "hl_group_set":
 			s.setHighlightGroup(args)
 		case "grid_line":
@@ -821,7 +1057,7 @@ func (w *Workspace) handleRedraw(updates [][]interface{}) {
 			// old impl
 			// s.windowScrollOverReset()
 		case "win_close":
-			s.windowClose()
+			s.windowClose(args)
 		case "msg_set_pos":
 			s.msgSetPos(args)
 		// case "win_viewport":
@@ -856,7 +1092,6 @@ func (w *Workspace) handleRedraw(updates [][]interface{}) {
 		case "cmdline_pos":
 			w.cmdline.changePos(args)
 		case "cmdline_special_char":
-		case "cmdline_char":
 			w.cmdline.putChar(args)
 		case "cmdline_hide":
 			w.cmdline.hide(args)
@@ -865,8 +1100,11 @@ func (w *Workspace) handleRedraw(updates [][]interface{}) {
 		case "cmdline_function_hide":
 			w.cmdline.functionHide()
 		case "cmdline_block_show":
+			w.cmdline.blockShow(args)
 		case "cmdline_block_append":
+			w.cmdline.blockAppend(args)
 		case "cmdline_block_hide":
+			w.cmdline.blockHide()
 
 		// // -- deprecated events
 		// case "wildmenu_show":
@@ -891,7 +1129,14 @@ func (w *Workspace) handleRedraw(updates [][]interface{}) {
 		}
 	}
 
-	s.update()
+	// Wait for the rest of the frame
+	if !flushed {
+		return
+	}
+	if w.throttleFlush() {
+		return
+	}
+	s.flush()
 	w.drawOtherUI()
 }
 
@@ -905,6 +1150,7 @@ func (w *Workspace) drawOtherUI() {
 	if w.drawStatusline {
 		w.statusline.pos.redraw(w.curLine, w.curColm)
 		w.statusline.mode.redraw()
+		w.statusline.wordcount.redraw()
 	}
 
 	if editor.config.ScrollBar.Visible {
@@ -917,9 +1163,10 @@ func (w *Workspace) drawOtherUI() {
 	}
 
 	if w.minimap.visible {
-		go w.updateMinimap()
-		w.minimap.mapScroll()
+		w.minimap.follow()
 	}
+
+	w.webPanes.place()
 }
 
 func (w *Workspace) disableImeInNormal() {
@@ -971,12 +1218,6 @@ func (w *Workspace) setColorsSet(args []interface{}) {
 	if !w.uiRemoteAttached {
 		if !isChangeFg || !isChangeBg {
 			editor.isSetGuiColor = false
-			aw := editor.workspaces[editor.active]
-			// change minimap colorscheme
-			aw.minimap.isSetColorscheme = false
-			if aw.minimap.visible && aw.minimap.nvim != nil && aw.nvim != nil {
-				editor.workspaces[editor.active].minimap.setColorscheme()
-			}
 		}
 	}
 	if len(editor.workspaces) > 1 {
@@ -1016,6 +1257,11 @@ func (w *Workspace) updateWorkspaceColor() {
 	w.signature.setColor()
 	w.message.setColor()
 	w.screen.setColor()
+	w.touchBar.setColor()
+	w.output.setColor()
+	w.webPanes.setColor()
+	w.navigation.setColor()
+	w.minimap.setColor()
 	if w.drawTabline {
 		w.tabline.setColor()
 	}
@@ -1031,6 +1277,9 @@ func (w *Workspace) updateWorkspaceColor() {
 	if editor.wsSide != nil {
 		editor.wsSide.setColor()
 	}
+	if editor.wsSplit != nil {
+		editor.wsSplit.setColor()
+	}
 }
 
 func (w *Workspace) modeInfoSet(args []interface{}) {
@@ -1056,6 +1305,7 @@ func (w *Workspace) setOption(update []interface{}) {
 		switch key {
 		case "arabicshape":
 		case "ambiwidth":
+			w.setAmbiwidth(val)
 		case "emoji":
 		case "guifont":
 			w.guiFont(val.(string))
@@ -1106,38 +1356,59 @@ func (w *Workspace) getPos() {
 	w.curColm = curPos[2]
 }
 
-func (w *Workspace) updateMinimap() {
-	var absMapTop int
-	var absMapBottom int
-	w.minimap.nvim.Eval("line('w0')", &absMapTop)
-	w.minimap.nvim.Eval("line('w$')", &absMapBottom)
-	w.minimap.nvim.Command(fmt.Sprintf("call cursor(%d, %d)", w.curLine, 0))
-	w.curPosMutex.RLock()
-	defer w.curPosMutex.RUnlock()
-	switch {
-	case w.curLine >= absMapBottom:
-		w.minimap.nvim.Input("<C-d>")
-	case absMapTop >= w.curLine:
-		w.minimap.nvim.Input("<C-u>")
-	default:
-	}
-}
-
 func (w *Workspace) handleRPCGui(updates []interface{}) {
 	event := updates[0].(string)
 	switch event {
 	case "gonvim_enter":
 		editor.window.SetWindowOpacity(1.0)
 		w.setCwd(updates[1].(string))
+		editor.theme.applyTo(w)
+		w.emitGuiEvent(guiEventStarted, map[string]interface{}{
+			"cwd":       w.cwd,
+			"workspace": editor.workspaceIndex(w),
+		})
 	case "gonvim_resize":
 		width, height := editor.setWindowSize(updates[1].(string))
 		editor.window.Resize2(width, height)
 	case "gonvim_maximize":
 		editor.window.WindowMaximize()
+	case "gonvim_place":
+		w.guiPlace(updates[1:])
+	case "gonvim_bidi":
+		w.updateBidi(updates[1:])
+	case "gonvim_hunk":
+		w.hunkPopup.show(updates[1:])
+	case "gonvim_hunk_diff":
+		w.hunkPopup.showDiff(updates[1:])
+	case "gonvim_stc_click":
+		w.statusColumnClicked(updates[1:])
+	case "gonvim_reflow_snapshot":
+		if w.screen.reflow != nil {
+			w.screen.reflow.setSnapshot(updates[1].([]*reflowWin))
+		}
+	case "gonvim_paste":
+		w.paster.pasteClipboard()
+	case "gonvim_paste_progress":
+		w.paster.progress(util.ReflectToInt(updates[1]), util.ReflectToInt(updates[2]))
+	case "gonvim_viewport_restored":
+		dust := util.ReflectToInt(updates[1])
+		w.restoredDust = &dust
+	case "gonvim_paste_file_read":
+		data, _ := updates[1].(string)
+		w.putFile(data)
+	case "gonvim_drag_selection":
+		text, _ := updates[1].(string)
+		w.screen.startSelectionDrag(text)
+	case "gonvim_resource_monitor":
+		w.resources.toggle()
+	case "gonvim_resource_sample":
+		w.resources.update(updates[1].(resourceSample))
 	case "Font":
 		w.guiFont(updates[1].(string))
 	case "Linespace":
 		w.guiLinespace(updates[1])
+	case "Letterspace":
+		w.guiLetterspace(updates[1])
 	case "finder_pattern":
 		w.finder.showPattern(updates[1:])
 	case "finder_pattern_pos":
@@ -1150,6 +1421,8 @@ func (w *Workspace) handleRPCGui(updates []interface{}) {
 		w.finder.hide()
 	case "finder_select":
 		w.finder.selectResult(updates[1:])
+	case "finder_path_columns":
+		w.finder.showPathColumns(updates[1:])
 	case "signature_show":
 		w.signature.showItem(updates[1:])
 	case "signature_pos":
@@ -1183,15 +1456,14 @@ func (w *Workspace) handleRPCGui(updates []interface{}) {
 	case "gonvim_grid_font":
 		w.screen.gridFont(updates[1])
 	case "gonvim_minimap_update":
-		if w.minimap.visible {
-			w.minimap.bufUpdate()
-		}
+		w.minimap.request(true)
 	case "gonvim_minimap_sync":
-		if w.minimap.visible {
-			go w.minimap.bufSync()
-		}
+		w.minimap.sync()
 	case "gonvim_minimap_toggle":
-		go w.minimap.toggle()
+		w.minimap.toggle()
+	case "gonvim_minimap_lines":
+		lines, _ := updates[1].(*minimapLines)
+		w.minimap.setLines(lines)
 	case "gonvim_copy_clipboard":
 		go editor.copyClipBoard()
 	case "gonvim_get_maxline":
@@ -1204,10 +1476,151 @@ func (w *Workspace) handleRPCGui(updates []interface{}) {
 		editor.workspacePrevious()
 	case "gonvim_workspace_switch":
 		editor.workspaceSwitch(util.ReflectToInt(updates[1]))
+	case "gonvim_workspace_split":
+		editor.workspaceSplit(updates[1].(string))
+	case "gonvim_workspace_unsplit":
+		editor.workspaceUnsplit()
 	case "gonvim_workspace_cwd":
 		w.setCwd(updates[1].(string))
 	case "gonvim_workspace_filepath":
 		w.filepath = updates[1].(string)
+	case "gonvim_record_start":
+		w.startRecording(updates[1].(string))
+	case "gonvim_record_stop":
+		w.stopRecording()
+	case "gonvim_replay":
+		w.replay(updates[1].(string))
+	case "gonvim_viewport_save":
+		w.saveViewport(updates[1:])
+	case "gonvim_viewport_restore":
+		w.restoreViewport(updates[1:])
+	case "gonvim_peek":
+		w.peek.show(updates[1:])
+	case "gonvim_peek_lines":
+		lines, _ := updates[2].(*minimapLines)
+		w.peek.setLines(util.ReflectToInt(updates[1]), lines)
+	case "gonvim_peek_close":
+		w.peek.hide()
+	case "gonvim_cheatsheet":
+		w.cheatsheet.toggle()
+	case "gonvim_cheatsheet_entries":
+		w.cheatsheet.loaded(updates[1:])
+	case "gonvim_help":
+		w.helpReader.update(updates[1:])
+	case "gonvim_help_toggle":
+		w.helpReader.toggle(updates[1:])
+	case "gonvim_spell_suggest":
+		if w.screen.spellHover != nil {
+			w.screen.spellHover.suggested(updates[1:])
+		}
+	case "gonvim_help_lines":
+		w.helpReader.loaded(updates[1:])
+	case "gonvim_reading":
+		w.reading.update(updates[1:])
+	case "gonvim_reading_lines":
+		w.reading.rendered(updates[1:])
+	case "gonvim_index_update":
+		if w.index != nil {
+			w.index.update(updates[1].(string))
+		}
+	case "gonvim_index_refresh":
+		if w.index != nil {
+			w.index.refresh()
+		}
+	case "gonvim_index_rebuild":
+		if w.index != nil {
+			w.index.rebuild()
+		}
+	case "gonvim_index_status":
+		if w.index != nil {
+			w.index.status()
+		}
+	case "gonvim_index_lsp":
+		if w.index != nil {
+			symbols, _ := updates[1].([]interface{})
+			w.index.setLSPSymbols(symbols)
+		}
+	case "gonvim_window_new":
+		w.openAttachedWindow()
+	case "gonvim_tabpage":
+		w.tabpage = nvim.Tabpage(util.ReflectToInt(updates[1]))
+	case "gonvim_colorcolumn":
+		w.screen.updateColorColumn(updates[1:])
+	case "gonvim_file_load":
+		w.fileLoad.update(updates[1:])
+	case "gonvim_zoom_toggle":
+		w.toggleZoom(0)
+	case "gonvim_zoom":
+		if w.tabline != nil {
+			w.tabline.updateZoom(updates[1:])
+		}
+	case "gonvim_browse":
+		w.browse(updates[1:])
+	case "gonvim_swap_exists":
+		w.swapExists(updates[1:])
+	case "gonvim_webpane":
+		w.webPanes.handle(updates[1:])
+	case "gonvim_focus_gui":
+		editor.focusChain.focusGui(w)
+	case "gonvim_reveal":
+		w.reveal(updates[1:])
+	case "gonvim_terminal_here":
+		w.terminalHere(updates[1:])
+	case "gonvim_follow":
+		w.follow.toggle(util.ReflectToInt(updates[1]))
+	case "gonvim_follow_cursor":
+		w.follow.cursorMoved(updates[1:])
+	case "gonvim_mousemoveevent":
+		w.screen.setMouseMoveEvent(updates[1])
+	case "gonvim_hscroll":
+		w.screen.updateHScroll(updates[1:])
+	case "gonvim_block_visual":
+		w.screen.updateBlockVisual(updates[1:])
+	case "gonvim_winhl":
+		w.screen.updateWinhighlight(updates[1:])
+	case "gonvim_winhl_refresh":
+		w.screen.refreshWinhighlight()
+	case "gonvim_jump_history":
+		locations, _ := updates[1].([]*jumpLocation)
+		w.navigation.popupHistory(locations, util.ReflectToInt(updates[2]))
+	case "gonvim_winhl_resolved":
+		w.screen.winhighlightResolved(updates[1:])
+	case "gonvim_readonly":
+		w.updateReadOnly(updates[1:])
+	case "gonvim_watermark":
+		w.updateWatermark(updates[1:])
+	case "gonvim_connection":
+		w.connection.update(updates[1:])
+	case "gonvim_drop_guard":
+		w.screen.dropGuarded(updates[1:])
+	case "gonvim_reconnected":
+		w.reconnected(updates[1:])
+	case "gonvim_run":
+		command, ok := updates[1].(string)
+		if ok {
+			w.output.run(command)
+		}
+	case "gonvim_paste_file":
+		file, _ := updates[1].(string)
+		w.pasteFile(file)
+	case "gonvim_run_stop":
+		w.output.kill()
+	case "gonvim_output_toggle":
+		w.output.toggle()
+	case "gonvim_highlight_editor":
+		if w.hlEditor == nil {
+			w.hlEditor = newHighlightEditor(w)
+		}
+		w.hlEditor.show()
+	case "gonvim_sharing_mode_toggle":
+		editor.sharing.toggle()
+	case "gonvim_theme_toggle":
+		editor.theme.toggle()
+	case "gonvim_dictation_toggle":
+		w.dictation.toggle()
+	case "gonvim_dictation_exited":
+		cmd, _ := updates[1].(*exec.Cmd)
+		w.dictation.exited(cmd)
 	case "gonvim_termenter":
 		w.mode = "terminal-input"
 	case "gonvim_termleave":
@@ -1258,23 +1671,16 @@ func (w *Workspace) guiFont(args string) {
 		fDialog.Show()
 		return
 	}
+	w.guifont = args
 
-	for _, gfn := range strings.Split(args, ",") {
-		fontFamily, fontHeight = getFontFamilyAndHeight(gfn)
-		ok := checkValidFont(fontFamily)
-		if ok {
-			break
-		}
-	}
-
-	if fontHeight == 0 {
-		fontHeight = 10.0
-	}
+	fontFamily, fontHeight = selectGuiFont(args)
 
-	w.font.change(fontFamily, fontHeight)
+	w.font.change(fontFamily, editor.displays.fontSize(fontHeight))
 	w.screen.font = w.font
+	w.fitLineHeight()
 
 	w.updateSize()
+	w.screen.refreshFont()
 	w.popup.updateFont(w.font)
 	w.message.updateFont(w.font)
 	w.cursor.updateFont(w.font)
@@ -1285,17 +1691,34 @@ func (w *Workspace) guiFont(args string) {
 		editor.extFontFamily = fontFamily
 	}
 	if editor.config.Editor.FontSize == 0 {
-		editor.extFontSize = int(fontHeight)
+		editor.setExtFontSize(int(fontHeight))
 	}
 
 	w.palette.updateFont()
 	w.fpalette.updateFont()
 	w.tabline.updateFont()
 	w.statusline.updateFont()
+
+	w.emitGuiEvent(guiEventFontChanged, map[string]interface{}{
+		"guifont":    args,
+		"family":     fontFamily,
+		"size":       fontHeight,
+		"lineHeight": w.font.lineHeight,
+	})
 }
 
 func (w *Workspace) guiFontWide(args string) {
 	if args == "" {
+		// The wide chars are drawn in 'guifont' again
+		if w.fontwide == nil {
+			return
+		}
+		w.fontwide = nil
+		w.cursor.fontwide = nil
+		w.screen.purgeTextCacheForWins()
+		w.fitLineHeight()
+		w.updateSize()
+		w.screen.refreshFont()
 		return
 	}
 
@@ -1321,21 +1744,13 @@ func (w *Workspace) guiFontWide(args string) {
 		return
 	}
 
-	for _, gfn := range strings.Split(args, ",") {
-		fontFamily, fontHeight = getFontFamilyAndHeight(gfn)
-		ok := checkValidFont(fontFamily)
-		if ok {
-			break
-		}
-	}
-
-	if fontHeight == 0 {
-		fontHeight = 10.0
-	}
+	fontFamily, fontHeight = selectGuiFont(args)
 
-	w.fontwide.change(fontFamily, fontHeight)
+	w.fontwide.change(fontFamily, editor.displays.fontSize(fontHeight))
+	w.fitLineHeight()
 
 	w.updateSize()
+	w.screen.refreshFont()
 	// w.cursor.updateFont(w.font)
 	// w.screen.toolTipFont(w.font)
 }
@@ -1368,6 +1783,45 @@ func getFontFamilyAndHeight(s string) (string, float64) {
 	return family, height
 }
 
+// selectGuiFont returns the family and the height of the first available
+// font in the comma separated list of 'guifont' or 'guifontwide'. If none is
+// available, the last one is returned, which Qt substitutes.
+func selectGuiFont(option string) (string, float64) {
+	var family string
+	var height float64
+	for _, gfn := range strings.Split(option, ",") {
+		var name string
+		name, height = getFontFamilyAndHeight(strings.TrimSpace(gfn))
+		found := false
+		for _, candidate := range fontFamilyCandidates(name) {
+			family = candidate
+			if checkValidFont(candidate) {
+				found = true
+				break
+			}
+		}
+		if found {
+			break
+		}
+	}
+	if height == 0 {
+		height = 10.0
+	}
+
+	return family, height
+}
+
+// fontFamilyCandidates returns the family name, and the name with the
+// underscores replaced by the spaces, as in Vim, e.g. "Fira_Code"
+func fontFamilyCandidates(name string) []string {
+	candidates := []string{name}
+	if strings.Contains(name, "_") {
+		candidates = append(candidates, strings.ReplaceAll(name, "_", " "))
+	}
+
+	return candidates
+}
+
 func checkValidFont(family string) bool {
 	f := gui.NewQFont2(family, 10.0, 1, false)
 	fi := gui.NewQFontInfo(f)
@@ -1392,15 +1846,24 @@ func (w *Workspace) guiLinespace(args interface{}) {
 	default:
 		return
 	}
-	w.font.changeLineSpace(lineSpace)
-	w.updateSize()
-	// w.cursor.updateShape()
+	w.linespace = lineSpace
+	w.font.changeLineSpace(editor.displays.linespace(lineSpace) + w.lineFit.extra())
+	if w.fontwide != nil {
+		w.fontwide.changeLineSpace(editor.displays.linespace(lineSpace) + w.lineFit.extra())
+	}
+	w.refreshFontGeometry()
 }
 
 // InputMethodEvent is
 func (w *Workspace) InputMethodEvent(event *gui.QInputMethodEvent) {
 	if event.CommitString() != "" {
-		w.nvim.Input(event.CommitString())
+		if w.dictation.active {
+			w.dictation.input(event.CommitString())
+		} else if isLargePaste(event.CommitString(), editor.config.Editor.LargePasteLines) {
+			w.paster.paste(event.CommitString())
+		} else {
+			w.inputQueue.input(event.CommitString())
+		}
 		w.screen.tooltip.Hide()
 	} else {
 		preeditString := event.PreeditString()
@@ -1474,6 +1937,7 @@ func newWorkspaceSide() *WorkspaceSide {
 	header.SetText("WORKSPACE")
 	widget := widgets.NewQWidget(nil, 0)
 	widget.SetContentsMargins(0, 0, 0, 100)
+	widget.SetObjectName("sidebar")
 	widget.SetLayout(layout)
 	widget.SetSizePolicy2(widgets.QSizePolicy__Expanding, widgets.QSizePolicy__Expanding)
 
@@ -1662,7 +2126,23 @@ func newWorkspaceSideItem() *WorkspaceSideItem {
 	}
 
 	sideitem.widget.ConnectMousePressEvent(sideitem.toggleContent)
+	editor.focusChain.add(sideitem.widget, focusRankSidebar, func() {
+		sideitem.toggleContent(nil)
+	})
+	editor.focusChain.add(content.QWidget_PTR(), focusRankSidebar, func() {
+		if item := content.CurrentItem(); item != nil && item.Pointer() != nil {
+			sideitem.fileDoubleClicked(item)
+		}
+	})
 	content.ConnectItemDoubleClicked(sideitem.fileDoubleClicked)
+	content.SetMouseTracking(true)
+	content.ConnectItemEntered(sideitem.fileEntered)
+	content.ConnectLeaveEvent(func(event *core.QEvent) {
+		editor.thumbnails.hide()
+		content.LeaveEventDefault(event)
+	})
+	content.SetContextMenuPolicy(core.Qt__CustomContextMenu)
+	content.ConnectCustomContextMenuRequested(sideitem.showContextMenu)
 
 	return sideitem
 }
@@ -1700,6 +2180,12 @@ func (i *WorkspaceSideItem) fileDoubleClicked(item *widgets.QListWidgetItem) {
 	}
 }
 
+// fileEntered shows the thumbnail of the image file on hover
+func (i *WorkspaceSideItem) fileEntered(item *widgets.QListWidgetItem) {
+	path := filepath.Join(i.cwdpath, item.Text())
+	editor.thumbnails.show(path, i.content.Viewport(), i.content.VisualItemRect(item))
+}
+
 func (i *WorkspaceSideItem) toggleContent(event *gui.QMouseEvent) {
 	if i.hidden {
 		return
@@ -1810,11 +2296,11 @@ func (side *WorkspaceSide) setColor() {
 	sfg := editor.colors.scrollBarFg.String()
 	sbg := editor.colors.scrollBarBg.StringTransparent()
 	side.header.SetStyleSheet(fmt.Sprintf(" .QLabel{ color: %s;} ", fg))
-	side.widget.SetStyleSheet(fmt.Sprintf(".QWidget { border: 0px solid #000; padding-top: 5px; background-color: rgba(0, 0, 0, 0); } QWidget { color: %s; border-right: 0px solid; }", fg))
+	side.widget.SetStyleSheet(withUserStyle(fmt.Sprintf(".QWidget { border: 0px solid #000; padding-top: 5px; background-color: rgba(0, 0, 0, 0); } QWidget { color: %s; border-right: 0px solid; }", fg)))
 	if side.scrollarea == nil {
 		return
 	}
-	side.scrollarea.SetStyleSheet(fmt.Sprintf(".QScrollBar { border-width: 0px; background-color: %s; width: 5px; margin: 0 0 0 0; } .QScrollBar::handle:vertical {background-color: %s; min-height: 25px;} .QScrollBar::handle:vertical:hover {background-color: %s; min-height: 25px;} .QScrollBar::add-line:vertical, .QScrollBar::sub-line:vertical { border: none; background: none; } .QScrollBar::add-page:vertical, QScrollBar::sub-page:vertical { background: none; }", sbg, sfg, editor.config.SideBar.AccentColor))
+	side.scrollarea.SetStyleSheet(withUserStyle(fmt.Sprintf(".QScrollBar { border-width: 0px; background-color: %s; width: 5px; margin: 0 0 0 0; } .QScrollBar::handle:vertical {background-color: %s; min-height: 25px;} .QScrollBar::handle:vertical:hover {background-color: %s; min-height: 25px;} .QScrollBar::add-line:vertical, .QScrollBar::sub-line:vertical { border: none; background: none; } .QScrollBar::add-page:vertical, QScrollBar::sub-page:vertical { background: none; }", sbg, sfg, editor.config.SideBar.AccentColor)))
 
 	if len(editor.workspaces) == 1 {
 		side.items[0].active = true
@@ -0,0 +1,22 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFontFamilyCandidates(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"Menlo", []string{"Menlo"}},
+		{"Fira Code", []string{"Fira Code"}},
+		{"Fira_Code", []string{"Fira_Code", "Fira Code"}},
+	}
+	for _, tt := range tests {
+		if got := fontFamilyCandidates(tt.name); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("fontFamilyCandidates(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
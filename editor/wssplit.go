@@ -0,0 +1,197 @@
+package editor
+
+import (
+	"fmt"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+const wsSplitHandleWidth = 4
+
+// WorkspaceSplit is the state of showing two workspaces simultaneously
+type WorkspaceSplit struct {
+	panes       [2]*Workspace
+	focused     int
+	orientation core.Qt__Orientation
+	ratio       float64
+	handle      *widgets.QWidget
+	isDrag      bool
+}
+
+// workspaceSplit shows the active workspace and the next workspace side by side.
+// If there is only one workspace, a new workspace is created.
+func (e *Editor) workspaceSplit(direction string) {
+	orientation := core.Qt__Horizontal
+	if direction == "horizontal" {
+		orientation = core.Qt__Vertical
+	}
+
+	first := e.workspaces[e.active]
+	if len(e.workspaces) == 1 {
+		e.workspaceNew()
+	} else {
+		e.active++
+		if e.active >= len(e.workspaces) {
+			e.active = 0
+		}
+	}
+	second := e.workspaces[e.active]
+
+	if e.wsSplit == nil {
+		e.wsSplit = &WorkspaceSplit{
+			ratio: 0.5,
+		}
+		e.wsSplit.initHandle()
+	}
+	e.wsSplit.panes = [2]*Workspace{first, second}
+	e.wsSplit.focused = 1
+	e.wsSplit.orientation = orientation
+	e.wsSplit.handle.Show()
+	e.wsSplit.handle.Raise()
+
+	e.workspaceUpdate()
+	e.updateWorkspacesSize()
+}
+
+// workspaceUnsplit shows only the active workspace
+func (e *Editor) workspaceUnsplit() {
+	if e.wsSplit == nil {
+		return
+	}
+	e.wsSplit.handle.Hide()
+	e.wsSplit = nil
+
+	e.workspaceUpdate()
+	e.updateWorkspacesSize()
+}
+
+func (e *Editor) updateWorkspacesSize() {
+	for _, ws := range e.workspaces {
+		ws.updateSize()
+	}
+	if e.wsSplit != nil {
+		e.wsSplit.updateHandle()
+	}
+}
+
+// focusWorkspace makes the workspace displayed in the split view active
+func (e *Editor) focusWorkspace(w *Workspace) {
+	if e.workspaces[e.active] == w {
+		return
+	}
+	for i, ws := range e.workspaces {
+		if ws == w {
+			e.active = i
+			e.workspaceUpdate()
+			return
+		}
+	}
+}
+
+// isShownInSplit reports whether the workspace is one of the split panes
+func (e *Editor) isShownInSplit(w *Workspace) bool {
+	if e.wsSplit == nil {
+		return false
+	}
+
+	return e.wsSplit.panes[0] == w || e.wsSplit.panes[1] == w
+}
+
+// syncActive replaces the focused pane with the active workspace when
+// the active workspace is switched to the one not being displayed.
+func (split *WorkspaceSplit) syncActive(active *Workspace) {
+	for i, pane := range split.panes {
+		if pane == active {
+			split.focused = i
+			return
+		}
+	}
+	split.panes[split.focused] = active
+	editor.updateWorkspacesSize()
+}
+
+// workspaceGeometry returns the area in which the workspace is displayed
+func (e *Editor) workspaceGeometry(w *Workspace) (int, int, int, int) {
+	width := e.wsWidget.Width()
+	height := e.wsWidget.Height()
+	if !e.isShownInSplit(w) {
+		return 0, 0, width, height
+	}
+
+	split := e.wsSplit
+	if split.orientation == core.Qt__Horizontal {
+		leftWidth := int(float64(width-wsSplitHandleWidth) * split.ratio)
+		if split.panes[0] == w {
+			return 0, 0, leftWidth, height
+		}
+		return leftWidth + wsSplitHandleWidth, 0, width - leftWidth - wsSplitHandleWidth, height
+	}
+
+	topHeight := int(float64(height-wsSplitHandleWidth) * split.ratio)
+	if split.panes[0] == w {
+		return 0, 0, width, topHeight
+	}
+	return 0, topHeight + wsSplitHandleWidth, width, height - topHeight - wsSplitHandleWidth
+}
+
+func (split *WorkspaceSplit) initHandle() {
+	handle := widgets.NewQWidget(editor.wsWidget, 0)
+	handle.SetMouseTracking(true)
+	handle.ConnectMousePressEvent(func(event *gui.QMouseEvent) {
+		split.isDrag = true
+	})
+	handle.ConnectMouseReleaseEvent(func(event *gui.QMouseEvent) {
+		split.isDrag = false
+	})
+	handle.ConnectMouseMoveEvent(func(event *gui.QMouseEvent) {
+		if !split.isDrag {
+			return
+		}
+		pos := handle.MapToParent(event.Pos())
+		var ratio float64
+		if split.orientation == core.Qt__Horizontal {
+			ratio = float64(pos.X()) / float64(editor.wsWidget.Width())
+		} else {
+			ratio = float64(pos.Y()) / float64(editor.wsWidget.Height())
+		}
+		if ratio < 0.1 {
+			ratio = 0.1
+		}
+		if ratio > 0.9 {
+			ratio = 0.9
+		}
+		split.ratio = ratio
+		editor.updateWorkspacesSize()
+	})
+	split.handle = handle
+	split.setColor()
+}
+
+func (split *WorkspaceSplit) updateHandle() {
+	width := editor.wsWidget.Width()
+	height := editor.wsWidget.Height()
+	if split.orientation == core.Qt__Horizontal {
+		x := int(float64(width-wsSplitHandleWidth) * split.ratio)
+		split.handle.SetGeometry2(x, 0, wsSplitHandleWidth, height)
+		split.handle.SetCursor(gui.NewQCursor2(core.Qt__SplitHCursor))
+	} else {
+		y := int(float64(height-wsSplitHandleWidth) * split.ratio)
+		split.handle.SetGeometry2(0, y, width, wsSplitHandleWidth)
+		split.handle.SetCursor(gui.NewQCursor2(core.Qt__SplitVCursor))
+	}
+	split.handle.Raise()
+}
+
+func (split *WorkspaceSplit) setColor() {
+	if editor.colors.windowSeparator == nil {
+		return
+	}
+	split.handle.SetStyleSheet(
+		fmt.Sprintf(
+			" * { background-color: %s; }",
+			editor.colors.windowSeparator.String(),
+		),
+	)
+}
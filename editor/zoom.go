@@ -0,0 +1,136 @@
+package editor
+
+import (
+	"fmt"
+
+	"github.com/akiyosi/goneovim/util"
+	"github.com/neovim/go-client/nvim"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/svg"
+	"github.com/therecipe/qt/widgets"
+)
+
+// zoomLua toggles the zoom of the current window, which expands the window
+// to the whole tabpage. The layout of the tabpage is saved in t: variables
+// and restored on the next toggle. The GUI is notified of the zoomed window
+// by gonvim_zoom, [tabpage, winid], where winid is 0 if not zoomed.
+const zoomLua = `
+local tab = vim.api.nvim_get_current_tabpage()
+local restore = vim.t.gonvim_zoom_restore
+if restore then
+  vim.t.gonvim_zoom_restore = nil
+  vim.t.gonvim_zoom_win = nil
+  vim.cmd(restore)
+  vim.rpcnotify(0, "Gui", "gonvim_zoom", tab, 0)
+  return
+end
+local cur = vim.api.nvim_get_current_win()
+if vim.api.nvim_win_get_config(cur).relative ~= "" then
+  return
+end
+local splits = 0
+for _, win in ipairs(vim.api.nvim_tabpage_list_wins(tab)) do
+  if vim.api.nvim_win_get_config(win).relative == "" then
+    splits = splits + 1
+  end
+end
+if splits <= 1 then
+  return
+end
+vim.t.gonvim_zoom_restore = vim.fn.winrestcmd()
+vim.t.gonvim_zoom_win = cur
+vim.api.nvim_win_set_height(cur, vim.o.lines)
+vim.api.nvim_win_set_width(cur, vim.o.columns)
+vim.rpcnotify(0, "Gui", "gonvim_zoom", tab, cur)
+`
+
+// zoomAutoCmds forget the zoom when the layout is changed by a new split or
+// by closing the zoomed window, since the saved layout no longer matches
+const zoomAutoCmds = `
+aug GonvimAuZoom | au! | aug END
+if exists("##WinClosed")
+au GonvimAuZoom WinNew * if exists("t:gonvim_zoom_win") && nvim_win_get_config(0).relative ==# "" | unlet t:gonvim_zoom_restore t:gonvim_zoom_win | call rpcnotify(0, "Gui", "gonvim_zoom", nvim_get_current_tabpage(), 0) | endif
+au GonvimAuZoom WinClosed * if get(t:, "gonvim_zoom_win") == str2nr(expand("<amatch>")) | unlet t:gonvim_zoom_restore t:gonvim_zoom_win | call rpcnotify(0, "Gui", "gonvim_zoom", nvim_get_current_tabpage(), 0) | endif
+endif
+`
+
+// toggleZoom toggles the zoom of the current window of the tabpage.
+// tab 0 is the current tabpage.
+func (w *Workspace) toggleZoom(tab int) {
+	go func() {
+		if tab != 0 {
+			err := w.nvim.SetCurrentTabpage(nvim.Tabpage(tab))
+			if err != nil {
+				return
+			}
+		}
+		err := w.nvim.ExecuteLua(zoomLua, nil)
+		if err != nil {
+			editor.pushNotification(NotifyWarn, -1, fmt.Sprintf("[Goneovim] Failed to zoom the window: %s", err))
+		}
+	}()
+}
+
+// updateZoom is called by the gonvim_zoom notification.
+// args: [tabpage, winid of the zoomed window or 0]
+func (t *Tabline) updateZoom(args []interface{}) {
+	if len(args) < 2 {
+		return
+	}
+	tab := util.ReflectToInt(args[0])
+	zoomed := util.ReflectToInt(args[1]) != 0
+	if t.zoomed == nil {
+		t.zoomed = make(map[int]bool)
+	}
+	if zoomed {
+		t.zoomed[tab] = true
+	} else {
+		delete(t.zoomed, tab)
+	}
+	for _, item := range t.Tabs {
+		if item.ID == tab {
+			item.setZoomed(zoomed)
+		}
+	}
+}
+
+func newZoomIcon() *svg.QSvgWidget {
+	icon := svg.NewQSvgWidget(nil)
+	icon.SetFixedWidth(editor.iconSize)
+	icon.SetFixedHeight(editor.iconSize)
+	icon.SetToolTip("Zoomed")
+	icon.Hide()
+
+	return icon
+}
+
+// setZoomed shows the badge in the tab whose window is zoomed
+func (t *Tab) setZoomed(zoomed bool) {
+	if t.zoomed == zoomed {
+		return
+	}
+	t.zoomed = zoomed
+	if zoomed {
+		t.zoomIcon.Show()
+	} else {
+		t.zoomIcon.Hide()
+	}
+	t.updateSize()
+}
+
+// showContextMenu shows the menu of the tab on the right click
+func (t *Tab) showContextMenu(event *gui.QMouseEvent) {
+	menu := widgets.NewQMenu(t.widget)
+	label := "Zoom Window"
+	if t.zoomed {
+		label = "Restore Windows"
+	}
+	id := t.ID
+	menu.AddAction(label).ConnectTriggered(func(bool) {
+		t.t.ws.toggleZoom(id)
+	})
+	menu.AddAction("Close Tab").ConnectTriggered(func(bool) {
+		t.closeIconReleaseEvent(nil)
+	})
+	menu.Popup(t.widget.MapToGlobal(event.Pos()), nil)
+}
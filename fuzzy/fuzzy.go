@@ -46,8 +46,18 @@ type Fuzzy struct {
 	lastMatch          [][]int
 	resultRWMtext      sync.RWMutex
 	running            bool
+	pathMode           bool
 	pwd                string
 	isRemoteAttachment bool
+	index              Index
+}
+
+// Index provides the sources from the index of the workspace, instead of
+// walking the directory on every run
+type Index interface {
+	// Entries returns the entries of the kind, "files" or "symbols", for the
+	// directory, and false if the index doesn't cover it
+	Entries(kind, dir string) ([]string, bool)
 }
 
 // Output is
@@ -58,7 +68,7 @@ type Output struct {
 }
 
 // RegisterPlugin registers this remote plugin
-func RegisterPlugin(nvim *nvim.Nvim, isRemoteAttachment bool) {
+func RegisterPlugin(nvim *nvim.Nvim, isRemoteAttachment bool) *Fuzzy {
 	nvim.Subscribe("GonvimFuzzy")
 	shim := &Fuzzy{
 		nvim:               nvim,
@@ -74,6 +84,14 @@ func RegisterPlugin(nvim *nvim.Nvim, isRemoteAttachment bool) {
 			defer shim.handleMutex.RUnlock()
 		}()
 	})
+
+	return shim
+}
+
+// SetIndex sets the index which the runs without the source take the files
+// from, and the runs with the "index" option take the entries from
+func (s *Fuzzy) SetIndex(index Index) {
+	s.index = index
 }
 
 // UpdateMax updates the max
@@ -110,6 +128,8 @@ func (s *Fuzzy) handle(args ...interface{}) {
 		s.cancel()
 	case "confirm":
 		s.confirm()
+	case "select":
+		s.selectIndex(args[1:])
 	case "resume":
 		s.resume()
 	case "update_max":
@@ -125,6 +145,7 @@ func (s *Fuzzy) run(args []interface{}) {
 		return
 	}
 	s.running = true
+	s.leavePathMode()
 	s.reset()
 	s.processSource()
 	s.outputPattern()
@@ -166,6 +187,11 @@ func (s *Fuzzy) filter() {
 	if s.cancelled {
 		return
 	}
+	if s.isPathMode() {
+		s.filterPath()
+		return
+	}
+	s.leavePathMode()
 	s.scoreNew = true
 	s.scoreMutext.Lock()
 	defer s.scoreMutext.Unlock()
@@ -250,12 +276,7 @@ func (s *Fuzzy) scoreSource(source string) {
 			chars = util.ToChars([]byte(source))
 		}
 
-		// fuzzy match like smart case
-		if strings.ContainsAny(s.pattern, "ABCDEFZHIJKLMNOPQRSTUVWXYZ") {
-			r, n = algo.FuzzyMatchV1(true, true, true, &chars, []rune(s.pattern), true, s.slab)
-		} else {
-			r, n = algo.FuzzyMatchV1(false, true, true, &chars, []rune(s.pattern), true, s.slab)
-		}
+		r, n = smartMatch(&chars, s.pattern, s.slab)
 
 		// Since the file name is excluded from the source string,
 		// the number of characters of the file name is added to the index.
@@ -300,6 +321,29 @@ func (s *Fuzzy) scoreSource(source string) {
 	}
 }
 
+// smartMatch matches the pattern fuzzily in the smart case: the pattern
+// with the upper case letters is matched case sensitively
+func smartMatch(chars *util.Chars, pattern string, slab *util.Slab) (algo.Result, *[]int) {
+	caseSensitive := strings.ContainsAny(pattern, "ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+
+	return algo.FuzzyMatchV1(caseSensitive, true, true, chars, []rune(pattern), true, slab)
+}
+
+// Score returns the score of the fuzzy match of the pattern in the text, as
+// the finder scores the sources, and false if the text doesn't match
+func Score(pattern, text string) (int, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+	chars := util.ToChars([]byte(text))
+	r, _ := smartMatch(&chars, pattern, nil)
+	if r.Score <= 0 {
+		return 0, false
+	}
+
+	return int(r.Score), true
+}
+
 func (s *Fuzzy) processSource() {
 	source := s.options["source"]
 	pwd, ok := s.options["pwd"]
@@ -318,6 +362,9 @@ func (s *Fuzzy) processSource() {
 	}
 	sourceNew := s.sourceNew
 	cancelChan := s.cancelChan
+	if s.processIndex() {
+		return
+	}
 	if source == nil {
 		dir := ""
 		dirInterface, ok := s.options["dir"]
@@ -512,6 +559,51 @@ func (s *Fuzzy) processSource() {
 	}
 }
 
+// processIndex sends the entries of the index as the source, and returns
+// true if the index is used. The files of the working directory of nvim, the
+// "pwd" option, come from the index if it has them, and the run with the
+// "index" option gets no entries without the index.
+func (s *Fuzzy) processIndex() bool {
+	kind, explicit := s.options["index"].(string)
+	if !explicit {
+		if s.options["source"] != nil || s.options["dir"] != nil {
+			return false
+		}
+		kind = "files"
+	}
+	sourceNew := s.sourceNew
+	cancelChan := s.cancelChan
+	var entries []string
+	ok := false
+	if s.index != nil && !s.isRemoteAttachment {
+		dir := s.pwd
+		if dir == "" {
+			dir, _ = os.Getwd()
+		}
+		if dir != "" {
+			entries, ok = s.index.Entries(kind, dir)
+		}
+	}
+	if !ok && !explicit {
+		return false
+	}
+	go func() {
+		defer close(sourceNew)
+		for _, entry := range entries {
+			if s.cancelled {
+				return
+			}
+			select {
+			case sourceNew <- entry:
+			case <-cancelChan:
+				return
+			}
+		}
+	}()
+
+	return true
+}
+
 func (s *Fuzzy) parseOptions(args []interface{}) bool {
 	if len(args) == 0 {
 		return false
@@ -662,7 +754,7 @@ func (s *Fuzzy) outputResult() {
 	s.lastOutput = output
 	s.lastMatch = match
 
-	go s.nvim.Call("rpcnotify", nil, 0, "Gui", "finder_show_result", output, selected-start, match, s.options["type"], start, total)
+	go s.nvim.Call("rpcnotify", nil, 0, "Gui", "finder_show_result", output, selected-start, match, s.resultType(), start, total)
 }
 
 func (s *Fuzzy) right() {
@@ -707,6 +799,15 @@ func (s *Fuzzy) confirm() {
 		return
 	}
 	arg := s.result[s.selected].output
+	if s.pathMode && strings.HasSuffix(arg, "/") {
+		// descend into the directory instead of opening it
+		s.pattern = arg
+		s.cursor = len([]rune(arg))
+		s.selected = 0
+		s.outputPattern()
+		s.filter()
+		return
+	}
 	s.cancel()
 
 	sink, ok := s.options["sink"]
@@ -724,6 +825,15 @@ func (s *Fuzzy) confirm() {
 	}
 }
 
+// selectIndex selects the result of the index in the shown results
+func (s *Fuzzy) selectIndex(args []interface{}) {
+	if len(args) < 1 {
+		return
+	}
+	s.selected = s.start + gonvimUtil.ReflectToInt(args[0])
+	s.processSelected()
+}
+
 func (s *Fuzzy) cancel() {
 	s.running = false
 	s.outputHide()
@@ -0,0 +1,251 @@
+package fuzzy
+
+import (
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/junegunn/fzf/src/algo"
+)
+
+// pathColumnsDepth is the number of the parent directories shown as the
+// columns on the left of the completion of the path
+const pathColumnsDepth = 2
+
+// pathColumn is the entries of a directory in the column browser, and the
+// index of the entry which the typed path goes through
+type pathColumn struct {
+	name     string
+	entries  []string
+	selected int
+}
+
+// isPathPattern reports whether the pattern of the file finder is a path
+// typed directly, which is completed from its directory instead of the
+// fuzzy match of the whole project
+func isPathPattern(pattern string) bool {
+	if pattern == "~" || strings.HasPrefix(pattern, "~/") {
+		return true
+	}
+	if strings.HasPrefix(pattern, "./") || strings.HasPrefix(pattern, "../") {
+		return true
+	}
+	if strings.HasPrefix(pattern, "/") {
+		return true
+	}
+
+	return filepath.IsAbs(pattern)
+}
+
+// splitPathPattern splits the pattern into the directory, which ends with
+// the separator, and the base name being typed
+func splitPathPattern(pattern string) (string, string) {
+	if pattern == "~" {
+		return "~/", ""
+	}
+	i := strings.LastIndexAny(pattern, "/"+string(os.PathSeparator))
+
+	return pattern[:i+1], pattern[i+1:]
+}
+
+func hasGlobMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// matchPathEntry reports whether the name of the entry is completed by the
+// base name, which is the prefix, or the glob of the name. The hidden
+// entries are matched only by the base name starting with a dot.
+func matchPathEntry(base, name string) bool {
+	if strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+		return false
+	}
+	if hasGlobMeta(base) {
+		ok, _ := filepath.Match(base, name)
+		return ok
+	}
+	// like smart case
+	if !strings.ContainsAny(base, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") {
+		name = strings.ToLower(name)
+	}
+
+	return strings.HasPrefix(name, base)
+}
+
+// expandPath expands the home directory of the typed path
+func expandPath(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	usr, err := user.Current()
+	if err != nil {
+		return path
+	}
+
+	return usr.HomeDir + path[1:]
+}
+
+// globDirs returns the directories the typed directory matches, in the form
+// they are typed in. The wildcards in the directory are expanded.
+func globDirs(dir string) []string {
+	if !hasGlobMeta(dir) {
+		return []string{dir}
+	}
+	expanded := expandPath(dir)
+	matches, _ := filepath.Glob(strings.TrimRight(expanded, "/"+string(os.PathSeparator)))
+	home := expandPath("~")
+	dirs := []string{}
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		if strings.HasPrefix(dir, "~") && strings.HasPrefix(match, home) {
+			match = "~" + match[len(home):]
+		}
+		dirs = append(dirs, match+"/")
+	}
+
+	return dirs
+}
+
+// completePath returns the entries completing the typed path. The
+// directories end with the separator and come first.
+func completePath(pattern string) []string {
+	dir, base := splitPathPattern(pattern)
+	var dirs, files []string
+	for _, d := range globDirs(dir) {
+		entries, err := ioutil.ReadDir(expandPath(d))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !matchPathEntry(base, name) {
+				continue
+			}
+			isDir := entry.IsDir()
+			if entry.Mode()&os.ModeSymlink != 0 {
+				info, err := os.Stat(filepath.Join(expandPath(d), name))
+				isDir = err == nil && info.IsDir()
+			}
+			if isDir {
+				dirs = append(dirs, d+name+"/")
+			} else {
+				files = append(files, d+name)
+			}
+		}
+	}
+	sort.Strings(dirs)
+	sort.Strings(files)
+
+	return append(dirs, files...)
+}
+
+// pathColumns returns the columns of the parent directories of the typed
+// directory, from the outermost, for the column browser
+func pathColumns(dir string) []pathColumn {
+	if dir == "" || hasGlobMeta(dir) {
+		return nil
+	}
+	current, err := filepath.Abs(expandPath(dir))
+	if err != nil {
+		return nil
+	}
+	columns := []pathColumn{}
+	for i := 0; i < pathColumnsDepth; i++ {
+		parent := filepath.Dir(current)
+		if parent == current {
+			break
+		}
+		entries, err := ioutil.ReadDir(parent)
+		if err != nil {
+			break
+		}
+		column := pathColumn{
+			name:     filepath.Base(parent),
+			selected: -1,
+		}
+		name := filepath.Base(current)
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			if strings.HasPrefix(entry.Name(), ".") && entry.Name() != name {
+				continue
+			}
+			if entry.Name() == name {
+				column.selected = len(column.entries)
+			}
+			column.entries = append(column.entries, entry.Name())
+		}
+		columns = append([]pathColumn{column}, columns...)
+		current = parent
+	}
+
+	return columns
+}
+
+// isPathMode reports whether the file finder completes the typed path
+func (s *Fuzzy) isPathMode() bool {
+	return s.options["type"] == "file" && !s.isRemoteAttachment && isPathPattern(s.pattern)
+}
+
+// resultType returns the type of the result for the GUI
+func (s *Fuzzy) resultType() interface{} {
+	if s.pathMode {
+		return "path"
+	}
+
+	return s.options["type"]
+}
+
+// filterPath sets the entries completing the typed path to the result
+func (s *Fuzzy) filterPath() {
+	s.pathMode = true
+	dir, base := splitPathPattern(s.pattern)
+	result := []*Output{}
+	for _, path := range completePath(s.pattern) {
+		match := &[]int{}
+		if !hasGlobMeta(s.pattern) {
+			for i := range base {
+				*match = append(*match, len(dir)+i)
+			}
+		}
+		result = append(result, &Output{
+			result: algo.Result{Score: -1},
+			output: path,
+			match:  match,
+		})
+	}
+	s.resultRWMtext.Lock()
+	s.result = result
+	s.resultRWMtext.Unlock()
+	s.outputResult()
+	s.outputPathColumns(pathColumns(dir))
+}
+
+// leavePathMode hides the column browser when the pattern is no longer a
+// path
+func (s *Fuzzy) leavePathMode() {
+	if !s.pathMode {
+		return
+	}
+	s.pathMode = false
+	s.lastOutput = []string{}
+	s.outputPathColumns(nil)
+}
+
+func (s *Fuzzy) outputPathColumns(columns []pathColumn) {
+	output := []interface{}{}
+	for _, column := range columns {
+		output = append(output, map[string]interface{}{
+			"name":     column.name,
+			"entries":  column.entries,
+			"selected": column.selected,
+		})
+	}
+	go s.nvim.Call("rpcnotify", nil, 0, "Gui", "finder_path_columns", output)
+}
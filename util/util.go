@@ -4,7 +4,24 @@ package util
 
 import (
 	"os/exec"
+	"syscall"
 )
 
 func PrepareRunProc(cmd *exec.Cmd) {
 }
+
+// PrepareProcGroup starts the command in its own process group, so that
+// KillProcGroup kills the children of the shell too
+func PrepareProcGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// KillProcGroup kills the process group of the command started with
+// PrepareProcGroup
+func KillProcGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
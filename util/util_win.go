@@ -4,9 +4,26 @@ package util
 
 import (
 	"os/exec"
+	"strconv"
 	"syscall"
 )
 
 func PrepareRunProc(cmd *exec.Cmd) {
 	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
 }
+
+// PrepareProcGroup prepares the command whose tree KillProcGroup kills
+func PrepareProcGroup(cmd *exec.Cmd) {
+	PrepareRunProc(cmd)
+}
+
+// KillProcGroup kills the command and its children with taskkill /T
+func KillProcGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	kill := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid))
+	PrepareRunProc(kill)
+
+	return kill.Run()
+}